@@ -0,0 +1,325 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/state"
+	"sync"
+)
+
+var (
+	ErrUnknownBlock       = errors.New("unknown block")
+	ErrUnknownTransaction = errors.New("unknown transaction")
+	ErrInvalidBlock       = errors.New("invalid block")
+	// ErrFinalizedReorg is returned for any attempt to replace a finalized block.
+	ErrFinalizedReorg = errors.New("reorg below finalized block")
+)
+
+// BlockValidator holds the consensus specific rules (who may propose, which
+// attestations are required) that the chain cannot know about on its own.
+type BlockValidator interface {
+	ValidateBlock(parent block.Header, blk block.Block) error
+}
+
+type Chain struct {
+	lock      sync.RWMutex
+	executor  *state.Executor
+	validator BlockValidator
+	genesis   block.Block
+	// base is the height of blocks[0]: zero for chains replayed from
+	// genesis, the snapshot height for chains restored from a snapshot.
+	base      uint64
+	baseState *state.StateDB
+	state     *state.StateDB
+	blocks    []block.Block
+	ids       map[string]uint64
+	txs       map[string]TxLocation
+	finalized uint64
+}
+
+// TxLocation is where an included transaction sits in the chain.
+type TxLocation struct {
+	Height  uint64
+	BlockID hash.Hash
+	Index   int
+}
+
+func NewChain(executor *state.Executor, genesis *Genesis, validator BlockValidator) (*Chain, error) {
+	genesisBlock, genesisState, err := genesis.ToBlock(executor.HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+
+	genesisID, err := genesisBlock.Hash(executor.HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Chain{
+		executor:  executor,
+		validator: validator,
+		genesis:   genesisBlock,
+		baseState: genesisState.Copy(),
+		state:     genesisState,
+		blocks:    []block.Block{genesisBlock},
+		ids:       map[string]uint64{string(genesisID.Bytes()): 0},
+		txs:       make(map[string]TxLocation),
+	}, nil
+}
+
+// NewChainFromSnapshot starts a chain at base instead of genesis, with st as
+// the state after base. st must match the state root of base, which the
+// caller is trusted to have verified; base is final and nothing below it is
+// kept.
+func NewChainFromSnapshot(executor *state.Executor, genesis *Genesis, validator BlockValidator, base block.Block, st *state.StateDB) (*Chain, error) {
+	deriver := executor.HashDeriver()
+
+	genesisBlock, _, err := genesis.ToBlock(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	if base == nil || base.GetHeader() == nil {
+		return nil, fmt.Errorf("%w: incomplete base block", ErrInvalidBlock)
+	}
+
+	stateRoot, err := st.Root(deriver)
+	if err != nil {
+		return nil, err
+	}
+	if !stateRoot.Equal(base.GetHeader().GetStateRoot()) {
+		return nil, fmt.Errorf("%w: snapshot state root mismatch", ErrInvalidBlock)
+	}
+
+	baseID, err := base.Hash(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	height := base.GetHeader().GetHeight()
+	c := &Chain{
+		executor:  executor,
+		validator: validator,
+		genesis:   genesisBlock,
+		base:      height,
+		baseState: st.Copy(),
+		state:     st.Copy(),
+		blocks:    []block.Block{base},
+		ids:       map[string]uint64{string(baseID.Bytes()): height},
+		txs:       make(map[string]TxLocation),
+		finalized: height,
+	}
+	c.indexTransactions(base, baseID, true)
+	return c, nil
+}
+
+func (c *Chain) Executor() *state.Executor {
+	return c.executor
+}
+
+func (c *Chain) Head() block.Block {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *Chain) Height() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.base + uint64(len(c.blocks)-1)
+}
+
+// Base is the lowest height the chain has a block for.
+func (c *Chain) Base() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.base
+}
+
+func (c *Chain) Genesis() block.Block {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.genesis
+}
+
+func (c *Chain) GetBlockByHeight(height uint64) (block.Block, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if height < c.base || height-c.base >= uint64(len(c.blocks)) {
+		return nil, fmt.Errorf("%w: height %d", ErrUnknownBlock, height)
+	}
+	return c.blocks[height-c.base], nil
+}
+
+func (c *Chain) GetBlockByID(id hash.Hash) (block.Block, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	height, ok := c.ids[string(id.Bytes())]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBlock, id.ShortString(8))
+	}
+	return c.blocks[height-c.base], nil
+}
+
+// GetTransactionByHash finds an included transaction and where it was
+// included.
+func (c *Chain) GetTransactionByHash(id hash.Hash) (transaction.Transaction, TxLocation, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	loc, ok := c.txs[string(id.Bytes())]
+	if !ok {
+		return nil, TxLocation{}, fmt.Errorf("%w: %s", ErrUnknownTransaction, id.ShortString(8))
+	}
+	return c.blocks[loc.Height-c.base].GetBody().GetTransactions()[loc.Index], loc, nil
+}
+
+func (c *Chain) HasBlock(id hash.Hash) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	_, ok := c.ids[string(id.Bytes())]
+	return ok
+}
+
+// State returns a copy of the state at the head, safe to execute against.
+func (c *Chain) State() *state.StateDB {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.state.Copy()
+}
+
+// StateAt returns a copy of the state after the canonical block at height.
+// States below the head are rebuilt by replaying the chain from its base.
+func (c *Chain) StateAt(height uint64) (*state.StateDB, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if height < c.base || height-c.base >= uint64(len(c.blocks)) {
+		return nil, fmt.Errorf("%w: height %d", ErrUnknownBlock, height)
+	}
+	return c.stateAt(height)
+}
+
+// AddBlock validates the block on top of the current head, executes it and
+// makes it the new head.
+func (c *Chain) AddBlock(blk block.Block) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	id, next, err := c.validate(blk, true)
+	if err != nil {
+		return err
+	}
+
+	c.blocks = append(c.blocks, blk)
+	c.ids[string(id.Bytes())] = blk.GetHeader().GetHeight()
+	c.indexTransactions(blk, id, true)
+	c.state = next
+
+	return nil
+}
+
+// indexTransactions adds or removes the transactions of blk to the lookup
+// index. The caller holds the write lock.
+func (c *Chain) indexTransactions(blk block.Block, id hash.Hash, add bool) {
+	if blk.GetBody() == nil {
+		return
+	}
+
+	for i, tx := range blk.GetBody().GetTransactions() {
+		txID, err := tx.Hash(c.executor.HashDeriver())
+		if err != nil {
+			continue
+		}
+		if add {
+			c.txs[string(txID.Bytes())] = TxLocation{Height: blk.GetHeader().GetHeight(), BlockID: id, Index: i}
+		} else {
+			delete(c.txs, string(txID.Bytes()))
+		}
+	}
+}
+
+// ValidateProposal runs every check of AddBlock except the BlockValidator, for
+// candidate blocks that do not carry their consensus attestations yet.
+func (c *Chain) ValidateProposal(blk block.Block) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	_, _, err := c.validate(blk, false)
+	return err
+}
+
+func (c *Chain) validate(blk block.Block, withConsensus bool) (hash.Hash, *state.StateDB, error) {
+	return c.validateOn(c.blocks[len(c.blocks)-1].GetHeader(), c.state, blk, withConsensus)
+}
+
+// validateOn checks blk on top of parent, whose post-state is st. st itself is
+// left untouched.
+func (c *Chain) validateOn(parent block.Header, st *state.StateDB, blk block.Block, withConsensus bool) (hash.Hash, *state.StateDB, error) {
+	deriver := c.executor.HashDeriver()
+
+	if blk == nil || blk.GetHeader() == nil || blk.GetBody() == nil {
+		return nil, nil, fmt.Errorf("%w: incomplete block", ErrInvalidBlock)
+	}
+
+	header := blk.GetHeader()
+
+	if header.GetHeight() != parent.GetHeight()+1 {
+		return nil, nil, fmt.Errorf("%w: expected height %d, got %d",
+			ErrInvalidBlock, parent.GetHeight()+1, header.GetHeight())
+	}
+
+	parentID, err := parent.Hash(deriver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !parentID.Equal(header.GetPrevBlockID()) {
+		return nil, nil, fmt.Errorf("%w: block does not extend the head", ErrInvalidBlock)
+	}
+
+	if header.GetTimestamp() <= parent.GetTimestamp() {
+		return nil, nil, fmt.Errorf("%w: timestamp is not after parent", ErrInvalidBlock)
+	}
+
+	bodyHash, err := blk.GetBody().Hash(deriver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !bodyHash.Equal(header.GetBodyHash()) {
+		return nil, nil, fmt.Errorf("%w: body hash mismatch", ErrInvalidBlock)
+	}
+
+	if withConsensus && c.validator != nil {
+		if err = c.validator.ValidateBlock(parent, blk); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidBlock, err)
+		}
+	}
+
+	next := st.Copy()
+	if err = c.executor.ApplyBody(next, blk.GetBody()); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidBlock, err)
+	}
+
+	stateRoot, err := next.Root(deriver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !stateRoot.Equal(header.GetStateRoot()) {
+		return nil, nil, fmt.Errorf("%w: state root mismatch", ErrInvalidBlock)
+	}
+
+	id, err := blk.Hash(deriver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return id, next, nil
+}
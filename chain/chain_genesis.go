@@ -0,0 +1,49 @@
+package chain
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangaroobody"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	"github.com/andantan/kangaroo/core/block/kangarootail"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/state"
+	"math/big"
+)
+
+type GenesisAccount struct {
+	Address hash.Address
+	Balance *big.Int
+}
+
+type Genesis struct {
+	Timestamp int64
+	Alloc     []GenesisAccount
+}
+
+// ToBlock builds the height 0 block and the state it commits to. The genesis
+// block has no parent, no proposer and no attestations.
+func (g *Genesis) ToBlock(deriver hash.HashDeriver) (block.Block, *state.StateDB, error) {
+	st := state.NewStateDB()
+	for _, acc := range g.Alloc {
+		if err := st.AddBalance(acc.Address, acc.Balance); err != nil {
+			return nil, nil, fmt.Errorf("invalid genesis allocation: %w", err)
+		}
+	}
+
+	stateRoot, err := st.Root(deriver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body := kangaroobody.NewKangarooBody(nil)
+	bodyHash, err := body.Hash(deriver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := kangarooheader.NewKangarooHeader(0, g.Timestamp, nil, bodyHash, stateRoot, nil)
+
+	return kangarooblock.NewKangarooBlock(header, body, kangarootail.NewKangarooTail(nil)), st, nil
+}
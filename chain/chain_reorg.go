@@ -0,0 +1,172 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/state"
+	"math/big"
+)
+
+// ErrLighterBranch is returned by AddBranch for branches that do not carry
+// more work than the blocks they would replace.
+var ErrLighterBranch = errors.New("branch does not carry more work")
+
+// WorkCounter is implemented by block validators whose blocks are not all
+// worth the same, e.g. proof-of-work. Without it every block counts as one
+// unit of work and the fork choice is the longest chain.
+type WorkCounter interface {
+	BlockWork(header block.Header) *big.Int
+}
+
+// Reorg replaces every block above branch[0]'s parent with branch. The parent
+// must be on the current chain and not below the finalized block. Reorg does
+// not weigh the branch against the current chain, see AddBranch for that; it
+// only makes sure every block is valid before switching. The replaced blocks
+// are returned so their transactions can go back to the mempool.
+func (c *Chain) Reorg(branch []block.Block) ([]block.Block, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.reorg(branch, false)
+}
+
+// AddBranch is the fork choice: it switches to branch only if branch carries
+// more work than the blocks above its fork point. A branch on top of the head
+// replaces nothing and is simply imported. The replaced blocks are returned
+// like Reorg does.
+func (c *Chain) AddBranch(branch []block.Block) ([]block.Block, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.reorg(branch, true)
+}
+
+// reorg does the work of Reorg and AddBranch. The caller holds the write lock.
+func (c *Chain) reorg(branch []block.Block, mostWork bool) ([]block.Block, error) {
+	if len(branch) == 0 || branch[0] == nil || branch[0].GetHeader() == nil {
+		return nil, fmt.Errorf("%w: empty branch", ErrInvalidBlock)
+	}
+
+	first := branch[0].GetHeader()
+	if first.GetHeight() == 0 {
+		return nil, fmt.Errorf("%w: cannot replace the genesis block", ErrFinalizedReorg)
+	}
+
+	ancestor, ok := c.ids[string(first.GetPrevBlockID().Bytes())]
+	if !ok || ancestor != first.GetHeight()-1 {
+		return nil, fmt.Errorf("%w: branch does not fork from the chain", ErrUnknownBlock)
+	}
+
+	if ancestor < c.finalized {
+		return nil, fmt.Errorf("%w: fork at height %d, finalized height %d",
+			ErrFinalizedReorg, ancestor, c.finalized)
+	}
+
+	if mostWork {
+		replaced := c.work(c.blocks[ancestor-c.base+1:])
+		if work := c.work(branch); work.Cmp(replaced) <= 0 {
+			return nil, fmt.Errorf("%w: %s against %s", ErrLighterBranch, work, replaced)
+		}
+	}
+
+	st, err := c.stateAt(ancestor)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]hash.Hash, len(branch))
+	parent := c.blocks[ancestor-c.base].GetHeader()
+	for i, blk := range branch {
+		if ids[i], st, err = c.validateOn(parent, st, blk, true); err != nil {
+			return nil, err
+		}
+		parent = blk.GetHeader()
+	}
+
+	removed := append([]block.Block(nil), c.blocks[ancestor-c.base+1:]...)
+	for _, blk := range removed {
+		id, err := blk.Hash(c.executor.HashDeriver())
+		if err != nil {
+			return nil, err
+		}
+		delete(c.ids, string(id.Bytes()))
+		c.indexTransactions(blk, id, false)
+	}
+
+	c.blocks = append(c.blocks[:ancestor-c.base+1], branch...)
+	for i, blk := range branch {
+		c.ids[string(ids[i].Bytes())] = blk.GetHeader().GetHeight()
+		c.indexTransactions(blk, ids[i], true)
+	}
+	c.state = st
+
+	return removed, nil
+}
+
+// Finalize marks the block at height with the given id as final. Finality
+// only moves forward, and nothing at or below the finalized height can be
+// reorganized away afterwards.
+func (c *Chain) Finalize(height uint64, id hash.Hash) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	got, ok := c.ids[string(id.Bytes())]
+	if !ok || got != height {
+		return fmt.Errorf("%w: %s at height %d", ErrUnknownBlock, id.ShortString(8), height)
+	}
+
+	if height < c.finalized {
+		return fmt.Errorf("%w: already finalized height %d", ErrFinalizedReorg, c.finalized)
+	}
+
+	c.finalized = height
+	return nil
+}
+
+// Finalized returns the height and id of the last finalized block. The
+// genesis block, or the snapshot base, is final from the start.
+func (c *Chain) Finalized() (uint64, hash.Hash, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	id, err := c.blocks[c.finalized-c.base].Hash(c.executor.HashDeriver())
+	if err != nil {
+		return 0, nil, err
+	}
+	return c.finalized, id, nil
+}
+
+// work sums the work of blocks as the validator counts it.
+func (c *Chain) work(blocks []block.Block) *big.Int {
+	counter, ok := c.validator.(WorkCounter)
+
+	total := new(big.Int)
+	for _, blk := range blocks {
+		if !ok {
+			total.Add(total, big.NewInt(1))
+			continue
+		}
+		if w := counter.BlockWork(blk.GetHeader()); w != nil {
+			total.Add(total, w)
+		}
+	}
+	return total
+}
+
+// stateAt rebuilds the state after the block at height by replaying the chain
+// from its base.
+func (c *Chain) stateAt(height uint64) (*state.StateDB, error) {
+	if height == c.base+uint64(len(c.blocks)-1) {
+		return c.state.Copy(), nil
+	}
+
+	st := c.baseState.Copy()
+	for _, blk := range c.blocks[1 : height-c.base+1] {
+		if err := c.executor.ApplyBody(st, blk.GetBody()); err != nil {
+			return nil, fmt.Errorf("failed to replay block %d: %w", blk.GetHeader().GetHeight(), err)
+		}
+	}
+	return st, nil
+}
@@ -0,0 +1,372 @@
+package chain
+
+import (
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangaroobody"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	"github.com/andantan/kangaroo/core/block/kangarootail"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func newTestChain(t *testing.T) (*Chain, key.PrivateKey) {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	privKey, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	executor := state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver())
+	genesis := &Genesis{
+		Timestamp: 1,
+		Alloc: []GenesisAccount{
+			{Address: privKey.PublicKey().Address(addressSuite.Deriver()), Balance: big.NewInt(1000)},
+		},
+	}
+
+	c, err := NewChain(executor, genesis, nil)
+	require.NoError(t, err)
+	return c, privKey
+}
+
+func buildBlock(t *testing.T, c *Chain, txs []transaction.Transaction, mutate func(h *kangarooheader.KangarooHeader)) block.Block {
+	deriver := c.Executor().HashDeriver()
+	parent := c.Head().GetHeader()
+
+	st := c.State()
+	for _, tx := range txs {
+		require.NoError(t, c.Executor().ApplyTransaction(st, tx))
+	}
+	stateRoot, err := st.Root(deriver)
+	require.NoError(t, err)
+
+	body := kangaroobody.NewKangarooBody(txs)
+	bodyHash, err := body.Hash(deriver)
+	require.NoError(t, err)
+
+	parentID, err := parent.Hash(deriver)
+	require.NoError(t, err)
+
+	header := kangarooheader.NewKangarooHeader(parent.GetHeight()+1, parent.GetTimestamp()+1, parentID, bodyHash, stateRoot, nil)
+	if mutate != nil {
+		mutate(header)
+	}
+
+	return kangarooblock.NewKangarooBlock(header, body, kangarootail.NewKangarooTail(nil))
+}
+
+func TestChain_AddBlock(t *testing.T) {
+	c, privKey := newTestChain(t)
+	deriver := c.Executor().HashDeriver()
+	sender := privKey.PublicKey().Address(c.Executor().AddressDeriver())
+	recipient := c.Executor().AddressDeriver().Derive([]byte("recipient"))
+
+	assert.Equal(t, uint64(0), c.Height())
+	assert.Equal(t, big.NewInt(1000), c.State().GetBalance(sender))
+
+	tx := kangarootransaction.NewKangarooTransaction(recipient, big.NewInt(250), nil, 0)
+	require.NoError(t, tx.Sign(privKey, deriver))
+
+	blk := buildBlock(t, c, []transaction.Transaction{tx}, nil)
+	require.NoError(t, c.AddBlock(blk))
+
+	assert.Equal(t, uint64(1), c.Height())
+	assert.Equal(t, big.NewInt(750), c.State().GetBalance(sender))
+	assert.Equal(t, big.NewInt(250), c.State().GetBalance(recipient))
+
+	id, err := blk.Hash(deriver)
+	require.NoError(t, err)
+	byID, err := c.GetBlockByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, blk, byID)
+
+	byHeight, err := c.GetBlockByHeight(1)
+	require.NoError(t, err)
+	assert.Equal(t, blk, byHeight)
+
+	_, err = c.GetBlockByHeight(2)
+	assert.ErrorIs(t, err, ErrUnknownBlock)
+
+	txID, err := tx.Hash(deriver)
+	require.NoError(t, err)
+	included, loc, err := c.GetTransactionByHash(txID)
+	require.NoError(t, err)
+	assert.Equal(t, tx, included)
+	assert.Equal(t, TxLocation{Height: 1, BlockID: id, Index: 0}, loc)
+
+	t.Run("should accept empty blocks", func(t *testing.T) {
+		require.NoError(t, c.AddBlock(buildBlock(t, c, nil, nil)))
+		assert.Equal(t, uint64(2), c.Height())
+	})
+
+	t.Run("should rebuild past states", func(t *testing.T) {
+		genesisState, err := c.StateAt(0)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(1000), genesisState.GetBalance(sender))
+
+		st, err := c.StateAt(1)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(250), st.GetBalance(recipient))
+
+		_, err = c.StateAt(3)
+		assert.ErrorIs(t, err, ErrUnknownBlock)
+	})
+}
+
+func TestChain_AddBlock_Failures(t *testing.T) {
+	c, _ := newTestChain(t)
+	deriver := c.Executor().HashDeriver()
+
+	cases := map[string]func(h *kangarooheader.KangarooHeader){
+		"wrong height":     func(h *kangarooheader.KangarooHeader) { h.Height = 5 },
+		"wrong parent":     func(h *kangarooheader.KangarooHeader) { h.PrevBlockID = deriver.Derive([]byte("x")) },
+		"stale timestamp":  func(h *kangarooheader.KangarooHeader) { h.Timestamp = 0 },
+		"wrong body hash":  func(h *kangarooheader.KangarooHeader) { h.BodyHash = deriver.Derive([]byte("x")) },
+		"wrong state root": func(h *kangarooheader.KangarooHeader) { h.StateRoot = deriver.Derive([]byte("x")) },
+	}
+
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := c.AddBlock(buildBlock(t, c, nil, mutate))
+			assert.ErrorIs(t, err, ErrInvalidBlock)
+			assert.Equal(t, uint64(0), c.Height())
+		})
+	}
+}
+
+// forkAt returns an independent copy of c truncated to height, to build a
+// competing branch on.
+func forkAt(t *testing.T, c *Chain, height uint64) *Chain {
+	st, err := c.stateAt(height)
+	require.NoError(t, err)
+
+	fork := &Chain{
+		executor:  c.executor,
+		genesis:   c.genesis,
+		base:      c.base,
+		baseState: c.baseState.Copy(),
+		state:     st,
+		blocks:    append([]block.Block(nil), c.blocks[:height-c.base+1]...),
+		ids:       make(map[string]uint64),
+		txs:       make(map[string]TxLocation),
+	}
+	for id, h := range c.ids {
+		if h <= height {
+			fork.ids[id] = h
+		}
+	}
+	return fork
+}
+
+func TestChain_Reorg(t *testing.T) {
+	c, privKey := newTestChain(t)
+	deriver := c.Executor().HashDeriver()
+	sender := privKey.PublicKey().Address(c.Executor().AddressDeriver())
+	recipient := c.Executor().AddressDeriver().Derive([]byte("recipient"))
+
+	require.NoError(t, c.AddBlock(buildBlock(t, c, nil, nil)))
+
+	tx := kangarootransaction.NewKangarooTransaction(recipient, big.NewInt(100), nil, 0)
+	require.NoError(t, tx.Sign(privKey, deriver))
+	require.NoError(t, c.AddBlock(buildBlock(t, c, []transaction.Transaction{tx}, nil)))
+	require.NoError(t, c.AddBlock(buildBlock(t, c, nil, nil)))
+
+	// competing branch from height 1 without the transfer
+	fork := forkAt(t, c, 1)
+	var branch []block.Block
+	for i := 0; i < 3; i++ {
+		blk := buildBlock(t, fork, nil, func(h *kangarooheader.KangarooHeader) { h.Timestamp += 100 })
+		require.NoError(t, fork.AddBlock(blk))
+		branch = append(branch, blk)
+	}
+
+	removed, err := c.Reorg(branch)
+	require.NoError(t, err)
+	assert.Len(t, removed, 2)
+	assert.Equal(t, uint64(4), c.Height())
+	assert.Equal(t, big.NewInt(1000), c.State().GetBalance(sender))
+	assert.Equal(t, 0, c.State().GetBalance(recipient).Sign())
+
+	removedID, err := removed[0].Hash(deriver)
+	require.NoError(t, err)
+	assert.False(t, c.HasBlock(removedID))
+
+	txID, err := tx.Hash(deriver)
+	require.NoError(t, err)
+	_, _, err = c.GetTransactionByHash(txID)
+	assert.ErrorIs(t, err, ErrUnknownTransaction, "transactions of replaced blocks are no longer included")
+
+	headID, err := branch[2].Hash(deriver)
+	require.NoError(t, err)
+	assert.True(t, c.HasBlock(headID))
+
+	t.Run("invalid branch leaves the chain untouched", func(t *testing.T) {
+		fork := forkAt(t, c, 2)
+		bad := buildBlock(t, fork, nil, func(h *kangarooheader.KangarooHeader) {
+			h.Timestamp += 100
+			h.StateRoot = deriver.Derive([]byte("x"))
+		})
+		_, err := c.Reorg([]block.Block{bad})
+		assert.ErrorIs(t, err, ErrInvalidBlock)
+		assert.Equal(t, uint64(4), c.Height())
+	})
+}
+
+func TestChain_AddBranch(t *testing.T) {
+	c, _ := newTestChain(t)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c.AddBlock(buildBlock(t, c, nil, nil)))
+	}
+
+	// two blocks from height 1 weigh as much as the two they would replace
+	fork := forkAt(t, c, 1)
+	var branch []block.Block
+	for i := 0; i < 3; i++ {
+		blk := buildBlock(t, fork, nil, func(h *kangarooheader.KangarooHeader) { h.Timestamp += 100 })
+		require.NoError(t, fork.AddBlock(blk))
+		branch = append(branch, blk)
+	}
+
+	_, err := c.AddBranch(branch[:2])
+	assert.ErrorIs(t, err, ErrLighterBranch)
+	assert.Equal(t, uint64(3), c.Height())
+
+	removed, err := c.AddBranch(branch)
+	require.NoError(t, err)
+	assert.Len(t, removed, 2)
+	assert.Equal(t, uint64(4), c.Height())
+
+	t.Run("extends the head", func(t *testing.T) {
+		removed, err := c.AddBranch([]block.Block{buildBlock(t, c, nil, nil)})
+		require.NoError(t, err)
+		assert.Empty(t, removed)
+		assert.Equal(t, uint64(5), c.Height())
+	})
+}
+
+func TestChain_Finality(t *testing.T) {
+	c, _ := newTestChain(t)
+	deriver := c.Executor().HashDeriver()
+
+	height, id, err := c.Finalized()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), height)
+	assert.True(t, c.HasBlock(id))
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, c.AddBlock(buildBlock(t, c, nil, nil)))
+	}
+
+	second, err := c.GetBlockByHeight(2)
+	require.NoError(t, err)
+	secondID, err := second.Hash(deriver)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, c.Finalize(3, secondID), ErrUnknownBlock)
+	require.NoError(t, c.Finalize(2, secondID))
+
+	height, id, err = c.Finalized()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), height)
+	assert.True(t, secondID.Equal(id))
+
+	genesisID, err := c.Genesis().Hash(deriver)
+	require.NoError(t, err)
+	assert.ErrorIs(t, c.Finalize(0, genesisID), ErrFinalizedReorg)
+
+	t.Run("reorg below finalized block", func(t *testing.T) {
+		fork := forkAt(t, c, 1)
+		blk := buildBlock(t, fork, nil, func(h *kangarooheader.KangarooHeader) { h.Timestamp += 100 })
+		_, err := c.Reorg([]block.Block{blk})
+		assert.ErrorIs(t, err, ErrFinalizedReorg)
+		assert.Equal(t, uint64(4), c.Height())
+	})
+
+	t.Run("reorg above finalized block", func(t *testing.T) {
+		fork := forkAt(t, c, 2)
+		blk := buildBlock(t, fork, nil, func(h *kangarooheader.KangarooHeader) { h.Timestamp += 100 })
+		_, err := c.Reorg([]block.Block{blk})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), c.Height())
+	})
+}
+
+func TestChain_FromSnapshot(t *testing.T) {
+	c, privKey := newTestChain(t)
+	deriver := c.Executor().HashDeriver()
+	recipient := c.Executor().AddressDeriver().Derive([]byte("recipient"))
+
+	tx := kangarootransaction.NewKangarooTransaction(recipient, big.NewInt(100), nil, 0)
+	require.NoError(t, tx.Sign(privKey, deriver))
+	require.NoError(t, c.AddBlock(buildBlock(t, c, []transaction.Transaction{tx}, nil)))
+	require.NoError(t, c.AddBlock(buildBlock(t, c, nil, nil)))
+
+	genesis := &Genesis{
+		Timestamp: 1,
+		Alloc: []GenesisAccount{
+			{Address: privKey.PublicKey().Address(c.Executor().AddressDeriver()), Balance: big.NewInt(1000)},
+		},
+	}
+
+	restored, err := NewChainFromSnapshot(c.Executor(), genesis, nil, c.Head(), c.State())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), restored.Height())
+	assert.Equal(t, uint64(2), restored.Base())
+	assert.Equal(t, big.NewInt(100), restored.State().GetBalance(recipient))
+
+	genesisID, err := c.Genesis().Hash(deriver)
+	require.NoError(t, err)
+	restoredGenesisID, err := restored.Genesis().Hash(deriver)
+	require.NoError(t, err)
+	assert.True(t, genesisID.Equal(restoredGenesisID))
+
+	_, err = restored.GetBlockByHeight(1)
+	assert.ErrorIs(t, err, ErrUnknownBlock)
+
+	height, _, err := restored.Finalized()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), height)
+
+	t.Run("extends like a chain replayed from genesis", func(t *testing.T) {
+		next := buildBlock(t, c, nil, nil)
+		require.NoError(t, c.AddBlock(next))
+		require.NoError(t, restored.AddBlock(next))
+		assert.Equal(t, uint64(3), restored.Height())
+
+		got, err := restored.GetBlockByHeight(3)
+		require.NoError(t, err)
+		assert.Equal(t, next, got)
+	})
+
+	t.Run("reorgs fork at or above the base", func(t *testing.T) {
+		fork := forkAt(t, restored, 2)
+		blk := buildBlock(t, fork, nil, func(h *kangarooheader.KangarooHeader) { h.Timestamp += 100 })
+		removed, err := restored.Reorg([]block.Block{blk})
+		require.NoError(t, err)
+		assert.Len(t, removed, 1)
+
+		belowBase, err := c.GetBlockByHeight(2)
+		require.NoError(t, err)
+		_, err = restored.Reorg([]block.Block{belowBase})
+		assert.ErrorIs(t, err, ErrUnknownBlock)
+	})
+
+	t.Run("state must match the base", func(t *testing.T) {
+		_, err := NewChainFromSnapshot(c.Executor(), genesis, nil, c.Head(), state.NewStateDB())
+		assert.ErrorIs(t, err, ErrInvalidBlock)
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/inspect"
+	"os"
+	"strings"
+)
+
+// runInspect prints every reading of each 0x blob given as an argument, or
+// of each line of stdin without arguments.
+func runInspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: kangaroo inspect [0x...]...\n\nBlobs are read from stdin, one per line, without arguments.\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	blobs := fs.Args()
+	if len(blobs) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(nil, 64<<20)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				blobs = append(blobs, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	unknown := 0
+	for i, blob := range blobs {
+		if i > 0 {
+			fmt.Println()
+		}
+		if !printCandidates(blob) {
+			unknown++
+		}
+	}
+	if unknown > 0 {
+		return fmt.Errorf("%d of %d blobs not recognized", unknown, len(blobs))
+	}
+	return nil
+}
+
+func printCandidates(blob string) bool {
+	fmt.Println(shorten(blob, 80))
+
+	candidates, err := inspect.InspectString(blob)
+	if err != nil {
+		fmt.Printf("  %v\n", err)
+		return false
+	}
+	if len(candidates) == 0 {
+		fmt.Println("  no reading: not a wrapped object, or of a type this build does not know")
+		return false
+	}
+
+	for i, c := range candidates {
+		fit := "exact"
+		if !c.Exact {
+			fit = "loose"
+		}
+		if c.Legacy {
+			fit += ", legacy"
+		}
+		fmt.Printf("  %d. %-12s %-18s %s\n", i+1, c.Kind, c.Type, fit)
+		fmt.Printf("     %s\n", shorten(c.Value.String(), 160))
+		for _, note := range c.Notes {
+			fmt.Printf("     %s\n", note)
+		}
+	}
+	return true
+}
+
+func shorten(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return fmt.Sprintf("%s...%s (%d chars)", s[:n/2], s[len(s)-n/4:], len(s))
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/node"
+	"github.com/andantan/kangaroo/p2p/tcpnet"
+	"github.com/andantan/kangaroo/registry"
+	"log"
+	"math/big"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+const defaultAlloc = "1000000000000000000000"
+
+// configFlags are the flags of every command that reads the config: the
+// config file and an override flag per setting.
+type configFlags struct {
+	path      string
+	overrides node.Overrides
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *configFlags) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cf := &configFlags{overrides: node.Overrides{}}
+	fs.StringVar(&cf.path, "config", "", "config file (default <data-dir>/config.yaml)")
+	cf.overrides.Register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: kangaroo %s [flags]\n\n", name)
+		fmt.Fprintf(fs.Output(), "Every setting can also be set with its %s environment variable,\ne.g. %s for --rpc.http. Flags win over the environment, which wins\nover the config file.\n\n", node.EnvPrefix+"*", node.EnvName("rpc.http"))
+		fs.PrintDefaults()
+	}
+	return fs, cf
+}
+
+// defaults are the built in settings with the environment and the flags
+// applied, i.e. the config without a file.
+func (cf *configFlags) defaults() (node.Config, error) {
+	cfg := node.DefaultConfig()
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return node.Config{}, err
+	}
+	if err := cf.overrides.Apply(&cfg); err != nil {
+		return node.Config{}, err
+	}
+	return cfg, nil
+}
+
+func (cf *configFlags) load() (node.Config, error) {
+	path := cf.path
+	if path == "" {
+		cfg, err := cf.defaults()
+		if err != nil {
+			return node.Config{}, err
+		}
+		path = node.ConfigPath(cfg)
+	}
+
+	cfg, err := node.LoadConfig(path)
+	if err != nil {
+		return node.Config{}, err
+	}
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return node.Config{}, err
+	}
+	if err := cf.overrides.Apply(&cfg); err != nil {
+		return node.Config{}, err
+	}
+	return cfg, nil
+}
+
+func runInit(args []string) error {
+	fs, cf := newFlagSet("init")
+	alloc := fs.String("alloc", defaultAlloc, "genesis balance of the node key's address")
+	force := fs.Bool("force", false, "overwrite an existing config and genesis")
+	_ = fs.Parse(args)
+
+	cfg, err := cf.defaults()
+	if err != nil {
+		return err
+	}
+	balance, ok := new(big.Int).SetString(*alloc, 10)
+	if !ok || balance.Sign() < 0 {
+		return fmt.Errorf("invalid --alloc %q", *alloc)
+	}
+
+	if cfg, err = node.Init(cfg, node.InitOptions{Alloc: balance, Force: *force}); err != nil {
+		return err
+	}
+
+	nodeKey, err := node.LoadNodeKey(cfg.NodeKeyPath())
+	if err != nil {
+		return err
+	}
+	addressSuite, err := registry.GetAddressSuite(cfg.Suites.Address)
+	if err != nil {
+		return err
+	}
+	address, err := wrapper.WrapAddressToString(nodeKey.PublicKey().Address(addressSuite.Deriver()))
+	if err != nil {
+		return err
+	}
+	publicKey, err := wrapper.WrapPublicKeyToString(nodeKey.PublicKey())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Initialized %s\n", cfg.DataDir)
+	fmt.Printf("%-12s %s\n", "Config:", node.ConfigPath(cfg))
+	fmt.Printf("%-12s %s\n", "Genesis:", cfg.GenesisPath())
+	fmt.Printf("%-12s %s\n", "Node key:", cfg.NodeKeyPath())
+	fmt.Printf("%-12s %s\n", "Public key:", publicKey)
+	fmt.Printf("%-12s %s\n", "Address:", address)
+	return nil
+}
+
+func runStart(args []string) error {
+	fs, cf := newFlagSet("start")
+	_ = fs.Parse(args)
+
+	cfg, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	n, err := node.New(cfg)
+	if err != nil {
+		return err
+	}
+	if err := n.Start(); err != nil {
+		return err
+	}
+	log.Printf("[Node] %s running, head %s", cfg.ChainID, n.Chain().Head().GetHeader())
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+	log.Printf("[Node] shutting down")
+
+	return n.Close()
+}
+
+func runVersion(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	fmt.Printf("kangaroo %s (%s %s/%s)\n", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("%-18s %d\n", "P2P protocol:", tcpnet.ProtocolVersion)
+	fmt.Printf("%-18s %s\n", "Key suites:", strings.Join(registry.ListKeySuiteTypes(), ", "))
+	fmt.Printf("%-18s %s\n", "Hash suites:", strings.Join(registry.ListHashSuiteTypes(), ", "))
+	fmt.Printf("%-18s %s\n", "Address suites:", strings.Join(registry.ListAddressSuiteTypes(), ", "))
+	return nil
+}
+
+func runExport(args []string) error {
+	fs, cf := newFlagSet("export")
+	out := fs.String("o", "-", "archive file, - for stdout")
+	_ = fs.Parse(args)
+
+	cfg, err := cf.load()
+	if err != nil {
+		return err
+	}
+	c, err := node.OpenChain(cfg)
+	if err != nil {
+		return err
+	}
+
+	var n int
+	if *out == "-" {
+		n, err = node.ExportChain(c, os.Stdout)
+	} else {
+		n, err = node.ExportChainFile(c, *out)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("exported %d blocks, head %s", n, c.Head().GetHeader())
+	return nil
+}
+
+// runImport works on the data directory directly, so the node must not be
+// running.
+func runImport(args []string) error {
+	fs, cf := newFlagSet("import")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("expected the archive file as the only argument")
+	}
+
+	cfg, err := cf.load()
+	if err != nil {
+		return err
+	}
+	c, err := node.OpenChain(cfg)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	added, importErr := node.ImportChain(c, f)
+	if added > 0 {
+		if _, err := node.ExportChainFile(c, cfg.ChainPath()); err != nil {
+			return err
+		}
+	}
+	if importErr != nil {
+		return fmt.Errorf("imported %d blocks before: %w", added, importErr)
+	}
+	log.Printf("imported %d blocks, head %s", added, c.Head().GetHeader())
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"golang.org/x/term"
+	"os"
+)
+
+// readPassword returns the first line of file when one is given. Otherwise
+// it prompts on the terminal without echo, or reads a line from stdin when
+// that is not a terminal.
+func readPassword(file, prompt string) ([]byte, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		return bytes.TrimSuffix(line, []byte("\r")), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+		if err != nil && len(line) == 0 {
+			return nil, err
+		}
+		return bytes.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return password, err
+}
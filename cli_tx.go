@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/andantan/kangaroo/client"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/keystore"
+	"github.com/andantan/kangaroo/node"
+	"github.com/andantan/kangaroo/registry"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+var txCommands = []command{
+	{"build", "write an unsigned transaction", runTxBuild},
+	{"sign", "sign a transaction with a key file", runTxSign},
+	{"verify", "check the signature of a transaction", runTxVerify},
+	{"decode", "print a transaction as JSON", runTxDecode},
+	{"hash", "print the id or signing hash of a transaction", runTxHash},
+	{"send", "submit a signed transaction to a node", runTxSend},
+}
+
+// Transactions move between the commands in their wrapped form, 0x hex on
+// a single line, so every step also takes the output of the node's RPC.
+//
+// e.g., make run ARGS="tx build --to=0x04... --value=25 --nonce=0 -o tx.unsigned"
+// e.g., make run ARGS="tx sign --key=wallet.json -o tx.signed tx.unsigned"
+// e.g., make run ARGS="tx send --wait tx.signed"
+func runTx(args []string) error {
+	if len(args) == 0 {
+		txUsage()
+		return errors.New("missing tx command")
+	}
+
+	name, args := args[0], args[1:]
+	for _, cmd := range txCommands {
+		if cmd.name == name {
+			return cmd.run(args)
+		}
+	}
+	txUsage()
+	return fmt.Errorf("unknown tx command %q", name)
+}
+
+func txUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: kangaroo tx <command> [flags] [tx]\n\nCommands:\n")
+	for _, cmd := range txCommands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nThe tx argument is a file, a 0x wrapped transaction or - for stdin.\n")
+}
+
+// suiteFlags pick the hash and address suites; they must match the chain's.
+type suiteFlags struct {
+	hash    string
+	address string
+}
+
+func newTxFlagSet(name string) (*flag.FlagSet, *suiteFlags) {
+	fs := flag.NewFlagSet("tx "+name, flag.ExitOnError)
+	defaults := node.DefaultConfig().Suites
+	sf := &suiteFlags{}
+	fs.StringVar(&sf.hash, "hash-suite", defaults.Hash, "hash suite of transaction ids and signing hashes")
+	fs.StringVar(&sf.address, "address-suite", defaults.Address, "address suite of the sender address")
+	return fs, sf
+}
+
+func (sf *suiteFlags) derivers() (hash.HashDeriver, hash.AddressDeriver, error) {
+	hashSuite, err := registry.GetHashSuite(sf.hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	addressSuite, err := registry.GetAddressSuite(sf.address)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hashSuite.Deriver(), addressSuite.Deriver(), nil
+}
+
+func runTxBuild(args []string) error {
+	fs := flag.NewFlagSet("tx build", flag.ExitOnError)
+	to := fs.String("to", "", "wrapped recipient address, empty for none")
+	value := fs.String("value", "0", "amount to transfer")
+	data := fs.String("data", "", "0x hex payload")
+	nonce := fs.Uint64("nonce", 0, "sender nonce")
+	from := fs.String("from", "", "wrapped sender address; without --nonce its pending nonce is asked from --rpc")
+	rpc := fs.String("rpc", defaultRPC(), "JSON-RPC endpoint used with --from")
+	out := fs.String("o", "-", "output file, - for stdout")
+	_ = fs.Parse(args)
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	var toAddress hash.Address
+	if *to != "" {
+		var err error
+		if toAddress, err = wrapper.UnwrapAddressFromString(*to); err != nil {
+			return fmt.Errorf("--to: %w", err)
+		}
+	}
+	amount, ok := new(big.Int).SetString(*value, 10)
+	if !ok || amount.Sign() < 0 {
+		return fmt.Errorf("invalid --value %q", *value)
+	}
+	var payload []byte
+	if *data != "" {
+		var err error
+		if payload, err = hex.DecodeString(strings.TrimPrefix(*data, "0x")); err != nil {
+			return fmt.Errorf("--data: %w", err)
+		}
+	}
+
+	if *from != "" && !flagSet(fs, "nonce") {
+		sender, err := wrapper.UnwrapAddressFromString(*from)
+		if err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if *nonce, err = client.NewClient(client.Config{URL: *rpc}).Nonce(ctx, sender, true); err != nil {
+			return fmt.Errorf("fetch nonce: %w", err)
+		}
+	} else if !flagSet(fs, "nonce") {
+		return errors.New("--nonce or --from is required")
+	}
+
+	return writeTransaction(*out, kangarootransaction.NewKangarooTransaction(toAddress, amount, payload, *nonce))
+}
+
+func runTxSign(args []string) error {
+	fs, sf := newTxFlagSet("sign")
+	keyFile := fs.String("key", "wallet.json", "encrypted key file of the sender")
+	passwordFile := fs.String("password-file", "", "read the password from the first line of this file instead of prompting")
+	out := fs.String("o", "-", "output file, - for stdout")
+	_ = fs.Parse(args)
+
+	tx, err := readTransaction(fs)
+	if err != nil {
+		return err
+	}
+	hashDeriver, _, err := sf.derivers()
+	if err != nil {
+		return err
+	}
+
+	password, err := readPassword(*passwordFile, "Password")
+	if err != nil {
+		return err
+	}
+	priv, err := keystore.Unlock(*keyFile, password)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Sign(priv, hashDeriver); err != nil {
+		return err
+	}
+	return writeTransaction(*out, tx)
+}
+
+func runTxVerify(args []string) error {
+	fs, sf := newTxFlagSet("verify")
+	_ = fs.Parse(args)
+
+	tx, err := readTransaction(fs)
+	if err != nil {
+		return err
+	}
+	hashDeriver, addressDeriver, err := sf.derivers()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Verify(hashDeriver); err != nil {
+		return err
+	}
+	from, err := wrapper.WrapAddressToString(tx.GetSigner().Address(addressDeriver))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("signature OK, from %s\n", from)
+	return nil
+}
+
+func runTxDecode(args []string) error {
+	fs, sf := newTxFlagSet("decode")
+	_ = fs.Parse(args)
+
+	tx, err := readTransaction(fs)
+	if err != nil {
+		return err
+	}
+	hashDeriver, addressDeriver, err := sf.derivers()
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeTransaction(tx, hashDeriver, addressDeriver)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(decoded)
+}
+
+func runTxHash(args []string) error {
+	fs, sf := newTxFlagSet("hash")
+	signing := fs.Bool("signing", false, "print the hash that is signed instead of the transaction id")
+	_ = fs.Parse(args)
+
+	tx, err := readTransaction(fs)
+	if err != nil {
+		return err
+	}
+	hashDeriver, _, err := sf.derivers()
+	if err != nil {
+		return err
+	}
+
+	var id hash.Hash
+	if *signing {
+		id, err = tx.HashForSigning(hashDeriver)
+	} else {
+		id, err = tx.Hash(hashDeriver)
+	}
+	if err != nil {
+		return err
+	}
+	wrapped, err := wrapper.WrapHashToString(id)
+	if err != nil {
+		return err
+	}
+	fmt.Println(wrapped)
+	return nil
+}
+
+func runTxSend(args []string) error {
+	fs := flag.NewFlagSet("tx send", flag.ExitOnError)
+	rpc := fs.String("rpc", defaultRPC(), "JSON-RPC endpoint of the node")
+	wait := fs.Bool("wait", false, "wait until the transaction is in a block")
+	timeout := fs.Duration("timeout", time.Minute, "give up sending and waiting after this long")
+	_ = fs.Parse(args)
+
+	tx, err := readTransaction(fs)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	c := client.NewClient(client.Config{URL: *rpc})
+
+	id, err := c.SendTransaction(ctx, tx)
+	if err != nil {
+		return err
+	}
+	wrapped, err := wrapper.WrapHashToString(id)
+	if err != nil {
+		return err
+	}
+	fmt.Println(wrapped)
+
+	if *wait {
+		included, err := c.WaitIncluded(ctx, id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("included in block %d (%s)\n", included.BlockHeight, included.BlockHash)
+	}
+	return nil
+}
+
+// decodedTransaction is the JSON form printed by tx decode. Hash, From and
+// the signature fields are only set for signed transactions.
+type decodedTransaction struct {
+	Type        string `json:"type"`
+	Hash        string `json:"hash,omitempty"`
+	SigningHash string `json:"signingHash"`
+	From        string `json:"from,omitempty"`
+	Signer      string `json:"signer,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+	To          string `json:"to,omitempty"`
+	Value       string `json:"value"`
+	Nonce       uint64 `json:"nonce"`
+	Data        string `json:"data,omitempty"`
+}
+
+func decodeTransaction(tx transaction.Transaction, hashDeriver hash.HashDeriver, addressDeriver hash.AddressDeriver) (*decodedTransaction, error) {
+	d := &decodedTransaction{Type: tx.Type(), Value: tx.GetValue().String(), Nonce: tx.GetNonce()}
+
+	signingHash, err := tx.HashForSigning(hashDeriver)
+	if err != nil {
+		return nil, err
+	}
+	if d.SigningHash, err = wrapper.WrapHashToString(signingHash); err != nil {
+		return nil, err
+	}
+	if to := tx.GetToAddress(); to != nil {
+		if d.To, err = wrapper.WrapAddressToString(to); err != nil {
+			return nil, err
+		}
+	}
+	if data := tx.GetData(); len(data) > 0 {
+		d.Data = "0x" + hex.EncodeToString(data)
+	}
+
+	signer := tx.GetSigner()
+	if signer == nil {
+		return d, nil
+	}
+	if d.Signer, err = wrapper.WrapPublicKeyToString(signer); err != nil {
+		return nil, err
+	}
+	if d.From, err = wrapper.WrapAddressToString(signer.Address(addressDeriver)); err != nil {
+		return nil, err
+	}
+	if ktx, ok := tx.(*kangarootransaction.KangarooTransaction); ok && ktx.Signature != nil {
+		if d.Signature, err = wrapper.WrapSignatureToString(ktx.Signature); err != nil {
+			return nil, err
+		}
+	}
+	if id, err := tx.Hash(hashDeriver); err == nil {
+		if d.Hash, err = wrapper.WrapHashToString(id); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// readTransaction unwraps the only argument of fs: a 0x string, a file
+// holding one, or - for stdin.
+func readTransaction(fs *flag.FlagSet) (transaction.Transaction, error) {
+	if fs.NArg() != 1 {
+		return nil, errors.New("expected the transaction as the only argument")
+	}
+
+	arg := fs.Arg(0)
+	var raw string
+	switch {
+	case strings.HasPrefix(arg, "0x"):
+		raw = arg
+	case arg == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		raw = string(data)
+	default:
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, err
+		}
+		raw = string(data)
+	}
+
+	return wrapper.UnwrapTransactionFromString(strings.TrimSpace(raw))
+}
+
+func writeTransaction(path string, tx transaction.Transaction) error {
+	wrapped, err := wrapper.WrapTransactionToString(tx)
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		_, err = fmt.Println(wrapped)
+		return err
+	}
+	return os.WriteFile(path, []byte(wrapped+"\n"), 0644)
+}
+
+func defaultRPC() string {
+	return "http://" + node.DefaultConfig().RPC.HTTP
+}
+
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
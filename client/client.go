@@ -0,0 +1,252 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/rpc/jsonrpc"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for blocks and transactions the node does not
+	// know.
+	ErrNotFound = errors.New("not found")
+	ErrBadReply = errors.New("bad reply")
+)
+
+const DefaultPollInterval = 500 * time.Millisecond
+
+type Config struct {
+	// URL is the node's JSON-RPC endpoint.
+	URL        string
+	HTTPClient *http.Client
+	// PollInterval is how often the Wait methods ask the node again.
+	PollInterval time.Duration
+}
+
+// Client is a typed client for the node's JSON-RPC API. It is safe for
+// concurrent use.
+type Client struct {
+	cfg    Config
+	nextID atomic.Uint64
+
+	lock           sync.Mutex
+	hashDeriver    hash.HashDeriver
+	addressDeriver hash.AddressDeriver
+}
+
+func NewClient(cfg Config) *Client {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	return &Client{cfg: cfg}
+}
+
+// Call invokes method with positional params and decodes the result into
+// out. Errors returned by the node are *jsonrpc.Error.
+func (c *Client) Call(ctx context.Context, out any, method string, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	id, err := json.Marshal(c.nextID.Add(1))
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(&jsonrpc.Request{JSONRPC: jsonrpc.Version, Method: method, Params: encoded, ID: id})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reply, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: http status %s", ErrBadReply, resp.Status)
+	}
+
+	var r jsonrpc.Response
+	if err := json.Unmarshal(reply, &r); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadReply, err)
+	}
+	if r.Error != nil {
+		return r.Error
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(r.Result, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadReply, err)
+	}
+	return nil
+}
+
+func (c *Client) ChainInfo(ctx context.Context) (*jsonrpc.ChainInfo, error) {
+	var info jsonrpc.ChainInfo
+	if err := c.Call(ctx, &info, "chainInfo"); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (c *Client) SupportedSuites(ctx context.Context) (*jsonrpc.Suites, error) {
+	var suites jsonrpc.Suites
+	if err := c.Call(ctx, &suites, "listSupportedSuites"); err != nil {
+		return nil, err
+	}
+	return &suites, nil
+}
+
+// Derivers returns the chain's hash and address derivers, looked up in the
+// registry by the suite names the node reports. They are fetched once.
+func (c *Client) Derivers(ctx context.Context) (hash.HashDeriver, hash.AddressDeriver, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.hashDeriver != nil {
+		return c.hashDeriver, c.addressDeriver, nil
+	}
+
+	info, err := c.ChainInfo(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashSuite, err := registry.GetHashSuite(info.HashSuite)
+	if err != nil {
+		return nil, nil, err
+	}
+	addressSuite, err := registry.GetAddressSuite(info.AddressSuite)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.hashDeriver, c.addressDeriver = hashSuite.Deriver(), addressSuite.Deriver()
+	return c.hashDeriver, c.addressDeriver, nil
+}
+
+func (c *Client) Balance(ctx context.Context, addr hash.Address) (*big.Int, error) {
+	wrapped, err := wrapper.WrapAddressToString(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var s string
+	if err := c.Call(ctx, &s, "getBalance", wrapped); err != nil {
+		return nil, err
+	}
+	balance, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: balance %q", ErrBadReply, s)
+	}
+	return balance, nil
+}
+
+// Nonce returns the next nonce of addr at the head or, with pending, after
+// the transactions waiting in the node's pool.
+func (c *Client) Nonce(ctx context.Context, addr hash.Address, pending bool) (uint64, error) {
+	wrapped, err := wrapper.WrapAddressToString(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	tag := jsonrpc.TagLatest
+	if pending {
+		tag = jsonrpc.TagPending
+	}
+	var nonce uint64
+	if err := c.Call(ctx, &nonce, "getNonce", wrapped, tag); err != nil {
+		return 0, err
+	}
+	return nonce, nil
+}
+
+// SendTransaction submits a signed transaction and returns its hash.
+func (c *Client) SendTransaction(ctx context.Context, tx transaction.Transaction) (hash.Hash, error) {
+	raw, err := wrapper.WrapTransactionToString(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var s string
+	if err := c.Call(ctx, &s, "sendRawTransaction", raw); err != nil {
+		return nil, err
+	}
+	id, err := wrapper.UnwrapHashFromString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadReply, err)
+	}
+	return id, nil
+}
+
+func (c *Client) TransactionByHash(ctx context.Context, id hash.Hash) (*jsonrpc.Transaction, error) {
+	wrapped, err := wrapper.WrapHashToString(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *jsonrpc.Transaction
+	if err := c.Call(ctx, &tx, "getTransactionByHash", wrapped); err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("%w: transaction %s", ErrNotFound, id.ShortString(8))
+	}
+	return tx, nil
+}
+
+func (c *Client) BlockByHeight(ctx context.Context, height uint64) (*jsonrpc.Block, error) {
+	var blk *jsonrpc.Block
+	if err := c.Call(ctx, &blk, "getBlockByHeight", height); err != nil {
+		return nil, err
+	}
+	if blk == nil {
+		return nil, fmt.Errorf("%w: block at height %d", ErrNotFound, height)
+	}
+	return blk, nil
+}
+
+func (c *Client) BlockByHash(ctx context.Context, id hash.Hash) (*jsonrpc.Block, error) {
+	wrapped, err := wrapper.WrapHashToString(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var blk *jsonrpc.Block
+	if err := c.Call(ctx, &blk, "getBlockByHash", wrapped); err != nil {
+		return nil, err
+	}
+	if blk == nil {
+		return nil, fmt.Errorf("%w: block %s", ErrNotFound, id.ShortString(8))
+	}
+	return blk, nil
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/rpc/jsonrpc"
+	"math/big"
+	"sync"
+)
+
+// Account signs and submits transactions for one key. It hands out nonces
+// itself, so several transactions can be in flight before any is included.
+type Account struct {
+	client      *Client
+	key         key.PrivateKey
+	address     hash.Address
+	hashDeriver hash.HashDeriver
+
+	lock sync.Mutex
+	// nonce is the next nonce to use, valid while synced.
+	nonce  uint64
+	synced bool
+}
+
+// Account binds priv to the client, deriving its address with the chain's
+// address deriver.
+func (c *Client) Account(ctx context.Context, priv key.PrivateKey) (*Account, error) {
+	hashDeriver, addressDeriver, err := c.Derivers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		client:      c,
+		key:         priv,
+		address:     priv.PublicKey().Address(addressDeriver),
+		hashDeriver: hashDeriver,
+	}, nil
+}
+
+func (a *Account) Address() hash.Address {
+	return a.address
+}
+
+// ResetNonce makes the next transaction ask the node for the nonce again,
+// e.g. after transactions were sent for the same key from elsewhere.
+func (a *Account) ResetNonce() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.synced = false
+}
+
+// BuildTransfer sends value to to with the next nonce, signed with the
+// account key and the chain's hash deriver.
+func (a *Account) BuildTransfer(ctx context.Context, to hash.Address, value *big.Int) (*PendingTransaction, error) {
+	return a.BuildTransaction(ctx, to, value, nil)
+}
+
+// BuildTransaction builds, signs and submits a transaction with the next
+// nonce. The first call, and any call after a rejected submission, asks the
+// node for the pending nonce; the others count on locally.
+func (a *Account) BuildTransaction(ctx context.Context, to hash.Address, value *big.Int, data []byte) (*PendingTransaction, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if !a.synced {
+		nonce, err := a.client.Nonce(ctx, a.address, true)
+		if err != nil {
+			return nil, err
+		}
+		a.nonce, a.synced = nonce, true
+	}
+
+	tx := kangarootransaction.NewKangarooTransaction(to, value, data, a.nonce)
+	if err := tx.Sign(a.key, a.hashDeriver); err != nil {
+		return nil, err
+	}
+	id, err := a.client.SendTransaction(ctx, tx)
+	if err != nil {
+		a.synced = false
+		return nil, err
+	}
+	a.nonce++
+
+	return &PendingTransaction{Hash: id, Transaction: tx, client: a.client}, nil
+}
+
+// PendingTransaction is a submitted transaction that can be waited on.
+type PendingTransaction struct {
+	Hash        hash.Hash
+	Transaction transaction.Transaction
+	client      *Client
+}
+
+func (p *PendingTransaction) WaitIncluded(ctx context.Context) (*jsonrpc.Transaction, error) {
+	return p.client.WaitIncluded(ctx, p.Hash)
+}
+
+func (p *PendingTransaction) WaitFinalized(ctx context.Context) (*jsonrpc.Transaction, error) {
+	return p.client.WaitFinalized(ctx, p.Hash)
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/rpc/jsonrpc"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fixture struct {
+	t      *testing.T
+	chain  *chain.Chain
+	pool   *mempool.Mempool
+	user   key.PrivateKey
+	client *Client
+}
+
+func newFixture(t *testing.T) *fixture {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("keccak256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	user, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	c, err := chain.NewChain(state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver()), &chain.Genesis{
+		Timestamp: 1,
+		Alloc:     []chain.GenesisAccount{{Address: user.PublicKey().Address(addressSuite.Deriver()), Balance: big.NewInt(1000)}},
+	}, nil)
+	require.NoError(t, err)
+
+	f := &fixture{t: t, chain: c, pool: mempool.NewMempool(hashSuite.Deriver(), 0), user: user}
+	srv := httptest.NewServer(jsonrpc.NewServer(jsonrpc.Config{ChainID: "kangaroo-test"}, c, f.pool))
+	t.Cleanup(srv.Close)
+
+	f.client = NewClient(Config{URL: srv.URL, PollInterval: 5 * time.Millisecond})
+	return f
+}
+
+func (f *fixture) mine() block.Block {
+	blk, err := assembler.NewAssembler(f.chain, f.pool, 0).Assemble(nil, f.chain.Head().GetHeader().GetTimestamp()+1, nil)
+	require.NoError(f.t, err)
+	require.NoError(f.t, f.chain.AddBlock(blk))
+	f.pool.RemoveTransactions(blk.GetBody().GetTransactions())
+	return blk
+}
+
+func (f *fixture) recipient() hash.Address {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(f.t, err)
+	priv, err := keySuite.GeneratePrivateKey()
+	require.NoError(f.t, err)
+	return priv.PublicKey().Address(f.chain.Executor().AddressDeriver())
+}
+
+func TestClient_Queries(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	info, err := f.client.ChainInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "kangaroo-test", info.ChainID)
+
+	suites, err := f.client.SupportedSuites(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, suites.Keys, "ecdsa-secp256k1")
+
+	hashDeriver, addressDeriver, err := f.client.Derivers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "keccak256", hashDeriver.Type())
+	assert.Equal(t, "keccak256", addressDeriver.Type())
+
+	account, err := f.client.Account(ctx, f.user)
+	require.NoError(t, err)
+	balance, err := f.client.Balance(ctx, account.Address())
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000), balance)
+
+	genesis, err := f.client.BlockByHeight(ctx, 0)
+	require.NoError(t, err)
+	genesisID, err := wrapper.UnwrapHashFromString(genesis.Hash)
+	require.NoError(t, err)
+	byHash, err := f.client.BlockByHash(ctx, genesisID)
+	require.NoError(t, err)
+	assert.Equal(t, genesis, byHash)
+
+	_, err = f.client.BlockByHeight(ctx, 3)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = f.client.TransactionByHash(ctx, genesisID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	var rpcErr *jsonrpc.Error
+	err = f.client.Call(ctx, nil, "nope")
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, jsonrpc.CodeMethodNotFound, rpcErr.Code)
+}
+
+func TestClient_Transfer(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	to := f.recipient()
+
+	account, err := f.client.Account(ctx, f.user)
+	require.NoError(t, err)
+
+	// Nonces are counted locally while earlier transfers are still pending.
+	var pending []*PendingTransaction
+	for i := 0; i < 3; i++ {
+		p, err := account.BuildTransfer(ctx, to, big.NewInt(10))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(i), p.Transaction.GetNonce())
+		pending = append(pending, p)
+	}
+	assert.Equal(t, 3, f.pool.Len())
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	_, err = pending[0].WaitIncluded(waitCtx)
+	cancel()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	included := make(chan error, 1)
+	go func() {
+		tx, err := pending[2].WaitIncluded(ctx)
+		if err == nil && tx.BlockHeight != 1 {
+			err = errors.New("wrong block")
+		}
+		included <- err
+	}()
+	blk := f.mine()
+	require.NoError(t, <-included)
+
+	finalized := make(chan error, 1)
+	go func() {
+		_, err := pending[0].WaitFinalized(ctx)
+		finalized <- err
+	}()
+	select {
+	case err := <-finalized:
+		t.Fatalf("finalized early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	id, err := blk.Hash(f.chain.Executor().HashDeriver())
+	require.NoError(t, err)
+	require.NoError(t, f.chain.Finalize(1, id))
+	require.NoError(t, <-finalized)
+
+	balance, err := f.client.Balance(ctx, to)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(30), balance)
+
+	// Transactions sent for the key from elsewhere are picked up after a
+	// reset.
+	other, err := f.client.Account(ctx, f.user)
+	require.NoError(t, err)
+	p, err := other.BuildTransfer(ctx, to, big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), p.Transaction.GetNonce())
+
+	account.ResetNonce()
+	p, err = account.BuildTransfer(ctx, to, big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), p.Transaction.GetNonce())
+}
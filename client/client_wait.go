@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/rpc/jsonrpc"
+	"time"
+)
+
+// WaitIncluded polls until the transaction is in a block of the canonical
+// chain. A transaction the node does not know yet, or dropped from its
+// pool, is waited for until ctx ends.
+func (c *Client) WaitIncluded(ctx context.Context, id hash.Hash) (*jsonrpc.Transaction, error) {
+	return c.wait(ctx, func() (*jsonrpc.Transaction, error) {
+		tx, err := c.TransactionByHash(ctx, id)
+		if err != nil || tx.Pending {
+			return nil, err
+		}
+		return tx, nil
+	})
+}
+
+// WaitFinalized polls until the block holding the transaction is finalized.
+// A transaction reorged out before that is waited for again wherever it is
+// included next.
+func (c *Client) WaitFinalized(ctx context.Context, id hash.Hash) (*jsonrpc.Transaction, error) {
+	return c.wait(ctx, func() (*jsonrpc.Transaction, error) {
+		tx, err := c.TransactionByHash(ctx, id)
+		if err != nil || tx.Pending {
+			return nil, err
+		}
+
+		blockID, err := wrapper.UnwrapHashFromString(tx.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		// Only canonical blocks are found by hash, so a finalized one is
+		// final for the transaction too.
+		blk, err := c.BlockByHash(ctx, blockID)
+		if err != nil || !blk.Finalized {
+			return nil, err
+		}
+		return tx, nil
+	})
+}
+
+// wait calls poll every PollInterval until it returns a transaction, an
+// error other than ErrNotFound, or ctx ends.
+func (c *Client) wait(ctx context.Context, poll func() (*jsonrpc.Transaction, error)) (*jsonrpc.Transaction, error) {
+	ticker := time.NewTicker(c.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		tx, err := poll()
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if tx != nil {
+			return tx, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,42 +1,66 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/andantan/kangaroo/codec/wrapper"
 	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/hd"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/crypto/keystore"
 	"github.com/andantan/kangaroo/registry"
 	"log"
 	"os"
 )
 
-type KeyFile struct {
-	KeyAlgorithm      string `json:"key_algorithm"`
-	PrivateKey        string `json:"private_key"`
-	WrappedPrivateKey string `json:"wrapped_private_key"`
-	PublicKey         string `json:"public_key"`
-	WrappedPublicKey  string `json:"wrapped_public_key"`
-	AddrAlgorithm     string `json:"address_algorithm"`
-	Address           string `json:"address"`
-	WrappedAddress    string `json:"wrapped_address"`
-}
-
 const defaultKeyAlgorithm = "ecdsa-secp256k1"
 const defaultAddressAlgorithm = "blake2b256"
 
 // e.g., make key-gen
 // e.g., make key-gen ARGS="--key-algo=eddsa-ed25519 --addr-algo=keccak256 -o mykey.json"
 // e.g., make key-gen ARGS="-list"
+// e.g., make key-gen ARGS="unlock -i mykey.json"
+// e.g., make key-gen ARGS="change-password -i mykey.json"
+// e.g., make key-gen ARGS="--mnemonic --path=m/44'/1'/0'/0'/0'"
+// e.g., make key-gen ARGS="restore --key-algo=eddsa-ed25519 -o restored.json"
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "generate":
+			generate(os.Args[2:])
+			return
+		case "unlock":
+			unlock(os.Args[2:])
+			return
+		case "restore":
+			restore(os.Args[2:])
+			return
+		case "change-password":
+			changePassword(os.Args[2:])
+			return
+		}
+	}
+	generate(os.Args[1:])
+}
+
+func generate(args []string) {
 	availableKeyAlgos := registry.ListKeySuiteTypes()
 	availableAddrAlgos := registry.ListAddressSuiteTypes()
 
-	keyAlgo := flag.String("key-algo", defaultKeyAlgorithm, "Key algorithm to use (e.g., ecdsa-secp256k1, eddsa-ed25519, ...)")
-	addrAlgo := flag.String("addr-algo", defaultAddressAlgorithm, "Address derivation algorithm (e.g., keccak256, sha256, ...)")
-	outputFile := flag.String("o", "wallet.json", "Output file name for the generated key pair")
-	listAlgos := flag.Bool("list", false, "List all available algorithms and exit")
-	flag.Parse()
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	keyAlgo := fs.String("key-algo", defaultKeyAlgorithm, "Key algorithm to use (e.g., ecdsa-secp256k1, eddsa-ed25519, ...)")
+	addrAlgo := fs.String("addr-algo", defaultAddressAlgorithm, "Address derivation algorithm (e.g., keccak256, sha256, ...)")
+	outputFile := fs.String("o", "wallet.json", "Output file name for the encrypted key file")
+	listAlgos := fs.Bool("list", false, "List all available algorithms and exit")
+	passwordFile := fs.String("password-file", "", "Read the password from the first line of this file instead of prompting")
+	fromMnemonic := fs.Bool("mnemonic", false, "Derive the key from a new BIP-39 mnemonic, printed once for backup")
+	path := fs.String("path", hd.DefaultPath, "HD derivation path used with --mnemonic")
+	opts := encryptionFlags(fs)
+	_ = fs.Parse(args)
+	if fs.NArg() > 0 {
+		log.Fatalf("FATAL: Unknown command or argument '%s'", fs.Arg(0))
+	}
 
 	if *listAlgos {
 		fmt.Println("Available Key Algorithms:")
@@ -58,61 +82,111 @@ func main() {
 	if err != nil {
 		log.Fatalf("FATAL: Unsupported address algorithm: %v", err)
 	}
+	refuseOverwrite(*outputFile)
 
-	log.Printf("Generating a new key pair using '%s' algorithm...", *keyAlgo)
-
-	privateKey, err := keySuite.GeneratePrivateKey()
-	if err != nil {
-		log.Fatalf("FATAL: Failed to generate private key: %v", err)
-	}
-	wrappedPrivateKeyString, err := wrapper.WrapPrivateKeyToString(privateKey)
-	if err != nil {
-		log.Fatalf("FATAL: Failed to wrap private key: %v", err)
+	if *fromMnemonic {
+		if _, err := hd.ParsePath(*path); err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+	} else if flagSet(fs, "path") {
+		log.Fatalf("FATAL: --path only applies with --mnemonic")
 	}
 
-	publicKey := privateKey.PublicKey()
-	wrappedPublicKeyString, err := wrapper.WrapPublicKeyToString(publicKey)
+	password, err := readPassword(*passwordFile, "New password", true)
 	if err != nil {
-		log.Fatalf("FATAL: Failed to wrap public key: %v", err)
+		log.Fatalf("FATAL: Failed to read password: %v", err)
 	}
 
-	address := publicKey.Address(addressSuite.Deriver())
-	wrappedAddressString, err := wrapper.WrapAddressToString(address)
-	if err != nil {
-		log.Fatalf("FATAL: Failed to wrap address: %v", err)
+	var privateKey key.PrivateKey
+	var mnemonic string
+	if *fromMnemonic {
+		log.Printf("Deriving a '%s' key at %s from a new mnemonic...", *keyAlgo, *path)
+
+		if mnemonic, err = hd.NewMnemonic(hd.DefaultMnemonicEntropy); err != nil {
+			log.Fatalf("FATAL: Failed to generate mnemonic: %v", err)
+		}
+		privateKey = deriveKey(keySuite, mnemonic, *path)
+	} else {
+		log.Printf("Generating a new key pair using '%s' algorithm...", *keyAlgo)
+
+		if privateKey, err = keySuite.GeneratePrivateKey(); err != nil {
+			log.Fatalf("FATAL: Failed to generate private key: %v", err)
+		}
 	}
 
-	keyFile := KeyFile{
-		KeyAlgorithm:      privateKey.Type(),
-		PrivateKey:        privateKey.String(),
-		WrappedPrivateKey: wrappedPrivateKeyString,
-		PublicKey:         publicKey.String(),
-		WrappedPublicKey:  wrappedPublicKeyString,
-		AddrAlgorithm:     address.Type(),
-		Address:           address.String(),
-		WrappedAddress:    wrappedAddressString,
+	keyFile := writeKeyFile(privateKey, addressSuite, *outputFile, password, *opts)
+
+	fmt.Println("\n--- 🔑 Key Generation Successful ---")
+	printKeyFile(keyFile)
+	if mnemonic != "" {
+		fmt.Printf("%-19s \t%s\n", "Derivation Path:", *path)
+		fmt.Println("\n⚠️  Write down this mnemonic and keep it offline. It restores the key and is not shown again:")
+		fmt.Printf("\n    %s\n", mnemonic)
 	}
+	fmt.Printf("\n✅  Encrypted key saved to '%s'\n", *outputFile)
 
-	jsonData, err := json.MarshalIndent(keyFile, "", "  ")
+	os.Exit(0)
+}
+
+// deriveKey derives the key of suite at path below the seed of mnemonic.
+func deriveKey(suite key.KeySuite, mnemonic, path string) key.PrivateKey {
+	seed, err := hd.SeedFromMnemonic(mnemonic, "")
 	if err != nil {
-		log.Fatalf("FATAL: Failed to marshal key data to JSON: %v", err)
+		log.Fatalf("FATAL: %v", err)
 	}
+	privateKey, err := suite.PrivateKeyFromSeed(seed, path)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to derive '%s' key at %s: %v", suite.Type(), path, err)
+	}
+	return privateKey
+}
 
-	err = os.WriteFile(*outputFile, jsonData, 0644)
+// writeKeyFile encrypts privateKey under password and saves it.
+func writeKeyFile(privateKey key.PrivateKey, addressSuite hash.AddressSuite, outputFile string, password []byte, opts keystore.Options) *keystore.KeyFile {
+	address := privateKey.PublicKey().Address(addressSuite.Deriver())
+
+	keyFile, err := keystore.Encrypt(privateKey, address, password, opts)
 	if err != nil {
+		log.Fatalf("FATAL: Failed to encrypt private key: %v", err)
+	}
+	if err = keyFile.Save(outputFile); err != nil {
 		log.Fatalf("FATAL: Failed to write key file: %v", err)
 	}
+	return keyFile
+}
 
-	fmt.Println("\n--- 🔑 Key Generation Successful ---")
-	fmt.Printf("%-19s \t%s\n", "Key Algorithm:", privateKey.Type())
-	fmt.Printf("%-19s \t%s\n", "Private Key (Raw):", privateKey.String())
-	fmt.Printf("%-19s \t%s\n", "Private Key (Wrapped):", wrappedPrivateKeyString)
-	fmt.Printf("%-19s \t%s\n", "Public Key (Raw):", publicKey.String())
-	fmt.Printf("%-19s \t%s\n", "Public Key (Wrapped):", wrappedPublicKeyString)
-	fmt.Printf("%-19s \t%s\n", "Address Algorithm:", address.Type())
-	fmt.Printf("%-19s \t%s\n", "Address (Raw):", address.String())
-	fmt.Printf("%-19s \t%s\n", "Address (Wrapped):", wrappedAddressString)
-	fmt.Printf("\n✅  Key pair successfully saved to '%s'\n", *outputFile)
+func refuseOverwrite(path string) {
+	if _, err := os.Stat(path); err == nil {
+		log.Fatalf("FATAL: '%s' already exists, refusing to overwrite a key file", path)
+	}
+}
 
-	os.Exit(0)
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// encryptionFlags registers the KDF and cipher choice of a new key file.
+func encryptionFlags(fs *flag.FlagSet) *keystore.Options {
+	opts := &keystore.Options{}
+	fs.StringVar(&opts.KDF, "kdf", keystore.KDFArgon2id, "Password KDF ("+keystore.KDFArgon2id+" or "+keystore.KDFScrypt+")")
+	fs.StringVar(&opts.Cipher, "cipher", keystore.CipherXChaCha20Poly1305, "Cipher ("+keystore.CipherXChaCha20Poly1305+" or "+keystore.CipherAES256GCM+")")
+	return opts
+}
+
+func printKeyFile(keyFile *keystore.KeyFile) {
+	fmt.Printf("%-19s \t%s\n", "Key Algorithm:", keyFile.KeyType)
+	fmt.Printf("%-19s \t%s\n", "Public Key (Wrapped):", keyFile.PublicKey)
+	if keyFile.Address != "" {
+		fmt.Printf("%-19s \t%s\n", "Address (Wrapped):", keyFile.Address)
+		if address, err := wrapper.UnwrapAddressFromString(keyFile.Address); err == nil {
+			fmt.Printf("%-19s \t%s\n", "Address Algorithm:", address.Type())
+		}
+	}
+	fmt.Printf("%-19s \t%s / %s\n", "Encryption:", keyFile.Crypto.KDF, keyFile.Crypto.Cipher)
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/keystore"
+	"log"
+	"os"
+)
+
+func unlock(args []string) {
+	fs := flag.NewFlagSet("unlock", flag.ExitOnError)
+	inputFile := fs.String("i", "wallet.json", "Encrypted key file")
+	passwordFile := fs.String("password-file", "", "Read the password from the first line of this file instead of prompting")
+	showPrivate := fs.Bool("show-private", false, "Also print the wrapped private key")
+	_ = fs.Parse(args)
+
+	keyFile, err := keystore.Load(*inputFile)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to load key file: %v", err)
+	}
+	password, err := readPassword(*passwordFile, "Password", false)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to read password: %v", err)
+	}
+	privateKey, err := keyFile.Decrypt(password)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to unlock key file: %v", err)
+	}
+
+	fmt.Println("\n--- 🔓 Key Unlocked ---")
+	printKeyFile(keyFile)
+	if *showPrivate {
+		wrapped, err := wrapper.WrapPrivateKeyToString(privateKey)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to wrap private key: %v", err)
+		}
+		fmt.Printf("%-19s \t%s\n", "Private Key (Wrapped):", wrapped)
+	}
+
+	os.Exit(0)
+}
+
+func changePassword(args []string) {
+	fs := flag.NewFlagSet("change-password", flag.ExitOnError)
+	inputFile := fs.String("i", "wallet.json", "Encrypted key file, rewritten in place")
+	passwordFile := fs.String("password-file", "", "Read the current password from the first line of this file")
+	newPasswordFile := fs.String("new-password-file", "", "Read the new password from the first line of this file")
+	opts := encryptionFlags(fs)
+	_ = fs.Parse(args)
+
+	keyFile, err := keystore.Load(*inputFile)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to load key file: %v", err)
+	}
+	oldPassword, err := readPassword(*passwordFile, "Current password", false)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to read password: %v", err)
+	}
+	// Check the current password before asking for a new one.
+	if _, err := keyFile.Decrypt(oldPassword); err != nil {
+		log.Fatalf("FATAL: Failed to unlock key file: %v", err)
+	}
+	newPassword, err := readPassword(*newPasswordFile, "New password", true)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to read password: %v", err)
+	}
+
+	changed, err := keyFile.ChangePassword(oldPassword, newPassword, *opts)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to change password: %v", err)
+	}
+	if err = changed.Save(*inputFile); err != nil {
+		log.Fatalf("FATAL: Failed to write key file: %v", err)
+	}
+
+	fmt.Printf("\n✅  Password of '%s' changed\n", *inputFile)
+	os.Exit(0)
+}
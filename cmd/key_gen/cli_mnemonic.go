@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/andantan/kangaroo/crypto/hd"
+	"github.com/andantan/kangaroo/registry"
+	"golang.org/x/term"
+	"log"
+	"os"
+)
+
+// restore rebuilds a key file from a mnemonic written down by
+// `generate --mnemonic`. The key algorithm and path must match the ones it
+// was generated with.
+func restore(args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	keyAlgo := fs.String("key-algo", defaultKeyAlgorithm, "Key algorithm the mnemonic was used with")
+	addrAlgo := fs.String("addr-algo", defaultAddressAlgorithm, "Address derivation algorithm")
+	outputFile := fs.String("o", "wallet.json", "Output file name for the encrypted key file")
+	path := fs.String("path", hd.DefaultPath, "HD derivation path")
+	mnemonicFile := fs.String("mnemonic-file", "", "Read the mnemonic from this file instead of prompting")
+	passwordFile := fs.String("password-file", "", "Read the password from the first line of this file instead of prompting")
+	opts := encryptionFlags(fs)
+	_ = fs.Parse(args)
+
+	keySuite, err := registry.GetKeySuite(*keyAlgo)
+	if err != nil {
+		log.Fatalf("FATAL: Unsupported key algorithm: %v", err)
+	}
+	addressSuite, err := registry.GetAddressSuite(*addrAlgo)
+	if err != nil {
+		log.Fatalf("FATAL: Unsupported address algorithm: %v", err)
+	}
+	if _, err := hd.ParsePath(*path); err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	refuseOverwrite(*outputFile)
+
+	mnemonic, err := readMnemonic(*mnemonicFile)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to read mnemonic: %v", err)
+	}
+	privateKey := deriveKey(keySuite, mnemonic, *path)
+
+	password, err := readPassword(*passwordFile, "New password", true)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to read password: %v", err)
+	}
+	keyFile := writeKeyFile(privateKey, addressSuite, *outputFile, password, *opts)
+
+	fmt.Println("\n--- ♻️  Key Restored ---")
+	printKeyFile(keyFile)
+	fmt.Printf("%-19s \t%s\n", "Derivation Path:", *path)
+	fmt.Printf("\n✅  Encrypted key saved to '%s'\n", *outputFile)
+
+	os.Exit(0)
+}
+
+// readMnemonic reads the whole of file when one is given. Otherwise it
+// prompts on the terminal without echo, or reads a line from stdin when
+// that is not a terminal.
+func readMnemonic(file string) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return hd.NormalizeMnemonic(string(data)), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := stdin.ReadBytes('\n')
+		if err != nil && len(line) == 0 {
+			return "", err
+		}
+		return hd.NormalizeMnemonic(string(line)), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Mnemonic: ")
+	line, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return hd.NormalizeMnemonic(string(bytes.TrimSpace(line))), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"golang.org/x/term"
+	"os"
+)
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// readPassword returns the first line of file when one is given. Otherwise
+// it prompts on the terminal without echo, asking twice for new passwords,
+// or reads a line from stdin when that is not a terminal.
+func readPassword(file, prompt string, confirm bool) ([]byte, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		return checkPassword(bytes.TrimSuffix(line, []byte("\r")), confirm)
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := stdin.ReadBytes('\n')
+		if err != nil && len(line) == 0 {
+			return nil, err
+		}
+		return checkPassword(bytes.TrimRight(line, "\r\n"), confirm)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		fmt.Fprintf(os.Stderr, "Repeat %s: ", prompt)
+		repeated, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(password, repeated) {
+			return nil, errors.New("passwords do not match")
+		}
+	}
+	return checkPassword(password, confirm)
+}
+
+// checkPassword refuses empty new passwords; existing key files are
+// unlocked with whatever they were sealed with.
+func checkPassword(password []byte, isNew bool) ([]byte, error) {
+	if isNew && len(password) == 0 {
+		return nil, errors.New("empty password")
+	}
+	return password, nil
+}
@@ -0,0 +1,187 @@
+// Package inspect tells what a wrapped blob is. Envelopes name their kind,
+// so they have a single reading. Legacy blobs only carry a type prefix from
+// the prefix space of their own kind, and the same bytes can be, say, a
+// sha256 hash and an ecdsa-secp256r1 private key; for them Inspect tries
+// every kind and reports each reading that survives the plausibility
+// checks.
+package inspect
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/types/format"
+	"sort"
+	"strings"
+)
+
+type Object interface {
+	format.Stringable
+	format.StringTypable
+}
+
+// Candidate is one reading of a blob.
+type Candidate struct {
+	Kind  wrapper.Kind
+	Type  string
+	Value Object
+	// Exact is set when wrapping Value again gives back the input, i.e.
+	// decoding neither skipped nor normalized any bytes. Legacy blobs are
+	// compared by type prefix and payload.
+	Exact bool
+	// Legacy is set for readings of a blob without an envelope.
+	Legacy bool
+	// Notes are further checks passed, e.g. a signature that verifies.
+	Notes []string
+}
+
+type inspector struct {
+	kind wrapper.Kind
+	try  func(data []byte) (Object, []byte, error)
+}
+
+// attempt adapts a wrapper's Unwrap/Wrap pair.
+func attempt[T Object](kind wrapper.Kind, unwrap func([]byte) (T, error), wrap func(T) ([]byte, error)) inspector {
+	return inspector{kind: kind, try: func(data []byte) (Object, []byte, error) {
+		v, err := unwrap(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		rewrapped, err := wrap(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		return v, rewrapped, nil
+	}}
+}
+
+// inspectors are in the order candidates of equal standing are reported:
+// the short fixed-size kinds first, where a match is most often meant.
+var inspectors = []inspector{
+	attempt(wrapper.KindHash, wrapper.UnwrapHash, wrapper.WrapHash),
+	attempt(wrapper.KindAddress, wrapper.UnwrapAddress, wrapper.WrapAddress),
+	attempt(wrapper.KindPublicKey, wrapper.UnwrapPublicKey, wrapper.WrapPublicKey),
+	attempt(wrapper.KindSignature, wrapper.UnwrapSignature, wrapper.WrapSignature),
+	attempt(wrapper.KindPrivateKey, wrapper.UnwrapPrivateKey, wrapper.WrapPrivateKey),
+	attempt(wrapper.KindTransaction, wrapper.UnwrapTransaction, wrapper.WrapTransaction),
+	attempt(wrapper.KindBlock, wrapper.UnwrapBlock, wrapper.WrapBlock),
+	attempt(wrapper.KindHeader, wrapper.UnwrapHeader, wrapper.WrapHeader),
+	attempt(wrapper.KindBody, wrapper.UnwrapBody, wrapper.WrapBody),
+	attempt(wrapper.KindTail, wrapper.UnwrapTail, wrapper.WrapTail),
+	attempt(wrapper.KindAttestation, wrapper.UnwrapAttestation, wrapper.WrapAttestation),
+	attempt(wrapper.KindEvidence, wrapper.UnwrapEvidence, wrapper.WrapEvidence),
+}
+
+// Inspect returns every plausible reading of data, exact ones first. A
+// reading is plausible when the wrapper's unwrap accepts data and the
+// result reports itself valid. Only exact readings of envelopes of the
+// protobuf kinds are kept, as their decoders accept many byte strings they
+// would never produce.
+//
+// An envelope is only read as the kind it names; a legacy blob is read as
+// every kind, whether or not legacy decoding is on.
+func Inspect(data []byte) []Candidate {
+	e, err := wrapper.ParseEnvelope(data)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []Candidate
+	for _, in := range inspectors {
+		if !e.Legacy() && e.Kind != in.kind {
+			continue
+		}
+
+		sealed := e
+		sealed.Kind = in.kind
+		if c, ok := in.inspect(sealed.Bytes(), e.Legacy()); ok {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Exact && !candidates[j].Exact
+	})
+	return candidates
+}
+
+// InspectString inspects a 0x hex blob.
+func InspectString(s string) ([]Candidate, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex string: %w", err)
+	}
+	return Inspect(data), nil
+}
+
+// inspect reads data, an envelope of the inspector's kind. For legacy blobs
+// it is the blob sealed as that kind.
+func (in inspector) inspect(data []byte, legacy bool) (c Candidate, ok bool) {
+	// Decoders of the protobuf kinds are not written for hostile input.
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	// An empty message decodes from the type prefix alone.
+	if e, err := wrapper.ParseEnvelope(data); err != nil || (!fixedSize(in.kind) && len(e.Payload) == 0) {
+		return Candidate{}, false
+	}
+
+	v, rewrapped, err := in.try(data)
+	if err != nil {
+		return Candidate{}, false
+	}
+	if valid, isValidatable := v.(format.Validatable); isValidatable && !valid.IsValid() {
+		return Candidate{}, false
+	}
+
+	c = Candidate{Kind: in.kind, Type: v.Type(), Value: v, Exact: bytes.Equal(rewrapped, data), Legacy: legacy}
+	if !c.Exact && !fixedSize(in.kind) {
+		return Candidate{}, false
+	}
+	c.Notes = notes(v)
+	return c, true
+}
+
+func fixedSize(kind wrapper.Kind) bool {
+	switch kind {
+	case wrapper.KindHash, wrapper.KindAddress, wrapper.KindPublicKey, wrapper.KindPrivateKey, wrapper.KindSignature:
+		return true
+	default:
+		return false
+	}
+}
+
+func notes(v Object) []string {
+	var notes []string
+	switch v := v.(type) {
+	case key.PrivateKey:
+		if wrapped, err := wrapper.WrapPublicKeyToString(v.PublicKey()); err == nil {
+			notes = append(notes, "public key "+wrapped)
+		}
+	case hash.Hash:
+		if v.IsZero() {
+			notes = append(notes, "zero hash")
+		}
+	case transaction.Transaction:
+		if v.GetSigner() == nil {
+			notes = append(notes, "unsigned")
+		}
+	case block.Attestation:
+		if v.Verify() {
+			notes = append(notes, "signature verifies")
+		}
+	case block.Evidence:
+		if v.Verify() {
+			notes = append(notes, "evidence verifies")
+		}
+	}
+	return notes
+}
@@ -0,0 +1,114 @@
+package inspect
+
+import (
+	"encoding/hex"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func kinds(candidates []Candidate) map[wrapper.Kind]Candidate {
+	m := make(map[wrapper.Kind]Candidate)
+	for _, c := range candidates {
+		m[c.Kind] = c
+	}
+	return m
+}
+
+// legacy strips the envelope off data.
+func legacy(t *testing.T, data []byte) []byte {
+	t.Helper()
+	e, err := wrapper.ParseEnvelope(data)
+	require.NoError(t, err)
+	require.False(t, e.Legacy())
+	return append([]byte{e.Type}, e.Payload...)
+}
+
+func TestInspect(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("keccak256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	priv, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	to := priv.PublicKey().Address(addressSuite.Deriver())
+	digest := hashSuite.Deriver().Derive([]byte("block"))
+	sig, err := priv.Sign(digest.Bytes())
+	require.NoError(t, err)
+
+	tx := kangarootransaction.NewKangarooTransaction(to, big.NewInt(5), []byte{1, 2}, 3)
+	require.NoError(t, tx.Sign(priv, hashSuite.Deriver()))
+	header := kangarooheader.NewKangarooHeader(1, 10, digest, digest, digest, priv.PublicKey())
+
+	wrap := func(b []byte, err error) []byte {
+		require.NoError(t, err)
+		return b
+	}
+	tests := []struct {
+		name string
+		data []byte
+		kind wrapper.Kind
+		typ  string
+	}{
+		{"hash", wrap(wrapper.WrapHash(digest)), wrapper.KindHash, "keccak256"},
+		{"address", wrap(wrapper.WrapAddress(to)), wrapper.KindAddress, "keccak256"},
+		{"public key", wrap(wrapper.WrapPublicKey(priv.PublicKey())), wrapper.KindPublicKey, "ecdsa-secp256k1"},
+		{"signature", wrap(wrapper.WrapSignature(sig)), wrapper.KindSignature, "ecdsa-secp256k1"},
+		{"transaction", wrap(wrapper.WrapTransaction(tx)), wrapper.KindTransaction, "kangaroo"},
+		{"header", wrap(wrapper.WrapHeader(header)), wrapper.KindHeader, "kangaroo"},
+		{"attestation", wrap(wrapper.WrapAttestation(kangarooattestation.NewKangarooAttestation(1, 0, 0, digest, priv.PublicKey(), sig))), wrapper.KindAttestation, "kangaroo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An envelope has exactly one reading.
+			candidates := Inspect(tt.data)
+			require.Len(t, candidates, 1)
+			assert.Equal(t, tt.kind, candidates[0].Kind)
+			assert.Equal(t, tt.typ, candidates[0].Type)
+			assert.True(t, candidates[0].Exact)
+			assert.False(t, candidates[0].Legacy)
+
+			// Without it the blob is read as every kind that accepts it.
+			candidates = Inspect(legacy(t, tt.data))
+			c, ok := kinds(candidates)[tt.kind]
+			require.True(t, ok, "%v", candidates)
+			assert.Equal(t, tt.typ, c.Type)
+			assert.True(t, c.Exact)
+			assert.True(t, c.Legacy)
+		})
+	}
+
+	// The prefix spaces overlap: 0x01 is sha256 among hashes and
+	// ecdsa-secp256r1 among keys, both 32 bytes long.
+	sha256Suite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	ambiguous := legacy(t, wrap(wrapper.WrapHash(sha256Suite.Deriver().Derive([]byte("x")))))
+	candidates, err := InspectString(hex.EncodeToString(ambiguous))
+	require.NoError(t, err)
+	found := kinds(candidates)
+	assert.Equal(t, "sha256", found[wrapper.KindHash].Type)
+	assert.Equal(t, "ecdsa-secp256r1", found[wrapper.KindPrivateKey].Type)
+	assert.NotEmpty(t, found[wrapper.KindPrivateKey].Notes)
+
+	unsigned := kangarootransaction.NewKangarooTransaction(to, big.NewInt(1), nil, 0)
+	candidates = Inspect(wrap(wrapper.WrapTransaction(unsigned)))
+	require.Len(t, candidates, 1)
+	assert.Contains(t, kinds(candidates)[wrapper.KindTransaction].Notes, "unsigned")
+
+	for _, junk := range [][]byte{nil, {0x01}, {0xff, 0xff, 0xff}, {0xa1, 0x01, 0x01, 0x05, 0x00}, []byte("not a kangaroo object at all")} {
+		assert.Empty(t, Inspect(junk), "%x", junk)
+	}
+	_, err = InspectString("0xzz")
+	assert.Error(t, err)
+}
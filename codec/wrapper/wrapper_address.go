@@ -13,7 +13,7 @@ func WrapAddress(a hash.Address) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("configuration error for address<%s>: %w", a.Type(), err)
 	}
-	return append([]byte{prefix}, a.Bytes()...), nil
+	return seal(KindAddress, prefix, a.Bytes()), nil
 }
 
 func WrapAddressToString(a hash.Address) (string, error) {
@@ -25,13 +25,11 @@ func WrapAddressToString(a hash.Address) (string, error) {
 }
 
 func UnwrapAddress(data []byte) (hash.Address, error) {
-	if len(data) < 1 {
-		return nil, fmt.Errorf("hash data is too short to contain a type prefix")
+	typePrefix, addressData, err := open(KindAddress, data)
+	if err != nil {
+		return nil, err
 	}
 
-	typePrefix := data[0]
-	addressData := data[1:]
-
 	typeName, err := hash.GetTypeFromAddressPrefix(typePrefix)
 	if err != nil {
 		return nil, err
@@ -55,3 +53,11 @@ func UnwrapAddressFromString(s string) (hash.Address, error) {
 
 	return UnwrapAddress(data)
 }
+
+func WrapAddressCanonical(a hash.Address) ([]byte, error) {
+	return canonical(WrapAddress(a))
+}
+
+func UnwrapAddressCanonical(data []byte) (hash.Address, error) {
+	return UnwrapAddress(reseal(KindAddress, data))
+}
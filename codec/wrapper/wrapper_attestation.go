@@ -20,7 +20,7 @@ func WrapAttestation(a block.Attestation) ([]byte, error) {
 		return nil, err
 	}
 
-	return append([]byte{prefix}, aData...), nil
+	return seal(KindAttestation, prefix, aData), nil
 }
 
 func WrapAttestationToString(a block.Attestation) (string, error) {
@@ -32,13 +32,11 @@ func WrapAttestationToString(a block.Attestation) (string, error) {
 }
 
 func UnwrapAttestation(data []byte) (block.Attestation, error) {
-	if len(data) < 1 {
-		return nil, fmt.Errorf("attestation data is too short to contain a type prefix")
+	typePrefix, aData, err := open(KindAttestation, data)
+	if err != nil {
+		return nil, err
 	}
 
-	typePrefix := data[0]
-	aData := data[1:]
-
 	typeName, err := block.GetTypeFromAttestationPrefix(typePrefix)
 	if err != nil {
 		return nil, err
@@ -67,3 +65,11 @@ func UnwrapAttestationFromString(s string) (block.Attestation, error) {
 
 	return UnwrapAttestation(data)
 }
+
+func WrapAttestationCanonical(a block.Attestation) ([]byte, error) {
+	return canonical(WrapAttestation(a))
+}
+
+func UnwrapAttestationCanonical(data []byte) (block.Attestation, error) {
+	return UnwrapAttestation(reseal(KindAttestation, data))
+}
@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/registry"
+	"strings"
+)
+
+func WrapBlock(b block.Block) ([]byte, error) {
+	prefix, err := block.GetBlockPrefixFromType(b.Type())
+	if err != nil {
+		return nil, fmt.Errorf("configuration error for block<%s>: %w", b.Type(), err)
+	}
+
+	bData, err := codec.EncodeProto(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return seal(KindBlock, prefix, bData), nil
+}
+
+func WrapBlockToString(b block.Block) (string, error) {
+	wrappedBytes, err := WrapBlock(b)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(wrappedBytes), nil
+}
+
+func UnwrapBlock(data []byte) (block.Block, error) {
+	typePrefix, bData, err := open(KindBlock, data)
+	if err != nil {
+		return nil, err
+	}
+
+	typeName, err := block.GetTypeFromBlockPrefix(typePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	suite, err := registry.GetBlockSuite(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	blk := suite.NewBlock()
+	if err = codec.DecodeProto(bData, blk); err != nil {
+		return nil, err
+	}
+
+	return blk, nil
+}
+
+func UnwrapBlockFromString(s string) (block.Block, error) {
+	s = strings.TrimPrefix(s, "0x")
+
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block hex string: %w", err)
+	}
+
+	return UnwrapBlock(data)
+}
+
+func WrapBlockCanonical(b block.Block) ([]byte, error) {
+	return canonical(WrapBlock(b))
+}
+
+func UnwrapBlockCanonical(data []byte) (block.Block, error) {
+	return UnwrapBlock(reseal(KindBlock, data))
+}
@@ -20,7 +20,7 @@ func WrapBody(b block.Body) ([]byte, error) {
 		return nil, err
 	}
 
-	return append([]byte{prefix}, bodyData...), nil
+	return seal(KindBody, prefix, bodyData), nil
 }
 
 func WrapBodyToString(b block.Body) (string, error) {
@@ -32,13 +32,11 @@ func WrapBodyToString(b block.Body) (string, error) {
 }
 
 func UnwrapBody(data []byte) (block.Body, error) {
-	if len(data) < 1 {
-		return nil, fmt.Errorf("body data is too short to contain a type prefix")
+	typePrefix, bodyData, err := open(KindBody, data)
+	if err != nil {
+		return nil, err
 	}
 
-	typePrefix := data[0]
-	bodyData := data[1:]
-
 	typeName, err := block.GetTypeFromBodyPrefix(typePrefix)
 	if err != nil {
 		return nil, err
@@ -67,3 +65,11 @@ func UnwrapBodyFromString(s string) (block.Body, error) {
 
 	return UnwrapBody(data)
 }
+
+func WrapBodyCanonical(b block.Body) ([]byte, error) {
+	return canonical(WrapBody(b))
+}
+
+func UnwrapBodyCanonical(data []byte) (block.Body, error) {
+	return UnwrapBody(reseal(KindBody, data))
+}
@@ -0,0 +1,193 @@
+package wrapper
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Wrapped bytes are an envelope:
+//
+//	kind byte | version | type prefix | uvarint payload length | payload
+//
+// The kind byte carries EnvelopeMagic in its high nibble and the Kind in
+// the low one, so a blob says what it is even where the type prefixes of
+// two kinds collide (0x01 is both sha256 and ecdsa-secp256r1).
+//
+// Legacy blobs, written before the envelope, are the bare type prefix
+// followed by the payload. All type prefixes are far below EnvelopeMagic,
+// so the first byte tells the two apart.
+const (
+	EnvelopeMagic   byte = 0xa0
+	EnvelopeVersion byte = 1
+
+	envelopeMagicMask byte = 0xf0
+)
+
+type Kind byte
+
+const (
+	KindUnknown Kind = iota
+	KindHash
+	KindAddress
+	KindPublicKey
+	KindPrivateKey
+	KindSignature
+	KindTransaction
+	KindBlock
+	KindHeader
+	KindBody
+	KindTail
+	KindAttestation
+	KindEvidence
+)
+
+var kindNames = map[Kind]string{
+	KindUnknown:     "unknown",
+	KindHash:        "hash",
+	KindAddress:     "address",
+	KindPublicKey:   "public-key",
+	KindPrivateKey:  "private-key",
+	KindSignature:   "signature",
+	KindTransaction: "transaction",
+	KindBlock:       "block",
+	KindHeader:      "header",
+	KindBody:        "body",
+	KindTail:        "tail",
+	KindAttestation: "attestation",
+	KindEvidence:    "evidence",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("kind(%d)", byte(k))
+}
+
+var (
+	ErrMalformedEnvelope = errors.New("malformed envelope")
+	ErrEnvelopeVersion   = errors.New("unsupported envelope version")
+	ErrWrongKind         = errors.New("wrapped object is of another kind")
+	ErrLegacyDisabled    = errors.New("legacy wrapped bytes are not accepted")
+)
+
+var legacyDecoding atomic.Bool
+
+func init() {
+	legacyDecoding.Store(true)
+}
+
+// SetLegacyDecoding turns the compatibility mode, in which Unwrap* also
+// accept legacy blobs, on or off. It is on by default.
+func SetLegacyDecoding(enabled bool) {
+	legacyDecoding.Store(enabled)
+}
+
+func LegacyDecoding() bool {
+	return legacyDecoding.Load()
+}
+
+// Envelope is a parsed wrapped blob. Kind and Version are zero for legacy
+// blobs.
+type Envelope struct {
+	Kind    Kind
+	Version byte
+	Type    byte
+	Payload []byte
+}
+
+func (e Envelope) Legacy() bool {
+	return e.Version == 0
+}
+
+// ParseEnvelope splits data into its envelope fields without decoding the
+// payload. Legacy blobs are parsed whether or not legacy decoding is on.
+func ParseEnvelope(data []byte) (Envelope, error) {
+	if len(data) < 1 {
+		return Envelope{}, fmt.Errorf("%w: empty", ErrMalformedEnvelope)
+	}
+	if data[0]&envelopeMagicMask != EnvelopeMagic {
+		return Envelope{Type: data[0], Payload: data[1:]}, nil
+	}
+
+	if len(data) < 3 {
+		return Envelope{}, fmt.Errorf("%w: %d bytes", ErrMalformedEnvelope, len(data))
+	}
+	kind, version, prefix := Kind(data[0]&^envelopeMagicMask), data[1], data[2]
+	if version != EnvelopeVersion {
+		return Envelope{}, fmt.Errorf("%w: %d", ErrEnvelopeVersion, version)
+	}
+	length, n := binary.Uvarint(data[3:])
+	if n <= 0 {
+		return Envelope{}, fmt.Errorf("%w: bad payload length", ErrMalformedEnvelope)
+	}
+	payload := data[3+n:]
+	if uint64(len(payload)) != length {
+		return Envelope{}, fmt.Errorf("%w: payload of %d bytes, header says %d", ErrMalformedEnvelope, len(payload), length)
+	}
+	return Envelope{Kind: kind, Version: version, Type: prefix, Payload: payload}, nil
+}
+
+// Bytes encodes e in the current envelope version, whatever version it
+// was parsed from.
+func (e Envelope) Bytes() []byte {
+	return seal(e.Kind, e.Type, e.Payload)
+}
+
+func seal(kind Kind, prefix byte, payload []byte) []byte {
+	out := make([]byte, 0, 3+binary.MaxVarintLen64+len(payload))
+	out = append(out, EnvelopeMagic|byte(kind), EnvelopeVersion, prefix)
+	out = binary.AppendUvarint(out, uint64(len(payload)))
+	return append(out, payload...)
+}
+
+// Canonical bytes are the type prefix followed by the payload, the layout
+// of legacy blobs. Hash preimages, signing digests, state keys and the
+// fields of protobuf messages are built from them, so they stay the same
+// whatever the envelope version; the field a value sits in already says
+// its kind.
+func canonical(sealed []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	e, err := ParseEnvelope(sealed)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{e.Type}, e.Payload...), nil
+}
+
+// reseal turns canonical bytes into an envelope of the given kind, so they
+// decode whether or not legacy decoding is on. Envelopes are passed through
+// and checked by open.
+func reseal(kind Kind, data []byte) []byte {
+	e, err := ParseEnvelope(data)
+	if err != nil || !e.Legacy() {
+		return data
+	}
+	return seal(kind, e.Type, e.Payload)
+}
+
+// open returns the type prefix and payload of a blob of the given kind.
+func open(kind Kind, data []byte) (byte, []byte, error) {
+	if len(data) < 1 {
+		return 0, nil, fmt.Errorf("%s data is too short to contain a type prefix", kind)
+	}
+
+	e, err := ParseEnvelope(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	if e.Legacy() {
+		if !LegacyDecoding() {
+			return 0, nil, fmt.Errorf("%w: %s", ErrLegacyDisabled, kind)
+		}
+		return e.Type, e.Payload, nil
+	}
+	if e.Kind != kind {
+		return 0, nil, fmt.Errorf("%w: expected %s, got %s", ErrWrongKind, kind, e.Kind)
+	}
+	return e.Type, e.Payload, nil
+}
@@ -0,0 +1,101 @@
+package wrapper
+
+import (
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite(hash.Sha256Type)
+	require.NoError(t, err)
+	h := hashSuite.Deriver().Derive([]byte("kangaroo"))
+
+	wrapped, err := WrapHash(h)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{EnvelopeMagic | byte(KindHash), EnvelopeVersion, hash.Sha256HashPrefixByte, hash.HashLength}, wrapped[:4])
+	assert.Equal(t, h.Bytes(), wrapped[4:])
+
+	e, err := ParseEnvelope(wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, Envelope{Kind: KindHash, Version: EnvelopeVersion, Type: hash.Sha256HashPrefixByte, Payload: h.Bytes()}, e)
+	assert.False(t, e.Legacy())
+	assert.Equal(t, wrapped, e.Bytes())
+
+	got, err := UnwrapHash(wrapped)
+	require.NoError(t, err)
+	assert.True(t, h.Equal(got))
+
+	// 0x01 is sha256 among hashes and ecdsa-secp256r1 among keys, and both
+	// are 32 bytes: only the kind tells them apart.
+	assert.Equal(t, hash.Sha256HashPrefixByte, key.ECDSASecp256r1PrefixByte)
+	_, err = UnwrapPrivateKey(wrapped)
+	assert.ErrorIs(t, err, ErrWrongKind)
+
+	legacy := append([]byte{hash.Sha256HashPrefixByte}, h.Bytes()...)
+	e, err = ParseEnvelope(legacy)
+	require.NoError(t, err)
+	assert.True(t, e.Legacy())
+	got, err = UnwrapHash(legacy)
+	require.NoError(t, err)
+	assert.True(t, h.Equal(got))
+	legacyKey, err := UnwrapPrivateKey(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "ecdsa-secp256r1", legacyKey.Type())
+
+	// Sealing a legacy blob upgrades it.
+	e.Kind = KindHash
+	assert.Equal(t, wrapped, e.Bytes())
+
+	SetLegacyDecoding(false)
+	t.Cleanup(func() { SetLegacyDecoding(true) })
+	_, err = UnwrapHash(legacy)
+	assert.ErrorIs(t, err, ErrLegacyDisabled)
+	_, err = UnwrapHash(wrapped)
+	assert.NoError(t, err)
+}
+
+func TestEnvelope_Malformed(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	priv, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	wrapped, err := WrapPublicKey(priv.PublicKey())
+	require.NoError(t, err)
+	s, err := WrapPublicKeyToString(priv.PublicKey())
+	require.NoError(t, err)
+	got, err := UnwrapPublicKeyFromString(s)
+	require.NoError(t, err)
+	assert.True(t, priv.PublicKey().Equal(got))
+
+	otherVersion := append([]byte(nil), wrapped...)
+	otherVersion[1] = EnvelopeVersion + 1
+
+	for name, tt := range map[string]struct {
+		data []byte
+		err  error
+	}{
+		"truncated":     {wrapped[:len(wrapped)-1], ErrMalformedEnvelope},
+		"trailing":      {append(append([]byte(nil), wrapped...), 0), ErrMalformedEnvelope},
+		"no length":     {wrapped[:3], ErrMalformedEnvelope},
+		"header only":   {wrapped[:2], ErrMalformedEnvelope},
+		"bad varint":    {append(append([]byte(nil), wrapped[:3]...), 0x80), ErrMalformedEnvelope},
+		"other version": {otherVersion, ErrEnvelopeVersion},
+		"other kind":    {append([]byte{EnvelopeMagic | byte(KindSignature)}, wrapped[1:]...), ErrWrongKind},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := UnwrapPublicKey(tt.data)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	_, err = UnwrapPublicKey(nil)
+	assert.Error(t, err)
+	assert.Equal(t, "public-key", KindPublicKey.String())
+	assert.Equal(t, "kind(15)", Kind(15).String())
+}
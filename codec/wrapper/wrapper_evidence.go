@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/registry"
+	"strings"
+)
+
+func WrapEvidence(e block.Evidence) ([]byte, error) {
+	prefix, err := block.GetEvidencePrefixFromType(e.Type())
+	if err != nil {
+		return nil, fmt.Errorf("configuration error for evidence<%s>: %w", e.Type(), err)
+	}
+
+	eData, err := codec.EncodeProto(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return seal(KindEvidence, prefix, eData), nil
+}
+
+func WrapEvidenceToString(e block.Evidence) (string, error) {
+	wrappedEvidence, err := WrapEvidence(e)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(wrappedEvidence), nil
+}
+
+func UnwrapEvidence(data []byte) (block.Evidence, error) {
+	typePrefix, eData, err := open(KindEvidence, data)
+	if err != nil {
+		return nil, err
+	}
+
+	typeName, err := block.GetTypeFromEvidencePrefix(typePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	suite, err := registry.GetEvidenceSuite(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := suite.NewEvidence()
+	if err = codec.DecodeProto(eData, ev); err != nil {
+		return nil, err
+	}
+
+	return ev, nil
+}
+
+func UnwrapEvidenceFromString(s string) (block.Evidence, error) {
+	s = strings.TrimPrefix(s, "0x")
+
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid evidence hex string: %w", err)
+	}
+
+	return UnwrapEvidence(data)
+}
+
+func WrapEvidenceCanonical(e block.Evidence) ([]byte, error) {
+	return canonical(WrapEvidence(e))
+}
+
+func UnwrapEvidenceCanonical(data []byte) (block.Evidence, error) {
+	return UnwrapEvidence(reseal(KindEvidence, data))
+}
@@ -13,7 +13,7 @@ func WrapHash(h hash.Hash) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("configuration error for hash<%s>: %w", h.Type(), err)
 	}
-	return append([]byte{prefix}, h.Bytes()...), nil
+	return seal(KindHash, prefix, h.Bytes()), nil
 }
 
 func WrapHashToString(a hash.Hash) (string, error) {
@@ -25,13 +25,11 @@ func WrapHashToString(a hash.Hash) (string, error) {
 }
 
 func UnwrapHash(data []byte) (hash.Hash, error) {
-	if len(data) < 1 {
-		return nil, fmt.Errorf("hash data is too short to contain a type prefix")
+	typePrefix, hashData, err := open(KindHash, data)
+	if err != nil {
+		return nil, err
 	}
 
-	typePrefix := data[0]
-	hashData := data[1:]
-
 	typeName, err := hash.GetTypeFromHashPrefix(typePrefix)
 	if err != nil {
 		return nil, err
@@ -55,3 +53,11 @@ func UnwrapHashFromString(s string) (hash.Hash, error) {
 
 	return UnwrapHash(data)
 }
+
+func WrapHashCanonical(h hash.Hash) ([]byte, error) {
+	return canonical(WrapHash(h))
+}
+
+func UnwrapHashCanonical(data []byte) (hash.Hash, error) {
+	return UnwrapHash(reseal(KindHash, data))
+}
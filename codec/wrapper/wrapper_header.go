@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/registry"
+	"strings"
+)
+
+func WrapHeader(h block.Header) ([]byte, error) {
+	prefix, err := block.GetHeaderPrefixFromType(h.Type())
+	if err != nil {
+		return nil, fmt.Errorf("configuration error for header<%s>: %w", h.Type(), err)
+	}
+
+	hData, err := codec.EncodeProto(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return seal(KindHeader, prefix, hData), nil
+}
+
+func WrapHeaderToString(h block.Header) (string, error) {
+	wrappedBytes, err := WrapHeader(h)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(wrappedBytes), nil
+}
+
+func UnwrapHeader(data []byte) (block.Header, error) {
+	typePrefix, hData, err := open(KindHeader, data)
+	if err != nil {
+		return nil, err
+	}
+
+	typeName, err := block.GetTypeFromHeaderPrefix(typePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	suite, err := registry.GetHeaderSuite(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	header := suite.NewHeader()
+	if err = codec.DecodeProto(hData, header); err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+func UnwrapHeaderFromString(s string) (block.Header, error) {
+	s = strings.TrimPrefix(s, "0x")
+
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header hex string: %w", err)
+	}
+
+	return UnwrapHeader(data)
+}
+
+func WrapHeaderCanonical(h block.Header) ([]byte, error) {
+	return canonical(WrapHeader(h))
+}
+
+func UnwrapHeaderCanonical(data []byte) (block.Header, error) {
+	return UnwrapHeader(reseal(KindHeader, data))
+}
@@ -13,7 +13,7 @@ func WrapPrivateKey(k key.PrivateKey) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("configuration error for private-key<%s>: %w", k.Type(), err)
 	}
-	return append([]byte{prefix}, k.Bytes()...), nil
+	return seal(KindPrivateKey, prefix, k.Bytes()), nil
 }
 
 func WrapPrivateKeyToString(k key.PrivateKey) (string, error) {
@@ -25,13 +25,11 @@ func WrapPrivateKeyToString(k key.PrivateKey) (string, error) {
 }
 
 func UnwrapPrivateKey(data []byte) (key.PrivateKey, error) {
-	if len(data) < 1 {
-		return nil, fmt.Errorf("private key data is too short to contain a type prefix")
+	typePrefix, keyData, err := open(KindPrivateKey, data)
+	if err != nil {
+		return nil, err
 	}
 
-	typePrefix := data[0]
-	keyData := data[1:]
-
 	typeName, err := key.GetTypeFromKeyPrefix(typePrefix)
 	if err != nil {
 		return nil, err
@@ -13,7 +13,7 @@ func WrapPublicKey(k key.PublicKey) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("configuration error for public-key<%s>: %w", k.Type(), err)
 	}
-	return append([]byte{prefix}, k.Bytes()...), nil
+	return seal(KindPublicKey, prefix, k.Bytes()), nil
 }
 
 func WrapPublicKeyToString(k key.PublicKey) (string, error) {
@@ -25,13 +25,11 @@ func WrapPublicKeyToString(k key.PublicKey) (string, error) {
 }
 
 func UnwrapPublicKey(data []byte) (key.PublicKey, error) {
-	if len(data) < 1 {
-		return nil, fmt.Errorf("public key data is too short to contain a type prefix")
+	typePrefix, keyData, err := open(KindPublicKey, data)
+	if err != nil {
+		return nil, err
 	}
 
-	typePrefix := data[0]
-	keyData := data[1:]
-
 	typeName, err := key.GetTypeFromKeyPrefix(typePrefix)
 	if err != nil {
 		return nil, err
@@ -55,3 +53,11 @@ func UnwrapPublicKeyFromString(s string) (key.PublicKey, error) {
 
 	return UnwrapPublicKey(data)
 }
+
+func WrapPublicKeyCanonical(k key.PublicKey) ([]byte, error) {
+	return canonical(WrapPublicKey(k))
+}
+
+func UnwrapPublicKeyCanonical(data []byte) (key.PublicKey, error) {
+	return UnwrapPublicKey(reseal(KindPublicKey, data))
+}
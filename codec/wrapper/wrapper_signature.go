@@ -13,7 +13,7 @@ func WrapSignature(s key.Signature) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("configuration error for signature<%s>: %w", s.Type(), err)
 	}
-	return append([]byte{prefix}, s.Bytes()...), nil
+	return seal(KindSignature, prefix, s.Bytes()), nil
 }
 
 func WrapSignatureToString(k key.Signature) (string, error) {
@@ -25,13 +25,11 @@ func WrapSignatureToString(k key.Signature) (string, error) {
 }
 
 func UnwrapSignature(data []byte) (key.Signature, error) {
-	if len(data) < 1 {
-		return nil, fmt.Errorf("signature data is too short to contain a type prefix")
+	typePrefix, sigData, err := open(KindSignature, data)
+	if err != nil {
+		return nil, err
 	}
 
-	typePrefix := data[0]
-	sigData := data[1:]
-
 	typeName, err := key.GetTypeFromKeyPrefix(typePrefix)
 	if err != nil {
 		return nil, err
@@ -55,3 +53,11 @@ func UnwrapSignatureFromString(s string) (key.Signature, error) {
 
 	return UnwrapSignature(data)
 }
+
+func WrapSignatureCanonical(s key.Signature) ([]byte, error) {
+	return canonical(WrapSignature(s))
+}
+
+func UnwrapSignatureCanonical(data []byte) (key.Signature, error) {
+	return UnwrapSignature(reseal(KindSignature, data))
+}
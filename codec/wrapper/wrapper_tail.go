@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/registry"
+	"strings"
+)
+
+func WrapTail(t block.Tail) ([]byte, error) {
+	prefix, err := block.GetTailPrefixFromType(t.Type())
+	if err != nil {
+		return nil, fmt.Errorf("configuration error for tail<%s>: %w", t.Type(), err)
+	}
+
+	tData, err := codec.EncodeProto(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return seal(KindTail, prefix, tData), nil
+}
+
+func WrapTailToString(t block.Tail) (string, error) {
+	wrappedBytes, err := WrapTail(t)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(wrappedBytes), nil
+}
+
+func UnwrapTail(data []byte) (block.Tail, error) {
+	typePrefix, tData, err := open(KindTail, data)
+	if err != nil {
+		return nil, err
+	}
+
+	typeName, err := block.GetTypeFromTailPrefix(typePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	suite, err := registry.GetTailSuite(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	tail := suite.NewTail()
+	if err = codec.DecodeProto(tData, tail); err != nil {
+		return nil, err
+	}
+
+	return tail, nil
+}
+
+func UnwrapTailFromString(s string) (block.Tail, error) {
+	s = strings.TrimPrefix(s, "0x")
+
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tail hex string: %w", err)
+	}
+
+	return UnwrapTail(data)
+}
+
+func WrapTailCanonical(t block.Tail) ([]byte, error) {
+	return canonical(WrapTail(t))
+}
+
+func UnwrapTailCanonical(data []byte) (block.Tail, error) {
+	return UnwrapTail(reseal(KindTail, data))
+}
@@ -20,7 +20,7 @@ func WrapTransaction(tx transaction.Transaction) ([]byte, error) {
 		return nil, err
 	}
 
-	return append([]byte{prefix}, txData...), nil
+	return seal(KindTransaction, prefix, txData), nil
 }
 
 func WrapTransactionToString(tx transaction.Transaction) (string, error) {
@@ -32,13 +32,11 @@ func WrapTransactionToString(tx transaction.Transaction) (string, error) {
 }
 
 func UnwrapTransaction(data []byte) (transaction.Transaction, error) {
-	if len(data) < 1 {
-		return nil, fmt.Errorf("transaction data is too short to contain a type prefix")
+	typePrefix, txData, err := open(KindTransaction, data)
+	if err != nil {
+		return nil, err
 	}
 
-	typePrefix := data[0]
-	txData := data[1:]
-
 	typeName, err := transaction.GetTypeFromTransactionPrefix(typePrefix)
 	if err != nil {
 		return nil, err
@@ -67,3 +65,11 @@ func UnwrapTransactionFromString(s string) (transaction.Transaction, error) {
 
 	return UnwrapTransaction(data)
 }
+
+func WrapTransactionCanonical(tx transaction.Transaction) ([]byte, error) {
+	return canonical(WrapTransaction(tx))
+}
+
+func UnwrapTransactionCanonical(data []byte) (transaction.Transaction, error) {
+	return UnwrapTransaction(reseal(KindTransaction, data))
+}
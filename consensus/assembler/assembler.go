@@ -0,0 +1,96 @@
+package assembler
+
+import (
+	"errors"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangaroobody"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	"github.com/andantan/kangaroo/core/block/kangarootail"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/state"
+)
+
+const DefaultMaxWeight = 1000
+
+// Assembler builds candidate blocks on top of the chain head from the mempool.
+// The returned blocks carry an empty tail; sealing them is up to the engine.
+type Assembler struct {
+	chain     *chain.Chain
+	pool      *mempool.Mempool
+	maxWeight uint64
+}
+
+func NewAssembler(c *chain.Chain, pool *mempool.Mempool, maxWeight uint64) *Assembler {
+	if maxWeight == 0 {
+		maxWeight = DefaultMaxWeight
+	}
+	return &Assembler{
+		chain:     c,
+		pool:      pool,
+		maxWeight: maxWeight,
+	}
+}
+
+// Assemble executes pending transactions on a copy of the head state and
+// returns the resulting block. The timestamp is bumped past the parent's if needed.
+func (a *Assembler) Assemble(proposer key.PublicKey, timestamp int64, evidence []block.Evidence) (*kangarooblock.KangarooBlock, error) {
+	executor := a.chain.Executor()
+	deriver := executor.HashDeriver()
+	parent := a.chain.Head().GetHeader()
+
+	st := a.chain.State()
+	txs := a.selectTransactions(executor, st)
+
+	body := kangaroobody.NewKangarooBodyWithEvidence(txs, evidence)
+	bodyHash, err := body.Hash(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	stateRoot, err := st.Root(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	parentID, err := parent.Hash(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	if timestamp <= parent.GetTimestamp() {
+		timestamp = parent.GetTimestamp() + 1
+	}
+
+	header := kangarooheader.NewKangarooHeader(
+		parent.GetHeight()+1, timestamp, parentID, bodyHash, stateRoot, proposer)
+
+	return kangarooblock.NewKangarooBlock(header, body, kangarootail.NewKangarooTail(nil)), nil
+}
+
+// selectTransactions executes pending transactions against st until the weight
+// limit is reached. Transactions ahead of their sender's nonce stay in the pool
+// for a later block; every other failure is final and evicts the transaction.
+func (a *Assembler) selectTransactions(executor *state.Executor, st *state.StateDB) []transaction.Transaction {
+	selected := make([]transaction.Transaction, 0)
+
+	for _, tx := range a.pool.Pending() {
+		if uint64(len(selected)) >= a.maxWeight {
+			break
+		}
+
+		if err := executor.ApplyTransaction(st, tx); err != nil {
+			if !errors.Is(err, state.ErrNonceTooHigh) {
+				a.pool.RemoveTransactions([]transaction.Transaction{tx})
+			}
+			continue
+		}
+
+		selected = append(selected, tx)
+	}
+
+	return selected
+}
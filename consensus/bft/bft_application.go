@@ -0,0 +1,75 @@
+package bft
+
+import (
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+)
+
+// Application is what the engine agrees on blocks for.
+type Application interface {
+	HashDeriver() hash.HashDeriver
+	// LastHeight is the height of the last committed block.
+	LastHeight() uint64
+	LastBlockID() (hash.Hash, error)
+	ProposeBlock(proposer key.PublicKey, timestamp int64, evidence []block.Evidence) (block.Block, error)
+	// ValidateBlock checks a proposed block, which carries no commit yet.
+	ValidateBlock(blk block.Block) error
+	// Commit imports a decided block together with its commit in the tail.
+	Commit(blk block.Block) error
+}
+
+type ChainApplication struct {
+	chain     *chain.Chain
+	pool      *mempool.Mempool
+	assembler *assembler.Assembler
+}
+
+var _ Application = (*ChainApplication)(nil)
+
+func NewChainApplication(c *chain.Chain, pool *mempool.Mempool, maxWeight uint64) *ChainApplication {
+	return &ChainApplication{
+		chain:     c,
+		pool:      pool,
+		assembler: assembler.NewAssembler(c, pool, maxWeight),
+	}
+}
+
+func (a *ChainApplication) HashDeriver() hash.HashDeriver {
+	return a.chain.Executor().HashDeriver()
+}
+
+func (a *ChainApplication) LastHeight() uint64 {
+	return a.chain.Height()
+}
+
+func (a *ChainApplication) LastBlockID() (hash.Hash, error) {
+	return a.chain.Head().Hash(a.HashDeriver())
+}
+
+func (a *ChainApplication) ProposeBlock(proposer key.PublicKey, timestamp int64, evidence []block.Evidence) (block.Block, error) {
+	return a.assembler.Assemble(proposer, timestamp, evidence)
+}
+
+func (a *ChainApplication) ValidateBlock(blk block.Block) error {
+	return a.chain.ValidateProposal(blk)
+}
+
+// Commit imports blk unless the chain has it already, which happens when
+// gossip or block sync delivered another validator's commit first.
+func (a *ChainApplication) Commit(blk block.Block) error {
+	id, err := blk.Hash(a.HashDeriver())
+	if err != nil {
+		return err
+	}
+	if !a.chain.HasBlock(id) {
+		if err := a.chain.AddBlock(blk); err != nil {
+			return err
+		}
+	}
+	a.pool.RemoveTransactions(blk.GetBody().GetTransactions())
+	return nil
+}
@@ -0,0 +1,23 @@
+package bft
+
+import "time"
+
+// Clock is the engine's only source of time, so simulations can run the engine
+// on virtual time.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f once d has elapsed. f may run on another goroutine.
+	AfterFunc(d time.Duration, f func())
+}
+
+type SystemClock struct{}
+
+var _ Clock = (*SystemClock)(nil)
+
+func (c *SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *SystemClock) AfterFunc(d time.Duration, f func()) {
+	time.AfterFunc(d, f)
+}
@@ -0,0 +1,75 @@
+package bft
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/consensus/validator"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+)
+
+// CommitValidator accepts blocks whose tail holds precommits from more than two
+// thirds of the validator set, cast in the round recorded by the tail.
+type CommitValidator struct {
+	valSet  *validator.ValidatorSet
+	deriver hash.HashDeriver
+}
+
+func NewCommitValidator(valSet *validator.ValidatorSet, deriver hash.HashDeriver) *CommitValidator {
+	return &CommitValidator{
+		valSet:  valSet,
+		deriver: deriver,
+	}
+}
+
+func (v *CommitValidator) ValidateBlock(_ block.Header, blk block.Block) error {
+	header := blk.GetHeader()
+	if !v.valSet.Has(header.GetProposer()) {
+		return errors.New("block is not proposed by a validator")
+	}
+
+	tail := blk.GetTail()
+	if tail == nil {
+		return errors.New("block carries no commit")
+	}
+
+	id, err := blk.Hash(v.deriver)
+	if err != nil {
+		return err
+	}
+
+	digest, err := VoteDigest(v.deriver, PrecommitType, header.GetHeight(), tail.GetRound(), id)
+	if err != nil {
+		return err
+	}
+
+	var (
+		power  uint64
+		signed = make(map[int]struct{})
+	)
+
+	for i, att := range tail.GetAttestations() {
+		idx, val := v.valSet.GetByPublicKey(att.GetSigner())
+		if val == nil {
+			return fmt.Errorf("commit attestation %d is not from a validator", i)
+		}
+		if _, dup := signed[idx]; dup {
+			return fmt.Errorf("duplicate commit attestation from %s", val.PublicKey.ShortString(8))
+		}
+		if !att.GetBlockID().Equal(digest) || !signedAt(att, header.GetHeight(), tail.GetRound(), stepPrecommit) {
+			return fmt.Errorf("commit attestation %d is not a precommit for this block", i)
+		}
+		if !att.Verify() {
+			return fmt.Errorf("invalid commit attestation %d", i)
+		}
+
+		signed[idx] = struct{}{}
+		power += val.Power
+	}
+
+	if !v.valSet.HasQuorum(power) {
+		return fmt.Errorf("commit has %d of %d voting power", power, v.valSet.TotalPower())
+	}
+
+	return nil
+}
@@ -0,0 +1,734 @@
+package bft
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/consensus/validator"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangarooevidence"
+	"github.com/andantan/kangaroo/core/block/kangarootail"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Step uint8
+
+const (
+	// StepNewHeight waits out TimeoutCommit after a decision before round 0 starts.
+	StepNewHeight Step = iota
+	StepPropose
+	StepPrevote
+	StepPrecommit
+)
+
+func (s Step) String() string {
+	switch s {
+	case StepNewHeight:
+		return "NewHeight"
+	case StepPropose:
+		return "Propose"
+	case StepPrevote:
+		return "Prevote"
+	case StepPrecommit:
+		return "Precommit"
+	default:
+		return fmt.Sprintf("Step(%d)", uint8(s))
+	}
+}
+
+const (
+	// maxFutureHeights bounds how far ahead of the local height messages are buffered.
+	maxFutureHeights = 16
+	// maxProposalsPerRound bounds the conflicting proposals kept from an equivocating proposer.
+	maxProposalsPerRound = 8
+)
+
+type Config struct {
+	TimeoutPropose   time.Duration
+	TimeoutPrevote   time.Duration
+	TimeoutPrecommit time.Duration
+	// TimeoutDelta is added to the propose, prevote and precommit timeouts for every round.
+	TimeoutDelta  time.Duration
+	TimeoutCommit time.Duration
+	// ProposerSelector defaults to weighted round robin. It is ignored when
+	// Election is set.
+	ProposerSelector validator.ProposerSelector
+	Election         validator.ProposerElection
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TimeoutPropose:   3 * time.Second,
+		TimeoutPrevote:   time.Second,
+		TimeoutPrecommit: time.Second,
+		TimeoutDelta:     500 * time.Millisecond,
+		TimeoutCommit:    time.Second,
+	}
+}
+
+// Engine is a Tendermint style BFT consensus engine. For every height it runs
+// rounds of propose, prevote and precommit until +2/3 of the voting power
+// precommits the same block; validators lock on a block once they see +2/3
+// prevotes for it and only unlock for a newer polka.
+//
+// The engine owns no goroutines: it reacts to HandleMessage and to timeouts
+// scheduled on its Clock, which makes it deterministic under a simulated clock
+// and transport.
+type Engine struct {
+	lock sync.Mutex
+
+	cfg       Config
+	valSet    *validator.ValidatorSet
+	election  validator.ProposerElection
+	signer    Signer
+	app       Application
+	transport Transport
+	clock     Clock
+	deriver   hash.HashDeriver
+
+	started bool
+	stopped bool
+	seed    []byte
+	height  uint64
+	round   uint64
+	step    Step
+
+	lockedBlock block.Block
+	lockedRound int64
+	validBlock  block.Block
+	validRound  int64
+
+	proposals  map[uint64][]*Proposal
+	blocks     map[string]block.Block
+	validity   map[string]error
+	prevotes   map[uint64]*voteSet
+	precommits map[uint64]*voteSet
+	senders    map[uint64]map[int]struct{}
+	// sent holds the proposals and votes of this height signed here, which
+	// Rebroadcast sends again.
+	sent []Message
+
+	// per round "for the first time" guards
+	prevoteWaitScheduled   bool
+	precommitWaitScheduled bool
+	polkaSeen              bool
+
+	queue  []Message
+	future []Message
+
+	prevoteDetector   *kangarooevidence.EquivocationDetector
+	precommitDetector *kangarooevidence.EquivocationDetector
+}
+
+func NewEngine(cfg Config, valSet *validator.ValidatorSet, signer Signer, app Application, transport Transport, clock Clock) (*Engine, error) {
+	if valSet == nil || signer == nil || app == nil || transport == nil || clock == nil {
+		return nil, errors.New("engine dependencies cannot be nil")
+	}
+	if cfg.ProposerSelector == nil {
+		cfg.ProposerSelector = validator.NewWeightedRoundRobinSelector()
+	}
+	if cfg.Election == nil {
+		cfg.Election = validator.NewSelectorElection(cfg.ProposerSelector, signer.PublicKey())
+	}
+
+	return &Engine{
+		cfg:               cfg,
+		valSet:            valSet,
+		election:          cfg.Election,
+		signer:            signer,
+		app:               app,
+		transport:         transport,
+		clock:             clock,
+		deriver:           app.HashDeriver(),
+		prevoteDetector:   kangarooevidence.NewEquivocationDetector(nil),
+		precommitDetector: kangarooevidence.NewEquivocationDetector(nil),
+	}, nil
+}
+
+// Start begins consensus on the height after the application's last block.
+func (e *Engine) Start() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.started || e.stopped {
+		return
+	}
+	e.started = true
+
+	e.enterHeight(e.app.LastHeight() + 1)
+	e.startRound(0)
+	e.process()
+}
+
+// Stop makes the engine ignore messages and pending timeouts for good.
+func (e *Engine) Stop() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.started = false
+	e.stopped = true
+}
+
+// SyncHeight moves the engine to the height after the application's last
+// block when that block was imported outside consensus, e.g. by block sync
+// after the validator fell behind. Otherwise it does nothing.
+func (e *Engine) SyncHeight() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	height := e.app.LastHeight() + 1
+	if !e.started || height <= e.height {
+		return
+	}
+
+	e.prevoteDetector.Prune(height - 1)
+	e.precommitDetector.Prune(height - 1)
+	e.enterHeight(height)
+	e.startRound(0)
+	e.process()
+}
+
+// Rebroadcast sends the proposals and votes signed at the current height
+// again. Messages are not retransmitted otherwise, so a validator that
+// connected after they were sent, or lost them, would wait for them forever.
+func (e *Engine) Rebroadcast() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if !e.started {
+		return
+	}
+	for _, msg := range e.sent {
+		e.transport.Broadcast(msg)
+	}
+}
+
+func (e *Engine) HandleMessage(msg Message) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if !e.started || msg == nil {
+		return
+	}
+
+	e.queue = append(e.queue, msg)
+	e.process()
+}
+
+// State returns the current height, round and step.
+func (e *Engine) State() (uint64, uint64, Step) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.height, e.round, e.step
+}
+
+// DrainEvidence returns the double votes observed so far and forgets them.
+func (e *Engine) DrainEvidence() []block.Evidence {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.drainEvidence()
+}
+
+func (e *Engine) drainEvidence() []block.Evidence {
+	return append(e.prevoteDetector.DrainEvidence(), e.precommitDetector.DrainEvidence()...)
+}
+
+func (e *Engine) enterHeight(height uint64) {
+	e.seed = nil
+	if parentID, err := e.app.LastBlockID(); err == nil {
+		e.seed = parentID.Bytes()
+	} else {
+		log.Printf("[BFT] failed to read parent block id: %v", err)
+	}
+
+	e.height = height
+	e.round = 0
+	e.step = StepNewHeight
+
+	e.lockedBlock, e.lockedRound = nil, -1
+	e.validBlock, e.validRound = nil, -1
+
+	e.proposals = make(map[uint64][]*Proposal)
+	e.blocks = make(map[string]block.Block)
+	e.validity = make(map[string]error)
+	e.prevotes = make(map[uint64]*voteSet)
+	e.precommits = make(map[uint64]*voteSet)
+	e.senders = make(map[uint64]map[int]struct{})
+	e.sent = nil
+
+	future := e.future
+	e.future = nil
+	for _, msg := range future {
+		switch {
+		case msg.GetHeight() == height:
+			e.queue = append(e.queue, msg)
+		case msg.GetHeight() > height:
+			e.future = append(e.future, msg)
+		}
+	}
+}
+
+func (e *Engine) startRound(round uint64) {
+	e.round = round
+	e.step = StepPropose
+	e.prevoteWaitScheduled = false
+	e.precommitWaitScheduled = false
+	e.polkaSeen = false
+
+	proof, err := e.election.Prove(e.valSet, e.seed, e.height, round)
+	switch {
+	case err == nil:
+		e.propose(proof)
+	case !errors.Is(err, validator.ErrNotElected):
+		log.Printf("[BFT] failed to run proposer election at %d/%d: %v", e.height, round, err)
+	}
+
+	e.scheduleTimeout(StepPropose, e.cfg.TimeoutPropose)
+}
+
+func (e *Engine) propose(proof []byte) {
+	blk, polRound := e.validBlock, e.validRound
+	if blk == nil {
+		var err error
+		blk, err = e.app.ProposeBlock(e.signer.PublicKey(), e.clock.Now().UnixNano(), e.drainEvidence())
+		if err != nil {
+			log.Printf("[BFT] failed to build proposal for height %d: %v", e.height, err)
+			return
+		}
+		polRound = -1
+	}
+
+	blockID, err := blk.Hash(e.deriver)
+	if err != nil {
+		log.Printf("[BFT] failed to hash proposal: %v", err)
+		return
+	}
+
+	digest, err := ProposalDigest(e.deriver, e.height, e.round, polRound, blockID)
+	if err != nil {
+		log.Printf("[BFT] failed to digest proposal: %v", err)
+		return
+	}
+
+	att, err := e.signer.SignAttestation(e.height, e.round, stepPropose, digest)
+	if err != nil {
+		log.Printf("[BFT] refused to sign proposal at %d/%d: %v", e.height, e.round, err)
+		return
+	}
+
+	e.broadcast(&Proposal{
+		Height:        e.height,
+		Round:         e.round,
+		POLRound:      polRound,
+		Block:         blk,
+		Attestation:   att,
+		ProposerProof: proof,
+	})
+}
+
+func (e *Engine) vote(voteType VoteType, blockID hash.Hash) {
+	digest, err := VoteDigest(e.deriver, voteType, e.height, e.round, blockID)
+	if err != nil {
+		log.Printf("[BFT] failed to digest %s: %v", voteType, err)
+		return
+	}
+
+	att, err := e.signer.SignAttestation(e.height, e.round, voteType.step(), digest)
+	if err != nil {
+		log.Printf("[BFT] refused to sign %s at %d/%d: %v", voteType, e.height, e.round, err)
+		return
+	}
+
+	e.broadcast(&Vote{
+		Type:        voteType,
+		Height:      e.height,
+		Round:       e.round,
+		BlockID:     blockID,
+		Attestation: att,
+	})
+}
+
+// broadcast sends msg to the peers and queues it for local processing.
+func (e *Engine) broadcast(msg Message) {
+	e.sent = append(e.sent, msg)
+	e.transport.Broadcast(msg)
+	e.queue = append(e.queue, msg)
+}
+
+func (e *Engine) scheduleTimeout(step Step, base time.Duration) {
+	height, round := e.height, e.round
+	d := base + time.Duration(round)*e.cfg.TimeoutDelta
+
+	e.clock.AfterFunc(d, func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+
+		if !e.started || e.height != height || e.round != round {
+			return
+		}
+
+		switch step {
+		case StepPropose:
+			if e.step == StepPropose {
+				e.step = StepPrevote
+				e.vote(PrevoteType, nil)
+			}
+		case StepPrevote:
+			if e.step == StepPrevote {
+				e.step = StepPrecommit
+				e.vote(PrecommitType, nil)
+			}
+		case StepPrecommit:
+			e.startRound(round + 1)
+		}
+
+		e.process()
+	})
+}
+
+// process drains the message queue, re-evaluating the state machine after
+// every message until nothing more fires.
+func (e *Engine) process() {
+	for {
+		for e.applyRules() {
+		}
+
+		if len(e.queue) == 0 {
+			return
+		}
+
+		msg := e.queue[0]
+		e.queue = e.queue[1:]
+		e.receive(msg)
+	}
+}
+
+func (e *Engine) receive(msg Message) {
+	switch {
+	case msg.GetHeight() < e.height:
+		return
+	case msg.GetHeight() > e.height:
+		if msg.GetHeight() <= e.height+maxFutureHeights {
+			e.future = append(e.future, msg)
+		}
+		return
+	}
+
+	att := msg.GetAttestation()
+	if att == nil {
+		return
+	}
+
+	idx, _ := e.valSet.GetByPublicKey(att.GetSigner())
+	if idx < 0 {
+		return
+	}
+
+	var err error
+	switch m := msg.(type) {
+	case *Proposal:
+		err = e.receiveProposal(m)
+	case *Vote:
+		err = e.receiveVote(idx, m)
+	default:
+		err = fmt.Errorf("unknown message %T", msg)
+	}
+
+	if err != nil {
+		log.Printf("[BFT] rejected %s: %v", msg, err)
+		return
+	}
+
+	if _, ok := e.senders[msg.GetRound()]; !ok {
+		e.senders[msg.GetRound()] = make(map[int]struct{})
+	}
+	e.senders[msg.GetRound()][idx] = struct{}{}
+}
+
+func (e *Engine) receiveProposal(p *Proposal) error {
+	err := e.election.Verify(e.valSet, p.Attestation.GetSigner(), e.seed, p.Height, p.Round, p.ProposerProof)
+	if err != nil {
+		return err
+	}
+
+	if err := p.Verify(e.deriver); err != nil {
+		return err
+	}
+
+	blockID, err := p.Block.Hash(e.deriver)
+	if err != nil {
+		return err
+	}
+
+	key := blockKey(blockID)
+	for _, known := range e.proposals[p.Round] {
+		known, _ := known.Block.Hash(e.deriver)
+		if blockKey(known) == key {
+			return nil
+		}
+	}
+
+	if len(e.proposals[p.Round]) >= maxProposalsPerRound {
+		return errors.New("too many proposals for round")
+	}
+
+	e.proposals[p.Round] = append(e.proposals[p.Round], p)
+	e.blocks[key] = p.Block
+
+	// Messages from others are relayed once, so every honest validator learns
+	// every block and vote that might matter even when a Byzantine sender only
+	// talks to part of the network.
+	if !p.Attestation.GetSigner().Equal(e.signer.PublicKey()) {
+		e.transport.Broadcast(p)
+	}
+
+	return nil
+}
+
+func (e *Engine) receiveVote(idx int, v *Vote) error {
+	if err := v.Verify(e.deriver); err != nil {
+		return err
+	}
+
+	sets, detector := e.prevotes, e.prevoteDetector
+	if v.Type == PrecommitType {
+		sets, detector = e.precommits, e.precommitDetector
+	}
+
+	set, ok := sets[v.Round]
+	if !ok {
+		set = newVoteSet(e.valSet)
+		sets[v.Round] = set
+	}
+
+	added, conflicting := set.add(idx, v)
+	if conflicting != nil {
+		if _, err := detector.Observe(conflicting.Attestation); err != nil {
+			return err
+		}
+		if _, err := detector.Observe(v.Attestation); err != nil {
+			return err
+		}
+	}
+
+	if added && !v.Attestation.GetSigner().Equal(e.signer.PublicKey()) {
+		e.transport.Broadcast(v)
+	}
+
+	return nil
+}
+
+func (e *Engine) votes(sets map[uint64]*voteSet, round uint64) *voteSet {
+	if set, ok := sets[round]; ok {
+		return set
+	}
+	return newVoteSet(e.valSet)
+}
+
+func (e *Engine) valid(blk block.Block) bool {
+	id, err := blk.Hash(e.deriver)
+	if err != nil {
+		return false
+	}
+
+	key := blockKey(id)
+	if err, ok := e.validity[key]; ok {
+		return err == nil
+	}
+
+	err = e.app.ValidateBlock(blk)
+	if err == nil && !e.valSet.Has(blk.GetHeader().GetProposer()) {
+		err = errors.New("block is not proposed by a validator")
+	}
+	if err != nil {
+		log.Printf("[BFT] invalid block %s at height %d: %v", id.ShortString(8), e.height, err)
+	}
+
+	e.validity[key] = err
+	return err == nil
+}
+
+func (e *Engine) sameBlock(a, b block.Block) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	idA, errA := a.Hash(e.deriver)
+	idB, errB := b.Hash(e.deriver)
+	return errA == nil && errB == nil && idA.Equal(idB)
+}
+
+// applyRules fires at most one rule of the state machine and reports whether
+// it did.
+func (e *Engine) applyRules() bool {
+	if e.step == StepNewHeight {
+		return false
+	}
+
+	if e.tryCommit() {
+		return true
+	}
+
+	if e.trySkipRound() {
+		return true
+	}
+
+	round := e.round
+	prevotes := e.votes(e.prevotes, round)
+
+	if e.step == StepPropose && len(e.proposals[round]) > 0 {
+		p := e.proposals[round][0]
+
+		if p.POLRound == -1 {
+			e.prevoteFor(p.Block, e.lockedRound == -1 || e.sameBlock(e.lockedBlock, p.Block))
+			return true
+		}
+
+		id, _ := p.Block.Hash(e.deriver)
+		if e.votes(e.prevotes, uint64(p.POLRound)).hasQuorumFor(blockKey(id)) {
+			e.prevoteFor(p.Block, e.lockedRound <= p.POLRound || e.sameBlock(e.lockedBlock, p.Block))
+			return true
+		}
+	}
+
+	if e.step == StepPrevote && !e.prevoteWaitScheduled && prevotes.hasQuorumAny() {
+		e.prevoteWaitScheduled = true
+		e.scheduleTimeout(StepPrevote, e.cfg.TimeoutPrevote)
+		return true
+	}
+
+	if e.step >= StepPrevote && !e.polkaSeen {
+		for _, p := range e.proposals[round] {
+			id, _ := p.Block.Hash(e.deriver)
+			if !prevotes.hasQuorumFor(blockKey(id)) || !e.valid(p.Block) {
+				continue
+			}
+
+			e.polkaSeen = true
+			if e.step == StepPrevote {
+				e.lockedBlock, e.lockedRound = p.Block, int64(round)
+				e.step = StepPrecommit
+				e.vote(PrecommitType, id)
+			}
+			e.validBlock, e.validRound = p.Block, int64(round)
+			return true
+		}
+	}
+
+	if e.step == StepPrevote && prevotes.hasQuorumFor(blockKey(nil)) {
+		e.step = StepPrecommit
+		e.vote(PrecommitType, nil)
+		return true
+	}
+
+	if !e.precommitWaitScheduled && e.votes(e.precommits, round).hasQuorumAny() {
+		e.precommitWaitScheduled = true
+		e.scheduleTimeout(StepPrecommit, e.cfg.TimeoutPrecommit)
+		return true
+	}
+
+	return false
+}
+
+func (e *Engine) prevoteFor(blk block.Block, acceptable bool) {
+	e.step = StepPrevote
+
+	if acceptable && e.valid(blk) {
+		if id, err := blk.Hash(e.deriver); err == nil {
+			e.vote(PrevoteType, id)
+			return
+		}
+	}
+	e.vote(PrevoteType, nil)
+}
+
+// tryCommit decides on a block once +2/3 precommits for it are seen in any round.
+func (e *Engine) tryCommit() bool {
+	rounds := make([]uint64, 0, len(e.precommits))
+	for r := range e.precommits {
+		rounds = append(rounds, r)
+	}
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i] < rounds[j] })
+
+	for _, r := range rounds {
+		set := e.precommits[r]
+		for _, key := range set.quorumKeys() {
+			if key == blockKey(nil) {
+				continue
+			}
+
+			blk, ok := e.blocks[key]
+			if !ok || !e.valid(blk) {
+				continue
+			}
+
+			e.commit(r, blk, set.votesFor(key))
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e *Engine) commit(round uint64, blk block.Block, precommits []*Vote) {
+	atts := make([]block.Attestation, len(precommits))
+	for i, v := range precommits {
+		atts[i] = v.Attestation
+	}
+
+	committed := kangarooblock.NewKangarooBlock(
+		blk.GetHeader(), blk.GetBody(), kangarootail.NewKangarooTailWithRound(round, atts))
+
+	if err := e.app.Commit(committed); err != nil {
+		// The block gathered a valid commit; failing to import it means local
+		// state is broken, and voting on the next height would be meaningless.
+		log.Printf("[BFT] failed to commit block at height %d: %v", e.height, err)
+		e.step = StepNewHeight
+		return
+	}
+
+	height := e.height
+	e.prevoteDetector.Prune(height)
+	e.precommitDetector.Prune(height)
+
+	e.enterHeight(height + 1)
+	e.clock.AfterFunc(e.cfg.TimeoutCommit, func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+
+		if e.started && e.height == height+1 && e.step == StepNewHeight {
+			e.startRound(0)
+			e.process()
+		}
+	})
+}
+
+// trySkipRound jumps to a later round once more than a third of the voting
+// power is seen there, since at least one honest validator has moved on.
+func (e *Engine) trySkipRound() bool {
+	var target uint64
+	found := false
+
+	for r, senders := range e.senders {
+		if r <= e.round || (found && r <= target) {
+			continue
+		}
+
+		var power uint64
+		for idx := range senders {
+			power += e.valSet.GetByIndex(idx).Power
+		}
+
+		if e.valSet.HasOneThird(power) {
+			target, found = r, true
+		}
+	}
+
+	if found {
+		e.startRound(target)
+	}
+	return found
+}
@@ -0,0 +1,372 @@
+package bft
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	kangaroobftpb "github.com/andantan/kangaroo/proto/consensus/bft/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+type VoteType uint8
+
+const (
+	PrevoteType VoteType = iota + 1
+	PrecommitType
+)
+
+// Steps recorded in the slashing protection database. They follow the order
+// in which a validator signs within a round.
+const (
+	stepPropose uint8 = iota
+	stepPrevote
+	stepPrecommit
+)
+
+func (t VoteType) String() string {
+	switch t {
+	case PrevoteType:
+		return "Prevote"
+	case PrecommitType:
+		return "Precommit"
+	default:
+		return fmt.Sprintf("VoteType(%d)", uint8(t))
+	}
+}
+
+func (t VoteType) step() uint8 {
+	if t == PrecommitType {
+		return stepPrecommit
+	}
+	return stepPrevote
+}
+
+func signedAt(att block.Attestation, height, round uint64, step uint8) bool {
+	return att.GetHeight() == height && att.GetRound() == round && att.GetStep() == step
+}
+
+// Message is anything the engine exchanges with its peers.
+type Message interface {
+	codec.ProtoCodec
+	fmt.Stringer
+
+	GetHeight() uint64
+	GetRound() uint64
+	GetAttestation() block.Attestation
+}
+
+// Proposal carries the proposer's block for a round. POLRound is the round in
+// which the block gathered a polka (+2/3 prevotes) earlier, or -1. ProposerProof
+// shows the signer was elected to propose, when the election needs a proof.
+type Proposal struct {
+	Height        uint64
+	Round         uint64
+	POLRound      int64
+	Block         block.Block
+	Attestation   block.Attestation
+	ProposerProof []byte
+}
+
+// Vote is a prevote or precommit. A nil BlockID is a vote for nil.
+//
+// The attestation does not sign the block ID directly but a digest binding the
+// vote type, height and round too, so a prevote can never be replayed as a
+// precommit or moved to another round.
+type Vote struct {
+	Type        VoteType
+	Height      uint64
+	Round       uint64
+	BlockID     hash.Hash
+	Attestation block.Attestation
+}
+
+var (
+	_ Message = (*Proposal)(nil)
+	_ Message = (*Vote)(nil)
+)
+
+func ProposalDigest(deriver hash.HashDeriver, height, round uint64, polRound int64, blockID hash.Hash) (hash.Hash, error) {
+	idBytes, err := wrapper.WrapHashCanonical(blockID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := proto.Marshal(&kangaroobftpb.KangarooProposalData{
+		Height:   height,
+		Round:    round,
+		PolRound: polRound,
+		BlockId:  idBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal proposal data: %w", err)
+	}
+
+	return deriver.Derive(b), nil
+}
+
+func VoteDigest(deriver hash.HashDeriver, voteType VoteType, height, round uint64, blockID hash.Hash) (hash.Hash, error) {
+	var idBytes []byte
+	if blockID != nil {
+		var err error
+		if idBytes, err = wrapper.WrapHashCanonical(blockID); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := proto.Marshal(&kangaroobftpb.KangarooVoteData{
+		VoteType: uint32(voteType),
+		Height:   height,
+		Round:    round,
+		BlockId:  idBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vote data: %w", err)
+	}
+
+	return deriver.Derive(b), nil
+}
+
+func (p *Proposal) GetHeight() uint64 {
+	return p.Height
+}
+
+func (p *Proposal) GetRound() uint64 {
+	return p.Round
+}
+
+func (p *Proposal) GetAttestation() block.Attestation {
+	return p.Attestation
+}
+
+// Verify checks that the attestation signs this proposal. Whether the signer
+// is the expected proposer is up to the caller.
+func (p *Proposal) Verify(deriver hash.HashDeriver) error {
+	if p.Block == nil || p.Block.GetHeader() == nil || p.Attestation == nil {
+		return errors.New("incomplete proposal")
+	}
+
+	if p.Block.GetHeader().GetHeight() != p.Height {
+		return fmt.Errorf("proposal for height %d carries block at height %d",
+			p.Height, p.Block.GetHeader().GetHeight())
+	}
+
+	if p.POLRound < -1 || p.POLRound >= int64(p.Round) {
+		return fmt.Errorf("invalid pol round %d for round %d", p.POLRound, p.Round)
+	}
+
+	blockID, err := p.Block.Hash(deriver)
+	if err != nil {
+		return err
+	}
+
+	digest, err := ProposalDigest(deriver, p.Height, p.Round, p.POLRound, blockID)
+	if err != nil {
+		return err
+	}
+
+	if !digest.Equal(p.Attestation.GetBlockID()) {
+		return errors.New("attestation does not sign this proposal")
+	}
+
+	if !signedAt(p.Attestation, p.Height, p.Round, stepPropose) {
+		return errors.New("proposal attestation is signed at another height, round or step")
+	}
+
+	if !p.Attestation.Verify() {
+		return errors.New("invalid proposal signature")
+	}
+
+	return nil
+}
+
+func (p *Proposal) ToProto() (proto.Message, error) {
+	var (
+		err        error
+		blockBytes []byte
+		attBytes   []byte
+	)
+
+	if p.Block != nil {
+		if blockBytes, err = wrapper.WrapBlockCanonical(p.Block); err != nil {
+			return nil, fmt.Errorf("failed to wrap block: %w", err)
+		}
+	}
+
+	if p.Attestation != nil {
+		if attBytes, err = wrapper.WrapAttestationCanonical(p.Attestation); err != nil {
+			return nil, fmt.Errorf("failed to wrap attestation: %w", err)
+		}
+	}
+
+	return &kangaroobftpb.KangarooProposal{
+		Height:        p.Height,
+		Round:         p.Round,
+		PolRound:      p.POLRound,
+		Block:         blockBytes,
+		Attestation:   attBytes,
+		ProposerProof: p.ProposerProof,
+	}, nil
+}
+
+func (p *Proposal) FromProto(m proto.Message) error {
+	pb, ok := m.(*kangaroobftpb.KangarooProposal)
+	if !ok {
+		return errors.New("cannot deserialize protobuf KangarooProposal")
+	}
+
+	var (
+		err error
+		blk block.Block
+		att block.Attestation
+	)
+
+	if len(pb.Block) != 0 {
+		if blk, err = wrapper.UnwrapBlockCanonical(pb.Block); err != nil {
+			return fmt.Errorf("failed to unwrap block: %w", err)
+		}
+	}
+
+	if len(pb.Attestation) != 0 {
+		if att, err = wrapper.UnwrapAttestationCanonical(pb.Attestation); err != nil {
+			return fmt.Errorf("failed to unwrap attestation: %w", err)
+		}
+	}
+
+	p.Height = pb.Height
+	p.Round = pb.Round
+	p.POLRound = pb.PolRound
+	p.Block = blk
+	p.Attestation = att
+	p.ProposerProof = pb.ProposerProof
+	return nil
+}
+
+func (p *Proposal) NewProto() proto.Message {
+	return &kangaroobftpb.KangarooProposal{}
+}
+
+func (p *Proposal) String() string {
+	return fmt.Sprintf("Proposal{Height: %d, Round: %d, POLRound: %d}", p.Height, p.Round, p.POLRound)
+}
+
+func (v *Vote) GetHeight() uint64 {
+	return v.Height
+}
+
+func (v *Vote) GetRound() uint64 {
+	return v.Round
+}
+
+func (v *Vote) GetAttestation() block.Attestation {
+	return v.Attestation
+}
+
+func (v *Vote) IsNil() bool {
+	return v.BlockID == nil
+}
+
+// Verify checks that the attestation signs this vote. Whether the signer is a
+// validator is up to the caller.
+func (v *Vote) Verify(deriver hash.HashDeriver) error {
+	if v.Type != PrevoteType && v.Type != PrecommitType {
+		return fmt.Errorf("unknown vote type %d", v.Type)
+	}
+
+	if v.Attestation == nil {
+		return errors.New("unsigned vote")
+	}
+
+	digest, err := VoteDigest(deriver, v.Type, v.Height, v.Round, v.BlockID)
+	if err != nil {
+		return err
+	}
+
+	if !digest.Equal(v.Attestation.GetBlockID()) {
+		return errors.New("attestation does not sign this vote")
+	}
+
+	if !signedAt(v.Attestation, v.Height, v.Round, v.Type.step()) {
+		return errors.New("vote attestation is signed at another height, round or step")
+	}
+
+	if !v.Attestation.Verify() {
+		return errors.New("invalid vote signature")
+	}
+
+	return nil
+}
+
+func (v *Vote) ToProto() (proto.Message, error) {
+	var (
+		err      error
+		idBytes  []byte
+		attBytes []byte
+	)
+
+	if v.BlockID != nil {
+		if idBytes, err = wrapper.WrapHashCanonical(v.BlockID); err != nil {
+			return nil, fmt.Errorf("failed to wrap block id: %w", err)
+		}
+	}
+
+	if v.Attestation != nil {
+		if attBytes, err = wrapper.WrapAttestationCanonical(v.Attestation); err != nil {
+			return nil, fmt.Errorf("failed to wrap attestation: %w", err)
+		}
+	}
+
+	return &kangaroobftpb.KangarooVote{
+		VoteType:    uint32(v.Type),
+		Height:      v.Height,
+		Round:       v.Round,
+		BlockId:     idBytes,
+		Attestation: attBytes,
+	}, nil
+}
+
+func (v *Vote) FromProto(m proto.Message) error {
+	pb, ok := m.(*kangaroobftpb.KangarooVote)
+	if !ok {
+		return errors.New("cannot deserialize protobuf KangarooVote")
+	}
+
+	var (
+		err     error
+		blockID hash.Hash
+		att     block.Attestation
+	)
+
+	if len(pb.BlockId) != 0 {
+		if blockID, err = wrapper.UnwrapHashCanonical(pb.BlockId); err != nil {
+			return fmt.Errorf("failed to unwrap block id: %w", err)
+		}
+	}
+
+	if len(pb.Attestation) != 0 {
+		if att, err = wrapper.UnwrapAttestationCanonical(pb.Attestation); err != nil {
+			return fmt.Errorf("failed to unwrap attestation: %w", err)
+		}
+	}
+
+	v.Type = VoteType(pb.VoteType)
+	v.Height = pb.Height
+	v.Round = pb.Round
+	v.BlockID = blockID
+	v.Attestation = att
+	return nil
+}
+
+func (v *Vote) NewProto() proto.Message {
+	return &kangaroobftpb.KangarooVote{}
+}
+
+func (v *Vote) String() string {
+	target := "nil"
+	if v.BlockID != nil {
+		target = v.BlockID.ShortString(8)
+	}
+	return fmt.Sprintf("%s{Height: %d, Round: %d, BlockID: %s}", v.Type, v.Height, v.Round, target)
+}
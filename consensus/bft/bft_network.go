@@ -0,0 +1,85 @@
+package bft
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/p2p"
+	"log"
+	"sync"
+)
+
+// NetworkTransport carries engine messages over a p2p transport, so the
+// engine runs on the same network as gossip and sync. Messages are not
+// relayed: validators are expected to be connected to each other.
+type NetworkTransport struct {
+	transport p2p.Transport
+	onInvalid func(from p2p.PeerID, err error)
+
+	lock   sync.RWMutex
+	engine *Engine
+}
+
+var _ Transport = (*NetworkTransport)(nil)
+
+// NewNetworkTransport registers the handlers of the BFT message types on
+// transport. Messages received before Attach are dropped. onInvalid, if set,
+// is told about peers that sent undecodable messages.
+func NewNetworkTransport(transport p2p.Transport, onInvalid func(from p2p.PeerID, err error)) *NetworkTransport {
+	t := &NetworkTransport{
+		transport: transport,
+		onInvalid: onInvalid,
+	}
+
+	transport.Handle(p2p.MessageBFTProposal, t.handler(func() Message { return new(Proposal) }))
+	transport.Handle(p2p.MessageBFTVote, t.handler(func() Message { return new(Vote) }))
+
+	return t
+}
+
+// Attach names the engine received messages are handed to.
+func (t *NetworkTransport) Attach(e *Engine) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.engine = e
+}
+
+func (t *NetworkTransport) Broadcast(msg Message) {
+	var msgType p2p.MessageType
+	switch msg.(type) {
+	case *Proposal:
+		msgType = p2p.MessageBFTProposal
+	case *Vote:
+		msgType = p2p.MessageBFTVote
+	default:
+		log.Printf("[BFT] cannot send %T", msg)
+		return
+	}
+
+	b, err := codec.EncodeProto(msg)
+	if err != nil {
+		log.Printf("[BFT] failed to encode %s: %v", msg, err)
+		return
+	}
+
+	t.transport.Broadcast(p2p.Message{Type: msgType, Payload: b})
+}
+
+func (t *NetworkTransport) handler(newMessage func() Message) p2p.Handler {
+	return func(from p2p.PeerID, raw p2p.Message) {
+		msg := newMessage()
+		if err := codec.DecodeProto(raw.Payload, msg); err != nil {
+			if t.onInvalid != nil {
+				t.onInvalid(from, fmt.Errorf("undecodable %T: %w", msg, err))
+			}
+			return
+		}
+
+		t.lock.RLock()
+		e := t.engine
+		t.lock.RUnlock()
+
+		if e != nil {
+			e.HandleMessage(msg)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package bft
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/consensus/slashprotection"
+	"github.com/andantan/kangaroo/consensus/validator"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangaroobody"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	"github.com/andantan/kangaroo/core/block/kangarootail"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/memnet"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fixture struct {
+	deriver hash.HashDeriver
+	keys    []key.PrivateKey
+	valSet  *validator.ValidatorSet
+}
+
+func newFixture(t *testing.T, n int) *fixture {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256r1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("blake2b256")
+	require.NoError(t, err)
+
+	f := &fixture{deriver: hashSuite.Deriver()}
+	vals := make([]*validator.Validator, n)
+	for i := 0; i < n; i++ {
+		privKey, err := keySuite.GeneratePrivateKey()
+		require.NoError(t, err)
+		f.keys = append(f.keys, privKey)
+		vals[i] = validator.NewValidator(privKey.PublicKey(), 1)
+	}
+
+	f.valSet, err = validator.NewValidatorSet(vals)
+	require.NoError(t, err)
+	return f
+}
+
+func (f *fixture) sign(t *testing.T, signer key.PrivateKey, height, round uint64, step uint8, digest hash.Hash) block.Attestation {
+	att, err := kangarooattestation.Sign(signer, height, round, step, digest)
+	require.NoError(t, err)
+	return att
+}
+
+func (f *fixture) vote(t *testing.T, signer key.PrivateKey, voteType VoteType, height, round uint64, id hash.Hash) *Vote {
+	digest, err := VoteDigest(f.deriver, voteType, height, round, id)
+	require.NoError(t, err)
+	return &Vote{Type: voteType, Height: height, Round: round, BlockID: id, Attestation: f.sign(t, signer, height, round, voteType.step(), digest)}
+}
+
+func (f *fixture) block(t *testing.T, height uint64, proposer key.PublicKey) block.Block {
+	body := kangaroobody.NewKangarooBody(nil)
+	bodyHash, err := body.Hash(f.deriver)
+	require.NoError(t, err)
+	header := kangarooheader.NewKangarooHeader(height, 10, f.deriver.Derive([]byte("parent")),
+		bodyHash, f.deriver.Derive([]byte("state")), proposer)
+	return kangarooblock.NewKangarooBlock(header, body, kangarootail.NewKangarooTail(nil))
+}
+
+func TestMessage_Vote(t *testing.T) {
+	f := newFixture(t, 1)
+	id := f.deriver.Derive([]byte("block"))
+
+	for _, target := range []hash.Hash{id, nil} {
+		v := f.vote(t, f.keys[0], PrevoteType, 3, 1, target)
+		t.Logf("%s\n", v)
+		require.NoError(t, v.Verify(f.deriver))
+		assert.Equal(t, target == nil, v.IsNil())
+
+		encoded, err := codec.EncodeProto(v)
+		require.NoError(t, err)
+		decoded := new(Vote)
+		require.NoError(t, codec.DecodeProto(encoded, decoded))
+		require.NoError(t, decoded.Verify(f.deriver))
+		assert.Equal(t, v.Type, decoded.Type)
+		assert.Equal(t, v.IsNil(), decoded.IsNil())
+
+		// the signature is bound to type, height and round
+		replayed := *v
+		replayed.Type = PrecommitType
+		assert.Error(t, replayed.Verify(f.deriver))
+		replayed = *v
+		replayed.Round = 2
+		assert.Error(t, replayed.Verify(f.deriver))
+	}
+}
+
+func TestMessage_Proposal(t *testing.T) {
+	f := newFixture(t, 1)
+	blk := f.block(t, 5, f.keys[0].PublicKey())
+	id, err := blk.Hash(f.deriver)
+	require.NoError(t, err)
+
+	digest, err := ProposalDigest(f.deriver, 5, 2, 1, id)
+	require.NoError(t, err)
+	p := &Proposal{Height: 5, Round: 2, POLRound: 1, Block: blk, Attestation: f.sign(t, f.keys[0], 5, 2, stepPropose, digest)}
+	require.NoError(t, p.Verify(f.deriver))
+
+	encoded, err := codec.EncodeProto(p)
+	require.NoError(t, err)
+	decoded := new(Proposal)
+	require.NoError(t, codec.DecodeProto(encoded, decoded))
+	require.NoError(t, decoded.Verify(f.deriver))
+	assert.Equal(t, int64(1), decoded.POLRound)
+
+	tampered := *p
+	tampered.POLRound = -1
+	assert.Error(t, tampered.Verify(f.deriver))
+
+	tampered = *p
+	tampered.POLRound = 2
+	assert.Error(t, tampered.Verify(f.deriver), "pol round must precede the round")
+
+	tampered = *p
+	tampered.Height = 6
+	assert.Error(t, tampered.Verify(f.deriver))
+}
+
+func TestVoteSet(t *testing.T) {
+	f := newFixture(t, 4)
+	a := f.deriver.Derive([]byte("a"))
+	b := f.deriver.Derive([]byte("b"))
+
+	vs := newVoteSet(f.valSet)
+	idx := func(k key.PrivateKey) int {
+		i, _ := f.valSet.GetByPublicKey(k.PublicKey())
+		return i
+	}
+
+	for _, k := range f.keys[:2] {
+		added, conflicting := vs.add(idx(k), f.vote(t, k, PrevoteType, 1, 0, a))
+		assert.True(t, added)
+		assert.Nil(t, conflicting)
+	}
+
+	added, _ := vs.add(idx(f.keys[0]), f.vote(t, f.keys[0], PrevoteType, 1, 0, a))
+	assert.False(t, added, "duplicates are not counted twice")
+	assert.False(t, vs.hasQuorumFor(blockKey(a)))
+
+	vs.add(idx(f.keys[2]), f.vote(t, f.keys[2], PrevoteType, 1, 0, nil))
+	assert.True(t, vs.hasQuorumAny())
+	assert.Empty(t, vs.quorumKeys())
+
+	// the equivocator counts towards both blocks but only once towards the total
+	added, conflicting := vs.add(idx(f.keys[3]), f.vote(t, f.keys[3], PrevoteType, 1, 0, b))
+	assert.True(t, added)
+	assert.Nil(t, conflicting)
+	added, conflicting = vs.add(idx(f.keys[3]), f.vote(t, f.keys[3], PrevoteType, 1, 0, a))
+	assert.True(t, added)
+	require.NotNil(t, conflicting)
+	assert.True(t, conflicting.BlockID.Equal(b))
+
+	assert.True(t, vs.hasQuorumFor(blockKey(a)))
+	assert.Equal(t, []string{blockKey(a)}, vs.quorumKeys())
+	assert.Len(t, vs.votesFor(blockKey(a)), 3)
+	assert.Equal(t, uint64(4), vs.sum)
+}
+
+func TestCommitValidator(t *testing.T) {
+	f := newFixture(t, 4)
+	cv := NewCommitValidator(f.valSet, f.deriver)
+	blk := f.block(t, 7, f.keys[0].PublicKey())
+	id, err := blk.Hash(f.deriver)
+	require.NoError(t, err)
+
+	withCommit := func(round uint64, atts []block.Attestation) block.Block {
+		return kangarooblock.NewKangarooBlock(blk.GetHeader(), blk.GetBody(), kangarootail.NewKangarooTailWithRound(round, atts))
+	}
+
+	precommits := func(voteType VoteType, round uint64, signers []key.PrivateKey) []block.Attestation {
+		atts := make([]block.Attestation, len(signers))
+		for i, k := range signers {
+			atts[i] = f.vote(t, k, voteType, 7, round, id).Attestation
+		}
+		return atts
+	}
+
+	assert.NoError(t, cv.ValidateBlock(nil, withCommit(2, precommits(PrecommitType, 2, f.keys[:3]))))
+
+	assert.Error(t, cv.ValidateBlock(nil, withCommit(2, precommits(PrecommitType, 2, f.keys[:2]))), "not enough power")
+	assert.Error(t, cv.ValidateBlock(nil, withCommit(1, precommits(PrecommitType, 2, f.keys[:3]))), "wrong round")
+	assert.Error(t, cv.ValidateBlock(nil, withCommit(2, precommits(PrevoteType, 2, f.keys[:3]))), "prevotes are no commit")
+
+	dup := precommits(PrecommitType, 2, []key.PrivateKey{f.keys[0], f.keys[1], f.keys[1]})
+	assert.Error(t, cv.ValidateBlock(nil, withCommit(2, dup)), "duplicate signer")
+
+	outsider := newFixture(t, 1).keys[0]
+	foreign := precommits(PrecommitType, 2, []key.PrivateKey{f.keys[0], f.keys[1], outsider})
+	assert.Error(t, cv.ValidateBlock(nil, withCommit(2, foreign)), "signer outside the set")
+}
+
+func TestNetworkTransport_Memnet(t *testing.T) {
+	f := newFixture(t, 4)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	net := memnet.NewNetwork(memnet.Config{Seed: 1, Latency: memnet.UniformLatency{Max: 5 * time.Millisecond}})
+	t.Cleanup(net.Close)
+
+	cfg := Config{
+		TimeoutPropose:   200 * time.Millisecond,
+		TimeoutPrevote:   100 * time.Millisecond,
+		TimeoutPrecommit: 100 * time.Millisecond,
+		TimeoutDelta:     50 * time.Millisecond,
+		TimeoutCommit:    10 * time.Millisecond,
+	}
+
+	var (
+		chains  []*chain.Chain
+		engines []*Engine
+		invalid atomic.Int32
+	)
+	for i, privKey := range f.keys {
+		tr, err := net.Join(p2p.PeerID(fmt.Sprintf("validator-%d", i)))
+		require.NoError(t, err)
+
+		executor := state.NewExecutor(f.deriver, addressSuite.Deriver())
+		c, err := chain.NewChain(executor, &chain.Genesis{Timestamp: 1}, NewCommitValidator(f.valSet, f.deriver))
+		require.NoError(t, err)
+		chains = append(chains, c)
+
+		db, err := slashprotection.Open("")
+		require.NoError(t, err)
+		signer, err := slashprotection.NewProtectedSigner(privKey, db)
+		require.NoError(t, err)
+
+		transport := NewNetworkTransport(tr, func(p2p.PeerID, error) { invalid.Add(1) })
+		app := NewChainApplication(c, mempool.NewMempool(f.deriver, 0), 0)
+		e, err := NewEngine(cfg, f.valSet, signer, app, transport, &SystemClock{})
+		require.NoError(t, err)
+		transport.Attach(e)
+		engines = append(engines, e)
+	}
+
+	net.ConnectAll()
+	for _, e := range engines {
+		e.Start()
+	}
+
+	require.Eventually(t, func() bool {
+		for _, c := range chains {
+			if c.Height() < 3 {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second, 10*time.Millisecond)
+
+	for height := uint64(1); height <= 3; height++ {
+		want, err := chains[0].GetBlockByHeight(height)
+		require.NoError(t, err)
+		wantID, err := want.Hash(f.deriver)
+		require.NoError(t, err)
+		for _, c := range chains[1:] {
+			got, err := c.GetBlockByHeight(height)
+			require.NoError(t, err)
+			gotID, err := got.Hash(f.deriver)
+			require.NoError(t, err)
+			assert.True(t, wantID.Equal(gotID), "validators committed different blocks at height %d", height)
+		}
+	}
+
+	t.Run("undecodable messages are reported", func(t *testing.T) {
+		spammer, err := net.Join("spammer")
+		require.NoError(t, err)
+		require.NoError(t, net.Connect("spammer", "validator-0"))
+		require.NoError(t, spammer.Send("validator-0", p2p.Message{Type: p2p.MessageBFTVote, Payload: []byte{0xff, 0xff}}))
+		require.Eventually(t, func() bool { return invalid.Load() == 1 }, time.Second, time.Millisecond)
+	})
+}
+
+// manualClock runs timeouts only when fired.
+type manualClock struct {
+	lock    sync.Mutex
+	pending []func()
+}
+
+func (c *manualClock) Now() time.Time {
+	return time.Unix(100, 0)
+}
+
+func (c *manualClock) AfterFunc(_ time.Duration, f func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pending = append(c.pending, f)
+}
+
+func (c *manualClock) fire() {
+	c.lock.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.lock.Unlock()
+
+	for _, f := range pending {
+		f()
+	}
+}
+
+// recordTransport keeps what the engine broadcasts.
+type recordTransport struct {
+	sent []Message
+}
+
+func (t *recordTransport) Broadcast(msg Message) {
+	t.sent = append(t.sent, msg)
+}
+
+// newChainEngine runs the first validator of f on a chain of its own.
+func newChainEngine(t *testing.T, f *fixture, transport Transport, clock Clock) (*Engine, *chain.Chain, *ChainApplication) {
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	c, err := chain.NewChain(state.NewExecutor(f.deriver, addressSuite.Deriver()), &chain.Genesis{Timestamp: 1}, nil)
+	require.NoError(t, err)
+	db, err := slashprotection.Open("")
+	require.NoError(t, err)
+	signer, err := slashprotection.NewProtectedSigner(f.keys[0], db)
+	require.NoError(t, err)
+
+	app := NewChainApplication(c, mempool.NewMempool(f.deriver, 0), 0)
+	e, err := NewEngine(DefaultConfig(), f.valSet, signer, app, transport, clock)
+	require.NoError(t, err)
+	return e, c, app
+}
+
+func TestEngine_SyncHeightAndStop(t *testing.T) {
+	f := newFixture(t, 1)
+	clock := &manualClock{}
+	e, c, app := newChainEngine(t, f, &recordTransport{}, clock)
+
+	// a lone validator decides at once and waits out TimeoutCommit
+	e.Start()
+	require.Equal(t, uint64(1), c.Height())
+	height, _, step := e.State()
+	require.Equal(t, uint64(2), height)
+	require.Equal(t, StepNewHeight, step)
+
+	// blocks imported by sync move the engine past them, where it decides
+	// the next one
+	for i := 0; i < 2; i++ {
+		blk, err := app.ProposeBlock(f.keys[0].PublicKey(), int64(10+i), nil)
+		require.NoError(t, err)
+		require.NoError(t, c.AddBlock(blk))
+	}
+	e.SyncHeight()
+	assert.Equal(t, uint64(4), c.Height())
+	height, _, _ = e.State()
+	assert.Equal(t, uint64(5), height)
+
+	e.SyncHeight()
+	height, _, _ = e.State()
+	assert.Equal(t, uint64(5), height)
+
+	// nothing runs once stopped, not even timeouts scheduled before
+	e.Stop()
+	clock.fire()
+	e.SyncHeight()
+	e.Start()
+	assert.Equal(t, uint64(4), c.Height())
+	height, _, _ = e.State()
+	assert.Equal(t, uint64(5), height)
+}
+
+func TestEngine_Rebroadcast(t *testing.T) {
+	f := newFixture(t, 2)
+	transport := &recordTransport{}
+	clock := &manualClock{}
+	e, _, _ := newChainEngine(t, f, transport, clock)
+
+	// whether proposer or not, the validator prevotes once the proposal
+	// timeout passed, and waits for the other one
+	e.Start()
+	clock.fire()
+	sent := len(transport.sent)
+	require.NotZero(t, sent)
+
+	e.Rebroadcast()
+	require.Len(t, transport.sent, 2*sent)
+	assert.Equal(t, transport.sent[:sent], transport.sent[sent:])
+
+	e.Stop()
+	e.Rebroadcast()
+	assert.Len(t, transport.sent, 2*sent)
+}
@@ -0,0 +1,24 @@
+package bft
+
+import (
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+)
+
+// Transport delivers the engine's messages to the other validators. Messages
+// are self-authenticating, so the transport needs no notion of identity. Received
+// messages are handed to Engine.HandleMessage.
+//
+// Broadcast is called with the engine lock held and must not block or call back
+// into the engine synchronously.
+type Transport interface {
+	Broadcast(msg Message)
+}
+
+// Signer signs proposals and votes. slashprotection.ProtectedSigner is the
+// implementation validators are expected to use.
+type Signer interface {
+	PublicKey() key.PublicKey
+	SignAttestation(height, round uint64, step uint8, digest hash.Hash) (block.Attestation, error)
+}
@@ -0,0 +1,111 @@
+package bft
+
+import (
+	"github.com/andantan/kangaroo/consensus/validator"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"sort"
+)
+
+// maxTargetsPerValidator bounds how many different blocks an equivocating
+// validator can get counted for in one vote set.
+const maxTargetsPerValidator = 4
+
+func blockKey(id hash.Hash) string {
+	if id == nil {
+		return ""
+	}
+	return string(id.Bytes())
+}
+
+// voteSet tallies the votes of one type for one height and round.
+//
+// The total counts each validator once, by its first vote. The per block tally
+// also counts conflicting votes of an equivocating validator: different honest
+// nodes may have seen different versions first, and they must still be able to
+// agree that a block has +2/3. This stays safe because any two +2/3 quorums
+// share at least one honest validator, who only ever votes once.
+type voteSet struct {
+	valSet  *validator.ValidatorSet
+	first   map[int]*Vote
+	targets map[int]int
+	byBlock map[string]map[int]*Vote
+	power   map[string]uint64
+	sum     uint64
+}
+
+func newVoteSet(valSet *validator.ValidatorSet) *voteSet {
+	return &voteSet{
+		valSet:  valSet,
+		first:   make(map[int]*Vote),
+		targets: make(map[int]int),
+		byBlock: make(map[string]map[int]*Vote),
+		power:   make(map[string]uint64),
+	}
+}
+
+// add records the vote of validator idx. It reports whether the vote was new
+// and, when the validator already voted for something else, returns that
+// earlier vote as conflicting.
+func (s *voteSet) add(idx int, v *Vote) (added bool, conflicting *Vote) {
+	key := blockKey(v.BlockID)
+	if _, ok := s.byBlock[key][idx]; ok {
+		return false, nil
+	}
+
+	prev, voted := s.first[idx]
+	if voted && s.targets[idx] >= maxTargetsPerValidator {
+		return false, prev
+	}
+
+	if _, ok := s.byBlock[key]; !ok {
+		s.byBlock[key] = make(map[int]*Vote)
+	}
+
+	power := s.valSet.GetByIndex(idx).Power
+	s.byBlock[key][idx] = v
+	s.power[key] += power
+	s.targets[idx]++
+
+	if voted {
+		return true, prev
+	}
+
+	s.first[idx] = v
+	s.sum += power
+	return true, nil
+}
+
+func (s *voteSet) hasQuorumFor(key string) bool {
+	return s.valSet.HasQuorum(s.power[key])
+}
+
+func (s *voteSet) hasQuorumAny() bool {
+	return s.valSet.HasQuorum(s.sum)
+}
+
+// quorumKeys returns the block keys holding +2/3 of the power, in a stable order.
+func (s *voteSet) quorumKeys() []string {
+	keys := make([]string, 0, 1)
+	for k, p := range s.power {
+		if s.valSet.HasQuorum(p) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// votesFor returns the votes for key ordered by validator index.
+func (s *voteSet) votesFor(key string) []*Vote {
+	indices := make([]int, 0, len(s.byBlock[key]))
+	for idx := range s.byBlock[key] {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+
+	votes := make([]*Vote, len(indices))
+	for i, idx := range indices {
+		votes[i] = s.byBlock[key][idx]
+	}
+	return votes
+}
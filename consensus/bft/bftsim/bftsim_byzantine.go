@@ -0,0 +1,123 @@
+package bftsim
+
+import (
+	"github.com/andantan/kangaroo/consensus/bft"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+)
+
+// equivocatingTransport sends the engine's own messages to the lower half of the
+// network and a conflicting, validly signed version to the upper half. It signs
+// with the raw key, bypassing slashing protection on purpose.
+type equivocatingTransport struct {
+	simTransport
+	privKey key.PrivateKey
+	deriver hash.HashDeriver
+}
+
+func (t *equivocatingTransport) Broadcast(msg bft.Message) {
+	forged := t.forge(msg)
+	if forged == nil {
+		t.simTransport.Broadcast(msg)
+		return
+	}
+
+	half := len(t.net.nodes) / 2
+	for to := range t.net.nodes {
+		switch {
+		case to == t.from:
+		case to < half:
+			t.net.send(to, msg)
+		default:
+			t.net.send(to, forged)
+		}
+	}
+}
+
+func (t *equivocatingTransport) forge(msg bft.Message) bft.Message {
+	if !msg.GetAttestation().GetSigner().Equal(t.privKey.PublicKey()) {
+		return nil // relayed message from someone else
+	}
+
+	switch m := msg.(type) {
+	case *bft.Vote:
+		return t.forgeVote(m)
+	case *bft.Proposal:
+		return t.forgeProposal(m)
+	default:
+		return nil
+	}
+}
+
+func (t *equivocatingTransport) forgeVote(v *bft.Vote) bft.Message {
+	var target hash.Hash
+	if v.IsNil() {
+		target = t.deriver.Derive([]byte(v.String()))
+	}
+
+	digest, err := bft.VoteDigest(t.deriver, v.Type, v.Height, v.Round, target)
+	if err != nil {
+		return nil
+	}
+
+	att := t.sign(v.Attestation, digest)
+	if att == nil {
+		return nil
+	}
+
+	return &bft.Vote{
+		Type:        v.Type,
+		Height:      v.Height,
+		Round:       v.Round,
+		BlockID:     target,
+		Attestation: att,
+	}
+}
+
+func (t *equivocatingTransport) forgeProposal(p *bft.Proposal) bft.Message {
+	h, ok := p.Block.GetHeader().(*kangarooheader.KangarooHeader)
+	if !ok {
+		return nil
+	}
+
+	header := kangarooheader.NewKangarooHeader(
+		h.Height, h.Timestamp+1, h.PrevBlockID, h.BodyHash, h.StateRoot, h.Proposer)
+	blk := kangarooblock.NewKangarooBlock(header, p.Block.GetBody(), p.Block.GetTail())
+
+	blockID, err := blk.Hash(t.deriver)
+	if err != nil {
+		return nil
+	}
+
+	digest, err := bft.ProposalDigest(t.deriver, p.Height, p.Round, p.POLRound, blockID)
+	if err != nil {
+		return nil
+	}
+
+	att := t.sign(p.Attestation, digest)
+	if att == nil {
+		return nil
+	}
+
+	return &bft.Proposal{
+		Height:        p.Height,
+		Round:         p.Round,
+		POLRound:      p.POLRound,
+		Block:         blk,
+		Attestation:   att,
+		ProposerProof: p.ProposerProof,
+	}
+}
+
+// sign attests to digest at the height, round and step of the original.
+func (t *equivocatingTransport) sign(original block.Attestation, digest hash.Hash) block.Attestation {
+	att, err := kangarooattestation.Sign(t.privKey, original.GetHeight(), original.GetRound(), original.GetStep(), digest)
+	if err != nil {
+		return nil
+	}
+	return att
+}
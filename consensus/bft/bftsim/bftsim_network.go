@@ -0,0 +1,382 @@
+package bftsim
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/consensus/bft"
+	"github.com/andantan/kangaroo/consensus/slashprotection"
+	"github.com/andantan/kangaroo/consensus/validator"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"math/big"
+	"math/rand"
+	"time"
+)
+
+type Behavior uint8
+
+const (
+	Honest Behavior = iota
+	// Silent validators never start their engine, as if they had crashed.
+	Silent
+	// Equivocator validators send one version of each of their proposals and
+	// votes to half of the network and a conflicting version to the other half.
+	Equivocator
+)
+
+type Config struct {
+	// Behaviors holds one entry per validator; its length is the network size.
+	Behaviors  []Behavior
+	Seed       int64
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	Engine     bft.Config
+	// Election, when set, builds each validator's proposer election from its key
+	// and overrides Engine.Election.
+	Election    func(privKey key.PrivateKey) (validator.ProposerElection, error)
+	KeyType     string
+	HashType    string
+	AddressType string
+	// Alloc funds genesis accounts on every node.
+	Alloc []chain.GenesisAccount
+}
+
+type Node struct {
+	Index    int
+	Behavior Behavior
+	PrivKey  key.PrivateKey
+	Chain    *chain.Chain
+	Pool     *mempool.Mempool
+	Engine   *bft.Engine
+}
+
+// Network runs validators in a single goroutine on virtual time. Messages go
+// through the protobuf codec and arrive after a latency drawn from the seeded
+// RNG, so runs with the same seed deliver in the same order.
+type Network struct {
+	cfg     Config
+	rng     *rand.Rand
+	now     time.Time
+	events  eventQueue
+	seq     uint64
+	nodes   []*Node
+	valSet  *validator.ValidatorSet
+	deriver hash.HashDeriver
+	started bool
+}
+
+func NewNetwork(cfg Config) (*Network, error) {
+	if len(cfg.Behaviors) == 0 {
+		return nil, errors.New("network needs at least one validator")
+	}
+	if cfg.MaxLatency < cfg.MinLatency {
+		return nil, errors.New("max latency is below min latency")
+	}
+
+	keySuite, err := registry.GetKeySuite(cfg.KeyType)
+	if err != nil {
+		return nil, err
+	}
+	hashSuite, err := registry.GetHashSuite(cfg.HashType)
+	if err != nil {
+		return nil, err
+	}
+	addressSuite, err := registry.GetAddressSuite(cfg.AddressType)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Network{
+		cfg:     cfg,
+		rng:     rand.New(rand.NewSource(cfg.Seed)),
+		now:     time.Unix(0, 0),
+		deriver: hashSuite.Deriver(),
+	}
+
+	validators := make([]*validator.Validator, len(cfg.Behaviors))
+	for i, behavior := range cfg.Behaviors {
+		privKey, err := keySuite.GeneratePrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		n.nodes = append(n.nodes, &Node{Index: i, Behavior: behavior, PrivKey: privKey})
+		validators[i] = validator.NewValidator(privKey.PublicKey(), 1)
+	}
+
+	if n.valSet, err = validator.NewValidatorSet(validators); err != nil {
+		return nil, err
+	}
+
+	for _, node := range n.nodes {
+		if err = n.setupNode(node, hashSuite.Deriver(), addressSuite.Deriver()); err != nil {
+			return nil, fmt.Errorf("node %d: %w", node.Index, err)
+		}
+	}
+
+	return n, nil
+}
+
+func (n *Network) setupNode(node *Node, hashDeriver hash.HashDeriver, addressDeriver hash.AddressDeriver) error {
+	genesis := &chain.Genesis{Timestamp: n.now.UnixNano(), Alloc: n.cfg.Alloc}
+	executor := state.NewExecutor(hashDeriver, addressDeriver)
+
+	c, err := chain.NewChain(executor, genesis, bft.NewCommitValidator(n.valSet, hashDeriver))
+	if err != nil {
+		return err
+	}
+	node.Chain = c
+	node.Pool = mempool.NewMempool(hashDeriver, 10_000)
+
+	if node.Behavior == Silent {
+		return nil
+	}
+
+	db, err := slashprotection.Open("")
+	if err != nil {
+		return err
+	}
+	signer, err := slashprotection.NewProtectedSigner(node.PrivKey, db)
+	if err != nil {
+		return err
+	}
+
+	var transport bft.Transport = &simTransport{net: n, from: node.Index}
+	if node.Behavior == Equivocator {
+		transport = &equivocatingTransport{
+			simTransport: simTransport{net: n, from: node.Index},
+			privKey:      node.PrivKey,
+			deriver:      hashDeriver,
+		}
+	}
+
+	engineCfg := n.cfg.Engine
+	if n.cfg.Election != nil {
+		if engineCfg.Election, err = n.cfg.Election(node.PrivKey); err != nil {
+			return err
+		}
+	}
+
+	app := bft.NewChainApplication(c, node.Pool, 0)
+	node.Engine, err = bft.NewEngine(engineCfg, n.valSet, signer, app, transport, &simClock{net: n})
+	return err
+}
+
+func (n *Network) Nodes() []*Node {
+	return n.nodes
+}
+
+func (n *Network) Honest() []*Node {
+	honest := make([]*Node, 0, len(n.nodes))
+	for _, node := range n.nodes {
+		if node.Behavior == Honest {
+			honest = append(honest, node)
+		}
+	}
+	return honest
+}
+
+func (n *Network) ValidatorSet() *validator.ValidatorSet {
+	return n.valSet
+}
+
+func (n *Network) Now() time.Time {
+	return n.now
+}
+
+// SubmitTransaction adds tx to the mempool of every honest node.
+func (n *Network) SubmitTransaction(tx transaction.Transaction) error {
+	for _, node := range n.Honest() {
+		if _, err := node.Pool.Add(tx); err != nil {
+			return fmt.Errorf("node %d: %w", node.Index, err)
+		}
+	}
+	return nil
+}
+
+// Run advances virtual time until every honest node has committed height, or
+// fails once limit of virtual time has passed.
+func (n *Network) Run(height uint64, limit time.Duration) error {
+	if !n.started {
+		n.started = true
+		for _, node := range n.nodes {
+			if node.Engine != nil {
+				node.Engine.Start()
+			}
+		}
+	}
+
+	deadline := n.now.Add(limit)
+	for !n.reached(height) {
+		if n.events.Len() == 0 {
+			return errors.New("network stalled: no pending events")
+		}
+
+		ev := heap.Pop(&n.events).(*event)
+		if ev.at.After(deadline) {
+			heap.Push(&n.events, ev)
+			return fmt.Errorf("honest nodes did not reach height %d within %s", height, limit)
+		}
+
+		n.now = ev.at
+		ev.fn()
+	}
+
+	return nil
+}
+
+func (n *Network) reached(height uint64) bool {
+	for _, node := range n.Honest() {
+		if node.Chain.Height() < height {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckSafety verifies that no two honest nodes committed different blocks at
+// the same height.
+func (n *Network) CheckSafety() error {
+	honest := n.Honest()
+
+	for height := uint64(1); ; height++ {
+		var (
+			ref      hash.Hash
+			refIndex int
+			checked  bool
+		)
+
+		for _, node := range honest {
+			blk, err := node.Chain.GetBlockByHeight(height)
+			if err != nil {
+				continue
+			}
+
+			id, err := blk.Hash(n.deriver)
+			if err != nil {
+				return err
+			}
+
+			if !checked {
+				ref, refIndex, checked = id, node.Index, true
+				continue
+			}
+
+			if !ref.Equal(id) {
+				return fmt.Errorf("fork at height %d: node %d has %s, node %d has %s",
+					height, refIndex, ref.ShortString(8), node.Index, id.ShortString(8))
+			}
+		}
+
+		if !checked {
+			return nil
+		}
+	}
+}
+
+func (n *Network) schedule(d time.Duration, fn func()) {
+	n.seq++
+	heap.Push(&n.events, &event{at: n.now.Add(d), seq: n.seq, fn: fn})
+}
+
+func (n *Network) latency() time.Duration {
+	spread := int64(n.cfg.MaxLatency - n.cfg.MinLatency)
+	if spread <= 0 {
+		return n.cfg.MinLatency
+	}
+	return n.cfg.MinLatency + time.Duration(n.rng.Int63n(spread+1))
+}
+
+// send delivers a copy of msg to node to after a random latency.
+func (n *Network) send(to int, msg bft.Message) {
+	if n.nodes[to].Engine == nil {
+		return
+	}
+
+	b, err := codec.EncodeProto(msg)
+	if err != nil {
+		panic(fmt.Sprintf("bftsim: failed to encode %s: %v", msg, err))
+	}
+
+	n.schedule(n.latency(), func() {
+		var copied bft.Message
+		switch msg.(type) {
+		case *bft.Proposal:
+			copied = new(bft.Proposal)
+		default:
+			copied = new(bft.Vote)
+		}
+
+		if err := codec.DecodeProto(b, copied); err != nil {
+			panic(fmt.Sprintf("bftsim: failed to decode %s: %v", msg, err))
+		}
+		n.nodes[to].Engine.HandleMessage(copied)
+	})
+}
+
+// FundedAccount is a convenience for building Config.Alloc.
+func FundedAccount(privKey key.PrivateKey, deriver hash.AddressDeriver, balance int64) chain.GenesisAccount {
+	return chain.GenesisAccount{
+		Address: privKey.PublicKey().Address(deriver),
+		Balance: big.NewInt(balance),
+	}
+}
+
+type simClock struct {
+	net *Network
+}
+
+func (c *simClock) Now() time.Time {
+	return c.net.now
+}
+
+func (c *simClock) AfterFunc(d time.Duration, f func()) {
+	c.net.schedule(d, f)
+}
+
+type simTransport struct {
+	net  *Network
+	from int
+}
+
+func (t *simTransport) Broadcast(msg bft.Message) {
+	for to := range t.net.nodes {
+		if to != t.from {
+			t.net.send(to, msg)
+		}
+	}
+}
+
+type event struct {
+	at  time.Time
+	seq uint64
+	fn  func()
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x any) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() any {
+	old := *q
+	ev := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ev
+}
@@ -0,0 +1,131 @@
+package bftsim
+
+import (
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/consensus/bft"
+	"github.com/andantan/kangaroo/consensus/validator"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func testConfig(behaviors ...Behavior) Config {
+	return Config{
+		Behaviors:   behaviors,
+		Seed:        42,
+		MinLatency:  10 * time.Millisecond,
+		MaxLatency:  200 * time.Millisecond,
+		Engine:      bft.DefaultConfig(),
+		KeyType:     "ecdsa-secp256k1",
+		HashType:    "sha256",
+		AddressType: "keccak256",
+	}
+}
+
+func TestNetwork_Honest(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+
+	sender, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	recipient := addressSuite.Deriver().Derive([]byte("recipient"))
+
+	cfg := testConfig(Honest, Honest, Honest, Honest)
+	cfg.Alloc = []chain.GenesisAccount{FundedAccount(sender, addressSuite.Deriver(), 1000)}
+
+	net, err := NewNetwork(cfg)
+	require.NoError(t, err)
+
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		tx := kangarootransaction.NewKangarooTransaction(recipient, big.NewInt(100), nil, nonce)
+		require.NoError(t, tx.Sign(sender, hashSuite.Deriver()))
+		require.NoError(t, net.SubmitTransaction(tx))
+	}
+
+	require.NoError(t, net.Run(5, time.Minute))
+	require.NoError(t, net.CheckSafety())
+
+	for _, node := range net.Honest() {
+		assert.Equal(t, big.NewInt(300), node.Chain.State().GetBalance(recipient))
+		assert.Zero(t, node.Pool.Len())
+	}
+}
+
+func TestNetwork_Byzantine(t *testing.T) {
+	cases := map[string][]Behavior{
+		"4 validators, 1 silent":       {Honest, Silent, Honest, Honest},
+		"4 validators, 1 equivocator":  {Equivocator, Honest, Honest, Honest},
+		"7 validators, 2 silent":       {Honest, Silent, Honest, Honest, Silent, Honest, Honest},
+		"7 validators, 2 equivocators": {Equivocator, Honest, Honest, Equivocator, Honest, Honest, Honest},
+		"7 validators, mixed":          {Honest, Equivocator, Honest, Honest, Honest, Silent, Honest},
+	}
+
+	for name, behaviors := range cases {
+		t.Run(name, func(t *testing.T) {
+			for seed := int64(1); seed <= 2; seed++ {
+				cfg := testConfig(behaviors...)
+				cfg.Seed = seed
+
+				net, err := NewNetwork(cfg)
+				require.NoError(t, err)
+
+				// liveness: every honest node keeps committing
+				require.NoError(t, net.Run(6, 10*time.Minute), "seed %d", seed)
+				// safety: and they all committed the same blocks
+				require.NoError(t, net.CheckSafety(), "seed %d", seed)
+			}
+		})
+	}
+}
+
+func TestNetwork_Equivocation_Evidence(t *testing.T) {
+	net, err := NewNetwork(testConfig(Equivocator, Honest, Honest, Honest))
+	require.NoError(t, err)
+	require.NoError(t, net.Run(6, 10*time.Minute))
+	require.NoError(t, net.CheckSafety())
+
+	equivocator := net.Nodes()[0].PrivKey.PublicKey()
+
+	found := 0
+	for _, node := range net.Honest() {
+		for h := uint64(1); h <= node.Chain.Height(); h++ {
+			blk, err := node.Chain.GetBlockByHeight(h)
+			require.NoError(t, err)
+			for _, ev := range blk.GetBody().GetEvidence() {
+				assert.True(t, ev.Verify())
+				assert.True(t, ev.GetSigner().Equal(equivocator))
+				found++
+			}
+		}
+		for _, ev := range node.Engine.DrainEvidence() {
+			assert.True(t, ev.GetSigner().Equal(equivocator))
+			found++
+		}
+	}
+
+	assert.Positive(t, found, "double votes of the equivocator should be caught")
+}
+
+func TestNetwork_VRFElection(t *testing.T) {
+	cfg := testConfig(Honest, Honest, Honest, Equivocator)
+	cfg.KeyType = "schnorr-sr25519"
+	cfg.Election = func(privKey key.PrivateKey) (validator.ProposerElection, error) {
+		return validator.NewVRFElection(privKey, validator.DefaultExpectedProposers)
+	}
+
+	net, err := NewNetwork(cfg)
+	require.NoError(t, err)
+	require.NoError(t, net.Run(5, 30*time.Minute))
+	require.NoError(t, net.CheckSafety())
+}
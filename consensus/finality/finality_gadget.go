@@ -0,0 +1,372 @@
+package finality
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/consensus/validator"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"log"
+	"sync"
+)
+
+const DefaultEpochLength = 32
+
+// checkpoint votes are recorded in the slashing protection database at
+// (target epoch, 0, voteStep)
+const voteStep uint8 = 0
+
+var (
+	ErrNotValidator   = errors.New("signer is not a validator")
+	ErrSlashableVote  = errors.New("slashable checkpoint vote")
+	ErrBadCheckpoint  = errors.New("checkpoint is not at an epoch boundary")
+	errNothingToVote  = errors.New("nothing to vote for")
+	errAlreadyCounted = errors.New("vote already counted")
+	errStaleVote      = errors.New("vote source precedes the finalized checkpoint")
+)
+
+type Config struct {
+	EpochLength uint64
+}
+
+// Signer signs checkpoint votes. It should be a slashprotection.ProtectedSigner
+// backed by a database of its own: checkpoint votes are recorded by epoch,
+// which would clash with the heights recorded by a block producer.
+type Signer interface {
+	PublicKey() key.PublicKey
+	SignAttestation(height, round uint64, step uint8, digest hash.Hash) (block.Attestation, error)
+}
+
+// Broadcaster delivers the gadget's own votes to the other validators, whose
+// gadgets receive them through HandleVote. It is called with the gadget lock
+// held and must not call back into the gadget synchronously.
+type Broadcaster interface {
+	BroadcastVote(v *Vote)
+}
+
+type link struct {
+	source Checkpoint
+	target Checkpoint
+	voters map[int]struct{}
+	power  uint64
+}
+
+type castVote struct {
+	source Checkpoint
+	target Checkpoint
+}
+
+// Gadget is a Casper FFG finality gadget running beside any block producing
+// engine. Validators vote for links from the last justified checkpoint to the
+// current epoch's checkpoint; a link with +2/3 of the voting power justifies
+// its target, and a justified checkpoint whose direct child is justified
+// becomes final, which the chain then refuses to reorganize away.
+//
+// Votes are counted whether or not the local chain has the checkpoint yet, so
+// a node that is behind still follows finality; it is applied to the chain as
+// soon as the block arrives.
+type Gadget struct {
+	lock        sync.Mutex
+	cfg         Config
+	valSet      *validator.ValidatorSet
+	chain       *chain.Chain
+	deriver     hash.HashDeriver
+	signer      Signer
+	broadcaster Broadcaster
+
+	links         map[string]*link
+	justified     map[string]Checkpoint
+	votes         map[int][]castVote
+	lastJustified Checkpoint
+	finalized     Checkpoint
+	applied       uint64 // epoch of the last checkpoint finalized on the chain
+	lastVoted     uint64
+}
+
+// NewGadget starts from the genesis checkpoint, which is justified and final.
+// A nil signer runs the gadget as an observer that only counts votes.
+func NewGadget(cfg Config, valSet *validator.ValidatorSet, c *chain.Chain, signer Signer, broadcaster Broadcaster) (*Gadget, error) {
+	if cfg.EpochLength == 0 {
+		cfg.EpochLength = DefaultEpochLength
+	}
+
+	deriver := c.Executor().HashDeriver()
+	genesisID, err := c.Genesis().Hash(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := Checkpoint{Epoch: 0, Height: 0, BlockID: genesisID}
+
+	return &Gadget{
+		cfg:           cfg,
+		valSet:        valSet,
+		chain:         c,
+		deriver:       deriver,
+		signer:        signer,
+		broadcaster:   broadcaster,
+		links:         make(map[string]*link),
+		justified:     map[string]Checkpoint{genesis.key(): genesis},
+		votes:         make(map[int][]castVote),
+		lastJustified: genesis,
+		finalized:     genesis,
+	}, nil
+}
+
+// Finalized returns the last finalized checkpoint.
+func (g *Gadget) Finalized() Checkpoint {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.finalized
+}
+
+func (g *Gadget) LastJustified() Checkpoint {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.lastJustified
+}
+
+// EpochLength is the number of blocks between two checkpoints.
+func (g *Gadget) EpochLength() uint64 {
+	return g.cfg.EpochLength
+}
+
+// OnNewHead is called whenever the chain head moves. It applies finality the
+// chain has not caught up with and votes for a new checkpoint once the head
+// reaches it.
+func (g *Gadget) OnNewHead() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.applyFinality()
+
+	v, err := g.vote()
+	if err != nil {
+		if errors.Is(err, errNothingToVote) {
+			return nil
+		}
+		return err
+	}
+
+	if err = g.addVote(v); err != nil && !errors.Is(err, errAlreadyCounted) && !errors.Is(err, errStaleVote) {
+		return err
+	}
+
+	if g.broadcaster != nil {
+		g.broadcaster.BroadcastVote(v)
+	}
+	return nil
+}
+
+// HandleVote counts a vote received from a peer. Votes from before the
+// finalized checkpoint can no longer matter and are ignored.
+func (g *Gadget) HandleVote(v *Vote) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if err := g.addVote(v); err != nil {
+		if errors.Is(err, errAlreadyCounted) || errors.Is(err, errStaleVote) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (g *Gadget) vote() (*Vote, error) {
+	if g.signer == nil || !g.valSet.Has(g.signer.PublicKey()) {
+		return nil, errNothingToVote
+	}
+
+	epoch := g.chain.Height() / g.cfg.EpochLength
+	if epoch <= g.lastVoted || epoch <= g.lastJustified.Epoch {
+		return nil, errNothingToVote
+	}
+
+	height := epoch * g.cfg.EpochLength
+	blk, err := g.chain.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	blockID, err := blk.Hash(g.deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Vote{
+		Source: g.lastJustified,
+		Target: Checkpoint{Epoch: epoch, Height: height, BlockID: blockID},
+	}
+
+	digest, err := VoteDigest(g.deriver, v.Source, v.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Attestation, err = g.signer.SignAttestation(epoch, 0, voteStep, digest); err != nil {
+		return nil, fmt.Errorf("failed to sign checkpoint vote: %w", err)
+	}
+
+	g.lastVoted = epoch
+	log.Printf("[FFG] voted %d -> %d", v.Source.Epoch, v.Target.Epoch)
+
+	return v, nil
+}
+
+func (g *Gadget) addVote(v *Vote) error {
+	if err := v.Verify(g.deriver); err != nil {
+		return err
+	}
+
+	for _, cp := range []Checkpoint{v.Source, v.Target} {
+		if cp.Height != cp.Epoch*g.cfg.EpochLength {
+			return fmt.Errorf("%w: %s", ErrBadCheckpoint, cp)
+		}
+	}
+
+	idx, _ := g.valSet.GetByPublicKey(v.Attestation.GetSigner())
+	if idx < 0 {
+		return ErrNotValidator
+	}
+
+	if v.Source.Epoch < g.finalized.Epoch {
+		return errStaleVote
+	}
+
+	if err := g.checkSlashable(idx, v); err != nil {
+		return err
+	}
+	g.votes[idx] = append(g.votes[idx], castVote{source: v.Source, target: v.Target})
+
+	linkKey := v.Source.key() + "/" + v.Target.key()
+	l, ok := g.links[linkKey]
+	if !ok {
+		l = &link{source: v.Source, target: v.Target, voters: make(map[int]struct{})}
+		g.links[linkKey] = l
+	}
+
+	l.voters[idx] = struct{}{}
+	l.power += g.valSet.GetByIndex(idx).Power
+
+	g.process()
+	return nil
+}
+
+// checkSlashable rejects the two votes Casper FFG slashes for: two different
+// votes for the same target epoch, and a vote surrounding (or surrounded by)
+// an earlier one. A repeated vote is reported as already counted.
+func (g *Gadget) checkSlashable(idx int, v *Vote) error {
+	for _, prev := range g.votes[idx] {
+		if prev.target.Epoch == v.Target.Epoch {
+			if prev.source.Equal(v.Source) && prev.target.Equal(v.Target) {
+				return errAlreadyCounted
+			}
+			return fmt.Errorf("%w: double vote for epoch %d", ErrSlashableVote, v.Target.Epoch)
+		}
+
+		if (prev.source.Epoch < v.Source.Epoch && v.Target.Epoch < prev.target.Epoch) ||
+			(v.Source.Epoch < prev.source.Epoch && prev.target.Epoch < v.Target.Epoch) {
+			return fmt.Errorf("%w: surround vote %d -> %d", ErrSlashableVote, v.Source.Epoch, v.Target.Epoch)
+		}
+	}
+	return nil
+}
+
+// process justifies and finalizes checkpoints until nothing changes. A link
+// only counts once its source is justified, which may happen after its votes
+// arrived.
+func (g *Gadget) process() {
+	finalized := g.finalized.Epoch
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, l := range g.links {
+			if _, ok := g.justified[l.source.key()]; !ok {
+				continue
+			}
+			if !g.valSet.HasQuorum(l.power) {
+				continue
+			}
+
+			if _, ok := g.justified[l.target.key()]; !ok {
+				g.justified[l.target.key()] = l.target
+				changed = true
+				log.Printf("[FFG] justified %s", l.target)
+
+				if l.target.Epoch > g.lastJustified.Epoch {
+					g.lastJustified = l.target
+				}
+			}
+
+			if l.target.Epoch == l.source.Epoch+1 && l.source.Epoch > g.finalized.Epoch {
+				g.finalized = l.source
+				changed = true
+				log.Printf("[FFG] finalized %s", l.source)
+			}
+		}
+	}
+
+	if g.finalized.Epoch > finalized {
+		g.prune()
+	}
+	g.applyFinality()
+}
+
+// prune forgets what can no longer change the outcome once a checkpoint is
+// final. Only votes with a source at or after it are counted from then on,
+// so links from older sources and justifications below it go. Votes are
+// kept while their target is later, as a new vote could still double vote
+// or surround them.
+func (g *Gadget) prune() {
+	final := g.finalized.Epoch
+
+	for k, l := range g.links {
+		if l.source.Epoch < final {
+			delete(g.links, k)
+		}
+	}
+
+	for k, cp := range g.justified {
+		if cp.Epoch < final {
+			delete(g.justified, k)
+		}
+	}
+
+	for idx, cast := range g.votes {
+		kept := cast[:0]
+		for _, c := range cast {
+			if c.target.Epoch > final {
+				kept = append(kept, c)
+			}
+		}
+		if len(kept) == 0 {
+			delete(g.votes, idx)
+		} else {
+			g.votes[idx] = kept
+		}
+	}
+}
+
+// applyFinality hands the finalized checkpoint to the chain once the chain
+// has the block.
+func (g *Gadget) applyFinality() {
+	if g.finalized.Epoch <= g.applied {
+		return
+	}
+
+	err := g.chain.Finalize(g.finalized.Height, g.finalized.BlockID)
+	if err != nil {
+		// a missing block is normal while syncing, a different block at that
+		// height is not
+		if !errors.Is(err, chain.ErrUnknownBlock) || g.chain.Height() >= g.finalized.Height {
+			log.Printf("[FFG] failed to finalize %s: %v", g.finalized, err)
+		}
+		return
+	}
+
+	g.applied = g.finalized.Epoch
+}
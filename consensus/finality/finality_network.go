@@ -0,0 +1,75 @@
+package finality
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/p2p"
+	"log"
+	"sync"
+)
+
+// NetworkBroadcaster carries checkpoint votes over a p2p transport. Votes are
+// not relayed: validators are expected to be connected to each other, and
+// observers to at least one validator.
+type NetworkBroadcaster struct {
+	transport p2p.Transport
+	onInvalid func(from p2p.PeerID, err error)
+
+	lock   sync.RWMutex
+	gadget *Gadget
+}
+
+var _ Broadcaster = (*NetworkBroadcaster)(nil)
+
+// NewNetworkBroadcaster registers the vote handler on transport. Votes
+// received before Attach are dropped. onInvalid, if set, is told about peers
+// that sent votes failing to decode or verify.
+func NewNetworkBroadcaster(transport p2p.Transport, onInvalid func(from p2p.PeerID, err error)) *NetworkBroadcaster {
+	b := &NetworkBroadcaster{
+		transport: transport,
+		onInvalid: onInvalid,
+	}
+
+	transport.Handle(p2p.MessageFinalityVote, b.handleVote)
+
+	return b
+}
+
+// Attach names the gadget received votes are counted by.
+func (b *NetworkBroadcaster) Attach(g *Gadget) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.gadget = g
+}
+
+func (b *NetworkBroadcaster) BroadcastVote(v *Vote) {
+	payload, err := codec.EncodeProto(v)
+	if err != nil {
+		log.Printf("[FFG] failed to encode %s: %v", v, err)
+		return
+	}
+
+	b.transport.Broadcast(p2p.Message{Type: p2p.MessageFinalityVote, Payload: payload})
+}
+
+func (b *NetworkBroadcaster) handleVote(from p2p.PeerID, msg p2p.Message) {
+	b.lock.RLock()
+	g := b.gadget
+	b.lock.RUnlock()
+
+	if g == nil {
+		return
+	}
+
+	v := new(Vote)
+	err := codec.DecodeProto(msg.Payload, v)
+	if err != nil {
+		err = fmt.Errorf("undecodable checkpoint vote: %w", err)
+	} else {
+		err = g.HandleVote(v)
+	}
+
+	if err != nil && b.onInvalid != nil {
+		b.onInvalid(from, err)
+	}
+}
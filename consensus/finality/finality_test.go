@@ -0,0 +1,323 @@
+package finality
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	"github.com/andantan/kangaroo/consensus/slashprotection"
+	"github.com/andantan/kangaroo/consensus/validator"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/memnet"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testEpochLength = 4
+
+type queue struct {
+	votes []*Vote
+}
+
+func (q *queue) BroadcastVote(v *Vote) {
+	q.votes = append(q.votes, v)
+}
+
+type fixture struct {
+	chain   *chain.Chain
+	builder *assembler.Assembler
+	keys    []key.PrivateKey
+	valSet  *validator.ValidatorSet
+	gadgets []*Gadget
+	queue   *queue
+}
+
+// newFixture runs one gadget per validator (the first voting ones of them)
+// plus an observer, all over the same chain.
+func newFixture(t *testing.T, validators, voting int) *fixture {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha3-256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	f := &fixture{queue: &queue{}}
+
+	vals := make([]*validator.Validator, validators)
+	for i := range vals {
+		privKey, err := keySuite.GeneratePrivateKey()
+		require.NoError(t, err)
+		f.keys = append(f.keys, privKey)
+		vals[i] = validator.NewValidator(privKey.PublicKey(), 1)
+	}
+
+	f.valSet, err = validator.NewValidatorSet(vals)
+	require.NoError(t, err)
+
+	executor := state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver())
+	f.chain, err = chain.NewChain(executor, &chain.Genesis{Timestamp: 1}, nil)
+	require.NoError(t, err)
+	f.builder = assembler.NewAssembler(f.chain, mempool.NewMempool(hashSuite.Deriver(), 0), 0)
+
+	for i := 0; i < voting; i++ {
+		db, err := slashprotection.Open("")
+		require.NoError(t, err)
+		signer, err := slashprotection.NewProtectedSigner(f.keys[i], db)
+		require.NoError(t, err)
+
+		g, err := NewGadget(Config{EpochLength: testEpochLength}, f.valSet, f.chain, signer, f.queue)
+		require.NoError(t, err)
+		f.gadgets = append(f.gadgets, g)
+	}
+
+	observer, err := NewGadget(Config{EpochLength: testEpochLength}, f.valSet, f.chain, nil, nil)
+	require.NoError(t, err)
+	f.gadgets = append(f.gadgets, observer)
+
+	return f
+}
+
+func (f *fixture) observer() *Gadget {
+	return f.gadgets[len(f.gadgets)-1]
+}
+
+// grow adds n empty blocks, letting every gadget vote and exchanging the votes
+// after each one.
+func (f *fixture) grow(t *testing.T, n int) {
+	for i := 0; i < n; i++ {
+		blk, err := f.builder.Assemble(nil, 0, nil)
+		require.NoError(t, err)
+		require.NoError(t, f.chain.AddBlock(blk))
+
+		for _, g := range f.gadgets {
+			require.NoError(t, g.OnNewHead())
+		}
+
+		votes := f.queue.votes
+		f.queue.votes = nil
+		for _, v := range votes {
+			for _, g := range f.gadgets {
+				require.NoError(t, g.HandleVote(v))
+			}
+		}
+	}
+}
+
+func (f *fixture) checkpoint(t *testing.T, epoch uint64) Checkpoint {
+	blk, err := f.chain.GetBlockByHeight(epoch * testEpochLength)
+	require.NoError(t, err)
+	id, err := blk.Hash(f.chain.Executor().HashDeriver())
+	require.NoError(t, err)
+	return Checkpoint{Epoch: epoch, Height: epoch * testEpochLength, BlockID: id}
+}
+
+func TestGadget_Finalization(t *testing.T) {
+	f := newFixture(t, 4, 3)
+
+	f.grow(t, testEpochLength)
+	assert.True(t, f.checkpoint(t, 1).Equal(f.observer().LastJustified()))
+	assert.Equal(t, uint64(0), f.observer().Finalized().Epoch)
+
+	f.grow(t, testEpochLength)
+	for _, g := range f.gadgets {
+		assert.True(t, f.checkpoint(t, 2).Equal(g.LastJustified()))
+		assert.True(t, f.checkpoint(t, 1).Equal(g.Finalized()))
+	}
+
+	height, id, err := f.chain.Finalized()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(testEpochLength), height)
+	assert.True(t, f.checkpoint(t, 1).BlockID.Equal(id))
+
+	t.Run("chain refuses reorgs below the finalized checkpoint", func(t *testing.T) {
+		blk, err := f.chain.GetBlockByHeight(testEpochLength)
+		require.NoError(t, err)
+		_, err = f.chain.Reorg([]block.Block{blk})
+		assert.ErrorIs(t, err, chain.ErrFinalizedReorg)
+	})
+
+	f.grow(t, testEpochLength)
+	assert.True(t, f.checkpoint(t, 2).Equal(f.observer().Finalized()))
+
+	t.Run("state below the finalized checkpoint is pruned", func(t *testing.T) {
+		f.grow(t, 4*testEpochLength)
+		g := f.observer()
+		final := g.Finalized().Epoch
+		require.Equal(t, uint64(6), final)
+
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		for _, l := range g.links {
+			assert.GreaterOrEqual(t, l.source.Epoch, final)
+		}
+		for _, cp := range g.justified {
+			assert.GreaterOrEqual(t, cp.Epoch, final)
+		}
+		for _, cast := range g.votes {
+			for _, c := range cast {
+				assert.Greater(t, c.target.Epoch, final)
+			}
+		}
+		assert.LessOrEqual(t, len(g.links), 1)
+		assert.LessOrEqual(t, len(g.justified), 2)
+	})
+}
+
+func TestGadget_NoQuorum(t *testing.T) {
+	f := newFixture(t, 4, 2)
+
+	f.grow(t, 3*testEpochLength)
+	assert.Equal(t, uint64(0), f.observer().LastJustified().Epoch)
+	assert.Equal(t, uint64(0), f.observer().Finalized().Epoch)
+
+	height, _, err := f.chain.Finalized()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), height)
+}
+
+func TestGadget_HandleVote(t *testing.T) {
+	f := newFixture(t, 4, 0)
+	deriver := f.chain.Executor().HashDeriver()
+	f.grow(t, 2*testEpochLength)
+
+	sign := func(privKey key.PrivateKey, source, target Checkpoint) *Vote {
+		db, err := slashprotection.Open("")
+		require.NoError(t, err)
+		signer, err := slashprotection.NewProtectedSigner(privKey, db)
+		require.NoError(t, err)
+
+		digest, err := VoteDigest(deriver, source, target)
+		require.NoError(t, err)
+		att, err := signer.SignAttestation(target.Epoch, 0, voteStep, digest)
+		require.NoError(t, err)
+		return &Vote{Source: source, Target: target, Attestation: att}
+	}
+
+	g := f.observer()
+	genesis, first, second := f.checkpoint(t, 0), f.checkpoint(t, 1), f.checkpoint(t, 2)
+
+	t.Run("codec round trip", func(t *testing.T) {
+		v := sign(f.keys[0], genesis, first)
+		b, err := codec.EncodeProto(v)
+		require.NoError(t, err)
+		decoded := new(Vote)
+		require.NoError(t, codec.DecodeProto(b, decoded))
+		assert.True(t, decoded.Source.Equal(genesis))
+		assert.True(t, decoded.Target.Equal(first))
+		require.NoError(t, decoded.Verify(deriver))
+	})
+
+	require.NoError(t, g.HandleVote(sign(f.keys[0], genesis, second)))
+	require.NoError(t, g.HandleVote(sign(f.keys[0], genesis, second)), "repeated votes are ignored")
+
+	other := second
+	other.BlockID = deriver.Derive([]byte("other"))
+	assert.ErrorIs(t, g.HandleVote(sign(f.keys[0], genesis, other)), ErrSlashableVote)
+
+	bad := first
+	bad.Height++
+	assert.ErrorIs(t, g.HandleVote(sign(f.keys[1], genesis, bad)), ErrBadCheckpoint)
+
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	outsider, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	assert.ErrorIs(t, g.HandleVote(sign(outsider, genesis, first)), ErrNotValidator)
+
+	tampered := sign(f.keys[1], genesis, first)
+	tampered.Target = second
+	assert.Error(t, g.HandleVote(tampered))
+
+	t.Run("surround votes", func(t *testing.T) {
+		third := Checkpoint{Epoch: 3, Height: 3 * testEpochLength, BlockID: deriver.Derive([]byte("third"))}
+		require.NoError(t, g.HandleVote(sign(f.keys[2], first, second)))
+		assert.ErrorIs(t, g.HandleVote(sign(f.keys[2], genesis, third)), ErrSlashableVote)
+	})
+
+	t.Run("votes arriving before their source is justified", func(t *testing.T) {
+		// the 1 -> 2 link reaches quorum first, then 0 -> 1 justifies its source
+		require.NoError(t, g.HandleVote(sign(f.keys[1], first, second)))
+		require.NoError(t, g.HandleVote(sign(f.keys[3], first, second)))
+		assert.Equal(t, uint64(0), g.LastJustified().Epoch)
+
+		for _, i := range []int{1, 2, 3} {
+			require.NoError(t, g.HandleVote(sign(f.keys[i], genesis, first)))
+		}
+		assert.True(t, second.Equal(g.LastJustified()))
+		assert.True(t, first.Equal(g.Finalized()))
+	})
+
+	t.Run("votes from before the finalized checkpoint are ignored", func(t *testing.T) {
+		require.NoError(t, g.HandleVote(sign(f.keys[0], genesis, first)))
+
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		for _, l := range g.links {
+			assert.Equal(t, uint64(1), l.source.Epoch)
+		}
+	})
+}
+
+func TestNetworkBroadcaster_Memnet(t *testing.T) {
+	f := newFixture(t, 4, 0)
+
+	net := memnet.NewNetwork(memnet.Config{Seed: 1, Latency: memnet.UniformLatency{Max: 5 * time.Millisecond}})
+	t.Cleanup(net.Close)
+
+	var invalid atomic.Int32
+	f.gadgets = nil
+	for i, privKey := range f.keys {
+		tr, err := net.Join(p2p.PeerID(fmt.Sprintf("validator-%d", i)))
+		require.NoError(t, err)
+
+		db, err := slashprotection.Open("")
+		require.NoError(t, err)
+		signer, err := slashprotection.NewProtectedSigner(privKey, db)
+		require.NoError(t, err)
+
+		broadcaster := NewNetworkBroadcaster(tr, func(p2p.PeerID, error) { invalid.Add(1) })
+		g, err := NewGadget(Config{EpochLength: testEpochLength}, f.valSet, f.chain, signer, broadcaster)
+		require.NoError(t, err)
+		broadcaster.Attach(g)
+		f.gadgets = append(f.gadgets, g)
+	}
+	net.ConnectAll()
+
+	// votes travel asynchronously, so each epoch waits for the previous
+	// checkpoint to be justified everywhere before the next one is voted on
+	for epoch := uint64(1); epoch <= 2; epoch++ {
+		for i := 0; i < testEpochLength; i++ {
+			blk, err := f.builder.Assemble(nil, 0, nil)
+			require.NoError(t, err)
+			require.NoError(t, f.chain.AddBlock(blk))
+			for _, g := range f.gadgets {
+				require.NoError(t, g.OnNewHead())
+			}
+		}
+
+		require.Eventually(t, func() bool {
+			for _, g := range f.gadgets {
+				if g.LastJustified().Epoch != epoch {
+					return false
+				}
+			}
+			return true
+		}, 2*time.Second, time.Millisecond)
+	}
+
+	for _, g := range f.gadgets {
+		assert.True(t, f.checkpoint(t, 1).Equal(g.Finalized()))
+	}
+	assert.Zero(t, invalid.Load())
+}
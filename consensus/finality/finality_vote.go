@@ -0,0 +1,205 @@
+package finality
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	kangaroofinalitypb "github.com/andantan/kangaroo/proto/consensus/finality/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Checkpoint is the block at the first height of an epoch.
+type Checkpoint struct {
+	Epoch   uint64
+	Height  uint64
+	BlockID hash.Hash
+}
+
+func (c Checkpoint) Equal(other Checkpoint) bool {
+	if c.Epoch != other.Epoch || c.Height != other.Height {
+		return false
+	}
+	if c.BlockID == nil || other.BlockID == nil {
+		return c.BlockID == nil && other.BlockID == nil
+	}
+	return c.BlockID.Equal(other.BlockID)
+}
+
+func (c Checkpoint) String() string {
+	id := "<nil>"
+	if c.BlockID != nil {
+		id = c.BlockID.ShortString(8)
+	}
+	return fmt.Sprintf("Checkpoint{Epoch: %d, Height: %d, BlockID: %s}", c.Epoch, c.Height, id)
+}
+
+func (c Checkpoint) key() string {
+	if c.BlockID == nil {
+		return fmt.Sprintf("%d:", c.Epoch)
+	}
+	return fmt.Sprintf("%d:%x", c.Epoch, c.BlockID.Bytes())
+}
+
+func (c Checkpoint) toProto() (*kangaroofinalitypb.KangarooCheckpoint, error) {
+	var idBytes []byte
+	if c.BlockID != nil {
+		var err error
+		if idBytes, err = wrapper.WrapHashCanonical(c.BlockID); err != nil {
+			return nil, fmt.Errorf("failed to wrap checkpoint block id: %w", err)
+		}
+	}
+
+	return &kangaroofinalitypb.KangarooCheckpoint{
+		Epoch:   c.Epoch,
+		Height:  c.Height,
+		BlockId: idBytes,
+	}, nil
+}
+
+func checkpointFromProto(pb *kangaroofinalitypb.KangarooCheckpoint) (Checkpoint, error) {
+	if pb == nil {
+		return Checkpoint{}, errors.New("missing checkpoint")
+	}
+
+	var blockID hash.Hash
+	if len(pb.BlockId) != 0 {
+		var err error
+		if blockID, err = wrapper.UnwrapHashCanonical(pb.BlockId); err != nil {
+			return Checkpoint{}, fmt.Errorf("failed to unwrap checkpoint block id: %w", err)
+		}
+	}
+
+	return Checkpoint{Epoch: pb.Epoch, Height: pb.Height, BlockID: blockID}, nil
+}
+
+// Vote is a Casper FFG link vote: the signer attests that Source is justified
+// and Target should be. The attestation signs VoteDigest, not a block id.
+type Vote struct {
+	Source      Checkpoint
+	Target      Checkpoint
+	Attestation block.Attestation
+}
+
+var _ codec.ProtoCodec = (*Vote)(nil)
+
+func VoteDigest(deriver hash.HashDeriver, source, target Checkpoint) (hash.Hash, error) {
+	sourcePb, err := source.toProto()
+	if err != nil {
+		return nil, err
+	}
+
+	targetPb, err := target.toProto()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := proto.Marshal(&kangaroofinalitypb.KangarooCheckpointVoteData{
+		Source: sourcePb,
+		Target: targetPb,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal checkpoint vote data: %w", err)
+	}
+
+	return deriver.Derive(b), nil
+}
+
+// Verify checks that the attestation signs this vote. Whether the signer is a
+// validator is up to the caller.
+func (v *Vote) Verify(deriver hash.HashDeriver) error {
+	if v.Attestation == nil {
+		return errors.New("unsigned checkpoint vote")
+	}
+
+	if v.Source.BlockID == nil || v.Target.BlockID == nil {
+		return errors.New("incomplete checkpoint vote")
+	}
+
+	if v.Target.Epoch <= v.Source.Epoch {
+		return fmt.Errorf("target epoch %d is not after source epoch %d", v.Target.Epoch, v.Source.Epoch)
+	}
+
+	digest, err := VoteDigest(deriver, v.Source, v.Target)
+	if err != nil {
+		return err
+	}
+
+	if !digest.Equal(v.Attestation.GetBlockID()) {
+		return errors.New("attestation does not sign this checkpoint vote")
+	}
+
+	if v.Attestation.GetHeight() != v.Target.Epoch || v.Attestation.GetRound() != 0 || v.Attestation.GetStep() != voteStep {
+		return errors.New("attestation is not signed at the target epoch")
+	}
+
+	if !v.Attestation.Verify() {
+		return errors.New("invalid checkpoint vote signature")
+	}
+
+	return nil
+}
+
+func (v *Vote) ToProto() (proto.Message, error) {
+	sourcePb, err := v.Source.toProto()
+	if err != nil {
+		return nil, err
+	}
+
+	targetPb, err := v.Target.toProto()
+	if err != nil {
+		return nil, err
+	}
+
+	var attBytes []byte
+	if v.Attestation != nil {
+		if attBytes, err = wrapper.WrapAttestationCanonical(v.Attestation); err != nil {
+			return nil, fmt.Errorf("failed to wrap attestation: %w", err)
+		}
+	}
+
+	return &kangaroofinalitypb.KangarooCheckpointVote{
+		Source:      sourcePb,
+		Target:      targetPb,
+		Attestation: attBytes,
+	}, nil
+}
+
+func (v *Vote) FromProto(m proto.Message) error {
+	pb, ok := m.(*kangaroofinalitypb.KangarooCheckpointVote)
+	if !ok {
+		return errors.New("cannot deserialize protobuf KangarooCheckpointVote")
+	}
+
+	source, err := checkpointFromProto(pb.Source)
+	if err != nil {
+		return err
+	}
+
+	target, err := checkpointFromProto(pb.Target)
+	if err != nil {
+		return err
+	}
+
+	var att block.Attestation
+	if len(pb.Attestation) != 0 {
+		if att, err = wrapper.UnwrapAttestationCanonical(pb.Attestation); err != nil {
+			return fmt.Errorf("failed to unwrap attestation: %w", err)
+		}
+	}
+
+	v.Source = source
+	v.Target = target
+	v.Attestation = att
+	return nil
+}
+
+func (v *Vote) NewProto() proto.Message {
+	return &kangaroofinalitypb.KangarooCheckpointVote{}
+}
+
+func (v *Vote) String() string {
+	return fmt.Sprintf("CheckpointVote{Source: %d, Target: %d}", v.Source.Epoch, v.Target.Epoch)
+}
@@ -0,0 +1,206 @@
+package poa
+
+import (
+	"context"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	"github.com/andantan/kangaroo/consensus/slashprotection"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangarootail"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"log"
+	"time"
+)
+
+type EmptyBlockPolicy uint8
+
+const (
+	// ProduceEmptyBlocks seals a block on every tick, even without transactions.
+	ProduceEmptyBlocks EmptyBlockPolicy = iota
+	// SkipEmptyBlocks waits until the mempool (or evidence pool) has something to include.
+	SkipEmptyBlocks
+)
+
+const (
+	DefaultInterval  = 2 * time.Second
+	DefaultMaxWeight = assembler.DefaultMaxWeight
+)
+
+type Config struct {
+	Interval         time.Duration
+	MaxWeight        uint64
+	EmptyBlockPolicy EmptyBlockPolicy
+}
+
+// EvidenceSource supplies detected double-signs for slashing. Evidence is only
+// removed from the source once a block including it has been imported.
+type EvidenceSource interface {
+	PendingEvidence() []block.Evidence
+	RemoveEvidence(evidence []block.Evidence)
+}
+
+// CandidateStore keeps the block the producer is about to sign. The
+// signature is recorded in the slashing protection db before the block is
+// imported and saved, so after a crash in between only the exact same block
+// may be signed again; the store is where it comes back from.
+type CandidateStore interface {
+	SaveCandidate(blk block.Block) error
+	// LoadCandidate returns nil, without error, when nothing is stored.
+	LoadCandidate() (block.Block, error)
+}
+
+// Producer is a single node proof-of-authority block producer meant for local
+// development: it alone proposes, executes and attests to every block.
+type Producer struct {
+	cfg        Config
+	chain      *chain.Chain
+	pool       *mempool.Mempool
+	assembler  *assembler.Assembler
+	signer     *slashprotection.ProtectedSigner
+	evidence   EvidenceSource
+	candidates CandidateStore
+	now        func() time.Time
+}
+
+func NewProducer(cfg Config, c *chain.Chain, pool *mempool.Mempool, privKey key.PrivateKey, db *slashprotection.SlashProtectionDB) (*Producer, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.MaxWeight == 0 {
+		cfg.MaxWeight = DefaultMaxWeight
+	}
+
+	if db == nil {
+		var err error
+		if db, err = slashprotection.Open(""); err != nil {
+			return nil, err
+		}
+	}
+
+	signer, err := slashprotection.NewProtectedSigner(privKey, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Producer{
+		cfg:       cfg,
+		chain:     c,
+		pool:      pool,
+		assembler: assembler.NewAssembler(c, pool, cfg.MaxWeight),
+		signer:    signer,
+		now:       time.Now,
+	}, nil
+}
+
+func (p *Producer) SetEvidenceSource(src EvidenceSource) {
+	p.evidence = src
+}
+
+func (p *Producer) SetCandidateStore(store CandidateStore) {
+	p.candidates = store
+}
+
+// Run produces a block on every interval until the context is cancelled.
+func (p *Producer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			blk, err := p.ProduceBlock()
+			if err != nil {
+				log.Printf("[PoA] failed to produce block: %v", err)
+				continue
+			}
+			if blk != nil {
+				log.Printf("[PoA] sealed %s", blk.GetHeader())
+			}
+		}
+	}
+}
+
+// ProduceBlock builds, signs and imports one block on top of the current head.
+// It returns a nil block when the empty block policy says to skip this round.
+// The candidate is validated before it is signed, so a block the chain would
+// reject never reaches the slashing protection history. A stored candidate
+// that still fits the head is signed again instead of building a new one.
+func (p *Producer) ProduceBlock() (block.Block, error) {
+	deriver := p.chain.Executor().HashDeriver()
+
+	candidate, err := p.storedCandidate()
+	if err != nil {
+		return nil, err
+	}
+
+	if candidate == nil {
+		var evidence []block.Evidence
+		if p.evidence != nil {
+			evidence = p.evidence.PendingEvidence()
+		}
+
+		if candidate, err = p.assembler.Assemble(p.signer.PublicKey(), p.now().UnixNano(), evidence); err != nil {
+			return nil, err
+		}
+
+		body := candidate.GetBody()
+		if len(body.GetTransactions()) == 0 && len(body.GetEvidence()) == 0 && p.cfg.EmptyBlockPolicy == SkipEmptyBlocks {
+			return nil, nil
+		}
+
+		if err = p.chain.ValidateProposal(candidate); err != nil {
+			return nil, err
+		}
+
+		if p.candidates != nil {
+			if err = p.candidates.SaveCandidate(candidate); err != nil {
+				return nil, fmt.Errorf("failed to save candidate: %w", err)
+			}
+		}
+	}
+
+	header := candidate.GetHeader()
+	blockID, err := header.Hash(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	att, err := p.signer.SignAttestation(header.GetHeight(), 0, 0, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attest block: %w", err)
+	}
+
+	blk := kangarooblock.NewKangarooBlock(header, candidate.GetBody(), kangarootail.NewKangarooTail([]block.Attestation{att}))
+	if err = p.chain.AddBlock(blk); err != nil {
+		return nil, err
+	}
+
+	p.pool.RemoveTransactions(blk.GetBody().GetTransactions())
+	if p.evidence != nil {
+		p.evidence.RemoveEvidence(blk.GetBody().GetEvidence())
+	}
+
+	return blk, nil
+}
+
+// storedCandidate returns the stored candidate if it is the next block on top
+// of the head, and nil otherwise.
+func (p *Producer) storedCandidate() (block.Block, error) {
+	if p.candidates == nil {
+		return nil, nil
+	}
+
+	candidate, err := p.candidates.LoadCandidate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load candidate: %w", err)
+	}
+	if candidate == nil || p.chain.ValidateProposal(candidate) != nil {
+		return nil, nil
+	}
+	return candidate, nil
+}
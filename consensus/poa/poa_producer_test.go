@@ -0,0 +1,281 @@
+package poa
+
+import (
+	"context"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/consensus/slashprotection"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	"github.com/andantan/kangaroo/core/block/kangarooevidence"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type fixture struct {
+	chain     *chain.Chain
+	pool      *mempool.Mempool
+	producer  *Producer
+	user      key.PrivateKey
+	authority key.PrivateKey
+	db        *slashprotection.SlashProtectionDB
+	newChain  func() *chain.Chain
+}
+
+func newFixture(t *testing.T, cfg Config) *fixture {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("blake2b256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("blake2b256")
+	require.NoError(t, err)
+
+	authority, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	user, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	executor := state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver())
+	genesis := &chain.Genesis{
+		Timestamp: 1,
+		Alloc: []chain.GenesisAccount{
+			{Address: user.PublicKey().Address(addressSuite.Deriver()), Balance: big.NewInt(100)},
+		},
+	}
+
+	newChain := func() *chain.Chain {
+		c, err := chain.NewChain(executor, genesis, NewAuthorityValidator(authority.PublicKey(), hashSuite.Deriver()))
+		require.NoError(t, err)
+		return c
+	}
+
+	db, err := slashprotection.Open("")
+	require.NoError(t, err)
+
+	c := newChain()
+	pool := mempool.NewMempool(hashSuite.Deriver(), 0)
+	producer, err := NewProducer(cfg, c, pool, authority, db)
+	require.NoError(t, err)
+
+	return &fixture{chain: c, pool: pool, producer: producer, user: user, authority: authority, db: db, newChain: newChain}
+}
+
+// memoryCandidates is a CandidateStore that outlives the producers of a test.
+type memoryCandidates struct {
+	candidate block.Block
+}
+
+func (m *memoryCandidates) SaveCandidate(blk block.Block) error {
+	m.candidate = blk
+	return nil
+}
+
+func (m *memoryCandidates) LoadCandidate() (block.Block, error) {
+	return m.candidate, nil
+}
+
+func (f *fixture) submit(t *testing.T, value int64, nonce uint64) {
+	tx := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(value), nil, nonce)
+	require.NoError(t, tx.Sign(f.user, f.chain.Executor().HashDeriver()))
+	_, err := f.pool.Add(tx)
+	require.NoError(t, err)
+}
+
+func TestProducer_ProduceBlock(t *testing.T) {
+	f := newFixture(t, Config{MaxWeight: 2})
+
+	f.submit(t, 10, 0)
+	f.submit(t, 10, 1)
+	f.submit(t, 10, 2)
+	f.submit(t, 10, 9) // nonce gap, stays pending
+
+	blk, err := f.producer.ProduceBlock()
+	require.NoError(t, err)
+	require.NotNil(t, blk)
+
+	assert.Equal(t, uint64(1), f.chain.Height())
+	assert.Equal(t, uint64(2), blk.GetBody().GetWeight(), "weight limit should cap the body")
+	require.Len(t, blk.GetTail().GetAttestations(), 1)
+	assert.True(t, blk.GetTail().GetAttestations()[0].Verify())
+	assert.Equal(t, 2, f.pool.Len())
+
+	blk, err = f.producer.ProduceBlock()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), blk.GetBody().GetWeight())
+	assert.Equal(t, 1, f.pool.Len(), "gapped transaction should stay in the pool")
+
+	sender := f.user.PublicKey().Address(f.chain.Executor().AddressDeriver())
+	assert.Equal(t, big.NewInt(70), f.chain.State().GetBalance(sender))
+}
+
+func TestProducer_EvictsFailingTransactions(t *testing.T) {
+	f := newFixture(t, Config{})
+
+	// an unfunded sender used to leave an empty account behind in the
+	// candidate state, so the sealed block failed its own state root check
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	unfunded, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	tx := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(10), nil, 0)
+	require.NoError(t, tx.Sign(unfunded, f.chain.Executor().HashDeriver()))
+	_, err = f.pool.Add(tx)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		blk, err := f.producer.ProduceBlock()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), blk.GetBody().GetWeight())
+		assert.Equal(t, 0, f.pool.Len(), "failing transaction should be evicted")
+	}
+
+	assert.Equal(t, uint64(2), f.chain.Height())
+}
+
+func TestProducer_EmptyBlockPolicy(t *testing.T) {
+	t.Run("should produce empty blocks", func(t *testing.T) {
+		f := newFixture(t, Config{EmptyBlockPolicy: ProduceEmptyBlocks})
+		blk, err := f.producer.ProduceBlock()
+		require.NoError(t, err)
+		require.NotNil(t, blk)
+		assert.Equal(t, uint64(0), blk.GetBody().GetWeight())
+	})
+
+	t.Run("should skip empty blocks", func(t *testing.T) {
+		f := newFixture(t, Config{EmptyBlockPolicy: SkipEmptyBlocks})
+		blk, err := f.producer.ProduceBlock()
+		require.NoError(t, err)
+		assert.Nil(t, blk)
+		assert.Equal(t, uint64(0), f.chain.Height())
+
+		f.submit(t, 1, 0)
+		blk, err = f.producer.ProduceBlock()
+		require.NoError(t, err)
+		require.NotNil(t, blk)
+	})
+}
+
+func TestProducer_IncludesEvidence(t *testing.T) {
+	f := newFixture(t, Config{EmptyBlockPolicy: SkipEmptyBlocks})
+	deriver := f.chain.Executor().HashDeriver()
+
+	detector := kangarooevidence.NewEquivocationDetector(nil)
+	f.producer.SetEvidenceSource(detector)
+
+	for _, data := range []string{"block_a", "block_b"} {
+		att, err := kangarooattestation.Sign(f.user, 3, 0, 0, deriver.Derive([]byte(data)))
+		require.NoError(t, err)
+		_, err = detector.Observe(att)
+		require.NoError(t, err)
+	}
+
+	blk, err := f.producer.ProduceBlock()
+	require.NoError(t, err)
+	require.NotNil(t, blk)
+	require.Len(t, blk.GetBody().GetEvidence(), 1)
+	assert.Empty(t, detector.PendingEvidence())
+}
+
+func TestProducer_KeepsEvidenceOnFailure(t *testing.T) {
+	f := newFixture(t, Config{})
+	deriver := f.chain.Executor().HashDeriver()
+
+	detector := kangarooevidence.NewEquivocationDetector(nil)
+	for _, data := range []string{"block_a", "block_b"} {
+		att, err := kangarooattestation.Sign(f.user, 3, 0, 0, deriver.Derive([]byte(data)))
+		require.NoError(t, err)
+		_, err = detector.Observe(att)
+		require.NoError(t, err)
+	}
+
+	// blocks sealed by the user are refused, so the evidence must stay pending
+	impostor, err := NewProducer(Config{}, f.chain, f.pool, f.user, nil)
+	require.NoError(t, err)
+	impostor.SetEvidenceSource(detector)
+
+	_, err = impostor.ProduceBlock()
+	require.ErrorIs(t, err, chain.ErrInvalidBlock)
+	assert.Len(t, detector.PendingEvidence(), 1)
+
+	f.producer.SetEvidenceSource(detector)
+	blk, err := f.producer.ProduceBlock()
+	require.NoError(t, err)
+	require.Len(t, blk.GetBody().GetEvidence(), 1)
+	assert.Empty(t, detector.PendingEvidence())
+}
+
+func TestProducer_RecoversSignedCandidate(t *testing.T) {
+	f := newFixture(t, Config{})
+	f.submit(t, 10, 0)
+
+	store := &memoryCandidates{}
+	f.producer.SetCandidateStore(store)
+	signed, err := f.producer.ProduceBlock()
+	require.NoError(t, err)
+
+	// a crash after signing loses the imported block but not the slashing
+	// protection db; any other block at that height is refused from then on
+	restart := func(store CandidateStore) (*chain.Chain, *Producer) {
+		c := f.newChain()
+		producer, err := NewProducer(Config{}, c, mempool.NewMempool(c.Executor().HashDeriver(), 0), f.authority, f.db)
+		require.NoError(t, err)
+		if store != nil {
+			producer.SetCandidateStore(store)
+		}
+		return c, producer
+	}
+
+	_, producer := restart(nil)
+	_, err = producer.ProduceBlock()
+	assert.ErrorIs(t, err, slashprotection.ErrConflictingVote)
+
+	c, producer := restart(store)
+	blk, err := producer.ProduceBlock()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), c.Height())
+
+	deriver := c.Executor().HashDeriver()
+	signedID, err := signed.GetHeader().Hash(deriver)
+	require.NoError(t, err)
+	blkID, err := blk.GetHeader().Hash(deriver)
+	require.NoError(t, err)
+	assert.True(t, signedID.Equal(blkID), "the recorded block should be signed again")
+	assert.Equal(t, uint64(1), blk.GetBody().GetWeight())
+
+	_, err = producer.ProduceBlock()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), c.Height(), "a stale candidate is not reused")
+}
+
+func TestProducer_Run(t *testing.T) {
+	f := newFixture(t, Config{Interval: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := f.producer.Run(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Greater(t, f.chain.Height(), uint64(2))
+}
+
+func TestAuthorityValidator_RejectsForeignBlocks(t *testing.T) {
+	f := newFixture(t, Config{})
+
+	// the user is not the authority, so its blocks must be refused
+	impostor, err := NewProducer(Config{}, f.chain, f.pool, f.user, nil)
+	require.NoError(t, err)
+
+	_, err = impostor.ProduceBlock()
+	assert.ErrorIs(t, err, chain.ErrInvalidBlock)
+	assert.Equal(t, uint64(0), f.chain.Height())
+}
@@ -0,0 +1,59 @@
+package poa
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+)
+
+// AuthorityValidator accepts only blocks proposed and attested by the single
+// configured authority.
+type AuthorityValidator struct {
+	authority key.PublicKey
+	deriver   hash.HashDeriver
+}
+
+func NewAuthorityValidator(authority key.PublicKey, deriver hash.HashDeriver) *AuthorityValidator {
+	return &AuthorityValidator{
+		authority: authority,
+		deriver:   deriver,
+	}
+}
+
+func (v *AuthorityValidator) ValidateBlock(_ block.Header, blk block.Block) error {
+	header := blk.GetHeader()
+	if header.GetProposer() == nil || !header.GetProposer().Equal(v.authority) {
+		return errors.New("block is not proposed by the authority")
+	}
+
+	if blk.GetTail() == nil || len(blk.GetTail().GetAttestations()) != 1 {
+		return errors.New("block must carry exactly one attestation")
+	}
+
+	id, err := blk.Hash(v.deriver)
+	if err != nil {
+		return err
+	}
+
+	att := blk.GetTail().GetAttestations()[0]
+	if !att.GetSigner().Equal(v.authority) {
+		return errors.New("attestation is not signed by the authority")
+	}
+
+	if height := blk.GetHeader().GetHeight(); att.GetHeight() != height || att.GetRound() != 0 || att.GetStep() != 0 {
+		return fmt.Errorf("attestation is signed at %d/%d/%d, not %d/0/0", att.GetHeight(), att.GetRound(), att.GetStep(), height)
+	}
+
+	if !att.GetBlockID().Equal(id) {
+		return fmt.Errorf("attestation is for block %s, not %s",
+			att.GetBlockID().ShortString(8), id.ShortString(8))
+	}
+
+	if !att.Verify() {
+		return errors.New("invalid attestation signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package pow
+
+import (
+	"errors"
+	"github.com/andantan/kangaroo/core/block"
+	"math/big"
+	"time"
+)
+
+// DifficultyAlgorithm decides the target of the block following parent. It
+// only sees the parent header and the new block's timestamp, so it can run
+// inside chain validation without reading further back.
+type DifficultyAlgorithm interface {
+	NextTarget(parent block.Header, timestamp int64) (*big.Int, error)
+}
+
+// FixedDifficulty keeps the same target forever. Mostly useful for tests.
+type FixedDifficulty struct {
+	Target *big.Int
+}
+
+var _ DifficultyAlgorithm = (*FixedDifficulty)(nil)
+
+func NewFixedDifficulty(difficulty uint64) *FixedDifficulty {
+	return &FixedDifficulty{Target: TargetFromDifficulty(difficulty)}
+}
+
+func (f *FixedDifficulty) NextTarget(_ block.Header, _ int64) (*big.Int, error) {
+	return new(big.Int).Set(f.Target), nil
+}
+
+const (
+	DefaultBlockTime = 10 * time.Second
+	DefaultWindow    = 32
+)
+
+// EMADifficulty adjusts the target on every block as an exponential moving
+// average of the solve time:
+//
+//	next = parent * (Window*BlockTime + solveTime - BlockTime) / (Window*BlockTime)
+//
+// so a block found exactly on time keeps the target, faster blocks make the
+// next one harder and slower blocks make it easier. The solve time is clamped
+// to [0, 6*BlockTime], which bounds how much a single (possibly lying)
+// timestamp can move the target.
+type EMADifficulty struct {
+	BlockTime     time.Duration
+	Window        uint64
+	InitialTarget *big.Int
+	MaxTarget     *big.Int
+}
+
+var _ DifficultyAlgorithm = (*EMADifficulty)(nil)
+
+func NewEMADifficulty(blockTime time.Duration, window uint64, initialDifficulty uint64) *EMADifficulty {
+	if blockTime <= 0 {
+		blockTime = DefaultBlockTime
+	}
+	if window == 0 {
+		window = DefaultWindow
+	}
+
+	return &EMADifficulty{
+		BlockTime:     blockTime,
+		Window:        window,
+		InitialTarget: TargetFromDifficulty(initialDifficulty),
+		MaxTarget:     MaxTarget,
+	}
+}
+
+func (e *EMADifficulty) NextTarget(parent block.Header, timestamp int64) (*big.Int, error) {
+	if parent.GetTarget() == nil {
+		// the genesis block (or the first block after switching to work) is not mined
+		return new(big.Int).Set(e.InitialTarget), nil
+	}
+
+	solveTime := timestamp - parent.GetTimestamp()
+	if solveTime < 0 {
+		return nil, errors.New("timestamp before parent")
+	}
+
+	blockTime := int64(e.BlockTime)
+	if solveTime > 6*blockTime {
+		solveTime = 6 * blockTime
+	}
+
+	span := new(big.Int).Mul(big.NewInt(blockTime), new(big.Int).SetUint64(e.Window))
+
+	next := HashToTarget(parent.GetTarget())
+	next.Mul(next, new(big.Int).Add(span, big.NewInt(solveTime-blockTime)))
+	next.Div(next, span)
+
+	return clampTarget(next, e.MaxTarget), nil
+}
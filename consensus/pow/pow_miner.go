@@ -0,0 +1,242 @@
+package pow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/registry"
+	"log"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultMaxWeight = assembler.DefaultMaxWeight
+
+	// workers look at the context once per batch of attempts
+	sealBatchSize = 1024
+	// how often a running seal checks whether the chain head moved under it
+	headPollInterval = 100 * time.Millisecond
+)
+
+var (
+	ErrNonceSpaceExhausted = errors.New("nonce space exhausted")
+	ErrStaleWork           = errors.New("chain head changed while mining")
+)
+
+type Config struct {
+	// Threads is the number of sealing goroutines, runtime.NumCPU() if zero.
+	Threads    int
+	MaxWeight  uint64
+	Difficulty DifficultyAlgorithm
+}
+
+// Miner assembles blocks from the mempool, seals them with a nonce that puts
+// the block id below the target and imports them into the chain.
+type Miner struct {
+	cfg       Config
+	chain     *chain.Chain
+	pool      *mempool.Mempool
+	assembler *assembler.Assembler
+	suite     hash.HashSuite
+	coinbase  key.PublicKey
+	now       func() time.Time
+}
+
+func NewMiner(cfg Config, c *chain.Chain, pool *mempool.Mempool, coinbase key.PublicKey) (*Miner, error) {
+	if cfg.Threads <= 0 {
+		cfg.Threads = runtime.NumCPU()
+	}
+	if cfg.MaxWeight == 0 {
+		cfg.MaxWeight = DefaultMaxWeight
+	}
+	if cfg.Difficulty == nil {
+		return nil, errors.New("no difficulty algorithm configured")
+	}
+
+	suite, err := registry.GetHashSuite(c.Executor().HashDeriver().Type())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Miner{
+		cfg:       cfg,
+		chain:     c,
+		pool:      pool,
+		assembler: assembler.NewAssembler(c, pool, cfg.MaxWeight),
+		suite:     suite,
+		coinbase:  coinbase,
+		now:       time.Now,
+	}, nil
+}
+
+// Run mines blocks back to back until the context is cancelled.
+func (m *Miner) Run(ctx context.Context) error {
+	for {
+		blk, err := m.MineBlock(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if !errors.Is(err, ErrStaleWork) {
+				log.Printf("[PoW] failed to mine block: %v", err)
+			}
+			continue
+		}
+		log.Printf("[PoW] sealed %s", blk.GetHeader())
+	}
+}
+
+// MineBlock seals and imports one block on top of the current head. Work is
+// abandoned with ErrStaleWork when another block becomes the head first.
+func (m *Miner) MineBlock(ctx context.Context) (block.Block, error) {
+	head := m.chain.Head()
+	parent := head.GetHeader()
+	parentID, err := head.Hash(m.suite.Deriver())
+	if err != nil {
+		return nil, err
+	}
+
+	candidate, err := m.assembler.Assemble(m.coinbase, m.now().UnixNano(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if !candidate.GetHeader().GetPrevBlockID().Equal(parentID) {
+		return nil, ErrStaleWork
+	}
+
+	if err = m.chain.ValidateProposal(candidate); err != nil {
+		return nil, err
+	}
+
+	header, ok := candidate.GetHeader().(*kangarooheader.KangarooHeader)
+	if !ok {
+		return nil, fmt.Errorf("cannot mine header of type %s", candidate.GetHeader().Type())
+	}
+
+	if header.Target, err = ExpectedTarget(m.cfg.Difficulty, m.suite, parent, header.Timestamp); err != nil {
+		return nil, err
+	}
+
+	sealCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stale atomic.Bool
+	go func() {
+		ticker := time.NewTicker(headPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sealCtx.Done():
+				return
+			case <-ticker.C:
+				// Compare ids, not heights: a heavier branch of the same
+				// length replaces the parent without changing the height.
+				if id, err := m.chain.Head().Hash(m.suite.Deriver()); err != nil || !id.Equal(parentID) {
+					stale.Store(true)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	sealed, err := Seal(sealCtx, header, m.suite.Deriver(), m.cfg.Threads)
+	if err != nil {
+		if stale.Load() && ctx.Err() == nil {
+			return nil, ErrStaleWork
+		}
+		return nil, err
+	}
+
+	blk := kangarooblock.NewKangarooBlock(sealed, candidate.GetBody(), candidate.GetTail())
+	if err = m.chain.AddBlock(blk); err != nil {
+		return nil, err
+	}
+
+	m.pool.RemoveTransactions(blk.GetBody().GetTransactions())
+
+	return blk, nil
+}
+
+// Seal searches for a nonce that puts the header's id below its target,
+// splitting the nonce space between threads goroutines. The given header is
+// not modified; a sealed copy is returned.
+func Seal(ctx context.Context, header *kangarooheader.KangarooHeader, deriver hash.HashDeriver, threads int) (*kangarooheader.KangarooHeader, error) {
+	if header.Target == nil {
+		return nil, fmt.Errorf("%w: header carries no target", ErrInvalidTarget)
+	}
+	if threads <= 0 {
+		threads = 1
+	}
+
+	var (
+		wg     sync.WaitGroup
+		once   sync.Once
+		found  *kangarooheader.KangarooHeader
+		sealed = make(chan struct{})
+		errs   = make(chan error, threads)
+	)
+
+	for w := 0; w < threads; w++ {
+		wg.Add(1)
+		go func(start uint64) {
+			defer wg.Done()
+
+			work := *header
+			stride := uint64(threads)
+
+			for nonce := start; ; nonce += stride {
+				if (nonce-start)/stride%sealBatchSize == 0 {
+					select {
+					case <-ctx.Done():
+						return
+					case <-sealed:
+						return
+					default:
+					}
+				}
+
+				work.Nonce = nonce
+				id, err := work.Hash(deriver)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if id.Lt(work.Target) {
+					once.Do(func() {
+						found = &work
+						close(sealed)
+					})
+					return
+				}
+
+				if nonce > ^uint64(0)-stride {
+					errs <- ErrNonceSpaceExhausted
+					return
+				}
+			}
+		}(uint64(w))
+	}
+
+	wg.Wait()
+
+	if found != nil {
+		return found, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, <-errs
+}
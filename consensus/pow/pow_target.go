@@ -0,0 +1,68 @@
+package pow
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"math/big"
+)
+
+// MaxTarget is the easiest possible target: every hash but the all-ones one
+// is below it.
+var MaxTarget = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), hash.HashLength*8), big.NewInt(1))
+
+var ErrInvalidTarget = errors.New("invalid target")
+
+// TargetFromDifficulty returns the target a block hash must stay below so
+// that, on average, difficulty attempts are needed to find one.
+func TargetFromDifficulty(difficulty uint64) *big.Int {
+	if difficulty <= 1 {
+		return new(big.Int).Set(MaxTarget)
+	}
+	return new(big.Int).Div(MaxTarget, new(big.Int).SetUint64(difficulty))
+}
+
+// Difficulty is the inverse of TargetFromDifficulty.
+func Difficulty(target *big.Int) uint64 {
+	if target.Sign() <= 0 {
+		return 0
+	}
+
+	d := new(big.Int).Div(MaxTarget, target)
+	if !d.IsUint64() {
+		return ^uint64(0)
+	}
+	return d.Uint64()
+}
+
+// TargetToHash encodes target as a hash of the suite's type, so it can be
+// compared with block ids through hash.Hash.Lt.
+func TargetToHash(suite hash.HashSuite, target *big.Int) (hash.Hash, error) {
+	if target.Sign() <= 0 || target.Cmp(MaxTarget) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTarget, target)
+	}
+
+	b := make([]byte, hash.HashLength)
+	target.FillBytes(b)
+
+	return suite.HashFromBytes(b)
+}
+
+func HashToTarget(h hash.Hash) *big.Int {
+	return new(big.Int).SetBytes(h.Bytes())
+}
+
+// clampTarget keeps an adjusted target inside [1, max].
+func clampTarget(target, max *big.Int) *big.Int {
+	if max == nil {
+		max = MaxTarget
+	}
+
+	if target.Sign() <= 0 {
+		return big.NewInt(1)
+	}
+	if target.Cmp(max) > 0 {
+		return new(big.Int).Set(max)
+	}
+	return target
+}
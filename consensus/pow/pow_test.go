@@ -0,0 +1,294 @@
+package pow
+
+import (
+	"context"
+	"github.com/andantan/kangaroo/chain"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestTarget(t *testing.T) {
+	assert.Equal(t, MaxTarget, TargetFromDifficulty(0))
+	assert.Equal(t, MaxTarget, TargetFromDifficulty(1))
+	assert.Equal(t, uint64(1000), Difficulty(TargetFromDifficulty(1000)))
+
+	for _, name := range []string{"sha256", "blake2b256", "keccak256"} {
+		t.Run(name, func(t *testing.T) {
+			suite, err := registry.GetHashSuite(name)
+			require.NoError(t, err)
+
+			target, err := TargetToHash(suite, TargetFromDifficulty(16))
+			require.NoError(t, err)
+			assert.Equal(t, name, target.Type())
+			assert.Equal(t, TargetFromDifficulty(16), HashToTarget(target))
+
+			_, err = TargetToHash(suite, big.NewInt(0))
+			assert.ErrorIs(t, err, ErrInvalidTarget)
+			_, err = TargetToHash(suite, new(big.Int).Add(MaxTarget, big.NewInt(1)))
+			assert.ErrorIs(t, err, ErrInvalidTarget)
+		})
+	}
+}
+
+func TestEMADifficulty(t *testing.T) {
+	suite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+
+	algo := NewEMADifficulty(10*time.Second, 10, 1000)
+	second := int64(time.Second)
+
+	genesis := kangarooheader.NewKangarooHeader(0, 0, nil, nil, nil, nil)
+	initial, err := algo.NextTarget(genesis, 5*second)
+	require.NoError(t, err)
+	assert.Equal(t, TargetFromDifficulty(1000), initial)
+
+	parent := kangarooheader.NewKangarooHeader(1, 100*second, nil, nil, nil, nil)
+	parent.Target, err = TargetToHash(suite, initial)
+	require.NoError(t, err)
+
+	next := func(solveTime int64) *big.Int {
+		target, err := algo.NextTarget(parent, parent.Timestamp+solveTime)
+		require.NoError(t, err)
+		return target
+	}
+
+	assert.Equal(t, initial, next(10*second), "on time blocks keep the target")
+	assert.Equal(t, -1, next(second).Cmp(initial), "fast blocks lower the target")
+	assert.Equal(t, 1, next(30*second).Cmp(initial), "slow blocks raise the target")
+	assert.Equal(t, next(60*second), next(time.Hour.Nanoseconds()), "solve time is clamped")
+
+	_, err = algo.NextTarget(parent, parent.Timestamp-1)
+	assert.Error(t, err)
+}
+
+func TestSeal(t *testing.T) {
+	for _, name := range []string{"sha256", "blake2b256", "keccak256", "sha3-256"} {
+		t.Run(name, func(t *testing.T) {
+			suite, err := registry.GetHashSuite(name)
+			require.NoError(t, err)
+			deriver := suite.Deriver()
+
+			header := kangarooheader.NewKangarooHeader(1, 1, deriver.Derive([]byte("parent")), deriver.Derive(nil), deriver.Derive(nil), nil)
+			header.Target, err = TargetToHash(suite, TargetFromDifficulty(256))
+			require.NoError(t, err)
+
+			sealed, err := Seal(context.Background(), header, deriver, 4)
+			require.NoError(t, err)
+			require.NoError(t, VerifySeal(sealed, deriver))
+			assert.Equal(t, uint64(0), header.Nonce, "input header must not be modified")
+		})
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		suite, err := registry.GetHashSuite("sha256")
+		require.NoError(t, err)
+		deriver := suite.Deriver()
+
+		header := kangarooheader.NewKangarooHeader(1, 1, nil, deriver.Derive(nil), deriver.Derive(nil), nil)
+		// practically impossible target
+		header.Target, err = TargetToHash(suite, big.NewInt(1))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = Seal(ctx, header, deriver, 2)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+type fixture struct {
+	chain *chain.Chain
+	pool  *mempool.Mempool
+	miner *Miner
+	user  key.PrivateKey
+}
+
+func newFixture(t *testing.T, difficulty DifficultyAlgorithm) *fixture {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("blake2b256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	coinbase, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	user, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	executor := state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver())
+	genesis := &chain.Genesis{
+		Timestamp: 1,
+		Alloc: []chain.GenesisAccount{
+			{Address: user.PublicKey().Address(addressSuite.Deriver()), Balance: big.NewInt(100)},
+		},
+	}
+
+	c, err := chain.NewChain(executor, genesis, NewWorkValidator(difficulty, hashSuite))
+	require.NoError(t, err)
+
+	pool := mempool.NewMempool(hashSuite.Deriver(), 0)
+	miner, err := NewMiner(Config{Threads: 2, Difficulty: difficulty}, c, pool, coinbase.PublicKey())
+	require.NoError(t, err)
+
+	return &fixture{chain: c, pool: pool, miner: miner, user: user}
+}
+
+func TestMiner_MineBlock(t *testing.T) {
+	f := newFixture(t, NewEMADifficulty(time.Second, 8, 64))
+
+	tx := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(10), nil, 0)
+	require.NoError(t, tx.Sign(f.user, f.chain.Executor().HashDeriver()))
+	_, err := f.pool.Add(tx)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		blk, err := f.miner.MineBlock(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, VerifySeal(blk.GetHeader(), f.chain.Executor().HashDeriver()))
+	}
+
+	assert.Equal(t, uint64(3), f.chain.Height())
+	assert.Equal(t, 0, f.pool.Len())
+
+	first, err := f.chain.GetBlockByHeight(1)
+	require.NoError(t, err)
+	assert.Len(t, first.GetBody().GetTransactions(), 1)
+}
+
+func TestMiner_Run_Cancel(t *testing.T) {
+	f := newFixture(t, NewFixedDifficulty(16))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- f.miner.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return f.chain.Height() >= 2 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("miner did not stop after cancellation")
+	}
+}
+
+// timestampDifficulty picks the target from the block timestamp so a test can
+// build same-height blocks of different work.
+type timestampDifficulty map[int64]*big.Int
+
+func (d timestampDifficulty) NextTarget(_ block.Header, timestamp int64) (*big.Int, error) {
+	if target, ok := d[timestamp]; ok {
+		return target, nil
+	}
+	// practically impossible target
+	return big.NewInt(1), nil
+}
+
+func TestMiner_MineBlock_StaleOnSameHeightReorg(t *testing.T) {
+	light := time.Now().Add(-2 * time.Second).UnixNano()
+	heavy := light + 1
+	f := newFixture(t, timestampDifficulty{light: MaxTarget, heavy: TargetFromDifficulty(4)})
+	deriver := f.chain.Executor().HashDeriver()
+
+	seal := func(timestamp int64) block.Block {
+		candidate, err := f.miner.assembler.Assemble(nil, timestamp, nil)
+		require.NoError(t, err)
+		header := candidate.GetHeader().(*kangarooheader.KangarooHeader)
+		header.Target, err = ExpectedTarget(f.miner.cfg.Difficulty, f.miner.suite, f.chain.Head().GetHeader(), timestamp)
+		require.NoError(t, err)
+		sealed, err := Seal(context.Background(), header, deriver, 2)
+		require.NoError(t, err)
+		return kangarooblock.NewKangarooBlock(sealed, candidate.GetBody(), candidate.GetTail())
+	}
+
+	lightBlock, heavyBlock := seal(light), seal(heavy)
+	require.NoError(t, f.chain.AddBlock(lightBlock))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := f.miner.MineBlock(context.Background())
+		done <- err
+	}()
+
+	time.Sleep(2 * headPollInterval)
+	_, err := f.chain.AddBranch([]block.Block{heavyBlock})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), f.chain.Height())
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrStaleWork)
+	case <-time.After(5 * time.Second):
+		t.Fatal("miner kept sealing on a replaced parent")
+	}
+}
+
+func TestWorkValidator(t *testing.T) {
+	f := newFixture(t, NewFixedDifficulty(64))
+	deriver := f.chain.Executor().HashDeriver()
+
+	candidate, err := f.miner.assembler.Assemble(nil, time.Now().UnixNano(), nil)
+	require.NoError(t, err)
+	header := candidate.GetHeader().(*kangarooheader.KangarooHeader)
+	header.Target, err = ExpectedTarget(f.miner.cfg.Difficulty, f.miner.suite, f.chain.Head().GetHeader(), header.Timestamp)
+	require.NoError(t, err)
+
+	sealed, err := Seal(context.Background(), header, deriver, 2)
+	require.NoError(t, err)
+
+	t.Run("unsealed", func(t *testing.T) {
+		unsealed := *sealed
+		for unsealed.Nonce++; VerifySeal(&unsealed, deriver) == nil; unsealed.Nonce++ {
+		}
+		err := f.chain.AddBlock(kangarooblock.NewKangarooBlock(&unsealed, candidate.GetBody(), candidate.GetTail()))
+		assert.ErrorIs(t, err, chain.ErrInvalidBlock)
+	})
+
+	t.Run("easier target", func(t *testing.T) {
+		easier := *header
+		easier.Target, err = TargetToHash(f.miner.suite, MaxTarget)
+		require.NoError(t, err)
+		easy, err := Seal(context.Background(), &easier, deriver, 1)
+		require.NoError(t, err)
+		err = f.chain.AddBlock(kangarooblock.NewKangarooBlock(easy, candidate.GetBody(), candidate.GetTail()))
+		assert.ErrorIs(t, err, chain.ErrInvalidBlock)
+	})
+
+	t.Run("future timestamp", func(t *testing.T) {
+		future := *header
+		future.Timestamp = time.Now().Add(time.Hour).UnixNano()
+		sealed, err := Seal(context.Background(), &future, deriver, 2)
+		require.NoError(t, err)
+		err = f.chain.AddBlock(kangarooblock.NewKangarooBlock(sealed, candidate.GetBody(), candidate.GetTail()))
+		assert.ErrorIs(t, err, chain.ErrInvalidBlock)
+	})
+
+	require.NoError(t, f.chain.AddBlock(kangarooblock.NewKangarooBlock(sealed, candidate.GetBody(), candidate.GetTail())))
+	assert.Equal(t, uint64(1), f.chain.Height())
+
+	t.Run("work grows with difficulty", func(t *testing.T) {
+		validator := NewWorkValidator(f.miner.cfg.Difficulty, f.miner.suite)
+		easier := *header
+		easier.Target, err = TargetToHash(f.miner.suite, MaxTarget)
+		require.NoError(t, err)
+
+		assert.Equal(t, big.NewInt(64), validator.BlockWork(header))
+		assert.Equal(t, big.NewInt(1), validator.BlockWork(&easier))
+	})
+}
@@ -0,0 +1,92 @@
+package pow
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"math/big"
+	"time"
+)
+
+// DefaultMaxFutureDrift bounds how far ahead of the local clock a block
+// timestamp may be. Without it a miner could claim a long solve time to make
+// the next target easier.
+const DefaultMaxFutureDrift = 15 * time.Second
+
+// WorkValidator accepts blocks whose id is below the target the difficulty
+// algorithm expects after their parent.
+type WorkValidator struct {
+	difficulty     DifficultyAlgorithm
+	suite          hash.HashSuite
+	maxFutureDrift time.Duration
+	now            func() time.Time
+}
+
+func NewWorkValidator(difficulty DifficultyAlgorithm, suite hash.HashSuite) *WorkValidator {
+	return &WorkValidator{
+		difficulty:     difficulty,
+		suite:          suite,
+		maxFutureDrift: DefaultMaxFutureDrift,
+		now:            time.Now,
+	}
+}
+
+func (v *WorkValidator) ValidateBlock(parent block.Header, blk block.Block) error {
+	header := blk.GetHeader()
+
+	if limit := v.now().Add(v.maxFutureDrift).UnixNano(); header.GetTimestamp() > limit {
+		return errors.New("block timestamp is too far in the future")
+	}
+
+	target, err := ExpectedTarget(v.difficulty, v.suite, parent, header.GetTimestamp())
+	if err != nil {
+		return err
+	}
+
+	if !target.Equal(header.GetTarget()) {
+		return fmt.Errorf("%w: block does not carry the expected target", ErrInvalidTarget)
+	}
+
+	return VerifySeal(header, v.suite.Deriver())
+}
+
+// BlockWork is the expected number of hashes needed to meet the header's
+// target, so the chain with the most work is the one that cost the most to mine.
+func (v *WorkValidator) BlockWork(header block.Header) *big.Int {
+	if header.GetTarget() == nil {
+		return new(big.Int)
+	}
+
+	target := HashToTarget(header.GetTarget())
+	return new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), hash.HashLength*8), target.Add(target, big.NewInt(1)))
+}
+
+// ExpectedTarget returns the target a block at timestamp on top of parent must
+// carry, encoded for the suite.
+func ExpectedTarget(difficulty DifficultyAlgorithm, suite hash.HashSuite, parent block.Header, timestamp int64) (hash.Hash, error) {
+	next, err := difficulty.NextTarget(parent, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return TargetToHash(suite, next)
+}
+
+// VerifySeal checks that the header's id is below its own target.
+func VerifySeal(header block.Header, deriver hash.HashDeriver) error {
+	if header.GetTarget() == nil {
+		return fmt.Errorf("%w: header carries no target", ErrInvalidTarget)
+	}
+
+	id, err := header.Hash(deriver)
+	if err != nil {
+		return err
+	}
+
+	if !id.Lt(header.GetTarget()) {
+		return fmt.Errorf("block id %s does not meet target %s",
+			id.ShortString(8), header.GetTarget().ShortString(8))
+	}
+
+	return nil
+}
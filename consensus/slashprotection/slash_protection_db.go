@@ -0,0 +1,271 @@
+package slashprotection
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/types/atomicfile"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrStaleVote        = errors.New("refusing to sign below the last signed height/round/step")
+	ErrConflictingVote  = errors.New("refusing to sign a different block at an already signed height/round/step")
+	ErrUnknownSignerKey = errors.New("signer has no signing history")
+)
+
+// SignedRecord is one entry of a signer's history: the block ID signed at a
+// height/round/step (HRS).
+type SignedRecord struct {
+	Height  uint64 `json:"height"`
+	Round   uint64 `json:"round"`
+	Step    uint8  `json:"step"`
+	BlockID string `json:"block_id"`
+}
+
+func (r SignedRecord) compareHRS(height, round uint64, step uint8) int {
+	switch {
+	case r.Height != height:
+		return cmp(r.Height, height)
+	case r.Round != round:
+		return cmp(r.Round, round)
+	default:
+		return cmp(uint64(r.Step), uint64(step))
+	}
+}
+
+func cmp(a, b uint64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// SlashProtectionDB is a file-backed signing history. Every accepted request is
+// flushed to disk before CheckAndRecord returns, so a restarted node (or a
+// backup that imported the history) never signs a conflicting attestation.
+type SlashProtectionDB struct {
+	lock    sync.Mutex
+	path    string
+	history map[string][]SignedRecord
+}
+
+// Open loads the history stored at path, or starts an empty one if the file does
+// not exist yet. An empty path keeps the history in memory only.
+func Open(path string) (*SlashProtectionDB, error) {
+	db := &SlashProtectionDB{
+		path:    path,
+		history: make(map[string][]SignedRecord),
+	}
+
+	if path == "" {
+		return db, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slashing protection db: %w", err)
+	}
+
+	var stored map[string][]SignedRecord
+	if err = json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse slashing protection db: %w", err)
+	}
+
+	// Histories written with the wrapper's envelope are keyed differently.
+	for signer, records := range stored {
+		if err = mergeSigner(db.history, signer, records); err != nil {
+			return nil, fmt.Errorf("failed to load slashing protection db: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+// CheckAndRecord refuses requests lower than or conflicting with the signer's
+// latest record. Accepted requests are persisted before returning; re-signing
+// the exact same block at the same HRS is allowed.
+func (db *SlashProtectionDB) CheckAndRecord(signer key.PublicKey, height, round uint64, step uint8, blockID hash.Hash) error {
+	signerKey, blockIDStr, err := encodeRequest(signer, blockID)
+	if err != nil {
+		return err
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	records := db.history[signerKey]
+	if n := len(records); n > 0 {
+		last := records[n-1]
+		switch c := last.compareHRS(height, round, step); {
+		case c > 0:
+			return fmt.Errorf("%w: last=%d/%d/%d, requested=%d/%d/%d",
+				ErrStaleVote, last.Height, last.Round, last.Step, height, round, step)
+		case c == 0 && last.BlockID != blockIDStr:
+			return fmt.Errorf("%w: height=%d round=%d step=%d", ErrConflictingVote, height, round, step)
+		case c == 0:
+			return nil
+		}
+	}
+
+	db.history[signerKey] = append(records, SignedRecord{
+		Height:  height,
+		Round:   round,
+		Step:    step,
+		BlockID: blockIDStr,
+	})
+
+	if err = db.flush(); err != nil {
+		db.history[signerKey] = records
+		return err
+	}
+
+	return nil
+}
+
+func (db *SlashProtectionDB) LastSigned(signer key.PublicKey) (SignedRecord, error) {
+	signerKey, err := encodeSigner(signer)
+	if err != nil {
+		return SignedRecord{}, err
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	records := db.history[signerKey]
+	if len(records) == 0 {
+		return SignedRecord{}, ErrUnknownSignerKey
+	}
+	return records[len(records)-1], nil
+}
+
+// Prune drops every record below height while always keeping each signer's
+// latest record, which is all CheckAndRecord needs.
+func (db *SlashProtectionDB) Prune(height uint64) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	for signer, records := range db.history {
+		kept := make([]SignedRecord, 0, len(records))
+		for i, r := range records {
+			if r.Height >= height || i == len(records)-1 {
+				kept = append(kept, r)
+			}
+		}
+		db.history[signer] = kept
+	}
+
+	return db.flush()
+}
+
+func (db *SlashProtectionDB) flush() error {
+	if db.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(db.history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal slashing protection db: %w", err)
+	}
+
+	if err = atomicfile.WriteFile(db.path, data); err != nil {
+		return fmt.Errorf("failed to write slashing protection db: %w", err)
+	}
+
+	return nil
+}
+
+func encodeRequest(signer key.PublicKey, blockID hash.Hash) (string, string, error) {
+	if signer == nil {
+		return "", "", errors.New("signer cannot be nil")
+	}
+	if blockID == nil {
+		return "", "", errors.New("block id cannot be nil")
+	}
+
+	signerKey, err := encodeSigner(signer)
+	if err != nil {
+		return "", "", err
+	}
+
+	blockIDStr, err := encodeBlockID(blockID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return signerKey, blockIDStr, nil
+}
+
+// Signers and block IDs are stored as the hex of their canonical wrapped
+// bytes, which do not depend on the wrapper's envelope.
+func encodeSigner(signer key.PublicKey) (string, error) {
+	b, err := wrapper.WrapPublicKeyCanonical(signer)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(b), nil
+}
+
+func encodeBlockID(blockID hash.Hash) (string, error) {
+	b, err := wrapper.WrapHashCanonical(blockID)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(b), nil
+}
+
+// mergeSigner adds records, keyed and holding block IDs in any wrapped form,
+// to history under the signer's canonical key.
+func mergeSigner(history map[string][]SignedRecord, signer string, records []SignedRecord) error {
+	b, err := hex.DecodeString(strings.TrimPrefix(signer, "0x"))
+	if err != nil {
+		return fmt.Errorf("signer %s: %w", signer, err)
+	}
+	pubKey, err := wrapper.UnwrapPublicKeyCanonical(b)
+	if err != nil {
+		return fmt.Errorf("signer %s: %w", signer, err)
+	}
+	signerKey, err := encodeSigner(pubKey)
+	if err != nil {
+		return err
+	}
+
+	merged := history[signerKey]
+	for _, r := range records {
+		if b, err = hex.DecodeString(strings.TrimPrefix(r.BlockID, "0x")); err != nil {
+			return fmt.Errorf("signer %s: block id %s: %w", signer, r.BlockID, err)
+		}
+		blockID, err := wrapper.UnwrapHashCanonical(b)
+		if err != nil {
+			return fmt.Errorf("signer %s: block id %s: %w", signer, r.BlockID, err)
+		}
+		if r.BlockID, err = encodeBlockID(blockID); err != nil {
+			return err
+		}
+		if merged, err = mergeRecord(merged, r); err != nil {
+			return fmt.Errorf("signer %s: %w", signer, err)
+		}
+	}
+	sortRecords(merged)
+	history[signerKey] = merged
+	return nil
+}
+
+func sortRecords(records []SignedRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].compareHRS(records[j].Height, records[j].Round, records[j].Step) < 0
+	})
+}
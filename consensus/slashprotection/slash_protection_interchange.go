@@ -0,0 +1,101 @@
+package slashprotection
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const InterchangeFormatVersion = "1"
+
+type InterchangeMetadata struct {
+	FormatVersion string `json:"interchange_format_version"`
+}
+
+type InterchangeSigner struct {
+	Signer string         `json:"signer"`
+	Signed []SignedRecord `json:"signed"`
+}
+
+// Interchange is the portable form of the signing history, used to move a
+// validator key between machines without losing its protection.
+type Interchange struct {
+	Metadata InterchangeMetadata `json:"metadata"`
+	Data     []InterchangeSigner `json:"data"`
+}
+
+func (db *SlashProtectionDB) Export(w io.Writer) error {
+	db.lock.Lock()
+	signers := make([]string, 0, len(db.history))
+	for signer := range db.history {
+		signers = append(signers, signer)
+	}
+	sort.Strings(signers)
+
+	ic := Interchange{
+		Metadata: InterchangeMetadata{FormatVersion: InterchangeFormatVersion},
+		Data:     make([]InterchangeSigner, 0, len(signers)),
+	}
+	for _, signer := range signers {
+		ic.Data = append(ic.Data, InterchangeSigner{
+			Signer: signer,
+			Signed: append([]SignedRecord(nil), db.history[signer]...),
+		})
+	}
+	db.lock.Unlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ic)
+}
+
+// Import merges an exported history into the database. The import is rejected as
+// a whole if any record conflicts with what is already stored.
+func (db *SlashProtectionDB) Import(r io.Reader) error {
+	var ic Interchange
+	if err := json.NewDecoder(r).Decode(&ic); err != nil {
+		return fmt.Errorf("failed to parse slashing protection interchange: %w", err)
+	}
+
+	if ic.Metadata.FormatVersion != InterchangeFormatVersion {
+		return fmt.Errorf("unsupported slashing protection interchange version: %q", ic.Metadata.FormatVersion)
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	merged := make(map[string][]SignedRecord, len(db.history))
+	for signer, records := range db.history {
+		merged[signer] = append([]SignedRecord(nil), records...)
+	}
+
+	for _, entry := range ic.Data {
+		if err := mergeSigner(merged, entry.Signer, entry.Signed); err != nil {
+			return err
+		}
+	}
+
+	previous := db.history
+	db.history = merged
+	if err := db.flush(); err != nil {
+		db.history = previous
+		return err
+	}
+
+	return nil
+}
+
+func mergeRecord(records []SignedRecord, incoming SignedRecord) ([]SignedRecord, error) {
+	for _, r := range records {
+		if r.compareHRS(incoming.Height, incoming.Round, incoming.Step) != 0 {
+			continue
+		}
+		if r.BlockID != incoming.BlockID {
+			return nil, fmt.Errorf("%w: height=%d round=%d step=%d",
+				ErrConflictingVote, incoming.Height, incoming.Round, incoming.Step)
+		}
+		return records, nil
+	}
+	return append(records, incoming), nil
+}
@@ -0,0 +1,49 @@
+package slashprotection
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+)
+
+// ProtectedSigner is the only path validator code should use to produce
+// attestations: the request is checked against (and recorded in) the database
+// before the private key is ever asked to sign.
+type ProtectedSigner struct {
+	privKey key.PrivateKey
+	db      *SlashProtectionDB
+}
+
+func NewProtectedSigner(privKey key.PrivateKey, db *SlashProtectionDB) (*ProtectedSigner, error) {
+	if privKey == nil || !privKey.IsValid() {
+		return nil, errors.New("invalid private key")
+	}
+	if db == nil {
+		return nil, errors.New("slashing protection db cannot be nil")
+	}
+
+	return &ProtectedSigner{
+		privKey: privKey,
+		db:      db,
+	}, nil
+}
+
+func (s *ProtectedSigner) PublicKey() key.PublicKey {
+	return s.privKey.PublicKey()
+}
+
+func (s *ProtectedSigner) SignAttestation(height, round uint64, step uint8, blockID hash.Hash) (block.Attestation, error) {
+	if err := s.db.CheckAndRecord(s.privKey.PublicKey(), height, round, step, blockID); err != nil {
+		return nil, err
+	}
+
+	att, err := kangarooattestation.Sign(s.privKey, height, round, step, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign attestation: %w", err)
+	}
+
+	return att, nil
+}
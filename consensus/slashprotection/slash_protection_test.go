@@ -0,0 +1,196 @@
+package slashprotection
+
+import (
+	"bytes"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	_ "github.com/andantan/kangaroo/core/all"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setup(t *testing.T) (key.PrivateKey, hash.HashDeriver) {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+
+	privKey, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	return privKey, hashSuite.Deriver()
+}
+
+func TestSlashProtectionDB_CheckAndRecord(t *testing.T) {
+	privKey, hasher := setup(t)
+	pubKey := privKey.PublicKey()
+	blockA := hasher.Derive([]byte("block_a"))
+	blockB := hasher.Derive([]byte("block_b"))
+
+	db, err := Open("")
+	require.NoError(t, err)
+
+	_, err = db.LastSigned(pubKey)
+	assert.ErrorIs(t, err, ErrUnknownSignerKey)
+
+	require.NoError(t, db.CheckAndRecord(pubKey, 10, 0, 0, blockA))
+
+	t.Run("should allow re-signing the same block", func(t *testing.T) {
+		assert.NoError(t, db.CheckAndRecord(pubKey, 10, 0, 0, blockA))
+	})
+
+	t.Run("should refuse a conflicting block", func(t *testing.T) {
+		assert.ErrorIs(t, db.CheckAndRecord(pubKey, 10, 0, 0, blockB), ErrConflictingVote)
+	})
+
+	t.Run("should refuse lower height, round or step", func(t *testing.T) {
+		require.NoError(t, db.CheckAndRecord(pubKey, 10, 2, 1, blockB))
+		assert.ErrorIs(t, db.CheckAndRecord(pubKey, 9, 5, 0, blockA), ErrStaleVote)
+		assert.ErrorIs(t, db.CheckAndRecord(pubKey, 10, 1, 2, blockA), ErrStaleVote)
+		assert.ErrorIs(t, db.CheckAndRecord(pubKey, 10, 2, 0, blockB), ErrStaleVote)
+	})
+
+	t.Run("should allow higher height", func(t *testing.T) {
+		assert.NoError(t, db.CheckAndRecord(pubKey, 11, 0, 0, blockA))
+		last, err := db.LastSigned(pubKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(11), last.Height)
+	})
+
+	t.Run("should keep the latest record on prune", func(t *testing.T) {
+		require.NoError(t, db.Prune(100))
+		last, err := db.LastSigned(pubKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(11), last.Height)
+		assert.ErrorIs(t, db.CheckAndRecord(pubKey, 10, 9, 0, blockA), ErrStaleVote)
+	})
+}
+
+func TestSlashProtectionDB_SurvivesRestart(t *testing.T) {
+	privKey, hasher := setup(t)
+	path := filepath.Join(t.TempDir(), "slashing.json")
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, db.CheckAndRecord(privKey.PublicKey(), 5, 1, 0, hasher.Derive([]byte("block_a"))))
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	err = reopened.CheckAndRecord(privKey.PublicKey(), 5, 1, 0, hasher.Derive([]byte("block_b")))
+	assert.ErrorIs(t, err, ErrConflictingVote)
+}
+
+func TestSlashProtectionDB_ImportExport(t *testing.T) {
+	privKey, hasher := setup(t)
+	pubKey := privKey.PublicKey()
+
+	primary, err := Open("")
+	require.NoError(t, err)
+	require.NoError(t, primary.CheckAndRecord(pubKey, 20, 0, 0, hasher.Derive([]byte("block_a"))))
+	require.NoError(t, primary.CheckAndRecord(pubKey, 21, 0, 0, hasher.Derive([]byte("block_b"))))
+
+	var buf bytes.Buffer
+	require.NoError(t, primary.Export(&buf))
+	exported := buf.Bytes()
+
+	t.Run("backup should inherit the protection", func(t *testing.T) {
+		backup, err := Open(filepath.Join(t.TempDir(), "backup.json"))
+		require.NoError(t, err)
+		require.NoError(t, backup.Import(bytes.NewReader(exported)))
+
+		last, err := backup.LastSigned(pubKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(21), last.Height)
+		assert.ErrorIs(t, backup.CheckAndRecord(pubKey, 20, 0, 0, hasher.Derive([]byte("block_c"))), ErrStaleVote)
+	})
+
+	t.Run("should reject conflicting imports", func(t *testing.T) {
+		other, err := Open("")
+		require.NoError(t, err)
+		require.NoError(t, other.CheckAndRecord(pubKey, 21, 0, 0, hasher.Derive([]byte("block_x"))))
+
+		assert.ErrorIs(t, other.Import(bytes.NewReader(exported)), ErrConflictingVote)
+		last, err := other.LastSigned(pubKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(21), last.Height)
+	})
+
+	t.Run("should reject unknown versions", func(t *testing.T) {
+		db, err := Open("")
+		require.NoError(t, err)
+		assert.Error(t, db.Import(bytes.NewReader([]byte(`{"metadata":{"interchange_format_version":"99"},"data":[]}`))))
+	})
+}
+
+func TestProtectedSigner_SignAttestation(t *testing.T) {
+	privKey, hasher := setup(t)
+	db, err := Open("")
+	require.NoError(t, err)
+
+	signer, err := NewProtectedSigner(privKey, db)
+	require.NoError(t, err)
+
+	blockA := hasher.Derive([]byte("block_a"))
+	att, err := signer.SignAttestation(1, 0, 0, blockA)
+	require.NoError(t, err)
+	assert.True(t, att.Verify())
+	assert.True(t, att.GetBlockID().Equal(blockA))
+	assert.True(t, att.GetSigner().Equal(privKey.PublicKey()))
+
+	_, err = signer.SignAttestation(1, 0, 0, hasher.Derive([]byte("block_b")))
+	assert.ErrorIs(t, err, ErrConflictingVote)
+}
+
+func TestSlashProtectionDB_LegacyKeys(t *testing.T) {
+	_, hasher := setup(t)
+	pubKey, err := wrapper.UnwrapPublicKeyFromString("0x0387addb00c23ae79c3c3fe56afe73005b856e7dd6670f2303e9819d2e844d8dfd")
+	require.NoError(t, err)
+	blockA := hasher.Derive([]byte("block_a"))
+	blockB := hasher.Derive([]byte("block_b"))
+
+	// Written before wrapped bytes got an envelope, plus a record keyed by
+	// an envelope.
+	enveloped, err := wrapper.WrapPublicKeyToString(pubKey)
+	require.NoError(t, err)
+	blockBID, err := wrapper.WrapHashToString(blockB)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "slashing.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "0x0387addb00c23ae79c3c3fe56afe73005b856e7dd6670f2303e9819d2e844d8dfd": [
+    {"height": 10, "round": 0, "step": 0, "block_id": "0x012efe8d296595f30a243e5f201be0642b6555fabfcb30d58750c3f45b787c5858"}
+  ],
+  "`+enveloped+`": [
+    {"height": 12, "round": 0, "step": 0, "block_id": "`+blockBID+`"}
+  ]
+}`), 0600))
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	last, err := db.LastSigned(pubKey)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12), last.Height)
+	assert.ErrorIs(t, db.CheckAndRecord(pubKey, 5, 0, 0, blockA), ErrStaleVote)
+	assert.ErrorIs(t, db.CheckAndRecord(pubKey, 12, 0, 0, blockA), ErrConflictingVote)
+	assert.NoError(t, db.CheckAndRecord(pubKey, 12, 0, 0, blockB))
+
+	// The same history arriving through an interchange file.
+	var buf bytes.Buffer
+	require.NoError(t, db.Export(&buf))
+	legacy := strings.ReplaceAll(buf.String(), "0x0387addb", "0x0387ADDB")
+	other, err := Open("")
+	require.NoError(t, err)
+	require.NoError(t, other.CheckAndRecord(pubKey, 10, 0, 0, blockA))
+	require.NoError(t, other.Import(strings.NewReader(legacy)))
+	assert.ErrorIs(t, other.CheckAndRecord(pubKey, 11, 0, 0, blockA), ErrStaleVote)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"0xzz": []}`), 0600))
+	_, err = Open(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/crypto/key"
+)
+
+var ErrNotElected = errors.New("not elected to propose")
+
+// ProposerElection decides who may propose at a height and round. seed is the
+// ID of the parent block, so elections cannot be computed long in advance.
+type ProposerElection interface {
+	// Prove returns the local validator's proof of being elected, or ErrNotElected.
+	Prove(vs *ValidatorSet, seed []byte, height, round uint64) ([]byte, error)
+	// Verify checks a proposer's proof before its proposal is accepted.
+	Verify(vs *ValidatorSet, proposer key.PublicKey, seed []byte, height, round uint64, proof []byte) error
+}
+
+// SelectorElection elects the single proposer a ProposerSelector picks. Anyone
+// can recompute the choice, so no proof is needed.
+type SelectorElection struct {
+	selector ProposerSelector
+	self     key.PublicKey
+}
+
+var _ ProposerElection = (*SelectorElection)(nil)
+
+func NewSelectorElection(selector ProposerSelector, self key.PublicKey) *SelectorElection {
+	return &SelectorElection{
+		selector: selector,
+		self:     self,
+	}
+}
+
+func (e *SelectorElection) Prove(vs *ValidatorSet, _ []byte, height, round uint64) ([]byte, error) {
+	proposer := e.selector.Proposer(vs, height, round)
+	if proposer == nil || !proposer.PublicKey.Equal(e.self) {
+		return nil, ErrNotElected
+	}
+	return nil, nil
+}
+
+func (e *SelectorElection) Verify(vs *ValidatorSet, proposer key.PublicKey, _ []byte, height, round uint64, _ []byte) error {
+	expected := e.selector.Proposer(vs, height, round)
+	if expected == nil || !expected.PublicKey.Equal(proposer) {
+		return fmt.Errorf("%w: %s at height %d round %d", ErrNotElected, proposer.ShortString(8), height, round)
+	}
+	return nil
+}
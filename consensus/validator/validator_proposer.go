@@ -0,0 +1,112 @@
+package validator
+
+import (
+	"sync"
+)
+
+// ProposerSelector picks the validator allowed to propose at a height and round.
+// Every node must derive the same proposer from the same inputs.
+type ProposerSelector interface {
+	Proposer(vs *ValidatorSet, height, round uint64) *Validator
+}
+
+// RoundRobinSelector rotates through the validators in set order, ignoring power.
+type RoundRobinSelector struct{}
+
+var _ ProposerSelector = (*RoundRobinSelector)(nil)
+
+func (s *RoundRobinSelector) Proposer(vs *ValidatorSet, height, round uint64) *Validator {
+	return vs.GetByIndex(int((height + round) % uint64(vs.Size())))
+}
+
+const (
+	// wrrCheckpointInterval is the number of steps between cached priority snapshots.
+	wrrCheckpointInterval = 1024
+	// wrrMaxCachedSets bounds the number of validator sets with cached schedules.
+	wrrMaxCachedSets = 8
+)
+
+// WeightedRoundRobinSelector lets every validator propose in proportion to its
+// power, spread as evenly as possible (smooth weighted round robin, the same
+// idea as Tendermint's proposer priority). At every step each validator's
+// priority grows by its power; the one with the highest priority proposes and
+// pays back the total power. Step height+round is the proposer of that round.
+type WeightedRoundRobinSelector struct {
+	lock      sync.Mutex
+	schedules map[*ValidatorSet]*wrrSchedule
+}
+
+var _ ProposerSelector = (*WeightedRoundRobinSelector)(nil)
+
+func NewWeightedRoundRobinSelector() *WeightedRoundRobinSelector {
+	return &WeightedRoundRobinSelector{
+		schedules: make(map[*ValidatorSet]*wrrSchedule),
+	}
+}
+
+func (s *WeightedRoundRobinSelector) Proposer(vs *ValidatorSet, height, round uint64) *Validator {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.schedules == nil {
+		s.schedules = make(map[*ValidatorSet]*wrrSchedule)
+	}
+
+	schedule, ok := s.schedules[vs]
+	if !ok {
+		if len(s.schedules) >= wrrMaxCachedSets {
+			s.schedules = make(map[*ValidatorSet]*wrrSchedule)
+		}
+		schedule = newWRRSchedule(vs)
+		s.schedules[vs] = schedule
+	}
+
+	return vs.GetByIndex(schedule.proposerAt(height + round))
+}
+
+// wrrSchedule caches the priorities at every checkpoint so that any step can be
+// replayed in at most wrrCheckpointInterval steps.
+type wrrSchedule struct {
+	vs          *ValidatorSet
+	checkpoints [][]int64
+}
+
+func newWRRSchedule(vs *ValidatorSet) *wrrSchedule {
+	return &wrrSchedule{
+		vs:          vs,
+		checkpoints: [][]int64{make([]int64, vs.Size())},
+	}
+}
+
+func (s *wrrSchedule) proposerAt(step uint64) int {
+	cp := step / wrrCheckpointInterval
+	for uint64(len(s.checkpoints)) <= cp {
+		next := append([]int64(nil), s.checkpoints[len(s.checkpoints)-1]...)
+		for i := 0; i < wrrCheckpointInterval; i++ {
+			s.advance(next)
+		}
+		s.checkpoints = append(s.checkpoints, next)
+	}
+
+	priorities := append([]int64(nil), s.checkpoints[cp]...)
+	for i := cp * wrrCheckpointInterval; i < step; i++ {
+		s.advance(priorities)
+	}
+
+	return s.advance(priorities)
+}
+
+// advance runs one step on priorities and returns the index that proposes.
+// Ties go to the lower index.
+func (s *wrrSchedule) advance(priorities []int64) int {
+	best := 0
+	for i := range priorities {
+		priorities[i] += int64(s.vs.GetByIndex(i).Power)
+		if priorities[i] > priorities[best] {
+			best = i
+		}
+	}
+
+	priorities[best] -= int64(s.vs.TotalPower())
+	return best
+}
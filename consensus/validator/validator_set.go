@@ -0,0 +1,154 @@
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/key"
+	"math"
+	"sort"
+	"strings"
+)
+
+// MaxTotalVotingPower keeps proposer priorities far away from overflowing int64.
+const MaxTotalVotingPower = math.MaxInt64 / 8
+
+type Validator struct {
+	PublicKey key.PublicKey
+	Power     uint64
+}
+
+func NewValidator(pubKey key.PublicKey, power uint64) *Validator {
+	return &Validator{
+		PublicKey: pubKey,
+		Power:     power,
+	}
+}
+
+func (v *Validator) String() string {
+	return fmt.Sprintf("Validator{%s, Power: %d}", v.PublicKey.ShortString(8), v.Power)
+}
+
+// ValidatorSet is an immutable, deterministically ordered set of validators.
+// Validators are ordered by their wrapped public key so every node derives the
+// same indices from the same members.
+type ValidatorSet struct {
+	validators []*Validator
+	index      map[string]int
+	totalPower uint64
+}
+
+func NewValidatorSet(validators []*Validator) (*ValidatorSet, error) {
+	if len(validators) == 0 {
+		return nil, errors.New("validator set cannot be empty")
+	}
+
+	type keyed struct {
+		wrapped []byte
+		val     *Validator
+	}
+
+	entries := make([]keyed, 0, len(validators))
+	for _, v := range validators {
+		if v == nil || v.PublicKey == nil {
+			return nil, errors.New("validator public key cannot be nil")
+		}
+		if v.Power == 0 {
+			return nil, fmt.Errorf("validator %s has no voting power", v.PublicKey.ShortString(8))
+		}
+
+		wrapped, err := wrapper.WrapPublicKeyCanonical(v.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, keyed{wrapped: wrapped, val: NewValidator(v.PublicKey, v.Power)})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].wrapped, entries[j].wrapped) < 0
+	})
+
+	vs := &ValidatorSet{
+		validators: make([]*Validator, len(entries)),
+		index:      make(map[string]int, len(entries)),
+	}
+
+	for i, e := range entries {
+		k := string(e.wrapped)
+		if _, exists := vs.index[k]; exists {
+			return nil, fmt.Errorf("duplicate validator: %s", e.val.PublicKey.ShortString(8))
+		}
+		vs.index[k] = i
+		vs.validators[i] = e.val
+		vs.totalPower += e.val.Power
+
+		if e.val.Power > MaxTotalVotingPower || vs.totalPower > MaxTotalVotingPower {
+			return nil, fmt.Errorf("total voting power exceeds %d", uint64(MaxTotalVotingPower))
+		}
+	}
+
+	return vs, nil
+}
+
+func (vs *ValidatorSet) Size() int {
+	return len(vs.validators)
+}
+
+func (vs *ValidatorSet) TotalPower() uint64 {
+	return vs.totalPower
+}
+
+func (vs *ValidatorSet) GetByIndex(i int) *Validator {
+	if i < 0 || i >= len(vs.validators) {
+		return nil
+	}
+	return vs.validators[i]
+}
+
+// GetByPublicKey returns the validator and its index, or -1 and nil when the
+// key is not part of the set.
+func (vs *ValidatorSet) GetByPublicKey(pubKey key.PublicKey) (int, *Validator) {
+	if pubKey == nil {
+		return -1, nil
+	}
+
+	wrapped, err := wrapper.WrapPublicKeyCanonical(pubKey)
+	if err != nil {
+		return -1, nil
+	}
+
+	i, ok := vs.index[string(wrapped)]
+	if !ok {
+		return -1, nil
+	}
+	return i, vs.validators[i]
+}
+
+func (vs *ValidatorSet) Has(pubKey key.PublicKey) bool {
+	i, _ := vs.GetByPublicKey(pubKey)
+	return i >= 0
+}
+
+func (vs *ValidatorSet) Validators() []*Validator {
+	return append([]*Validator(nil), vs.validators...)
+}
+
+// HasQuorum reports whether power is strictly more than two thirds of the total.
+func (vs *ValidatorSet) HasQuorum(power uint64) bool {
+	return power*3 > vs.totalPower*2
+}
+
+// HasOneThird reports whether power is strictly more than one third of the total,
+// i.e. at least one honest validator is guaranteed to be among them.
+func (vs *ValidatorSet) HasOneThird(power uint64) bool {
+	return power*3 > vs.totalPower
+}
+
+func (vs *ValidatorSet) String() string {
+	parts := make([]string, len(vs.validators))
+	for i, v := range vs.validators {
+		parts[i] = v.String()
+	}
+	return fmt.Sprintf("ValidatorSet{TotalPower: %d, Validators: [%s]}", vs.totalPower, strings.Join(parts, ", "))
+}
@@ -0,0 +1,219 @@
+package validator
+
+import (
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func generateKeys(t *testing.T, n int) []key.PrivateKey {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+
+	keys := make([]key.PrivateKey, n)
+	for i := range keys {
+		keys[i], err = keySuite.GeneratePrivateKey()
+		require.NoError(t, err)
+	}
+	return keys
+}
+
+func TestValidatorSet(t *testing.T) {
+	keys := generateKeys(t, 4)
+
+	vals := []*Validator{
+		NewValidator(keys[0].PublicKey(), 10),
+		NewValidator(keys[1].PublicKey(), 20),
+		NewValidator(keys[2].PublicKey(), 30),
+		NewValidator(keys[3].PublicKey(), 40),
+	}
+
+	vs, err := NewValidatorSet(vals)
+	require.NoError(t, err)
+	t.Logf("%s\n", vs)
+
+	assert.Equal(t, 4, vs.Size())
+	assert.Equal(t, uint64(100), vs.TotalPower())
+
+	t.Run("order does not depend on input order", func(t *testing.T) {
+		reversed, err := NewValidatorSet([]*Validator{vals[3], vals[2], vals[1], vals[0]})
+		require.NoError(t, err)
+		for i := 0; i < vs.Size(); i++ {
+			assert.True(t, vs.GetByIndex(i).PublicKey.Equal(reversed.GetByIndex(i).PublicKey))
+		}
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		for _, k := range keys {
+			idx, v := vs.GetByPublicKey(k.PublicKey())
+			require.NotNil(t, v)
+			assert.True(t, vs.GetByIndex(idx).PublicKey.Equal(k.PublicKey()))
+			assert.True(t, vs.Has(k.PublicKey()))
+		}
+
+		outsider := generateKeys(t, 1)[0]
+		idx, v := vs.GetByPublicKey(outsider.PublicKey())
+		assert.Equal(t, -1, idx)
+		assert.Nil(t, v)
+		assert.Nil(t, vs.GetByIndex(4))
+	})
+
+	t.Run("thresholds", func(t *testing.T) {
+		assert.False(t, vs.HasQuorum(66))
+		assert.True(t, vs.HasQuorum(67))
+		assert.False(t, vs.HasOneThird(33))
+		assert.True(t, vs.HasOneThird(34))
+	})
+
+	t.Run("invalid sets", func(t *testing.T) {
+		_, err := NewValidatorSet(nil)
+		assert.Error(t, err)
+		_, err = NewValidatorSet([]*Validator{vals[0], vals[0]})
+		assert.Error(t, err)
+		_, err = NewValidatorSet([]*Validator{NewValidator(keys[0].PublicKey(), 0)})
+		assert.Error(t, err)
+	})
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	keys := generateKeys(t, 3)
+	vals := make([]*Validator, len(keys))
+	for i, k := range keys {
+		vals[i] = NewValidator(k.PublicKey(), 1)
+	}
+
+	vs, err := NewValidatorSet(vals)
+	require.NoError(t, err)
+
+	selector := &RoundRobinSelector{}
+	for height := uint64(0); height < 3; height++ {
+		for round := uint64(0); round < 3; round++ {
+			expected := vs.GetByIndex(int((height + round) % 3))
+			assert.True(t, selector.Proposer(vs, height, round).PublicKey.Equal(expected.PublicKey))
+		}
+	}
+}
+
+func TestWeightedRoundRobinSelector(t *testing.T) {
+	keys := generateKeys(t, 3)
+	vs, err := NewValidatorSet([]*Validator{
+		NewValidator(keys[0].PublicKey(), 1),
+		NewValidator(keys[1].PublicKey(), 2),
+		NewValidator(keys[2].PublicKey(), 3),
+	})
+	require.NoError(t, err)
+
+	selector := NewWeightedRoundRobinSelector()
+
+	t.Run("proposals follow voting power", func(t *testing.T) {
+		counts := make(map[uint64]int)
+		for step := uint64(0); step < 600; step++ {
+			counts[selector.Proposer(vs, step, 0).Power]++
+		}
+		assert.Equal(t, 100, counts[1])
+		assert.Equal(t, 200, counts[2])
+		assert.Equal(t, 300, counts[3])
+	})
+
+	t.Run("proposals are spread evenly", func(t *testing.T) {
+		// every window of total power steps holds each validator exactly power times
+		for start := uint64(0); start < 50; start++ {
+			counts := make(map[uint64]int)
+			for step := start; step < start+6; step++ {
+				counts[selector.Proposer(vs, step, 0).Power]++
+			}
+			assert.Equal(t, map[uint64]int{1: 1, 2: 2, 3: 3}, counts, "window at %d", start)
+		}
+	})
+
+	t.Run("height and round share one sequence", func(t *testing.T) {
+		for height := uint64(0); height < 10; height++ {
+			for round := uint64(0); round < 10; round++ {
+				assert.True(t, selector.Proposer(vs, height+round, 0).PublicKey.Equal(
+					selector.Proposer(vs, height, round).PublicKey))
+			}
+		}
+	})
+
+	t.Run("queries are independent of order and cache", func(t *testing.T) {
+		far := selector.Proposer(vs, 5*wrrCheckpointInterval+17, 3)
+		fresh := NewWeightedRoundRobinSelector()
+		assert.True(t, far.PublicKey.Equal(fresh.Proposer(vs, 5*wrrCheckpointInterval+17, 3).PublicKey))
+		assert.True(t, selector.Proposer(vs, 2, 1).PublicKey.Equal(fresh.Proposer(vs, 2, 1).PublicKey))
+	})
+}
+
+func TestVRFElection(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("schnorr-sr25519")
+	require.NoError(t, err)
+
+	const size = 5
+	keys := make([]key.PrivateKey, size)
+	vals := make([]*Validator, size)
+	for i := range keys {
+		keys[i], err = keySuite.GeneratePrivateKey()
+		require.NoError(t, err)
+		vals[i] = NewValidator(keys[i].PublicKey(), uint64(i+1))
+	}
+
+	vs, err := NewValidatorSet(vals)
+	require.NoError(t, err)
+
+	elections := make([]*VRFElection, size)
+	for i, k := range keys {
+		elections[i], err = NewVRFElection(k, DefaultExpectedProposers)
+		require.NoError(t, err)
+	}
+
+	verifier, err := NewVRFElection(nil, DefaultExpectedProposers)
+	require.NoError(t, err)
+
+	seed := []byte("parent block id")
+	const rounds = 400
+	wins := 0
+
+	for round := uint64(0); round < rounds; round++ {
+		for i, election := range elections {
+			proof, err := election.Prove(vs, seed, 7, round)
+			if err != nil {
+				require.ErrorIs(t, err, ErrNotElected)
+				continue
+			}
+			wins++
+
+			pubKey := keys[i].PublicKey()
+			require.NoError(t, verifier.Verify(vs, pubKey, seed, 7, round, proof))
+
+			assert.Error(t, verifier.Verify(vs, pubKey, []byte("other seed"), 7, round, proof))
+			assert.Error(t, verifier.Verify(vs, pubKey, seed, 8, round, proof))
+			assert.Error(t, verifier.Verify(vs, keys[(i+1)%size].PublicKey(), seed, 7, round, proof))
+
+			tampered := append([]byte(nil), proof...)
+			tampered[len(tampered)-1] ^= 0xff
+			assert.Error(t, verifier.Verify(vs, pubKey, seed, 7, round, tampered))
+		}
+	}
+
+	// about DefaultExpectedProposers winners per round
+	average := float64(wins) / rounds
+	assert.InDelta(t, DefaultExpectedProposers, average, 0.3)
+
+	t.Run("requires sr25519 keys", func(t *testing.T) {
+		_, err := NewVRFElection(generateKeys(t, 1)[0], DefaultExpectedProposers)
+		assert.Error(t, err)
+		_, err = NewVRFElection(keys[0], 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("outsiders are never elected", func(t *testing.T) {
+		outsider, err := keySuite.GeneratePrivateKey()
+		require.NoError(t, err)
+		election, err := NewVRFElection(outsider, float64(size*10))
+		require.NoError(t, err)
+		_, err = election.Prove(vs, seed, 7, 0)
+		assert.ErrorIs(t, err, ErrNotElected)
+	})
+}
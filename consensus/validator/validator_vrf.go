@@ -0,0 +1,124 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/crypto/key/schnorr"
+	"github.com/andantan/kangaroo/crypto/key/schnorr/sr25519"
+	"math/big"
+)
+
+const (
+	DefaultExpectedProposers = 1.0
+	vrfTicketDomain          = "kangaroo-vrf-ticket"
+)
+
+// VRFElection is a private lottery: every validator evaluates the sr25519 VRF on
+// (seed, height, round) and is elected when the resulting ticket falls below a
+// threshold proportional to its power, so that ExpectedProposers validators win
+// a round on average. Nobody learns who won until the winner reveals its proof.
+//
+// A round may elect no one or several validators. The engine then fails the round
+// and moves on to the next one; safety never depends on the lottery.
+type VRFElection struct {
+	privKey  *sr25519.SchnorrSr25519PrivateKey
+	expected *big.Rat
+}
+
+var _ ProposerElection = (*VRFElection)(nil)
+
+// NewVRFElection returns an election for the validator holding privKey. A nil key
+// gives an election that can only verify.
+func NewVRFElection(privKey key.PrivateKey, expectedProposers float64) (*VRFElection, error) {
+	if expectedProposers <= 0 {
+		return nil, errors.New("expected proposers must be positive")
+	}
+
+	e := &VRFElection{
+		expected: new(big.Rat).SetFloat64(expectedProposers),
+	}
+
+	if privKey != nil {
+		sk, ok := privKey.(*sr25519.SchnorrSr25519PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("vrf election requires a %s key, got %s", schnorr.SchnorrSr25519Type, privKey.Type())
+		}
+		e.privKey = sk
+	}
+
+	return e, nil
+}
+
+func (e *VRFElection) Prove(vs *ValidatorSet, seed []byte, height, round uint64) ([]byte, error) {
+	if e.privKey == nil {
+		return nil, ErrNotElected
+	}
+
+	_, val := vs.GetByPublicKey(e.privKey.PublicKey())
+	if val == nil {
+		return nil, ErrNotElected
+	}
+
+	output, proof, err := e.privKey.VrfSign(vrfInput(seed, height, round))
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.wins(vs, val, output) {
+		return nil, ErrNotElected
+	}
+
+	return append(output, proof...), nil
+}
+
+func (e *VRFElection) Verify(vs *ValidatorSet, proposer key.PublicKey, seed []byte, height, round uint64, proof []byte) error {
+	pk, ok := proposer.(*sr25519.SchnorrSr25519PublicKey)
+	if !ok {
+		return fmt.Errorf("vrf election requires a %s key, got %s", schnorr.SchnorrSr25519Type, proposer.Type())
+	}
+
+	_, val := vs.GetByPublicKey(proposer)
+	if val == nil {
+		return fmt.Errorf("%w: %s is not a validator", ErrNotElected, proposer.ShortString(8))
+	}
+
+	if len(proof) != schnorr.SchnorrSr25519VrfOutputBytesLength+schnorr.SchnorrSr25519VrfProofBytesLength {
+		return fmt.Errorf("invalid vrf election proof length %d", len(proof))
+	}
+
+	output := proof[:schnorr.SchnorrSr25519VrfOutputBytesLength]
+	if err := pk.VrfVerify(vrfInput(seed, height, round), output, proof[schnorr.SchnorrSr25519VrfOutputBytesLength:]); err != nil {
+		return err
+	}
+
+	if !e.wins(vs, val, output) {
+		return fmt.Errorf("%w: ticket of %s is above its threshold", ErrNotElected, proposer.ShortString(8))
+	}
+
+	return nil
+}
+
+// wins reports ticket / 2^256 < expected * power / total, in exact arithmetic so
+// that every platform agrees.
+func (e *VRFElection) wins(vs *ValidatorSet, val *Validator, output []byte) bool {
+	digest := sha256.Sum256(append([]byte(vrfTicketDomain), output...))
+	ticket := new(big.Int).SetBytes(digest[:])
+
+	lhs := new(big.Int).Mul(ticket, new(big.Int).SetUint64(vs.TotalPower()))
+	lhs.Mul(lhs, e.expected.Denom())
+
+	rhs := new(big.Int).Mul(e.expected.Num(), new(big.Int).SetUint64(val.Power))
+	rhs.Lsh(rhs, 256)
+
+	return lhs.Cmp(rhs) < 0
+}
+
+func vrfInput(seed []byte, height, round uint64) []byte {
+	b := make([]byte, 0, len(seed)+16)
+	b = append(b, seed...)
+	b = binary.BigEndian.AppendUint64(b, height)
+	return binary.BigEndian.AppendUint64(b, round)
+}
@@ -2,6 +2,10 @@ package all
 
 import (
 	_ "github.com/andantan/kangaroo/core/block/kangarooattestation"
+	_ "github.com/andantan/kangaroo/core/block/kangarooblock"
 	_ "github.com/andantan/kangaroo/core/block/kangaroobody"
+	_ "github.com/andantan/kangaroo/core/block/kangarooevidence"
+	_ "github.com/andantan/kangaroo/core/block/kangarooheader"
+	_ "github.com/andantan/kangaroo/core/block/kangarootail"
 	_ "github.com/andantan/kangaroo/core/transaction/kangarootransaction"
 )
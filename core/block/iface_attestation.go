@@ -17,6 +17,9 @@ type Attestation interface {
 	format.StringTypable
 	format.Verifyable
 
+	GetHeight() uint64
+	GetRound() uint64
+	GetStep() uint8
 	GetBlockID() hash.Hash
 	GetSigner() key.PublicKey
 	GetSignature() key.Signature
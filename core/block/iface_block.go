@@ -1 +1,28 @@
 package block
+
+import (
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/types/format"
+)
+
+const (
+	KangarooBlockType = "kangaroo"
+)
+
+type Block interface {
+	hash.Hashable // block id, the hash of the header
+	codec.ProtoCodec
+	format.Stringable
+	format.StringTypable
+
+	GetHeader() Header
+	GetBody() Body
+	GetTail() Tail
+}
+
+type BlockSuite interface {
+	format.StringTypable
+
+	NewBlock() Block
+}
@@ -18,6 +18,7 @@ type Body interface {
 	format.StringTypable
 
 	GetTransactions() []transaction.Transaction
+	GetEvidence() []Evidence
 	GetWeight() uint64
 }
 
@@ -0,0 +1,33 @@
+package block
+
+import (
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/types/format"
+)
+
+const (
+	KangarooEvidenceType = "kangaroo"
+)
+
+// Evidence proves that a single signer attested to two different block IDs
+// for the same height and round (double-signing).
+type Evidence interface {
+	hash.Hashable
+	codec.ProtoCodec
+	format.Stringable
+	format.StringTypable
+	format.Verifyable
+
+	GetHeight() uint64
+	GetRound() uint64
+	GetSigner() key.PublicKey
+	GetAttestations() (Attestation, Attestation)
+}
+
+type EvidenceSuite interface {
+	format.StringTypable
+
+	NewEvidence() Evidence
+}
@@ -1 +1,36 @@
 package block
+
+import (
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/types/format"
+)
+
+const (
+	KangarooHeaderType = "kangaroo"
+)
+
+type Header interface {
+	hash.Hashable // block id
+	codec.ProtoCodec
+	format.Stringable
+	format.StringTypable
+
+	GetHeight() uint64
+	GetTimestamp() int64
+	GetPrevBlockID() hash.Hash
+	GetBodyHash() hash.Hash
+	GetStateRoot() hash.Hash
+	GetProposer() key.PublicKey
+	// GetNonce and GetTarget are the proof-of-work seal; both are zero for
+	// blocks sealed by attestations.
+	GetNonce() uint64
+	GetTarget() hash.Hash
+}
+
+type HeaderSuite interface {
+	format.StringTypable
+
+	NewHeader() Header
+}
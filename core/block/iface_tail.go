@@ -13,4 +13,13 @@ type Tail interface {
 	codec.ProtoCodec
 	format.Stringable
 	format.StringTypable
+
+	GetRound() uint64
+	GetAttestations() []Attestation
+}
+
+type TailSuite interface {
+	format.StringTypable
+
+	NewTail() Tail
 }
@@ -1,6 +1,7 @@
 package kangarooattestation
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/andantan/kangaroo/codec/wrapper"
@@ -8,10 +9,19 @@ import (
 	"github.com/andantan/kangaroo/crypto/hash"
 	"github.com/andantan/kangaroo/crypto/key"
 	kangarooblockpb "github.com/andantan/kangaroo/proto/core/block/pb"
+	"github.com/andantan/kangaroo/registry"
 	"google.golang.org/protobuf/proto"
+	"math"
 )
 
+// KangarooAttestation is a signature over Digest made at a height, round and
+// step. The signature covers all four, so two attestations of one signer
+// with the same height, round and step but different digests prove that it
+// equivocated.
 type KangarooAttestation struct {
+	Height    uint64
+	Round     uint64
+	Step      uint8
 	Digest    hash.Hash
 	Signer    key.PublicKey
 	Signature key.Signature
@@ -19,20 +29,56 @@ type KangarooAttestation struct {
 
 var _ block.Attestation = (*KangarooAttestation)(nil)
 
-func NewKangarooAttestation(digest hash.Hash, signer key.PublicKey, siganture key.Signature) *KangarooAttestation {
+func NewKangarooAttestation(height, round uint64, step uint8, digest hash.Hash, signer key.PublicKey, siganture key.Signature) *KangarooAttestation {
 	return &KangarooAttestation{
+		Height:    height,
+		Round:     round,
+		Step:      step,
 		Digest:    digest,
 		Signer:    signer,
 		Signature: siganture,
 	}
 }
 
+// SigningDigest is what the signer signs: digest bound to the height, round
+// and step, hashed with the digest's own hash suite.
+func SigningDigest(height, round uint64, step uint8, digest hash.Hash) (hash.Hash, error) {
+	suite, err := registry.GetHashSuite(digest.Type())
+	if err != nil {
+		return nil, err
+	}
+	digestBytes, err := wrapper.WrapHashCanonical(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 17+len(digestBytes))
+	buf = binary.BigEndian.AppendUint64(buf, height)
+	buf = binary.BigEndian.AppendUint64(buf, round)
+	buf = append(buf, step)
+	return suite.Deriver().Derive(append(buf, digestBytes...)), nil
+}
+
+// Sign attests to digest at the given height, round and step. Validators
+// should go through a slashing-protected signer instead.
+func Sign(privKey key.PrivateKey, height, round uint64, step uint8, digest hash.Hash) (*KangarooAttestation, error) {
+	signing, err := SigningDigest(height, round, step, digest)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := privKey.Sign(signing.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return NewKangarooAttestation(height, round, step, digest, privKey.PublicKey(), sig), nil
+}
+
 func (a *KangarooAttestation) ToProto() (proto.Message, error) {
 	var err error
 	var digestBytes []byte
 
 	if a.Digest != nil {
-		digestBytes, err = wrapper.WrapHash(a.Digest)
+		digestBytes, err = wrapper.WrapHashCanonical(a.Digest)
 		if err != nil {
 			return nil, err
 		}
@@ -40,7 +86,7 @@ func (a *KangarooAttestation) ToProto() (proto.Message, error) {
 
 	var signerBytes []byte
 	if a.Signer != nil {
-		signerBytes, err = wrapper.WrapPublicKey(a.Signer)
+		signerBytes, err = wrapper.WrapPublicKeyCanonical(a.Signer)
 		if err != nil {
 			return nil, err
 		}
@@ -48,7 +94,7 @@ func (a *KangarooAttestation) ToProto() (proto.Message, error) {
 
 	var signatureBytes []byte
 	if a.Signature != nil {
-		signatureBytes, err = wrapper.WrapSignature(a.Signature)
+		signatureBytes, err = wrapper.WrapSignatureCanonical(a.Signature)
 		if err != nil {
 			return nil, err
 		}
@@ -58,6 +104,9 @@ func (a *KangarooAttestation) ToProto() (proto.Message, error) {
 		Digest:    digestBytes,
 		Signer:    signerBytes,
 		Signature: signatureBytes,
+		Height:    a.Height,
+		Round:     a.Round,
+		Step:      uint32(a.Step),
 	}, nil
 }
 
@@ -67,21 +116,28 @@ func (a *KangarooAttestation) FromProto(m proto.Message) error {
 		return errors.New("cannot deserialize protobuf KangarooAttestation")
 	}
 
-	unwrappedDigest, err := wrapper.UnwrapHash(pb.Digest)
+	unwrappedDigest, err := wrapper.UnwrapHashCanonical(pb.Digest)
 	if err != nil {
 		return err
 	}
 
-	unwrappedSigner, err := wrapper.UnwrapPublicKey(pb.Signer)
+	unwrappedSigner, err := wrapper.UnwrapPublicKeyCanonical(pb.Signer)
 	if err != nil {
 		return err
 	}
 
-	unwrappedSignature, err := wrapper.UnwrapSignature(pb.Signature)
+	unwrappedSignature, err := wrapper.UnwrapSignatureCanonical(pb.Signature)
 	if err != nil {
 		return err
 	}
 
+	if pb.Step > math.MaxUint8 {
+		return fmt.Errorf("attestation step %d out of range", pb.Step)
+	}
+
+	a.Height = pb.Height
+	a.Round = pb.Round
+	a.Step = uint8(pb.Step)
 	a.Digest = unwrappedDigest
 	a.Signer = unwrappedSigner
 	a.Signature = unwrappedSignature
@@ -109,8 +165,8 @@ func (a *KangarooAttestation) String() string {
 		hasSig = a.Signature.ShortString(8)
 	}
 
-	return fmt.Sprintf("Attestation<%s>{Digest: %s, Signer: %s, Signature: %s}",
-		a.Type(), digestStr, signerStr, hasSig)
+	return fmt.Sprintf("Attestation<%s>{HRS: %d/%d/%d, Digest: %s, Signer: %s, Signature: %s}",
+		a.Type(), a.Height, a.Round, a.Step, digestStr, signerStr, hasSig)
 }
 
 func (a *KangarooAttestation) Type() string {
@@ -130,7 +186,24 @@ func (a *KangarooAttestation) Verify() bool {
 		return false
 	}
 
-	return a.Signature.Verify(a.Signer, a.Digest.Bytes())
+	signing, err := SigningDigest(a.Height, a.Round, a.Step, a.Digest)
+	if err != nil {
+		return false
+	}
+
+	return a.Signature.Verify(a.Signer, signing.Bytes())
+}
+
+func (a *KangarooAttestation) GetHeight() uint64 {
+	return a.Height
+}
+
+func (a *KangarooAttestation) GetRound() uint64 {
+	return a.Round
+}
+
+func (a *KangarooAttestation) GetStep() uint8 {
+	return a.Step
 }
 
 func (a *KangarooAttestation) GetBlockID() hash.Hash {
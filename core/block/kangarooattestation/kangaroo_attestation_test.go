@@ -22,18 +22,17 @@ func TestKangarooAttestation_FullLifecycle(t *testing.T) {
 
 			digest := tc.HashSuite.Deriver().Derive([]byte("test_block_digest"))
 
-			signature, err := signer.Sign(digest.Bytes())
-			require.NoError(t, err)
 			signerAddr := signer.PublicKey().Address(tc.AddressSuite.Deriver())
 			assert.Equal(t, hash.AddressLength, len(signerAddr.Bytes()))
 
-			att := NewKangarooAttestation(digest, signer.PublicKey(), signature)
+			att, err := Sign(signer, 7, 2, 1, digest)
+			require.NoError(t, err)
 			t.Logf("%s\n", att)
 
 			assert.Equal(t, block.KangarooAttestationType, att.Type())
 			assert.True(t, att.GetBlockID().Equal(digest))
 			assert.True(t, att.GetSigner().Equal(signer.PublicKey()))
-			assert.True(t, att.GetSignature().Equal(signature))
+			assert.Equal(t, []uint64{7, 2, 1}, []uint64{att.GetHeight(), att.GetRound(), uint64(att.GetStep())})
 
 			assert.True(t, att.Verify(), "correctly signed attestation should verify successfully")
 
@@ -47,6 +46,9 @@ func TestKangarooAttestation_FullLifecycle(t *testing.T) {
 			require.NoError(t, err)
 
 			// 4c. compare
+			assert.Equal(t, att.Height, newAtt.Height)
+			assert.Equal(t, att.Round, newAtt.Round)
+			assert.Equal(t, att.Step, newAtt.Step)
 			assert.True(t, att.GetBlockID().Equal(newAtt.GetBlockID()))
 			assert.True(t, att.GetSigner().Equal(newAtt.GetSigner()))
 			assert.True(t, att.GetSignature().Equal(newAtt.GetSignature()))
@@ -69,12 +71,32 @@ func TestKangarooAttestation_Verify_Failures(t *testing.T) {
 
 	// original attestation
 	getValidAtt := func() *KangarooAttestation {
-		digest := hasher.Derive([]byte("valid_digest"))
-		sig, err := signer.Sign(digest.Bytes())
+		att, err := Sign(signer, 7, 2, 1, hasher.Derive([]byte("valid_digest")))
 		require.NoError(t, err)
-		return NewKangarooAttestation(digest, signer.PublicKey(), sig)
+		return att
 	}
 
+	t.Run("should fail if the height, round or step is changed", func(t *testing.T) {
+		for _, tamper := range []func(att *KangarooAttestation){
+			func(att *KangarooAttestation) { att.Height++ },
+			func(att *KangarooAttestation) { att.Round++ },
+			func(att *KangarooAttestation) { att.Step++ },
+		} {
+			att := getValidAtt()
+			require.True(t, att.Verify())
+			tamper(att)
+			assert.False(t, att.Verify())
+		}
+	})
+
+	t.Run("should fail on a signature over the bare digest", func(t *testing.T) {
+		att := getValidAtt()
+		sig, err := signer.Sign(att.Digest.Bytes())
+		require.NoError(t, err)
+		att.Signature = sig
+		assert.False(t, att.Verify())
+	})
+
 	t.Run("should fail if signature is nil", func(t *testing.T) {
 		att := getValidAtt()
 		att.Signature = nil
@@ -123,11 +145,8 @@ func TestKangarooAttestation_Wrapper_RoundTrip(t *testing.T) {
 			signer, err := tc.KeySuite.GeneratePrivateKey()
 			require.NoError(t, err)
 
-			digest := hasher.Derive([]byte("test_digest"))
-			sig, err := signer.Sign(digest.Bytes())
+			att, err := Sign(signer, 1, 0, 0, hasher.Derive([]byte("test_digest")))
 			require.NoError(t, err)
-
-			att := NewKangarooAttestation(digest, signer.PublicKey(), sig)
 			t.Logf("%s\n", att)
 			assert.True(t, att.Verify())
 
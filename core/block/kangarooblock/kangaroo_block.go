@@ -0,0 +1,136 @@
+package kangarooblock
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	kangarooblockpb "github.com/andantan/kangaroo/proto/core/block/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+type KangarooBlock struct {
+	Header block.Header
+	Body   block.Body
+	Tail   block.Tail
+}
+
+var _ block.Block = (*KangarooBlock)(nil)
+
+func NewKangarooBlock(header block.Header, body block.Body, tail block.Tail) *KangarooBlock {
+	return &KangarooBlock{
+		Header: header,
+		Body:   body,
+		Tail:   tail,
+	}
+}
+
+func (b *KangarooBlock) Hash(deriver hash.HashDeriver) (hash.Hash, error) {
+	if b.Header == nil {
+		return nil, errors.New("cannot hash block without header")
+	}
+	return b.Header.Hash(deriver)
+}
+
+func (b *KangarooBlock) ToProto() (proto.Message, error) {
+	var (
+		err         error
+		headerBytes []byte
+		bodyBytes   []byte
+		tailBytes   []byte
+	)
+
+	if b.Header != nil {
+		if headerBytes, err = wrapper.WrapHeaderCanonical(b.Header); err != nil {
+			return nil, fmt.Errorf("failed to wrap header: %w", err)
+		}
+	}
+
+	if b.Body != nil {
+		if bodyBytes, err = wrapper.WrapBodyCanonical(b.Body); err != nil {
+			return nil, fmt.Errorf("failed to wrap body: %w", err)
+		}
+	}
+
+	if b.Tail != nil {
+		if tailBytes, err = wrapper.WrapTailCanonical(b.Tail); err != nil {
+			return nil, fmt.Errorf("failed to wrap tail: %w", err)
+		}
+	}
+
+	return &kangarooblockpb.KangarooBlock{
+		Header: headerBytes,
+		Body:   bodyBytes,
+		Tail:   tailBytes,
+	}, nil
+}
+
+func (b *KangarooBlock) FromProto(m proto.Message) error {
+	pb, ok := m.(*kangarooblockpb.KangarooBlock)
+	if !ok {
+		return errors.New("cannot deserialize protobuf KangarooBlock")
+	}
+
+	header, err := wrapper.UnwrapHeaderCanonical(pb.Header)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap header: %w", err)
+	}
+
+	body, err := wrapper.UnwrapBodyCanonical(pb.Body)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap body: %w", err)
+	}
+
+	var tail block.Tail
+	if len(pb.Tail) > 0 {
+		if tail, err = wrapper.UnwrapTailCanonical(pb.Tail); err != nil {
+			return fmt.Errorf("failed to unwrap tail: %w", err)
+		}
+	}
+
+	b.Header = header
+	b.Body = body
+	b.Tail = tail
+
+	return nil
+}
+
+func (b *KangarooBlock) NewProto() proto.Message {
+	return &kangarooblockpb.KangarooBlock{}
+}
+
+func (b *KangarooBlock) String() string {
+	headerStr := "<nil>"
+	if b.Header != nil {
+		headerStr = b.Header.String()
+	}
+
+	bodyStr := "<nil>"
+	if b.Body != nil {
+		bodyStr = b.Body.String()
+	}
+
+	tailStr := "<nil>"
+	if b.Tail != nil {
+		tailStr = b.Tail.String()
+	}
+
+	return fmt.Sprintf("Block<%s>{%s, %s, %s}", b.Type(), headerStr, bodyStr, tailStr)
+}
+
+func (b *KangarooBlock) Type() string {
+	return block.KangarooBlockType
+}
+
+func (b *KangarooBlock) GetHeader() block.Header {
+	return b.Header
+}
+
+func (b *KangarooBlock) GetBody() block.Body {
+	return b.Body
+}
+
+func (b *KangarooBlock) GetTail() block.Tail {
+	return b.Tail
+}
@@ -0,0 +1,22 @@
+package kangarooblock
+
+import (
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/registry"
+)
+
+func init() {
+	registry.RegistryBlockSuite(&KangarooBlockSuite{})
+}
+
+type KangarooBlockSuite struct{}
+
+var _ block.BlockSuite = (*KangarooBlockSuite)(nil)
+
+func (s *KangarooBlockSuite) Type() string {
+	return block.KangarooBlockType
+}
+
+func (s *KangarooBlockSuite) NewBlock() block.Block {
+	return &KangarooBlock{}
+}
@@ -0,0 +1,69 @@
+package kangarooblock
+
+import (
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	"github.com/andantan/kangaroo/core/block/kangaroobody"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	"github.com/andantan/kangaroo/core/block/kangarootail"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	"github.com/andantan/kangaroo/crypto/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestKangarooBlock_Wrapper_RoundTrip(t *testing.T) {
+	testCases := testutil.GetSuitesPairTestCases(t)
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			hasher := tc.HashSuite.Deriver()
+			signer, err := tc.KeySuite.GeneratePrivateKey()
+			require.NoError(t, err)
+
+			tx := kangarootransaction.NewKangarooTransaction(nil, nil, []byte("tx"), 0)
+			require.NoError(t, tx.Sign(signer, hasher))
+
+			body := kangaroobody.NewKangarooBody([]transaction.Transaction{tx})
+			bodyHash, err := body.Hash(hasher)
+			require.NoError(t, err)
+
+			header := kangarooheader.NewKangarooHeader(1, 2, hasher.Derive([]byte("parent")), bodyHash,
+				hasher.Derive([]byte("state")), signer.PublicKey())
+			id, err := header.Hash(hasher)
+			require.NoError(t, err)
+
+			att, err := kangarooattestation.Sign(signer, header.GetHeight(), 0, 0, id)
+			require.NoError(t, err)
+
+			blk := NewKangarooBlock(header, body, kangarootail.NewKangarooTail([]block.Attestation{att}))
+			t.Logf("%s\n", blk)
+			assert.Equal(t, block.KangarooBlockType, blk.Type())
+
+			blockID, err := blk.Hash(hasher)
+			require.NoError(t, err)
+			assert.True(t, id.Equal(blockID), "block id should be the header hash")
+
+			wrappedString, err := wrapper.WrapBlockToString(blk)
+			require.NoError(t, err)
+			parsedBlock, err := wrapper.UnwrapBlockFromString(wrappedString)
+			require.NoError(t, err)
+
+			parsedID, err := parsedBlock.Hash(hasher)
+			require.NoError(t, err)
+			assert.True(t, id.Equal(parsedID))
+
+			parsedBodyHash, err := parsedBlock.GetBody().Hash(hasher)
+			require.NoError(t, err)
+			assert.True(t, bodyHash.Equal(parsedBodyHash))
+
+			require.Len(t, parsedBlock.GetTail().GetAttestations(), 1)
+			parsedAtt := parsedBlock.GetTail().GetAttestations()[0]
+			assert.True(t, parsedAtt.Verify())
+			assert.True(t, parsedAtt.GetBlockID().Equal(parsedID))
+		})
+	}
+}
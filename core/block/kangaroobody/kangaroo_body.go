@@ -15,6 +15,7 @@ import (
 
 type KangarooBody struct {
 	Transactions []transaction.Transaction
+	Evidence     []block.Evidence
 }
 
 var _ block.Body = (*KangarooBody)(nil)
@@ -28,11 +29,40 @@ func NewKangarooBody(txs []transaction.Transaction) *KangarooBody {
 	}
 }
 
+func NewKangarooBodyWithEvidence(txs []transaction.Transaction, evidence []block.Evidence) *KangarooBody {
+	b := NewKangarooBody(txs)
+	b.Evidence = evidence
+	return b
+}
+
 func (b *KangarooBody) Hash(deriver hash.HashDeriver) (hash.Hash, error) {
 	if b.Transactions == nil {
 		return nil, errors.New("transactions is nil")
 	}
 
+	txRoot, err := b.transactionRoot(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b.Evidence) == 0 {
+		return txRoot, nil
+	}
+
+	evidenceHashes := make([]hash.Hash, len(b.Evidence))
+	for i, ev := range b.Evidence {
+		h, err := ev.Hash(deriver)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hash for evidence %d: %w", i, err)
+		}
+		evidenceHashes[i] = h
+	}
+
+	evidenceRoot := merkleRoot(deriver, evidenceHashes)
+	return deriver.Derive(append(txRoot.Bytes(), evidenceRoot.Bytes()...)), nil
+}
+
+func (b *KangarooBody) transactionRoot(deriver hash.HashDeriver) (hash.Hash, error) {
 	if len(b.Transactions) == 0 {
 		return deriver.Derive(nil), nil
 	}
@@ -46,42 +76,56 @@ func (b *KangarooBody) Hash(deriver hash.HashDeriver) (hash.Hash, error) {
 		txHashes[i] = h
 	}
 
-	sort.Slice(txHashes, func(i, j int) bool {
-		return txHashes[i].Lt(txHashes[j])
+	return merkleRoot(deriver, txHashes), nil
+}
+
+func merkleRoot(deriver hash.HashDeriver, hashes []hash.Hash) hash.Hash {
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i].Lt(hashes[j])
 	})
 
-	for len(txHashes) > 1 {
-		if len(txHashes)%2 != 0 {
-			txHashes = append(txHashes, txHashes[len(txHashes)-1])
+	for len(hashes) > 1 {
+		if len(hashes)%2 != 0 {
+			hashes = append(hashes, hashes[len(hashes)-1])
 		}
 
 		var nextLevelHashes []hash.Hash
-		for i := 0; i < len(txHashes); i += 2 {
-			left := txHashes[i]
-			right := txHashes[i+1]
+		for i := 0; i < len(hashes); i += 2 {
+			left := hashes[i]
+			right := hashes[i+1]
 			combinedHashData := append(left.Bytes(), right.Bytes()...)
 			parentHash := deriver.Derive(combinedHashData)
 			nextLevelHashes = append(nextLevelHashes, parentHash)
 		}
-		txHashes = nextLevelHashes
+		hashes = nextLevelHashes
 	}
 
-	return txHashes[0], nil
+	return hashes[0]
 }
 
 func (b *KangarooBody) ToProto() (proto.Message, error) {
 	txxBytes := make([][]byte, len(b.Transactions))
 
 	for i, tx := range b.Transactions {
-		wrappedTxBytes, err := wrapper.WrapTransaction(tx)
+		wrappedTxBytes, err := wrapper.WrapTransactionCanonical(tx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to wrap transaction %d: %w", i, err)
 		}
 		txxBytes[i] = wrappedTxBytes
 	}
 
+	evidenceBytes := make([][]byte, len(b.Evidence))
+	for i, ev := range b.Evidence {
+		wrappedEvidenceBytes, err := wrapper.WrapEvidenceCanonical(ev)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap evidence %d: %w", i, err)
+		}
+		evidenceBytes[i] = wrappedEvidenceBytes
+	}
+
 	return &kangarooblockpb.KangarooBody{
 		Transactions: txxBytes,
+		Evidence:     evidenceBytes,
 	}, nil
 }
 
@@ -93,14 +137,27 @@ func (b *KangarooBody) FromProto(m proto.Message) error {
 
 	txx := make([]transaction.Transaction, len(pb.Transactions))
 	for i, wrappedTxBytes := range pb.Transactions {
-		unwrappedTx, err := wrapper.UnwrapTransaction(wrappedTxBytes)
+		unwrappedTx, err := wrapper.UnwrapTransactionCanonical(wrappedTxBytes)
 		if err != nil {
 			return fmt.Errorf("failed to unwrap transaction %d: %w", i, err)
 		}
 		txx[i] = unwrappedTx
 	}
 
+	var evidence []block.Evidence
+	if len(pb.Evidence) > 0 {
+		evidence = make([]block.Evidence, len(pb.Evidence))
+		for i, wrappedEvidenceBytes := range pb.Evidence {
+			unwrappedEvidence, err := wrapper.UnwrapEvidenceCanonical(wrappedEvidenceBytes)
+			if err != nil {
+				return fmt.Errorf("failed to unwrap evidence %d: %w", i, err)
+			}
+			evidence[i] = unwrappedEvidence
+		}
+	}
+
 	b.Transactions = txx
+	b.Evidence = evidence
 	return nil
 }
 
@@ -119,8 +176,8 @@ func (b *KangarooBody) String() string {
 		txTypes = append(txTypes, tx.Type())
 	}
 
-	return fmt.Sprintf("Body<%s>{Weight: %d, Transactions: [%s]}",
-		b.Type(), txCount, strings.Join(txTypes, ", "))
+	return fmt.Sprintf("Body<%s>{Weight: %d, Transactions: [%s], Evidence: %d}",
+		b.Type(), txCount, strings.Join(txTypes, ", "), len(b.Evidence))
 }
 
 func (b *KangarooBody) Type() string {
@@ -131,6 +188,10 @@ func (b *KangarooBody) GetTransactions() []transaction.Transaction {
 	return b.Transactions
 }
 
+func (b *KangarooBody) GetEvidence() []block.Evidence {
+	return b.Evidence
+}
+
 func (b *KangarooBody) GetWeight() uint64 {
 	return uint64(len(b.Transactions))
 }
@@ -4,6 +4,8 @@ import (
 	"github.com/andantan/kangaroo/codec"
 	"github.com/andantan/kangaroo/codec/wrapper"
 	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	"github.com/andantan/kangaroo/core/block/kangarooevidence"
 	"github.com/andantan/kangaroo/core/transaction"
 	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
 	"github.com/andantan/kangaroo/crypto/hash"
@@ -184,3 +186,46 @@ func TestKangarooBody_Wrapper_RoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestKangarooBody_With_Evidence(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256r1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha3-256")
+	require.NoError(t, err)
+	hasher := hashSuite.Deriver()
+
+	signer, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	createAttestation := func(data string) block.Attestation {
+		att, err := kangarooattestation.Sign(signer, 3, 0, 0, hasher.Derive([]byte(data)))
+		require.NoError(t, err)
+		return att
+	}
+
+	tx1 := createSignedTx(t, "tx1", 1, signer, hasher)
+	ev := kangarooevidence.NewKangarooEvidence(3, 0, createAttestation("block_a"), createAttestation("block_b"))
+
+	plainBody := NewKangarooBody([]transaction.Transaction{tx1})
+	evidenceBody := NewKangarooBodyWithEvidence([]transaction.Transaction{tx1}, []block.Evidence{ev})
+	t.Logf("%s\n", evidenceBody)
+
+	// evidence must be committed to by the body hash
+	plainHash, err := plainBody.Hash(hasher)
+	require.NoError(t, err)
+	evidenceHash, err := evidenceBody.Hash(hasher)
+	require.NoError(t, err)
+	assert.False(t, plainHash.Equal(evidenceHash))
+
+	wrappedBody, err := wrapper.WrapBody(evidenceBody)
+	require.NoError(t, err)
+	unwrappedBody, err := wrapper.UnwrapBody(wrappedBody)
+	require.NoError(t, err)
+
+	require.Len(t, unwrappedBody.GetEvidence(), 1)
+	assert.True(t, unwrappedBody.GetEvidence()[0].Verify())
+
+	unwrappedHash, err := unwrappedBody.Hash(hasher)
+	require.NoError(t, err)
+	assert.True(t, evidenceHash.Equal(unwrappedHash))
+}
@@ -0,0 +1,184 @@
+package kangarooevidence
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	kangarooblockpb "github.com/andantan/kangaroo/proto/core/block/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// KangarooEvidence holds two attestations from the same signer to different
+// digests at the same height, round and step. Attestations sign their height,
+// round and step, so an honest signer never produces such a pair.
+type KangarooEvidence struct {
+	Height uint64
+	Round  uint64
+	First  block.Attestation
+	Second block.Attestation
+}
+
+var _ block.Evidence = (*KangarooEvidence)(nil)
+
+// NewKangarooEvidence orders the attestations by block ID so that the same
+// double-sign always produces the same evidence (and the same evidence hash).
+func NewKangarooEvidence(height, round uint64, a, b block.Attestation) *KangarooEvidence {
+	first, second := a, b
+	if first != nil && second != nil &&
+		first.GetBlockID() != nil && second.GetBlockID() != nil &&
+		second.GetBlockID().Lt(first.GetBlockID()) {
+		first, second = second, first
+	}
+
+	return &KangarooEvidence{
+		Height: height,
+		Round:  round,
+		First:  first,
+		Second: second,
+	}
+}
+
+func (e *KangarooEvidence) Hash(deriver hash.HashDeriver) (hash.Hash, error) {
+	if e.First == nil || e.Second == nil {
+		return nil, errors.New("cannot hash incomplete evidence")
+	}
+
+	b, err := codec.EncodeProto(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return deriver.Derive(b), nil
+}
+
+func (e *KangarooEvidence) ToProto() (proto.Message, error) {
+	var (
+		err         error
+		firstBytes  []byte
+		secondBytes []byte
+	)
+
+	if e.First != nil {
+		if firstBytes, err = wrapper.WrapAttestationCanonical(e.First); err != nil {
+			return nil, fmt.Errorf("failed to wrap first attestation: %w", err)
+		}
+	}
+
+	if e.Second != nil {
+		if secondBytes, err = wrapper.WrapAttestationCanonical(e.Second); err != nil {
+			return nil, fmt.Errorf("failed to wrap second attestation: %w", err)
+		}
+	}
+
+	return &kangarooblockpb.KangarooEvidence{
+		Height: e.Height,
+		Round:  e.Round,
+		First:  firstBytes,
+		Second: secondBytes,
+	}, nil
+}
+
+func (e *KangarooEvidence) FromProto(m proto.Message) error {
+	pb, ok := m.(*kangarooblockpb.KangarooEvidence)
+	if !ok {
+		return errors.New("cannot deserialize protobuf KangarooEvidence")
+	}
+
+	first, err := wrapper.UnwrapAttestationCanonical(pb.First)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap first attestation: %w", err)
+	}
+
+	second, err := wrapper.UnwrapAttestationCanonical(pb.Second)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap second attestation: %w", err)
+	}
+
+	e.Height = pb.Height
+	e.Round = pb.Round
+	e.First = first
+	e.Second = second
+
+	return nil
+}
+
+func (e *KangarooEvidence) NewProto() proto.Message {
+	return &kangarooblockpb.KangarooEvidence{}
+}
+
+func (e *KangarooEvidence) String() string {
+	signerStr := "<nil>"
+	if signer := e.GetSigner(); signer != nil {
+		signerStr = signer.ShortString(8)
+	}
+
+	firstStr := "<nil>"
+	if e.First != nil && e.First.GetBlockID() != nil {
+		firstStr = e.First.GetBlockID().ShortString(8)
+	}
+
+	secondStr := "<nil>"
+	if e.Second != nil && e.Second.GetBlockID() != nil {
+		secondStr = e.Second.GetBlockID().ShortString(8)
+	}
+
+	return fmt.Sprintf("Evidence<%s>{Height: %d, Round: %d, Signer: %s, BlockIDs: [%s, %s]}",
+		e.Type(), e.Height, e.Round, signerStr, firstStr, secondStr)
+}
+
+func (e *KangarooEvidence) Type() string {
+	return block.KangarooEvidenceType
+}
+
+func (e *KangarooEvidence) Verify() bool {
+	if e.First == nil || e.Second == nil {
+		return false
+	}
+
+	if e.First.GetSigner() == nil || e.Second.GetSigner() == nil {
+		return false
+	}
+
+	if e.First.GetHeight() != e.Height || e.Second.GetHeight() != e.Height ||
+		e.First.GetRound() != e.Round || e.Second.GetRound() != e.Round ||
+		e.First.GetStep() != e.Second.GetStep() {
+		return false
+	}
+
+	if !e.First.GetSigner().Equal(e.Second.GetSigner()) {
+		return false
+	}
+
+	if e.First.GetBlockID() == nil || e.Second.GetBlockID() == nil {
+		return false
+	}
+
+	if e.First.GetBlockID().Equal(e.Second.GetBlockID()) {
+		return false
+	}
+
+	return e.First.Verify() && e.Second.Verify()
+}
+
+func (e *KangarooEvidence) GetHeight() uint64 {
+	return e.Height
+}
+
+func (e *KangarooEvidence) GetRound() uint64 {
+	return e.Round
+}
+
+func (e *KangarooEvidence) GetSigner() key.PublicKey {
+	if e.First == nil {
+		return nil
+	}
+	return e.First.GetSigner()
+}
+
+func (e *KangarooEvidence) GetAttestations() (block.Attestation, block.Attestation) {
+	return e.First, e.Second
+}
@@ -0,0 +1,156 @@
+package kangarooevidence
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"sync"
+)
+
+type voteKey struct {
+	height uint64
+	round  uint64
+	step   uint8
+	signer string
+}
+
+// EquivocationDetector remembers the first attestation each signer made for a
+// height, round and step, and emits evidence as soon as a conflicting one
+// shows up.
+type EquivocationDetector struct {
+	lock     sync.Mutex
+	seen     map[voteKey]block.Attestation
+	reported map[voteKey]struct{}
+	pending  []block.Evidence
+	onFound  func(block.Evidence)
+}
+
+func NewEquivocationDetector(onFound func(block.Evidence)) *EquivocationDetector {
+	return &EquivocationDetector{
+		seen:     make(map[voteKey]block.Attestation),
+		reported: make(map[voteKey]struct{}),
+		pending:  make([]block.Evidence, 0),
+		onFound:  onFound,
+	}
+}
+
+// Observe records an attestation. It returns evidence the first time the signer
+// is seen attesting to a second block ID at the same height, round and step, and
+// nil otherwise.
+func (d *EquivocationDetector) Observe(att block.Attestation) (block.Evidence, error) {
+	if att == nil {
+		return nil, errors.New("attestation cannot be nil")
+	}
+
+	if !att.Verify() {
+		return nil, fmt.Errorf("invalid attestation: %s", att)
+	}
+
+	signer, err := wrapper.WrapPublicKeyToString(att.GetSigner())
+	if err != nil {
+		return nil, err
+	}
+
+	k := voteKey{height: att.GetHeight(), round: att.GetRound(), step: att.GetStep(), signer: signer}
+
+	d.lock.Lock()
+	prev, ok := d.seen[k]
+	if !ok {
+		d.seen[k] = att
+		d.lock.Unlock()
+		return nil, nil
+	}
+
+	if _, done := d.reported[k]; done || prev.GetBlockID().Equal(att.GetBlockID()) {
+		d.lock.Unlock()
+		return nil, nil
+	}
+
+	ev := NewKangarooEvidence(k.height, k.round, prev, att)
+	d.reported[k] = struct{}{}
+	d.pending = append(d.pending, ev)
+	d.lock.Unlock()
+
+	if d.onFound != nil {
+		d.onFound(ev)
+	}
+
+	return ev, nil
+}
+
+func (d *EquivocationDetector) PendingEvidence() []block.Evidence {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return append([]block.Evidence(nil), d.pending...)
+}
+
+// RemoveEvidence forgets pending evidence once it has been included in a block.
+// The signer stays reported, so the same double-sign is not emitted again.
+func (d *EquivocationDetector) RemoveEvidence(evidence []block.Evidence) {
+	included := make(map[voteKey]struct{}, len(evidence))
+	for _, ev := range evidence {
+		if k, err := evidenceKey(ev); err == nil {
+			included[k] = struct{}{}
+		}
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	kept := make([]block.Evidence, 0, len(d.pending))
+	for _, ev := range d.pending {
+		k, err := evidenceKey(ev)
+		if err == nil {
+			if _, ok := included[k]; ok {
+				continue
+			}
+		}
+		kept = append(kept, ev)
+	}
+	d.pending = kept
+}
+
+func evidenceKey(ev block.Evidence) (voteKey, error) {
+	first, _ := ev.GetAttestations()
+	if first == nil {
+		return voteKey{}, errors.New("evidence has no attestations")
+	}
+
+	signer, err := wrapper.WrapPublicKeyToString(ev.GetSigner())
+	if err != nil {
+		return voteKey{}, err
+	}
+
+	return voteKey{height: ev.GetHeight(), round: ev.GetRound(), step: first.GetStep(), signer: signer}, nil
+}
+
+// DrainEvidence hands the pending evidence to the caller (e.g. a block producer
+// submitting it for slashing) and clears the pending list.
+func (d *EquivocationDetector) DrainEvidence() []block.Evidence {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	drained := d.pending
+	d.pending = make([]block.Evidence, 0)
+	return drained
+}
+
+// Prune forgets every attestation recorded below the given height.
+func (d *EquivocationDetector) Prune(height uint64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for k := range d.seen {
+		if k.height < height {
+			delete(d.seen, k)
+		}
+	}
+
+	for k := range d.reported {
+		if k.height < height {
+			delete(d.reported, k)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package kangarooevidence
+
+import (
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/registry"
+)
+
+func init() {
+	registry.RegistryEvidenceSuite(&KangarooEvidenceSuite{})
+}
+
+type KangarooEvidenceSuite struct{}
+
+var _ block.EvidenceSuite = (*KangarooEvidenceSuite)(nil)
+
+func (s *KangarooEvidenceSuite) Type() string {
+	return block.KangarooEvidenceType
+}
+
+func (s *KangarooEvidenceSuite) NewEvidence() block.Evidence {
+	return &KangarooEvidence{}
+}
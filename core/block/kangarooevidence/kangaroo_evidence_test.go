@@ -0,0 +1,236 @@
+package kangarooevidence
+
+import (
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/crypto/testutil"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func createAttestation(t *testing.T, signer key.PrivateKey, deriver hash.HashDeriver, height, round uint64, data string) block.Attestation {
+	return createStepAttestation(t, signer, deriver, height, round, 0, data)
+}
+
+func createStepAttestation(t *testing.T, signer key.PrivateKey, deriver hash.HashDeriver, height, round uint64, step uint8, data string) block.Attestation {
+	att, err := kangarooattestation.Sign(signer, height, round, step, deriver.Derive([]byte(data)))
+	require.NoError(t, err)
+	return att
+}
+
+func TestKangarooEvidence_FullLifecycle(t *testing.T) {
+	testCases := testutil.GetSuitesPairTestCases(t)
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			hasher := tc.HashSuite.Deriver()
+			signer, err := tc.KeySuite.GeneratePrivateKey()
+			require.NoError(t, err)
+
+			a1 := createAttestation(t, signer, hasher, 10, 2, "block_a")
+			a2 := createAttestation(t, signer, hasher, 10, 2, "block_b")
+
+			ev := NewKangarooEvidence(10, 2, a1, a2)
+			t.Logf("%s\n", ev)
+
+			assert.Equal(t, block.KangarooEvidenceType, ev.Type())
+			assert.Equal(t, uint64(10), ev.GetHeight())
+			assert.Equal(t, uint64(2), ev.GetRound())
+			assert.True(t, ev.GetSigner().Equal(signer.PublicKey()))
+			assert.True(t, ev.Verify(), "conflicting attestations from one signer should verify")
+
+			// order of the attestations must not change the evidence
+			swapped := NewKangarooEvidence(10, 2, a2, a1)
+			h1, err := ev.Hash(hasher)
+			require.NoError(t, err)
+			h2, err := swapped.Hash(hasher)
+			require.NoError(t, err)
+			assert.True(t, h1.Equal(h2))
+
+			encodedBytes, err := codec.EncodeProto(ev)
+			require.NoError(t, err)
+
+			newEv := new(KangarooEvidence)
+			err = codec.DecodeProto(encodedBytes, newEv)
+			require.NoError(t, err)
+
+			assert.Equal(t, ev.GetHeight(), newEv.GetHeight())
+			assert.Equal(t, ev.GetRound(), newEv.GetRound())
+			assert.True(t, newEv.Verify(), "restored evidence should also verify")
+
+			newHash, err := newEv.Hash(hasher)
+			require.NoError(t, err)
+			assert.True(t, h1.Equal(newHash))
+		})
+	}
+}
+
+func TestKangarooEvidence_Verify_Failures(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	hasher := hashSuite.Deriver()
+
+	signer, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	otherSigner, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	t.Run("should fail if block IDs are equal", func(t *testing.T) {
+		a1 := createAttestation(t, signer, hasher, 1, 0, "block_a")
+		a2 := createAttestation(t, signer, hasher, 1, 0, "block_a")
+		assert.False(t, NewKangarooEvidence(1, 0, a1, a2).Verify())
+	})
+
+	t.Run("should fail if signers differ", func(t *testing.T) {
+		a1 := createAttestation(t, signer, hasher, 1, 0, "block_a")
+		a2 := createAttestation(t, otherSigner, hasher, 1, 0, "block_b")
+		assert.False(t, NewKangarooEvidence(1, 0, a1, a2).Verify())
+	})
+
+	t.Run("should fail if an attestation is missing", func(t *testing.T) {
+		a1 := createAttestation(t, signer, hasher, 1, 0, "block_a")
+		assert.False(t, NewKangarooEvidence(1, 0, a1, nil).Verify())
+		assert.False(t, NewKangarooEvidence(1, 0, nil, a1).Verify())
+	})
+
+	t.Run("should fail on attestations at different heights, rounds or steps", func(t *testing.T) {
+		// e.g. two consecutive blocks, or a prevote and a precommit
+		a1 := createAttestation(t, signer, hasher, 1, 0, "block_a")
+		assert.False(t, NewKangarooEvidence(1, 0, a1, createAttestation(t, signer, hasher, 2, 0, "block_b")).Verify())
+		assert.False(t, NewKangarooEvidence(1, 0, a1, createAttestation(t, signer, hasher, 1, 1, "block_b")).Verify())
+		assert.False(t, NewKangarooEvidence(1, 0,
+			createStepAttestation(t, signer, hasher, 1, 0, 1, "block_a"),
+			createStepAttestation(t, signer, hasher, 1, 0, 2, "block_b")).Verify())
+	})
+
+	t.Run("should fail if the evidence names another height or round", func(t *testing.T) {
+		a1 := createAttestation(t, signer, hasher, 1, 0, "block_a")
+		a2 := createAttestation(t, signer, hasher, 1, 0, "block_b")
+		assert.True(t, NewKangarooEvidence(1, 0, a1, a2).Verify())
+		assert.False(t, NewKangarooEvidence(2, 0, a1, a2).Verify())
+		assert.False(t, NewKangarooEvidence(1, 1, a1, a2).Verify())
+	})
+
+	t.Run("should fail if a signature is forged", func(t *testing.T) {
+		a1 := createAttestation(t, signer, hasher, 1, 0, "block_a")
+		forged := createAttestation(t, otherSigner, hasher, 1, 0, "block_b").(*kangarooattestation.KangarooAttestation)
+		forged.Signer = signer.PublicKey()
+		assert.False(t, NewKangarooEvidence(1, 0, a1, forged).Verify())
+	})
+}
+
+func TestKangarooEvidence_Wrapper_RoundTrip(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("schnorr-sr25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("keccak256")
+	require.NoError(t, err)
+	hasher := hashSuite.Deriver()
+
+	signer, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	ev := NewKangarooEvidence(7, 1,
+		createAttestation(t, signer, hasher, 7, 1, "block_a"),
+		createAttestation(t, signer, hasher, 7, 1, "block_b"),
+	)
+
+	wrappedEv, err := wrapper.WrapEvidence(ev)
+	require.NoError(t, err)
+	unwrappedEv, err := wrapper.UnwrapEvidence(wrappedEv)
+	require.NoError(t, err)
+	assert.True(t, unwrappedEv.Verify())
+	assert.Equal(t, ev.GetHeight(), unwrappedEv.GetHeight())
+
+	wrappedString, err := wrapper.WrapEvidenceToString(ev)
+	require.NoError(t, err)
+	parsedEv, err := wrapper.UnwrapEvidenceFromString(wrappedString)
+	require.NoError(t, err)
+	assert.True(t, parsedEv.Verify())
+	assert.True(t, parsedEv.GetSigner().Equal(signer.PublicKey()))
+}
+
+func TestEquivocationDetector(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("blake2b256")
+	require.NoError(t, err)
+	hasher := hashSuite.Deriver()
+
+	signer, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	otherSigner, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	var found []block.Evidence
+	d := NewEquivocationDetector(func(ev block.Evidence) {
+		found = append(found, ev)
+	})
+
+	t.Run("should ignore the first and repeated attestations", func(t *testing.T) {
+		ev, err := d.Observe(createAttestation(t, signer, hasher, 5, 0, "block_a"))
+		require.NoError(t, err)
+		assert.Nil(t, ev)
+
+		ev, err = d.Observe(createAttestation(t, signer, hasher, 5, 0, "block_a"))
+		require.NoError(t, err)
+		assert.Nil(t, ev)
+	})
+
+	t.Run("should ignore different rounds, steps and signers", func(t *testing.T) {
+		ev, err := d.Observe(createAttestation(t, signer, hasher, 5, 1, "block_b"))
+		require.NoError(t, err)
+		assert.Nil(t, ev)
+
+		ev, err = d.Observe(createStepAttestation(t, signer, hasher, 5, 0, 1, "block_b"))
+		require.NoError(t, err)
+		assert.Nil(t, ev)
+
+		ev, err = d.Observe(createAttestation(t, otherSigner, hasher, 5, 0, "block_b"))
+		require.NoError(t, err)
+		assert.Nil(t, ev)
+	})
+
+	t.Run("should emit evidence on double sign", func(t *testing.T) {
+		ev, err := d.Observe(createAttestation(t, signer, hasher, 5, 0, "block_b"))
+		require.NoError(t, err)
+		require.NotNil(t, ev)
+		assert.True(t, ev.Verify())
+		assert.Equal(t, uint64(5), ev.GetHeight())
+		assert.Equal(t, uint64(0), ev.GetRound())
+		assert.True(t, ev.GetSigner().Equal(signer.PublicKey()))
+		require.Len(t, found, 1)
+	})
+
+	t.Run("should report a double sign only once", func(t *testing.T) {
+		ev, err := d.Observe(createAttestation(t, signer, hasher, 5, 0, "block_c"))
+		require.NoError(t, err)
+		assert.Nil(t, ev)
+		assert.Len(t, found, 1)
+	})
+
+	t.Run("should reject invalid attestations", func(t *testing.T) {
+		att := createAttestation(t, signer, hasher, 6, 0, "block_d").(*kangarooattestation.KangarooAttestation)
+		att.Digest = hasher.Derive([]byte("tampered"))
+		_, err := d.Observe(att)
+		assert.Error(t, err)
+	})
+
+	t.Run("should drain and prune", func(t *testing.T) {
+		assert.Len(t, d.PendingEvidence(), 1)
+		assert.Len(t, d.DrainEvidence(), 1)
+		assert.Empty(t, d.PendingEvidence())
+
+		d.Prune(6)
+		ev, err := d.Observe(createAttestation(t, signer, hasher, 5, 0, "block_e"))
+		require.NoError(t, err)
+		assert.Nil(t, ev, "pruned heights start over")
+	})
+}
@@ -0,0 +1,230 @@
+package kangarooheader
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	kangarooblockpb "github.com/andantan/kangaroo/proto/core/block/pb"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+type KangarooHeader struct {
+	Height      uint64
+	Timestamp   int64
+	PrevBlockID hash.Hash
+	BodyHash    hash.Hash
+	StateRoot   hash.Hash
+	Proposer    key.PublicKey
+	Nonce       uint64
+	Target      hash.Hash
+}
+
+var _ block.Header = (*KangarooHeader)(nil)
+
+func NewKangarooHeader(height uint64, timestamp int64, prevBlockID, bodyHash, stateRoot hash.Hash, proposer key.PublicKey) *KangarooHeader {
+	return &KangarooHeader{
+		Height:      height,
+		Timestamp:   timestamp,
+		PrevBlockID: prevBlockID,
+		BodyHash:    bodyHash,
+		StateRoot:   stateRoot,
+		Proposer:    proposer,
+	}
+}
+
+func (h *KangarooHeader) Hash(deriver hash.HashDeriver) (hash.Hash, error) {
+	if h.BodyHash == nil || h.StateRoot == nil {
+		return nil, errors.New("cannot hash incomplete header")
+	}
+
+	b, err := codec.EncodeProto(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return deriver.Derive(b), nil
+}
+
+func (h *KangarooHeader) ToProto() (proto.Message, error) {
+	var (
+		err            error
+		prevBlockBytes []byte
+		bodyHashBytes  []byte
+		stateRootBytes []byte
+		proposerBytes  []byte
+		targetBytes    []byte
+	)
+
+	if h.PrevBlockID != nil {
+		if prevBlockBytes, err = wrapper.WrapHashCanonical(h.PrevBlockID); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.BodyHash != nil {
+		if bodyHashBytes, err = wrapper.WrapHashCanonical(h.BodyHash); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.StateRoot != nil {
+		if stateRootBytes, err = wrapper.WrapHashCanonical(h.StateRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.Proposer != nil {
+		if proposerBytes, err = wrapper.WrapPublicKeyCanonical(h.Proposer); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.Target != nil {
+		if targetBytes, err = wrapper.WrapHashCanonical(h.Target); err != nil {
+			return nil, err
+		}
+	}
+
+	return &kangarooblockpb.KangarooHeader{
+		Height:      h.Height,
+		Timestamp:   h.Timestamp,
+		PrevBlockId: prevBlockBytes,
+		BodyHash:    bodyHashBytes,
+		StateRoot:   stateRootBytes,
+		Proposer:    proposerBytes,
+		Nonce:       h.Nonce,
+		Target:      targetBytes,
+	}, nil
+}
+
+func (h *KangarooHeader) FromProto(m proto.Message) error {
+	pb, ok := m.(*kangarooblockpb.KangarooHeader)
+	if !ok {
+		return errors.New("cannot deserialize protobuf KangarooHeader")
+	}
+
+	var (
+		err         error
+		prevBlockID hash.Hash
+		bodyHash    hash.Hash
+		stateRoot   hash.Hash
+		proposer    key.PublicKey
+		target      hash.Hash
+	)
+
+	if len(pb.PrevBlockId) > 0 {
+		if prevBlockID, err = wrapper.UnwrapHashCanonical(pb.PrevBlockId); err != nil {
+			return fmt.Errorf("failed to parse previous block id: %w", err)
+		}
+	}
+
+	if len(pb.BodyHash) > 0 {
+		if bodyHash, err = wrapper.UnwrapHashCanonical(pb.BodyHash); err != nil {
+			return fmt.Errorf("failed to parse body hash: %w", err)
+		}
+	}
+
+	if len(pb.StateRoot) > 0 {
+		if stateRoot, err = wrapper.UnwrapHashCanonical(pb.StateRoot); err != nil {
+			return fmt.Errorf("failed to parse state root: %w", err)
+		}
+	}
+
+	if len(pb.Proposer) > 0 {
+		if proposer, err = wrapper.UnwrapPublicKeyCanonical(pb.Proposer); err != nil {
+			return fmt.Errorf("failed to parse proposer: %w", err)
+		}
+	}
+
+	if len(pb.Target) > 0 {
+		if target, err = wrapper.UnwrapHashCanonical(pb.Target); err != nil {
+			return fmt.Errorf("failed to parse target: %w", err)
+		}
+	}
+
+	h.Height = pb.Height
+	h.Timestamp = pb.Timestamp
+	h.PrevBlockID = prevBlockID
+	h.BodyHash = bodyHash
+	h.StateRoot = stateRoot
+	h.Proposer = proposer
+	h.Nonce = pb.Nonce
+	h.Target = target
+
+	return nil
+}
+
+func (h *KangarooHeader) NewProto() proto.Message {
+	return &kangarooblockpb.KangarooHeader{}
+}
+
+func (h *KangarooHeader) String() string {
+	prevStr := "<nil>"
+	if h.PrevBlockID != nil {
+		prevStr = h.PrevBlockID.ShortString(8)
+	}
+
+	bodyStr := "<nil>"
+	if h.BodyHash != nil {
+		bodyStr = h.BodyHash.ShortString(8)
+	}
+
+	stateStr := "<nil>"
+	if h.StateRoot != nil {
+		stateStr = h.StateRoot.ShortString(8)
+	}
+
+	proposerStr := "<nil>"
+	if h.Proposer != nil {
+		proposerStr = h.Proposer.ShortString(8)
+	}
+
+	targetStr := "<nil>"
+	if h.Target != nil {
+		targetStr = h.Target.ShortString(8)
+	}
+
+	return fmt.Sprintf("Header<%s>{Height: %d, Time: %s, Prev: %s, Body: %s, State: %s, Proposer: %s, Nonce: %d, Target: %s}",
+		h.Type(), h.Height, time.Unix(0, h.Timestamp).UTC().Format(time.RFC3339), prevStr, bodyStr, stateStr, proposerStr, h.Nonce, targetStr)
+}
+
+func (h *KangarooHeader) Type() string {
+	return block.KangarooHeaderType
+}
+
+func (h *KangarooHeader) GetHeight() uint64 {
+	return h.Height
+}
+
+func (h *KangarooHeader) GetTimestamp() int64 {
+	return h.Timestamp
+}
+
+func (h *KangarooHeader) GetPrevBlockID() hash.Hash {
+	return h.PrevBlockID
+}
+
+func (h *KangarooHeader) GetBodyHash() hash.Hash {
+	return h.BodyHash
+}
+
+func (h *KangarooHeader) GetStateRoot() hash.Hash {
+	return h.StateRoot
+}
+
+func (h *KangarooHeader) GetProposer() key.PublicKey {
+	return h.Proposer
+}
+
+func (h *KangarooHeader) GetNonce() uint64 {
+	return h.Nonce
+}
+
+func (h *KangarooHeader) GetTarget() hash.Hash {
+	return h.Target
+}
@@ -0,0 +1,22 @@
+package kangarooheader
+
+import (
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/registry"
+)
+
+func init() {
+	registry.RegistryHeaderSuite(&KangarooHeaderSuite{})
+}
+
+type KangarooHeaderSuite struct{}
+
+var _ block.HeaderSuite = (*KangarooHeaderSuite)(nil)
+
+func (s *KangarooHeaderSuite) Type() string {
+	return block.KangarooHeaderType
+}
+
+func (s *KangarooHeaderSuite) NewHeader() block.Header {
+	return &KangarooHeader{}
+}
@@ -0,0 +1,143 @@
+package kangarooheader
+
+import (
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/testutil"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestKangarooHeader_FullLifecycle(t *testing.T) {
+	testCases := testutil.GetSuitesPairTestCases(t)
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			hasher := tc.HashSuite.Deriver()
+			proposer, err := tc.KeySuite.GeneratePrivateKey()
+			require.NoError(t, err)
+
+			header := NewKangarooHeader(
+				42,
+				time.Now().UnixNano(),
+				hasher.Derive([]byte("parent")),
+				hasher.Derive([]byte("body")),
+				hasher.Derive([]byte("state")),
+				proposer.PublicKey(),
+			)
+			t.Logf("%s\n", header)
+
+			assert.Equal(t, block.KangarooHeaderType, header.Type())
+			assert.Equal(t, uint64(42), header.GetHeight())
+
+			id, err := header.Hash(hasher)
+			require.NoError(t, err)
+			assert.False(t, id.IsZero())
+
+			encodedBytes, err := codec.EncodeProto(header)
+			require.NoError(t, err)
+
+			newHeader := new(KangarooHeader)
+			require.NoError(t, codec.DecodeProto(encodedBytes, newHeader))
+
+			assert.Equal(t, header.GetHeight(), newHeader.GetHeight())
+			assert.Equal(t, header.GetTimestamp(), newHeader.GetTimestamp())
+			assert.True(t, header.GetPrevBlockID().Equal(newHeader.GetPrevBlockID()))
+			assert.True(t, header.GetBodyHash().Equal(newHeader.GetBodyHash()))
+			assert.True(t, header.GetStateRoot().Equal(newHeader.GetStateRoot()))
+			assert.True(t, header.GetProposer().Equal(newHeader.GetProposer()))
+
+			newID, err := newHeader.Hash(hasher)
+			require.NoError(t, err)
+			assert.True(t, id.Equal(newID), "block id should be deterministic")
+		})
+	}
+}
+
+func TestKangarooHeader_Genesis(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	hasher := hashSuite.Deriver()
+
+	header := NewKangarooHeader(0, 1, nil, hasher.Derive(nil), hasher.Derive(nil), nil)
+
+	wrappedString, err := wrapper.WrapHeaderToString(header)
+	require.NoError(t, err)
+	parsedHeader, err := wrapper.UnwrapHeaderFromString(wrappedString)
+	require.NoError(t, err)
+
+	assert.Nil(t, parsedHeader.GetPrevBlockID())
+	assert.Nil(t, parsedHeader.GetProposer())
+	assert.True(t, parsedHeader.GetBodyHash().IsZero())
+
+	id, err := header.Hash(hasher)
+	require.NoError(t, err)
+	parsedID, err := parsedHeader.Hash(hasher)
+	require.NoError(t, err)
+	assert.True(t, id.Equal(parsedID))
+}
+
+func TestKangarooHeader_Hash_Failures(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+
+	_, err = NewKangarooHeader(1, 1, nil, nil, nil, nil).Hash(hashSuite.Deriver())
+	assert.Error(t, err)
+}
+
+func TestKangarooHeader_Work(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("blake2b256")
+	require.NoError(t, err)
+	hasher := hashSuite.Deriver()
+
+	header := NewKangarooHeader(3, 1, hasher.Derive([]byte("parent")), hasher.Derive(nil), hasher.Derive(nil), nil)
+	plainID, err := header.Hash(hasher)
+	require.NoError(t, err)
+
+	header.Nonce = 12345
+	header.Target = hasher.Derive([]byte("target"))
+	t.Logf("%s\n", header)
+
+	// the seal must be committed to by the block id
+	sealedID, err := header.Hash(hasher)
+	require.NoError(t, err)
+	assert.False(t, plainID.Equal(sealedID))
+
+	wrappedBytes, err := wrapper.WrapHeader(header)
+	require.NoError(t, err)
+	parsedHeader, err := wrapper.UnwrapHeader(wrappedBytes)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(12345), parsedHeader.GetNonce())
+	assert.True(t, header.Target.Equal(parsedHeader.GetTarget()))
+
+	parsedID, err := parsedHeader.Hash(hasher)
+	require.NoError(t, err)
+	assert.True(t, sealedID.Equal(parsedID))
+}
+
+// Block IDs do not depend on the wrapper's envelope.
+func TestKangarooHeader_Legacy(t *testing.T) {
+	const legacyHeader = "0x010804102a1a210307a64f2010cf5a94158dc803666d72b450aadcb156892caf5620cee71556f6ba222103512227571b4b801d3bbe8f01e3b651e6c4462eb9780ee5b9fb9ea4fb6899a5c42a210369e39af32bd0cc2d5f8ad822a3afcd7fe8d7211e4ca7c42654cdbda7a9b7451632210387addb00c23ae79c3c3fe56afe73005b856e7dd6670f2303e9819d2e844d8dfd"
+	const legacyID = "0x5e87d1214781f1d72647c41e68ed0e9bf62e2d95b193f9353b9502d80819b8cd"
+
+	hashSuite, err := registry.GetHashSuite("keccak256")
+	require.NoError(t, err)
+
+	header, err := wrapper.UnwrapHeaderFromString(legacyHeader)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), header.GetHeight())
+
+	id, err := header.Hash(hashSuite.Deriver())
+	require.NoError(t, err)
+	assert.Equal(t, legacyID, id.String())
+
+	rebuilt := NewKangarooHeader(4, 42, hashSuite.Deriver().Derive([]byte("prev")), hashSuite.Deriver().Derive([]byte("body")), hashSuite.Deriver().Derive([]byte("state")), header.GetProposer())
+	id, err = rebuilt.Hash(hashSuite.Deriver())
+	require.NoError(t, err)
+	assert.Equal(t, legacyID, id.String())
+}
@@ -0,0 +1,90 @@
+package kangarootail
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	kangarooblockpb "github.com/andantan/kangaroo/proto/core/block/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// KangarooTail carries the attestations that commit a block. Round is the
+// consensus round the attestations were cast in; single round engines leave it 0.
+type KangarooTail struct {
+	Round        uint64
+	Attestations []block.Attestation
+}
+
+var _ block.Tail = (*KangarooTail)(nil)
+
+func NewKangarooTail(attestations []block.Attestation) *KangarooTail {
+	return NewKangarooTailWithRound(0, attestations)
+}
+
+func NewKangarooTailWithRound(round uint64, attestations []block.Attestation) *KangarooTail {
+	if attestations == nil {
+		attestations = make([]block.Attestation, 0)
+	}
+	return &KangarooTail{
+		Round:        round,
+		Attestations: attestations,
+	}
+}
+
+func (t *KangarooTail) ToProto() (proto.Message, error) {
+	attBytes := make([][]byte, len(t.Attestations))
+
+	for i, att := range t.Attestations {
+		wrappedAttBytes, err := wrapper.WrapAttestationCanonical(att)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap attestation %d: %w", i, err)
+		}
+		attBytes[i] = wrappedAttBytes
+	}
+
+	return &kangarooblockpb.KangarooTail{
+		Attestations: attBytes,
+		Round:        t.Round,
+	}, nil
+}
+
+func (t *KangarooTail) FromProto(m proto.Message) error {
+	pb, ok := m.(*kangarooblockpb.KangarooTail)
+	if !ok {
+		return errors.New("cannot deserialize protobuf KangarooTail")
+	}
+
+	atts := make([]block.Attestation, len(pb.Attestations))
+	for i, wrappedAttBytes := range pb.Attestations {
+		unwrappedAtt, err := wrapper.UnwrapAttestationCanonical(wrappedAttBytes)
+		if err != nil {
+			return fmt.Errorf("failed to unwrap attestation %d: %w", i, err)
+		}
+		atts[i] = unwrappedAtt
+	}
+
+	t.Round = pb.Round
+	t.Attestations = atts
+	return nil
+}
+
+func (t *KangarooTail) NewProto() proto.Message {
+	return &kangarooblockpb.KangarooTail{}
+}
+
+func (t *KangarooTail) String() string {
+	return fmt.Sprintf("Tail<%s>{Round: %d, Attestations: %d}", t.Type(), t.Round, len(t.Attestations))
+}
+
+func (t *KangarooTail) Type() string {
+	return block.KangarooTailType
+}
+
+func (t *KangarooTail) GetAttestations() []block.Attestation {
+	return t.Attestations
+}
+
+func (t *KangarooTail) GetRound() uint64 {
+	return t.Round
+}
@@ -0,0 +1,22 @@
+package kangarootail
+
+import (
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/registry"
+)
+
+func init() {
+	registry.RegistryTailSuite(&KangarooTailSuite{})
+}
+
+type KangarooTailSuite struct{}
+
+var _ block.TailSuite = (*KangarooTailSuite)(nil)
+
+func (s *KangarooTailSuite) Type() string {
+	return block.KangarooTailType
+}
+
+func (s *KangarooTailSuite) NewTail() block.Tail {
+	return &KangarooTail{}
+}
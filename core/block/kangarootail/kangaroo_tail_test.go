@@ -0,0 +1,56 @@
+package kangarootail
+
+import (
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestKangarooTail_Wrapper_RoundTrip(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("schnorr-secp256k1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("blake2b256")
+	require.NoError(t, err)
+	hasher := hashSuite.Deriver()
+
+	atts := make([]block.Attestation, 0, 3)
+	for i := 0; i < 3; i++ {
+		signer, err := keySuite.GeneratePrivateKey()
+		require.NoError(t, err)
+		att, err := kangarooattestation.Sign(signer, 1, 0, 0, hasher.Derive([]byte("block")))
+		require.NoError(t, err)
+		atts = append(atts, att)
+	}
+
+	tail := NewKangarooTailWithRound(2, atts)
+	t.Logf("%s\n", tail)
+	assert.Equal(t, block.KangarooTailType, tail.Type())
+
+	wrappedTail, err := wrapper.WrapTail(tail)
+	require.NoError(t, err)
+	unwrappedTail, err := wrapper.UnwrapTail(wrappedTail)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(2), unwrappedTail.GetRound())
+	require.Len(t, unwrappedTail.GetAttestations(), 3)
+	for i, att := range unwrappedTail.GetAttestations() {
+		assert.True(t, att.Verify())
+		assert.True(t, att.GetSigner().Equal(atts[i].GetSigner()))
+	}
+
+	emptyTail, err := wrapper.UnwrapTail(mustWrap(t, NewKangarooTail(nil)))
+	require.NoError(t, err)
+	assert.Empty(t, emptyTail.GetAttestations())
+	assert.Zero(t, emptyTail.GetRound())
+}
+
+func mustWrap(t *testing.T, tail block.Tail) []byte {
+	b, err := wrapper.WrapTail(tail)
+	require.NoError(t, err)
+	return b
+}
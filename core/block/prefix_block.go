@@ -0,0 +1,38 @@
+package block
+
+import "fmt"
+
+const (
+	_ byte = iota
+	KangarooBlockPrefixByte
+)
+
+var typeToBlockPrefix = map[string]byte{
+	KangarooBlockType: KangarooBlockPrefixByte,
+}
+var blockPrefixToType = make(map[byte]string)
+
+func init() {
+	for name, prefix := range typeToBlockPrefix {
+		if _, exists := blockPrefixToType[prefix]; exists {
+			panic(fmt.Sprintf("duplicate block type prefix defined: 0x%x", prefix))
+		}
+		blockPrefixToType[prefix] = name
+	}
+}
+
+func GetBlockPrefixFromType(name string) (byte, error) {
+	prefix, ok := typeToBlockPrefix[name]
+	if !ok {
+		return 0, fmt.Errorf("no prefix defined for block type: %s", name)
+	}
+	return prefix, nil
+}
+
+func GetTypeFromBlockPrefix(prefix byte) (string, error) {
+	name, ok := blockPrefixToType[prefix]
+	if !ok {
+		return "", fmt.Errorf("unknown block type prefix: 0x%x", prefix)
+	}
+	return name, nil
+}
@@ -0,0 +1,38 @@
+package block
+
+import "fmt"
+
+const (
+	_ byte = iota
+	KangarooEvidencePrefixByte
+)
+
+var typeToEvidencePrefix = map[string]byte{
+	KangarooEvidenceType: KangarooEvidencePrefixByte,
+}
+var evidencePrefixToType = make(map[byte]string)
+
+func init() {
+	for name, prefix := range typeToEvidencePrefix {
+		if _, exists := evidencePrefixToType[prefix]; exists {
+			panic(fmt.Sprintf("duplicate evidence type prefix defined: 0x%x", prefix))
+		}
+		evidencePrefixToType[prefix] = name
+	}
+}
+
+func GetEvidencePrefixFromType(name string) (byte, error) {
+	prefix, ok := typeToEvidencePrefix[name]
+	if !ok {
+		return 0, fmt.Errorf("no prefix defined for evidence type: %s", name)
+	}
+	return prefix, nil
+}
+
+func GetTypeFromEvidencePrefix(prefix byte) (string, error) {
+	name, ok := evidencePrefixToType[prefix]
+	if !ok {
+		return "", fmt.Errorf("unknown evidence type prefix: 0x%x", prefix)
+	}
+	return name, nil
+}
@@ -0,0 +1,38 @@
+package block
+
+import "fmt"
+
+const (
+	_ byte = iota
+	KangarooHeaderPrefixByte
+)
+
+var typeToHeaderPrefix = map[string]byte{
+	KangarooHeaderType: KangarooHeaderPrefixByte,
+}
+var headerPrefixToType = make(map[byte]string)
+
+func init() {
+	for name, prefix := range typeToHeaderPrefix {
+		if _, exists := headerPrefixToType[prefix]; exists {
+			panic(fmt.Sprintf("duplicate header type prefix defined: 0x%x", prefix))
+		}
+		headerPrefixToType[prefix] = name
+	}
+}
+
+func GetHeaderPrefixFromType(name string) (byte, error) {
+	prefix, ok := typeToHeaderPrefix[name]
+	if !ok {
+		return 0, fmt.Errorf("no prefix defined for header type: %s", name)
+	}
+	return prefix, nil
+}
+
+func GetTypeFromHeaderPrefix(prefix byte) (string, error) {
+	name, ok := headerPrefixToType[prefix]
+	if !ok {
+		return "", fmt.Errorf("unknown header type prefix: 0x%x", prefix)
+	}
+	return name, nil
+}
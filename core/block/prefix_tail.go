@@ -0,0 +1,38 @@
+package block
+
+import "fmt"
+
+const (
+	_ byte = iota
+	KangarooTailPrefixByte
+)
+
+var typeToTailPrefix = map[string]byte{
+	KangarooTailType: KangarooTailPrefixByte,
+}
+var tailPrefixToType = make(map[byte]string)
+
+func init() {
+	for name, prefix := range typeToTailPrefix {
+		if _, exists := tailPrefixToType[prefix]; exists {
+			panic(fmt.Sprintf("duplicate tail type prefix defined: 0x%x", prefix))
+		}
+		tailPrefixToType[prefix] = name
+	}
+}
+
+func GetTailPrefixFromType(name string) (byte, error) {
+	prefix, ok := typeToTailPrefix[name]
+	if !ok {
+		return 0, fmt.Errorf("no prefix defined for tail type: %s", name)
+	}
+	return prefix, nil
+}
+
+func GetTypeFromTailPrefix(prefix byte) (string, error) {
+	name, ok := tailPrefixToType[prefix]
+	if !ok {
+		return "", fmt.Errorf("unknown tail type prefix: 0x%x", prefix)
+	}
+	return name, nil
+}
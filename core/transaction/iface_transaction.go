@@ -20,6 +20,7 @@ type Transaction interface {
 	format.Stringable    // string format
 	format.StringTypable // string type
 
+	GetToAddress() hash.Address
 	GetValue() *big.Int
 	GetData() []byte
 	GetNonce() uint64
@@ -1,6 +1,7 @@
 package kangarootransaction
 
 import (
+	"encoding/hex"
 	"github.com/andantan/kangaroo/codec"
 	"github.com/andantan/kangaroo/codec/wrapper"
 	"github.com/andantan/kangaroo/core/transaction"
@@ -302,3 +303,29 @@ func TestKangarooTransaction_Wrapper_RoundTrip(t *testing.T) {
 		})
 	}
 }
+
+// A transaction signed before wrapped bytes got an envelope keeps its hash
+// and signature.
+func TestKangarooTransaction_Legacy(t *testing.T) {
+	const legacyTx = "0x010a1503680ae7add8e8ffccb2a9ed105ef9b0f0930e156b1201071a02686920032a4103902ce4b13e8c6d55b5795a3d364dbb4f85c229ce69ea28e0124fdb72515b4577fa192cb30ebde296d37731b2e92251f9a3d6ca39618a4e096beca070bcc69d0132210387addb00c23ae79c3c3fe56afe73005b856e7dd6670f2303e9819d2e844d8dfd"
+	const legacyHash = "0x44710014c227c05b8cd9d5431e9b4b9e9fa9e8018311e0fa9b2a1b2b5d2d7e81"
+
+	hashSuite, err := registry.GetHashSuite("keccak256")
+	require.NoError(t, err)
+	hasher := hashSuite.Deriver()
+
+	tx, err := wrapper.UnwrapTransactionFromString(legacyTx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Verify(hasher))
+
+	h, err := tx.Hash(hasher)
+	require.NoError(t, err)
+	assert.Equal(t, legacyHash, h.String())
+
+	// Only the outer envelope is new.
+	wrapped, err := wrapper.WrapTransaction(tx)
+	require.NoError(t, err)
+	e, err := wrapper.ParseEnvelope(wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, legacyTx, "0x"+hex.EncodeToString(append([]byte{e.Type}, e.Payload...)))
+}
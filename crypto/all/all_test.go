@@ -0,0 +1,40 @@
+package all
+
+import (
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHashDerivers_Equal(t *testing.T) {
+	data := []byte("kangaroo")
+
+	for _, name := range registry.ListHashSuiteTypes() {
+		t.Run(name, func(t *testing.T) {
+			suite, err := registry.GetHashSuite(name)
+			require.NoError(t, err)
+			deriver := suite.Deriver()
+
+			assert.True(t, deriver.Derive(data).Equal(deriver.Derive(data)))
+			assert.True(t, deriver.Derive(nil).Equal(deriver.Derive(nil)))
+			assert.False(t, deriver.Derive(data).Equal(deriver.Derive([]byte("wallaby"))))
+		})
+	}
+}
+
+func TestAddressDerivers_Equal(t *testing.T) {
+	data := []byte("kangaroo")
+
+	for _, name := range registry.ListAddressSuiteTypes() {
+		t.Run(name, func(t *testing.T) {
+			suite, err := registry.GetAddressSuite(name)
+			require.NoError(t, err)
+			deriver := suite.Deriver()
+
+			assert.True(t, deriver.Derive(data).Equal(deriver.Derive(data)))
+			assert.True(t, deriver.Derive(nil).Equal(deriver.Derive(nil)))
+			assert.False(t, deriver.Derive(data).Equal(deriver.Derive([]byte("wallaby"))))
+		})
+	}
+}
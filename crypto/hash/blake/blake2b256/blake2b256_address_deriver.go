@@ -16,7 +16,7 @@ func (_ *Blake2b256AddressDeriver) Type() string {
 
 func (_ *Blake2b256AddressDeriver) Derive(data []byte) hash.Address {
 	if data == nil {
-		return &Blake2b256Address{}
+		return Blake2b256Address{}
 	}
 
 	hashBytes := blake2b.Sum256(data)
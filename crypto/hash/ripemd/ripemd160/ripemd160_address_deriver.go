@@ -16,7 +16,7 @@ func (_ *Ripemd160AddressDeriver) Type() string {
 
 func (_ *Ripemd160AddressDeriver) Derive(data []byte) hash.Address {
 	if data == nil {
-		return &Ripemd160Address{}
+		return Ripemd160Address{}
 	}
 
 	rh := ripemd160.New()
@@ -16,7 +16,7 @@ func (_ *Keccak256AddressDeriver) Type() string {
 
 func (_ *Keccak256AddressDeriver) Derive(data []byte) hash.Address {
 	if data == nil {
-		return &Keccak256Address{}
+		return Keccak256Address{}
 	}
 
 	kh := sha3.NewLegacyKeccak256()
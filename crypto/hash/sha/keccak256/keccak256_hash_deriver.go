@@ -16,7 +16,7 @@ func (_ *Keccak256HashDeriver) Type() string {
 
 func (_ *Keccak256HashDeriver) Derive(data []byte) hash.Hash {
 	if data == nil {
-		return &Keccak256Hash{}
+		return Keccak256Hash{}
 	}
 
 	kh := sha3.NewLegacyKeccak256()
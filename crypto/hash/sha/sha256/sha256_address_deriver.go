@@ -16,7 +16,7 @@ func (_ *Sha256AddressDeriver) Type() string {
 
 func (_ *Sha256AddressDeriver) Derive(data []byte) hash.Address {
 	if data == nil {
-		return &Sha256Address{}
+		return Sha256Address{}
 	}
 
 	hashBytes := sha256.Sum256(data)
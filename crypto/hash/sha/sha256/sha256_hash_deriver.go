@@ -16,7 +16,7 @@ func (_ *Sha256HashDeriver) Type() string {
 
 func (_ *Sha256HashDeriver) Derive(data []byte) hash.Hash {
 	if data == nil {
-		return &Sha256Hash{}
+		return Sha256Hash{}
 	}
 
 	hashBytes := sha256.Sum256(data)
@@ -16,7 +16,7 @@ func (_ *Sha3AddressDeriver) Type() string {
 
 func (_ *Sha3AddressDeriver) Derive(data []byte) hash.Address {
 	if data == nil {
-		return &Sha3Address{}
+		return Sha3Address{}
 	}
 
 	hashBytes := sha3.Sum256(data)
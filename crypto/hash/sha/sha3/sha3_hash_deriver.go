@@ -16,7 +16,7 @@ func (_ *Sha3HashDeriver) Type() string {
 
 func (_ *Sha3HashDeriver) Derive(data []byte) hash.Hash {
 	if data == nil {
-		return &Sha3Hash{}
+		return Sha3Hash{}
 	}
 
 	hashBytes := sha3.Sum256(data)
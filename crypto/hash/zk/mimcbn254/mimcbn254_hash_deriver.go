@@ -17,7 +17,7 @@ func (_ *MimcBN254HashDeriver) Type() string {
 
 func (_ *MimcBN254HashDeriver) Derive(data []byte) hash.Hash {
 	if data == nil {
-		return &MimcBN254Hash{}
+		return MimcBN254Hash{}
 	}
 
 	f := mimc.NewMiMC()
@@ -17,7 +17,7 @@ func (_ *PoseidonBN254AddressDeriver) Type() string {
 
 func (_ *PoseidonBN254AddressDeriver) Derive(data []byte) hash.Address {
 	if data == nil {
-		return &PoseidonBN254Address{}
+		return PoseidonBN254Address{}
 	}
 
 	f := poseidon2.NewMerkleDamgardHasher()
@@ -0,0 +1,172 @@
+package hd
+
+import (
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"math/big"
+)
+
+var (
+	ErrUnsupportedCurve = errors.New("hd derivation is not supported for this key type")
+	ErrInvalidPath      = errors.New("invalid derivation path")
+	ErrInvalidSeed      = errors.New("invalid seed")
+)
+
+// Curve selects the derivation scheme: BIP-32 for secp256k1 and SLIP-10
+// for nist256p1 and ed25519.
+type Curve int
+
+const (
+	Secp256k1 Curve = iota
+	Nist256p1
+	Ed25519
+)
+
+const (
+	MinSeedLength = 16
+	MaxSeedLength = 64
+)
+
+// masterKeys are the HMAC keys of the master node, as fixed by BIP-32 and
+// SLIP-10.
+var masterKeys = map[Curve][]byte{
+	Secp256k1: []byte("Bitcoin seed"),
+	Nist256p1: []byte("Nist256p1 seed"),
+	Ed25519:   []byte("ed25519 seed"),
+}
+
+func (c Curve) String() string {
+	switch c {
+	case Secp256k1:
+		return "secp256k1"
+	case Nist256p1:
+		return "nist256p1"
+	case Ed25519:
+		return "ed25519"
+	default:
+		return fmt.Sprintf("curve(%d)", int(c))
+	}
+}
+
+func (c Curve) order() *big.Int {
+	switch c {
+	case Secp256k1:
+		return secp256k1.S256().N
+	case Nist256p1:
+		return elliptic.P256().Params().N
+	default:
+		return nil
+	}
+}
+
+// publicKey is serP(point(k)): the compressed public key of a weierstrass
+// curve private key.
+func (c Curve) publicKey(k []byte) []byte {
+	switch c {
+	case Secp256k1:
+		return secp256k1.PrivKeyFromBytes(k).PubKey().SerializeCompressed()
+	default:
+		curve := elliptic.P256()
+		x, y := curve.ScalarBaseMult(k)
+		return elliptic.MarshalCompressed(curve, x, y)
+	}
+}
+
+// node is an extended private key.
+type node struct {
+	key       []byte
+	chainCode []byte
+}
+
+// Derive returns the 32 byte private key at path below the master key of
+// seed. For ed25519 this is the RFC 8032 seed of the key, not the scalar.
+func Derive(curve Curve, seed []byte, path Path) ([]byte, error) {
+	if _, ok := masterKeys[curve]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurve, curve)
+	}
+	if len(seed) < MinSeedLength || len(seed) > MaxSeedLength {
+		return nil, fmt.Errorf("%w: %d bytes, expected %d to %d", ErrInvalidSeed, len(seed), MinSeedLength, MaxSeedLength)
+	}
+	if curve == Ed25519 {
+		for _, index := range path {
+			if index < HardenedOffset {
+				return nil, fmt.Errorf("%w: ed25519 only derives hardened children, got %s", ErrInvalidPath, path)
+			}
+		}
+	}
+
+	n := master(curve, seed)
+	for _, index := range path {
+		n = n.child(curve, index)
+	}
+	return n.key, nil
+}
+
+func master(curve Curve, seed []byte) node {
+	data := seed
+	for {
+		i := hmacSHA512(masterKeys[curve], data)
+		il, ir := i[:32], i[32:]
+		if curve == Ed25519 || validScalar(curve, new(big.Int).SetBytes(il)) {
+			return node{key: il, chainCode: ir}
+		}
+		// SLIP-10: an out of range master key is retried on the HMAC output.
+		data = i
+	}
+}
+
+func (n node) child(curve Curve, index uint32) node {
+	data := make([]byte, 0, 37)
+	if index >= HardenedOffset {
+		data = append(data, 0)
+		data = append(data, n.key...)
+	} else {
+		data = append(data, curve.publicKey(n.key)...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	for {
+		i := hmacSHA512(n.chainCode, data)
+		il, ir := i[:32], i[32:]
+		if curve == Ed25519 {
+			return node{key: il, chainCode: ir}
+		}
+
+		parse := new(big.Int).SetBytes(il)
+		if parse.Cmp(curve.order()) < 0 {
+			k := parse.Add(parse, new(big.Int).SetBytes(n.key))
+			k.Mod(k, curve.order())
+			if k.Sign() != 0 {
+				return node{key: k.FillBytes(make([]byte, 32)), chainCode: ir}
+			}
+		}
+		// SLIP-10: retry with 0x01 || IR || ser32(i) rather than skipping
+		// the index as BIP-32 does. Both happen with probability < 2^-127.
+		data = append([]byte{1}, ir...)
+		data = binary.BigEndian.AppendUint32(data, index)
+	}
+}
+
+func validScalar(curve Curve, k *big.Int) bool {
+	return k.Sign() != 0 && k.Cmp(curve.order()) < 0
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// DerivePath parses path and derives the private key at it.
+func DerivePath(curve Curve, seed []byte, path string) ([]byte, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return Derive(curve, seed, p)
+}
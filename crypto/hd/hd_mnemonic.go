@@ -0,0 +1,40 @@
+package hd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cosmos/go-bip39"
+	"strings"
+)
+
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// DefaultMnemonicEntropy gives 24 word mnemonics.
+const DefaultMnemonicEntropy = 256
+
+// NewMnemonic returns a fresh English BIP-39 mnemonic of entropyBits (128
+// to 256, a multiple of 32) random bits.
+func NewMnemonic(entropyBits int) (string, error) {
+	entropy, err := bip39.NewEntropy(entropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// NormalizeMnemonic lowercases the words and collapses the whitespace
+// between them.
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
+
+// SeedFromMnemonic checks the word list and checksum of mnemonic and
+// returns its 64 byte BIP-39 seed. The passphrase may be empty.
+func SeedFromMnemonic(mnemonic, passphrase string) ([]byte, error) {
+	mnemonic = NormalizeMnemonic(mnemonic)
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	return seed, nil
+}
@@ -0,0 +1,56 @@
+package hd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const HardenedOffset uint32 = 1 << 31
+
+// DefaultPath is BIP-44 shaped with SLIP-44 coin type 1 and hardened at
+// every level, so keys of every supported curve can be derived at it.
+const DefaultPath = "m/44'/1'/0'/0'/0'"
+
+// Path is a list of child indexes below the master key; hardened indexes
+// include HardenedOffset.
+type Path []uint32
+
+// ParsePath reads paths like m/44'/1'/0'/0/7. Hardened levels are marked
+// with ' or h.
+func ParsePath(s string) (Path, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("%w: %q does not start at m", ErrInvalidPath, s)
+	}
+
+	path := make(Path, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || index >= uint64(HardenedOffset) {
+			return nil, fmt.Errorf("%w: bad level %q in %q", ErrInvalidPath, part, s)
+		}
+		if hardened {
+			index += uint64(HardenedOffset)
+		}
+		path = append(path, uint32(index))
+	}
+	return path, nil
+}
+
+func (p Path) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range p {
+		if index >= HardenedOffset {
+			fmt.Fprintf(&b, "/%d'", index-HardenedOffset)
+		} else {
+			fmt.Fprintf(&b, "/%d", index)
+		}
+	}
+	return b.String()
+}
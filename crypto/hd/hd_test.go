@@ -0,0 +1,106 @@
+package hd
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// Test vector 1 of BIP-32 and SLIP-10, seed 000102...0f.
+func TestDerive_Vectors(t *testing.T) {
+	seed := mustHex(t, "000102030405060708090a0b0c0d0e0f")
+
+	tests := []struct {
+		curve     Curve
+		path      string
+		key       string
+		chainCode string
+	}{
+		{Secp256k1, "m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508"},
+		{Secp256k1, "m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea", "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141"},
+		{Secp256k1, "m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19"},
+		{Nist256p1, "m", "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2", "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea"},
+		{Nist256p1, "m/0'", "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c", "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11"},
+		{Nist256p1, "m/0'/1", "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129", "4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c"},
+		{Ed25519, "m", "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7", "90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb"},
+		{Ed25519, "m/0'", "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3", "8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69"},
+		{Ed25519, "m/0'/1'", "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2", "a320425f77d1b5c2505a6b1b27382b37368ee640e3557c315416801243552f14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.curve.String()+"/"+tt.path, func(t *testing.T) {
+			path, err := ParsePath(tt.path)
+			require.NoError(t, err)
+
+			n := master(tt.curve, seed)
+			for _, index := range path {
+				n = n.child(tt.curve, index)
+			}
+			assert.Equal(t, tt.key, hex.EncodeToString(n.key))
+			assert.Equal(t, tt.chainCode, hex.EncodeToString(n.chainCode))
+
+			key, err := Derive(tt.curve, seed, path)
+			require.NoError(t, err)
+			assert.Equal(t, n.key, key)
+		})
+	}
+
+	_, err := Derive(Ed25519, seed, Path{1})
+	assert.ErrorIs(t, err, ErrInvalidPath)
+	_, err = Derive(Secp256k1, seed[:8], nil)
+	assert.ErrorIs(t, err, ErrInvalidSeed)
+	_, err = Derive(Curve(9), seed, nil)
+	assert.ErrorIs(t, err, ErrUnsupportedCurve)
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath("m/44'/1h/0H/0/7")
+	require.NoError(t, err)
+	assert.Equal(t, Path{44 + HardenedOffset, 1 + HardenedOffset, HardenedOffset, 0, 7}, path)
+	assert.Equal(t, "m/44'/1'/0'/0/7", path.String())
+
+	path, err = ParsePath(DefaultPath)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPath, path.String())
+
+	for _, bad := range []string{"", "44'/0'", "m/", "m/x", "m/-1", "m/2147483648", "m/1''"} {
+		_, err := ParsePath(bad)
+		assert.ErrorIs(t, err, ErrInvalidPath, bad)
+	}
+}
+
+func TestMnemonic(t *testing.T) {
+	// BIP-39 vector with passphrase TREZOR.
+	seed, err := SeedFromMnemonic("  Abandon abandon abandon abandon abandon abandon\nabandon abandon abandon abandon abandon about ", "TREZOR")
+	require.NoError(t, err)
+	assert.Equal(t, "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04", hex.EncodeToString(seed))
+
+	mnemonic, err := NewMnemonic(DefaultMnemonicEntropy)
+	require.NoError(t, err)
+	assert.Len(t, mnemonic, len(NormalizeMnemonic(mnemonic)))
+	assert.Len(t, strings.Fields(mnemonic), 24)
+	_, err = SeedFromMnemonic(mnemonic, "")
+	require.NoError(t, err)
+
+	// Bad checksum, unknown word and bad length.
+	for _, bad := range []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon wallaby",
+		"abandon about",
+	} {
+		_, err := SeedFromMnemonic(bad, "")
+		assert.ErrorIs(t, err, ErrInvalidMnemonic, bad)
+	}
+
+	_, err = NewMnemonic(100)
+	assert.Error(t, err)
+}
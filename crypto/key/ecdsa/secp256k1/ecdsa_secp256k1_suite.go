@@ -26,6 +26,10 @@ func (s *ECDSASecp256k1Suite) PrivateKeyFromBytes(data []byte) (key.PrivateKey,
 	return ECDSASecp256k1PrivateKeyFromBytes(data)
 }
 
+func (s *ECDSASecp256k1Suite) PrivateKeyFromSeed(seed []byte, path string) (key.PrivateKey, error) {
+	return ECDSASecp256k1PrivateKeyFromSeed(seed, path)
+}
+
 func (s *ECDSASecp256k1Suite) PublicKeyFromBytes(data []byte) (key.PublicKey, error) {
 	return ECDSASecp256k1PublicKeyFromBytes(data)
 }
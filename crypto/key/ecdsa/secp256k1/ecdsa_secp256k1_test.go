@@ -1,10 +1,12 @@
 package secp256k1
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/andantan/kangaroo/codec/wrapper"
 	"github.com/andantan/kangaroo/crypto/hash"
 	"github.com/andantan/kangaroo/crypto/hash/testutil"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key/ecdsa"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,6 +28,24 @@ func Test_ECDSA_Secp256k1_PrivateKey_Lifecycle(t *testing.T) {
 	assert.Equal(t, privKey, reloadedPrivKey)
 }
 
+func Test_ECDSA_Secp256k1_PrivateKey_FromSeed(t *testing.T) {
+	// BIP-32 test vector 1.
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+
+	privKey, err := ECDSASecp256k1PrivateKeyFromSeed(seed, "m/0'/1")
+	require.NoError(t, err)
+	assert.True(t, privKey.IsValid())
+	assert.Equal(t, "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", hex.EncodeToString(privKey.Bytes()))
+
+	again, err := (&ECDSASecp256k1Suite{}).PrivateKeyFromSeed(seed, "m/0'/1")
+	require.NoError(t, err)
+	assert.Equal(t, privKey, again)
+
+	_, err = ECDSASecp256k1PrivateKeyFromSeed(seed, "0/1")
+	assert.ErrorIs(t, err, hd.ErrInvalidPath)
+}
+
 func Test_ECDSA_Secp256k1_PublicKey_Lifecycle(t *testing.T) {
 	addressSuites := testutil.GetAddressSuiteTestCases(t)
 	privKey, err := GenerateECDSASecp256k1PrivateKey()
@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key"
 	kangarooecdsa "github.com/andantan/kangaroo/crypto/key/ecdsa"
 	"math/big"
@@ -60,6 +61,17 @@ func ECDSASecp256r1PrivateKeyFromBytes(b []byte) (key.PrivateKey, error) {
 	}, nil
 }
 
+// ECDSASecp256r1PrivateKeyFromSeed derives the key at path below a BIP-39 seed with
+// SLIP-10.
+func ECDSASecp256r1PrivateKeyFromSeed(seed []byte, path string) (key.PrivateKey, error) {
+	k, err := hd.DerivePath(hd.Nist256p1, seed, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ECDSASecp256r1PrivateKeyFromBytes(k)
+}
+
 func (k *ECDSASecp256r1PrivateKey) Bytes() []byte {
 	b := make([]byte, kangarooecdsa.ECDSASecp256r1PrivateKeyBytesLength)
 	k.key.D.FillBytes(b)
@@ -26,6 +26,10 @@ func (s *ECDSASecp256r1Suite) PrivateKeyFromBytes(data []byte) (key.PrivateKey,
 	return ECDSASecp256r1PrivateKeyFromBytes(data)
 }
 
+func (s *ECDSASecp256r1Suite) PrivateKeyFromSeed(seed []byte, path string) (key.PrivateKey, error) {
+	return ECDSASecp256r1PrivateKeyFromSeed(seed, path)
+}
+
 func (s *ECDSASecp256r1Suite) PublicKeyFromBytes(data []byte) (key.PublicKey, error) {
 	return ECDSASecp256r1PublicKeyFromBytes(data)
 }
@@ -1,10 +1,12 @@
 package secp256r1
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/andantan/kangaroo/codec/wrapper"
 	"github.com/andantan/kangaroo/crypto/hash"
 	"github.com/andantan/kangaroo/crypto/hash/testutil"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key/ecdsa"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,6 +28,24 @@ func Test_ECDSA_Secp256r1_PrivateKey_Lifecycle(t *testing.T) {
 	assert.Equal(t, privKey, reloadedPrivKey)
 }
 
+func Test_ECDSA_Secp256r1_PrivateKey_FromSeed(t *testing.T) {
+	// SLIP-10 test vector 1.
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+
+	privKey, err := ECDSASecp256r1PrivateKeyFromSeed(seed, "m/0'/1")
+	require.NoError(t, err)
+	assert.True(t, privKey.IsValid())
+	assert.Equal(t, "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129", hex.EncodeToString(privKey.Bytes()))
+
+	again, err := (&ECDSASecp256r1Suite{}).PrivateKeyFromSeed(seed, "m/0'/1")
+	require.NoError(t, err)
+	assert.Equal(t, privKey, again)
+
+	_, err = ECDSASecp256r1PrivateKeyFromSeed(seed, "0/1")
+	assert.ErrorIs(t, err, hd.ErrInvalidPath)
+}
+
 func Test_ECDSA_Secp256r1_PublicKey_Lifecycle(t *testing.T) {
 	addressSuites := testutil.GetAddressSuiteTestCases(t)
 	privKey, err := GenerateECDSASecp256r1PrivateKey()
@@ -4,6 +4,7 @@ import (
 	"crypto/ed25519"
 	"encoding/hex"
 	"fmt"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key"
 	"github.com/andantan/kangaroo/crypto/key/eddsa"
 )
@@ -36,6 +37,17 @@ func EdDSAEd25519PrivateKeyFromBytes(b []byte) (key.PrivateKey, error) {
 	}, nil
 }
 
+// EdDSAEd25519PrivateKeyFromSeed derives the key at path below a BIP-39 seed with
+// SLIP-10; only hardened paths are accepted.
+func EdDSAEd25519PrivateKeyFromSeed(seed []byte, path string) (key.PrivateKey, error) {
+	k, err := hd.DerivePath(hd.Ed25519, seed, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return EdDSAEd25519PrivateKeyFromBytes(ed25519.NewKeyFromSeed(k))
+}
+
 func (k *EdDSAEd25519PrivateKey) Bytes() []byte {
 	return k.key[:]
 }
@@ -26,6 +26,10 @@ func (s *EdDSAEd25519Suite) PrivateKeyFromBytes(data []byte) (key.PrivateKey, er
 	return EdDSAEd25519PrivateKeyFromBytes(data)
 }
 
+func (s *EdDSAEd25519Suite) PrivateKeyFromSeed(seed []byte, path string) (key.PrivateKey, error) {
+	return EdDSAEd25519PrivateKeyFromSeed(seed, path)
+}
+
 func (s *EdDSAEd25519Suite) PublicKeyFromBytes(data []byte) (key.PublicKey, error) {
 	return EdDSAEd25519PublicKeyFromBytes(data)
 }
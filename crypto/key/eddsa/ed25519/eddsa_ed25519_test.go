@@ -1,10 +1,12 @@
 package ed25519
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/andantan/kangaroo/codec/wrapper"
 	"github.com/andantan/kangaroo/crypto/hash"
 	"github.com/andantan/kangaroo/crypto/hash/testutil"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key/eddsa"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,6 +28,24 @@ func Test_EdDSA_Ed25519_PrivateKey_Lifecycle(t *testing.T) {
 	assert.Equal(t, privKey, reloadedPrivKey)
 }
 
+func Test_EdDSA_Ed25519_PrivateKey_FromSeed(t *testing.T) {
+	// SLIP-10 test vector 1.
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+
+	privKey, err := EdDSAEd25519PrivateKeyFromSeed(seed, "m/0'/1'")
+	require.NoError(t, err)
+	assert.True(t, privKey.IsValid())
+	assert.Equal(t, "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2", hex.EncodeToString(privKey.Bytes()[:32]))
+
+	again, err := (&EdDSAEd25519Suite{}).PrivateKeyFromSeed(seed, "m/0'/1'")
+	require.NoError(t, err)
+	assert.Equal(t, privKey, again)
+
+	_, err = EdDSAEd25519PrivateKeyFromSeed(seed, "0/1")
+	assert.ErrorIs(t, err, hd.ErrInvalidPath)
+}
+
 func Test_EdDSA_Ed25519_PublicKey_Lifecycle(t *testing.T) {
 	addressSuites := testutil.GetAddressSuiteTestCases(t)
 	privKey, err := GenerateEdDSAEd25519PrivateKey()
@@ -3,6 +3,7 @@ package ed448
 import (
 	"encoding/hex"
 	"fmt"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key"
 	"github.com/andantan/kangaroo/crypto/key/eddsa"
 	"github.com/cloudflare/circl/sign/ed448"
@@ -36,6 +37,12 @@ func EdDSAEd448PrivateKeyFromBytes(b []byte) (key.PrivateKey, error) {
 	}, nil
 }
 
+// EdDSAEd448PrivateKeyFromSeed always fails: neither BIP-32 nor SLIP-10 define
+// derivation for this curve.
+func EdDSAEd448PrivateKeyFromSeed(_ []byte, _ string) (key.PrivateKey, error) {
+	return nil, fmt.Errorf("%w: %s", hd.ErrUnsupportedCurve, eddsa.EdDSAEd448Type)
+}
+
 func (k *EdDSAEd448PrivateKey) Bytes() []byte {
 	return append([]byte(nil), k.key...)
 }
@@ -26,6 +26,10 @@ func (s *EdDSAEd448Suite) PrivateKeyFromBytes(data []byte) (key.PrivateKey, erro
 	return EdDSAEd448PrivateKeyFromBytes(data)
 }
 
+func (s *EdDSAEd448Suite) PrivateKeyFromSeed(seed []byte, path string) (key.PrivateKey, error) {
+	return EdDSAEd448PrivateKeyFromSeed(seed, path)
+}
+
 func (s *EdDSAEd448Suite) PublicKeyFromBytes(data []byte) (key.PublicKey, error) {
 	return EdDSAEd448PublicKeyFromBytes(data)
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/andantan/kangaroo/codec/wrapper"
 	"github.com/andantan/kangaroo/crypto/hash"
 	"github.com/andantan/kangaroo/crypto/hash/testutil"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key/eddsa"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,6 +27,11 @@ func Test_EdDSA_Ed448_PrivateKey_Lifecycle(t *testing.T) {
 	assert.Equal(t, privKey, reloadedPrivKey)
 }
 
+func Test_EdDSA_Ed448_PrivateKey_FromSeed(t *testing.T) {
+	_, err := (&EdDSAEd448Suite{}).PrivateKeyFromSeed(make([]byte, 32), hd.DefaultPath)
+	assert.ErrorIs(t, err, hd.ErrUnsupportedCurve)
+}
+
 func Test_EdDSA_Ed448_PublicKey_Lifecycle(t *testing.T) {
 	addressSuites := testutil.GetAddressSuiteTestCases(t)
 	privKey, err := GenerateEdDSAEd448PrivateKey()
@@ -7,6 +7,9 @@ type KeySuite interface {
 
 	GeneratePrivateKey() (PrivateKey, error)
 	PrivateKeyFromBytes(data []byte) (PrivateKey, error)
+	// PrivateKeyFromSeed derives the key at a BIP-32 style path below a
+	// BIP-39 seed; suites without a derivation scheme return an error.
+	PrivateKeyFromSeed(seed []byte, path string) (PrivateKey, error)
 	PublicKeyFromBytes(data []byte) (PublicKey, error)
 	SignatureFromBytes(data []byte) (Signature, error)
 }
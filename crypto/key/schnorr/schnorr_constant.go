@@ -19,4 +19,7 @@ const (
 	SchnorrSr25519PrivateKeyHexLength   = SchnorrSr25519PrivateKeyBytesLength * 2
 	SchnorrSr25519PublicKeyHexLength    = SchnorrSr25519PublicKeyBytesLength * 2
 	SchnorrSr25519SignatureHexLength    = SchnorrSr25519SignatureBytesLength * 2
+	SchnorrSr25519VrfContextString      = "kangaroo-schnorr-sr25519-vrf-context-string"
+	SchnorrSr25519VrfOutputBytesLength  = 32
+	SchnorrSr25519VrfProofBytesLength   = 64
 )
@@ -3,6 +3,7 @@ package secp256k1
 import (
 	"encoding/hex"
 	"fmt"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key"
 	kangarooschnorr "github.com/andantan/kangaroo/crypto/key/schnorr"
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
@@ -39,6 +40,17 @@ func SchnorrSecp256k1PrivateKeyFromBytes(b []byte) (key.PrivateKey, error) {
 	}, nil
 }
 
+// SchnorrSecp256k1PrivateKeyFromSeed derives the key at path below a BIP-39 seed with
+// BIP-32.
+func SchnorrSecp256k1PrivateKeyFromSeed(seed []byte, path string) (key.PrivateKey, error) {
+	k, err := hd.DerivePath(hd.Secp256k1, seed, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return SchnorrSecp256k1PrivateKeyFromBytes(k)
+}
+
 func (k *SchnorrSecp256k1PrivateKey) Bytes() []byte {
 	return k.key.Serialize()
 }
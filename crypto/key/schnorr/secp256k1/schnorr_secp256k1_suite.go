@@ -26,6 +26,10 @@ func (s *SchnorrSecp256k1Suite) PrivateKeyFromBytes(data []byte) (key.PrivateKey
 	return SchnorrSecp256k1PrivateKeyFromBytes(data)
 }
 
+func (s *SchnorrSecp256k1Suite) PrivateKeyFromSeed(seed []byte, path string) (key.PrivateKey, error) {
+	return SchnorrSecp256k1PrivateKeyFromSeed(seed, path)
+}
+
 func (s *SchnorrSecp256k1Suite) PublicKeyFromBytes(data []byte) (key.PublicKey, error) {
 	return SchnorrSecp256k1PublicKeyFromBytes(data)
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/ChainSafe/go-schnorrkel"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key"
 	"github.com/andantan/kangaroo/crypto/key/schnorr"
 )
@@ -43,6 +44,12 @@ func SchnorrSr25519PrivateKeyFromBytes(b []byte) (key.PrivateKey, error) {
 	}, nil
 }
 
+// SchnorrSr25519PrivateKeyFromSeed always fails: neither BIP-32 nor SLIP-10 define
+// derivation for this curve.
+func SchnorrSr25519PrivateKeyFromSeed(_ []byte, _ string) (key.PrivateKey, error) {
+	return nil, fmt.Errorf("%w: %s", hd.ErrUnsupportedCurve, schnorr.SchnorrSr25519Type)
+}
+
 func (k *SchnorrSr25519PrivateKey) Bytes() []byte {
 	ke := k.key.Encode()
 	return append([]byte(nil), ke[:]...)
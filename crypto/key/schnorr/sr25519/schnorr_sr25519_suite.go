@@ -26,6 +26,10 @@ func (s *SchnorrSr25519Suite) PrivateKeyFromBytes(data []byte) (key.PrivateKey,
 	return SchnorrSr25519PrivateKeyFromBytes(data)
 }
 
+func (s *SchnorrSr25519Suite) PrivateKeyFromSeed(seed []byte, path string) (key.PrivateKey, error) {
+	return SchnorrSr25519PrivateKeyFromSeed(seed, path)
+}
+
 func (s *SchnorrSr25519Suite) PublicKeyFromBytes(data []byte) (key.PublicKey, error) {
 	return SchnorrSr25519PublicKeyFromBytes(data)
 }
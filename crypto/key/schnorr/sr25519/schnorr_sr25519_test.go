@@ -6,6 +6,7 @@ import (
 	"github.com/andantan/kangaroo/codec/wrapper"
 	"github.com/andantan/kangaroo/crypto/hash"
 	"github.com/andantan/kangaroo/crypto/hash/testutil"
+	"github.com/andantan/kangaroo/crypto/hd"
 	"github.com/andantan/kangaroo/crypto/key/schnorr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,6 +28,11 @@ func Test_SCHNORR_Sr25519_PrivateKey_Lifecycle(t *testing.T) {
 	assert.True(t, bytes.Equal(privKey.Bytes(), reloadedPrivKey.Bytes()))
 }
 
+func Test_SCHNORR_Sr25519_PrivateKey_FromSeed(t *testing.T) {
+	_, err := (&SchnorrSr25519Suite{}).PrivateKeyFromSeed(make([]byte, 32), hd.DefaultPath)
+	assert.ErrorIs(t, err, hd.ErrUnsupportedCurve)
+}
+
 func Test_SCHNORR_Sr25519_PublicKey_Lifecycle(t *testing.T) {
 	addressSuites := testutil.GetAddressSuiteTestCases(t)
 	privKey, err := GenerateSchnorrSr25519PrivateKey()
@@ -185,3 +191,47 @@ func Test_SCHNORR_Sr25519_Key_Wrapper_RoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func Test_SCHNORR_Sr25519_Vrf(t *testing.T) {
+	privKey, err := GenerateSchnorrSr25519PrivateKey()
+	require.NoError(t, err)
+	sk := privKey.(*SchnorrSr25519PrivateKey)
+	pk := privKey.PublicKey().(*SchnorrSr25519PublicKey)
+
+	input := []byte("vrf input")
+	output, proof, err := sk.VrfSign(input)
+	require.NoError(t, err)
+	assert.Len(t, output, schnorr.SchnorrSr25519VrfOutputBytesLength)
+	assert.Len(t, proof, schnorr.SchnorrSr25519VrfProofBytesLength)
+
+	t.Run("Output is deterministic", func(t *testing.T) {
+		again, _, err := sk.VrfSign(input)
+		require.NoError(t, err)
+		assert.Equal(t, output, again)
+	})
+
+	t.Run("Verification with correct key and input should succeed", func(t *testing.T) {
+		assert.NoError(t, pk.VrfVerify(input, output, proof))
+	})
+
+	t.Run("Verification with wrong input should fail", func(t *testing.T) {
+		assert.Error(t, pk.VrfVerify([]byte("other input"), output, proof))
+	})
+
+	t.Run("Verification with wrong output should fail", func(t *testing.T) {
+		otherOutput, _, err := sk.VrfSign([]byte("other input"))
+		require.NoError(t, err)
+		assert.Error(t, pk.VrfVerify(input, otherOutput, proof))
+	})
+
+	t.Run("Verification with wrong key should fail", func(t *testing.T) {
+		otherPrivKey, err := GenerateSchnorrSr25519PrivateKey()
+		require.NoError(t, err)
+		otherPubKey := otherPrivKey.PublicKey().(*SchnorrSr25519PublicKey)
+		assert.Error(t, otherPubKey.VrfVerify(input, output, proof))
+	})
+
+	t.Run("Verification with malformed proof should fail", func(t *testing.T) {
+		assert.Error(t, pk.VrfVerify(input, output, proof[:10]))
+	})
+}
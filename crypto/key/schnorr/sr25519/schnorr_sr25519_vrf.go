@@ -0,0 +1,65 @@
+package sr25519
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ChainSafe/go-schnorrkel"
+	"github.com/andantan/kangaroo/crypto/key/schnorr"
+)
+
+// VrfSign evaluates the sr25519 VRF on input. The output is unique for the key
+// and input, and the proof lets anyone holding the public key check it.
+func (k *SchnorrSr25519PrivateKey) VrfSign(input []byte) (output []byte, proof []byte, err error) {
+	t := schnorrkel.NewSigningContext([]byte(schnorr.SchnorrSr25519VrfContextString), input)
+	inout, p, err := k.key.VrfSign(t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := inout.Output().Encode()
+	pr := p.Encode()
+	return append([]byte(nil), out[:]...), append([]byte(nil), pr[:]...), nil
+}
+
+func (k *SchnorrSr25519PublicKey) VrfVerify(input, output, proof []byte) error {
+	if len(output) != schnorr.SchnorrSr25519VrfOutputBytesLength {
+		return fmt.Errorf("invalid vrf output length: expected %d, got %d",
+			schnorr.SchnorrSr25519VrfOutputBytesLength, len(output))
+	}
+	if len(proof) != schnorr.SchnorrSr25519VrfProofBytesLength {
+		return fmt.Errorf("invalid vrf proof length: expected %d, got %d",
+			schnorr.SchnorrSr25519VrfProofBytesLength, len(proof))
+	}
+
+	a := [schnorr.SchnorrSr25519PublicKeyBytesLength]byte{}
+	copy(a[:], k.Key)
+	pk := &schnorrkel.PublicKey{}
+	if err := pk.Decode(a); err != nil {
+		return err
+	}
+
+	outBytes := [schnorr.SchnorrSr25519VrfOutputBytesLength]byte{}
+	copy(outBytes[:], output)
+	out := &schnorrkel.VrfOutput{}
+	if err := out.Decode(outBytes); err != nil {
+		return err
+	}
+
+	proofBytes := [schnorr.SchnorrSr25519VrfProofBytesLength]byte{}
+	copy(proofBytes[:], proof)
+	p := &schnorrkel.VrfProof{}
+	if err := p.Decode(proofBytes); err != nil {
+		return err
+	}
+
+	t := schnorrkel.NewSigningContext([]byte(schnorr.SchnorrSr25519VrfContextString), input)
+	ok, err := pk.VrfVerify(t, out, p)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid vrf proof")
+	}
+
+	return nil
+}
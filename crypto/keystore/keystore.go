@@ -0,0 +1,221 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/types/atomicfile"
+	"os"
+	"strconv"
+)
+
+// Version is the keystore format written by Encrypt. Files of other
+// versions are rejected.
+const Version = 1
+
+var (
+	ErrWrongPassword      = errors.New("wrong password or corrupted key file")
+	ErrUnsupportedVersion = errors.New("unsupported key file version")
+	ErrInvalidKeyFile     = errors.New("invalid key file")
+)
+
+// KeyFile is the JSON form of a password encrypted private key. The key
+// type, public key and address stay in cleartext so a key file can be
+// identified without the password; they are bound to the ciphertext as
+// associated data, so editing them makes decryption fail.
+type KeyFile struct {
+	Version   int    `json:"version"`
+	KeyType   string `json:"key_type"`
+	PublicKey string `json:"public_key"`
+	Address   string `json:"address,omitempty"`
+	Crypto    Crypto `json:"crypto"`
+}
+
+type Crypto struct {
+	Cipher     string    `json:"cipher"`
+	CipherText string    `json:"ciphertext"`
+	Nonce      string    `json:"nonce"`
+	KDF        string    `json:"kdf"`
+	KDFParams  KDFParams `json:"kdf_params"`
+}
+
+// Encrypt seals priv under a key derived from password. The address is
+// optional and only recorded for display.
+func Encrypt(priv key.PrivateKey, address hash.Address, password []byte, opts Options) (*KeyFile, error) {
+	var wrappedAddress string
+	if address != nil {
+		var err error
+		if wrappedAddress, err = wrapper.WrapAddressToString(address); err != nil {
+			return nil, err
+		}
+	}
+	return encrypt(priv, wrappedAddress, password, opts)
+}
+
+func encrypt(priv key.PrivateKey, address string, password []byte, opts Options) (*KeyFile, error) {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := wrapper.WrapPublicKeyToString(priv.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+	f := &KeyFile{Version: Version, KeyType: priv.Type(), PublicKey: publicKey, Address: address}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params := opts.kdfParams(salt)
+	derived, err := deriveKey(opts.KDF, params, password)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(opts.Cipher, derived)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	f.Crypto = Crypto{
+		Cipher:    opts.Cipher,
+		Nonce:     hex.EncodeToString(nonce),
+		KDF:       opts.KDF,
+		KDFParams: params,
+	}
+	f.Crypto.CipherText = hex.EncodeToString(aead.Seal(nil, nonce, priv.Bytes(), f.associatedData()))
+	return f, nil
+}
+
+// Decrypt opens the key file and rebuilds the private key with the key
+// suite registered for its type.
+func (f *KeyFile) Decrypt(password []byte) (key.PrivateKey, error) {
+	if f.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, f.Version)
+	}
+	suite, err := registry.GetKeySuite(f.KeyType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+
+	nonce, err := hex.DecodeString(f.Crypto.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: nonce: %v", ErrInvalidKeyFile, err)
+	}
+	cipherText, err := hex.DecodeString(f.Crypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ciphertext: %v", ErrInvalidKeyFile, err)
+	}
+
+	derived, err := deriveKey(f.Crypto.KDF, f.Crypto.KDFParams, password)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(f.Crypto.Cipher, derived)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: nonce of %d bytes", ErrInvalidKeyFile, len(nonce))
+	}
+
+	plain, err := aead.Open(nil, nonce, cipherText, f.associatedData())
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+
+	priv, err := suite.PrivateKeyFromBytes(plain)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+
+	// The stored key may predate the wrapper's envelope, so compare keys
+	// rather than strings.
+	publicKey, err := wrapper.UnwrapPublicKeyFromString(f.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: public key: %v", ErrInvalidKeyFile, err)
+	}
+	if !priv.PublicKey().Equal(publicKey) {
+		return nil, fmt.Errorf("%w: public key does not match the private key", ErrInvalidKeyFile)
+	}
+	return priv, nil
+}
+
+// ChangePassword decrypts the key file with oldPassword and returns it
+// encrypted under newPassword with fresh salt and nonce.
+func (f *KeyFile) ChangePassword(oldPassword, newPassword []byte, opts Options) (*KeyFile, error) {
+	priv, err := f.Decrypt(oldPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return encrypt(priv, f.Address, newPassword, opts)
+}
+
+// associatedData binds the cleartext fields and the crypto parameters to
+// the ciphertext.
+func (f *KeyFile) associatedData() []byte {
+	p := f.Crypto.KDFParams
+	var ad []byte
+	for _, field := range []string{
+		"kangaroo-keystore", strconv.Itoa(f.Version), f.KeyType, f.PublicKey, f.Address,
+		f.Crypto.Cipher, f.Crypto.KDF, p.Salt,
+		strconv.FormatUint(uint64(p.Time), 10), strconv.FormatUint(uint64(p.Memory), 10), strconv.FormatUint(uint64(p.Threads), 10),
+		strconv.Itoa(p.N), strconv.Itoa(p.R), strconv.Itoa(p.P),
+	} {
+		ad = strconv.AppendInt(ad, int64(len(field)), 10)
+		ad = append(ad, ':')
+		ad = append(ad, field...)
+	}
+	return ad
+}
+
+func Parse(data []byte) (*KeyFile, error) {
+	var f KeyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, f.Version)
+	}
+	return &f, nil
+}
+
+func Load(path string) (*KeyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Save writes the key file readable by the owner only, replacing path
+// atomically so an interrupted password change never loses the key.
+func (f *KeyFile) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data)
+}
+
+// Unlock loads the key file at path and decrypts it.
+func Unlock(path string, password []byte) (key.PrivateKey, error) {
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return f.Decrypt(password)
+}
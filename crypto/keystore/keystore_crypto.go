@@ -0,0 +1,166 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	KDFArgon2id = "argon2id"
+	KDFScrypt   = "scrypt"
+
+	CipherAES256GCM         = "aes-256-gcm"
+	CipherXChaCha20Poly1305 = "xchacha20-poly1305"
+)
+
+// Defaults follow the RFC 9106 second recommended option for argon2id and
+// the usual interactive-login parameters for scrypt.
+const (
+	DefaultArgon2Time    = 3
+	DefaultArgon2Memory  = 64 << 10
+	DefaultArgon2Threads = 4
+
+	DefaultScryptN = 1 << 15
+	DefaultScryptR = 8
+	DefaultScryptP = 1
+)
+
+// Upper bounds on the KDF cost a key file may ask for, so a crafted file
+// cannot make unlocking exhaust memory or spin forever.
+const (
+	maxArgon2Time   = 64
+	maxArgon2Memory = 4 << 20
+	maxScryptN      = 1 << 22
+	maxScryptRP     = 1 << 10
+)
+
+const (
+	saltSize = 32
+	keySize  = 32
+)
+
+// Options pick the KDF, its cost and the cipher. Zero fields take the
+// defaults: argon2id and XChaCha20-Poly1305.
+type Options struct {
+	KDF    string
+	Cipher string
+
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+
+	ScryptN int
+	ScryptR int
+	ScryptP int
+}
+
+// KDFParams holds the salt and the cost parameters of the file's KDF; the
+// fields of the other KDF are left out.
+type KDFParams struct {
+	Salt string `json:"salt"`
+
+	// argon2id; Memory is in KiB.
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+}
+
+func (o Options) withDefaults() Options {
+	if o.KDF == "" {
+		o.KDF = KDFArgon2id
+	}
+	if o.Cipher == "" {
+		o.Cipher = CipherXChaCha20Poly1305
+	}
+	if o.Argon2Time == 0 {
+		o.Argon2Time = DefaultArgon2Time
+	}
+	if o.Argon2Memory == 0 {
+		o.Argon2Memory = DefaultArgon2Memory
+	}
+	if o.Argon2Threads == 0 {
+		o.Argon2Threads = DefaultArgon2Threads
+	}
+	if o.ScryptN == 0 {
+		o.ScryptN = DefaultScryptN
+	}
+	if o.ScryptR == 0 {
+		o.ScryptR = DefaultScryptR
+	}
+	if o.ScryptP == 0 {
+		o.ScryptP = DefaultScryptP
+	}
+	return o
+}
+
+func (o Options) validate() error {
+	if o.KDF != KDFArgon2id && o.KDF != KDFScrypt {
+		return fmt.Errorf("unsupported kdf %q", o.KDF)
+	}
+	if o.Cipher != CipherAES256GCM && o.Cipher != CipherXChaCha20Poly1305 {
+		return fmt.Errorf("unsupported cipher %q", o.Cipher)
+	}
+	return nil
+}
+
+func (o Options) kdfParams(salt []byte) KDFParams {
+	params := KDFParams{Salt: hex.EncodeToString(salt)}
+	switch o.KDF {
+	case KDFArgon2id:
+		params.Time, params.Memory, params.Threads = o.Argon2Time, o.Argon2Memory, o.Argon2Threads
+	case KDFScrypt:
+		params.N, params.R, params.P = o.ScryptN, o.ScryptR, o.ScryptP
+	}
+	return params
+}
+
+func deriveKey(kdf string, params KDFParams, password []byte) ([]byte, error) {
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("%w: bad salt", ErrInvalidKeyFile)
+	}
+
+	switch kdf {
+	case KDFArgon2id:
+		if params.Time == 0 || params.Time > maxArgon2Time || params.Memory == 0 || params.Memory > maxArgon2Memory || params.Threads == 0 {
+			return nil, fmt.Errorf("%w: argon2id parameters out of range", ErrInvalidKeyFile)
+		}
+		return argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, keySize), nil
+	case KDFScrypt:
+		if params.N < 2 || params.N > maxScryptN || params.R <= 0 || params.R > maxScryptRP || params.P <= 0 || params.P > maxScryptRP {
+			return nil, fmt.Errorf("%w: scrypt parameters out of range", ErrInvalidKeyFile)
+		}
+		derived, err := scrypt.Key(password, salt, params.N, params.R, params.P, keySize)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+		}
+		return derived, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown kdf %q", ErrInvalidKeyFile, kdf)
+	}
+}
+
+func newAEAD(name string, derived []byte) (cipher.AEAD, error) {
+	switch name {
+	case CipherAES256GCM:
+		block, err := aes.NewCipher(derived)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NewX(derived)
+	default:
+		return nil, fmt.Errorf("%w: unknown cipher %q", ErrInvalidKeyFile, name)
+	}
+}
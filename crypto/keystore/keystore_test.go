@@ -0,0 +1,171 @@
+package keystore
+
+import (
+	"encoding/json"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cheap keeps the KDFs fast in tests.
+var cheap = []Options{
+	{KDF: KDFArgon2id, Cipher: CipherXChaCha20Poly1305, Argon2Time: 1, Argon2Memory: 64, Argon2Threads: 1},
+	{KDF: KDFArgon2id, Cipher: CipherAES256GCM, Argon2Time: 1, Argon2Memory: 64, Argon2Threads: 1},
+	{KDF: KDFScrypt, Cipher: CipherXChaCha20Poly1305, ScryptN: 16, ScryptR: 1, ScryptP: 1},
+	{KDF: KDFScrypt, Cipher: CipherAES256GCM, ScryptN: 16, ScryptR: 1, ScryptP: 1},
+}
+
+func TestKeystore_RoundTrip(t *testing.T) {
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	for _, keyType := range registry.ListKeySuiteTypes() {
+		for _, opts := range cheap {
+			t.Run(keyType+"/"+opts.KDF+"/"+opts.Cipher, func(t *testing.T) {
+				suite, err := registry.GetKeySuite(keyType)
+				require.NoError(t, err)
+				priv, err := suite.GeneratePrivateKey()
+				require.NoError(t, err)
+
+				f, err := Encrypt(priv, priv.PublicKey().Address(addressSuite.Deriver()), []byte("hunter2"), opts)
+				require.NoError(t, err)
+				assert.Equal(t, Version, f.Version)
+				assert.Equal(t, keyType, f.KeyType)
+				assert.NotEmpty(t, f.Address)
+				assert.NotContains(t, f.Crypto.CipherText, priv.String())
+
+				encoded, err := json.Marshal(f)
+				require.NoError(t, err)
+				parsed, err := Parse(encoded)
+				require.NoError(t, err)
+
+				got, err := parsed.Decrypt([]byte("hunter2"))
+				require.NoError(t, err)
+				assert.Equal(t, priv.Bytes(), got.Bytes())
+
+				_, err = parsed.Decrypt([]byte("hunter3"))
+				assert.ErrorIs(t, err, ErrWrongPassword)
+			})
+		}
+	}
+}
+
+func TestKeystore_Tampering(t *testing.T) {
+	suite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	priv, err := suite.GeneratePrivateKey()
+	require.NoError(t, err)
+	other, err := suite.GeneratePrivateKey()
+	require.NoError(t, err)
+	otherFile, err := Encrypt(other, nil, []byte("pw"), cheap[0])
+	require.NoError(t, err)
+
+	password := []byte("pw")
+	f, err := Encrypt(priv, nil, password, cheap[0])
+	require.NoError(t, err)
+
+	// The cleartext fields are bound to the ciphertext.
+	swapped := *f
+	swapped.PublicKey = otherFile.PublicKey
+	_, err = swapped.Decrypt(password)
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	cheaper := *f
+	cheaper.Crypto.KDFParams.Time = 2
+	_, err = cheaper.Decrypt(password)
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	// Crafted parameters are refused before running the KDF.
+	greedy := *f
+	greedy.Crypto.KDFParams.Memory = 1 << 30
+	_, err = greedy.Decrypt(password)
+	assert.ErrorIs(t, err, ErrInvalidKeyFile)
+
+	unknown := *f
+	unknown.Crypto.Cipher = "rot13"
+	_, err = unknown.Decrypt(password)
+	assert.ErrorIs(t, err, ErrInvalidKeyFile)
+
+	_, err = Parse([]byte(`{"version": 2}`))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	_, err = Parse([]byte(`{`))
+	assert.ErrorIs(t, err, ErrInvalidKeyFile)
+
+	_, err = Encrypt(priv, nil, password, Options{KDF: "pbkdf2"})
+	assert.Error(t, err)
+}
+
+func TestKeystore_FileAndPasswordChange(t *testing.T) {
+	suite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	priv, err := suite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	f, err := Encrypt(priv, nil, []byte("old"), cheap[0])
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "wallet.json")
+	require.NoError(t, f.Save(path))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, f, loaded)
+
+	_, err = loaded.ChangePassword([]byte("wrong"), []byte("new"), cheap[2])
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	changed, err := loaded.ChangePassword([]byte("old"), []byte("new"), cheap[2])
+	require.NoError(t, err)
+	assert.Equal(t, KDFScrypt, changed.Crypto.KDF)
+	assert.NotEqual(t, f.Crypto.KDFParams.Salt, changed.Crypto.KDFParams.Salt)
+	require.NoError(t, changed.Save(path))
+
+	_, err = Unlock(path, []byte("old"))
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	got, err := Unlock(path, []byte("new"))
+	require.NoError(t, err)
+	assert.Equal(t, priv.Bytes(), got.Bytes())
+}
+
+// legacyKeyFile was written before wrapped bytes got an envelope.
+const legacyKeyFile = `{
+  "version": 1,
+  "key_type": "eddsa-ed25519",
+  "public_key": "0x0387addb00c23ae79c3c3fe56afe73005b856e7dd6670f2303e9819d2e844d8dfd",
+  "address": "0x03680ae7add8e8ffccb2a9ed105ef9b0f0930e156b",
+  "crypto": {
+    "cipher": "xchacha20-poly1305",
+    "ciphertext": "d22c4a71ea49ce51c0c0d901246b3217b9c03d4aeef6b84859c565b30b5c034f6c2db2b2797cfbd82910c8d5e1ee9ea567e7faf53539311db40f95f2ed6961be278ff2cb692d4d7eff0a967132a5cdd8",
+    "nonce": "89205a1753e7ad95b4d2b6c082a877537e2cb5d1ed49fb90",
+    "kdf": "scrypt",
+    "kdf_params": {
+      "salt": "516c1b879bd8caaa70e74364d426a9de84f7f66dae1c389134004e2ccc999686",
+      "n": 16,
+      "r": 1,
+      "p": 1
+    }
+  }
+}`
+
+func TestKeystore_Legacy(t *testing.T) {
+	f, err := Parse([]byte(legacyKeyFile))
+	require.NoError(t, err)
+
+	priv, err := f.Decrypt([]byte("hunter2"))
+	require.NoError(t, err)
+	assert.Equal(t, "eddsa-ed25519", priv.Type())
+
+	changed, err := f.ChangePassword([]byte("hunter2"), []byte("hunter3"), cheap[0])
+	require.NoError(t, err)
+	assert.Equal(t, f.Address, changed.Address)
+	got, err := changed.Decrypt([]byte("hunter3"))
+	require.NoError(t, err)
+	assert.Equal(t, priv.Bytes(), got.Bytes())
+}
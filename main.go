@@ -4,8 +4,60 @@ import (
 	"fmt"
 	_ "github.com/andantan/kangaroo/core/all"
 	_ "github.com/andantan/kangaroo/crypto/all"
+	"log"
+	"os"
 )
 
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"init", "write a config, genesis and node key for a new network", runInit},
+	{"start", "run the node", runStart},
+	{"version", "print version and supported suites", runVersion},
+	{"export", "write the chain to an archive file", runExport},
+	{"import", "add the blocks of an archive file to the chain", runImport},
+	{"tx", "build, sign, verify, decode, hash and send transactions", runTx},
+	{"inspect", "guess what a 0x wrapped blob is", runInspect},
+}
+
+// e.g., make run ARGS="init --data-dir=.kangaroo"
+// e.g., make run ARGS="start --config=.kangaroo/config.yaml --rpc.http=127.0.0.1:8645"
+// e.g., make run ARGS="export -o chain.dat"
+// e.g., make run ARGS="tx decode 0x01..."
 func main() {
-	fmt.Println("Hello, World!")
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name, args := os.Args[1], os.Args[2:]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(args); err != nil {
+				log.Fatalf("FATAL: %s: %v", name, err)
+			}
+			return
+		}
+	}
+
+	if name != "help" && name != "-h" && name != "--help" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: kangaroo <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'kangaroo <command> -h' for the flags of a command.\n")
 }
@@ -0,0 +1,186 @@
+package mempool
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrAlreadyKnown = errors.New("transaction already known")
+	ErrMempoolFull  = errors.New("mempool is full")
+	// ErrInvalidTransaction marks transactions rejected for their own
+	// content, as opposed to the state of the pool.
+	ErrInvalidTransaction = errors.New("invalid transaction")
+)
+
+type entry struct {
+	tx     transaction.Transaction
+	id     hash.Hash
+	sender string
+	seq    uint64
+}
+
+// Mempool keeps verified, not yet included transactions keyed by their hash.
+type Mempool struct {
+	lock     sync.RWMutex
+	hasher   hash.HashDeriver
+	capacity int
+	entries  map[string]*entry
+	seq      uint64
+}
+
+func NewMempool(hasher hash.HashDeriver, capacity int) *Mempool {
+	return &Mempool{
+		hasher:   hasher,
+		capacity: capacity,
+		entries:  make(map[string]*entry),
+	}
+}
+
+func (m *Mempool) Add(tx transaction.Transaction) (hash.Hash, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("%w: transaction cannot be nil", ErrInvalidTransaction)
+	}
+
+	if err := tx.Verify(m.hasher); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+
+	id, err := tx.Hash(m.hasher)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+
+	sender, err := wrapper.WrapPublicKeyToString(tx.GetSigner())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	k := string(id.Bytes())
+	if _, ok := m.entries[k]; ok {
+		return id, fmt.Errorf("%w: %s", ErrAlreadyKnown, id.ShortString(8))
+	}
+
+	if m.capacity > 0 && len(m.entries) >= m.capacity {
+		return nil, ErrMempoolFull
+	}
+
+	m.seq++
+	m.entries[k] = &entry{tx: tx, id: id, sender: sender, seq: m.seq}
+
+	return id, nil
+}
+
+func (m *Mempool) Has(id hash.Hash) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	_, ok := m.entries[string(id.Bytes())]
+	return ok
+}
+
+func (m *Mempool) Get(id hash.Hash) (transaction.Transaction, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	e, ok := m.entries[string(id.Bytes())]
+	if !ok {
+		return nil, false
+	}
+	return e.tx, true
+}
+
+// Range calls fn with every transaction and its hash until fn returns false.
+func (m *Mempool) Range(fn func(id hash.Hash, tx transaction.Transaction) bool) {
+	m.lock.RLock()
+	entries := make([]*entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, e)
+	}
+	m.lock.RUnlock()
+
+	for _, e := range entries {
+		if !fn(e.id, e.tx) {
+			return
+		}
+	}
+}
+
+func (m *Mempool) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return len(m.entries)
+}
+
+// Pending returns every transaction, each sender's transactions in nonce order,
+// and senders ordered by the arrival of their oldest transaction.
+func (m *Mempool) Pending() []transaction.Transaction {
+	m.lock.RLock()
+	bySender := make(map[string][]*entry)
+	for _, e := range m.entries {
+		bySender[e.sender] = append(bySender[e.sender], e)
+	}
+	total := len(m.entries)
+	m.lock.RUnlock()
+
+	groups := make([][]*entry, 0, len(bySender))
+	for _, es := range bySender {
+		sort.Slice(es, func(i, j int) bool {
+			if es[i].tx.GetNonce() != es[j].tx.GetNonce() {
+				return es[i].tx.GetNonce() < es[j].tx.GetNonce()
+			}
+			return es[i].seq < es[j].seq
+		})
+		groups = append(groups, es)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return oldest(groups[i]) < oldest(groups[j])
+	})
+
+	txs := make([]transaction.Transaction, 0, total)
+	for _, es := range groups {
+		for _, e := range es {
+			txs = append(txs, e.tx)
+		}
+	}
+	return txs
+}
+
+func oldest(es []*entry) uint64 {
+	min := es[0].seq
+	for _, e := range es[1:] {
+		if e.seq < min {
+			min = e.seq
+		}
+	}
+	return min
+}
+
+func (m *Mempool) Remove(ids ...hash.Hash) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, id := range ids {
+		delete(m.entries, string(id.Bytes()))
+	}
+}
+
+// RemoveTransactions drops transactions that were included in a block.
+func (m *Mempool) RemoveTransactions(txs []transaction.Transaction) {
+	ids := make([]hash.Hash, 0, len(txs))
+	for _, tx := range txs {
+		id, err := tx.Hash(m.hasher)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	m.Remove(ids...)
+}
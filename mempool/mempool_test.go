@@ -0,0 +1,104 @@
+package mempool
+
+import (
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func signedTx(t *testing.T, signer key.PrivateKey, hasher hash.HashDeriver, nonce uint64) transaction.Transaction {
+	tx := kangarootransaction.NewKangarooTransaction(nil, nil, []byte("data"), nonce)
+	require.NoError(t, tx.Sign(signer, hasher))
+	return tx
+}
+
+func TestMempool(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("keccak256")
+	require.NoError(t, err)
+	hasher := hashSuite.Deriver()
+
+	alice, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	bob, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	pool := NewMempool(hasher, 4)
+
+	bob0 := signedTx(t, bob, hasher, 0)
+	alice1 := signedTx(t, alice, hasher, 1)
+	alice0 := signedTx(t, alice, hasher, 0)
+
+	for _, tx := range []transaction.Transaction{bob0, alice1, alice0} {
+		_, err := pool.Add(tx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, pool.Len())
+
+	t.Run("should reject duplicates", func(t *testing.T) {
+		_, err := pool.Add(alice0)
+		assert.ErrorIs(t, err, ErrAlreadyKnown)
+	})
+
+	t.Run("should reject unsigned or tampered transactions", func(t *testing.T) {
+		_, err := pool.Add(kangarootransaction.NewKangarooTransaction(nil, nil, nil, 0))
+		assert.ErrorIs(t, err, ErrInvalidTransaction)
+
+		tampered := signedTx(t, alice, hasher, 7).(*kangarootransaction.KangarooTransaction)
+		tampered.Nonce = 8
+		_, err = pool.Add(tampered)
+		assert.ErrorIs(t, err, ErrInvalidTransaction)
+	})
+
+	t.Run("should order senders by arrival and nonces per sender", func(t *testing.T) {
+		pending := pool.Pending()
+		require.Len(t, pending, 3)
+		assert.True(t, pending[0].GetSigner().Equal(bob.PublicKey()))
+		assert.Equal(t, uint64(0), pending[1].GetNonce())
+		assert.Equal(t, uint64(1), pending[2].GetNonce())
+	})
+
+	t.Run("should range over every transaction with its hash", func(t *testing.T) {
+		seen := 0
+		pool.Range(func(id hash.Hash, tx transaction.Transaction) bool {
+			want, err := tx.Hash(hasher)
+			require.NoError(t, err)
+			assert.True(t, want.Equal(id))
+			seen++
+			return true
+		})
+		assert.Equal(t, 3, seen)
+
+		seen = 0
+		pool.Range(func(hash.Hash, transaction.Transaction) bool {
+			seen++
+			return false
+		})
+		assert.Equal(t, 1, seen)
+	})
+
+	t.Run("should enforce capacity", func(t *testing.T) {
+		_, err := pool.Add(signedTx(t, bob, hasher, 1))
+		require.NoError(t, err)
+		_, err = pool.Add(signedTx(t, bob, hasher, 2))
+		assert.ErrorIs(t, err, ErrMempoolFull)
+	})
+
+	t.Run("should remove included transactions", func(t *testing.T) {
+		id, err := alice0.Hash(hasher)
+		require.NoError(t, err)
+		assert.True(t, pool.Has(id))
+
+		pool.RemoveTransactions([]transaction.Transaction{alice0, bob0})
+		assert.False(t, pool.Has(id))
+		assert.Equal(t, 2, pool.Len())
+	})
+}
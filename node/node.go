@@ -0,0 +1,562 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/consensus/bft"
+	"github.com/andantan/kangaroo/consensus/finality"
+	"github.com/andantan/kangaroo/consensus/poa"
+	"github.com/andantan/kangaroo/consensus/pow"
+	"github.com/andantan/kangaroo/consensus/slashprotection"
+	"github.com/andantan/kangaroo/consensus/validator"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/blocksync"
+	"github.com/andantan/kangaroo/p2p/compact"
+	"github.com/andantan/kangaroo/p2p/gossip"
+	"github.com/andantan/kangaroo/p2p/noise"
+	"github.com/andantan/kangaroo/p2p/peerscore"
+	"github.com/andantan/kangaroo/p2p/statesync"
+	"github.com/andantan/kangaroo/p2p/tcpnet"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/rpc/grpcapi"
+	"github.com/andantan/kangaroo/rpc/jsonrpc"
+	"github.com/andantan/kangaroo/state"
+	"google.golang.org/grpc"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrNotInitialized = errors.New("data directory is not initialized")
+
+// Node runs a chain with its mempool, the p2p stack, the RPC servers and,
+// when the node key is allowed to, block production.
+type Node struct {
+	cfg     Config
+	key     key.PrivateKey
+	chain   *chain.Chain
+	pool    *mempool.Mempool
+	slashDB *slashprotection.SlashProtectionDB
+
+	// The protocols run on scorer, which wraps transport so that peers
+	// sending them invalid data are disconnected and banned.
+	transport *tcpnet.Transport
+	scorer    *peerscore.Scorer
+	security  tcpnet.Security
+	gossip    *gossip.Gossip
+	compact   *compact.Relay
+	syncer    *blocksync.Syncer
+	snapshots *statesync.Server
+	jsonrpc   *jsonrpc.Server
+	grpcapi   *grpcapi.Server
+	producer  *poa.Producer
+	miner     *pow.Miner
+	engine    *bft.Engine
+	gadget    *finality.Gadget
+	store     *chainStore
+
+	lock       sync.Mutex
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	httpServer *http.Server
+	grpcServer *grpc.Server
+	p2pAddr    net.Addr
+	httpAddr   net.Addr
+	grpcAddr   net.Addr
+}
+
+// New builds a node from an initialized data directory and loads the chain
+// saved by the previous run. A new data directory with state_sync.sync set is
+// first restored from a snapshot of the configured peers. Nothing listens
+// until Start.
+func New(cfg Config) (*Node, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	nodeKey, err := LoadNodeKey(cfg.NodeKeyPath())
+	if err != nil {
+		return nil, fmt.Errorf("%w: node key: %v", ErrNotInitialized, err)
+	}
+	if nodeKey.Type() != cfg.Suites.Key {
+		return nil, fmt.Errorf("%w: node key is %s, suites.key is %s", ErrInvalidConfig, nodeKey.Type(), cfg.Suites.Key)
+	}
+
+	c, err := OpenChain(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Node{cfg: cfg, key: nodeKey, chain: c}
+	n.pool = mempool.NewMempool(c.Executor().HashDeriver(), cfg.Mempool.Capacity)
+
+	if err := n.setupTransport(); err != nil {
+		return nil, err
+	}
+	if cfg.StateSync.Sync && c.Height() == 0 {
+		if err := n.syncState(); err != nil {
+			n.transport.Close()
+			return nil, err
+		}
+	}
+	if err := n.setupProtocols(); err != nil {
+		n.transport.Close()
+		return nil, err
+	}
+	if err := n.setupConsensus(); err != nil {
+		n.transport.Close()
+		return nil, err
+	}
+	if err := n.setupFinality(); err != nil {
+		n.transport.Close()
+		return nil, err
+	}
+
+	n.jsonrpc = jsonrpc.NewServer(jsonrpc.Config{ChainID: cfg.ChainID, OnTransaction: func(tx transaction.Transaction) {
+		n.onLocalTransaction(tx, n.grpcapi)
+	}}, n.chain, n.pool)
+	n.grpcapi = grpcapi.NewServer(grpcapi.Config{ChainID: cfg.ChainID, OnTransaction: func(tx transaction.Transaction) {
+		n.onLocalTransaction(tx, n.jsonrpc)
+	}}, n.chain, n.pool)
+
+	return n, nil
+}
+
+// OpenChain builds the chain of an initialized data directory with the
+// configured suites and consensus rules, and loads the blocks saved by the
+// last run. A data directory bootstrapped by state sync starts the chain at
+// its snapshot.
+func OpenChain(cfg Config) (*chain.Chain, error) {
+	rules, err := loadChainRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := openSnapshotChain(cfg.SnapshotPath(), rules.executor, rules.genesis, rules.validator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	if c == nil {
+		if c, err = chain.NewChain(rules.executor, rules.genesis, rules.validator); err != nil {
+			return nil, err
+		}
+	}
+
+	loaded, err := ImportChainFile(c, cfg.ChainPath())
+	if errors.Is(err, ErrTruncatedArchive) {
+		log.Printf("[Node] chain file ends in a partly written block, dropped it")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load chain: %w", err)
+	}
+	if loaded > 0 {
+		log.Printf("[Node] loaded %d blocks, head %s", loaded, c.Head().GetHeader())
+	}
+	return c, nil
+}
+
+// chainRules are what the chains of a config are built with.
+type chainRules struct {
+	executor  *state.Executor
+	genesis   *chain.Genesis
+	validator chain.BlockValidator
+}
+
+func loadChainRules(cfg Config) (*chainRules, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	hashSuite, err := registry.GetHashSuite(cfg.Suites.Hash)
+	if err != nil {
+		return nil, err
+	}
+	addressSuite, err := registry.GetAddressSuite(cfg.Suites.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis, err := LoadGenesis(cfg.GenesisPath())
+	if err != nil {
+		return nil, fmt.Errorf("%w: genesis: %v", ErrNotInitialized, err)
+	}
+	for _, acc := range genesis.Alloc {
+		if acc.Address.Type() != cfg.Suites.Address {
+			return nil, fmt.Errorf("%w: genesis address %s is not a %s address", ErrInvalidConfig, acc.Address.ShortString(8), cfg.Suites.Address)
+		}
+	}
+
+	var validator chain.BlockValidator
+	switch cfg.Consensus.Engine {
+	case EnginePoA:
+		authority, err := wrapper.UnwrapPublicKeyFromString(cfg.Consensus.Authority)
+		if err != nil {
+			return nil, err
+		}
+		validator = poa.NewAuthorityValidator(authority, hashSuite.Deriver())
+	case EnginePoW:
+		validator = pow.NewWorkValidator(difficulty(cfg.Consensus), hashSuite)
+	case EngineBFT:
+		valSet, err := validatorSet(cfg.Consensus)
+		if err != nil {
+			return nil, err
+		}
+		validator = bft.NewCommitValidator(valSet, hashSuite.Deriver())
+	}
+
+	return &chainRules{
+		executor:  state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver()),
+		genesis:   genesis,
+		validator: validator,
+	}, nil
+}
+
+// validatorSet gives every configured BFT validator one vote.
+func validatorSet(cfg ConsensusConfig) (*validator.ValidatorSet, error) {
+	vals := make([]*validator.Validator, 0, len(cfg.Validators))
+	for _, wrapped := range cfg.Validators {
+		pubKey, err := wrapper.UnwrapPublicKeyFromString(wrapped)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, validator.NewValidator(pubKey, 1))
+	}
+	return validator.NewValidatorSet(vals)
+}
+
+func difficulty(cfg ConsensusConfig) pow.DifficultyAlgorithm {
+	if cfg.BlockTime > 0 {
+		return pow.NewEMADifficulty(cfg.BlockTime, 0, cfg.Difficulty)
+	}
+	return pow.NewFixedDifficulty(cfg.Difficulty)
+}
+
+func (n *Node) setupTransport() error {
+	executor := n.chain.Executor()
+
+	bans, err := peerscore.OpenBanList(n.cfg.BanListPath())
+	if err != nil {
+		return err
+	}
+
+	n.transport, err = tcpnet.NewTransport(tcpnet.Config{
+		ChainID:        n.cfg.ChainID,
+		PrivateKey:     n.key,
+		HashDeriver:    executor.HashDeriver(),
+		AddressDeriver: executor.AddressDeriver(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if n.cfg.P2P.Noise {
+		security, err := noise.NewSecurity(n.key, executor.HashDeriver())
+		if err != nil {
+			n.transport.Close()
+			return err
+		}
+		n.security = security
+	}
+
+	n.scorer = peerscore.NewScorer(peerscore.Config{Bans: bans}, n.transport)
+	return nil
+}
+
+// setupProtocols starts the protocols on the chain the node runs with, which
+// is only known once state sync is done.
+func (n *Node) setupProtocols() error {
+	executor := n.chain.Executor()
+
+	n.gossip = gossip.NewGossip(gossip.Config{OnInvalid: n.scorer.GossipInvalid}, n.scorer, executor.HashDeriver())
+	txHandler := gossip.TransactionHandler(n.pool, executor.HashDeriver())
+	n.gossip.Subscribe(gossip.TopicTransactions, func(from p2p.PeerID, payload []byte) error {
+		if err := txHandler(from, payload); err != nil {
+			return err
+		}
+		if tx, err := wrapper.UnwrapTransaction(payload); err == nil {
+			n.jsonrpc.PublishPendingTransaction(tx)
+			n.grpcapi.PublishPendingTransaction(tx)
+		}
+		return nil
+	})
+	n.gossip.Subscribe(gossip.TopicBlocks, gossip.BlockHandler(n.chain, n.onReorg))
+	if n.cfg.P2P.Compact {
+		n.compact = compact.NewRelay(compact.Config{OnBlock: n.importBlock, OnInvalid: n.scorer.Invalid}, n.chain, n.pool, n.scorer)
+	}
+
+	var err error
+	if n.syncer, err = blocksync.NewSyncer(blocksync.Config{OnReorg: n.onReorg, OnInvalid: n.scorer.Invalid}, n.chain, n.scorer); err != nil {
+		return err
+	}
+	if n.cfg.StateSync.Serve {
+		if n.snapshots, err = statesync.NewServer(statesync.ServerConfig{Interval: n.cfg.StateSync.Interval}, n.chain, n.scorer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Node) setupConsensus() error {
+	switch n.cfg.Consensus.Engine {
+	case EnginePoA:
+		authority, err := wrapper.UnwrapPublicKeyFromString(n.cfg.Consensus.Authority)
+		if err != nil {
+			return err
+		}
+		if !authority.Equal(n.key.PublicKey()) {
+			return nil
+		}
+
+		if n.slashDB, err = slashprotection.Open(n.cfg.SlashProtectionPath()); err != nil {
+			return err
+		}
+		policy := poa.ProduceEmptyBlocks
+		if n.cfg.Consensus.SkipEmpty {
+			policy = poa.SkipEmptyBlocks
+		}
+		if n.producer, err = poa.NewProducer(poa.Config{Interval: n.cfg.Consensus.Interval, EmptyBlockPolicy: policy}, n.chain, n.pool, n.key, n.slashDB); err != nil {
+			return err
+		}
+		n.producer.SetCandidateStore(&candidateStore{path: n.cfg.CandidatePath()})
+		return nil
+	case EnginePoW:
+		if !n.cfg.Consensus.Mine {
+			return nil
+		}
+		var err error
+		n.miner, err = pow.NewMiner(pow.Config{Threads: n.cfg.Consensus.Threads, Difficulty: difficulty(n.cfg.Consensus)}, n.chain, n.pool, n.key.PublicKey())
+		return err
+	case EngineBFT:
+		valSet, err := validatorSet(n.cfg.Consensus)
+		if err != nil {
+			return err
+		}
+		if !valSet.Has(n.key.PublicKey()) {
+			return nil
+		}
+
+		if n.slashDB, err = slashprotection.Open(n.cfg.SlashProtectionPath()); err != nil {
+			return err
+		}
+		signer, err := slashprotection.NewProtectedSigner(n.key, n.slashDB)
+		if err != nil {
+			return err
+		}
+		app := &bftApplication{ChainApplication: bft.NewChainApplication(n.chain, n.pool, 0), publish: n.publishBlock}
+		transport := bft.NewNetworkTransport(n.scorer, n.scorer.Invalid)
+		if n.engine, err = bft.NewEngine(bftConfig(n.cfg.Consensus), valSet, signer, app, transport, &bft.SystemClock{}); err != nil {
+			return err
+		}
+		transport.Attach(n.engine)
+		return nil
+	}
+	return nil
+}
+
+// setupFinality runs the finality gadget when checkpoints are on. It votes
+// when the node key is one of the validators and only counts votes
+// otherwise; the votes are gossiped as attestations.
+func (n *Node) setupFinality() error {
+	if !n.cfg.Consensus.Checkpoints {
+		return nil
+	}
+	valSet, err := validatorSet(n.cfg.Consensus)
+	if err != nil {
+		return err
+	}
+
+	var signer finality.Signer
+	if valSet.Has(n.key.PublicKey()) {
+		db, err := slashprotection.Open(n.cfg.CheckpointVotesPath())
+		if err != nil {
+			return err
+		}
+		protected, err := slashprotection.NewProtectedSigner(n.key, db)
+		if err != nil {
+			return err
+		}
+		signer = protected
+	}
+
+	if n.gadget, err = finality.NewGadget(finality.Config{EpochLength: n.cfg.Consensus.EpochLength}, valSet, n.chain, signer, n.gossip); err != nil {
+		return err
+	}
+	n.gossip.Subscribe(gossip.TopicAttestations, gossip.CheckpointVoteHandler(n.gadget))
+	return nil
+}
+
+func bftConfig(cfg ConsensusConfig) bft.Config {
+	c := bft.DefaultConfig()
+	c.TimeoutCommit = cfg.Interval
+	if cfg.RoundTimeout > 0 {
+		c.TimeoutPropose = cfg.RoundTimeout
+		c.TimeoutPrevote = cfg.RoundTimeout / 3
+		c.TimeoutPrecommit = cfg.RoundTimeout / 3
+		c.TimeoutDelta = cfg.RoundTimeout / 6
+	}
+	return c
+}
+
+// bftApplication gossips the blocks the engine commits, so that nodes
+// outside the validator set follow the chain.
+type bftApplication struct {
+	*bft.ChainApplication
+	publish func(blk block.Block)
+}
+
+func (a *bftApplication) Commit(blk block.Block) error {
+	if err := a.ChainApplication.Commit(blk); err != nil {
+		return err
+	}
+	a.publish(blk)
+	return nil
+}
+
+func (n *Node) Chain() *chain.Chain {
+	return n.chain
+}
+
+func (n *Node) Mempool() *mempool.Mempool {
+	return n.pool
+}
+
+func (n *Node) Key() key.PrivateKey {
+	return n.key
+}
+
+// Start opens the listeners, dials the configured peers and starts block
+// production. It returns once everything is running; Close stops it.
+func (n *Node) Start() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.cancel != nil {
+		return errors.New("node already started")
+	}
+
+	if n.cfg.P2P.Listen != "" {
+		addr, err := n.transport.Listen(n.cfg.P2P.Listen, n.security)
+		if err != nil {
+			return fmt.Errorf("p2p listen: %w", err)
+		}
+		n.p2pAddr = addr
+		log.Printf("[Node] p2p listening on %s as %s", addr, n.transport.ID().ShortString(10))
+	}
+
+	if n.cfg.RPC.HTTP != "" {
+		l, err := net.Listen("tcp", n.cfg.RPC.HTTP)
+		if err != nil {
+			n.stopLocked()
+			return fmt.Errorf("rpc listen: %w", err)
+		}
+		n.httpAddr = l.Addr()
+		n.httpServer = &http.Server{Handler: n.jsonrpc, ReadHeaderTimeout: 10 * time.Second}
+		go n.httpServer.Serve(l)
+		log.Printf("[Node] JSON-RPC listening on %s", l.Addr())
+	}
+
+	if n.cfg.RPC.GRPC != "" {
+		l, err := net.Listen("tcp", n.cfg.RPC.GRPC)
+		if err != nil {
+			n.stopLocked()
+			return fmt.Errorf("grpc listen: %w", err)
+		}
+		n.grpcAddr = l.Addr()
+		n.grpcServer = grpc.NewServer()
+		n.grpcapi.Register(n.grpcServer)
+		go n.grpcServer.Serve(l)
+		log.Printf("[Node] gRPC listening on %s", l.Addr())
+	}
+
+	store, err := openChainStore(n.chain, n.cfg.ChainPath())
+	if err != nil {
+		n.stopLocked()
+		return fmt.Errorf("failed to open chain file: %w", err)
+	}
+	n.store = store
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel = cancel
+
+	n.spawn(ctx, n.watchHead)
+	n.spawn(ctx, n.syncLoop)
+	n.spawn(ctx, n.dialLoop)
+	if n.producer != nil {
+		n.spawn(ctx, n.produceLoop)
+	}
+	if n.miner != nil {
+		n.spawn(ctx, n.mineLoop)
+	}
+	if n.engine != nil {
+		n.engine.Start()
+		n.spawn(ctx, n.rebroadcastLoop)
+	}
+	return nil
+}
+
+func (n *Node) spawn(ctx context.Context, fn func(ctx context.Context)) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		fn(ctx)
+	}()
+}
+
+// Close stops the node and saves the chain, including blocks imported
+// after the last head poll.
+func (n *Node) Close() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.stopLocked()
+	if n.store == nil {
+		return nil
+	}
+	if err := n.store.rewrite(n.chain); err != nil {
+		return fmt.Errorf("failed to save chain: %w", err)
+	}
+	return n.store.close()
+}
+
+func (n *Node) stopLocked() {
+	if n.cancel != nil {
+		n.cancel()
+	}
+	if n.engine != nil {
+		n.engine.Stop()
+	}
+	if n.httpServer != nil {
+		_ = n.httpServer.Close()
+	}
+	if n.grpcServer != nil {
+		n.grpcServer.Stop()
+	}
+	_ = n.transport.Close()
+	n.wg.Wait()
+}
+
+func (n *Node) P2PAddr() net.Addr {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.p2pAddr
+}
+
+func (n *Node) HTTPAddr() net.Addr {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.httpAddr
+}
+
+func (n *Node) GRPCAddr() net.Addr {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.grpcAddr
+}
@@ -0,0 +1,176 @@
+package node
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/types/atomicfile"
+	"io"
+	"os"
+)
+
+// An archive starts with archiveMagic and the format version, followed by
+// the blocks. Version 1 archives hold blocks wrapped before the envelope.
+const (
+	archiveMagic         = "KGRC"
+	archiveVersion       = 2
+	legacyArchiveVersion = 1
+
+	maxArchivedBlockSize = 64 << 20
+)
+
+var (
+	ErrBadArchive      = errors.New("bad chain archive")
+	ErrArchiveMismatch = errors.New("archive does not match the local chain")
+	// ErrTruncatedArchive is a bad archive that ends inside a block, e.g. one
+	// whose writer crashed while appending.
+	ErrTruncatedArchive = fmt.Errorf("%w: truncated", ErrBadArchive)
+)
+
+// ExportChain writes the canonical blocks of c, from its base to its head,
+// as length prefixed wrapped blocks. It returns the number of blocks
+// written.
+func ExportChain(c *chain.Chain, w io.Writer) (int, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(archiveMagic); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteByte(archiveVersion); err != nil {
+		return 0, err
+	}
+
+	head := c.Height()
+	n := 0
+	for height := c.Base(); height <= head; height++ {
+		blk, err := c.GetBlockByHeight(height)
+		if err != nil {
+			return n, err
+		}
+		if err := writeArchivedBlock(bw, blk); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, bw.Flush()
+}
+
+func writeArchivedBlock(w io.Writer, blk block.Block) error {
+	data, err := wrapper.WrapBlock(blk)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(binary.AppendUvarint(nil, uint64(len(data))), data...))
+	return err
+}
+
+// ImportChain reads an archive written by ExportChain and adds the blocks
+// extending c. Blocks c already has at their height must be the same ones,
+// so an archive of another chain, or of a fork below the local head, is
+// rejected. It returns the number of blocks added.
+func ImportChain(c *chain.Chain, r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(archiveMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrBadArchive, err)
+	}
+	if string(header[:len(archiveMagic)]) != archiveMagic {
+		return 0, fmt.Errorf("%w: missing magic", ErrBadArchive)
+	}
+	unwrapBlock := wrapper.UnwrapBlock
+	switch version := header[len(archiveMagic)]; version {
+	case archiveVersion:
+	case legacyArchiveVersion:
+		unwrapBlock = wrapper.UnwrapBlockCanonical
+	default:
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrBadArchive, version)
+	}
+
+	deriver := c.Executor().HashDeriver()
+	added := 0
+	for {
+		size, err := binary.ReadUvarint(br)
+		if errors.Is(err, io.EOF) {
+			return added, nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return added, ErrTruncatedArchive
+		}
+		if err != nil {
+			return added, fmt.Errorf("%w: %v", ErrBadArchive, err)
+		}
+		if size > maxArchivedBlockSize {
+			return added, fmt.Errorf("%w: block of %d bytes", ErrBadArchive, size)
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return added, ErrTruncatedArchive
+		}
+		blk, err := unwrapBlock(data)
+		if err != nil {
+			return added, fmt.Errorf("%w: %v", ErrBadArchive, err)
+		}
+
+		height := blk.GetHeader().GetHeight()
+		if height > c.Height() {
+			if err := c.AddBlock(blk); err != nil {
+				return added, fmt.Errorf("block %d: %w", height, err)
+			}
+			added++
+			continue
+		}
+		if height < c.Base() {
+			continue
+		}
+
+		local, err := c.GetBlockByHeight(height)
+		if err != nil {
+			return added, err
+		}
+		localID, err := local.Hash(deriver)
+		if err != nil {
+			return added, err
+		}
+		id, err := blk.Hash(deriver)
+		if err != nil {
+			return added, err
+		}
+		if !id.Equal(localID) {
+			return added, fmt.Errorf("%w: block %d is %s, local %s", ErrArchiveMismatch, height, id.ShortString(8), localID.ShortString(8))
+		}
+	}
+}
+
+// ImportChainFile imports the archive at path; a missing file imports
+// nothing.
+func ImportChainFile(c *chain.Chain, path string) (int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return ImportChain(c, f)
+}
+
+// ExportChainFile replaces the archive at path atomically, so a crash never
+// leaves a truncated chain behind.
+func ExportChainFile(c *chain.Chain, path string) (int, error) {
+	var n int
+	err := atomicfile.Write(path, func(w io.Writer) error {
+		var err error
+		n, err = ExportChain(c, w)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,286 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/registry"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	EnginePoA = "poa"
+	EnginePoW = "pow"
+	EngineBFT = "bft"
+)
+
+const (
+	DefaultConfigFile = "config.yaml"
+	DefaultGenesis    = "genesis.yaml"
+	DefaultNodeKey    = "node.key"
+
+	chainFile           = "chain.dat"
+	slashProtectionFile = "slashprotection.json"
+	checkpointVotesFile = "checkpointvotes.json"
+	candidateFile       = "candidate.dat"
+	banListFile         = "banlist.json"
+	snapshotFile        = "snapshot.dat"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Config is the node configuration as read from YAML. Relative file paths
+// are resolved against DataDir. Every setting can be overridden from the
+// environment and the command line, see Set.
+type Config struct {
+	ChainID string `yaml:"chain_id" usage:"network identifier checked in the p2p handshake"`
+	DataDir string `yaml:"data_dir" usage:"directory holding the genesis, node key and chain data"`
+	Genesis string `yaml:"genesis" usage:"genesis file"`
+	NodeKey string `yaml:"node_key" usage:"file holding the wrapped node private key"`
+
+	Suites    SuitesConfig    `yaml:"suites"`
+	P2P       P2PConfig       `yaml:"p2p"`
+	RPC       RPCConfig       `yaml:"rpc"`
+	Mempool   MempoolConfig   `yaml:"mempool"`
+	Consensus ConsensusConfig `yaml:"consensus"`
+	StateSync StateSyncConfig `yaml:"state_sync"`
+}
+
+// SuitesConfig picks the crypto suites by their registry type names.
+type SuitesConfig struct {
+	Hash    string `yaml:"hash" usage:"hash suite for block and transaction ids"`
+	Address string `yaml:"address" usage:"address suite for account addresses"`
+	Key     string `yaml:"key" usage:"key suite of the node key"`
+}
+
+type P2PConfig struct {
+	// Listen is the TCP address peers connect to; empty disables inbound
+	// connections.
+	Listen string   `yaml:"listen" usage:"p2p listen address, empty to only dial out"`
+	Peers  []string `yaml:"peers,omitempty" usage:"comma separated addresses of peers to keep connected to"`
+	Noise  bool     `yaml:"noise" usage:"encrypt p2p connections with the noise handshake"`
+	// Compact also relays blocks as short transaction ids, which peers
+	// rebuild from their mempool, next to the full blocks gossiped.
+	Compact bool `yaml:"compact" usage:"also relay blocks in compact form"`
+}
+
+// RPCConfig holds the listen addresses of the APIs; an empty address turns
+// the API off.
+type RPCConfig struct {
+	HTTP string `yaml:"http" usage:"JSON-RPC and websocket listen address, empty to disable"`
+	GRPC string `yaml:"grpc" usage:"gRPC listen address, empty to disable"`
+}
+
+type MempoolConfig struct {
+	Capacity int `yaml:"capacity" usage:"maximum number of pending transactions, 0 for the default"`
+}
+
+type ConsensusConfig struct {
+	Engine string `yaml:"engine" usage:"consensus engine, poa, pow or bft"`
+
+	// Authority is the wrapped public key of the proof-of-authority block
+	// producer. The node produces blocks when it holds that key.
+	Authority string        `yaml:"authority" usage:"wrapped public key of the poa authority"`
+	Interval  time.Duration `yaml:"interval" usage:"poa block interval, bft pause after each block"`
+	SkipEmpty bool          `yaml:"skip_empty" usage:"poa: do not produce blocks without transactions"`
+
+	// Validators are the wrapped public keys of the BFT validators or of the
+	// checkpoint voters, one vote each. The node votes when it holds one of
+	// them.
+	Validators []string `yaml:"validators,omitempty" usage:"bft, checkpoints: comma separated wrapped public keys of the validators"`
+	// RoundTimeout is how long a BFT validator waits for the proposal of a
+	// round; the vote timeouts are a third of it.
+	RoundTimeout time.Duration `yaml:"round_timeout" usage:"bft: propose timeout of a round, 0 for the default"`
+
+	Mine       bool   `yaml:"mine" usage:"pow: mine blocks paying the node key"`
+	Threads    int    `yaml:"threads" usage:"pow: sealing threads, 0 for one per cpu"`
+	Difficulty uint64 `yaml:"difficulty" usage:"pow: (initial) difficulty"`
+	// BlockTime switches proof-of-work from a fixed difficulty to one that
+	// adjusts towards this block time.
+	BlockTime time.Duration `yaml:"block_time" usage:"pow: target block time, 0 for a fixed difficulty"`
+	// FinalityDepth is how many blocks deep a proof-of-work block must be
+	// before the node treats it as final; 0 never finalizes.
+	FinalityDepth uint64 `yaml:"finality_depth" usage:"pow: confirmations after which a block is final, 0 for never"`
+
+	// Checkpoints runs the Casper FFG finality gadget beside a poa or pow
+	// engine: the Validators vote on the first block of every epoch, and the
+	// checkpoints they finalize are the only final blocks.
+	Checkpoints bool   `yaml:"checkpoints" usage:"poa, pow: finalize epoch checkpoints voted on by the validators"`
+	EpochLength uint64 `yaml:"epoch_length" usage:"checkpoints: blocks per epoch, 0 for the default"`
+}
+
+// StateSyncConfig covers state snapshots: serving them to peers and starting
+// a new data directory from one instead of replaying the chain from genesis.
+type StateSyncConfig struct {
+	Serve    bool   `yaml:"serve" usage:"take state snapshots and serve them to syncing peers"`
+	Interval uint64 `yaml:"interval" usage:"blocks between snapshots, 0 for the default"`
+	Sync     bool   `yaml:"sync" usage:"start a new data directory from a peer's snapshot"`
+	// CheckpointID, the wrapped id of the block at CheckpointHeight, is the
+	// only snapshot block a syncing node accepts. Without it any block the
+	// consensus rules accept on their own is trusted, which proof-of-work
+	// blocks cannot be.
+	CheckpointHeight uint64 `yaml:"checkpoint_height" usage:"height of the trusted snapshot block"`
+	CheckpointID     string `yaml:"checkpoint_id" usage:"wrapped id of the trusted snapshot block, empty to trust the consensus rules"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ChainID: "kangaroo-devnet",
+		DataDir: ".kangaroo",
+		Genesis: DefaultGenesis,
+		NodeKey: DefaultNodeKey,
+		Suites: SuitesConfig{
+			Hash:    "keccak256",
+			Address: "keccak256",
+			Key:     "ecdsa-secp256k1",
+		},
+		P2P: P2PConfig{
+			Listen: "0.0.0.0:30300",
+			Noise:  true,
+		},
+		RPC: RPCConfig{
+			HTTP: "127.0.0.1:8545",
+			GRPC: "127.0.0.1:9545",
+		},
+		Consensus: ConsensusConfig{
+			Engine:     EnginePoA,
+			Interval:   2 * time.Second,
+			Difficulty: 1 << 16,
+		},
+	}
+}
+
+// LoadConfig reads a YAML config on top of the defaults. Unknown keys are
+// rejected so typos do not silently fall back to defaults.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+	return cfg, nil
+}
+
+func WriteConfig(path string, cfg Config) error {
+	data, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Validate checks the settings that can be checked without touching the
+// data directory.
+func (c *Config) Validate() error {
+	if c.ChainID == "" {
+		return fmt.Errorf("%w: chain_id is empty", ErrInvalidConfig)
+	}
+	if c.DataDir == "" {
+		return fmt.Errorf("%w: data_dir is empty", ErrInvalidConfig)
+	}
+
+	if _, err := registry.GetHashSuite(c.Suites.Hash); err != nil {
+		return fmt.Errorf("%w: suites.hash: %v", ErrInvalidConfig, err)
+	}
+	if _, err := registry.GetAddressSuite(c.Suites.Address); err != nil {
+		return fmt.Errorf("%w: suites.address: %v", ErrInvalidConfig, err)
+	}
+	if _, err := registry.GetKeySuite(c.Suites.Key); err != nil {
+		return fmt.Errorf("%w: suites.key: %v", ErrInvalidConfig, err)
+	}
+
+	switch c.Consensus.Engine {
+	case EnginePoA:
+		if _, err := wrapper.UnwrapPublicKeyFromString(c.Consensus.Authority); err != nil {
+			return fmt.Errorf("%w: consensus.authority: %v", ErrInvalidConfig, err)
+		}
+	case EnginePoW:
+		if c.Consensus.Difficulty == 0 {
+			return fmt.Errorf("%w: consensus.difficulty is zero", ErrInvalidConfig)
+		}
+	case EngineBFT:
+		if _, err := validatorSet(c.Consensus); err != nil {
+			return fmt.Errorf("%w: consensus.validators: %v", ErrInvalidConfig, err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown consensus engine %q", ErrInvalidConfig, c.Consensus.Engine)
+	}
+
+	if c.Consensus.Checkpoints {
+		if c.Consensus.Engine == EngineBFT {
+			return fmt.Errorf("%w: consensus.checkpoints with bft, whose blocks are final already", ErrInvalidConfig)
+		}
+		if _, err := validatorSet(c.Consensus); err != nil {
+			return fmt.Errorf("%w: consensus.validators: %v", ErrInvalidConfig, err)
+		}
+	}
+
+	if c.StateSync.CheckpointID != "" {
+		if _, err := wrapper.UnwrapHashFromString(c.StateSync.CheckpointID); err != nil {
+			return fmt.Errorf("%w: state_sync.checkpoint_id: %v", ErrInvalidConfig, err)
+		}
+	} else if c.StateSync.Sync && c.Consensus.Engine == EnginePoW {
+		return fmt.Errorf("%w: state_sync.sync needs a checkpoint with pow", ErrInvalidConfig)
+	}
+	return nil
+}
+
+func (c *Config) GenesisPath() string {
+	return c.resolve(c.Genesis)
+}
+
+func (c *Config) NodeKeyPath() string {
+	return c.resolve(c.NodeKey)
+}
+
+// ChainPath is where the node keeps its blocks between runs, in the export
+// format.
+func (c *Config) ChainPath() string {
+	return c.resolve(chainFile)
+}
+
+func (c *Config) SlashProtectionPath() string {
+	return c.resolve(slashProtectionFile)
+}
+
+// CheckpointVotesPath is the slashing protection database of the checkpoint
+// votes, which are recorded by epoch and so kept apart from the block
+// signatures.
+func (c *Config) CheckpointVotesPath() string {
+	return c.resolve(checkpointVotesFile)
+}
+
+// CandidatePath is where the producer keeps the block it is signing until the
+// block reaches the chain file.
+func (c *Config) CandidatePath() string {
+	return c.resolve(candidateFile)
+}
+
+// SnapshotPath is where a node started by state sync keeps the state its
+// chain starts from.
+func (c *Config) SnapshotPath() string {
+	return c.resolve(snapshotFile)
+}
+
+// BanListPath is where the node keeps the peers it banned for misbehaving.
+func (c *Config) BanListPath() string {
+	return c.resolve(banListFile)
+}
+
+func (c *Config) resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.DataDir, path)
+}
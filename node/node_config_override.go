@@ -0,0 +1,160 @@
+package node
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the environment variable of every setting, e.g.
+// KANGAROO_RPC_HTTP for rpc.http.
+const EnvPrefix = "KANGAROO_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setting is one leaf of the config, named by the dotted path of its YAML
+// keys.
+type setting struct {
+	key   string
+	usage string
+	value reflect.Value
+}
+
+func settings(cfg *Config) []setting {
+	var out []setting
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if field.Type.Kind() == reflect.Struct {
+				walk(prefix+name+".", v.Field(i))
+				continue
+			}
+			out = append(out, setting{key: prefix + name, usage: field.Tag.Get("usage"), value: v.Field(i)})
+		}
+	}
+	walk("", reflect.ValueOf(cfg).Elem())
+	return out
+}
+
+func (s setting) set(raw string) error {
+	v := s.value
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.ParseInt(raw, 10, 0)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// Set overrides the setting named by its dotted YAML path, e.g.
+// "consensus.interval", parsing raw for the setting's type. Lists are comma
+// separated.
+func (c *Config) Set(key, raw string) error {
+	for _, s := range settings(c) {
+		if s.key == key {
+			if err := s.set(raw); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown setting %q", ErrInvalidConfig, key)
+}
+
+// ApplyEnv overrides every setting whose environment variable lookup
+// finds. Pass os.LookupEnv outside of tests.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	for _, s := range settings(c) {
+		raw, ok := lookup(EnvName(s.key))
+		if !ok {
+			continue
+		}
+		if err := s.set(raw); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, EnvName(s.key), err)
+		}
+	}
+	return nil
+}
+
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func FlagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// Overrides collects settings given as command line flags. They are applied
+// after the config file and the environment, so they win over both.
+type Overrides map[string]string
+
+// Register adds a flag for every setting to fs, e.g. --rpc.http and
+// --consensus.block-time.
+func (o Overrides) Register(fs *flag.FlagSet) {
+	defaults := DefaultConfig()
+	for _, s := range settings(&defaults) {
+		key := s.key
+		fs.Func(FlagName(key), s.usage, func(raw string) error {
+			o[key] = raw
+			return nil
+		})
+	}
+}
+
+func (o Overrides) Apply(cfg *Config) error {
+	keys := make([]string, 0, len(o))
+	for key := range o {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := cfg.Set(key, o[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
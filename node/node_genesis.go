@@ -0,0 +1,88 @@
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/key"
+	"gopkg.in/yaml.v3"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// GenesisFile is the YAML form of chain.Genesis. Addresses are wrapped, so
+// they carry their suite, and balances are decimal strings.
+type GenesisFile struct {
+	Timestamp int64          `yaml:"timestamp"`
+	Alloc     []GenesisAlloc `yaml:"alloc"`
+}
+
+type GenesisAlloc struct {
+	Address string `yaml:"address"`
+	Balance string `yaml:"balance"`
+}
+
+func LoadGenesis(path string) (*chain.Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file GenesisFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+
+	genesis := &chain.Genesis{Timestamp: file.Timestamp}
+	for i, alloc := range file.Alloc {
+		addr, err := wrapper.UnwrapAddressFromString(alloc.Address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: genesis alloc %d: %v", ErrInvalidConfig, i, err)
+		}
+		balance, ok := new(big.Int).SetString(alloc.Balance, 10)
+		if !ok || balance.Sign() < 0 {
+			return nil, fmt.Errorf("%w: genesis alloc %d: invalid balance %q", ErrInvalidConfig, i, alloc.Balance)
+		}
+		genesis.Alloc = append(genesis.Alloc, chain.GenesisAccount{Address: addr, Balance: balance})
+	}
+	return genesis, nil
+}
+
+func WriteGenesis(path string, genesis *chain.Genesis) error {
+	file := GenesisFile{Timestamp: genesis.Timestamp}
+	for _, acc := range genesis.Alloc {
+		addr, err := wrapper.WrapAddressToString(acc.Address)
+		if err != nil {
+			return err
+		}
+		file.Alloc = append(file.Alloc, GenesisAlloc{Address: addr, Balance: acc.Balance.String()})
+	}
+
+	data, err := yaml.Marshal(&file)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadNodeKey reads a private key written by WriteNodeKey.
+func LoadNodeKey(path string) (key.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return wrapper.UnwrapPrivateKeyFromString(strings.TrimSpace(string(data)))
+}
+
+// WriteNodeKey stores the wrapped private key, readable by the owner only.
+func WriteNodeKey(path string, priv key.PrivateKey) error {
+	wrapped, err := wrapper.WrapPrivateKeyToString(priv)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(wrapped+"\n"), 0600)
+}
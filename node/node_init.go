@@ -0,0 +1,120 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/registry"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var ErrAlreadyInitialized = errors.New("data directory is already initialized")
+
+// InitOptions tune the files Init writes.
+type InitOptions struct {
+	// Alloc is credited to the node key's address in the genesis; nothing is
+	// allocated when nil or zero.
+	Alloc *big.Int
+	// Timestamp of the genesis block, the current time if zero.
+	Timestamp int64
+	// Force overwrites an existing config and genesis. An existing node key
+	// is always kept.
+	Force bool
+}
+
+// Init prepares cfg.DataDir for a new single node network: it creates the
+// node key, a genesis funding it and the config itself, with the node as
+// proof-of-authority authority or only validator unless those are
+// configured. It returns the
+// written config, whose path is ConfigPath(cfg).
+func Init(cfg Config, opts InitOptions) (Config, error) {
+	keySuite, err := registry.GetKeySuite(cfg.Suites.Key)
+	if err != nil {
+		return Config{}, fmt.Errorf("%w: suites.key: %v", ErrInvalidConfig, err)
+	}
+	addressSuite, err := registry.GetAddressSuite(cfg.Suites.Address)
+	if err != nil {
+		return Config{}, fmt.Errorf("%w: suites.address: %v", ErrInvalidConfig, err)
+	}
+
+	if !opts.Force {
+		for _, path := range []string{ConfigPath(cfg), cfg.GenesisPath()} {
+			if _, err := os.Stat(path); err == nil {
+				return Config{}, fmt.Errorf("%w: %s exists", ErrAlreadyInitialized, path)
+			}
+		}
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0700); err != nil {
+		return Config{}, err
+	}
+
+	nodeKey, err := loadOrCreateNodeKey(cfg.NodeKeyPath(), keySuite)
+	if err != nil {
+		return Config{}, err
+	}
+	if nodeKey.Type() != cfg.Suites.Key {
+		return Config{}, fmt.Errorf("%w: existing node key is %s, suites.key is %s", ErrInvalidConfig, nodeKey.Type(), cfg.Suites.Key)
+	}
+
+	genesis := &chain.Genesis{Timestamp: opts.Timestamp}
+	if genesis.Timestamp == 0 {
+		genesis.Timestamp = time.Now().UnixNano()
+	}
+	if opts.Alloc != nil && opts.Alloc.Sign() > 0 {
+		genesis.Alloc = []chain.GenesisAccount{{
+			Address: nodeKey.PublicKey().Address(addressSuite.Deriver()),
+			Balance: new(big.Int).Set(opts.Alloc),
+		}}
+	}
+	if err := WriteGenesis(cfg.GenesisPath(), genesis); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.Consensus.Engine == EnginePoA && cfg.Consensus.Authority == "" {
+		if cfg.Consensus.Authority, err = wrapper.WrapPublicKeyToString(nodeKey.PublicKey()); err != nil {
+			return Config{}, err
+		}
+	}
+	if (cfg.Consensus.Engine == EngineBFT || cfg.Consensus.Checkpoints) && len(cfg.Consensus.Validators) == 0 {
+		self, err := wrapper.WrapPublicKeyToString(nodeKey.PublicKey())
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Consensus.Validators = []string{self}
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	if err := WriteConfig(ConfigPath(cfg), cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// ConfigPath is where Init writes the config of cfg.DataDir.
+func ConfigPath(cfg Config) string {
+	return filepath.Join(cfg.DataDir, DefaultConfigFile)
+}
+
+func loadOrCreateNodeKey(path string, suite key.KeySuite) (key.PrivateKey, error) {
+	priv, err := LoadNodeKey(path)
+	if err == nil {
+		return priv, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if priv, err = suite.GeneratePrivateKey(); err != nil {
+		return nil, err
+	}
+	if err := WriteNodeKey(path, priv); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
@@ -0,0 +1,372 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/consensus/pow"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/blocksync"
+	"github.com/andantan/kangaroo/p2p/gossip"
+	"log"
+	"time"
+)
+
+const (
+	headPollInterval    = 100 * time.Millisecond
+	syncInterval        = time.Second
+	redialInterval      = 5 * time.Second
+	dialTimeout         = 5 * time.Second
+	rebroadcastInterval = time.Second
+
+	// recentHeads bounds how many published head ids are remembered to find
+	// where a reorg branched off.
+	recentHeads = 256
+)
+
+// pendingPublisher is the part of an RPC server that notifies its pending
+// transaction subscribers.
+type pendingPublisher interface {
+	PublishPendingTransaction(tx transaction.Transaction)
+}
+
+// onLocalTransaction gossips a transaction submitted over one RPC API and
+// tells the subscribers of the other one about it. The receiving server has
+// already notified its own subscribers.
+func (n *Node) onLocalTransaction(tx transaction.Transaction, other pendingPublisher) {
+	other.PublishPendingTransaction(tx)
+
+	payload, err := wrapper.WrapTransaction(tx)
+	if err != nil {
+		log.Printf("[Node] wrap transaction: %v", err)
+		return
+	}
+	if err := n.gossip.Publish(gossip.TopicTransactions, payload); err != nil {
+		log.Printf("[Node] gossip transaction: %v", err)
+	}
+}
+
+// onReorg returns the transactions of blocks a fork switch replaced to the
+// pool. Those the new branch already includes are dropped again once its
+// blocks are published.
+func (n *Node) onReorg(removed []block.Block) {
+	for _, blk := range removed {
+		for _, tx := range blk.GetBody().GetTransactions() {
+			if _, err := n.pool.Add(tx); err != nil && !errors.Is(err, mempool.ErrAlreadyKnown) {
+				log.Printf("[Node] requeue transaction: %v", err)
+			}
+		}
+	}
+}
+
+// importBlock adds a block relayed in compact form, switching to its branch
+// when that carries more work like gossiped blocks do.
+func (n *Node) importBlock(blk block.Block) error {
+	removed, err := n.chain.AddBranch([]block.Block{blk})
+	if err != nil {
+		return err
+	}
+	if len(removed) > 0 {
+		n.onReorg(removed)
+	}
+	return nil
+}
+
+func (n *Node) publishBlock(blk block.Block) {
+	payload, err := wrapper.WrapBlock(blk)
+	if err != nil {
+		log.Printf("[Node] wrap block: %v", err)
+		return
+	}
+	// the compact block goes first, so peers rebuilding it from their pool
+	// have the block before the full one arrives
+	if n.compact != nil {
+		if err := n.compact.Announce(blk); err != nil {
+			log.Printf("[Node] relay compact block: %v", err)
+		}
+	}
+	if err := n.gossip.Publish(gossip.TopicBlocks, payload); err != nil {
+		log.Printf("[Node] gossip block: %v", err)
+	}
+}
+
+func (n *Node) produceLoop(ctx context.Context) {
+	ticker := time.NewTicker(n.cfg.Consensus.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			blk, err := n.producer.ProduceBlock()
+			if err != nil {
+				log.Printf("[PoA] failed to produce block: %v", err)
+				continue
+			}
+			if blk != nil {
+				log.Printf("[PoA] sealed %s", blk.GetHeader())
+				n.publishBlock(blk)
+			}
+		}
+	}
+}
+
+func (n *Node) mineLoop(ctx context.Context) {
+	for {
+		blk, err := n.miner.MineBlock(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if !errors.Is(err, pow.ErrStaleWork) {
+				log.Printf("[PoW] failed to mine block: %v", err)
+			}
+			continue
+		}
+		log.Printf("[PoW] sealed %s", blk.GetHeader())
+		n.publishBlock(blk)
+	}
+}
+
+// rebroadcastLoop repeats the BFT votes of the current height for
+// validators that missed them, e.g. because they connected later.
+func (n *Node) rebroadcastLoop(ctx context.Context) {
+	ticker := time.NewTicker(rebroadcastInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.engine.Rebroadcast()
+		}
+	}
+}
+
+// syncLoop catches up with peers reporting a higher head than ours, e.g.
+// after a restart or when gossip missed blocks.
+func (n *Node) syncLoop(ctx context.Context) {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n.syncer.BestHeight() <= n.chain.Height() {
+				continue
+			}
+			if err := n.syncer.Sync(ctx); err != nil && !errors.Is(err, blocksync.ErrNoPeers) && ctx.Err() == nil {
+				log.Printf("[Node] sync: %v", err)
+			}
+		}
+	}
+}
+
+// dialLoop keeps the configured peers connected, redialing the ones that
+// dropped.
+func (n *Node) dialLoop(ctx context.Context) {
+	if len(n.cfg.P2P.Peers) == 0 {
+		return
+	}
+
+	ids := make(map[string]p2p.PeerID)
+	ticker := time.NewTicker(redialInterval)
+	defer ticker.Stop()
+
+	for {
+		connected := make(map[p2p.PeerID]bool)
+		for _, id := range n.transport.Peers() {
+			connected[id] = true
+		}
+
+		for _, addr := range n.cfg.P2P.Peers {
+			if id, ok := ids[addr]; ok && connected[id] {
+				continue
+			}
+			dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+			id, err := n.transport.Dial(dialCtx, addr, n.security)
+			cancel()
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("[Node] dial %s: %v", addr, err)
+				}
+				continue
+			}
+			ids[addr] = id
+			log.Printf("[Node] connected to %s at %s", id.ShortString(10), addr)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchHead follows the canonical chain whatever changed it (local
+// production, gossip, sync or a reorg): it publishes new heads to the RPC
+// subscribers, drops their transactions from the pool, announces the head to
+// peers, moves the BFT engine past blocks it did not commit itself, votes
+// on checkpoints, takes state snapshots and moves finality forward.
+func (n *Node) watchHead(ctx context.Context) {
+	recent := make(map[uint64]string)
+	finalized, _, _ := n.chain.Finalized()
+	head := n.chain.Height()
+	if blk, err := n.chain.GetBlockByHeight(head); err == nil {
+		if id, err := blk.Hash(n.chain.Executor().HashDeriver()); err == nil {
+			recent[head] = string(id.Bytes())
+		}
+	}
+
+	ticker := time.NewTicker(headPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if n.publishHeads(recent, &head) {
+			n.syncer.BroadcastStatus()
+			if n.engine != nil {
+				n.engine.SyncHeight()
+			}
+			if n.gadget != nil {
+				if err := n.gadget.OnNewHead(); err != nil {
+					log.Printf("[Node] checkpoint vote: %v", err)
+				}
+			}
+			if n.snapshots != nil {
+				if err := n.snapshots.OnNewHead(); err != nil {
+					log.Printf("[Node] snapshot: %v", err)
+				}
+			}
+		}
+		n.advanceFinality(&finalized)
+	}
+}
+
+// publishHeads publishes and saves the canonical blocks after the point
+// where the chain last published diverges from the current one. It reports
+// whether anything was published.
+func (n *Node) publishHeads(recent map[uint64]string, last *uint64) bool {
+	deriver := n.chain.Executor().HashDeriver()
+	height := n.chain.Height()
+
+	fork := min(*last, height)
+	for fork > n.chain.Base() {
+		blk, err := n.chain.GetBlockByHeight(fork)
+		if err != nil {
+			return false
+		}
+		id, err := blk.Hash(deriver)
+		if err != nil {
+			return false
+		}
+		if known, ok := recent[fork]; !ok || known == string(id.Bytes()) {
+			break
+		}
+		fork--
+	}
+	if fork == height {
+		return false
+	}
+
+	var blocks []block.Block
+	for h := fork + 1; h <= height; h++ {
+		blk, err := n.chain.GetBlockByHeight(h)
+		if err != nil {
+			break
+		}
+		id, err := blk.Hash(deriver)
+		if err != nil {
+			break
+		}
+		recent[h] = string(id.Bytes())
+		n.pool.RemoveTransactions(blk.GetBody().GetTransactions())
+		n.jsonrpc.PublishHead(blk)
+		n.grpcapi.PublishHead(blk)
+		blocks = append(blocks, blk)
+	}
+	for h := range recent {
+		if h > height || h+recentHeads < height {
+			delete(recent, h)
+		}
+	}
+
+	var err error
+	if fork < *last {
+		err = n.store.rewrite(n.chain)
+	} else {
+		err = n.store.append(blocks)
+	}
+	if err != nil {
+		log.Printf("[Node] save chain: %v", err)
+	}
+
+	*last = height
+	return len(blocks) != 0
+}
+
+// advanceFinality finalizes what the engine considers final and publishes
+// newly finalized blocks. A single proof-of-authority producer never
+// reorganizes and a BFT block carries the commit of its validators, so their
+// blocks are final as soon as they are imported; a proof-of-work block is
+// final FinalityDepth blocks deep. With checkpoints on, the finality gadget
+// alone finalizes blocks.
+func (n *Node) advanceFinality(last *uint64) {
+	head := n.chain.Height()
+	target := uint64(0)
+	switch {
+	case n.gadget != nil:
+		// the gadget finalizes its checkpoints on the chain itself
+	case n.cfg.Consensus.Engine == EnginePoA, n.cfg.Consensus.Engine == EngineBFT:
+		target = head
+	case n.cfg.Consensus.Engine == EnginePoW:
+		if depth := n.cfg.Consensus.FinalityDepth; depth > 0 && head >= depth {
+			target = head - depth
+		}
+	}
+
+	if target > *last {
+		if err := n.finalize(target); err != nil {
+			log.Printf("[Node] finalize %d: %v", target, err)
+		}
+	}
+
+	finalized, _, err := n.chain.Finalized()
+	if err != nil {
+		return
+	}
+	for h := *last + 1; h <= finalized; h++ {
+		blk, err := n.chain.GetBlockByHeight(h)
+		if err != nil {
+			break
+		}
+		n.jsonrpc.PublishFinalized(blk)
+		n.grpcapi.PublishFinalized(blk)
+		*last = h
+	}
+}
+
+func (n *Node) finalize(height uint64) error {
+	blk, err := n.chain.GetBlockByHeight(height)
+	if err != nil {
+		return err
+	}
+	id, err := blk.Hash(n.chain.Executor().HashDeriver())
+	if err != nil {
+		return err
+	}
+	return n.chain.Finalize(height, id)
+}
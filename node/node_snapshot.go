@@ -0,0 +1,212 @@
+package node
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/p2p/statesync"
+	"github.com/andantan/kangaroo/state"
+	"github.com/andantan/kangaroo/state/snapshot"
+	"github.com/andantan/kangaroo/types/atomicfile"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// stateSyncTimeout bounds how long a fresh node looks for a snapshot before
+// it syncs from genesis instead.
+const stateSyncTimeout = 30 * time.Second
+
+// The snapshot file of a node bootstrapped by state sync holds the block its
+// chain starts at and the state after that block: the snapshot manifest, the
+// wrapped block and the chunks, each length prefixed. The chain file of such
+// a node starts at the same block.
+
+var ErrBadSnapshot = errors.New("bad snapshot file")
+
+// writeSnapshotFile saves the base of a chain that has not moved past it.
+func writeSnapshotFile(path string, c *chain.Chain) error {
+	deriver := c.Executor().HashDeriver()
+
+	base, err := c.GetBlockByHeight(c.Base())
+	if err != nil {
+		return err
+	}
+	baseID, err := base.Hash(deriver)
+	if err != nil {
+		return err
+	}
+
+	snap, err := snapshot.New(deriver, c.State(), c.Base(), baseID, snapshot.DefaultChunkLevels)
+	if err != nil {
+		return err
+	}
+	manifest, err := codec.EncodeProto(snap.Manifest)
+	if err != nil {
+		return err
+	}
+	wrapped, err := wrapper.WrapBlock(base)
+	if err != nil {
+		return err
+	}
+
+	records := [][]byte{manifest, wrapped}
+	for _, chunk := range snap.Chunks {
+		data, err := snapshot.EncodeChunk(chunk)
+		if err != nil {
+			return err
+		}
+		records = append(records, data)
+	}
+
+	return atomicfile.Write(path, func(w io.Writer) error {
+		bw := bufio.NewWriter(w)
+		for _, data := range records {
+			if _, err := bw.Write(append(binary.AppendUvarint(nil, uint64(len(data))), data...)); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	})
+}
+
+// openSnapshotChain restores the chain saved by writeSnapshotFile. It
+// returns nil if there is no snapshot file, i.e. the chain starts at genesis.
+func openSnapshotChain(path string, executor *state.Executor, genesis *chain.Genesis, validator chain.BlockValidator) (*chain.Chain, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records [][]byte
+	br := bufio.NewReader(f)
+	for {
+		size, err := binary.ReadUvarint(br)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadSnapshot, err)
+		}
+		if size > maxArchivedBlockSize {
+			return nil, fmt.Errorf("%w: record of %d bytes", ErrBadSnapshot, size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadSnapshot, err)
+		}
+		records = append(records, data)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%w: %d records", ErrBadSnapshot, len(records))
+	}
+
+	deriver := executor.HashDeriver()
+	manifest := &snapshot.Manifest{}
+	if err := codec.DecodeProto(records[0], manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadSnapshot, err)
+	}
+	base, err := wrapper.UnwrapBlock(records[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadSnapshot, err)
+	}
+	if err := manifest.Verify(deriver, base.GetHeader()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadSnapshot, err)
+	}
+
+	chunks := make([][]*state.Account, len(records)-2)
+	for i, data := range records[2:] {
+		if chunks[i], err = snapshot.DecodeChunk(data); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadSnapshot, err)
+		}
+	}
+	st, err := snapshot.Restore(deriver, manifest, chunks)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadSnapshot, err)
+	}
+	return chain.NewChainFromSnapshot(executor, genesis, validator, base, st)
+}
+
+// stateSyncTrust is the statesync trust function of the config: the
+// configured checkpoint, or else any block the consensus rules accept on
+// their own.
+func stateSyncTrust(cfg Config, rules *chainRules) (func(blk block.Block) error, error) {
+	if cfg.StateSync.CheckpointID == "" {
+		return statesync.TrustValidator(rules.validator), nil
+	}
+	id, err := wrapper.UnwrapHashFromString(cfg.StateSync.CheckpointID)
+	if err != nil {
+		return nil, err
+	}
+	return statesync.TrustCheckpoint(rules.executor.HashDeriver(), cfg.StateSync.CheckpointHeight, id), nil
+}
+
+// syncState replaces the fresh chain of a new data directory with one
+// restored from a peer's snapshot. It dials the configured peers until one
+// serves a trusted snapshot or stateSyncTimeout passes, in which case the
+// node keeps its chain and syncs it from genesis.
+func (n *Node) syncState() error {
+	rules, err := loadChainRules(n.cfg)
+	if err != nil {
+		return err
+	}
+	trust, err := stateSyncTrust(n.cfg, rules)
+	if err != nil {
+		return err
+	}
+	syncer, err := statesync.NewSyncer(statesync.Config{Trust: trust}, rules.executor, rules.genesis, rules.validator, n.scorer)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stateSyncTimeout)
+	defer cancel()
+
+	dialed := make(map[string]bool)
+	for {
+		for _, addr := range n.cfg.P2P.Peers {
+			if dialed[addr] {
+				continue
+			}
+			dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+			_, err := n.transport.Dial(dialCtx, addr, n.security)
+			cancel()
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("[Node] dial %s: %v", addr, err)
+				}
+				continue
+			}
+			dialed[addr] = true
+		}
+
+		c, err := syncer.Sync(ctx)
+		if err == nil {
+			if err := writeSnapshotFile(n.cfg.SnapshotPath(), c); err != nil {
+				return fmt.Errorf("failed to save snapshot: %w", err)
+			}
+			log.Printf("[Node] restored state at height %d", c.Base())
+			n.chain = c
+			return nil
+		}
+
+		log.Printf("[Node] state sync: %v", err)
+		select {
+		case <-ctx.Done():
+			log.Printf("[Node] no snapshot found, syncing from genesis")
+			return nil
+		case <-time.After(syncInterval):
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package node
+
+import (
+	"errors"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/types/atomicfile"
+	"os"
+)
+
+// chainStore keeps the chain file in step with the canonical chain while the
+// node runs. New heads are appended; a reorg rewrites the file. A crash
+// therefore loses at most the blocks of the last head poll; a block the
+// producer already signed is then rebuilt from the candidate file.
+type chainStore struct {
+	path string
+	file *os.File
+}
+
+func openChainStore(c *chain.Chain, path string) (*chainStore, error) {
+	s := &chainStore{path: path}
+	if err := s.rewrite(c); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *chainStore) append(blocks []block.Block) error {
+	for _, blk := range blocks {
+		if err := writeArchivedBlock(s.file, blk); err != nil {
+			return err
+		}
+	}
+	return s.file.Sync()
+}
+
+func (s *chainStore) rewrite(c *chain.Chain) error {
+	if err := s.close(); err != nil {
+		return err
+	}
+	if _, err := ExportChainFile(c, s.path); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	s.file = f
+	return nil
+}
+
+func (s *chainStore) close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// candidateStore is the producer's poa.CandidateStore: one file holding the
+// last block it was about to sign.
+type candidateStore struct {
+	path string
+}
+
+func (s *candidateStore) SaveCandidate(blk block.Block) error {
+	b, err := wrapper.WrapBlock(blk)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, b)
+}
+
+func (s *candidateStore) LoadCandidate() (block.Block, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wrapper.UnwrapBlock(b)
+}
@@ -0,0 +1,514 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"flag"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/client"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p/peerscore"
+	kangarootxpb "github.com/andantan/kangaroo/proto/core/transaction/pb"
+	kangaroorpcpb "github.com/andantan/kangaroo/proto/rpc/pb"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/rpc/jsonrpc"
+	"github.com/andantan/kangaroo/state"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfig_Overrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("chain_id: from-file\nrpc:\n  http: 127.0.0.1:1\nconsensus:\n  engine: pow\n"), 0644))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.ChainID)
+	assert.Equal(t, "127.0.0.1:1", cfg.RPC.HTTP)
+	assert.Equal(t, EnginePoW, cfg.Consensus.Engine)
+	// Settings missing from the file keep their defaults.
+	assert.Equal(t, DefaultConfig().Suites, cfg.Suites)
+
+	env := map[string]string{
+		"KANGAROO_RPC_HTTP":              "127.0.0.1:2",
+		"KANGAROO_P2P_PEERS":             "a:1, b:2,",
+		"KANGAROO_CONSENSUS_BLOCK_TIME":  "3s",
+		"KANGAROO_CONSENSUS_MINE":        "true",
+		"KANGAROO_CONSENSUS_DIFFICULTY":  "7",
+		"KANGAROO_MEMPOOL_CAPACITY":      "9",
+		"KANGAROO_SUITES_ADDRESS":        "sha256",
+		"KANGAROO_UNRELATED_SETTING_XYZ": "ignored",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	require.NoError(t, cfg.ApplyEnv(lookup))
+	assert.Equal(t, "127.0.0.1:2", cfg.RPC.HTTP)
+	assert.Equal(t, []string{"a:1", "b:2"}, cfg.P2P.Peers)
+	assert.Equal(t, 3*time.Second, cfg.Consensus.BlockTime)
+	assert.True(t, cfg.Consensus.Mine)
+	assert.Equal(t, uint64(7), cfg.Consensus.Difficulty)
+	assert.Equal(t, 9, cfg.Mempool.Capacity)
+	assert.Equal(t, "sha256", cfg.Suites.Address)
+
+	// Flags win over the environment.
+	overrides := Overrides{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	overrides.Register(fs)
+	require.NoError(t, fs.Parse([]string{"--rpc.http=127.0.0.1:3", "--consensus.block-time=1m", "--chain-id=from-flag"}))
+	require.NoError(t, overrides.Apply(&cfg))
+	assert.Equal(t, "127.0.0.1:3", cfg.RPC.HTTP)
+	assert.Equal(t, time.Minute, cfg.Consensus.BlockTime)
+	assert.Equal(t, "from-flag", cfg.ChainID)
+	require.NoError(t, cfg.Validate())
+
+	assert.ErrorIs(t, cfg.Set("consensus.nope", "1"), ErrInvalidConfig)
+	assert.ErrorIs(t, cfg.Set("consensus.interval", "soon"), ErrInvalidConfig)
+	assert.ErrorIs(t, cfg.ApplyEnv(func(name string) (string, bool) {
+		return "many", name == "KANGAROO_MEMPOOL_CAPACITY"
+	}), ErrInvalidConfig)
+
+	require.NoError(t, cfg.Set("suites.hash", "md5"))
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidConfig)
+
+	require.NoError(t, os.WriteFile(path, []byte("chain_id: x\nrcp:\n  http: y\n"), 0644))
+	_, err = LoadConfig(path)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestInit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.Suites.Key = "eddsa-ed25519"
+
+	written, err := Init(cfg, InitOptions{Alloc: big.NewInt(500), Timestamp: 42})
+	require.NoError(t, err)
+	assert.NotEmpty(t, written.Consensus.Authority)
+
+	loaded, err := LoadConfig(ConfigPath(cfg))
+	require.NoError(t, err)
+	assert.Equal(t, written, loaded)
+
+	nodeKey, err := LoadNodeKey(loaded.NodeKeyPath())
+	require.NoError(t, err)
+	assert.Equal(t, "eddsa-ed25519", nodeKey.Type())
+
+	genesis, err := LoadGenesis(loaded.GenesisPath())
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), genesis.Timestamp)
+	require.Len(t, genesis.Alloc, 1)
+	assert.Equal(t, big.NewInt(500), genesis.Alloc[0].Balance)
+
+	_, err = Init(cfg, InitOptions{})
+	assert.ErrorIs(t, err, ErrAlreadyInitialized)
+
+	// Forcing rewrites the genesis but keeps the node key.
+	_, err = Init(cfg, InitOptions{Force: true})
+	require.NoError(t, err)
+	again, err := LoadNodeKey(loaded.NodeKeyPath())
+	require.NoError(t, err)
+	assert.True(t, nodeKey.PublicKey().Equal(again.PublicKey()))
+	genesis, err = LoadGenesis(loaded.GenesisPath())
+	require.NoError(t, err)
+	assert.Empty(t, genesis.Alloc)
+}
+
+func newTestChain(t *testing.T, genesisTime int64) *chain.Chain {
+	hashSuite, err := registry.GetHashSuite("keccak256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	c, err := chain.NewChain(state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver()), &chain.Genesis{Timestamp: genesisTime}, nil)
+	require.NoError(t, err)
+	return c
+}
+
+func TestArchive(t *testing.T) {
+	src := newTestChain(t, 1)
+	pool := mempool.NewMempool(src.Executor().HashDeriver(), 0)
+	for i := 0; i < 5; i++ {
+		blk, err := assembler.NewAssembler(src, pool, 0).Assemble(nil, src.Head().GetHeader().GetTimestamp()+1, nil)
+		require.NoError(t, err)
+		require.NoError(t, src.AddBlock(blk))
+	}
+
+	var archive bytes.Buffer
+	n, err := ExportChain(src, &archive)
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+
+	dst := newTestChain(t, 1)
+	added, err := ImportChain(dst, bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 5, added)
+	assert.Equal(t, src.Head(), dst.Head())
+
+	// Importing again is a no-op.
+	added, err = ImportChain(dst, bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Zero(t, added)
+
+	_, err = ImportChain(newTestChain(t, 2), bytes.NewReader(archive.Bytes()))
+	assert.ErrorIs(t, err, ErrArchiveMismatch)
+
+	truncated := archive.Bytes()[:archive.Len()-3]
+	partial := newTestChain(t, 1)
+	added, err = ImportChain(partial, bytes.NewReader(truncated))
+	assert.ErrorIs(t, err, ErrTruncatedArchive)
+	assert.Equal(t, 4, added)
+
+	_, err = ImportChain(newTestChain(t, 1), bytes.NewReader([]byte("nope!")))
+	assert.ErrorIs(t, err, ErrBadArchive)
+
+	// Archives written before the envelope hold bare blocks.
+	legacy := bytes.NewBufferString(archiveMagic)
+	legacy.WriteByte(legacyArchiveVersion)
+	for height := src.Base(); height <= src.Height(); height++ {
+		blk, err := src.GetBlockByHeight(height)
+		require.NoError(t, err)
+		wrapped, err := wrapper.WrapBlock(blk)
+		require.NoError(t, err)
+		e, err := wrapper.ParseEnvelope(wrapped)
+		require.NoError(t, err)
+		bare := append([]byte{e.Type}, e.Payload...)
+		legacy.Write(append(binary.AppendUvarint(nil, uint64(len(bare))), bare...))
+	}
+	legacyDst := newTestChain(t, 1)
+	added, err = ImportChain(legacyDst, legacy)
+	require.NoError(t, err)
+	assert.Equal(t, 5, added)
+	assert.Equal(t, src.Head(), legacyDst.Head())
+
+	path := filepath.Join(t.TempDir(), "chain.dat")
+	n, err = ExportChainFile(src, path)
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+	added, err = ImportChainFile(newTestChain(t, 1), path)
+	require.NoError(t, err)
+	assert.Equal(t, 5, added)
+
+	added, err = ImportChainFile(newTestChain(t, 1), filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Zero(t, added)
+}
+
+func testConfig(dir string) Config {
+	cfg := DefaultConfig()
+	cfg.DataDir = dir
+	cfg.P2P.Listen = "127.0.0.1:0"
+	cfg.RPC.HTTP = "127.0.0.1:0"
+	cfg.RPC.GRPC = "127.0.0.1:0"
+	cfg.Consensus.Interval = 20 * time.Millisecond
+	return cfg
+}
+
+// followerConfig sets up the data directory of a node with its own key, the
+// producer's genesis and consensus settings and the producer as peer.
+func followerConfig(t *testing.T, producer *Node) (Config, key.PrivateKey) {
+	cfg := testConfig(t.TempDir())
+	cfg.Genesis = producer.cfg.GenesisPath()
+	cfg.Consensus = producer.cfg.Consensus
+	cfg.P2P.Listen = ""
+	cfg.P2P.Peers = []string{producer.P2PAddr().String()}
+
+	keySuite, err := registry.GetKeySuite(cfg.Suites.Key)
+	require.NoError(t, err)
+	followerKey, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	require.NoError(t, WriteNodeKey(cfg.NodeKeyPath(), followerKey))
+	return cfg, followerKey
+}
+
+func TestNode(t *testing.T) {
+	cfg, err := Init(testConfig(t.TempDir()), InitOptions{Alloc: big.NewInt(1000)})
+	require.NoError(t, err)
+
+	producer, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, producer.Start())
+	assert.NotNil(t, producer.GRPCAddr())
+
+	followerCfg, followerKey := followerConfig(t, producer)
+	followerCfg.RPC.GRPC = ""
+	follower, err := New(followerCfg)
+	require.NoError(t, err)
+	require.NoError(t, follower.Start())
+
+	// Transactions sent to the follower are gossiped to the producer, and
+	// its blocks back to the follower.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := client.NewClient(client.Config{URL: "http://" + follower.HTTPAddr().String(), PollInterval: 10 * time.Millisecond})
+	account, err := c.Account(ctx, producer.Key())
+	require.NoError(t, err)
+	to := followerKey.PublicKey().Address(follower.Chain().Executor().AddressDeriver())
+	pending, err := account.BuildTransfer(ctx, to, big.NewInt(25))
+	require.NoError(t, err)
+	_, err = pending.WaitFinalized(ctx)
+	require.NoError(t, err)
+
+	balance, err := c.Balance(ctx, to)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(25), balance)
+	assert.Zero(t, follower.Mempool().Len())
+
+	require.NoError(t, follower.Close())
+	require.NoError(t, producer.Close())
+
+	// Both pick up where they stopped.
+	for _, n := range []*Node{producer, follower} {
+		restarted, err := New(n.cfg)
+		require.NoError(t, err)
+		assert.Equal(t, n.Chain().Head(), restarted.Chain().Head())
+		require.NoError(t, restarted.Close())
+	}
+}
+
+func TestNode_PendingNotifications(t *testing.T) {
+	cfg, err := Init(testConfig(t.TempDir()), InitOptions{Alloc: big.NewInt(1000)})
+	require.NoError(t, err)
+	cfg.Consensus.SkipEmpty = true
+
+	n, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, n.Start())
+	t.Cleanup(func() { _ = n.Close() })
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+n.HTTPAddr().String(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ws.Close() })
+	require.NoError(t, ws.WriteJSON(map[string]any{"jsonrpc": jsonrpc.Version, "id": 1, "method": "subscribe", "params": []any{jsonrpc.TopicPendingTransactions}}))
+	var subscribed jsonrpc.Response
+	require.NoError(t, ws.ReadJSON(&subscribed))
+	require.Nil(t, subscribed.Error)
+
+	conn, err := grpc.NewClient(n.GRPCAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	deriver := n.Chain().Executor().HashDeriver()
+	transfer := func(nonce uint64) transaction.Transaction {
+		tx := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(1), nil, nonce)
+		require.NoError(t, tx.Sign(n.Key(), deriver))
+		return tx
+	}
+
+	// one transaction over each API, each must be announced exactly once
+	_, err = client.NewClient(client.Config{URL: "http://" + n.HTTPAddr().String()}).SendTransaction(ctx, transfer(0))
+	require.NoError(t, err)
+	pb, err := transfer(1).ToProto()
+	require.NoError(t, err)
+	_, err = kangaroorpcpb.NewMempoolClient(conn).SendTransaction(ctx, pb.(*kangarootxpb.KangarooTransaction))
+	require.NoError(t, err)
+
+	notifications := 0
+	for {
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(300*time.Millisecond)))
+		var notification jsonrpc.Notification
+		if err := ws.ReadJSON(&notification); err != nil {
+			break
+		}
+		notifications++
+	}
+	assert.Equal(t, 2, notifications)
+}
+
+func TestNode_RefusesBannedPeers(t *testing.T) {
+	cfg, err := Init(testConfig(t.TempDir()), InitOptions{})
+	require.NoError(t, err)
+	producer, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, producer.Start())
+	t.Cleanup(func() { _ = producer.Close() })
+
+	followerCfg, _ := followerConfig(t, producer)
+
+	// a ban from an earlier run is read from the data directory
+	bans, err := peerscore.OpenBanList(followerCfg.BanListPath())
+	require.NoError(t, err)
+	require.NoError(t, bans.Ban(producer.transport.ID(), time.Now().Add(time.Hour)))
+
+	follower, err := New(followerCfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = follower.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = follower.transport.Dial(ctx, producer.P2PAddr().String(), follower.security)
+	assert.ErrorIs(t, err, peerscore.ErrBanned)
+	assert.Empty(t, follower.transport.Peers())
+}
+
+func TestNode_CompactRelay(t *testing.T) {
+	cfg, err := Init(testConfig(t.TempDir()), InitOptions{})
+	require.NoError(t, err)
+	cfg.P2P.Compact = true
+	producer, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, producer.Start())
+	t.Cleanup(func() { _ = producer.Close() })
+
+	followerCfg, _ := followerConfig(t, producer)
+	followerCfg.P2P.Compact = true
+	follower, err := New(followerCfg)
+	require.NoError(t, err)
+	require.NoError(t, follower.Start())
+	t.Cleanup(func() { _ = follower.Close() })
+
+	// the empty blocks are rebuilt without asking for anything
+	require.Eventually(t, func() bool {
+		return follower.Chain().Height() >= 3 && follower.compact.Stats().Reconstructed > 0
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Zero(t, follower.compact.Stats().FullBlocks)
+}
+
+func TestNode_BFT(t *testing.T) {
+	keySuite, err := registry.GetKeySuite(DefaultConfig().Suites.Key)
+	require.NoError(t, err)
+	secondKey, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	second, err := wrapper.WrapPublicKeyToString(secondKey.PublicKey())
+	require.NoError(t, err)
+
+	cfg := testConfig(t.TempDir())
+	cfg.Consensus.Engine = EngineBFT
+	cfg.Consensus.RoundTimeout = 300 * time.Millisecond
+	cfg, err = Init(cfg, InitOptions{})
+	require.NoError(t, err)
+	require.Len(t, cfg.Consensus.Validators, 1)
+	cfg.Consensus.Validators = append(cfg.Consensus.Validators, second)
+
+	first, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, first.Start())
+	t.Cleanup(func() { _ = first.Close() })
+
+	// neither validator decides alone
+	secondCfg, _ := followerConfig(t, first)
+	require.NoError(t, WriteNodeKey(secondCfg.NodeKeyPath(), secondKey))
+	validator, err := New(secondCfg)
+	require.NoError(t, err)
+	require.NoError(t, validator.Start())
+	t.Cleanup(func() { _ = validator.Close() })
+
+	observerCfg, _ := followerConfig(t, first)
+	observer, err := New(observerCfg)
+	require.NoError(t, err)
+	assert.Nil(t, observer.engine)
+	require.NoError(t, observer.Start())
+	t.Cleanup(func() { _ = observer.Close() })
+
+	// the committed blocks reach the observer, final at once
+	require.Eventually(t, func() bool {
+		finalized, _, err := observer.Chain().Finalized()
+		return err == nil && finalized >= 3 && validator.Chain().Height() >= 3
+	}, 10*time.Second, 10*time.Millisecond)
+	want, err := first.Chain().GetBlockByHeight(3)
+	require.NoError(t, err)
+	for _, n := range []*Node{validator, observer} {
+		blk, err := n.Chain().GetBlockByHeight(3)
+		require.NoError(t, err)
+		assert.Equal(t, want.GetHeader(), blk.GetHeader())
+	}
+}
+
+func TestNode_Checkpoints(t *testing.T) {
+	cfg := testConfig(t.TempDir())
+	cfg.Consensus.Checkpoints = true
+	cfg.Consensus.EpochLength = 10
+	cfg, err := Init(cfg, InitOptions{})
+	require.NoError(t, err)
+	require.Len(t, cfg.Consensus.Validators, 1)
+
+	producer, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, producer.Start())
+	t.Cleanup(func() { _ = producer.Close() })
+
+	followerCfg, _ := followerConfig(t, producer)
+	follower, err := New(followerCfg)
+	require.NoError(t, err)
+	require.NoError(t, follower.Start())
+	t.Cleanup(func() { _ = follower.Close() })
+
+	// the producer's votes finalize the checkpoints on both chains, and
+	// nothing but them
+	for _, n := range []*Node{producer, follower} {
+		require.Eventually(t, func() bool {
+			finalized, _, err := n.Chain().Finalized()
+			return err == nil && finalized >= 20
+		}, 10*time.Second, 10*time.Millisecond)
+		finalized, _, err := n.Chain().Finalized()
+		require.NoError(t, err)
+		assert.Zero(t, finalized%10)
+	}
+
+	t.Run("not with bft", func(t *testing.T) {
+		bftCfg := cfg
+		bftCfg.Consensus.Engine = EngineBFT
+		assert.ErrorIs(t, bftCfg.Validate(), ErrInvalidConfig)
+	})
+}
+
+func TestNode_StateSync(t *testing.T) {
+	cfg, err := Init(testConfig(t.TempDir()), InitOptions{Alloc: big.NewInt(1000)})
+	require.NoError(t, err)
+	cfg.StateSync.Serve = true
+	cfg.StateSync.Interval = 5
+	producer, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, producer.Start())
+	t.Cleanup(func() { _ = producer.Close() })
+
+	require.Eventually(t, func() bool {
+		return len(producer.snapshots.Snapshots()) > 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	followerCfg, _ := followerConfig(t, producer)
+	followerCfg.StateSync.Sync = true
+	follower, err := New(followerCfg)
+	require.NoError(t, err)
+	base := follower.Chain().Base()
+	assert.NotZero(t, base)
+	assert.Zero(t, base%cfg.StateSync.Interval)
+
+	// block sync carries on from the snapshot
+	require.NoError(t, follower.Start())
+	require.Eventually(t, func() bool {
+		return follower.Chain().Height() > base+3
+	}, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, follower.Close())
+
+	restarted, err := New(followerCfg)
+	require.NoError(t, err)
+	assert.Equal(t, base, restarted.Chain().Base())
+	assert.Equal(t, follower.Chain().Head(), restarted.Chain().Head())
+	require.NoError(t, restarted.Close())
+
+	t.Run("pow needs a checkpoint", func(t *testing.T) {
+		powCfg := testConfig(t.TempDir())
+		powCfg.Consensus.Engine = EnginePoW
+		powCfg.StateSync.Sync = true
+		assert.ErrorIs(t, powCfg.Validate(), ErrInvalidConfig)
+	})
+}
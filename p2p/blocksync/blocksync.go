@@ -0,0 +1,161 @@
+package blocksync
+
+import (
+	"errors"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/p2p"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultHeaderBatch    = 128
+	DefaultBodyBatch      = 16
+	DefaultRequestTimeout = 5 * time.Second
+	DefaultMaxAttempts    = 3
+
+	// serving limits, whatever the requester asks for
+	maxServedHeaders = 512
+	maxServedBodies  = 64
+)
+
+var (
+	ErrNoPeers     = errors.New("no peers to sync from")
+	ErrStalled     = errors.New("peer stalled")
+	ErrBadResponse = errors.New("bad sync response")
+
+	// errForked means a peer's chain does not continue from the local head.
+	errForked = errors.New("peer is on another branch")
+)
+
+// Progress is reported after every imported window of blocks.
+type Progress struct {
+	Height uint64
+	Target uint64
+	Peers  int
+}
+
+type Config struct {
+	// HeaderBatch is the size of a header range request and of the window
+	// whose bodies are fetched in parallel before being imported.
+	HeaderBatch uint64
+	// BodyBatch is the size of a body range request.
+	BodyBatch      uint64
+	RequestTimeout time.Duration
+	// MaxAttempts bounds how many peers a single range is tried on.
+	MaxAttempts int
+	OnProgress  func(Progress)
+	// OnReorg receives the blocks replaced when Sync switches to a heavier
+	// branch, so their transactions can go back to the pool.
+	OnReorg func(removed []block.Block)
+	// OnInvalid, if set, is told about peers that served responses failing
+	// validation.
+	OnInvalid func(from p2p.PeerID, err error)
+}
+
+// Syncer downloads the chain from peers and serves it to them. Every node
+// runs one: it announces its head on connect and answers range requests,
+// and Sync catches the local chain up with the best known peer.
+type Syncer struct {
+	cfg       Config
+	chain     *chain.Chain
+	transport p2p.Transport
+	genesisID hash.Hash
+
+	lock    sync.Mutex
+	peers   map[p2p.PeerID]uint64
+	nextID  uint64
+	pending map[uint64]*request
+}
+
+type request struct {
+	peer  p2p.PeerID
+	reply chan proto.Message
+}
+
+func NewSyncer(cfg Config, c *chain.Chain, transport p2p.Transport) (*Syncer, error) {
+	if cfg.HeaderBatch == 0 {
+		cfg.HeaderBatch = DefaultHeaderBatch
+	}
+	if cfg.BodyBatch == 0 {
+		cfg.BodyBatch = DefaultBodyBatch
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = DefaultRequestTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+
+	genesisID, err := c.Genesis().Hash(c.Executor().HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Syncer{
+		cfg:       cfg,
+		chain:     c,
+		transport: transport,
+		genesisID: genesisID,
+		peers:     make(map[p2p.PeerID]uint64),
+		pending:   make(map[uint64]*request),
+	}
+
+	transport.Handle(p2p.MessageSyncStatus, s.handleStatus)
+	transport.Handle(p2p.MessageSyncHeadersRequest, s.handleHeadersRequest)
+	transport.Handle(p2p.MessageSyncBodiesRequest, s.handleBodiesRequest)
+	transport.Handle(p2p.MessageSyncHeadersResponse, s.handleHeadersResponse)
+	transport.Handle(p2p.MessageSyncBodiesResponse, s.handleBodiesResponse)
+	transport.OnPeer(s.handlePeer)
+	s.BroadcastStatus()
+
+	return s, nil
+}
+
+// PeerHeight returns the head height a peer last reported.
+func (s *Syncer) PeerHeight(id p2p.PeerID) (uint64, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	height, ok := s.peers[id]
+	return height, ok
+}
+
+// BestHeight is the highest head reported by any peer.
+func (s *Syncer) BestHeight() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var best uint64
+	for _, height := range s.peers {
+		if height > best {
+			best = height
+		}
+	}
+	return best
+}
+
+func (s *Syncer) send(to p2p.PeerID, t p2p.MessageType, m proto.Message) error {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.transport.Send(to, p2p.Message{Type: t, Payload: b})
+}
+
+func (s *Syncer) handlePeer(id p2p.PeerID, connected bool) {
+	if !connected {
+		s.lock.Lock()
+		delete(s.peers, id)
+		s.lock.Unlock()
+		return
+	}
+
+	if err := s.sendStatus(id); err != nil {
+		log.Printf("[Sync] failed to send status to %s: %v", id.ShortString(8), err)
+	}
+}
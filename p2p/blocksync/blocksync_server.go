@@ -0,0 +1,127 @@
+package blocksync
+
+import (
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/p2p"
+	kangaroosyncpb "github.com/andantan/kangaroo/proto/p2p/blocksync/pb"
+	"google.golang.org/protobuf/proto"
+	"log"
+)
+
+// BroadcastStatus announces the local head to every peer, typically after
+// importing blocks.
+func (s *Syncer) BroadcastStatus() {
+	for _, id := range s.transport.Peers() {
+		if err := s.sendStatus(id); err != nil {
+			log.Printf("[Sync] failed to send status to %s: %v", id.ShortString(8), err)
+		}
+	}
+}
+
+func (s *Syncer) sendStatus(to p2p.PeerID) error {
+	head := s.chain.Head()
+	headID, err := head.Hash(s.chain.Executor().HashDeriver())
+	if err != nil {
+		return err
+	}
+
+	return s.send(to, p2p.MessageSyncStatus, &kangaroosyncpb.KangarooStatus{
+		HeadHeight:  head.GetHeader().GetHeight(),
+		HeadHash:    headID.Bytes(),
+		GenesisHash: s.genesisID.Bytes(),
+	})
+}
+
+func (s *Syncer) handleStatus(from p2p.PeerID, msg p2p.Message) {
+	status := &kangaroosyncpb.KangarooStatus{}
+	if err := proto.Unmarshal(msg.Payload, status); err != nil {
+		return
+	}
+
+	if string(status.GenesisHash) != string(s.genesisID.Bytes()) {
+		log.Printf("[Sync] ignoring %s: different genesis", from.ShortString(8))
+		return
+	}
+
+	s.lock.Lock()
+	_, known := s.peers[from]
+	s.peers[from] = status.HeadHeight
+	s.lock.Unlock()
+
+	// a syncer started after the connection came up has not told this peer
+	// its head yet
+	if !known {
+		if err := s.sendStatus(from); err != nil {
+			log.Printf("[Sync] failed to send status to %s: %v", from.ShortString(8), err)
+		}
+	}
+}
+
+func (s *Syncer) handleHeadersRequest(from p2p.PeerID, msg p2p.Message) {
+	req := &kangaroosyncpb.KangarooRangeRequest{}
+	if err := proto.Unmarshal(msg.Payload, req); err != nil {
+		return
+	}
+
+	resp := &kangaroosyncpb.KangarooHeadersResponse{RequestId: req.RequestId}
+	for _, blk := range s.served(req, maxServedHeaders) {
+		b, err := wrapper.WrapHeader(blk.GetHeader())
+		if err != nil {
+			return
+		}
+		resp.Headers = append(resp.Headers, b)
+	}
+
+	if err := s.send(from, p2p.MessageSyncHeadersResponse, resp); err != nil {
+		log.Printf("[Sync] failed to serve headers to %s: %v", from.ShortString(8), err)
+	}
+}
+
+func (s *Syncer) handleBodiesRequest(from p2p.PeerID, msg p2p.Message) {
+	req := &kangaroosyncpb.KangarooRangeRequest{}
+	if err := proto.Unmarshal(msg.Payload, req); err != nil {
+		return
+	}
+
+	resp := &kangaroosyncpb.KangarooBodiesResponse{RequestId: req.RequestId}
+	for _, blk := range s.served(req, maxServedBodies) {
+		body, err := wrapper.WrapBody(blk.GetBody())
+		if err != nil {
+			return
+		}
+
+		// blocks without a tail are sent with an empty one
+		var tail []byte
+		if blk.GetTail() != nil {
+			if tail, err = wrapper.WrapTail(blk.GetTail()); err != nil {
+				return
+			}
+		}
+
+		resp.Bodies = append(resp.Bodies, body)
+		resp.Tails = append(resp.Tails, tail)
+	}
+
+	if err := s.send(from, p2p.MessageSyncBodiesResponse, resp); err != nil {
+		log.Printf("[Sync] failed to serve bodies to %s: %v", from.ShortString(8), err)
+	}
+}
+
+// served returns the local blocks a range request covers, capped at limit.
+func (s *Syncer) served(req *kangaroosyncpb.KangarooRangeRequest, limit uint64) []block.Block {
+	count := req.Count
+	if count > limit {
+		count = limit
+	}
+
+	blocks := make([]block.Block, 0, count)
+	for height := req.Start; height < req.Start+count; height++ {
+		blk, err := s.chain.GetBlockByHeight(height)
+		if err != nil {
+			break
+		}
+		blocks = append(blocks, blk)
+	}
+	return blocks
+}
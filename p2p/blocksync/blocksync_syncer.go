@@ -0,0 +1,500 @@
+package blocksync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/p2p"
+	kangaroosyncpb "github.com/andantan/kangaroo/proto/p2p/blocksync/pb"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"sync"
+	"time"
+)
+
+// exclusions are the peers that stalled or lied during one Sync call.
+type exclusions struct {
+	lock      sync.Mutex
+	peers     map[p2p.PeerID]bool
+	onInvalid func(from p2p.PeerID, err error)
+}
+
+// add excludes id and reports it when it served invalid data rather than
+// just stalling.
+func (e *exclusions) add(id p2p.PeerID, err error) {
+	e.lock.Lock()
+	if !e.peers[id] {
+		log.Printf("[Sync] excluding %s: %v", id.ShortString(8), err)
+	}
+	e.peers[id] = true
+	e.lock.Unlock()
+
+	if e.onInvalid != nil && (errors.Is(err, ErrBadResponse) || errors.Is(err, chain.ErrInvalidBlock)) {
+		e.onInvalid(id, err)
+	}
+}
+
+func (e *exclusions) has(id p2p.PeerID) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.peers[id]
+}
+
+type bodyRange struct {
+	start  uint64
+	bodies []block.Body
+	tails  []block.Tail
+	peer   p2p.PeerID
+}
+
+// Sync downloads blocks until the local chain reaches the best head reported
+// by a peer. Headers of a window come from one peer, bodies are fetched in
+// parallel from every peer that has them, and a peer that stalls or serves
+// invalid data is skipped for the rest of the call. A peer whose chain forked
+// from ours has its branch handed to the chain's fork choice instead, and is
+// skipped as well when the branch carries less work.
+func (s *Syncer) Sync(ctx context.Context) error {
+	excluded := &exclusions{peers: make(map[p2p.PeerID]bool), onInvalid: s.cfg.OnInvalid}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		height := s.chain.Height()
+		ahead, known := s.candidates(excluded, height+1)
+		if len(ahead) == 0 {
+			if known == 0 {
+				return ErrNoPeers
+			}
+			return nil
+		}
+
+		target := ahead[0].height
+		count := target - height
+		if count > s.cfg.HeaderBatch {
+			count = s.cfg.HeaderBatch
+		}
+
+		headers, err := s.fetchHeaders(ctx, ahead[0].id, s.chain.Head().GetHeader(), count)
+		if errors.Is(err, errForked) {
+			err = s.syncBranch(ctx, ahead[0].id, target)
+			if err == nil {
+				if s.cfg.OnProgress != nil {
+					s.cfg.OnProgress(Progress{Height: s.chain.Height(), Target: target, Peers: len(ahead)})
+				}
+				continue
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			excluded.add(ahead[0].id, err)
+			continue
+		}
+
+		ranges, err := s.fetchBodies(ctx, excluded, headers)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		if err := s.importWindow(headers, ranges); err != nil {
+			// the chain rejected data that matched its own headers, so the
+			// header source is as suspect as whoever served the body
+			excluded.add(ahead[0].id, err)
+			continue
+		}
+
+		if s.cfg.OnProgress != nil {
+			s.cfg.OnProgress(Progress{Height: s.chain.Height(), Target: target, Peers: len(ahead)})
+		}
+	}
+}
+
+type candidate struct {
+	id     p2p.PeerID
+	height uint64
+}
+
+// candidates returns the usable peers at or above height, highest first,
+// and how many usable peers are known at all.
+func (s *Syncer) candidates(excluded *exclusions, height uint64) ([]candidate, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var (
+		ahead []candidate
+		known int
+	)
+	for id, h := range s.peers {
+		if excluded.has(id) {
+			continue
+		}
+		known++
+		if h >= height {
+			ahead = append(ahead, candidate{id: id, height: h})
+		}
+	}
+
+	// highest first, ties broken by id so runs are reproducible
+	for i := 1; i < len(ahead); i++ {
+		for j := i; j > 0 && less(ahead[j], ahead[j-1]); j-- {
+			ahead[j], ahead[j-1] = ahead[j-1], ahead[j]
+		}
+	}
+	return ahead, known
+}
+
+func less(a, b candidate) bool {
+	if a.height != b.height {
+		return a.height > b.height
+	}
+	return a.id < b.id
+}
+
+// fetchHeaders downloads up to count headers following parent and checks that
+// they link up. It returns errForked when the first one has parent's height
+// but another parent.
+func (s *Syncer) fetchHeaders(ctx context.Context, peer p2p.PeerID, parent block.Header, count uint64) ([]block.Header, error) {
+	headers, err := s.requestHeaders(ctx, peer, parent.GetHeight()+1, count)
+	if err != nil {
+		return nil, err
+	}
+
+	deriver := s.chain.Executor().HashDeriver()
+	parentID, err := parent.Hash(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, h := range headers {
+		if i == 0 && h.GetHeight() == parent.GetHeight()+1 && h.GetPrevBlockID() != nil && !h.GetPrevBlockID().Equal(parentID) {
+			return nil, errForked
+		}
+		if err := checkLink(parent, parentID, h); err != nil {
+			return nil, err
+		}
+		if parentID, err = h.Hash(deriver); err != nil {
+			return nil, err
+		}
+		parent = h
+	}
+	return headers, nil
+}
+
+func (s *Syncer) requestHeaders(ctx context.Context, peer p2p.PeerID, start, count uint64) ([]block.Header, error) {
+	m, err := s.request(ctx, peer, p2p.MessageSyncHeadersRequest, start, count)
+	if err != nil {
+		return nil, err
+	}
+	resp := m.(*kangaroosyncpb.KangarooHeadersResponse)
+
+	if len(resp.Headers) == 0 || uint64(len(resp.Headers)) > count {
+		return nil, fmt.Errorf("%w: %d headers for a range of %d", ErrBadResponse, len(resp.Headers), count)
+	}
+
+	headers := make([]block.Header, len(resp.Headers))
+	for i, b := range resp.Headers {
+		if headers[i], err = wrapper.UnwrapHeader(b); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadResponse, err)
+		}
+	}
+	return headers, nil
+}
+
+// syncBranch downloads a forked peer's chain from the last block both chains
+// share up to target and lets the chain's fork choice decide whether to
+// switch to it. The whole branch comes from that one peer, since the others
+// may well be on our side of the fork.
+func (s *Syncer) syncBranch(ctx context.Context, peer p2p.PeerID, target uint64) error {
+	fork, err := s.findFork(ctx, peer)
+	if err != nil {
+		return err
+	}
+
+	parent, err := s.chain.GetBlockByHeight(fork)
+	if err != nil {
+		return err
+	}
+
+	var headers []block.Header
+	for last := parent.GetHeader(); last.GetHeight() < target; last = headers[len(headers)-1] {
+		count := min(target-last.GetHeight(), s.cfg.HeaderBatch)
+		window, err := s.fetchHeaders(ctx, peer, last, count)
+		if err != nil {
+			return err
+		}
+		headers = append(headers, window...)
+	}
+
+	branch := make([]block.Block, 0, len(headers))
+	for offset := uint64(0); offset < uint64(len(headers)); offset += s.cfg.BodyBatch {
+		end := min(offset+s.cfg.BodyBatch, uint64(len(headers)))
+		r := &bodyRange{start: headers[offset].GetHeight()}
+		if err := s.fetchRangeFrom(ctx, peer, r, headers[offset:end]); err != nil {
+			return err
+		}
+		for j := range r.bodies {
+			branch = append(branch, kangarooblock.NewKangarooBlock(headers[offset+uint64(j)], r.bodies[j], r.tails[j]))
+		}
+	}
+
+	removed, err := s.chain.AddBranch(branch)
+	if err != nil {
+		return fmt.Errorf("branch from %s at height %d: %w", peer.ShortString(8), fork, err)
+	}
+
+	log.Printf("[Sync] switched to the branch of %s at height %d, replacing %d blocks", peer.ShortString(8), fork, len(removed))
+	if s.cfg.OnReorg != nil && len(removed) > 0 {
+		s.cfg.OnReorg(removed)
+	}
+	return nil
+}
+
+// findFork walks back from the local head, a header window at a time, to the
+// highest block the peer's chain shares with ours. Blocks at or below the
+// finalized height are shared by definition; a peer disagreeing with them is
+// on a chain we will never switch to.
+func (s *Syncer) findFork(ctx context.Context, peer p2p.PeerID) (uint64, error) {
+	deriver := s.chain.Executor().HashDeriver()
+
+	finalized, finalizedID, err := s.chain.Finalized()
+	if err != nil {
+		return 0, err
+	}
+
+	for end := s.chain.Height(); end > finalized; {
+		start := finalized + 1
+		if end-finalized > s.cfg.HeaderBatch {
+			start = end - s.cfg.HeaderBatch + 1
+		}
+
+		headers, err := s.requestHeaders(ctx, peer, start, end-start+1)
+		if err != nil {
+			return 0, err
+		}
+		if uint64(len(headers)) != end-start+1 {
+			return 0, fmt.Errorf("%w: %d headers for a range of %d", ErrBadResponse, len(headers), end-start+1)
+		}
+
+		for i := len(headers) - 1; i >= 0; i-- {
+			if headers[i].GetHeight() != start+uint64(i) {
+				return 0, fmt.Errorf("%w: header height %d at %d", ErrBadResponse, headers[i].GetHeight(), start+uint64(i))
+			}
+			id, err := headers[i].Hash(deriver)
+			if err != nil {
+				return 0, err
+			}
+			if s.chain.HasBlock(id) {
+				return headers[i].GetHeight(), nil
+			}
+		}
+
+		if start == finalized+1 && !finalizedID.Equal(headers[0].GetPrevBlockID()) {
+			return 0, fmt.Errorf("%w: %s", chain.ErrFinalizedReorg, peer.ShortString(8))
+		}
+		end = start - 1
+	}
+
+	return finalized, nil
+}
+
+// checkLink is the part of header validation that needs no state: the chain
+// checks everything else when the block is imported.
+func checkLink(parent block.Header, parentID hash.Hash, h block.Header) error {
+	if h.GetHeight() != parent.GetHeight()+1 {
+		return fmt.Errorf("%w: header height %d after %d", ErrBadResponse, h.GetHeight(), parent.GetHeight())
+	}
+	if h.GetPrevBlockID() == nil || !h.GetPrevBlockID().Equal(parentID) {
+		return fmt.Errorf("%w: header %d does not link to its parent", ErrBadResponse, h.GetHeight())
+	}
+	if h.GetTimestamp() <= parent.GetTimestamp() {
+		return fmt.Errorf("%w: header %d is not after its parent", ErrBadResponse, h.GetHeight())
+	}
+	return nil
+}
+
+// fetchBodies splits the window into ranges and downloads them in parallel,
+// spreading the ranges over the peers that have them. A failed range moves on
+// to the next peer, at most MaxAttempts times.
+func (s *Syncer) fetchBodies(ctx context.Context, excluded *exclusions, headers []block.Header) ([]*bodyRange, error) {
+	start := headers[0].GetHeight()
+
+	var ranges []*bodyRange
+	for offset := uint64(0); offset < uint64(len(headers)); offset += s.cfg.BodyBatch {
+		ranges = append(ranges, &bodyRange{start: start + offset})
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(ranges))
+	)
+	for i, r := range ranges {
+		end := r.start + s.cfg.BodyBatch
+		if last := start + uint64(len(headers)); end > last {
+			end = last
+		}
+
+		wg.Add(1)
+		go func(i int, r *bodyRange, end uint64) {
+			defer wg.Done()
+			errs[i] = s.fetchRange(ctx, excluded, r, headers[r.start-start:end-start], i)
+		}(i, r, end)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ranges, nil
+}
+
+func (s *Syncer) fetchRange(ctx context.Context, excluded *exclusions, r *bodyRange, headers []block.Header, slot int) error {
+	last := headers[len(headers)-1].GetHeight()
+
+	var err error
+	for attempt := 0; attempt < s.cfg.MaxAttempts; attempt++ {
+		peers, _ := s.candidates(excluded, last)
+		if len(peers) == 0 {
+			return fmt.Errorf("%w: range %d-%d", ErrNoPeers, r.start, last)
+		}
+
+		peer := peers[(slot+attempt)%len(peers)].id
+		if err = s.fetchRangeFrom(ctx, peer, r, headers); err == nil {
+			r.peer = peer
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		excluded.add(peer, err)
+	}
+	return err
+}
+
+func (s *Syncer) fetchRangeFrom(ctx context.Context, peer p2p.PeerID, r *bodyRange, headers []block.Header) error {
+	m, err := s.request(ctx, peer, p2p.MessageSyncBodiesRequest, r.start, uint64(len(headers)))
+	if err != nil {
+		return err
+	}
+	resp := m.(*kangaroosyncpb.KangarooBodiesResponse)
+
+	if len(resp.Bodies) != len(headers) || len(resp.Tails) != len(headers) {
+		return fmt.Errorf("%w: %d bodies for a range of %d", ErrBadResponse, len(resp.Bodies), len(headers))
+	}
+
+	deriver := s.chain.Executor().HashDeriver()
+	bodies := make([]block.Body, len(headers))
+	tails := make([]block.Tail, len(headers))
+	for i, h := range headers {
+		body, err := wrapper.UnwrapBody(resp.Bodies[i])
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrBadResponse, err)
+		}
+		bodyHash, err := body.Hash(deriver)
+		if err != nil {
+			return err
+		}
+		if !bodyHash.Equal(h.GetBodyHash()) {
+			return fmt.Errorf("%w: body %d does not match its header", ErrBadResponse, h.GetHeight())
+		}
+		bodies[i] = body
+
+		if len(resp.Tails[i]) > 0 {
+			if tails[i], err = wrapper.UnwrapTail(resp.Tails[i]); err != nil {
+				return fmt.Errorf("%w: %v", ErrBadResponse, err)
+			}
+		}
+	}
+
+	r.bodies, r.tails = bodies, tails
+	return nil
+}
+
+func (s *Syncer) importWindow(headers []block.Header, ranges []*bodyRange) error {
+	i := 0
+	for _, r := range ranges {
+		for j := range r.bodies {
+			blk := kangarooblock.NewKangarooBlock(headers[i], r.bodies[j], r.tails[j])
+			if err := s.chain.AddBlock(blk); err != nil {
+				return fmt.Errorf("block %d from %s: %w", headers[i].GetHeight(), r.peer.ShortString(8), err)
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) request(ctx context.Context, peer p2p.PeerID, t p2p.MessageType, start, count uint64) (proto.Message, error) {
+	s.lock.Lock()
+	s.nextID++
+	id := s.nextID
+	req := &request{peer: peer, reply: make(chan proto.Message, 1)}
+	s.pending[id] = req
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.pending, id)
+		s.lock.Unlock()
+	}()
+
+	if err := s.send(peer, t, &kangaroosyncpb.KangarooRangeRequest{RequestId: id, Start: start, Count: count}); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(s.cfg.RequestTimeout)
+	defer timer.Stop()
+
+	select {
+	case m := <-req.reply:
+		return m, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: %s", ErrStalled, peer.ShortString(8))
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Syncer) deliver(from p2p.PeerID, id uint64, m proto.Message) {
+	s.lock.Lock()
+	req, ok := s.pending[id]
+	s.lock.Unlock()
+
+	// responses nobody asked that peer for are dropped
+	if !ok || req.peer != from {
+		return
+	}
+
+	select {
+	case req.reply <- m:
+	default:
+	}
+}
+
+func (s *Syncer) handleHeadersResponse(from p2p.PeerID, msg p2p.Message) {
+	resp := &kangaroosyncpb.KangarooHeadersResponse{}
+	if err := proto.Unmarshal(msg.Payload, resp); err != nil {
+		return
+	}
+	s.deliver(from, resp.RequestId, resp)
+}
+
+func (s *Syncer) handleBodiesResponse(from p2p.PeerID, msg p2p.Message) {
+	resp := &kangaroosyncpb.KangarooBodiesResponse{}
+	if err := proto.Unmarshal(msg.Payload, resp); err != nil {
+		return
+	}
+	s.deliver(from, resp.RequestId, resp)
+}
@@ -0,0 +1,325 @@
+package blocksync
+
+import (
+	"context"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/memnet"
+	kangaroosyncpb "github.com/andantan/kangaroo/proto/p2p/blocksync/pb"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newChain(t *testing.T, genesisTime int64) *chain.Chain {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	executor := state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver())
+	c, err := chain.NewChain(executor, &chain.Genesis{Timestamp: genesisTime}, nil)
+	require.NoError(t, err)
+	return c
+}
+
+// buildBlocks extends a scratch chain and returns its blocks above genesis.
+func buildBlocks(t *testing.T, n int) []block.Block {
+	return buildBranch(t, nil, n, 0)
+}
+
+// buildBranch extends base by n blocks and returns the new ones. Branches
+// built with different skews have different timestamps, hence different ids.
+func buildBranch(t *testing.T, base []block.Block, n int, skew int64) []block.Block {
+	c := newChain(t, 1)
+	for _, blk := range base {
+		require.NoError(t, c.AddBlock(blk))
+	}
+	pool := mempool.NewMempool(c.Executor().HashDeriver(), 0)
+
+	blocks := make([]block.Block, n)
+	for i := range blocks {
+		blk, err := assembler.NewAssembler(c, pool, 0).Assemble(nil, int64(len(base)+i+2)+skew, nil)
+		require.NoError(t, err)
+		require.NoError(t, c.AddBlock(blk))
+		blocks[i] = blk
+	}
+	return blocks
+}
+
+func newServer(t *testing.T, net *memnet.Network, id p2p.PeerID, blocks []block.Block) *Syncer {
+	tr, err := net.Join(id)
+	require.NoError(t, err)
+
+	c := newChain(t, 1)
+	for _, blk := range blocks {
+		require.NoError(t, c.AddBlock(blk))
+	}
+
+	s, err := NewSyncer(Config{}, c, tr)
+	require.NoError(t, err)
+	return s
+}
+
+func newNetwork(t *testing.T) *memnet.Network {
+	net := memnet.NewNetwork(memnet.Config{Seed: 1, Latency: memnet.UniformLatency{Max: time.Millisecond}})
+	t.Cleanup(net.Close)
+	return net
+}
+
+func requireSameChain(t *testing.T, c *chain.Chain, blocks []block.Block) {
+	require.Equal(t, uint64(len(blocks)), c.Height())
+
+	deriver := c.Executor().HashDeriver()
+	for _, want := range blocks {
+		got, err := c.GetBlockByHeight(want.GetHeader().GetHeight())
+		require.NoError(t, err)
+
+		wantID, err := want.Hash(deriver)
+		require.NoError(t, err)
+		gotID, err := got.Hash(deriver)
+		require.NoError(t, err)
+		require.True(t, wantID.Equal(gotID))
+	}
+}
+
+func TestSyncer_CatchUp(t *testing.T) {
+	blocks := buildBlocks(t, 150)
+	net := newNetwork(t)
+
+	for _, id := range []p2p.PeerID{"server-1", "server-2", "server-3"} {
+		newServer(t, net, id, blocks)
+	}
+
+	tr, err := net.Join("fresh")
+	require.NoError(t, err)
+	fresh := newChain(t, 1)
+
+	var progress []Progress
+	s, err := NewSyncer(Config{
+		HeaderBatch: 40,
+		BodyBatch:   7,
+		OnProgress:  func(p Progress) { progress = append(progress, p) },
+	}, fresh, tr)
+	require.NoError(t, err)
+
+	net.ConnectAll()
+	require.Eventually(t, func() bool {
+		for _, id := range []p2p.PeerID{"server-1", "server-2", "server-3"} {
+			if _, ok := s.PeerHeight(id); !ok {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, time.Millisecond)
+	assert.Equal(t, uint64(len(blocks)), s.BestHeight())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, s.Sync(ctx))
+	requireSameChain(t, fresh, blocks)
+
+	t.Run("progress is reported per window", func(t *testing.T) {
+		require.Len(t, progress, 4)
+		for i, p := range progress {
+			assert.Equal(t, uint64(len(blocks)), p.Target)
+			assert.Equal(t, 3, p.Peers)
+			if i > 0 {
+				assert.Greater(t, p.Height, progress[i-1].Height)
+			}
+		}
+		assert.Equal(t, uint64(len(blocks)), progress[len(progress)-1].Height)
+	})
+
+	t.Run("nothing to do when caught up", func(t *testing.T) {
+		require.NoError(t, s.Sync(ctx))
+		assert.Len(t, progress, 4)
+	})
+}
+
+func TestSyncer_SwitchesToHeavierBranch(t *testing.T) {
+	shared := buildBlocks(t, 5)
+	ours := append(append([]block.Block(nil), shared...), buildBranch(t, shared, 3, 100)...)
+	theirs := append(append([]block.Block(nil), shared...), buildBranch(t, shared, 6, 200)...)
+
+	net := newNetwork(t)
+	newServer(t, net, "server", theirs)
+
+	tr, err := net.Join("local")
+	require.NoError(t, err)
+	local := newChain(t, 1)
+	for _, blk := range ours {
+		require.NoError(t, local.AddBlock(blk))
+	}
+
+	var removed []block.Block
+	s, err := NewSyncer(Config{
+		HeaderBatch: 2,
+		BodyBatch:   2,
+		OnReorg:     func(blocks []block.Block) { removed = append(removed, blocks...) },
+	}, local, tr)
+	require.NoError(t, err)
+
+	net.ConnectAll()
+	require.Eventually(t, func() bool {
+		_, ok := s.PeerHeight("server")
+		return ok
+	}, 2*time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, s.Sync(ctx))
+	requireSameChain(t, local, theirs)
+	assert.Equal(t, ours[len(shared):], removed)
+
+	t.Run("never reorganizes finalized blocks", func(t *testing.T) {
+		other := append(append([]block.Block(nil), shared[:2]...), buildBranch(t, shared[:2], 12, 300)...)
+		newServer(t, net, "other", other)
+		net.ConnectAll()
+
+		id, err := local.Head().Hash(local.Executor().HashDeriver())
+		require.NoError(t, err)
+		require.NoError(t, local.Finalize(local.Height(), id))
+
+		require.Eventually(t, func() bool {
+			_, ok := s.PeerHeight("other")
+			return ok
+		}, 2*time.Second, time.Millisecond)
+		require.NoError(t, s.Sync(ctx))
+		requireSameChain(t, local, theirs)
+	})
+}
+
+func TestSyncer_NoPeers(t *testing.T) {
+	net := newNetwork(t)
+	tr, err := net.Join("alone")
+	require.NoError(t, err)
+
+	s, err := NewSyncer(Config{}, newChain(t, 1), tr)
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.Sync(context.Background()), ErrNoPeers)
+}
+
+func TestSyncer_DifferentGenesisIgnored(t *testing.T) {
+	net := newNetwork(t)
+	newServer(t, net, "server", buildBlocks(t, 3))
+
+	tr, err := net.Join("other")
+	require.NoError(t, err)
+	s, err := NewSyncer(Config{}, newChain(t, 2), tr)
+	require.NoError(t, err)
+
+	net.ConnectAll()
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok := s.PeerHeight("server")
+	assert.False(t, ok)
+	assert.ErrorIs(t, s.Sync(context.Background()), ErrNoPeers)
+}
+
+// fakePeer claims a head and then either never answers or answers with junk.
+func fakePeer(t *testing.T, net *memnet.Network, id p2p.PeerID, genesis []byte, height uint64, lie bool) {
+	tr, err := net.Join(id)
+	require.NoError(t, err)
+
+	status, err := proto.Marshal(&kangaroosyncpb.KangarooStatus{HeadHeight: height, GenesisHash: genesis})
+	require.NoError(t, err)
+	tr.OnPeer(func(peer p2p.PeerID, connected bool) {
+		if connected {
+			_ = tr.Send(peer, p2p.Message{Type: p2p.MessageSyncStatus, Payload: status})
+		}
+	})
+
+	if !lie {
+		return
+	}
+
+	reply := func(t p2p.MessageType, build func(req *kangaroosyncpb.KangarooRangeRequest) proto.Message) p2p.Handler {
+		return func(from p2p.PeerID, msg p2p.Message) {
+			req := &kangaroosyncpb.KangarooRangeRequest{}
+			if proto.Unmarshal(msg.Payload, req) != nil {
+				return
+			}
+			b, _ := proto.Marshal(build(req))
+			_ = tr.Send(from, p2p.Message{Type: t, Payload: b})
+		}
+	}
+	junk := func(n uint64) [][]byte {
+		out := make([][]byte, n)
+		for i := range out {
+			out[i] = []byte{0xff, 0xee}
+		}
+		return out
+	}
+
+	tr.Handle(p2p.MessageSyncHeadersRequest, reply(p2p.MessageSyncHeadersResponse, func(req *kangaroosyncpb.KangarooRangeRequest) proto.Message {
+		return &kangaroosyncpb.KangarooHeadersResponse{RequestId: req.RequestId, Headers: junk(req.Count)}
+	}))
+	tr.Handle(p2p.MessageSyncBodiesRequest, reply(p2p.MessageSyncBodiesResponse, func(req *kangaroosyncpb.KangarooRangeRequest) proto.Message {
+		return &kangaroosyncpb.KangarooBodiesResponse{RequestId: req.RequestId, Bodies: junk(req.Count), Tails: junk(req.Count)}
+	}))
+}
+
+func TestSyncer_RetriesStalledAndLyingPeers(t *testing.T) {
+	blocks := buildBlocks(t, 60)
+	net := newNetwork(t)
+
+	fresh := newChain(t, 1)
+	genesisID, err := fresh.Genesis().Hash(fresh.Executor().HashDeriver())
+	require.NoError(t, err)
+
+	// the staller claims the best head so it is asked for headers first; the
+	// liar sorts after the servers, so it only ever serves a share of bodies
+	fakePeer(t, net, "z-liar", genesisID.Bytes(), uint64(len(blocks)), true)
+	fakePeer(t, net, "staller", genesisID.Bytes(), 1000, false)
+	newServer(t, net, "server-1", blocks)
+	newServer(t, net, "server-2", blocks)
+
+	var lock sync.Mutex
+	invalid := make(map[p2p.PeerID]error)
+	onInvalid := func(from p2p.PeerID, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		invalid[from] = err
+	}
+
+	tr, err := net.Join("fresh")
+	require.NoError(t, err)
+	s, err := NewSyncer(Config{HeaderBatch: 20, BodyBatch: 5, RequestTimeout: 50 * time.Millisecond, OnInvalid: onInvalid}, fresh, tr)
+	require.NoError(t, err)
+
+	net.ConnectAll()
+	require.Eventually(t, func() bool {
+		_, liar := s.PeerHeight("z-liar")
+		_, s1 := s.PeerHeight("server-1")
+		_, s2 := s.PeerHeight("server-2")
+		return liar && s1 && s2 && s.BestHeight() == 1000
+	}, 2*time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, s.Sync(ctx))
+	requireSameChain(t, fresh, blocks)
+
+	// only the liar served invalid data; stalling is not reported
+	lock.Lock()
+	require.Len(t, invalid, 1)
+	assert.ErrorIs(t, invalid["z-liar"], ErrBadResponse)
+	lock.Unlock()
+
+	t.Run("context cancellation stops sync", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, s.Sync(cancelled), context.Canceled)
+	})
+}
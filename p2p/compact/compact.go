@@ -0,0 +1,525 @@
+package compact
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooblock"
+	"github.com/andantan/kangaroo/core/block/kangaroobody"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	kangaroocompactpb "github.com/andantan/kangaroo/proto/p2p/compact/pb"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"sync"
+	"time"
+)
+
+const DefaultFetchTimeout = 2 * time.Second
+
+var (
+	ErrUndecodable   = errors.New("undecodable compact message")
+	ErrBodyMismatch  = errors.New("reconstructed body does not match the header")
+	ErrUnexpectedTxs = errors.New("unexpected transactions")
+)
+
+type Config struct {
+	// FetchTimeout is how long missing transactions may take to arrive
+	// before the full block is requested instead.
+	FetchTimeout time.Duration
+
+	// OnBlock imports a received block, Chain.AddBlock if nil.
+	OnBlock func(blk block.Block) error
+	// OnInvalid, if set, is told about peers that sent invalid messages.
+	OnInvalid func(from p2p.PeerID, err error)
+}
+
+// Stats counts how blocks were obtained.
+type Stats struct {
+	// Reconstructed blocks were rebuilt entirely from the mempool.
+	Reconstructed int
+	// Completed blocks needed some transactions fetched from the sender.
+	Completed int
+	// FullBlocks fell back to downloading the whole block.
+	FullBlocks int
+	// FetchedTransactions is how many transactions were fetched in total.
+	FetchedTransactions int
+}
+
+// pending is a block waiting for missing transactions or for its full body.
+type pending struct {
+	from     p2p.PeerID
+	header   block.Header
+	tail     block.Tail
+	evidence []block.Evidence
+	txs      []transaction.Transaction
+	missing  []uint32
+	full     bool
+	timer    *time.Timer
+}
+
+// Relay sends blocks as their header plus short transaction ids. Receivers
+// rebuild the body from their mempool, fetch only what they lack from the
+// sender and fall back to the full block if the body cannot be rebuilt.
+type Relay struct {
+	cfg       Config
+	chain     *chain.Chain
+	pool      *mempool.Mempool
+	transport p2p.Transport
+	deriver   hash.HashDeriver
+
+	lock    sync.Mutex
+	pending map[string]*pending
+	stats   Stats
+}
+
+func NewRelay(cfg Config, c *chain.Chain, pool *mempool.Mempool, transport p2p.Transport) *Relay {
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = DefaultFetchTimeout
+	}
+	if cfg.OnBlock == nil {
+		cfg.OnBlock = c.AddBlock
+	}
+
+	r := &Relay{
+		cfg:       cfg,
+		chain:     c,
+		pool:      pool,
+		transport: transport,
+		deriver:   c.Executor().HashDeriver(),
+		pending:   make(map[string]*pending),
+	}
+
+	transport.Handle(p2p.MessageCompactBlock, r.handleCompactBlock)
+	transport.Handle(p2p.MessageCompactGetTransactions, r.handleGetTransactions)
+	transport.Handle(p2p.MessageCompactTransactions, r.handleTransactions)
+	transport.Handle(p2p.MessageCompactGetBlock, r.handleGetBlock)
+	transport.Handle(p2p.MessageCompactFullBlock, r.handleFullBlock)
+
+	return r
+}
+
+func (r *Relay) Stats() Stats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.stats
+}
+
+// Announce sends blk in compact form to every peer.
+func (r *Relay) Announce(blk block.Block) error {
+	return r.announce(blk, "")
+}
+
+func (r *Relay) announce(blk block.Block, exclude p2p.PeerID) error {
+	m, err := r.compact(blk)
+	if err != nil {
+		return err
+	}
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range r.transport.Peers() {
+		if id != exclude {
+			_ = r.transport.Send(id, p2p.Message{Type: p2p.MessageCompactBlock, Payload: b})
+		}
+	}
+	return nil
+}
+
+func (r *Relay) compact(blk block.Block) (*kangaroocompactpb.KangarooCompactBlock, error) {
+	blockID, err := blk.Hash(r.deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &kangaroocompactpb.KangarooCompactBlock{}
+	if m.Header, err = wrapper.WrapHeader(blk.GetHeader()); err != nil {
+		return nil, err
+	}
+	if blk.GetTail() != nil {
+		if m.Tail, err = wrapper.WrapTail(blk.GetTail()); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tx := range blk.GetBody().GetTransactions() {
+		txID, err := tx.Hash(r.deriver)
+		if err != nil {
+			return nil, err
+		}
+		m.ShortIds = append(m.ShortIds, ShortID(r.deriver, blockID, txID))
+	}
+
+	for _, ev := range blk.GetBody().GetEvidence() {
+		b, err := wrapper.WrapEvidence(ev)
+		if err != nil {
+			return nil, err
+		}
+		m.Evidence = append(m.Evidence, b)
+	}
+
+	return m, nil
+}
+
+func (r *Relay) handleCompactBlock(from p2p.PeerID, msg p2p.Message) {
+	m := &kangaroocompactpb.KangarooCompactBlock{}
+	if err := proto.Unmarshal(msg.Payload, m); err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	p, err := r.decode(from, m)
+	if err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	blockID, err := p.header.Hash(r.deriver)
+	if err != nil {
+		return
+	}
+	k := string(blockID.Bytes())
+
+	// blocks that do not extend a known block are left to block sync
+	if r.chain.HasBlock(blockID) || !r.chain.HasBlock(p.header.GetPrevBlockID()) {
+		return
+	}
+
+	r.lock.Lock()
+	if _, ok := r.pending[k]; ok {
+		r.lock.Unlock()
+		return
+	}
+	r.lock.Unlock()
+
+	r.fill(p, blockID, m.ShortIds)
+
+	if len(p.missing) == 0 {
+		r.complete(blockID, p, false)
+		return
+	}
+
+	r.lock.Lock()
+	if _, ok := r.pending[k]; ok {
+		r.lock.Unlock()
+		return
+	}
+	r.pending[k] = p
+	p.timer = time.AfterFunc(r.cfg.FetchTimeout, func() { r.timeout(blockID) })
+	r.lock.Unlock()
+
+	r.send(from, p2p.MessageCompactGetTransactions, &kangaroocompactpb.KangarooGetTransactions{
+		BlockId: r.wrapID(blockID),
+		Indexes: p.missing,
+	})
+}
+
+func (r *Relay) decode(from p2p.PeerID, m *kangaroocompactpb.KangarooCompactBlock) (*pending, error) {
+	header, err := wrapper.UnwrapHeader(m.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	var tail block.Tail
+	if len(m.Tail) > 0 {
+		if tail, err = wrapper.UnwrapTail(m.Tail); err != nil {
+			return nil, err
+		}
+	}
+
+	evidence := make([]block.Evidence, len(m.Evidence))
+	for i, b := range m.Evidence {
+		if evidence[i], err = wrapper.UnwrapEvidence(b); err != nil {
+			return nil, err
+		}
+	}
+
+	return &pending{
+		from:     from,
+		header:   header,
+		tail:     tail,
+		evidence: evidence,
+		txs:      make([]transaction.Transaction, len(m.ShortIds)),
+	}, nil
+}
+
+// fill takes what it can from the mempool. A short id matching several pool
+// transactions is treated as missing rather than guessed.
+func (r *Relay) fill(p *pending, blockID hash.Hash, shortIDs []uint64) {
+	wanted := make(map[uint64][]int, len(shortIDs))
+	for i, id := range shortIDs {
+		wanted[id] = append(wanted[id], i)
+	}
+
+	found := make(map[uint64]transaction.Transaction)
+	ambiguous := make(map[uint64]bool)
+	r.pool.Range(func(txID hash.Hash, tx transaction.Transaction) bool {
+		id := ShortID(r.deriver, blockID, txID)
+		if _, ok := wanted[id]; !ok {
+			return true
+		}
+		if _, dup := found[id]; dup {
+			ambiguous[id] = true
+		}
+		found[id] = tx
+		return true
+	})
+
+	for i, id := range shortIDs {
+		if tx, ok := found[id]; ok && !ambiguous[id] && len(wanted[id]) == 1 {
+			p.txs[i] = tx
+			continue
+		}
+		p.missing = append(p.missing, uint32(i))
+	}
+}
+
+func (r *Relay) handleGetTransactions(from p2p.PeerID, msg p2p.Message) {
+	m := &kangaroocompactpb.KangarooGetTransactions{}
+	if err := proto.Unmarshal(msg.Payload, m); err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	blockID, err := wrapper.UnwrapHash(m.BlockId)
+	if err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	blk, err := r.chain.GetBlockByID(blockID)
+	if err != nil {
+		return
+	}
+
+	txs := blk.GetBody().GetTransactions()
+	resp := &kangaroocompactpb.KangarooTransactions{BlockId: m.BlockId}
+	for _, i := range m.Indexes {
+		if int(i) >= len(txs) {
+			r.invalid(from, fmt.Errorf("%w: index %d of %d", ErrUndecodable, i, len(txs)))
+			return
+		}
+		b, err := wrapper.WrapTransaction(txs[i])
+		if err != nil {
+			return
+		}
+		resp.Transactions = append(resp.Transactions, b)
+	}
+
+	r.send(from, p2p.MessageCompactTransactions, resp)
+}
+
+func (r *Relay) handleTransactions(from p2p.PeerID, msg p2p.Message) {
+	m := &kangaroocompactpb.KangarooTransactions{}
+	if err := proto.Unmarshal(msg.Payload, m); err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	blockID, err := wrapper.UnwrapHash(m.BlockId)
+	if err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+	k := string(blockID.Bytes())
+
+	r.lock.Lock()
+	p, ok := r.pending[k]
+	if !ok || p.full || p.from != from {
+		r.lock.Unlock()
+		return
+	}
+	delete(r.pending, k)
+	p.timer.Stop()
+	r.lock.Unlock()
+
+	if len(m.Transactions) != len(p.missing) {
+		r.invalid(from, fmt.Errorf("%w: %d transactions for %d missing", ErrUnexpectedTxs, len(m.Transactions), len(p.missing)))
+		r.requestFull(blockID, p)
+		return
+	}
+
+	for i, b := range m.Transactions {
+		tx, err := wrapper.UnwrapTransaction(b)
+		if err != nil {
+			r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+			r.requestFull(blockID, p)
+			return
+		}
+		p.txs[p.missing[i]] = tx
+	}
+
+	r.lock.Lock()
+	r.stats.FetchedTransactions += len(m.Transactions)
+	r.lock.Unlock()
+
+	r.complete(blockID, p, true)
+}
+
+// complete assembles the body and imports the block. A body that does not
+// hash to the header's body hash, e.g. because of a short id collision, is
+// downloaded in full instead.
+func (r *Relay) complete(blockID hash.Hash, p *pending, fetched bool) {
+	body := kangaroobody.NewKangarooBodyWithEvidence(p.txs, p.evidence)
+	bodyHash, err := body.Hash(r.deriver)
+	if err != nil || !bodyHash.Equal(p.header.GetBodyHash()) {
+		log.Printf("[Compact] %s: %v, requesting full block", blockID.ShortString(8), ErrBodyMismatch)
+		r.requestFull(blockID, p)
+		return
+	}
+
+	blk := kangarooblock.NewKangarooBlock(p.header, body, p.tail)
+	if !r.importBlock(p.from, blk) {
+		return
+	}
+
+	r.lock.Lock()
+	if fetched {
+		r.stats.Completed++
+	} else {
+		r.stats.Reconstructed++
+	}
+	r.lock.Unlock()
+}
+
+func (r *Relay) requestFull(blockID hash.Hash, p *pending) {
+	r.lock.Lock()
+	p.full = true
+	r.pending[string(blockID.Bytes())] = p
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.timer = time.AfterFunc(r.cfg.FetchTimeout, func() { r.timeout(blockID) })
+	r.lock.Unlock()
+
+	r.send(p.from, p2p.MessageCompactGetBlock, &kangaroocompactpb.KangarooGetBlock{BlockId: r.wrapID(blockID)})
+}
+
+// timeout falls back to the full block when missing transactions do not
+// arrive, and gives the block up to block sync when the full block does not
+// either.
+func (r *Relay) timeout(blockID hash.Hash) {
+	k := string(blockID.Bytes())
+
+	r.lock.Lock()
+	p, ok := r.pending[k]
+	if !ok {
+		r.lock.Unlock()
+		return
+	}
+	if p.full {
+		delete(r.pending, k)
+		r.lock.Unlock()
+		log.Printf("[Compact] giving up on %s from %s", blockID.ShortString(8), p.from.ShortString(8))
+		return
+	}
+	r.lock.Unlock()
+
+	r.requestFull(blockID, p)
+}
+
+func (r *Relay) handleGetBlock(from p2p.PeerID, msg p2p.Message) {
+	m := &kangaroocompactpb.KangarooGetBlock{}
+	if err := proto.Unmarshal(msg.Payload, m); err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	blockID, err := wrapper.UnwrapHash(m.BlockId)
+	if err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	blk, err := r.chain.GetBlockByID(blockID)
+	if err != nil {
+		return
+	}
+
+	b, err := wrapper.WrapBlock(blk)
+	if err != nil {
+		return
+	}
+	r.send(from, p2p.MessageCompactFullBlock, &kangaroocompactpb.KangarooFullBlock{Block: b})
+}
+
+func (r *Relay) handleFullBlock(from p2p.PeerID, msg p2p.Message) {
+	m := &kangaroocompactpb.KangarooFullBlock{}
+	if err := proto.Unmarshal(msg.Payload, m); err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	blk, err := wrapper.UnwrapBlock(m.Block)
+	if err != nil {
+		r.invalid(from, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	blockID, err := blk.Hash(r.deriver)
+	if err != nil {
+		return
+	}
+	k := string(blockID.Bytes())
+
+	// only blocks that were asked for are accepted this way
+	r.lock.Lock()
+	p, ok := r.pending[k]
+	if !ok || !p.full || p.from != from {
+		r.lock.Unlock()
+		return
+	}
+	delete(r.pending, k)
+	p.timer.Stop()
+	r.lock.Unlock()
+
+	if !r.importBlock(from, blk) {
+		return
+	}
+
+	r.lock.Lock()
+	r.stats.FullBlocks++
+	r.lock.Unlock()
+}
+
+// importBlock hands blk to OnBlock and relays it on success.
+func (r *Relay) importBlock(from p2p.PeerID, blk block.Block) bool {
+	if err := r.cfg.OnBlock(blk); err != nil {
+		if errors.Is(err, chain.ErrInvalidBlock) {
+			r.invalid(from, err)
+		}
+		return false
+	}
+
+	if err := r.announce(blk, from); err != nil {
+		log.Printf("[Compact] failed to relay block: %v", err)
+	}
+	return true
+}
+
+func (r *Relay) wrapID(id hash.Hash) []byte {
+	b, _ := wrapper.WrapHash(id)
+	return b
+}
+
+func (r *Relay) send(to p2p.PeerID, t p2p.MessageType, m proto.Message) {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		log.Printf("[Compact] failed to encode message: %v", err)
+		return
+	}
+	_ = r.transport.Send(to, p2p.Message{Type: t, Payload: b})
+}
+
+func (r *Relay) invalid(from p2p.PeerID, err error) {
+	if r.cfg.OnInvalid != nil {
+		r.cfg.OnInvalid(from, err)
+	}
+}
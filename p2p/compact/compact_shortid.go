@@ -0,0 +1,21 @@
+package compact
+
+import (
+	"encoding/binary"
+	"github.com/andantan/kangaroo/crypto/hash"
+)
+
+// shortIDMask keeps 48 bits of the derived id, enough to make accidental
+// collisions in a mempool rare while keeping compact blocks small.
+const shortIDMask = 1<<48 - 1
+
+// ShortID is the short id of a transaction within one block. Salting the
+// transaction hash with the block id means a collision crafted for one block
+// does not carry over to the next.
+func ShortID(deriver hash.HashDeriver, blockID, txID hash.Hash) uint64 {
+	buf := make([]byte, 0, len(blockID.Bytes())+len(txID.Bytes()))
+	buf = append(buf, blockID.Bytes()...)
+	buf = append(buf, txID.Bytes()...)
+
+	return binary.BigEndian.Uint64(deriver.Derive(buf).Bytes()[:8]) & shortIDMask
+}
@@ -0,0 +1,233 @@
+package compact
+
+import (
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/memnet"
+	kangaroocompactpb "github.com/andantan/kangaroo/proto/p2p/compact/pb"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type node struct {
+	chain *chain.Chain
+	pool  *mempool.Mempool
+	relay *Relay
+}
+
+type fixture struct {
+	t       *testing.T
+	net     *memnet.Network
+	user    key.PrivateKey
+	genesis *chain.Genesis
+	txs     []transaction.Transaction
+}
+
+func newFixture(t *testing.T) *fixture {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	user, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	f := &fixture{
+		t:    t,
+		net:  memnet.NewNetwork(memnet.Config{Seed: 1}),
+		user: user,
+		genesis: &chain.Genesis{
+			Timestamp: 1,
+			Alloc: []chain.GenesisAccount{
+				{Address: user.PublicKey().Address(addressSuite.Deriver()), Balance: big.NewInt(1000)},
+			},
+		},
+	}
+	t.Cleanup(f.net.Close)
+
+	for nonce := uint64(0); nonce < 10; nonce++ {
+		tx := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(1), nil, nonce)
+		require.NoError(t, tx.Sign(user, hashSuite.Deriver()))
+		f.txs = append(f.txs, tx)
+	}
+	return f
+}
+
+func (f *fixture) newChain() *chain.Chain {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(f.t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(f.t, err)
+
+	c, err := chain.NewChain(state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver()), f.genesis, nil)
+	require.NoError(f.t, err)
+	return c
+}
+
+// join adds a node whose pool holds the fixture transactions at known.
+func (f *fixture) join(id p2p.PeerID, cfg Config, known ...int) *node {
+	tr, err := f.net.Join(id)
+	require.NoError(f.t, err)
+
+	n := &node{chain: f.newChain()}
+	n.pool = mempool.NewMempool(n.chain.Executor().HashDeriver(), 0)
+	for _, i := range known {
+		_, err := n.pool.Add(f.txs[i])
+		require.NoError(f.t, err)
+	}
+	n.relay = NewRelay(cfg, n.chain, n.pool, tr)
+	return n
+}
+
+func (f *fixture) mine(n *node) block.Block {
+	blk, err := assembler.NewAssembler(n.chain, n.pool, 0).Assemble(nil, 2, nil)
+	require.NoError(f.t, err)
+	require.Len(f.t, blk.GetBody().GetTransactions(), len(f.txs))
+	require.NoError(f.t, n.chain.AddBlock(blk))
+	return blk
+}
+
+func all(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+func TestShortID(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	deriver := hashSuite.Deriver()
+
+	blockA, blockB := deriver.Derive([]byte("a")), deriver.Derive([]byte("b"))
+	tx := deriver.Derive([]byte("tx"))
+
+	id := ShortID(deriver, blockA, tx)
+	assert.Equal(t, id, ShortID(deriver, blockA, tx))
+	assert.NotEqual(t, id, ShortID(deriver, blockB, tx), "ids are salted per block")
+	assert.Zero(t, id>>48)
+}
+
+func TestRelay_Reconstruct(t *testing.T) {
+	f := newFixture(t)
+	a := f.join("a", Config{}, all(10)...)
+	b := f.join("b", Config{}, all(10)...)
+	c := f.join("c", Config{}, 0, 1, 2, 3, 4, 5, 6)
+	require.NoError(t, f.net.Connect("a", "b"))
+	require.NoError(t, f.net.Connect("b", "c"))
+
+	blk := f.mine(a)
+	require.NoError(t, a.relay.Announce(blk))
+
+	require.Eventually(t, func() bool {
+		return b.chain.Height() == 1 && c.chain.Height() == 1
+	}, 2*time.Second, time.Millisecond)
+
+	t.Run("full mempool rebuilds the block locally", func(t *testing.T) {
+		assert.Equal(t, Stats{Reconstructed: 1}, b.relay.Stats())
+	})
+
+	t.Run("only missing transactions are fetched from the relayer", func(t *testing.T) {
+		assert.Equal(t, Stats{Completed: 1, FetchedTransactions: 3}, c.relay.Stats())
+	})
+
+	t.Run("known blocks are ignored", func(t *testing.T) {
+		require.NoError(t, a.relay.Announce(blk))
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, Stats{Reconstructed: 1}, b.relay.Stats())
+	})
+}
+
+// fakeSender announces blk compactly, answers transaction requests with
+// wrongTx (or not at all) and full block requests honestly.
+func fakeSender(t *testing.T, f *fixture, blk block.Block, wrongTx transaction.Transaction) *memnet.Transport {
+	tr, err := f.net.Join("sender")
+	require.NoError(t, err)
+
+	c := f.newChain()
+	require.NoError(t, c.AddBlock(blk))
+	helper := &Relay{chain: c, deriver: c.Executor().HashDeriver()}
+
+	if wrongTx != nil {
+		tr.Handle(p2p.MessageCompactGetTransactions, func(from p2p.PeerID, msg p2p.Message) {
+			req := &kangaroocompactpb.KangarooGetTransactions{}
+			require.NoError(t, proto.Unmarshal(msg.Payload, req))
+
+			resp := &kangaroocompactpb.KangarooTransactions{BlockId: req.BlockId}
+			for range req.Indexes {
+				b, err := wrapper.WrapTransaction(wrongTx)
+				require.NoError(t, err)
+				resp.Transactions = append(resp.Transactions, b)
+			}
+			b, _ := proto.Marshal(resp)
+			_ = tr.Send(from, p2p.Message{Type: p2p.MessageCompactTransactions, Payload: b})
+		})
+	}
+	tr.Handle(p2p.MessageCompactGetBlock, func(from p2p.PeerID, msg p2p.Message) {
+		b, err := wrapper.WrapBlock(blk)
+		require.NoError(t, err)
+		payload, _ := proto.Marshal(&kangaroocompactpb.KangarooFullBlock{Block: b})
+		_ = tr.Send(from, p2p.Message{Type: p2p.MessageCompactFullBlock, Payload: payload})
+	})
+
+	m, err := helper.compact(blk)
+	require.NoError(t, err)
+	payload, err := proto.Marshal(m)
+	require.NoError(t, err)
+
+	tr.OnPeer(func(id p2p.PeerID, connected bool) {
+		if connected {
+			_ = tr.Send(id, p2p.Message{Type: p2p.MessageCompactBlock, Payload: payload})
+		}
+	})
+	return tr
+}
+
+func TestRelay_FallbackToFullBlock(t *testing.T) {
+	t.Run("when missing transactions never arrive", func(t *testing.T) {
+		f := newFixture(t)
+		blk := f.mine(f.join("miner", Config{}, all(10)...))
+
+		fakeSender(t, f, blk, nil)
+		receiver := f.join("receiver", Config{FetchTimeout: 20 * time.Millisecond}, 0, 1)
+		require.NoError(t, f.net.Connect("sender", "receiver"))
+
+		require.Eventually(t, func() bool { return receiver.chain.Height() == 1 }, 2*time.Second, time.Millisecond)
+		assert.Equal(t, Stats{FullBlocks: 1}, receiver.relay.Stats())
+	})
+
+	t.Run("when the rebuilt body does not match the header", func(t *testing.T) {
+		f := newFixture(t)
+		blk := f.mine(f.join("miner", Config{}, all(10)...))
+
+		wrong := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(2), nil, 99)
+		require.NoError(t, wrong.Sign(f.user, f.newChain().Executor().HashDeriver()))
+
+		var invalid []error
+		fakeSender(t, f, blk, wrong)
+		receiver := f.join("receiver", Config{OnInvalid: func(_ p2p.PeerID, err error) { invalid = append(invalid, err) }}, all(9)...)
+		require.NoError(t, f.net.Connect("sender", "receiver"))
+
+		require.Eventually(t, func() bool { return receiver.chain.Height() == 1 }, 2*time.Second, time.Millisecond)
+		assert.Equal(t, Stats{FullBlocks: 1, FetchedTransactions: 1}, receiver.relay.Stats())
+		assert.Empty(t, invalid)
+	})
+}
@@ -0,0 +1,339 @@
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/p2p"
+	kangaroogossippb "github.com/andantan/kangaroo/proto/p2p/gossip/pb"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultFanout            = 6
+	DefaultAnnounceThreshold = 16 << 10
+	DefaultSeenCacheSize     = 1 << 16
+	DefaultStoreSize         = 1024
+	DefaultFetchTimeout      = 2 * time.Second
+
+	// how many other announcers of a payload are remembered while it is
+	// being fetched
+	maxFetchAlternates = 8
+)
+
+var ErrUnknownTopic = errors.New("unknown topic")
+
+type Config struct {
+	// Fanout is the number of peers each message is pushed or announced to,
+	// DefaultFanout if zero. A negative fanout sends to every peer.
+	Fanout int
+	// Payloads of at least AnnounceThreshold bytes are announced by id and
+	// only sent to peers that ask for them.
+	AnnounceThreshold int
+	SeenCacheSize     int
+	// StoreSize is how many recent payloads are kept to answer requests.
+	StoreSize int
+	// FetchTimeout is how long a request to one announcer may stay open
+	// before the payload is requested from the next peer that announced it.
+	FetchTimeout time.Duration
+	Seed         int64
+
+	// OnInvalid, if set, is told about peers that sent payloads failing
+	// validation.
+	OnInvalid func(from p2p.PeerID, topic Topic, err error)
+}
+
+// Gossip floods topic messages over a transport. Every payload is validated
+// by its topic handler before it is relayed, and a cache of seen message ids
+// keeps messages from looping. A message only enters the cache once its
+// handler accepted or rejected it; ignored messages, such as a block whose
+// parent has not arrived yet, are handled again when they show up again.
+type Gossip struct {
+	cfg       Config
+	transport p2p.Transport
+	deriver   hash.HashDeriver
+
+	lock     sync.Mutex
+	rng      *rand.Rand
+	handlers map[Topic]TopicHandler
+	seen     *boundedCache
+	store    *boundedCache
+	fetching map[string]*fetch
+	// handling holds the messages a handler is running for, so a copy
+	// arriving from another peer meanwhile is dropped.
+	handling map[string]struct{}
+}
+
+func NewGossip(cfg Config, transport p2p.Transport, deriver hash.HashDeriver) *Gossip {
+	if cfg.Fanout == 0 {
+		cfg.Fanout = DefaultFanout
+	}
+	if cfg.AnnounceThreshold <= 0 {
+		cfg.AnnounceThreshold = DefaultAnnounceThreshold
+	}
+	if cfg.SeenCacheSize <= 0 {
+		cfg.SeenCacheSize = DefaultSeenCacheSize
+	}
+	if cfg.StoreSize <= 0 {
+		cfg.StoreSize = DefaultStoreSize
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = DefaultFetchTimeout
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+
+	g := &Gossip{
+		cfg:       cfg,
+		transport: transport,
+		deriver:   deriver,
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+		handlers:  make(map[Topic]TopicHandler),
+		seen:      newBoundedCache(cfg.SeenCacheSize),
+		store:     newBoundedCache(cfg.StoreSize),
+		fetching:  make(map[string]*fetch),
+		handling:  make(map[string]struct{}),
+	}
+
+	transport.Handle(p2p.MessageGossip, g.handleGossip)
+	transport.Handle(p2p.MessageGossipAnnounce, g.handleAnnounce)
+	transport.Handle(p2p.MessageGossipRequest, g.handleRequest)
+
+	return g
+}
+
+// Subscribe registers the handler of a topic. Messages of topics without a
+// handler are neither processed nor relayed.
+func (g *Gossip) Subscribe(topic Topic, h TopicHandler) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.handlers[topic] = h
+}
+
+// Publish sends a locally created payload to the network. The payload is not
+// run through the topic handler; the caller already holds it.
+func (g *Gossip) Publish(topic Topic, payload []byte) error {
+	id := g.deriver.Derive(payload)
+	k := string(id.Bytes())
+
+	g.lock.Lock()
+	if _, ok := g.handlers[topic]; !ok {
+		g.lock.Unlock()
+		return fmt.Errorf("%w: %s", ErrUnknownTopic, topic)
+	}
+	g.seen.add(k, nil)
+	g.store.add(k, payload)
+	g.lock.Unlock()
+
+	g.relay(topic, id, payload, "")
+	return nil
+}
+
+func (g *Gossip) handleGossip(from p2p.PeerID, msg p2p.Message) {
+	pb := new(kangaroogossippb.KangarooGossip)
+	if err := proto.Unmarshal(msg.Payload, pb); err != nil {
+		g.invalid(from, 0, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	topic := Topic(pb.Topic)
+	id := g.deriver.Derive(pb.Payload)
+	k := string(id.Bytes())
+
+	g.lock.Lock()
+	handler, ok := g.handlers[topic]
+	delete(g.fetching, k)
+	_, busy := g.handling[k]
+	if !ok || busy || g.seen.has(k) {
+		g.lock.Unlock()
+		return
+	}
+	g.handling[k] = struct{}{}
+	g.lock.Unlock()
+
+	err := handler(from, pb.Payload)
+
+	g.lock.Lock()
+	delete(g.handling, k)
+	if err == nil || !errors.Is(err, ErrIgnore) {
+		g.seen.add(k, nil)
+	}
+	if err == nil {
+		g.store.add(k, pb.Payload)
+	}
+	g.lock.Unlock()
+
+	if err != nil {
+		if !errors.Is(err, ErrIgnore) {
+			g.invalid(from, topic, err)
+		}
+		return
+	}
+
+	g.relay(topic, id, pb.Payload, from)
+}
+
+// fetch tracks an announced payload that was requested but has not arrived.
+// Peers that announce it meanwhile are kept as alternates and asked in turn
+// whenever the outstanding request times out.
+type fetch struct {
+	topic      uint32
+	alternates []p2p.PeerID
+}
+
+func (g *Gossip) handleAnnounce(from p2p.PeerID, msg p2p.Message) {
+	pb := new(kangaroogossippb.KangarooAnnounce)
+	if err := proto.Unmarshal(msg.Payload, pb); err != nil {
+		g.invalid(from, 0, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	wanted := make([][]byte, 0, len(pb.Ids))
+
+	g.lock.Lock()
+	if _, ok := g.handlers[Topic(pb.Topic)]; ok {
+		for _, id := range pb.Ids {
+			k := string(id)
+			if g.seen.has(k) {
+				continue
+			}
+			// one request at a time; other announcers wait their turn
+			if f, ok := g.fetching[k]; ok {
+				if len(f.alternates) < maxFetchAlternates && !containsPeer(f.alternates, from) {
+					f.alternates = append(f.alternates, from)
+				}
+				continue
+			}
+			g.fetching[k] = &fetch{topic: pb.Topic}
+			time.AfterFunc(g.cfg.FetchTimeout, func() { g.refetch(k) })
+			wanted = append(wanted, id)
+		}
+	}
+	g.lock.Unlock()
+
+	if len(wanted) == 0 {
+		return
+	}
+
+	g.send(from, p2p.MessageGossipRequest, &kangaroogossippb.KangarooRequest{Topic: pb.Topic, Ids: wanted})
+}
+
+// refetch runs when a request timed out. It asks the next alternate announcer
+// for the payload, or forgets the fetch when none is left.
+func (g *Gossip) refetch(k string) {
+	g.lock.Lock()
+	f, ok := g.fetching[k]
+	if !ok {
+		g.lock.Unlock()
+		return
+	}
+	if len(f.alternates) == 0 || g.seen.has(k) {
+		delete(g.fetching, k)
+		g.lock.Unlock()
+		return
+	}
+	next := f.alternates[0]
+	f.alternates = f.alternates[1:]
+	time.AfterFunc(g.cfg.FetchTimeout, func() { g.refetch(k) })
+	g.lock.Unlock()
+
+	g.send(next, p2p.MessageGossipRequest, &kangaroogossippb.KangarooRequest{Topic: f.topic, Ids: [][]byte{[]byte(k)}})
+}
+
+func containsPeer(peers []p2p.PeerID, id p2p.PeerID) bool {
+	for _, p := range peers {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Gossip) handleRequest(from p2p.PeerID, msg p2p.Message) {
+	pb := new(kangaroogossippb.KangarooRequest)
+	if err := proto.Unmarshal(msg.Payload, pb); err != nil {
+		g.invalid(from, 0, fmt.Errorf("%w: %v", ErrUndecodable, err))
+		return
+	}
+
+	for _, id := range pb.Ids {
+		g.lock.Lock()
+		payload, ok := g.store.get(string(id))
+		g.lock.Unlock()
+
+		if ok {
+			g.send(from, p2p.MessageGossip, &kangaroogossippb.KangarooGossip{Topic: pb.Topic, Payload: payload})
+		}
+	}
+}
+
+// relay pushes small payloads and announces large ones to a random subset of
+// peers, never back to the peer the message came from.
+func (g *Gossip) relay(topic Topic, id hash.Hash, payload []byte, from p2p.PeerID) {
+	peers := g.pickPeers(from)
+	if len(peers) == 0 {
+		return
+	}
+
+	var (
+		msgType p2p.MessageType
+		m       proto.Message
+	)
+	if len(payload) >= g.cfg.AnnounceThreshold {
+		msgType = p2p.MessageGossipAnnounce
+		m = &kangaroogossippb.KangarooAnnounce{Topic: uint32(topic), Ids: [][]byte{id.Bytes()}}
+	} else {
+		msgType = p2p.MessageGossip
+		m = &kangaroogossippb.KangarooGossip{Topic: uint32(topic), Payload: payload}
+	}
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		log.Printf("[Gossip] failed to encode %s message: %v", topic, err)
+		return
+	}
+
+	for _, peer := range peers {
+		_ = g.transport.Send(peer, p2p.Message{Type: msgType, Payload: b})
+	}
+}
+
+func (g *Gossip) pickPeers(exclude p2p.PeerID) []p2p.PeerID {
+	all := g.transport.Peers()
+	peers := make([]p2p.PeerID, 0, len(all))
+	for _, id := range all {
+		if id != exclude {
+			peers = append(peers, id)
+		}
+	}
+
+	if g.cfg.Fanout <= 0 || len(peers) <= g.cfg.Fanout {
+		return peers
+	}
+
+	g.lock.Lock()
+	g.rng.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+	g.lock.Unlock()
+
+	return peers[:g.cfg.Fanout]
+}
+
+func (g *Gossip) send(to p2p.PeerID, t p2p.MessageType, m proto.Message) {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		log.Printf("[Gossip] failed to encode message: %v", err)
+		return
+	}
+	_ = g.transport.Send(to, p2p.Message{Type: t, Payload: b})
+}
+
+func (g *Gossip) invalid(from p2p.PeerID, topic Topic, err error) {
+	if g.cfg.OnInvalid != nil {
+		g.cfg.OnInvalid(from, topic, err)
+	}
+}
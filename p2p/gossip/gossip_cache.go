@@ -0,0 +1,46 @@
+package gossip
+
+// boundedCache remembers the most recent keys, with an optional value each,
+// evicting the oldest once full.
+type boundedCache struct {
+	capacity int
+	entries  map[string][]byte
+	order    []string
+	next     int
+}
+
+func newBoundedCache(capacity int) *boundedCache {
+	return &boundedCache{
+		capacity: capacity,
+		entries:  make(map[string][]byte, capacity),
+		order:    make([]string, 0, capacity),
+	}
+}
+
+func (c *boundedCache) has(k string) bool {
+	_, ok := c.entries[k]
+	return ok
+}
+
+func (c *boundedCache) get(k string) ([]byte, bool) {
+	v, ok := c.entries[k]
+	return v, ok
+}
+
+// add reports whether k was new.
+func (c *boundedCache) add(k string, v []byte) bool {
+	if _, ok := c.entries[k]; ok {
+		return false
+	}
+
+	if len(c.order) < c.capacity {
+		c.order = append(c.order, k)
+	} else {
+		delete(c.entries, c.order[c.next])
+		c.order[c.next] = k
+		c.next = (c.next + 1) % c.capacity
+	}
+
+	c.entries[k] = v
+	return true
+}
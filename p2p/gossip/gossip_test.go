@@ -0,0 +1,470 @@
+package gossip
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	"github.com/andantan/kangaroo/consensus/finality"
+	"github.com/andantan/kangaroo/consensus/slashprotection"
+	"github.com/andantan/kangaroo/consensus/validator"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/block/kangarooattestation"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/memnet"
+	kangaroogossippb "github.com/andantan/kangaroo/proto/p2p/gossip/pb"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+type node struct {
+	transport *memnet.Transport
+	gossip    *Gossip
+
+	lock      sync.Mutex
+	processed map[string]int
+	invalid   int
+}
+
+func (n *node) count(payload []byte) int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.processed[string(payload)]
+}
+
+func (n *node) invalidCount() int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.invalid
+}
+
+// newNodes joins size nodes to a network; connect decides the topology.
+func newNodes(t *testing.T, size int, cfg Config, deriver hash.HashDeriver, connect func(net *memnet.Network, ids []p2p.PeerID)) []*node {
+	net := memnet.NewNetwork(memnet.Config{Seed: 1, Latency: memnet.UniformLatency{Max: time.Millisecond}})
+	t.Cleanup(net.Close)
+
+	nodes := make([]*node, size)
+	ids := make([]p2p.PeerID, size)
+	for i := range nodes {
+		ids[i] = p2p.PeerID(fmt.Sprintf("node-%02d", i))
+		tr, err := net.Join(ids[i])
+		require.NoError(t, err)
+
+		n := &node{transport: tr, processed: make(map[string]int)}
+		nodeCfg := cfg
+		nodeCfg.Seed = int64(i + 1)
+		nodeCfg.OnInvalid = func(p2p.PeerID, Topic, error) {
+			n.lock.Lock()
+			defer n.lock.Unlock()
+			n.invalid++
+		}
+		n.gossip = NewGossip(nodeCfg, tr, deriver)
+		nodes[i] = n
+	}
+
+	connect(net, ids)
+	return nodes
+}
+
+func ring(net *memnet.Network, ids []p2p.PeerID) {
+	for i := range ids {
+		_ = net.Connect(ids[i], ids[(i+1)%len(ids)])
+	}
+}
+
+func full(net *memnet.Network, _ []p2p.PeerID) {
+	net.ConnectAll()
+}
+
+// recording wraps h so the test can see how often each payload was processed.
+func recording(n *node, h TopicHandler) TopicHandler {
+	return func(from p2p.PeerID, payload []byte) error {
+		if err := h(from, payload); err != nil {
+			return err
+		}
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		n.processed[string(payload)]++
+		return nil
+	}
+}
+
+func accept(p2p.PeerID, []byte) error { return nil }
+
+func TestGossip_Transactions(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	deriver := hashSuite.Deriver()
+
+	nodes := newNodes(t, 6, Config{Fanout: -1}, deriver, ring)
+
+	pools := make([]*mempool.Mempool, len(nodes))
+	for i, n := range nodes {
+		pools[i] = mempool.NewMempool(deriver, 0)
+		n.gossip.Subscribe(TopicTransactions, recording(n, TransactionHandler(pools[i], deriver)))
+	}
+
+	signer, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	tx := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(1), nil, 0)
+	require.NoError(t, tx.Sign(signer, deriver))
+	_, err = pools[0].Add(tx)
+	require.NoError(t, err)
+	require.NoError(t, nodes[0].gossip.PublishTransaction(tx))
+
+	require.Eventually(t, func() bool {
+		for _, pool := range pools {
+			if pool.Len() != 1 {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, time.Millisecond)
+
+	t.Run("messages do not loop", func(t *testing.T) {
+		time.Sleep(20 * time.Millisecond)
+		for _, n := range nodes[1:] {
+			total := 0
+			n.lock.Lock()
+			for _, c := range n.processed {
+				total += c
+			}
+			n.lock.Unlock()
+			assert.Equal(t, 1, total)
+		}
+	})
+
+	t.Run("invalid messages are not relayed", func(t *testing.T) {
+		forged := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(1000), nil, 1)
+		require.NoError(t, forged.Sign(signer, deriver))
+		forged.Value = big.NewInt(2000) // breaks the signature
+
+		b, err := wrapper.WrapTransaction(forged)
+		require.NoError(t, err)
+
+		// bypass Publish, which trusts local payloads
+		msg, err := proto.Marshal(&kangaroogossippb.KangarooGossip{Topic: uint32(TopicTransactions), Payload: b})
+		require.NoError(t, err)
+		require.NoError(t, nodes[0].transport.Send(nodes[1].transport.ID(), p2p.Message{Type: p2p.MessageGossip, Payload: msg}))
+
+		require.Eventually(t, func() bool { return nodes[1].invalidCount() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		for i, n := range nodes {
+			if i != 1 {
+				assert.Zero(t, n.invalidCount())
+			}
+			assert.Equal(t, 1, pools[i].Len())
+		}
+	})
+}
+
+func TestTransactionHandler_FullPool(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	deriver := hashSuite.Deriver()
+
+	nodes := newNodes(t, 2, Config{Fanout: -1}, deriver, full)
+	pool := mempool.NewMempool(deriver, 1)
+	nodes[1].gossip.Subscribe(TopicTransactions, recording(nodes[1], TransactionHandler(pool, deriver)))
+	nodes[0].gossip.Subscribe(TopicTransactions, accept)
+
+	signer, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	sign := func(nonce uint64) *kangarootransaction.KangarooTransaction {
+		tx := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(1), nil, nonce)
+		require.NoError(t, tx.Sign(signer, deriver))
+		return tx
+	}
+
+	_, err = pool.Add(sign(0))
+	require.NoError(t, err)
+
+	tx := sign(1)
+	b, err := wrapper.WrapTransaction(tx)
+	require.NoError(t, err)
+	require.ErrorIs(t, TransactionHandler(pool, deriver)(nodes[0].transport.ID(), b), ErrIgnore)
+
+	require.NoError(t, nodes[0].gossip.PublishTransaction(tx))
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, 1, pool.Len())
+	assert.Zero(t, nodes[1].count(b))
+	assert.Zero(t, nodes[1].invalidCount(), "a full pool is not the sender's fault")
+}
+
+func TestGossip_AnnounceThenFetch(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("blake2b256")
+	require.NoError(t, err)
+
+	nodes := newNodes(t, 8, Config{Fanout: 3, AnnounceThreshold: 64}, hashSuite.Deriver(), full)
+	for _, n := range nodes {
+		n.gossip.Subscribe(TopicBlocks, recording(n, accept))
+	}
+
+	small := []byte("small payload, pushed directly")
+	large := make([]byte, 4096)
+	for i := range large {
+		large[i] = byte(i)
+	}
+
+	require.NoError(t, nodes[0].gossip.Publish(TopicBlocks, small))
+	require.NoError(t, nodes[0].gossip.Publish(TopicBlocks, large))
+
+	require.Eventually(t, func() bool {
+		for _, n := range nodes[1:] {
+			if n.count(small) != 1 || n.count(large) != 1 {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, time.Millisecond)
+
+	// late announcements of known payloads are ignored
+	time.Sleep(20 * time.Millisecond)
+	for _, n := range nodes[1:] {
+		assert.Equal(t, 1, n.count(large))
+	}
+
+	assert.ErrorIs(t, nodes[0].gossip.Publish(TopicAttestations, small), ErrUnknownTopic)
+}
+
+func TestGossip_FetchFromAlternateAnnouncer(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	deriver := hashSuite.Deriver()
+
+	nodes := newNodes(t, 3, Config{AnnounceThreshold: 64, FetchTimeout: 50 * time.Millisecond}, deriver, full)
+	for _, n := range nodes {
+		n.gossip.Subscribe(TopicBlocks, recording(n, accept))
+	}
+
+	payload := make([]byte, 4096)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	id := deriver.Derive(payload).Bytes()
+
+	// node-01 announces first but cannot serve the payload; node-02 can
+	nodes[2].gossip.lock.Lock()
+	nodes[2].gossip.store.add(string(id), payload)
+	nodes[2].gossip.lock.Unlock()
+
+	announce, err := proto.Marshal(&kangaroogossippb.KangarooAnnounce{Topic: uint32(TopicBlocks), Ids: [][]byte{id}})
+	require.NoError(t, err)
+	msg := p2p.Message{Type: p2p.MessageGossipAnnounce, Payload: announce}
+
+	require.NoError(t, nodes[1].transport.Send(nodes[0].transport.ID(), msg))
+	require.Eventually(t, func() bool {
+		nodes[0].gossip.lock.Lock()
+		defer nodes[0].gossip.lock.Unlock()
+		_, ok := nodes[0].gossip.fetching[string(id)]
+		return ok
+	}, time.Second, time.Millisecond)
+	require.NoError(t, nodes[2].transport.Send(nodes[0].transport.ID(), msg))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, nodes[0].count(payload), "the first announcer did not answer")
+
+	require.Eventually(t, func() bool { return nodes[0].count(payload) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestGossip_Fanout(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("sha3-256")
+	require.NoError(t, err)
+
+	nodes := newNodes(t, 10, Config{Fanout: 2}, hashSuite.Deriver(), full)
+
+	for i := 0; i < 20; i++ {
+		peers := nodes[0].gossip.pickPeers(nodes[1].transport.ID())
+		assert.Len(t, peers, 2)
+		assert.NotContains(t, peers, nodes[1].transport.ID())
+	}
+
+	all := newNodes(t, 4, Config{Fanout: -1}, hashSuite.Deriver(), full)
+	assert.Len(t, all[0].gossip.pickPeers(""), 3)
+}
+
+func TestGossip_Blocks_And_CheckpointVotes(t *testing.T) {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("keccak256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+	deriver := hashSuite.Deriver()
+
+	voterKey, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	valSet, err := validator.NewValidatorSet([]*validator.Validator{validator.NewValidator(voterKey.PublicKey(), 1)})
+	require.NoError(t, err)
+	db, err := slashprotection.Open("")
+	require.NoError(t, err)
+	voter, err := slashprotection.NewProtectedSigner(voterKey, db)
+	require.NoError(t, err)
+
+	nodes := newNodes(t, 3, Config{}, deriver, ring)
+
+	// only the first node votes, the others follow through the ring
+	chains := make([]*chain.Chain, len(nodes))
+	gadgets := make([]*finality.Gadget, len(nodes))
+	for i, n := range nodes {
+		executor := state.NewExecutor(deriver, addressSuite.Deriver())
+		chains[i], err = chain.NewChain(executor, &chain.Genesis{Timestamp: 1}, nil)
+		require.NoError(t, err)
+
+		var signer finality.Signer
+		if i == 0 {
+			signer = voter
+		}
+		gadgets[i], err = finality.NewGadget(finality.Config{EpochLength: 1}, valSet, chains[i], signer, n.gossip)
+		require.NoError(t, err)
+
+		n.gossip.Subscribe(TopicBlocks, BlockHandler(chains[i], nil))
+		n.gossip.Subscribe(TopicAttestations, CheckpointVoteHandler(gadgets[i]))
+	}
+
+	blk, err := assembler.NewAssembler(chains[0], mempool.NewMempool(deriver, 0), 0).Assemble(nil, 2, nil)
+	require.NoError(t, err)
+	require.NoError(t, chains[0].AddBlock(blk))
+	require.NoError(t, nodes[0].gossip.PublishBlock(blk))
+
+	require.Eventually(t, func() bool {
+		return chains[1].Height() == 1 && chains[2].Height() == 1
+	}, 2*time.Second, time.Millisecond)
+
+	require.NoError(t, gadgets[0].OnNewHead())
+	require.Eventually(t, func() bool {
+		return gadgets[1].LastJustified().Height == 1 && gadgets[2].LastJustified().Height == 1
+	}, 2*time.Second, time.Millisecond)
+
+	// a vote the gadget refuses is not relayed and counts against the sender
+	outsider, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	att, err := kangarooattestation.Sign(outsider, 2, 0, 0, deriver.Derive([]byte("vote")))
+	require.NoError(t, err)
+	v := &finality.Vote{Source: gadgets[0].LastJustified(), Target: gadgets[0].LastJustified(), Attestation: att}
+	require.NoError(t, nodes[0].gossip.PublishCheckpointVote(v))
+	require.Eventually(t, func() bool {
+		return nodes[1].invalidCount() == 1 && nodes[2].invalidCount() == 1
+	}, 2*time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, nodes[1].invalidCount())
+	assert.Equal(t, 1, nodes[2].invalidCount())
+}
+
+// timestampWork weighs blocks by their timestamp, so a later sibling of the
+// head carries more work than the head itself.
+type timestampWork struct{}
+
+func (timestampWork) ValidateBlock(block.Header, block.Block) error { return nil }
+
+func (timestampWork) BlockWork(header block.Header) *big.Int {
+	return big.NewInt(header.GetTimestamp())
+}
+
+func TestBlockHandler_ForkChoice(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+	deriver := hashSuite.Deriver()
+
+	newChain := func() *chain.Chain {
+		c, err := chain.NewChain(state.NewExecutor(deriver, addressSuite.Deriver()), &chain.Genesis{Timestamp: 1}, timestampWork{})
+		require.NoError(t, err)
+		return c
+	}
+	build := func(c *chain.Chain, timestamp int64) []byte {
+		blk, err := assembler.NewAssembler(c, mempool.NewMempool(deriver, 0), 0).Assemble(nil, timestamp, nil)
+		require.NoError(t, err)
+		b, err := wrapper.WrapBlock(blk)
+		require.NoError(t, err)
+		return b
+	}
+
+	c := newChain()
+	var removed []block.Block
+	handler := BlockHandler(c, func(blocks []block.Block) { removed = append(removed, blocks...) })
+
+	head := build(c, 2)
+	require.NoError(t, handler("peer", head))
+	assert.ErrorIs(t, handler("peer", head), ErrIgnore)
+
+	sibling := build(newChain(), 50)
+	require.NoError(t, handler("peer", sibling))
+	assert.Equal(t, uint64(1), c.Height())
+	assert.Equal(t, int64(50), c.Head().GetHeader().GetTimestamp())
+	require.Len(t, removed, 1)
+	assert.Equal(t, int64(2), removed[0].GetHeader().GetTimestamp())
+
+	t.Run("lighter forks and orphans are ignored", func(t *testing.T) {
+		lighter := build(newChain(), 10)
+		assert.ErrorIs(t, handler("peer", lighter), ErrIgnore)
+
+		other := newChain()
+		parent, err := wrapper.UnwrapBlock(build(other, 60))
+		require.NoError(t, err)
+		require.NoError(t, other.AddBlock(parent))
+		assert.ErrorIs(t, handler("peer", build(other, 70)), ErrIgnore)
+		assert.Equal(t, int64(50), c.Head().GetHeader().GetTimestamp())
+	})
+}
+
+func TestGossip_RetriesIgnoredMessages(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+	deriver := hashSuite.Deriver()
+
+	nodes := newNodes(t, 2, Config{}, deriver, full)
+
+	chains := make([]*chain.Chain, len(nodes))
+	for i, n := range nodes {
+		chains[i], err = chain.NewChain(state.NewExecutor(deriver, addressSuite.Deriver()), &chain.Genesis{Timestamp: 1}, nil)
+		require.NoError(t, err)
+		n.gossip.Subscribe(TopicBlocks, recording(n, BlockHandler(chains[i], nil)))
+	}
+
+	var blocks []block.Block
+	for i := 0; i < 2; i++ {
+		blk, err := assembler.NewAssembler(chains[0], mempool.NewMempool(deriver, 0), 0).Assemble(nil, int64(i+2), nil)
+		require.NoError(t, err)
+		require.NoError(t, chains[0].AddBlock(blk))
+		blocks = append(blocks, blk)
+	}
+
+	// the child arrives before its parent: not the sender's fault
+	require.NoError(t, nodes[0].gossip.PublishBlock(blocks[1]))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, uint64(0), chains[1].Height())
+	assert.Zero(t, nodes[1].invalidCount())
+
+	require.NoError(t, nodes[0].gossip.PublishBlock(blocks[0]))
+	require.Eventually(t, func() bool {
+		return chains[1].Height() == 1
+	}, 2*time.Second, time.Millisecond)
+
+	require.NoError(t, nodes[0].gossip.PublishBlock(blocks[1]))
+	require.Eventually(t, func() bool {
+		return chains[1].Height() == 2
+	}, 2*time.Second, time.Millisecond)
+	assert.Zero(t, nodes[1].invalidCount())
+}
@@ -0,0 +1,168 @@
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/consensus/finality"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	"log"
+)
+
+type Topic uint32
+
+const (
+	TopicTransactions Topic = iota + 1
+	TopicBlocks
+	TopicAttestations
+)
+
+func (t Topic) String() string {
+	switch t {
+	case TopicTransactions:
+		return "transactions"
+	case TopicBlocks:
+		return "blocks"
+	case TopicAttestations:
+		return "attestations"
+	default:
+		return fmt.Sprintf("Topic(%d)", uint32(t))
+	}
+}
+
+// TopicHandler validates and processes one payload of its topic. Only
+// payloads it accepts (returns nil for) are relayed further.
+type TopicHandler func(from p2p.PeerID, payload []byte) error
+
+// ErrIgnore tells the gossip layer a payload is not at fault but should not be
+// relayed, e.g. a transaction the local pool already had or a block the chain
+// cannot place yet. The sender is not penalized.
+var ErrIgnore = errors.New("ignore message")
+
+// ErrUndecodable marks payloads that are not even well-formed, as opposed to
+// well-formed ones that failed validation.
+var ErrUndecodable = errors.New("undecodable payload")
+
+// TransactionHandler adds gossiped transactions to the pool, which verifies
+// their signatures. Transactions the pool already has are ignored before
+// paying for that. Only transactions the pool rejects for their content are
+// reported invalid; a full pool is not the sender's fault.
+func TransactionHandler(pool *mempool.Mempool, deriver hash.HashDeriver) TopicHandler {
+	return func(_ p2p.PeerID, payload []byte) error {
+		tx, err := wrapper.UnwrapTransaction(payload)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUndecodable, err)
+		}
+
+		id, err := tx.Hash(deriver)
+		if err != nil {
+			return err
+		}
+		if pool.Has(id) {
+			return ErrIgnore
+		}
+
+		if _, err = pool.Add(tx); err != nil {
+			if errors.Is(err, mempool.ErrInvalidTransaction) {
+				return err
+			}
+			return fmt.Errorf("%w: %v", ErrIgnore, err)
+		}
+		return nil
+	}
+}
+
+// BlockHandler imports gossiped blocks through the chain's fork choice, so a
+// block on a known fork replaces the head when it carries more work. Blocks
+// the chain already has, blocks whose parent it does not have (left to block
+// sync) and blocks of forks it does not switch to are ignored rather than
+// reported invalid. onReorg, if set, receives the blocks a switch replaced.
+func BlockHandler(c *chain.Chain, onReorg func(removed []block.Block)) TopicHandler {
+	return func(_ p2p.PeerID, payload []byte) error {
+		blk, err := wrapper.UnwrapBlock(payload)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUndecodable, err)
+		}
+
+		id, err := blk.Hash(c.Executor().HashDeriver())
+		if err != nil {
+			return err
+		}
+		if c.HasBlock(id) {
+			return ErrIgnore
+		}
+		parentID := blk.GetHeader().GetPrevBlockID()
+		if parentID == nil {
+			return errors.New("block has no parent id")
+		}
+		if !c.HasBlock(parentID) {
+			return fmt.Errorf("%w: unknown parent", ErrIgnore)
+		}
+
+		removed, err := c.AddBranch([]block.Block{blk})
+		if errors.Is(err, chain.ErrLighterBranch) || errors.Is(err, chain.ErrFinalizedReorg) {
+			return fmt.Errorf("%w: %v", ErrIgnore, err)
+		}
+		if err != nil {
+			return err
+		}
+		if len(removed) > 0 && onReorg != nil {
+			onReorg(removed)
+		}
+		return nil
+	}
+}
+
+// CheckpointVoteHandler counts gossiped checkpoint votes, the attestations
+// validators cast on epoch checkpoints, in a finality gadget, which checks
+// their signature and signer. Votes it already counted are relayed once
+// more at most, as the seen cache drops repeats.
+func CheckpointVoteHandler(gadget *finality.Gadget) TopicHandler {
+	return func(_ p2p.PeerID, payload []byte) error {
+		v := new(finality.Vote)
+		if err := codec.DecodeProto(payload, v); err != nil {
+			return fmt.Errorf("%w: %v", ErrUndecodable, err)
+		}
+		return gadget.HandleVote(v)
+	}
+}
+
+func (g *Gossip) PublishTransaction(tx transaction.Transaction) error {
+	b, err := wrapper.WrapTransaction(tx)
+	if err != nil {
+		return err
+	}
+	return g.Publish(TopicTransactions, b)
+}
+
+func (g *Gossip) PublishBlock(blk block.Block) error {
+	b, err := wrapper.WrapBlock(blk)
+	if err != nil {
+		return err
+	}
+	return g.Publish(TopicBlocks, b)
+}
+
+func (g *Gossip) PublishCheckpointVote(v *finality.Vote) error {
+	b, err := codec.EncodeProto(v)
+	if err != nil {
+		return err
+	}
+	return g.Publish(TopicAttestations, b)
+}
+
+var _ finality.Broadcaster = (*Gossip)(nil)
+
+// BroadcastVote makes g the broadcaster of a finality gadget, whose votes
+// then reach validators and observers through every node relaying them.
+func (g *Gossip) BroadcastVote(v *finality.Vote) {
+	if err := g.PublishCheckpointVote(v); err != nil {
+		log.Printf("[Gossip] failed to publish %s: %v", v, err)
+	}
+}
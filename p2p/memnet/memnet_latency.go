@@ -0,0 +1,44 @@
+package memnet
+
+import (
+	"math/rand"
+	"time"
+)
+
+// LatencyDistribution draws the one-way delay of each message. Draws come from
+// the network's seeded RNG so runs reproduce.
+type LatencyDistribution interface {
+	Sample(rng *rand.Rand) time.Duration
+}
+
+type FixedLatency time.Duration
+
+func (l FixedLatency) Sample(_ *rand.Rand) time.Duration {
+	return time.Duration(l)
+}
+
+type UniformLatency struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (l UniformLatency) Sample(rng *rand.Rand) time.Duration {
+	if l.Max <= l.Min {
+		return l.Min
+	}
+	return l.Min + time.Duration(rng.Int63n(int64(l.Max-l.Min)+1))
+}
+
+// NormalLatency is clamped at zero.
+type NormalLatency struct {
+	Mean   time.Duration
+	StdDev time.Duration
+}
+
+func (l NormalLatency) Sample(rng *rand.Rand) time.Duration {
+	d := time.Duration(rng.NormFloat64()*float64(l.StdDev)) + l.Mean
+	if d < 0 {
+		return 0
+	}
+	return d
+}
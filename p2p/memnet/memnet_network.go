@@ -0,0 +1,393 @@
+package memnet
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/andantan/kangaroo/p2p"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const DefaultReorderDelay = 10 * time.Millisecond
+
+type Config struct {
+	Seed    int64
+	Latency LatencyDistribution
+
+	// probabilities in [0, 1] applied to every message independently
+	LossRate      float64
+	DuplicateRate float64
+	// ReorderRate is the chance a message is not kept behind earlier messages
+	// on the same link and is delayed by up to ReorderDelay more instead.
+	ReorderRate  float64
+	ReorderDelay time.Duration
+
+	Limits p2p.Limits
+}
+
+type Stats struct {
+	Sent       uint64
+	Delivered  uint64
+	Dropped    uint64
+	Duplicated uint64
+	Reordered  uint64
+}
+
+// Network is an in-process network of transports. Messages are delivered by
+// a single goroutine in delivery time order, so with the same seed and the
+// same sends every run makes the same loss, duplication and delay decisions.
+type Network struct {
+	lock      sync.Mutex
+	cfg       Config
+	rng       *rand.Rand
+	nodes     map[p2p.PeerID]*Transport
+	lastSent  map[[2]p2p.PeerID]time.Time
+	partition map[p2p.PeerID]int
+	queue     deliveries
+	seq       uint64
+	stats     Stats
+
+	wake   chan struct{}
+	closed chan struct{}
+	done   chan struct{}
+}
+
+func NewNetwork(cfg Config) *Network {
+	if cfg.Latency == nil {
+		cfg.Latency = FixedLatency(0)
+	}
+	if cfg.ReorderDelay <= 0 {
+		cfg.ReorderDelay = DefaultReorderDelay
+	}
+	if cfg.Limits.Default == 0 {
+		cfg.Limits.Default = p2p.DefaultMaxMessageSize
+	}
+
+	n := &Network{
+		cfg:      cfg,
+		rng:      rand.New(rand.NewSource(cfg.Seed)),
+		nodes:    make(map[p2p.PeerID]*Transport),
+		lastSent: make(map[[2]p2p.PeerID]time.Time),
+		wake:     make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go n.run()
+	return n
+}
+
+// Join adds a node with the given id and returns its transport. It starts
+// without peers.
+func (n *Network) Join(id p2p.PeerID) (*Transport, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if _, ok := n.nodes[id]; ok {
+		return nil, fmt.Errorf("peer %s already joined", id)
+	}
+
+	t := &Transport{
+		net:   n,
+		id:    id,
+		peers: make(map[p2p.PeerID]struct{}),
+	}
+	n.nodes[id] = t
+	return t, nil
+}
+
+// Connect links a and b both ways, unless a gate of either side refuses.
+func (n *Network) Connect(a, b p2p.PeerID) error {
+	n.lock.Lock()
+	ta, okA := n.nodes[a]
+	tb, okB := n.nodes[b]
+	if !okA || !okB || a == b {
+		n.lock.Unlock()
+		return fmt.Errorf("%w: cannot connect %s and %s", p2p.ErrUnknownPeer, a, b)
+	}
+	n.lock.Unlock()
+
+	if err := ta.Admit(b); err != nil {
+		return err
+	}
+	if err := tb.Admit(a); err != nil {
+		return err
+	}
+
+	n.lock.Lock()
+	if n.nodes[a] != ta || n.nodes[b] != tb {
+		n.lock.Unlock()
+		return fmt.Errorf("%w: %s or %s left", p2p.ErrUnknownPeer, a, b)
+	}
+	_, already := ta.peers[b]
+	ta.peers[b] = struct{}{}
+	tb.peers[a] = struct{}{}
+	n.lock.Unlock()
+
+	if !already {
+		ta.NotifyPeer(b, true)
+		tb.NotifyPeer(a, true)
+	}
+	return nil
+}
+
+// ConnectAll links every pair of joined nodes.
+func (n *Network) ConnectAll() {
+	n.lock.Lock()
+	ids := make([]p2p.PeerID, 0, len(n.nodes))
+	for id := range n.nodes {
+		ids = append(ids, id)
+	}
+	n.lock.Unlock()
+
+	sortPeerIDs(ids)
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			_ = n.Connect(ids[i], ids[j])
+		}
+	}
+}
+
+// Partition splits the network into the given groups. Messages between nodes
+// of different groups, including those already in flight, are lost until Heal.
+// Nodes not listed form one more group together.
+func (n *Network) Partition(groups ...[]p2p.PeerID) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.partition = make(map[p2p.PeerID]int)
+	for i, group := range groups {
+		for _, id := range group {
+			n.partition[id] = i + 1
+		}
+	}
+}
+
+func (n *Network) Heal() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.partition = nil
+}
+
+func (n *Network) Stats() Stats {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.stats
+}
+
+// Close stops delivery. Messages still in flight are dropped.
+func (n *Network) Close() {
+	select {
+	case <-n.closed:
+	default:
+		close(n.closed)
+	}
+	<-n.done
+}
+
+func (n *Network) reachable(from, to p2p.PeerID) bool {
+	if n.partition == nil {
+		return true
+	}
+	return n.partition[from] == n.partition[to]
+}
+
+func (n *Network) send(from, to p2p.PeerID, msg p2p.Message) error {
+	if err := n.cfg.Limits.Check(msg); err != nil {
+		return err
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	src, ok := n.nodes[from]
+	if !ok {
+		return p2p.ErrClosed
+	}
+	if _, ok = src.peers[to]; !ok {
+		return fmt.Errorf("%w: %s", p2p.ErrUnknownPeer, to)
+	}
+
+	n.stats.Sent++
+
+	if !n.reachable(from, to) || n.rng.Float64() < n.cfg.LossRate {
+		n.stats.Dropped++
+		return nil
+	}
+
+	copies := 1
+	if n.rng.Float64() < n.cfg.DuplicateRate {
+		copies = 2
+		n.stats.Duplicated++
+	}
+
+	payload := append([]byte(nil), msg.Payload...)
+	now := time.Now()
+	link := [2]p2p.PeerID{from, to}
+
+	for i := 0; i < copies; i++ {
+		at := now.Add(n.cfg.Latency.Sample(n.rng))
+
+		if n.rng.Float64() < n.cfg.ReorderRate {
+			at = at.Add(time.Duration(n.rng.Int63n(int64(n.cfg.ReorderDelay) + 1)))
+			n.stats.Reordered++
+		} else {
+			// links are FIFO unless the message was picked for reordering
+			if last := n.lastSent[link]; at.Before(last) {
+				at = last
+			}
+			n.lastSent[link] = at
+		}
+
+		n.seq++
+		heap.Push(&n.queue, &delivery{
+			at:   at,
+			seq:  n.seq,
+			from: from,
+			to:   to,
+			msg:  p2p.Message{Type: msg.Type, Payload: payload},
+		})
+	}
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (n *Network) disconnect(a, b p2p.PeerID) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	ta, okA := n.nodes[a]
+	if !okA {
+		return false
+	}
+	if _, ok := ta.peers[b]; !ok {
+		return false
+	}
+
+	delete(ta.peers, b)
+	if tb, ok := n.nodes[b]; ok {
+		delete(tb.peers, a)
+	}
+	return true
+}
+
+func (n *Network) leave(id p2p.PeerID) []p2p.PeerID {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	t, ok := n.nodes[id]
+	if !ok {
+		return nil
+	}
+
+	peers := make([]p2p.PeerID, 0, len(t.peers))
+	for peer := range t.peers {
+		peers = append(peers, peer)
+		if other, ok := n.nodes[peer]; ok {
+			delete(other.peers, id)
+		}
+	}
+	delete(n.nodes, id)
+
+	sortPeerIDs(peers)
+	return peers
+}
+
+func (n *Network) run() {
+	defer close(n.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		n.lock.Lock()
+		var (
+			next *delivery
+			wait = time.Hour
+		)
+		if n.queue.Len() > 0 {
+			if d := time.Until(n.queue[0].at); d <= 0 {
+				next = heap.Pop(&n.queue).(*delivery)
+			} else {
+				wait = d
+			}
+		}
+		n.lock.Unlock()
+
+		if next != nil {
+			n.deliver(next)
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-n.closed:
+			return
+		case <-n.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+func (n *Network) deliver(d *delivery) {
+	n.lock.Lock()
+	dst, ok := n.nodes[d.to]
+	if ok {
+		_, ok = dst.peers[d.from]
+	}
+	if ok && !n.reachable(d.from, d.to) {
+		ok = false
+	}
+	if ok {
+		n.stats.Delivered++
+	} else {
+		n.stats.Dropped++
+	}
+	n.lock.Unlock()
+
+	if ok {
+		dst.Dispatch(d.from, d.msg)
+	}
+}
+
+type delivery struct {
+	at   time.Time
+	seq  uint64
+	from p2p.PeerID
+	to   p2p.PeerID
+	msg  p2p.Message
+}
+
+type deliveries []*delivery
+
+func (q deliveries) Len() int { return len(q) }
+
+func (q deliveries) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+
+func (q deliveries) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *deliveries) Push(x any) { *q = append(*q, x.(*delivery)) }
+
+func (q *deliveries) Pop() any {
+	old := *q
+	d := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return d
+}
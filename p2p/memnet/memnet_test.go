@@ -0,0 +1,197 @@
+package memnet
+
+import (
+	"encoding/binary"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testType p2p.MessageType = 1
+
+type inbox struct {
+	lock     sync.Mutex
+	messages []uint64
+	from     []p2p.PeerID
+}
+
+func (in *inbox) handle(from p2p.PeerID, msg p2p.Message) {
+	in.lock.Lock()
+	defer in.lock.Unlock()
+	in.messages = append(in.messages, binary.BigEndian.Uint64(msg.Payload))
+	in.from = append(in.from, from)
+}
+
+func (in *inbox) received() []uint64 {
+	in.lock.Lock()
+	defer in.lock.Unlock()
+	return append([]uint64(nil), in.messages...)
+}
+
+func numbered(i uint64) p2p.Message {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, i)
+	return p2p.Message{Type: testType, Payload: payload}
+}
+
+func newPair(t *testing.T, cfg Config) (*Network, *Transport, *Transport, *inbox) {
+	n := NewNetwork(cfg)
+	t.Cleanup(n.Close)
+
+	a, err := n.Join("a")
+	require.NoError(t, err)
+	b, err := n.Join("b")
+	require.NoError(t, err)
+	require.NoError(t, n.Connect("a", "b"))
+
+	in := &inbox{}
+	b.Handle(testType, in.handle)
+	return n, a, b, in
+}
+
+// sendAll sends count numbered messages and waits until the network has
+// nothing left in flight.
+func sendAll(t *testing.T, n *Network, from *Transport, to p2p.PeerID, count uint64) {
+	for i := uint64(0); i < count; i++ {
+		require.NoError(t, from.Send(to, numbered(i)))
+	}
+	require.Eventually(t, func() bool {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		return n.queue.Len() == 0
+	}, 5*time.Second, time.Millisecond)
+	// the last delivery may still be inside its handler
+	time.Sleep(5 * time.Millisecond)
+}
+
+func TestNetwork_Delivery(t *testing.T) {
+	n, a, b, in := newPair(t, Config{Seed: 1, Latency: UniformLatency{Min: 0, Max: 3 * time.Millisecond}})
+
+	var events []string
+	var lock sync.Mutex
+	a.OnPeer(func(id p2p.PeerID, connected bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, string(id)+map[bool]string{true: "+", false: "-"}[connected])
+	})
+
+	sendAll(t, n, a, "b", 50)
+
+	// links are FIFO without reordering
+	expected := make([]uint64, 50)
+	for i := range expected {
+		expected[i] = uint64(i)
+	}
+	assert.Equal(t, expected, in.received())
+	assert.Equal(t, []p2p.PeerID{"a"}, b.Peers())
+
+	stats := n.Stats()
+	assert.Equal(t, uint64(50), stats.Sent)
+	assert.Equal(t, uint64(50), stats.Delivered)
+
+	t.Run("unknown peers and types", func(t *testing.T) {
+		assert.ErrorIs(t, a.Send("c", numbered(0)), p2p.ErrUnknownPeer)
+		require.NoError(t, b.Send("a", numbered(0))) // a has no handler, dropped silently
+	})
+
+	t.Run("size limits", func(t *testing.T) {
+		n, a, _, _ := newPair(t, Config{Limits: p2p.Limits{Default: 4, PerType: map[p2p.MessageType]uint32{2: 16}}})
+		defer n.Close()
+		assert.ErrorIs(t, a.Send("b", numbered(0)), p2p.ErrMessageTooLarge)
+		assert.NoError(t, a.Send("b", p2p.Message{Type: 2, Payload: make([]byte, 16)}))
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		require.NoError(t, b.Disconnect("a"))
+		assert.Empty(t, a.Peers())
+		assert.ErrorIs(t, a.Send("b", numbered(0)), p2p.ErrUnknownPeer)
+
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, []string{"b-"}, events)
+	})
+}
+
+func TestNetwork_Faults(t *testing.T) {
+	const count = 2000
+
+	t.Run("loss", func(t *testing.T) {
+		n, a, _, in := newPair(t, Config{Seed: 2, LossRate: 0.3})
+		sendAll(t, n, a, "b", count)
+
+		received := len(in.received())
+		assert.InDelta(t, count*0.7, received, count*0.05)
+		assert.Equal(t, uint64(count-received), n.Stats().Dropped)
+	})
+
+	t.Run("duplication", func(t *testing.T) {
+		n, a, _, in := newPair(t, Config{Seed: 3, DuplicateRate: 0.2})
+		sendAll(t, n, a, "b", count)
+
+		received := len(in.received())
+		assert.InDelta(t, count*1.2, received, count*0.05)
+		assert.Equal(t, uint64(received-count), n.Stats().Duplicated)
+	})
+
+	t.Run("reordering", func(t *testing.T) {
+		n, a, _, in := newPair(t, Config{Seed: 4, ReorderRate: 0.2, ReorderDelay: 2 * time.Millisecond})
+		sendAll(t, n, a, "b", 200)
+
+		received := in.received()
+		assert.Len(t, received, 200)
+		assert.False(t, sort.SliceIsSorted(received, func(i, j int) bool { return received[i] < received[j] }))
+	})
+}
+
+func TestNetwork_Reproducible(t *testing.T) {
+	run := func() []uint64 {
+		n, a, _, in := newPair(t, Config{Seed: 42, LossRate: 0.2, DuplicateRate: 0.1})
+		sendAll(t, n, a, "b", 500)
+		received := in.received()
+		sort.Slice(received, func(i, j int) bool { return received[i] < received[j] })
+		return received
+	}
+
+	assert.Equal(t, run(), run())
+}
+
+func TestNetwork_Partition(t *testing.T) {
+	n := NewNetwork(Config{Seed: 5})
+	defer n.Close()
+
+	inboxes := make(map[p2p.PeerID]*inbox)
+	transports := make(map[p2p.PeerID]*Transport)
+	for _, id := range []p2p.PeerID{"a", "b", "c", "d"} {
+		tr, err := n.Join(id)
+		require.NoError(t, err)
+		inboxes[id] = &inbox{}
+		tr.Handle(testType, inboxes[id].handle)
+		transports[id] = tr
+	}
+	n.ConnectAll()
+	assert.Len(t, transports["a"].Peers(), 3)
+
+	n.Partition([]p2p.PeerID{"a", "b"}, []p2p.PeerID{"c"})
+
+	transports["a"].Broadcast(numbered(1))
+	require.Eventually(t, func() bool { return len(inboxes["b"].received()) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	assert.Empty(t, inboxes["c"].received())
+	assert.Empty(t, inboxes["d"].received(), "unlisted nodes form their own group")
+
+	n.Heal()
+	transports["a"].Broadcast(numbered(2))
+	for _, id := range []p2p.PeerID{"b", "c", "d"} {
+		require.Eventually(t, func() bool { return len(inboxes[id].received()) >= 1 }, time.Second, time.Millisecond)
+	}
+
+	t.Run("close", func(t *testing.T) {
+		require.NoError(t, transports["d"].Close())
+		assert.Len(t, transports["a"].Peers(), 2)
+		assert.ErrorIs(t, transports["d"].Send("a", numbered(0)), p2p.ErrClosed)
+	})
+}
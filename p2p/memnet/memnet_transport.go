@@ -0,0 +1,79 @@
+package memnet
+
+import (
+	"github.com/andantan/kangaroo/p2p"
+	"sort"
+)
+
+// Transport is one node's view of a Network.
+type Transport struct {
+	p2p.Router
+
+	net   *Network
+	id    p2p.PeerID
+	peers map[p2p.PeerID]struct{} // guarded by net.lock
+}
+
+var _ p2p.Transport = (*Transport)(nil)
+var _ p2p.Gated = (*Transport)(nil)
+
+func (t *Transport) ID() p2p.PeerID {
+	return t.id
+}
+
+func (t *Transport) Peers() []p2p.PeerID {
+	t.net.lock.Lock()
+	defer t.net.lock.Unlock()
+
+	peers := make([]p2p.PeerID, 0, len(t.peers))
+	for id := range t.peers {
+		peers = append(peers, id)
+	}
+	sortPeerIDs(peers)
+	return peers
+}
+
+func (t *Transport) Send(to p2p.PeerID, msg p2p.Message) error {
+	return t.net.send(t.id, to, msg)
+}
+
+func (t *Transport) Broadcast(msg p2p.Message) {
+	for _, id := range t.Peers() {
+		_ = t.Send(id, msg)
+	}
+}
+
+func (t *Transport) Disconnect(id p2p.PeerID) error {
+	if !t.net.disconnect(t.id, id) {
+		return p2p.ErrUnknownPeer
+	}
+
+	t.NotifyPeer(id, false)
+
+	t.net.lock.Lock()
+	other, ok := t.net.nodes[id]
+	t.net.lock.Unlock()
+	if ok {
+		other.NotifyPeer(t.id, false)
+	}
+	return nil
+}
+
+// Close leaves the network; the node's peers see it disconnect.
+func (t *Transport) Close() error {
+	for _, id := range t.net.leave(t.id) {
+		t.NotifyPeer(id, false)
+
+		t.net.lock.Lock()
+		other, ok := t.net.nodes[id]
+		t.net.lock.Unlock()
+		if ok {
+			other.NotifyPeer(t.id, false)
+		}
+	}
+	return nil
+}
+
+func sortPeerIDs(ids []p2p.PeerID) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
@@ -0,0 +1,71 @@
+package noise
+
+import (
+	"net"
+	"sync"
+)
+
+// maxPlaintextLength keeps every encrypted record within a Noise message.
+const maxPlaintextLength = maxMessageLength - tagLength
+
+// Conn encrypts everything written to the underlying connection and decrypts
+// everything read from it, one Noise transport message per record.
+type Conn struct {
+	net.Conn
+
+	readLock sync.Mutex
+	recv     *cipherState
+	pending  []byte
+
+	writeLock sync.Mutex
+	send      *cipherState
+}
+
+var _ net.Conn = (*Conn)(nil)
+
+func newConn(conn net.Conn, send, recv *cipherState) *Conn {
+	return &Conn{Conn: conn, send: send, recv: recv}
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	for len(c.pending) == 0 {
+		msg, err := readMessage(c.Conn)
+		if err != nil {
+			return 0, err
+		}
+
+		if c.pending, err = c.recv.decrypt(nil, msg); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *Conn) Write(b []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	written := 0
+	for written < len(b) {
+		chunk := b[written:]
+		if len(chunk) > maxPlaintextLength {
+			chunk = chunk[:maxPlaintextLength]
+		}
+
+		msg, err := c.send.encrypt(nil, chunk)
+		if err != nil {
+			return written, err
+		}
+		if err = writeMessage(c.Conn, msg); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+	}
+	return written, nil
+}
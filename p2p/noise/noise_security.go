@@ -0,0 +1,275 @@
+package noise
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	kangaroop2ppb "github.com/andantan/kangaroo/proto/p2p/pb"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+)
+
+const (
+	staticKeyDomain = "kangaroo-noise-static-key"
+	prologue        = "kangaroo-p2p"
+
+	maxMessageLength = 65535
+)
+
+var ErrHandshake = errors.New("noise handshake failed")
+
+// Security runs Noise XX handshakes for one node. Its X25519 static key is
+// generated per instance and bound to the node's identity key by a signature
+// that travels, encrypted, inside the handshake.
+type Security struct {
+	deriver hash.HashDeriver
+	static  *keypair
+	payload []byte
+}
+
+func NewSecurity(identity key.PrivateKey, deriver hash.HashDeriver) (*Security, error) {
+	static, err := generateKeypair(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := identity.Sign(staticKeyDigest(deriver, static.public).Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign static key: %w", err)
+	}
+
+	pubKeyBytes, err := wrapper.WrapPublicKey(identity.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+
+	sigBytes, err := wrapper.WrapSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := proto.Marshal(&kangaroop2ppb.KangarooNoisePayload{
+		PublicKey: pubKeyBytes,
+		Signature: sigBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Security{deriver: deriver, static: static, payload: payload}, nil
+}
+
+func staticKeyDigest(deriver hash.HashDeriver, static []byte) hash.Hash {
+	data := make([]byte, 0, len(staticKeyDomain)+len(static))
+	data = append(data, staticKeyDomain...)
+	data = append(data, static...)
+	return deriver.Derive(data)
+}
+
+// Secure runs the handshake over conn and returns the encrypted connection
+// together with the identity key the peer bound its static key to.
+//
+//	-> e
+//	<- e, ee, s, es, payload
+//	-> s, se, payload
+func (s *Security) Secure(conn net.Conn, initiator bool) (net.Conn, key.PublicKey, error) {
+	ss := newSymmetricState([]byte(prologue))
+
+	ephemeral, err := generateKeypair(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		remoteEphemeral []byte
+		remoteStatic    []byte
+		remotePayload   []byte
+	)
+
+	if initiator {
+		// -> e
+		ss.mixHash(ephemeral.public)
+		msg, err := ss.encryptAndHash(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = writeMessage(conn, append(append([]byte(nil), ephemeral.public...), msg...)); err != nil {
+			return nil, nil, err
+		}
+
+		// <- e, ee, s, es, payload
+		msg, err = readMessage(conn)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(msg) < dhLength+dhLength+tagLength {
+			return nil, nil, fmt.Errorf("%w: short message", ErrHandshake)
+		}
+
+		remoteEphemeral, msg = msg[:dhLength], msg[dhLength:]
+		ss.mixHash(remoteEphemeral)
+		if err = s.mixDH(ss, ephemeral, remoteEphemeral); err != nil {
+			return nil, nil, err
+		}
+
+		if remoteStatic, err = ss.decryptAndHash(msg[:dhLength+tagLength]); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+		}
+		if err = s.mixDH(ss, ephemeral, remoteStatic); err != nil {
+			return nil, nil, err
+		}
+
+		if remotePayload, err = ss.decryptAndHash(msg[dhLength+tagLength:]); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+		}
+
+		// -> s, se, payload
+		encStatic, err := ss.encryptAndHash(s.static.public)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = s.mixDH(ss, s.static, remoteEphemeral); err != nil {
+			return nil, nil, err
+		}
+		encPayload, err := ss.encryptAndHash(s.payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = writeMessage(conn, append(encStatic, encPayload...)); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		// -> e
+		msg, err := readMessage(conn)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(msg) < dhLength {
+			return nil, nil, fmt.Errorf("%w: short message", ErrHandshake)
+		}
+
+		remoteEphemeral = msg[:dhLength]
+		ss.mixHash(remoteEphemeral)
+		if _, err = ss.decryptAndHash(msg[dhLength:]); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+		}
+
+		// <- e, ee, s, es, payload
+		ss.mixHash(ephemeral.public)
+		if err = s.mixDH(ss, ephemeral, remoteEphemeral); err != nil {
+			return nil, nil, err
+		}
+		encStatic, err := ss.encryptAndHash(s.static.public)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = s.mixDH(ss, s.static, remoteEphemeral); err != nil {
+			return nil, nil, err
+		}
+		encPayload, err := ss.encryptAndHash(s.payload)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		out := append(append([]byte(nil), ephemeral.public...), encStatic...)
+		if err = writeMessage(conn, append(out, encPayload...)); err != nil {
+			return nil, nil, err
+		}
+
+		// -> s, se, payload
+		if msg, err = readMessage(conn); err != nil {
+			return nil, nil, err
+		}
+		if len(msg) < dhLength+tagLength+tagLength {
+			return nil, nil, fmt.Errorf("%w: short message", ErrHandshake)
+		}
+
+		if remoteStatic, err = ss.decryptAndHash(msg[:dhLength+tagLength]); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+		}
+		if err = s.mixDH(ss, ephemeral, remoteStatic); err != nil {
+			return nil, nil, err
+		}
+		if remotePayload, err = ss.decryptAndHash(msg[dhLength+tagLength:]); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+		}
+	}
+
+	identity, err := s.verifyPayload(remoteStatic, remotePayload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c1, c2, err := ss.split()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if initiator {
+		return newConn(conn, c1, c2), identity, nil
+	}
+	return newConn(conn, c2, c1), identity, nil
+}
+
+func (s *Security) mixDH(ss *symmetricState, local *keypair, remote []byte) error {
+	shared, err := dh(local, remote)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+	return ss.mixKey(shared)
+}
+
+// verifyPayload checks that the peer's identity key signed its static key.
+func (s *Security) verifyPayload(remoteStatic, payload []byte) (key.PublicKey, error) {
+	pb := new(kangaroop2ppb.KangarooNoisePayload)
+	if err := proto.Unmarshal(payload, pb); err != nil {
+		return nil, fmt.Errorf("%w: malformed payload: %w", ErrHandshake, err)
+	}
+
+	identity, err := wrapper.UnwrapPublicKey(pb.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+
+	sig, err := wrapper.UnwrapSignature(pb.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+
+	if !sig.Verify(identity, staticKeyDigest(s.deriver, remoteStatic).Bytes()) {
+		return nil, fmt.Errorf("%w: static key is not bound to the identity key", ErrHandshake)
+	}
+
+	return identity, nil
+}
+
+func writeMessage(w io.Writer, msg []byte) error {
+	if len(msg) > maxMessageLength {
+		return fmt.Errorf("noise message of %d bytes exceeds %d", len(msg), maxMessageLength)
+	}
+
+	frame := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(frame, uint16(len(msg)))
+	copy(frame[2:], msg)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+func readMessage(r io.Reader) ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, binary.BigEndian.Uint16(header[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,174 @@
+package noise
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"io"
+)
+
+// ProtocolName fixes the pattern and primitives of the handshake.
+const ProtocolName = "Noise_XX_25519_ChaChaPoly_SHA256"
+
+const (
+	dhLength   = curve25519.PointSize
+	hashLength = sha256.Size
+	tagLength  = chacha20poly1305.Overhead
+)
+
+var errNonceExhausted = errors.New("cipher nonce exhausted")
+
+// cipherState is the Noise CipherState: a key and a counter nonce.
+type cipherState struct {
+	aead  cipher.AEAD
+	nonce uint64
+}
+
+func newCipherState(k []byte) (*cipherState, error) {
+	aead, err := chacha20poly1305.New(k)
+	if err != nil {
+		return nil, err
+	}
+	return &cipherState{aead: aead}, nil
+}
+
+func (c *cipherState) nextNonce() ([]byte, error) {
+	if c.nonce == ^uint64(0) {
+		return nil, errNonceExhausted
+	}
+
+	// 32 bits of zeros followed by the little endian counter
+	n := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(n[4:], c.nonce)
+	c.nonce++
+	return n, nil
+}
+
+func (c *cipherState) encrypt(ad, plaintext []byte) ([]byte, error) {
+	n, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nil, n, plaintext, ad), nil
+}
+
+func (c *cipherState) decrypt(ad, ciphertext []byte) ([]byte, error) {
+	n, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return c.aead.Open(nil, n, ciphertext, ad)
+}
+
+// symmetricState is the Noise SymmetricState: chaining key, handshake hash
+// and the cipher keyed by the latest MixKey.
+type symmetricState struct {
+	ck     [hashLength]byte
+	h      [hashLength]byte
+	cipher *cipherState
+}
+
+func newSymmetricState(prologue []byte) *symmetricState {
+	s := new(symmetricState)
+	// the protocol name is exactly hashLength bytes, so it is used as is
+	copy(s.h[:], ProtocolName)
+	s.ck = s.h
+	s.mixHash(prologue)
+	return s
+}
+
+func (s *symmetricState) mixHash(data []byte) {
+	d := sha256.New()
+	d.Write(s.h[:])
+	d.Write(data)
+	copy(s.h[:], d.Sum(nil))
+}
+
+func (s *symmetricState) mixKey(ikm []byte) error {
+	ck, k, err := hkdf2(s.ck[:], ikm)
+	if err != nil {
+		return err
+	}
+
+	copy(s.ck[:], ck)
+	s.cipher, err = newCipherState(k)
+	return err
+}
+
+func (s *symmetricState) encryptAndHash(plaintext []byte) ([]byte, error) {
+	out := plaintext
+	if s.cipher != nil {
+		var err error
+		if out, err = s.cipher.encrypt(s.h[:], plaintext); err != nil {
+			return nil, err
+		}
+	}
+	s.mixHash(out)
+	return out, nil
+}
+
+func (s *symmetricState) decryptAndHash(ciphertext []byte) ([]byte, error) {
+	out := ciphertext
+	if s.cipher != nil {
+		var err error
+		if out, err = s.cipher.decrypt(s.h[:], ciphertext); err != nil {
+			return nil, err
+		}
+	}
+	s.mixHash(ciphertext)
+	return out, nil
+}
+
+// split derives the two transport ciphers, initiator to responder first.
+func (s *symmetricState) split() (*cipherState, *cipherState, error) {
+	k1, k2, err := hkdf2(s.ck[:], nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c1, err := newCipherState(k1)
+	if err != nil {
+		return nil, nil, err
+	}
+	c2, err := newCipherState(k2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c1, c2, nil
+}
+
+// hkdf2 is Noise's HKDF with two outputs, which is RFC 5869 with the chaining
+// key as salt and no info.
+func hkdf2(ck, ikm []byte) ([]byte, []byte, error) {
+	out := make([]byte, 2*hashLength)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, ck, nil), out); err != nil {
+		return nil, nil, err
+	}
+	return out[:hashLength], out[hashLength:], nil
+}
+
+type keypair struct {
+	private []byte
+	public  []byte
+}
+
+func generateKeypair(rng io.Reader) (*keypair, error) {
+	private := make([]byte, curve25519.ScalarSize)
+	if _, err := io.ReadFull(rng, private); err != nil {
+		return nil, err
+	}
+
+	public, err := curve25519.X25519(private, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	return &keypair{private: private, public: public}, nil
+}
+
+func dh(kp *keypair, remote []byte) ([]byte, error) {
+	return curve25519.X25519(kp.private, remote)
+}
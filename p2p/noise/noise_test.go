@@ -0,0 +1,209 @@
+package noise
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/tcpnet"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newSecurity(t *testing.T, keyType string) (*Security, key.PrivateKey) {
+	keySuite, err := registry.GetKeySuite(keyType)
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+
+	identity, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	s, err := NewSecurity(identity, hashSuite.Deriver())
+	require.NoError(t, err)
+	return s, identity
+}
+
+type result struct {
+	conn     net.Conn
+	identity key.PublicKey
+	err      error
+}
+
+// secure runs both ends of a handshake over the given connections.
+func secure(initiator, responder *Security, a, b net.Conn) (result, result) {
+	done := make(chan result, 1)
+	go func() {
+		conn, identity, err := responder.Secure(b, false)
+		done <- result{conn, identity, err}
+	}()
+
+	conn, identity, err := initiator.Secure(a, true)
+	if err != nil {
+		_ = a.Close()
+	}
+	r := <-done
+	if r.err != nil {
+		_ = b.Close()
+	}
+	return result{conn, identity, err}, r
+}
+
+func TestSecurity_Handshake(t *testing.T) {
+	for _, keyType := range []string{"ecdsa-secp256k1", "eddsa-ed25519", "schnorr-sr25519"} {
+		t.Run(keyType, func(t *testing.T) {
+			initiator, initiatorKey := newSecurity(t, keyType)
+			responder, responderKey := newSecurity(t, "ecdsa-secp256r1")
+
+			a, b := net.Pipe()
+			defer a.Close()
+			defer b.Close()
+
+			ri, rr := secure(initiator, responder, a, b)
+			require.NoError(t, ri.err)
+			require.NoError(t, rr.err)
+
+			assert.True(t, ri.identity.Equal(responderKey.PublicKey()))
+			assert.True(t, rr.identity.Equal(initiatorKey.PublicKey()))
+
+			// larger than one Noise message, both directions at once
+			large := make([]byte, 3*maxPlaintextLength+17)
+			_, err := rand.Read(large)
+			require.NoError(t, err)
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := ri.conn.Write(large)
+				assert.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				_, err := rr.conn.Write([]byte("hello"))
+				assert.NoError(t, err)
+			}()
+
+			received := make([]byte, len(large))
+			_, err = io.ReadFull(rr.conn, received)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(large, received))
+
+			hello := make([]byte, 5)
+			_, err = io.ReadFull(ri.conn, hello)
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(hello))
+
+			wg.Wait()
+		})
+	}
+}
+
+func TestSecurity_Rejections(t *testing.T) {
+	t.Run("static key not bound to identity", func(t *testing.T) {
+		initiator, _ := newSecurity(t, "eddsa-ed25519")
+		responder, _ := newSecurity(t, "eddsa-ed25519")
+		other, _ := newSecurity(t, "eddsa-ed25519")
+
+		// a signature made for someone else's static key
+		initiator.payload = other.payload
+
+		a, b := net.Pipe()
+		defer a.Close()
+		defer b.Close()
+
+		_, rr := secure(initiator, responder, a, b)
+		assert.ErrorIs(t, rr.err, ErrHandshake)
+	})
+
+	t.Run("tampered transport message", func(t *testing.T) {
+		initiator, _ := newSecurity(t, "ecdsa-secp256k1")
+		responder, _ := newSecurity(t, "ecdsa-secp256k1")
+
+		a, b := net.Pipe()
+		defer a.Close()
+		defer b.Close()
+
+		ri, rr := secure(initiator, responder, a, b)
+		require.NoError(t, ri.err)
+		require.NoError(t, rr.err)
+
+		go func() {
+			msg, _ := ri.conn.(*Conn).send.encrypt(nil, []byte("payload"))
+			msg[0] ^= 0xff
+			_ = writeMessage(a, msg)
+		}()
+
+		_, err := rr.conn.Read(make([]byte, 16))
+		assert.Error(t, err)
+	})
+}
+
+func TestSecurity_Transport(t *testing.T) {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	newNode := func(keyType string) (*tcpnet.Transport, *Security) {
+		security, identity := newSecurity(t, keyType)
+		tr, err := tcpnet.NewTransport(tcpnet.Config{
+			ChainID:        "kangaroo-test",
+			PrivateKey:     identity,
+			HashDeriver:    hashSuite.Deriver(),
+			AddressDeriver: addressSuite.Deriver(),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = tr.Close() })
+		return tr, security
+	}
+
+	a, securityA := newNode("eddsa-ed448")
+	b, securityB := newNode("ecdsa-secp256k1")
+
+	encrypted, err := b.Listen("127.0.0.1:0", securityB)
+	require.NoError(t, err)
+	plain, err := b.Listen("127.0.0.1:0", nil)
+	require.NoError(t, err)
+
+	received := make(chan string, 1)
+	b.Handle(1, func(_ p2p.PeerID, msg p2p.Message) { received <- string(msg.Payload) })
+
+	_, err = a.Dial(context.Background(), encrypted.String(), nil)
+	assert.Error(t, err, "plaintext dial to an encrypted listener")
+
+	id, err := a.Dial(context.Background(), encrypted.String(), securityA)
+	require.NoError(t, err)
+	assert.Equal(t, b.ID(), id)
+
+	require.NoError(t, a.Send(b.ID(), p2p.Message{Type: 1, Payload: []byte("secret")}))
+	select {
+	case msg := <-received:
+		assert.Equal(t, "secret", msg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not delivered")
+	}
+
+	t.Run("session bound to another identity", func(t *testing.T) {
+		c, _ := newNode("eddsa-ed25519")
+		// c's transport identity does not match the session's identity, which
+		// only b can notice; it drops the connection
+		_, _ = c.Dial(context.Background(), encrypted.String(), securityA)
+		require.Eventually(t, func() bool { return len(c.Peers()) == 0 }, time.Second, time.Millisecond)
+		assert.NotContains(t, b.Peers(), c.ID())
+	})
+
+	require.NoError(t, a.Disconnect(b.ID()))
+	require.Eventually(t, func() bool { return len(b.Peers()) == 0 }, time.Second, time.Millisecond)
+
+	_, err = a.Dial(context.Background(), plain.String(), nil)
+	require.NoError(t, err, "listeners choose their security independently")
+}
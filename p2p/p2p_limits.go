@@ -0,0 +1,35 @@
+package p2p
+
+import (
+	"fmt"
+)
+
+const DefaultMaxMessageSize = 4 << 20
+
+// Limits caps payload sizes, per message type where configured and Default
+// otherwise.
+type Limits struct {
+	Default uint32
+	PerType map[MessageType]uint32
+}
+
+func DefaultLimits() Limits {
+	return Limits{Default: DefaultMaxMessageSize}
+}
+
+func (l Limits) Max(t MessageType) uint32 {
+	if max, ok := l.PerType[t]; ok {
+		return max
+	}
+	if l.Default == 0 {
+		return DefaultMaxMessageSize
+	}
+	return l.Default
+}
+
+func (l Limits) Check(msg Message) error {
+	if max := l.Max(msg.Type); uint64(len(msg.Payload)) > uint64(max) {
+		return fmt.Errorf("%w: type %d carries %d bytes, limit %d", ErrMessageTooLarge, msg.Type, len(msg.Payload), max)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package p2p
+
+// Message types of the protocols shipped with the node. Each protocol owns a
+// contiguous block so new types do not shift existing ones.
+const (
+	MessageGossip MessageType = 0x10 + iota
+	MessageGossipAnnounce
+	MessageGossipRequest
+)
+
+const (
+	MessageSyncStatus MessageType = 0x20 + iota
+	MessageSyncHeadersRequest
+	MessageSyncHeadersResponse
+	MessageSyncBodiesRequest
+	MessageSyncBodiesResponse
+)
+
+const (
+	MessageCompactBlock MessageType = 0x30 + iota
+	MessageCompactGetTransactions
+	MessageCompactTransactions
+	MessageCompactGetBlock
+	MessageCompactFullBlock
+)
+
+const (
+	MessageSnapshotsRequest MessageType = 0x40 + iota
+	MessageSnapshotsResponse
+	MessageSnapshotChunkRequest
+	MessageSnapshotChunkResponse
+)
+
+const (
+	MessageBFTProposal MessageType = 0x50 + iota
+	MessageBFTVote
+)
+
+const (
+	MessageFinalityVote MessageType = 0x60 + iota
+)
@@ -0,0 +1,73 @@
+package p2p
+
+import (
+	"sync"
+)
+
+// Router keeps the handlers registered on a transport and dispatches to them.
+// Transports embed it to implement Handle, OnPeer and Gate.
+type Router struct {
+	lock         sync.RWMutex
+	handlers     map[MessageType]Handler
+	peerHandlers []PeerHandler
+	gates        []PeerGate
+}
+
+func (r *Router) Handle(t MessageType, h Handler) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.handlers == nil {
+		r.handlers = make(map[MessageType]Handler)
+	}
+	r.handlers[t] = h
+}
+
+func (r *Router) OnPeer(h PeerHandler) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.peerHandlers = append(r.peerHandlers, h)
+}
+
+func (r *Router) Gate(g PeerGate) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.gates = append(r.gates, g)
+}
+
+// Admit runs the gates for a connecting peer and returns the first refusal.
+func (r *Router) Admit(id PeerID) error {
+	r.lock.RLock()
+	gates := append([]PeerGate(nil), r.gates...)
+	r.lock.RUnlock()
+
+	for _, g := range gates {
+		if err := g(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dispatch hands msg to the handler of its type and reports whether there was one.
+func (r *Router) Dispatch(from PeerID, msg Message) bool {
+	r.lock.RLock()
+	h, ok := r.handlers[msg.Type]
+	r.lock.RUnlock()
+
+	if !ok {
+		return false
+	}
+	h(from, msg)
+	return true
+}
+
+func (r *Router) NotifyPeer(id PeerID, connected bool) {
+	r.lock.RLock()
+	handlers := append([]PeerHandler(nil), r.peerHandlers...)
+	r.lock.RUnlock()
+
+	for _, h := range handlers {
+		h(id, connected)
+	}
+}
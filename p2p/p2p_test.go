@@ -0,0 +1,50 @@
+package p2p
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	limits := Limits{Default: 8, PerType: map[MessageType]uint32{2: 16}}
+
+	assert.NoError(t, limits.Check(Message{Type: 1, Payload: make([]byte, 8)}))
+	assert.ErrorIs(t, limits.Check(Message{Type: 1, Payload: make([]byte, 9)}), ErrMessageTooLarge)
+	assert.NoError(t, limits.Check(Message{Type: 2, Payload: make([]byte, 16)}))
+	assert.ErrorIs(t, limits.Check(Message{Type: 2, Payload: make([]byte, 17)}), ErrMessageTooLarge)
+
+	assert.Equal(t, uint32(DefaultMaxMessageSize), Limits{}.Max(1))
+}
+
+func TestRouter(t *testing.T) {
+	var r Router
+
+	var got []Message
+	r.Handle(1, func(from PeerID, msg Message) {
+		assert.Equal(t, PeerID("peer"), from)
+		got = append(got, msg)
+	})
+
+	var events []bool
+	r.OnPeer(func(id PeerID, connected bool) { events = append(events, connected) })
+
+	assert.True(t, r.Dispatch("peer", Message{Type: 1, Payload: []byte{1}}))
+	assert.False(t, r.Dispatch("peer", Message{Type: 2}))
+	assert.Len(t, got, 1)
+
+	r.NotifyPeer("peer", true)
+	r.NotifyPeer("peer", false)
+	assert.Equal(t, []bool{true, false}, events)
+
+	assert.NoError(t, r.Admit("peer"))
+	refused := errors.New("refused")
+	r.Gate(func(id PeerID) error {
+		if id == "banned" {
+			return refused
+		}
+		return nil
+	})
+	assert.NoError(t, r.Admit("peer"))
+	assert.ErrorIs(t, r.Admit("banned"), refused)
+}
@@ -0,0 +1,73 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PeerID identifies a node on the network. The TCP transport derives it from
+// the node key's address; in-process networks may use any unique string.
+type PeerID string
+
+func (id PeerID) ShortString(n int) string {
+	if len(id) <= n {
+		return string(id)
+	}
+	return string(id[:n])
+}
+
+// MessageType tells the receiving side which protocol a payload belongs to.
+// Protocols built on the transport (consensus, gossip, sync) each reserve
+// their own types.
+type MessageType uint32
+
+// Message is one unit of delivery: the type and the protobuf encoded payload.
+type Message struct {
+	Type    MessageType
+	Payload []byte
+}
+
+func (m Message) String() string {
+	return fmt.Sprintf("Message{Type: %d, Size: %d}", m.Type, len(m.Payload))
+}
+
+type Handler func(from PeerID, msg Message)
+
+// PeerHandler is told when a peer connects or disconnects.
+type PeerHandler func(id PeerID, connected bool)
+
+// PeerGate decides whether a peer may connect; a non-nil error refuses it
+// before it is registered or announced to peer handlers.
+type PeerGate func(id PeerID) error
+
+var (
+	ErrUnknownPeer     = errors.New("unknown peer")
+	ErrClosed          = errors.New("transport closed")
+	ErrMessageTooLarge = errors.New("message too large")
+)
+
+// Transport is what consensus, gossip and sync code talk to. Delivery is best
+// effort and messages may arrive late, twice, out of order or not at all;
+// protocols on top must cope.
+//
+// Handlers are called from the transport's own goroutines and must not block
+// for long. Messages of a type without a handler are dropped.
+type Transport interface {
+	ID() PeerID
+	Peers() []PeerID
+
+	Send(to PeerID, msg Message) error
+	Broadcast(msg Message)
+
+	Handle(t MessageType, h Handler)
+	OnPeer(h PeerHandler)
+
+	Disconnect(id PeerID) error
+	Close() error
+}
+
+// Gated is implemented by transports that consult gates before accepting a
+// peer.
+type Gated interface {
+	Gate(g PeerGate)
+}
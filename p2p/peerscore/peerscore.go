@@ -0,0 +1,243 @@
+package peerscore
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/gossip"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultHalfLife            = 10 * time.Minute
+	DefaultMaxScore            = 100
+	DefaultDisconnectThreshold = -50
+	DefaultBanThreshold        = -100
+	DefaultBanDuration         = time.Hour
+)
+
+// Penalties for the misbehaviour the scorer sees itself or is told about.
+const (
+	PenaltyInvalid     = -10
+	PenaltyUndecodable = -25
+	PenaltyRateLimited = -2
+)
+
+// ErrBanned refuses connections from banned peers.
+var ErrBanned = errors.New("peer is banned")
+
+type Config struct {
+	// HalfLife is how long it takes a score to decay halfway back to zero.
+	HalfLife time.Duration
+	MaxScore float64
+	// A peer whose score drops to DisconnectThreshold is disconnected; one
+	// that drops to BanThreshold is also banned for BanDuration.
+	DisconnectThreshold float64
+	BanThreshold        float64
+	BanDuration         time.Duration
+	// RateLimits holds the token bucket of each limited message type; other
+	// types are not limited.
+	RateLimits map[p2p.MessageType]RateLimit
+	// Bans is where bans are kept; an in-memory list if nil.
+	Bans *BanList
+	Now  func() time.Time
+}
+
+type peerState struct {
+	score   float64
+	updated time.Time
+	buckets map[p2p.MessageType]*bucket
+}
+
+// Scorer guards a transport: handlers registered through it only see
+// messages from peers that are neither banned nor over their rate limit, and
+// peers whose score drops too far are disconnected or banned.
+type Scorer struct {
+	p2p.Transport
+
+	cfg Config
+
+	lock  sync.Mutex
+	peers map[p2p.PeerID]*peerState
+}
+
+func NewScorer(cfg Config, transport p2p.Transport) *Scorer {
+	if cfg.HalfLife <= 0 {
+		cfg.HalfLife = DefaultHalfLife
+	}
+	if cfg.MaxScore <= 0 {
+		cfg.MaxScore = DefaultMaxScore
+	}
+	if cfg.DisconnectThreshold >= 0 {
+		cfg.DisconnectThreshold = DefaultDisconnectThreshold
+	}
+	if cfg.BanThreshold >= 0 {
+		cfg.BanThreshold = DefaultBanThreshold
+	}
+	if cfg.BanDuration <= 0 {
+		cfg.BanDuration = DefaultBanDuration
+	}
+	if cfg.Bans == nil {
+		cfg.Bans, _ = OpenBanList("")
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	s := &Scorer{
+		Transport: transport,
+		cfg:       cfg,
+		peers:     make(map[p2p.PeerID]*peerState),
+	}
+
+	if gated, ok := transport.(p2p.Gated); ok {
+		gated.Gate(s.admit)
+	}
+	transport.OnPeer(s.handlePeer)
+	return s
+}
+
+// Handle registers h behind the ban check and the rate limit of t.
+func (s *Scorer) Handle(t p2p.MessageType, h p2p.Handler) {
+	s.Transport.Handle(t, func(from p2p.PeerID, msg p2p.Message) {
+		if s.IsBanned(from) {
+			_ = s.Transport.Disconnect(from)
+			return
+		}
+		if !s.allow(from, t) {
+			s.Penalize(from, PenaltyRateLimited, "rate limited")
+			return
+		}
+		h(from, msg)
+	})
+}
+
+func (s *Scorer) Score(id p2p.PeerID) float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.decayed(id, s.cfg.Now()).score
+}
+
+func (s *Scorer) IsBanned(id p2p.PeerID) bool {
+	_, banned := s.cfg.Bans.BannedUntil(id, s.cfg.Now())
+	return banned
+}
+
+// Reward raises the score of a peer for useful work, up to MaxScore.
+func (s *Scorer) Reward(id p2p.PeerID, amount float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	st := s.decayed(id, s.cfg.Now())
+	st.score = math.Min(st.score+math.Abs(amount), s.cfg.MaxScore)
+}
+
+// Penalize lowers the score of a peer and disconnects or bans it once the
+// score crosses a threshold. A ban settles the score: the peer starts from
+// zero when the ban ends instead of being banned again on its first offence.
+func (s *Scorer) Penalize(id p2p.PeerID, amount float64, reason string) {
+	now := s.cfg.Now()
+
+	s.lock.Lock()
+	st := s.decayed(id, now)
+	st.score -= math.Abs(amount)
+	score := st.score
+	if score <= s.cfg.BanThreshold {
+		st.score = 0
+	}
+	s.lock.Unlock()
+
+	switch {
+	case score <= s.cfg.BanThreshold:
+		log.Printf("[P2P] banning %s for %s (score %.1f): %s", id.ShortString(8), s.cfg.BanDuration, score, reason)
+		if err := s.cfg.Bans.Ban(id, now.Add(s.cfg.BanDuration)); err != nil {
+			log.Printf("[P2P] failed to persist ban of %s: %v", id.ShortString(8), err)
+		}
+		_ = s.Transport.Disconnect(id)
+	case score <= s.cfg.DisconnectThreshold:
+		log.Printf("[P2P] disconnecting %s (score %.1f): %s", id.ShortString(8), score, reason)
+		_ = s.Transport.Disconnect(id)
+	}
+}
+
+// GossipInvalid penalizes peers that gossiped invalid payloads; it has the
+// signature of gossip.Config.OnInvalid.
+func (s *Scorer) GossipInvalid(from p2p.PeerID, topic gossip.Topic, err error) {
+	penalty := PenaltyInvalid
+	if errors.Is(err, gossip.ErrUndecodable) {
+		penalty = PenaltyUndecodable
+	}
+	s.Penalize(from, float64(penalty), topic.String()+": "+err.Error())
+}
+
+// Invalid penalizes peers that sent invalid messages; it has the signature of
+// the OnInvalid hooks of blocksync and compact.
+func (s *Scorer) Invalid(from p2p.PeerID, err error) {
+	s.Penalize(from, PenaltyInvalid, err.Error())
+}
+
+// decayed returns the state of id with its score decayed to now. The caller
+// holds the lock.
+func (s *Scorer) decayed(id p2p.PeerID, now time.Time) *peerState {
+	st, ok := s.peers[id]
+	if !ok {
+		st = &peerState{updated: now, buckets: make(map[p2p.MessageType]*bucket)}
+		s.peers[id] = st
+		return st
+	}
+
+	if elapsed := now.Sub(st.updated); elapsed > 0 {
+		st.score *= math.Pow(0.5, float64(elapsed)/float64(s.cfg.HalfLife))
+		st.updated = now
+	}
+	return st
+}
+
+func (s *Scorer) allow(id p2p.PeerID, t p2p.MessageType) bool {
+	limit, ok := s.cfg.RateLimits[t]
+	if !ok {
+		return true
+	}
+
+	now := s.cfg.Now()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	st := s.decayed(id, now)
+	b, ok := st.buckets[t]
+	if !ok {
+		b = newBucket(limit, now)
+		st.buckets[t] = b
+	}
+	return b.take(limit, now)
+}
+
+// admit refuses banned peers before the transport registers them.
+func (s *Scorer) admit(id p2p.PeerID) error {
+	if until, banned := s.cfg.Bans.BannedUntil(id, s.cfg.Now()); banned {
+		return fmt.Errorf("%w: %s until %s", ErrBanned, id.ShortString(8), until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (s *Scorer) handlePeer(id p2p.PeerID, connected bool) {
+	if !connected {
+		// the score outlives the connection so reconnecting does not reset it
+		s.lock.Lock()
+		if st, ok := s.peers[id]; ok {
+			st.buckets = make(map[p2p.MessageType]*bucket)
+		}
+		s.lock.Unlock()
+		return
+	}
+
+	// transports without gates register the peer first
+	if err := s.admit(id); err != nil {
+		log.Printf("[P2P] refusing %v", err)
+		_ = s.Transport.Disconnect(id)
+	}
+}
@@ -0,0 +1,121 @@
+package peerscore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/types/atomicfile"
+	"os"
+	"sync"
+	"time"
+)
+
+// BanList is a file-backed set of timed bans. Every change is flushed to disk
+// before it returns, so bans survive a restart.
+type BanList struct {
+	lock sync.Mutex
+	path string
+	bans map[p2p.PeerID]time.Time
+}
+
+// OpenBanList loads the bans stored at path, or starts an empty list if the
+// file does not exist yet. An empty path keeps the list in memory only.
+func OpenBanList(path string) (*BanList, error) {
+	l := &BanList{
+		path: path,
+		bans: make(map[p2p.PeerID]time.Time),
+	}
+
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ban list: %w", err)
+	}
+
+	if err = json.Unmarshal(data, &l.bans); err != nil {
+		return nil, fmt.Errorf("failed to parse ban list: %w", err)
+	}
+
+	return l, nil
+}
+
+// Ban bans id until the given time, extending but never shortening an
+// existing ban.
+func (l *BanList) Ban(id p2p.PeerID, until time.Time) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if current, ok := l.bans[id]; ok && !until.After(current) {
+		return nil
+	}
+	l.bans[id] = until.UTC()
+
+	return l.flush()
+}
+
+func (l *BanList) Unban(id p2p.PeerID) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.bans[id]; !ok {
+		return nil
+	}
+	delete(l.bans, id)
+
+	return l.flush()
+}
+
+// BannedUntil reports whether id is banned at now and until when.
+func (l *BanList) BannedUntil(id p2p.PeerID, now time.Time) (time.Time, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	until, ok := l.bans[id]
+	if !ok || !now.Before(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// Prune drops the bans that expired before now.
+func (l *BanList) Prune(now time.Time) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	pruned := false
+	for id, until := range l.bans {
+		if !now.Before(until) {
+			delete(l.bans, id)
+			pruned = true
+		}
+	}
+
+	if !pruned {
+		return nil
+	}
+	return l.flush()
+}
+
+func (l *BanList) flush() error {
+	if l.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(l.bans, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ban list: %w", err)
+	}
+
+	if err = atomicfile.WriteFile(l.path, data); err != nil {
+		return fmt.Errorf("failed to write ban list: %w", err)
+	}
+
+	return nil
+}
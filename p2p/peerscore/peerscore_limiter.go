@@ -0,0 +1,37 @@
+package peerscore
+
+import (
+	"time"
+)
+
+// RateLimit is a token bucket: Rate messages per second on average, with
+// bursts of up to Burst messages.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(limit RateLimit, now time.Time) *bucket {
+	return &bucket{tokens: float64(limit.Burst), last: now}
+}
+
+func (b *bucket) take(limit RateLimit, now time.Time) bool {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * limit.Rate
+		if max := float64(limit.Burst); b.tokens > max {
+			b.tokens = max
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
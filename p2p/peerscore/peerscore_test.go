@@ -0,0 +1,192 @@
+package peerscore
+
+import (
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/gossip"
+	"github.com/andantan/kangaroo/p2p/memnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// pair joins a guarded node and a plain remote peer and connects them.
+func pair(t *testing.T, cfg Config) (*memnet.Network, *Scorer, *memnet.Transport) {
+	net := memnet.NewNetwork(memnet.Config{Seed: 1})
+	t.Cleanup(net.Close)
+
+	local, err := net.Join("local")
+	require.NoError(t, err)
+	remote, err := net.Join("remote")
+	require.NoError(t, err)
+
+	s := NewScorer(cfg, local)
+	require.NoError(t, net.Connect("local", "remote"))
+	return net, s, remote
+}
+
+func connected(tr p2p.Transport, id p2p.PeerID) bool {
+	for _, peer := range tr.Peers() {
+		if peer == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestScorer_Decay(t *testing.T) {
+	clk := newClock()
+	_, s, _ := pair(t, Config{HalfLife: time.Minute, Now: clk.Now})
+
+	s.Penalize("remote", 40, "test")
+	assert.InDelta(t, -40, s.Score("remote"), 1e-9)
+
+	clk.advance(time.Minute)
+	assert.InDelta(t, -20, s.Score("remote"), 1e-9)
+
+	clk.advance(2 * time.Minute)
+	assert.InDelta(t, -5, s.Score("remote"), 1e-9)
+
+	t.Run("rewards are capped", func(t *testing.T) {
+		s.Reward("other", 1000)
+		assert.Equal(t, float64(DefaultMaxScore), s.Score("other"))
+	})
+}
+
+func TestScorer_Disconnect(t *testing.T) {
+	clk := newClock()
+	_, s, remote := pair(t, Config{Now: clk.Now})
+	require.True(t, connected(s, "remote"))
+
+	s.Penalize("remote", 30, "test")
+	assert.True(t, connected(s, "remote"))
+
+	s.Penalize("remote", 30, "test")
+	assert.False(t, connected(s, "remote"))
+	assert.False(t, connected(remote, "local"))
+	assert.False(t, s.IsBanned("remote"))
+}
+
+func TestScorer_BanPersists(t *testing.T) {
+	clk := newClock()
+	path := filepath.Join(t.TempDir(), "bans.json")
+
+	bans, err := OpenBanList(path)
+	require.NoError(t, err)
+
+	net, s, _ := pair(t, Config{Bans: bans, BanDuration: time.Hour, Now: clk.Now})
+	s.Penalize("remote", 150, "test")
+	assert.True(t, s.IsBanned("remote"))
+	assert.False(t, connected(s, "remote"))
+	assert.Zero(t, s.Score("remote"), "a ban settles the score")
+
+	t.Run("banned peers are refused on connect", func(t *testing.T) {
+		var notified atomic.Int32
+		s.OnPeer(func(p2p.PeerID, bool) { notified.Add(1) })
+
+		assert.ErrorIs(t, net.Connect("local", "remote"), ErrBanned)
+		assert.False(t, connected(s, "remote"))
+		assert.Zero(t, notified.Load(), "a banned peer must not be announced as connected")
+	})
+
+	t.Run("survives a restart", func(t *testing.T) {
+		reopened, err := OpenBanList(path)
+		require.NoError(t, err)
+
+		until, banned := reopened.BannedUntil("remote", clk.Now())
+		require.True(t, banned)
+		assert.True(t, until.Equal(clk.Now().Add(time.Hour)))
+
+		restarted := NewScorer(Config{Bans: reopened, Now: clk.Now}, s.Transport)
+		assert.True(t, restarted.IsBanned("remote"))
+	})
+
+	t.Run("bans expire", func(t *testing.T) {
+		clk.advance(time.Hour)
+		assert.False(t, s.IsBanned("remote"))
+
+		require.NoError(t, bans.Prune(clk.Now()))
+		reopened, err := OpenBanList(path)
+		require.NoError(t, err)
+		_, banned := reopened.BannedUntil("remote", clk.Now())
+		assert.False(t, banned)
+
+		require.NoError(t, net.Connect("local", "remote"))
+		assert.True(t, connected(s, "remote"))
+	})
+
+	t.Run("the ban settled the score", func(t *testing.T) {
+		s.Penalize("remote", float64(-PenaltyInvalid), "test")
+		assert.False(t, s.IsBanned("remote"))
+		assert.True(t, connected(s, "remote"))
+	})
+}
+
+func TestScorer_RateLimit(t *testing.T) {
+	const testType p2p.MessageType = 1
+
+	clk := newClock()
+	_, s, remote := pair(t, Config{
+		RateLimits: map[p2p.MessageType]RateLimit{testType: {Rate: 1, Burst: 3}},
+		Now:        clk.Now,
+	})
+
+	var received, unlimited atomic.Int32
+	s.Handle(testType, func(p2p.PeerID, p2p.Message) { received.Add(1) })
+	s.Handle(testType+1, func(p2p.PeerID, p2p.Message) { unlimited.Add(1) })
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, remote.Send("local", p2p.Message{Type: testType}))
+		require.NoError(t, remote.Send("local", p2p.Message{Type: testType + 1}))
+	}
+	require.Eventually(t, func() bool { return unlimited.Load() == 10 }, time.Second, time.Millisecond)
+
+	assert.Equal(t, int32(3), received.Load())
+	assert.InDelta(t, 7*PenaltyRateLimited, s.Score("remote"), 1e-9)
+
+	clk.advance(2 * time.Second)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, remote.Send("local", p2p.Message{Type: testType}))
+	}
+	require.Eventually(t, func() bool { return received.Load() == 5 }, time.Second, time.Millisecond)
+}
+
+func TestScorer_GossipInvalid(t *testing.T) {
+	clk := newClock()
+	_, s, remote := pair(t, Config{Now: clk.Now})
+
+	g := gossip.NewGossip(gossip.Config{OnInvalid: s.GossipInvalid}, s, nil)
+	g.Subscribe(gossip.TopicTransactions, gossip.TransactionHandler(nil, nil))
+
+	require.NoError(t, remote.Send("local", p2p.Message{Type: p2p.MessageGossip, Payload: []byte{0xff}}))
+	require.Eventually(t, func() bool {
+		return s.Score("remote") == PenaltyUndecodable
+	}, time.Second, time.Millisecond)
+
+	s.GossipInvalid("remote", gossip.TopicBlocks, assert.AnError)
+	assert.Equal(t, float64(PenaltyUndecodable+PenaltyInvalid), s.Score("remote"))
+}
@@ -0,0 +1,463 @@
+package statesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/p2p"
+	kangaroostatesyncpb "github.com/andantan/kangaroo/proto/p2p/statesync/pb"
+	"github.com/andantan/kangaroo/state"
+	"github.com/andantan/kangaroo/state/snapshot"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRequestTimeout = 5 * time.Second
+)
+
+var (
+	ErrNoPeers     = errors.New("no peers to sync from")
+	ErrNoSnapshot  = errors.New("no usable snapshot")
+	ErrStalled     = errors.New("peer stalled")
+	ErrUntrusted   = errors.New("snapshot block is not trusted")
+	ErrNoTrust     = errors.New("state sync needs a trust function")
+	ErrBadResponse = errors.New("bad state sync response")
+)
+
+// Progress is reported after every verified chunk.
+type Progress struct {
+	Height uint64
+	Chunks int
+	Total  int
+	Peers  int
+}
+
+type Config struct {
+	RequestTimeout time.Duration
+	// Trust decides whether the block a snapshot was taken after may be
+	// built on. It is required: the restored chain treats that block as
+	// final, and peers cannot vouch for it themselves. See TrustCheckpoint
+	// and TrustValidator.
+	Trust      func(blk block.Block) error
+	OnProgress func(Progress)
+}
+
+type request struct {
+	peer  p2p.PeerID
+	reply chan proto.Message
+}
+
+// Syncer bootstraps a node from a snapshot instead of replaying the chain
+// from genesis. The chain it returns starts at the snapshot block and is
+// caught up with block sync from there.
+type Syncer struct {
+	cfg       Config
+	executor  *state.Executor
+	genesis   *chain.Genesis
+	validator chain.BlockValidator
+	transport p2p.Transport
+	genesisID hash.Hash
+
+	lock    sync.Mutex
+	nextID  uint64
+	pending map[uint64]*request
+}
+
+// TrustCheckpoint trusts only the block with the given height and id, e.g.
+// one taken from a block explorer or another node the operator runs.
+func TrustCheckpoint(deriver hash.HashDeriver, height uint64, id hash.Hash) func(blk block.Block) error {
+	return func(blk block.Block) error {
+		if blk.GetHeader().GetHeight() != height {
+			return fmt.Errorf("height %d is not the checkpoint height %d", blk.GetHeader().GetHeight(), height)
+		}
+		blockID, err := blk.Hash(deriver)
+		if err != nil {
+			return err
+		}
+		if !blockID.Equal(id) {
+			return fmt.Errorf("block %s is not the checkpoint %s", blockID.ShortString(8), id.ShortString(8))
+		}
+		return nil
+	}
+}
+
+// TrustValidator trusts blocks that pass validator without their parent. It
+// suits validators that check a block's seal on its own, like the authority
+// validator; a proof-of-work seal says nothing without the parent's target
+// and needs a checkpoint instead.
+func TrustValidator(validator chain.BlockValidator) func(blk block.Block) error {
+	return func(blk block.Block) error {
+		return validator.ValidateBlock(nil, blk)
+	}
+}
+
+func NewSyncer(cfg Config, executor *state.Executor, genesis *chain.Genesis, validator chain.BlockValidator, transport p2p.Transport) (*Syncer, error) {
+	if cfg.Trust == nil {
+		return nil, ErrNoTrust
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = DefaultRequestTimeout
+	}
+
+	genesisBlock, _, err := genesis.ToBlock(executor.HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+	genesisID, err := genesisBlock.Hash(executor.HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Syncer{
+		cfg:       cfg,
+		executor:  executor,
+		genesis:   genesis,
+		validator: validator,
+		transport: transport,
+		genesisID: genesisID,
+		pending:   make(map[uint64]*request),
+	}
+
+	transport.Handle(p2p.MessageSnapshotsResponse, s.handleSnapshotsResponse)
+	transport.Handle(p2p.MessageSnapshotChunkResponse, s.handleChunkResponse)
+
+	return s, nil
+}
+
+// candidate is one snapshot and the peers offering it.
+type candidate struct {
+	manifest *snapshot.Manifest
+	base     block.Block
+	peers    []p2p.PeerID
+}
+
+// Sync picks a snapshot, downloads its chunks from every peer offering it,
+// verifies each against the snapshot block's state root and returns a chain
+// starting at that block.
+func (s *Syncer) Sync(ctx context.Context) (*chain.Chain, error) {
+	peers := s.transport.Peers()
+	if len(peers) == 0 {
+		return nil, ErrNoPeers
+	}
+
+	best, err := s.discover(ctx, peers)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[StateSync] syncing snapshot at height %d from %d peers", best.manifest.Height, len(best.peers))
+
+	chunks, err := s.fetchChunks(ctx, best)
+	if err != nil {
+		return nil, err
+	}
+
+	deriver := s.executor.HashDeriver()
+	st, err := snapshot.Restore(deriver, best.manifest, chunks)
+	if err != nil {
+		return nil, err
+	}
+
+	return chain.NewChainFromSnapshot(s.executor, s.genesis, s.validator, best.base, st)
+}
+
+// discover asks every peer for its snapshots and returns the highest valid
+// one, preferring the one offered by more peers at equal height. Only offers
+// Trust accepted take part, so a peer cannot steer the choice by claiming a
+// height.
+func (s *Syncer) discover(ctx context.Context, peers []p2p.PeerID) (*candidate, error) {
+	var (
+		wg    sync.WaitGroup
+		lock  sync.Mutex
+		byKey = make(map[string]*candidate)
+	)
+
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer p2p.PeerID) {
+			defer wg.Done()
+
+			offers, err := s.snapshotsOf(ctx, peer)
+			if err != nil {
+				log.Printf("[StateSync] no snapshots from %s: %v", peer.ShortString(8), err)
+				return
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			for _, c := range offers {
+				k := string(c.manifest.BlockID.Bytes())
+				if existing, ok := byKey[k]; ok {
+					existing.peers = append(existing.peers, peer)
+					continue
+				}
+				c.peers = []p2p.PeerID{peer}
+				byKey[k] = c
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var best *candidate
+	for _, c := range byKey {
+		if best == nil ||
+			c.manifest.Height > best.manifest.Height ||
+			(c.manifest.Height == best.manifest.Height && len(c.peers) > len(best.peers)) {
+			best = c
+		}
+	}
+	if best == nil {
+		return nil, ErrNoSnapshot
+	}
+	return best, nil
+}
+
+// snapshotsOf returns the offers of peer that pass every check not needing
+// a chunk.
+func (s *Syncer) snapshotsOf(ctx context.Context, peer p2p.PeerID) ([]*candidate, error) {
+	m, err := s.request(ctx, peer, p2p.MessageSnapshotsRequest, func(id uint64) proto.Message {
+		return &kangaroostatesyncpb.KangarooSnapshotsRequest{RequestId: id}
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := m.(*kangaroostatesyncpb.KangarooSnapshotsResponse)
+
+	if string(resp.GenesisHash) != string(s.genesisID.Bytes()) {
+		return nil, fmt.Errorf("%w: different genesis", ErrBadResponse)
+	}
+
+	var candidates []*candidate
+	for _, offer := range resp.Offers {
+		c, err := s.checkOffer(offer)
+		if err != nil {
+			log.Printf("[StateSync] rejecting snapshot from %s: %v", peer.ShortString(8), err)
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+func (s *Syncer) checkOffer(offer *kangaroostatesyncpb.KangarooSnapshotOffer) (*candidate, error) {
+	if offer == nil {
+		return nil, fmt.Errorf("%w: missing offer", ErrBadResponse)
+	}
+
+	manifest := &snapshot.Manifest{}
+	if err := codec.DecodeProto(offer.Manifest, manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadResponse, err)
+	}
+
+	base, err := wrapper.UnwrapBlock(offer.Block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadResponse, err)
+	}
+	if base.GetHeader() == nil || base.GetBody() == nil {
+		return nil, fmt.Errorf("%w: incomplete snapshot block", ErrBadResponse)
+	}
+
+	deriver := s.executor.HashDeriver()
+	if err := manifest.Verify(deriver, base.GetHeader()); err != nil {
+		return nil, err
+	}
+
+	bodyHash, err := base.GetBody().Hash(deriver)
+	if err != nil {
+		return nil, err
+	}
+	if !bodyHash.Equal(base.GetHeader().GetBodyHash()) {
+		return nil, fmt.Errorf("%w: snapshot block body mismatch", ErrBadResponse)
+	}
+
+	if err := s.cfg.Trust(base); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUntrusted, err)
+	}
+
+	return &candidate{manifest: manifest, base: base}, nil
+}
+
+// fetchChunks runs one worker per peer, each taking the next chunk nobody
+// has yet. A peer that stalls or serves a bad chunk stops working and its
+// chunk goes back to the queue for the others.
+func (s *Syncer) fetchChunks(ctx context.Context, c *candidate) ([][]*state.Account, error) {
+	total := len(c.manifest.ChunkRoots)
+	chunks := make([][]*state.Account, total)
+	if total == 0 {
+		return chunks, nil
+	}
+
+	var (
+		lock    sync.Mutex
+		cond    = sync.NewCond(&lock)
+		queue   = make([]int, total)
+		done    int
+		working = len(c.peers)
+	)
+	for i := range queue {
+		queue[i] = i
+	}
+
+	// wake workers waiting for requeued chunks when the context ends
+	stop := context.AfterFunc(ctx, func() {
+		lock.Lock()
+		defer lock.Unlock()
+		cond.Broadcast()
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for _, peer := range c.peers {
+		wg.Add(1)
+		go func(peer p2p.PeerID) {
+			defer wg.Done()
+
+			for {
+				lock.Lock()
+				for len(queue) == 0 && done < total && ctx.Err() == nil {
+					cond.Wait()
+				}
+				if done == total || ctx.Err() != nil {
+					lock.Unlock()
+					return
+				}
+				index := queue[0]
+				queue = queue[1:]
+				lock.Unlock()
+
+				accounts, err := s.fetchChunk(ctx, peer, c.manifest, index)
+
+				lock.Lock()
+				if err != nil {
+					queue = append(queue, index)
+					working--
+					cond.Broadcast()
+					lock.Unlock()
+					if ctx.Err() == nil {
+						log.Printf("[StateSync] dropping %s: %v", peer.ShortString(8), err)
+					}
+					return
+				}
+				chunks[index] = accounts
+				done++
+				progress := Progress{Height: c.manifest.Height, Chunks: done, Total: total, Peers: working}
+				cond.Broadcast()
+				lock.Unlock()
+
+				if s.cfg.OnProgress != nil {
+					s.cfg.OnProgress(progress)
+				}
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if done < total {
+		return nil, fmt.Errorf("%w: %d of %d chunks fetched", ErrNoPeers, done, total)
+	}
+	return chunks, nil
+}
+
+func (s *Syncer) fetchChunk(ctx context.Context, peer p2p.PeerID, manifest *snapshot.Manifest, index int) ([]*state.Account, error) {
+	m, err := s.request(ctx, peer, p2p.MessageSnapshotChunkRequest, func(id uint64) proto.Message {
+		return &kangaroostatesyncpb.KangarooChunkRequest{RequestId: id, Height: manifest.Height, Index: uint32(index)}
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := m.(*kangaroostatesyncpb.KangarooChunkResponse)
+
+	if len(resp.Chunk) == 0 {
+		return nil, fmt.Errorf("%w: chunk %d not served", ErrBadResponse, index)
+	}
+
+	accounts, err := snapshot.DecodeChunk(resp.Chunk)
+	if err != nil {
+		return nil, err
+	}
+	if err := manifest.VerifyChunk(s.executor.HashDeriver(), index, accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (s *Syncer) request(ctx context.Context, peer p2p.PeerID, t p2p.MessageType, build func(id uint64) proto.Message) (proto.Message, error) {
+	s.lock.Lock()
+	s.nextID++
+	id := s.nextID
+	req := &request{peer: peer, reply: make(chan proto.Message, 1)}
+	s.pending[id] = req
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.pending, id)
+		s.lock.Unlock()
+	}()
+
+	b, err := proto.Marshal(build(id))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transport.Send(peer, p2p.Message{Type: t, Payload: b}); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(s.cfg.RequestTimeout)
+	defer timer.Stop()
+
+	select {
+	case m := <-req.reply:
+		return m, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: %s", ErrStalled, peer.ShortString(8))
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Syncer) deliver(from p2p.PeerID, id uint64, m proto.Message) {
+	s.lock.Lock()
+	req, ok := s.pending[id]
+	s.lock.Unlock()
+
+	if !ok || req.peer != from {
+		return
+	}
+
+	select {
+	case req.reply <- m:
+	default:
+	}
+}
+
+func (s *Syncer) handleSnapshotsResponse(from p2p.PeerID, msg p2p.Message) {
+	resp := &kangaroostatesyncpb.KangarooSnapshotsResponse{}
+	if err := proto.Unmarshal(msg.Payload, resp); err != nil {
+		return
+	}
+	s.deliver(from, resp.RequestId, resp)
+}
+
+func (s *Syncer) handleChunkResponse(from p2p.PeerID, msg p2p.Message) {
+	resp := &kangaroostatesyncpb.KangarooChunkResponse{}
+	if err := proto.Unmarshal(msg.Payload, resp); err != nil {
+		return
+	}
+	s.deliver(from, resp.RequestId, resp)
+}
@@ -0,0 +1,228 @@
+package statesync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/p2p"
+	kangaroostatesyncpb "github.com/andantan/kangaroo/proto/p2p/statesync/pb"
+	"github.com/andantan/kangaroo/state/snapshot"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"sync"
+)
+
+const (
+	DefaultInterval = 1000
+	DefaultKeep     = 2
+)
+
+// errMoved means a reorg replaced the snapshot block while it was taken.
+var errMoved = errors.New("chain moved while taking a snapshot")
+
+type ServerConfig struct {
+	// Interval is the distance between snapshot heights.
+	Interval    uint64
+	ChunkLevels uint32
+	// Keep is how many of the latest snapshots are served.
+	Keep int
+}
+
+// served is a snapshot with everything it is served with encoded up front.
+type served struct {
+	manifest *snapshot.Manifest
+	offer    *kangaroostatesyncpb.KangarooSnapshotOffer
+	chunks   [][]byte
+}
+
+// Server takes snapshots of the local chain at every Interval heights and
+// serves them to syncing peers.
+type Server struct {
+	cfg       ServerConfig
+	chain     *chain.Chain
+	transport p2p.Transport
+	genesisID hash.Hash
+
+	lock      sync.RWMutex
+	snapshots []*served
+}
+
+func NewServer(cfg ServerConfig, c *chain.Chain, transport p2p.Transport) (*Server, error) {
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.ChunkLevels == 0 {
+		cfg.ChunkLevels = snapshot.DefaultChunkLevels
+	}
+	if cfg.Keep <= 0 {
+		cfg.Keep = DefaultKeep
+	}
+
+	genesisID, err := c.Genesis().Hash(c.Executor().HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		cfg:       cfg,
+		chain:     c,
+		transport: transport,
+		genesisID: genesisID,
+	}
+
+	transport.Handle(p2p.MessageSnapshotsRequest, s.handleSnapshotsRequest)
+	transport.Handle(p2p.MessageSnapshotChunkRequest, s.handleChunkRequest)
+
+	return s, nil
+}
+
+// OnNewHead takes a snapshot at the last snapshot height up to the head,
+// unless it has one already. It is called after the chain imports blocks; a
+// caller that is told about several blocks at once still gets the snapshot
+// of a height it skipped.
+func (s *Server) OnNewHead() error {
+	height := s.chain.Height()
+	height -= height % s.cfg.Interval
+	if height == 0 || height < s.chain.Base() {
+		return nil
+	}
+
+	s.lock.RLock()
+	taken := len(s.snapshots) > 0 && s.snapshots[len(s.snapshots)-1].manifest.Height >= height
+	s.lock.RUnlock()
+	if taken {
+		return nil
+	}
+
+	base, err := s.chain.GetBlockByHeight(height)
+	if err != nil {
+		return err
+	}
+	st, err := s.chain.StateAt(height)
+	if err != nil {
+		return err
+	}
+
+	deriver := s.chain.Executor().HashDeriver()
+	blockID, err := base.Hash(deriver)
+	if err != nil {
+		return err
+	}
+
+	snap, err := snapshot.New(deriver, st, height, blockID, s.cfg.ChunkLevels)
+	if err != nil {
+		return err
+	}
+	// a reorg between reading the block and its state leaves them apart
+	if !snap.Manifest.StateRoot.Equal(base.GetHeader().GetStateRoot()) {
+		return fmt.Errorf("%w at height %d", errMoved, height)
+	}
+
+	entry, err := encode(snap, base)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.snapshots = append(s.snapshots, entry)
+	if len(s.snapshots) > s.cfg.Keep {
+		s.snapshots = s.snapshots[len(s.snapshots)-s.cfg.Keep:]
+	}
+	s.lock.Unlock()
+
+	log.Printf("[StateSync] took snapshot at height %d: %d chunks", height, len(entry.chunks))
+	return nil
+}
+
+func encode(snap *snapshot.Snapshot, base block.Block) (*served, error) {
+	manifest, err := codec.EncodeProto(snap.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := wrapper.WrapBlock(base)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([][]byte, len(snap.Chunks))
+	for i, chunk := range snap.Chunks {
+		if chunks[i], err = snapshot.EncodeChunk(chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	return &served{
+		manifest: snap.Manifest,
+		offer:    &kangaroostatesyncpb.KangarooSnapshotOffer{Manifest: manifest, Block: wrapped},
+		chunks:   chunks,
+	}, nil
+}
+
+// Snapshots returns the manifests of the snapshots being served, oldest
+// first.
+func (s *Server) Snapshots() []*snapshot.Manifest {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	manifests := make([]*snapshot.Manifest, len(s.snapshots))
+	for i, entry := range s.snapshots {
+		manifests[i] = entry.manifest
+	}
+	return manifests
+}
+
+func (s *Server) handleSnapshotsRequest(from p2p.PeerID, msg p2p.Message) {
+	req := &kangaroostatesyncpb.KangarooSnapshotsRequest{}
+	if err := proto.Unmarshal(msg.Payload, req); err != nil {
+		return
+	}
+
+	resp := &kangaroostatesyncpb.KangarooSnapshotsResponse{
+		RequestId:   req.RequestId,
+		GenesisHash: s.genesisID.Bytes(),
+	}
+
+	s.lock.RLock()
+	for _, entry := range s.snapshots {
+		resp.Offers = append(resp.Offers, entry.offer)
+	}
+	s.lock.RUnlock()
+
+	s.send(from, p2p.MessageSnapshotsResponse, resp)
+}
+
+func (s *Server) handleChunkRequest(from p2p.PeerID, msg p2p.Message) {
+	req := &kangaroostatesyncpb.KangarooChunkRequest{}
+	if err := proto.Unmarshal(msg.Payload, req); err != nil {
+		return
+	}
+
+	var chunk []byte
+	s.lock.RLock()
+	for _, entry := range s.snapshots {
+		if entry.manifest.Height == req.Height && int(req.Index) < len(entry.chunks) {
+			chunk = entry.chunks[req.Index]
+		}
+	}
+	s.lock.RUnlock()
+
+	// an empty chunk tells the requester to ask someone else
+	s.send(from, p2p.MessageSnapshotChunkResponse, &kangaroostatesyncpb.KangarooChunkResponse{
+		RequestId: req.RequestId,
+		Chunk:     chunk,
+	})
+}
+
+func (s *Server) send(to p2p.PeerID, t p2p.MessageType, m proto.Message) {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		log.Printf("[StateSync] failed to encode message: %v", err)
+		return
+	}
+	_ = s.transport.Send(to, p2p.Message{Type: t, Payload: b})
+}
@@ -0,0 +1,346 @@
+package statesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/p2p/blocksync"
+	"github.com/andantan/kangaroo/p2p/memnet"
+	kangaroostatesyncpb "github.com/andantan/kangaroo/proto/p2p/statesync/pb"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fixture struct {
+	t        *testing.T
+	net      *memnet.Network
+	executor *state.Executor
+	genesis  *chain.Genesis
+	blocks   []block.Block
+}
+
+// newFixture builds a chain of n blocks over a genesis with many accounts,
+// each block moving funds to a new account.
+func newFixture(t *testing.T, n int) *fixture {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	user, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	f := &fixture{
+		t:        t,
+		net:      memnet.NewNetwork(memnet.Config{Seed: 1, Latency: memnet.UniformLatency{Max: time.Millisecond}}),
+		executor: state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver()),
+		genesis: &chain.Genesis{
+			Timestamp: 1,
+			Alloc: []chain.GenesisAccount{
+				{Address: user.PublicKey().Address(addressSuite.Deriver()), Balance: big.NewInt(1_000_000)},
+			},
+		},
+	}
+	t.Cleanup(f.net.Close)
+
+	for i := 0; i < 40; i++ {
+		addr := addressSuite.Deriver().Derive([]byte(fmt.Sprintf("genesis-%d", i)))
+		f.genesis.Alloc = append(f.genesis.Alloc, chain.GenesisAccount{Address: addr, Balance: big.NewInt(int64(i + 1))})
+	}
+
+	c := f.newChain()
+	pool := mempool.NewMempool(hashSuite.Deriver(), 0)
+	for i := 0; i < n; i++ {
+		to := addressSuite.Deriver().Derive([]byte(fmt.Sprintf("recipient-%d", i)))
+		tx := kangarootransaction.NewKangarooTransaction(to, big.NewInt(int64(i+1)), nil, uint64(i))
+		require.NoError(t, tx.Sign(user, hashSuite.Deriver()))
+		_, err := pool.Add(tx)
+		require.NoError(t, err)
+
+		blk, err := assembler.NewAssembler(c, pool, 0).Assemble(nil, int64(i+2), nil)
+		require.NoError(t, err)
+		require.NoError(t, c.AddBlock(blk))
+		pool.RemoveTransactions(blk.GetBody().GetTransactions())
+		f.blocks = append(f.blocks, blk)
+	}
+	return f
+}
+
+func (f *fixture) newChain() *chain.Chain {
+	c, err := chain.NewChain(f.executor, f.genesis, nil)
+	require.NoError(f.t, err)
+	return c
+}
+
+// checkpoint trusts the fixture block at height.
+func (f *fixture) checkpoint(height uint64) func(block.Block) error {
+	deriver := f.executor.HashDeriver()
+	id, err := f.blocks[height-1].Hash(deriver)
+	require.NoError(f.t, err)
+	return TrustCheckpoint(deriver, height, id)
+}
+
+// server joins a node that imports the fixture chain, snapshotting as it goes.
+func (f *fixture) server(id p2p.PeerID) (*chain.Chain, *Server) {
+	tr, err := f.net.Join(id)
+	require.NoError(f.t, err)
+
+	c := f.newChain()
+	s, err := NewServer(ServerConfig{Interval: 10, ChunkLevels: 2}, c, tr)
+	require.NoError(f.t, err)
+	_, err = blocksync.NewSyncer(blocksync.Config{}, c, tr)
+	require.NoError(f.t, err)
+
+	for _, blk := range f.blocks {
+		require.NoError(f.t, c.AddBlock(blk))
+		require.NoError(f.t, s.OnNewHead())
+	}
+	return c, s
+}
+
+func TestServer_Snapshots(t *testing.T) {
+	f := newFixture(t, 35)
+	c, s := f.server("server")
+
+	manifests := s.Snapshots()
+	require.Len(t, manifests, DefaultKeep)
+	assert.Equal(t, uint64(20), manifests[0].Height)
+	assert.Equal(t, uint64(30), manifests[1].Height)
+
+	base, err := c.GetBlockByHeight(30)
+	require.NoError(t, err)
+	require.NoError(t, manifests[1].Verify(c.Executor().HashDeriver(), base.GetHeader()))
+
+	require.NoError(t, s.OnNewHead())
+	assert.Len(t, s.Snapshots(), DefaultKeep)
+
+	t.Run("heights passed between calls", func(t *testing.T) {
+		tr, err := f.net.Join("late")
+		require.NoError(t, err)
+		late, err := NewServer(ServerConfig{Interval: 10, ChunkLevels: 2}, c, tr)
+		require.NoError(t, err)
+
+		require.NoError(t, late.OnNewHead())
+		manifests := late.Snapshots()
+		require.Len(t, manifests, 1)
+		assert.Equal(t, uint64(30), manifests[0].Height)
+		require.NoError(t, manifests[0].Verify(c.Executor().HashDeriver(), base.GetHeader()))
+	})
+}
+
+func TestSyncer_SnapshotThenBlockSync(t *testing.T) {
+	f := newFixture(t, 25)
+	source, _ := f.server("server-1")
+	f.server("server-2")
+	f.server("server-3")
+
+	tr, err := f.net.Join("fresh")
+	require.NoError(t, err)
+
+	var (
+		lock     sync.Mutex
+		progress []Progress
+	)
+	s, err := NewSyncer(Config{
+		Trust: f.checkpoint(20),
+		OnProgress: func(p Progress) {
+			lock.Lock()
+			defer lock.Unlock()
+			progress = append(progress, p)
+		},
+	}, f.executor, f.genesis, nil, tr)
+	require.NoError(t, err)
+
+	f.net.ConnectAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), c.Base())
+	assert.Equal(t, uint64(20), c.Height())
+
+	deriver := f.executor.HashDeriver()
+	want, err := source.GetBlockByHeight(20)
+	require.NoError(t, err)
+	root, err := c.State().Root(deriver)
+	require.NoError(t, err)
+	assert.True(t, root.Equal(want.GetHeader().GetStateRoot()))
+
+	lock.Lock()
+	require.NotEmpty(t, progress)
+	last := progress[len(progress)-1]
+	lock.Unlock()
+	assert.Equal(t, last.Total, last.Chunks)
+	assert.Equal(t, uint64(20), last.Height)
+
+	t.Run("block sync continues from the snapshot", func(t *testing.T) {
+		bs, err := blocksync.NewSyncer(blocksync.Config{}, c, tr)
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return bs.BestHeight() == 25 }, 2*time.Second, time.Millisecond)
+
+		require.NoError(t, bs.Sync(ctx))
+		assert.Equal(t, uint64(25), c.Height())
+
+		headID, err := c.Head().Hash(deriver)
+		require.NoError(t, err)
+		wantID, err := source.Head().Hash(deriver)
+		require.NoError(t, err)
+		assert.True(t, headID.Equal(wantID))
+	})
+}
+
+// liar offers an honest snapshot but serves garbage chunks.
+func liar(t *testing.T, f *fixture, honest *Server) {
+	tr, err := f.net.Join("liar")
+	require.NoError(t, err)
+
+	respond := func(to p2p.PeerID, typ p2p.MessageType, m proto.Message) {
+		b, err := proto.Marshal(m)
+		require.NoError(t, err)
+		_ = tr.Send(to, p2p.Message{Type: typ, Payload: b})
+	}
+
+	tr.Handle(p2p.MessageSnapshotsRequest, func(from p2p.PeerID, msg p2p.Message) {
+		req := &kangaroostatesyncpb.KangarooSnapshotsRequest{}
+		require.NoError(t, proto.Unmarshal(msg.Payload, req))
+
+		resp := &kangaroostatesyncpb.KangarooSnapshotsResponse{RequestId: req.RequestId, GenesisHash: honest.genesisID.Bytes()}
+		honest.lock.RLock()
+		for _, entry := range honest.snapshots {
+			resp.Offers = append(resp.Offers, entry.offer)
+		}
+		honest.lock.RUnlock()
+		respond(from, p2p.MessageSnapshotsResponse, resp)
+	})
+	tr.Handle(p2p.MessageSnapshotChunkRequest, func(from p2p.PeerID, msg p2p.Message) {
+		req := &kangaroostatesyncpb.KangarooChunkRequest{}
+		require.NoError(t, proto.Unmarshal(msg.Payload, req))
+		respond(from, p2p.MessageSnapshotChunkResponse, &kangaroostatesyncpb.KangarooChunkResponse{
+			RequestId: req.RequestId,
+			Chunk:     []byte{0x0a, 0x03, 0x0a, 0x01, 0xff},
+		})
+	})
+}
+
+func TestSyncer_BadChunksAreFetchedElsewhere(t *testing.T) {
+	f := newFixture(t, 12)
+	_, honest := f.server("server")
+	liar(t, f, honest)
+
+	tr, err := f.net.Join("fresh")
+	require.NoError(t, err)
+	s, err := NewSyncer(Config{RequestTimeout: 100 * time.Millisecond, Trust: f.checkpoint(10)}, f.executor, f.genesis, nil, tr)
+	require.NoError(t, err)
+	f.net.ConnectAll()
+
+	c, err := s.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), c.Height())
+
+	t.Run("fails once every peer serving the snapshot is dropped", func(t *testing.T) {
+		f.net.Partition([]p2p.PeerID{"fresh", "liar"}, []p2p.PeerID{"server"})
+		defer f.net.Heal()
+
+		// the partition only drops traffic, so the honest server stalls
+		_, err := s.Sync(context.Background())
+		assert.ErrorIs(t, err, ErrNoPeers)
+	})
+}
+
+func TestSyncer_Trust(t *testing.T) {
+	f := newFixture(t, 25)
+	f.server("server")
+
+	_, err := NewSyncer(Config{}, f.executor, f.genesis, nil, nil)
+	assert.ErrorIs(t, err, ErrNoTrust)
+
+	t.Run("the trusted snapshot wins over higher ones", func(t *testing.T) {
+		tr, err := f.net.Join("checkpointed")
+		require.NoError(t, err)
+		s, err := NewSyncer(Config{Trust: f.checkpoint(10)}, f.executor, f.genesis, nil, tr)
+		require.NoError(t, err)
+		require.NoError(t, f.net.Connect("checkpointed", "server"))
+
+		c, err := s.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10), c.Base())
+	})
+
+	t.Run("validator", func(t *testing.T) {
+		tr, err := f.net.Join("validating")
+		require.NoError(t, err)
+		s, err := NewSyncer(Config{Trust: TrustValidator(maxHeight(15))}, f.executor, f.genesis, nil, tr)
+		require.NoError(t, err)
+		require.NoError(t, f.net.Connect("validating", "server"))
+
+		c, err := s.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10), c.Base())
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		tr, err := f.net.Join("fresh")
+		require.NoError(t, err)
+		untrusted := errors.New("not the checkpoint")
+		s, err := NewSyncer(Config{
+			Trust: func(blk block.Block) error { return untrusted },
+		}, f.executor, f.genesis, nil, tr)
+		require.NoError(t, err)
+		require.NoError(t, f.net.Connect("fresh", "server"))
+
+		_, err = s.Sync(context.Background())
+		assert.ErrorIs(t, err, ErrNoSnapshot)
+	})
+
+	t.Run("different genesis", func(t *testing.T) {
+		other := *f.genesis
+		other.Timestamp = 2
+		tr, err := f.net.Join("other")
+		require.NoError(t, err)
+		s, err := NewSyncer(Config{Trust: f.checkpoint(20)}, f.executor, &other, nil, tr)
+		require.NoError(t, err)
+		require.NoError(t, f.net.Connect("other", "server"))
+
+		_, err = s.Sync(context.Background())
+		assert.ErrorIs(t, err, ErrNoSnapshot)
+	})
+
+	t.Run("no peers", func(t *testing.T) {
+		tr, err := f.net.Join("alone")
+		require.NoError(t, err)
+		s, err := NewSyncer(Config{Trust: f.checkpoint(20)}, f.executor, f.genesis, nil, tr)
+		require.NoError(t, err)
+
+		_, err = s.Sync(context.Background())
+		assert.ErrorIs(t, err, ErrNoPeers)
+	})
+}
+
+// maxHeight is a validator that accepts blocks up to a height.
+type maxHeight uint64
+
+func (m maxHeight) ValidateBlock(_ block.Header, blk block.Block) error {
+	if blk.GetHeader().GetHeight() > uint64(m) {
+		return errors.New("too high")
+	}
+	return nil
+}
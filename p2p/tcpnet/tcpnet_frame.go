@@ -0,0 +1,40 @@
+package tcpnet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/andantan/kangaroo/p2p"
+	"io"
+)
+
+const frameHeaderLength = 4
+
+// writeFrame writes b prefixed with its big endian uint32 length.
+func writeFrame(w io.Writer, b []byte) error {
+	frame := make([]byte, frameHeaderLength+len(b))
+	binary.BigEndian.PutUint32(frame, uint32(len(b)))
+	copy(frame[frameHeaderLength:], b)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+// readFrame reads one length prefixed frame, refusing frames above max before
+// allocating for them.
+func readFrame(r io.Reader, max uint32) ([]byte, error) {
+	var header [frameHeaderLength]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[:])
+	if length > max {
+		return nil, fmt.Errorf("%w: frame of %d bytes, limit %d", p2p.ErrMessageTooLarge, length, max)
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
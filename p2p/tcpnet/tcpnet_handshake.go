@@ -0,0 +1,139 @@
+package tcpnet
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	kangaroop2ppb "github.com/andantan/kangaroo/proto/p2p/pb"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"time"
+)
+
+const (
+	handshakeDomain    = "kangaroo-p2p-handshake"
+	handshakeNonceSize = 32
+	maxHandshakeFrame  = 4096
+)
+
+var (
+	ErrHandshake       = errors.New("handshake failed")
+	ErrChainMismatch   = errors.New("peer is on a different chain")
+	ErrVersionMismatch = errors.New("peer speaks a different protocol version")
+)
+
+// handshake runs the same steps on both ends of conn:
+//
+//  1. send a hello with the node key, chain id, protocol version and a fresh nonce
+//  2. check the peer's chain id and version
+//  3. sign the peer's nonce together with our own hello and send the signature
+//  4. verify the peer's signature over our nonce and its hello
+//
+// Signing the other side's nonce proves possession of the key for this very
+// connection, so a recorded handshake cannot be replayed.
+func handshake(conn net.Conn, cfg *Config) (key.PublicKey, error) {
+	if err := conn.SetDeadline(time.Now().Add(cfg.HandshakeTimeout)); err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.SetDeadline(time.Time{}) }()
+
+	nonce := make([]byte, handshakeNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	pubKeyBytes, err := wrapper.WrapPublicKey(cfg.PrivateKey.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := proto.Marshal(&kangaroop2ppb.KangarooHello{
+		PublicKey:       pubKeyBytes,
+		ChainId:         cfg.ChainID,
+		ProtocolVersion: cfg.ProtocolVersion,
+		Nonce:           nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = writeFrame(conn, local); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+
+	remote, err := readFrame(conn, maxHandshakeFrame)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+
+	hello := new(kangaroop2ppb.KangarooHello)
+	if err = proto.Unmarshal(remote, hello); err != nil {
+		return nil, fmt.Errorf("%w: malformed hello: %w", ErrHandshake, err)
+	}
+
+	if hello.ChainId != cfg.ChainID {
+		return nil, fmt.Errorf("%w: %q", ErrChainMismatch, hello.ChainId)
+	}
+	if hello.ProtocolVersion != cfg.ProtocolVersion {
+		return nil, fmt.Errorf("%w: %d", ErrVersionMismatch, hello.ProtocolVersion)
+	}
+	if len(hello.Nonce) != handshakeNonceSize {
+		return nil, fmt.Errorf("%w: bad nonce", ErrHandshake)
+	}
+
+	remoteKey, err := wrapper.UnwrapPublicKey(hello.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+
+	sig, err := cfg.PrivateKey.Sign(handshakeDigest(cfg.HashDeriver, hello.Nonce, local).Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	sigBytes, err := wrapper.WrapSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := proto.Marshal(&kangaroop2ppb.KangarooHelloProof{Signature: sigBytes})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = writeFrame(conn, proof); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+
+	b, err := readFrame(conn, maxHandshakeFrame)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+
+	remoteProof := new(kangaroop2ppb.KangarooHelloProof)
+	if err = proto.Unmarshal(b, remoteProof); err != nil {
+		return nil, fmt.Errorf("%w: malformed proof: %w", ErrHandshake, err)
+	}
+
+	remoteSig, err := wrapper.UnwrapSignature(remoteProof.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+
+	if !remoteSig.Verify(remoteKey, handshakeDigest(cfg.HashDeriver, nonce, remote).Bytes()) {
+		return nil, fmt.Errorf("%w: peer does not own its key", ErrHandshake)
+	}
+
+	return remoteKey, nil
+}
+
+func handshakeDigest(deriver hash.HashDeriver, nonce, hello []byte) hash.Hash {
+	data := make([]byte, 0, len(handshakeDomain)+len(nonce)+len(hello))
+	data = append(data, handshakeDomain...)
+	data = append(data, nonce...)
+	data = append(data, hello...)
+	return deriver.Derive(data)
+}
@@ -0,0 +1,14 @@
+package tcpnet
+
+import (
+	"github.com/andantan/kangaroo/crypto/key"
+	"net"
+)
+
+// Security upgrades a raw connection before the transport's own handshake,
+// for example to a noise.Security session. It returns the identity key the
+// peer authenticated with; the transport requires the key proven in its own
+// handshake to match it. A nil Security leaves the connection in plaintext.
+type Security interface {
+	Secure(conn net.Conn, initiator bool) (net.Conn, key.PublicKey, error)
+}
@@ -0,0 +1,233 @@
+package tcpnet
+
+import (
+	"context"
+	"errors"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/p2p"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testType p2p.MessageType = 7
+
+func newConfig(t *testing.T, keyType, chainID string) Config {
+	keySuite, err := registry.GetKeySuite(keyType)
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	privKey, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	return Config{
+		ChainID:          chainID,
+		PrivateKey:       privKey,
+		HashDeriver:      hashSuite.Deriver(),
+		AddressDeriver:   addressSuite.Deriver(),
+		HandshakeTimeout: time.Second,
+	}
+}
+
+func newTransport(t *testing.T, cfg Config) (*Transport, string) {
+	tr, err := NewTransport(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tr.Close() })
+
+	addr, err := tr.Listen("127.0.0.1:0", nil)
+	require.NoError(t, err)
+	return tr, addr.String()
+}
+
+type recorder struct {
+	lock     sync.Mutex
+	messages []string
+	events   []bool
+}
+
+func (r *recorder) handle(_ p2p.PeerID, msg p2p.Message) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.messages = append(r.messages, string(msg.Payload))
+}
+
+func (r *recorder) peer(_ p2p.PeerID, connected bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, connected)
+}
+
+func (r *recorder) count() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.messages)
+}
+
+func TestTransport(t *testing.T) {
+	cfgA := newConfig(t, "ecdsa-secp256k1", "kangaroo-test")
+	cfgB := newConfig(t, "eddsa-ed25519", "kangaroo-test")
+
+	a, _ := newTransport(t, cfgA)
+	b, addrB := newTransport(t, cfgB)
+
+	recA, recB := &recorder{}, &recorder{}
+	a.Handle(testType, recA.handle)
+	b.Handle(testType, recB.handle)
+	b.OnPeer(recB.peer)
+
+	id, err := a.Dial(context.Background(), addrB, nil)
+	require.NoError(t, err)
+	assert.Equal(t, b.ID(), id)
+	assert.Equal(t, PeerIDFromPublicKey(cfgB.PrivateKey.PublicKey(), cfgB.AddressDeriver), id)
+
+	require.Eventually(t, func() bool { return len(b.Peers()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []p2p.PeerID{a.ID()}, b.Peers())
+
+	pubKey, ok := b.PeerPublicKey(a.ID())
+	require.True(t, ok)
+	assert.True(t, pubKey.Equal(cfgA.PrivateKey.PublicKey()))
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, a.Send(b.ID(), p2p.Message{Type: testType, Payload: []byte("ping")}))
+	}
+	b.Broadcast(p2p.Message{Type: testType, Payload: []byte("pong")})
+
+	require.Eventually(t, func() bool { return recB.count() == 100 && recA.count() == 1 }, 2*time.Second, time.Millisecond)
+
+	t.Run("duplicate connection", func(t *testing.T) {
+		_, err := a.Dial(context.Background(), addrB, nil)
+		assert.ErrorIs(t, err, ErrDuplicatePeer)
+		assert.Len(t, a.Peers(), 1)
+	})
+
+	t.Run("self connection", func(t *testing.T) {
+		_, addrSelf := newTransport(t, cfgA)
+		_, err := a.Dial(context.Background(), addrSelf, nil)
+		assert.ErrorIs(t, err, ErrSelfConnection)
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		require.NoError(t, a.Disconnect(b.ID()))
+		require.Eventually(t, func() bool { return len(b.Peers()) == 0 }, time.Second, time.Millisecond)
+		assert.ErrorIs(t, a.Send(b.ID(), p2p.Message{Type: testType}), p2p.ErrUnknownPeer)
+
+		recB.lock.Lock()
+		defer recB.lock.Unlock()
+		assert.Equal(t, []bool{true, false}, recB.events)
+	})
+}
+
+func TestTransport_Gate(t *testing.T) {
+	a, addrA := newTransport(t, newConfig(t, "ecdsa-secp256k1", "kangaroo-test"))
+	b, addrB := newTransport(t, newConfig(t, "eddsa-ed25519", "kangaroo-test"))
+	c, _ := newTransport(t, newConfig(t, "eddsa-ed25519", "kangaroo-test"))
+
+	refused := errors.New("refused")
+	a.Gate(func(id p2p.PeerID) error {
+		if id == b.ID() {
+			return refused
+		}
+		return nil
+	})
+	rec := &recorder{}
+	a.OnPeer(rec.peer)
+
+	_, err := a.Dial(context.Background(), addrB, nil)
+	assert.ErrorIs(t, err, refused)
+
+	_, _ = b.Dial(context.Background(), addrA, nil)
+	_, err = c.Dial(context.Background(), addrA, nil)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(a.Peers()) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, []p2p.PeerID{c.ID()}, a.Peers())
+
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	assert.Equal(t, []bool{true}, rec.events, "refused peers are never announced")
+}
+
+func TestTransport_Handshake_Rejections(t *testing.T) {
+	_, addr := newTransport(t, newConfig(t, "ecdsa-secp256r1", "kangaroo-main"))
+
+	t.Run("different chain", func(t *testing.T) {
+		other, err := NewTransport(newConfig(t, "ecdsa-secp256r1", "kangaroo-test"))
+		require.NoError(t, err)
+		defer other.Close()
+
+		_, err = other.Dial(context.Background(), addr, nil)
+		assert.ErrorIs(t, err, ErrChainMismatch)
+	})
+
+	t.Run("different version", func(t *testing.T) {
+		cfg := newConfig(t, "schnorr-secp256k1", "kangaroo-main")
+		cfg.ProtocolVersion = ProtocolVersion + 1
+		other, err := NewTransport(cfg)
+		require.NoError(t, err)
+		defer other.Close()
+
+		_, err = other.Dial(context.Background(), addr, nil)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+	})
+
+	t.Run("key not owned", func(t *testing.T) {
+		cfg := newConfig(t, "eddsa-ed448", "kangaroo-main")
+		victim := newConfig(t, "eddsa-ed448", "kangaroo-main")
+		cfg.PrivateKey = impostor{PrivateKey: cfg.PrivateKey, claimed: victim.PrivateKey.PublicKey()}
+
+		local, remote := net.Pipe()
+		defer local.Close()
+		defer remote.Close()
+
+		honest := newConfig(t, "eddsa-ed448", "kangaroo-main")
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := handshake(remote, &cfg)
+			errs <- err
+		}()
+
+		_, err := handshake(local, &honest)
+		assert.ErrorIs(t, err, ErrHandshake)
+		_ = local.Close()
+		<-errs
+	})
+}
+
+// impostor claims someone else's public key but can only sign with its own.
+type impostor struct {
+	key.PrivateKey
+	claimed key.PublicKey
+}
+
+func (i impostor) PublicKey() key.PublicKey {
+	return i.claimed
+}
+
+func TestTransport_Limits(t *testing.T) {
+	cfgA := newConfig(t, "ecdsa-secp256k1", "kangaroo-test")
+	cfgB := newConfig(t, "ecdsa-secp256k1", "kangaroo-test")
+	cfgB.Limits = p2p.Limits{Default: 1024, PerType: map[p2p.MessageType]uint32{testType: 8}}
+
+	a, _ := newTransport(t, cfgA)
+	b, addrB := newTransport(t, cfgB)
+
+	_, err := a.Dial(context.Background(), addrB, nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(b.Peers()) == 1 }, time.Second, time.Millisecond)
+
+	assert.ErrorIs(t, b.Send(a.ID(), p2p.Message{Type: testType, Payload: make([]byte, 9)}), p2p.ErrMessageTooLarge)
+
+	// a allows the message, b drops a peer that breaks its limits
+	require.NoError(t, a.Send(b.ID(), p2p.Message{Type: testType, Payload: make([]byte, 9)}))
+	require.Eventually(t, func() bool { return len(b.Peers()) == 0 && len(a.Peers()) == 0 }, time.Second, time.Millisecond)
+}
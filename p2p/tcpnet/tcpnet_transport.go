@@ -0,0 +1,413 @@
+package tcpnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/p2p"
+	kangaroop2ppb "github.com/andantan/kangaroo/proto/p2p/pb"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ProtocolVersion = 2
+
+	DefaultHandshakeTimeout = 5 * time.Second
+	DefaultWriteTimeout     = 10 * time.Second
+	DefaultSendQueueSize    = 256
+
+	// room for the envelope's type and length fields around the payload
+	envelopeOverhead = 16
+)
+
+var (
+	ErrSelfConnection = errors.New("connection to self")
+	ErrDuplicatePeer  = errors.New("peer already connected")
+	ErrSendQueueFull  = errors.New("send queue full")
+)
+
+type Config struct {
+	ChainID         string
+	ProtocolVersion uint32
+
+	// PrivateKey is the node key proven in the handshake. The peer id is the
+	// address AddressDeriver derives from its public key.
+	PrivateKey     key.PrivateKey
+	HashDeriver    hash.HashDeriver
+	AddressDeriver hash.AddressDeriver
+
+	Limits           p2p.Limits
+	HandshakeTimeout time.Duration
+	WriteTimeout     time.Duration
+	SendQueueSize    int
+}
+
+type peer struct {
+	id     p2p.PeerID
+	pubKey key.PublicKey
+	conn   net.Conn
+	out    chan []byte
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (p *peer) close() {
+	p.once.Do(func() {
+		close(p.closed)
+		_ = p.conn.Close()
+	})
+}
+
+// Transport is a p2p.Transport over TCP. Every connection starts with an
+// authenticated handshake; afterwards both sides exchange length prefixed
+// KangarooEnvelope frames.
+type Transport struct {
+	p2p.Router
+
+	cfg      Config
+	id       p2p.PeerID
+	maxFrame uint32
+
+	lock      sync.Mutex
+	listeners []net.Listener
+	peers     map[p2p.PeerID]*peer
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+var _ p2p.Transport = (*Transport)(nil)
+var _ p2p.Gated = (*Transport)(nil)
+
+func NewTransport(cfg Config) (*Transport, error) {
+	if cfg.PrivateKey == nil || cfg.HashDeriver == nil || cfg.AddressDeriver == nil {
+		return nil, errors.New("node key, hash deriver and address deriver are required")
+	}
+	if cfg.ProtocolVersion == 0 {
+		cfg.ProtocolVersion = ProtocolVersion
+	}
+	if cfg.HandshakeTimeout <= 0 {
+		cfg.HandshakeTimeout = DefaultHandshakeTimeout
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = DefaultWriteTimeout
+	}
+	if cfg.SendQueueSize <= 0 {
+		cfg.SendQueueSize = DefaultSendQueueSize
+	}
+	if cfg.Limits.Default == 0 {
+		cfg.Limits.Default = p2p.DefaultMaxMessageSize
+	}
+
+	maxPayload := cfg.Limits.Default
+	for _, max := range cfg.Limits.PerType {
+		if max > maxPayload {
+			maxPayload = max
+		}
+	}
+
+	return &Transport{
+		cfg:      cfg,
+		id:       PeerIDFromPublicKey(cfg.PrivateKey.PublicKey(), cfg.AddressDeriver),
+		maxFrame: maxPayload + envelopeOverhead,
+		peers:    make(map[p2p.PeerID]*peer),
+	}, nil
+}
+
+func PeerIDFromPublicKey(pubKey key.PublicKey, deriver hash.AddressDeriver) p2p.PeerID {
+	return p2p.PeerID(pubKey.Address(deriver).String())
+}
+
+func (t *Transport) ID() p2p.PeerID {
+	return t.id
+}
+
+// Listen accepts connections on addr until the transport is closed and
+// returns the bound address. Connections are upgraded with security first,
+// so each listener can choose plaintext or an encrypted session.
+func (t *Transport) Listen(addr string, security Security) (net.Addr, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	t.lock.Lock()
+	if t.closed {
+		t.lock.Unlock()
+		_ = l.Close()
+		return nil, p2p.ErrClosed
+	}
+	t.listeners = append(t.listeners, l)
+	t.lock.Unlock()
+
+	t.wg.Add(1)
+	go t.accept(l, security)
+
+	return l.Addr(), nil
+}
+
+func (t *Transport) accept(l net.Listener, security Security) {
+	defer t.wg.Done()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			if _, err := t.setup(conn, security, false); err != nil {
+				log.Printf("[P2P] rejected inbound %s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// Dial connects to addr and returns the peer's id once the handshake passed.
+// security must match what the remote listener expects.
+func (t *Transport) Dial(ctx context.Context, addr string, security Security) (p2p.PeerID, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	return t.setup(conn, security, true)
+}
+
+func (t *Transport) setup(conn net.Conn, security Security, initiator bool) (p2p.PeerID, error) {
+	var sessionKey key.PublicKey
+	if security != nil {
+		if err := conn.SetDeadline(time.Now().Add(t.cfg.HandshakeTimeout)); err != nil {
+			_ = conn.Close()
+			return "", err
+		}
+
+		secured, remote, err := security.Secure(conn, initiator)
+		if err != nil {
+			_ = conn.Close()
+			return "", fmt.Errorf("%w: %w", ErrHandshake, err)
+		}
+		conn, sessionKey = secured, remote
+	}
+
+	pubKey, err := handshake(conn, &t.cfg)
+	if err != nil {
+		_ = conn.Close()
+		return "", err
+	}
+
+	// the session must belong to the same identity, or it could be relayed
+	if sessionKey != nil && !sessionKey.Equal(pubKey) {
+		_ = conn.Close()
+		return "", fmt.Errorf("%w: session and handshake keys differ", ErrHandshake)
+	}
+
+	id := PeerIDFromPublicKey(pubKey, t.cfg.AddressDeriver)
+	if err = t.Admit(id); err != nil {
+		_ = conn.Close()
+		return "", err
+	}
+
+	p := &peer{
+		id:     id,
+		pubKey: pubKey,
+		conn:   conn,
+		out:    make(chan []byte, t.cfg.SendQueueSize),
+		closed: make(chan struct{}),
+	}
+
+	if err = t.addPeer(p); err != nil {
+		_ = conn.Close()
+		return "", err
+	}
+
+	t.wg.Add(2)
+	go t.readLoop(p)
+	go t.writeLoop(p)
+
+	t.NotifyPeer(p.id, true)
+	return p.id, nil
+}
+
+func (t *Transport) addPeer(p *peer) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.closed {
+		return p2p.ErrClosed
+	}
+	if p.id == t.id {
+		return ErrSelfConnection
+	}
+	if _, ok := t.peers[p.id]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicatePeer, p.id)
+	}
+
+	t.peers[p.id] = p
+	return nil
+}
+
+func (t *Transport) removePeer(p *peer) {
+	p.close()
+
+	t.lock.Lock()
+	current, ok := t.peers[p.id]
+	if ok && current == p {
+		delete(t.peers, p.id)
+	}
+	t.lock.Unlock()
+
+	if ok && current == p {
+		t.NotifyPeer(p.id, false)
+	}
+}
+
+func (t *Transport) readLoop(p *peer) {
+	defer t.wg.Done()
+	defer t.removePeer(p)
+
+	for {
+		b, err := readFrame(p.conn, t.maxFrame)
+		if err != nil {
+			return
+		}
+
+		env := new(kangaroop2ppb.KangarooEnvelope)
+		if err = proto.Unmarshal(b, env); err != nil {
+			log.Printf("[P2P] malformed frame from %s: %v", p.id.ShortString(8), err)
+			return
+		}
+
+		msg := p2p.Message{Type: p2p.MessageType(env.Type), Payload: env.Payload}
+		if err = t.cfg.Limits.Check(msg); err != nil {
+			log.Printf("[P2P] dropping %s: %v", p.id.ShortString(8), err)
+			return
+		}
+
+		t.Dispatch(p.id, msg)
+	}
+}
+
+func (t *Transport) writeLoop(p *peer) {
+	defer t.wg.Done()
+	defer t.removePeer(p)
+
+	for {
+		select {
+		case <-p.closed:
+			return
+		case b := <-p.out:
+			if err := p.conn.SetWriteDeadline(time.Now().Add(t.cfg.WriteTimeout)); err != nil {
+				return
+			}
+			if err := writeFrame(p.conn, b); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (t *Transport) peer(id p2p.PeerID) (*peer, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p, ok := t.peers[id]
+	return p, ok
+}
+
+func (t *Transport) Peers() []p2p.PeerID {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ids := make([]p2p.PeerID, 0, len(t.peers))
+	for id := range t.peers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// PeerPublicKey returns the key a connected peer proved in its handshake.
+func (t *Transport) PeerPublicKey(id p2p.PeerID) (key.PublicKey, bool) {
+	p, ok := t.peer(id)
+	if !ok {
+		return nil, false
+	}
+	return p.pubKey, true
+}
+
+// Send queues msg for the peer. It never blocks; a peer that does not keep up
+// with its queue gets ErrSendQueueFull.
+func (t *Transport) Send(to p2p.PeerID, msg p2p.Message) error {
+	if err := t.cfg.Limits.Check(msg); err != nil {
+		return err
+	}
+
+	p, ok := t.peer(to)
+	if !ok {
+		return fmt.Errorf("%w: %s", p2p.ErrUnknownPeer, to)
+	}
+
+	b, err := proto.Marshal(&kangaroop2ppb.KangarooEnvelope{Type: uint32(msg.Type), Payload: msg.Payload})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-p.closed:
+		return fmt.Errorf("%w: %s", p2p.ErrUnknownPeer, to)
+	case p.out <- b:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrSendQueueFull, to)
+	}
+}
+
+func (t *Transport) Broadcast(msg p2p.Message) {
+	for _, id := range t.Peers() {
+		_ = t.Send(id, msg)
+	}
+}
+
+func (t *Transport) Disconnect(id p2p.PeerID) error {
+	p, ok := t.peer(id)
+	if !ok {
+		return fmt.Errorf("%w: %s", p2p.ErrUnknownPeer, id)
+	}
+	t.removePeer(p)
+	return nil
+}
+
+// Close stops listening, drops every peer and waits for the connection
+// goroutines to finish.
+func (t *Transport) Close() error {
+	t.lock.Lock()
+	if t.closed {
+		t.lock.Unlock()
+		return nil
+	}
+	t.closed = true
+	listeners := t.listeners
+	peers := make([]*peer, 0, len(t.peers))
+	for _, p := range t.peers {
+		peers = append(peers, p)
+	}
+	t.lock.Unlock()
+
+	for _, l := range listeners {
+		_ = l.Close()
+	}
+	for _, p := range peers {
+		t.removePeer(p)
+	}
+
+	t.wg.Wait()
+	return nil
+}
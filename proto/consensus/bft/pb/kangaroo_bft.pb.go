@@ -0,0 +1,398 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: consensus/bft/kangaroo_bft.proto
+
+package kangaroobftpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooProposal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        uint64                 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Round         uint64                 `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	PolRound      int64                  `protobuf:"varint,3,opt,name=pol_round,json=polRound,proto3" json:"pol_round,omitempty"`
+	Block         []byte                 `protobuf:"bytes,4,opt,name=block,proto3" json:"block,omitempty"`
+	Attestation   []byte                 `protobuf:"bytes,5,opt,name=attestation,proto3" json:"attestation,omitempty"`
+	ProposerProof []byte                 `protobuf:"bytes,6,opt,name=proposer_proof,json=proposerProof,proto3" json:"proposer_proof,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooProposal) Reset() {
+	*x = KangarooProposal{}
+	mi := &file_consensus_bft_kangaroo_bft_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooProposal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooProposal) ProtoMessage() {}
+
+func (x *KangarooProposal) ProtoReflect() protoreflect.Message {
+	mi := &file_consensus_bft_kangaroo_bft_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooProposal.ProtoReflect.Descriptor instead.
+func (*KangarooProposal) Descriptor() ([]byte, []int) {
+	return file_consensus_bft_kangaroo_bft_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooProposal) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooProposal) GetRound() uint64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *KangarooProposal) GetPolRound() int64 {
+	if x != nil {
+		return x.PolRound
+	}
+	return 0
+}
+
+func (x *KangarooProposal) GetBlock() []byte {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *KangarooProposal) GetAttestation() []byte {
+	if x != nil {
+		return x.Attestation
+	}
+	return nil
+}
+
+func (x *KangarooProposal) GetProposerProof() []byte {
+	if x != nil {
+		return x.ProposerProof
+	}
+	return nil
+}
+
+type KangarooProposalData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        uint64                 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Round         uint64                 `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	PolRound      int64                  `protobuf:"varint,3,opt,name=pol_round,json=polRound,proto3" json:"pol_round,omitempty"`
+	BlockId       []byte                 `protobuf:"bytes,4,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooProposalData) Reset() {
+	*x = KangarooProposalData{}
+	mi := &file_consensus_bft_kangaroo_bft_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooProposalData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooProposalData) ProtoMessage() {}
+
+func (x *KangarooProposalData) ProtoReflect() protoreflect.Message {
+	mi := &file_consensus_bft_kangaroo_bft_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooProposalData.ProtoReflect.Descriptor instead.
+func (*KangarooProposalData) Descriptor() ([]byte, []int) {
+	return file_consensus_bft_kangaroo_bft_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KangarooProposalData) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooProposalData) GetRound() uint64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *KangarooProposalData) GetPolRound() int64 {
+	if x != nil {
+		return x.PolRound
+	}
+	return 0
+}
+
+func (x *KangarooProposalData) GetBlockId() []byte {
+	if x != nil {
+		return x.BlockId
+	}
+	return nil
+}
+
+type KangarooVote struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VoteType      uint32                 `protobuf:"varint,1,opt,name=vote_type,json=voteType,proto3" json:"vote_type,omitempty"`
+	Height        uint64                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Round         uint64                 `protobuf:"varint,3,opt,name=round,proto3" json:"round,omitempty"`
+	BlockId       []byte                 `protobuf:"bytes,4,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	Attestation   []byte                 `protobuf:"bytes,5,opt,name=attestation,proto3" json:"attestation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooVote) Reset() {
+	*x = KangarooVote{}
+	mi := &file_consensus_bft_kangaroo_bft_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooVote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooVote) ProtoMessage() {}
+
+func (x *KangarooVote) ProtoReflect() protoreflect.Message {
+	mi := &file_consensus_bft_kangaroo_bft_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooVote.ProtoReflect.Descriptor instead.
+func (*KangarooVote) Descriptor() ([]byte, []int) {
+	return file_consensus_bft_kangaroo_bft_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KangarooVote) GetVoteType() uint32 {
+	if x != nil {
+		return x.VoteType
+	}
+	return 0
+}
+
+func (x *KangarooVote) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooVote) GetRound() uint64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *KangarooVote) GetBlockId() []byte {
+	if x != nil {
+		return x.BlockId
+	}
+	return nil
+}
+
+func (x *KangarooVote) GetAttestation() []byte {
+	if x != nil {
+		return x.Attestation
+	}
+	return nil
+}
+
+type KangarooVoteData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VoteType      uint32                 `protobuf:"varint,1,opt,name=vote_type,json=voteType,proto3" json:"vote_type,omitempty"`
+	Height        uint64                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Round         uint64                 `protobuf:"varint,3,opt,name=round,proto3" json:"round,omitempty"`
+	BlockId       []byte                 `protobuf:"bytes,4,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooVoteData) Reset() {
+	*x = KangarooVoteData{}
+	mi := &file_consensus_bft_kangaroo_bft_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooVoteData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooVoteData) ProtoMessage() {}
+
+func (x *KangarooVoteData) ProtoReflect() protoreflect.Message {
+	mi := &file_consensus_bft_kangaroo_bft_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooVoteData.ProtoReflect.Descriptor instead.
+func (*KangarooVoteData) Descriptor() ([]byte, []int) {
+	return file_consensus_bft_kangaroo_bft_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KangarooVoteData) GetVoteType() uint32 {
+	if x != nil {
+		return x.VoteType
+	}
+	return 0
+}
+
+func (x *KangarooVoteData) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooVoteData) GetRound() uint64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *KangarooVoteData) GetBlockId() []byte {
+	if x != nil {
+		return x.BlockId
+	}
+	return nil
+}
+
+var File_consensus_bft_kangaroo_bft_proto protoreflect.FileDescriptor
+
+const file_consensus_bft_kangaroo_bft_proto_rawDesc = "" +
+	"\n" +
+	" consensus/bft/kangaroo_bft.proto\x12\x03bft\"\xbc\x01\n" +
+	"\x10KangarooProposal\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x04R\x06height\x12\x14\n" +
+	"\x05round\x18\x02 \x01(\x04R\x05round\x12\x1b\n" +
+	"\tpol_round\x18\x03 \x01(\x03R\bpolRound\x12\x14\n" +
+	"\x05block\x18\x04 \x01(\fR\x05block\x12 \n" +
+	"\vattestation\x18\x05 \x01(\fR\vattestation\x12%\n" +
+	"\x0eproposer_proof\x18\x06 \x01(\fR\rproposerProof\"|\n" +
+	"\x14KangarooProposalData\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x04R\x06height\x12\x14\n" +
+	"\x05round\x18\x02 \x01(\x04R\x05round\x12\x1b\n" +
+	"\tpol_round\x18\x03 \x01(\x03R\bpolRound\x12\x19\n" +
+	"\bblock_id\x18\x04 \x01(\fR\ablockId\"\x96\x01\n" +
+	"\fKangarooVote\x12\x1b\n" +
+	"\tvote_type\x18\x01 \x01(\rR\bvoteType\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\x12\x14\n" +
+	"\x05round\x18\x03 \x01(\x04R\x05round\x12\x19\n" +
+	"\bblock_id\x18\x04 \x01(\fR\ablockId\x12 \n" +
+	"\vattestation\x18\x05 \x01(\fR\vattestation\"x\n" +
+	"\x10KangarooVoteData\x12\x1b\n" +
+	"\tvote_type\x18\x01 \x01(\rR\bvoteType\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\x12\x14\n" +
+	"\x05round\x18\x03 \x01(\x04R\x05round\x12\x19\n" +
+	"\bblock_id\x18\x04 \x01(\fR\ablockIdB Z\x1econsensus/bft/pb;kangaroobftpbb\x06proto3"
+
+var (
+	file_consensus_bft_kangaroo_bft_proto_rawDescOnce sync.Once
+	file_consensus_bft_kangaroo_bft_proto_rawDescData []byte
+)
+
+func file_consensus_bft_kangaroo_bft_proto_rawDescGZIP() []byte {
+	file_consensus_bft_kangaroo_bft_proto_rawDescOnce.Do(func() {
+		file_consensus_bft_kangaroo_bft_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_consensus_bft_kangaroo_bft_proto_rawDesc), len(file_consensus_bft_kangaroo_bft_proto_rawDesc)))
+	})
+	return file_consensus_bft_kangaroo_bft_proto_rawDescData
+}
+
+var file_consensus_bft_kangaroo_bft_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_consensus_bft_kangaroo_bft_proto_goTypes = []any{
+	(*KangarooProposal)(nil),     // 0: bft.KangarooProposal
+	(*KangarooProposalData)(nil), // 1: bft.KangarooProposalData
+	(*KangarooVote)(nil),         // 2: bft.KangarooVote
+	(*KangarooVoteData)(nil),     // 3: bft.KangarooVoteData
+}
+var file_consensus_bft_kangaroo_bft_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_consensus_bft_kangaroo_bft_proto_init() }
+func file_consensus_bft_kangaroo_bft_proto_init() {
+	if File_consensus_bft_kangaroo_bft_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_consensus_bft_kangaroo_bft_proto_rawDesc), len(file_consensus_bft_kangaroo_bft_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_consensus_bft_kangaroo_bft_proto_goTypes,
+		DependencyIndexes: file_consensus_bft_kangaroo_bft_proto_depIdxs,
+		MessageInfos:      file_consensus_bft_kangaroo_bft_proto_msgTypes,
+	}.Build()
+	File_consensus_bft_kangaroo_bft_proto = out.File
+	file_consensus_bft_kangaroo_bft_proto_goTypes = nil
+	file_consensus_bft_kangaroo_bft_proto_depIdxs = nil
+}
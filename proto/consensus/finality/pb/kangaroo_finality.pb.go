@@ -0,0 +1,265 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: consensus/finality/kangaroo_finality.proto
+
+package kangaroofinalitypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooCheckpoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Epoch         uint64                 `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Height        uint64                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	BlockId       []byte                 `protobuf:"bytes,3,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooCheckpoint) Reset() {
+	*x = KangarooCheckpoint{}
+	mi := &file_consensus_finality_kangaroo_finality_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooCheckpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooCheckpoint) ProtoMessage() {}
+
+func (x *KangarooCheckpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_consensus_finality_kangaroo_finality_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooCheckpoint.ProtoReflect.Descriptor instead.
+func (*KangarooCheckpoint) Descriptor() ([]byte, []int) {
+	return file_consensus_finality_kangaroo_finality_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooCheckpoint) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *KangarooCheckpoint) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooCheckpoint) GetBlockId() []byte {
+	if x != nil {
+		return x.BlockId
+	}
+	return nil
+}
+
+type KangarooCheckpointVote struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        *KangarooCheckpoint    `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Target        *KangarooCheckpoint    `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Attestation   []byte                 `protobuf:"bytes,3,opt,name=attestation,proto3" json:"attestation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooCheckpointVote) Reset() {
+	*x = KangarooCheckpointVote{}
+	mi := &file_consensus_finality_kangaroo_finality_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooCheckpointVote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooCheckpointVote) ProtoMessage() {}
+
+func (x *KangarooCheckpointVote) ProtoReflect() protoreflect.Message {
+	mi := &file_consensus_finality_kangaroo_finality_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooCheckpointVote.ProtoReflect.Descriptor instead.
+func (*KangarooCheckpointVote) Descriptor() ([]byte, []int) {
+	return file_consensus_finality_kangaroo_finality_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KangarooCheckpointVote) GetSource() *KangarooCheckpoint {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *KangarooCheckpointVote) GetTarget() *KangarooCheckpoint {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *KangarooCheckpointVote) GetAttestation() []byte {
+	if x != nil {
+		return x.Attestation
+	}
+	return nil
+}
+
+type KangarooCheckpointVoteData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        *KangarooCheckpoint    `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Target        *KangarooCheckpoint    `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooCheckpointVoteData) Reset() {
+	*x = KangarooCheckpointVoteData{}
+	mi := &file_consensus_finality_kangaroo_finality_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooCheckpointVoteData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooCheckpointVoteData) ProtoMessage() {}
+
+func (x *KangarooCheckpointVoteData) ProtoReflect() protoreflect.Message {
+	mi := &file_consensus_finality_kangaroo_finality_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooCheckpointVoteData.ProtoReflect.Descriptor instead.
+func (*KangarooCheckpointVoteData) Descriptor() ([]byte, []int) {
+	return file_consensus_finality_kangaroo_finality_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KangarooCheckpointVoteData) GetSource() *KangarooCheckpoint {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *KangarooCheckpointVoteData) GetTarget() *KangarooCheckpoint {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+var File_consensus_finality_kangaroo_finality_proto protoreflect.FileDescriptor
+
+const file_consensus_finality_kangaroo_finality_proto_rawDesc = "" +
+	"\n" +
+	"*consensus/finality/kangaroo_finality.proto\x12\bfinality\"]\n" +
+	"\x12KangarooCheckpoint\x12\x14\n" +
+	"\x05epoch\x18\x01 \x01(\x04R\x05epoch\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\x12\x19\n" +
+	"\bblock_id\x18\x03 \x01(\fR\ablockId\"\xa6\x01\n" +
+	"\x16KangarooCheckpointVote\x124\n" +
+	"\x06source\x18\x01 \x01(\v2\x1c.finality.KangarooCheckpointR\x06source\x124\n" +
+	"\x06target\x18\x02 \x01(\v2\x1c.finality.KangarooCheckpointR\x06target\x12 \n" +
+	"\vattestation\x18\x03 \x01(\fR\vattestation\"\x88\x01\n" +
+	"\x1aKangarooCheckpointVoteData\x124\n" +
+	"\x06source\x18\x01 \x01(\v2\x1c.finality.KangarooCheckpointR\x06source\x124\n" +
+	"\x06target\x18\x02 \x01(\v2\x1c.finality.KangarooCheckpointR\x06targetB*Z(consensus/finality/pb;kangaroofinalitypbb\x06proto3"
+
+var (
+	file_consensus_finality_kangaroo_finality_proto_rawDescOnce sync.Once
+	file_consensus_finality_kangaroo_finality_proto_rawDescData []byte
+)
+
+func file_consensus_finality_kangaroo_finality_proto_rawDescGZIP() []byte {
+	file_consensus_finality_kangaroo_finality_proto_rawDescOnce.Do(func() {
+		file_consensus_finality_kangaroo_finality_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_consensus_finality_kangaroo_finality_proto_rawDesc), len(file_consensus_finality_kangaroo_finality_proto_rawDesc)))
+	})
+	return file_consensus_finality_kangaroo_finality_proto_rawDescData
+}
+
+var file_consensus_finality_kangaroo_finality_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_consensus_finality_kangaroo_finality_proto_goTypes = []any{
+	(*KangarooCheckpoint)(nil),         // 0: finality.KangarooCheckpoint
+	(*KangarooCheckpointVote)(nil),     // 1: finality.KangarooCheckpointVote
+	(*KangarooCheckpointVoteData)(nil), // 2: finality.KangarooCheckpointVoteData
+}
+var file_consensus_finality_kangaroo_finality_proto_depIdxs = []int32{
+	0, // 0: finality.KangarooCheckpointVote.source:type_name -> finality.KangarooCheckpoint
+	0, // 1: finality.KangarooCheckpointVote.target:type_name -> finality.KangarooCheckpoint
+	0, // 2: finality.KangarooCheckpointVoteData.source:type_name -> finality.KangarooCheckpoint
+	0, // 3: finality.KangarooCheckpointVoteData.target:type_name -> finality.KangarooCheckpoint
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_consensus_finality_kangaroo_finality_proto_init() }
+func file_consensus_finality_kangaroo_finality_proto_init() {
+	if File_consensus_finality_kangaroo_finality_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_consensus_finality_kangaroo_finality_proto_rawDesc), len(file_consensus_finality_kangaroo_finality_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_consensus_finality_kangaroo_finality_proto_goTypes,
+		DependencyIndexes: file_consensus_finality_kangaroo_finality_proto_depIdxs,
+		MessageInfos:      file_consensus_finality_kangaroo_finality_proto_msgTypes,
+	}.Build()
+	File_consensus_finality_kangaroo_finality_proto = out.File
+	file_consensus_finality_kangaroo_finality_proto_goTypes = nil
+	file_consensus_finality_kangaroo_finality_proto_depIdxs = nil
+}
@@ -26,6 +26,9 @@ type KangarooAttestation struct {
 	Digest        []byte                 `protobuf:"bytes,1,opt,name=digest,proto3" json:"digest,omitempty"`
 	Signer        []byte                 `protobuf:"bytes,2,opt,name=signer,proto3" json:"signer,omitempty"`
 	Signature     []byte                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	Height        uint64                 `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	Round         uint64                 `protobuf:"varint,5,opt,name=round,proto3" json:"round,omitempty"`
+	Step          uint32                 `protobuf:"varint,6,opt,name=step,proto3" json:"step,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -81,15 +84,39 @@ func (x *KangarooAttestation) GetSignature() []byte {
 	return nil
 }
 
+func (x *KangarooAttestation) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooAttestation) GetRound() uint64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *KangarooAttestation) GetStep() uint32 {
+	if x != nil {
+		return x.Step
+	}
+	return 0
+}
+
 var File_core_block_kangaroo_attestation_proto protoreflect.FileDescriptor
 
 const file_core_block_kangaroo_attestation_proto_rawDesc = "" +
 	"\n" +
-	"%core/block/kangaroo_attestation.proto\x12\x05block\"c\n" +
+	"%core/block/kangaroo_attestation.proto\x12\x05block\"\xa5\x01\n" +
 	"\x13KangarooAttestation\x12\x16\n" +
 	"\x06digest\x18\x01 \x01(\fR\x06digest\x12\x16\n" +
 	"\x06signer\x18\x02 \x01(\fR\x06signer\x12\x1c\n" +
-	"\tsignature\x18\x03 \x01(\fR\tsignatureB\x1fZ\x1dcore/block/pb;kangarooblockpbb\x06proto3"
+	"\tsignature\x18\x03 \x01(\fR\tsignature\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\x04R\x06height\x12\x14\n" +
+	"\x05round\x18\x05 \x01(\x04R\x05round\x12\x12\n" +
+	"\x04step\x18\x06 \x01(\rR\x04stepB\x1fZ\x1dcore/block/pb;kangarooblockpbb\x06proto3"
 
 var (
 	file_core_block_kangaroo_attestation_proto_rawDescOnce sync.Once
@@ -24,6 +24,7 @@ const (
 type KangarooBody struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Transactions  [][]byte               `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	Evidence      [][]byte               `protobuf:"bytes,2,rep,name=evidence,proto3" json:"evidence,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -65,13 +66,21 @@ func (x *KangarooBody) GetTransactions() [][]byte {
 	return nil
 }
 
+func (x *KangarooBody) GetEvidence() [][]byte {
+	if x != nil {
+		return x.Evidence
+	}
+	return nil
+}
+
 var File_core_block_kangaroo_body_proto protoreflect.FileDescriptor
 
 const file_core_block_kangaroo_body_proto_rawDesc = "" +
 	"\n" +
-	"\x1ecore/block/kangaroo_body.proto\x12\x05block\"2\n" +
+	"\x1ecore/block/kangaroo_body.proto\x12\x05block\"N\n" +
 	"\fKangarooBody\x12\"\n" +
-	"\ftransactions\x18\x01 \x03(\fR\ftransactionsB\x1fZ\x1dcore/block/pb;kangarooblockpbb\x06proto3"
+	"\ftransactions\x18\x01 \x03(\fR\ftransactions\x12\x1a\n" +
+	"\bevidence\x18\x02 \x03(\fR\bevidenceB\x1fZ\x1dcore/block/pb;kangarooblockpbb\x06proto3"
 
 var (
 	file_core_block_kangaroo_body_proto_rawDescOnce sync.Once
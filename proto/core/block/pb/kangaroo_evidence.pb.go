@@ -0,0 +1,149 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: core/block/kangaroo_evidence.proto
+
+package kangarooblockpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooEvidence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        uint64                 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Round         uint64                 `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	First         []byte                 `protobuf:"bytes,3,opt,name=first,proto3" json:"first,omitempty"`
+	Second        []byte                 `protobuf:"bytes,4,opt,name=second,proto3" json:"second,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooEvidence) Reset() {
+	*x = KangarooEvidence{}
+	mi := &file_core_block_kangaroo_evidence_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooEvidence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooEvidence) ProtoMessage() {}
+
+func (x *KangarooEvidence) ProtoReflect() protoreflect.Message {
+	mi := &file_core_block_kangaroo_evidence_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooEvidence.ProtoReflect.Descriptor instead.
+func (*KangarooEvidence) Descriptor() ([]byte, []int) {
+	return file_core_block_kangaroo_evidence_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooEvidence) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooEvidence) GetRound() uint64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *KangarooEvidence) GetFirst() []byte {
+	if x != nil {
+		return x.First
+	}
+	return nil
+}
+
+func (x *KangarooEvidence) GetSecond() []byte {
+	if x != nil {
+		return x.Second
+	}
+	return nil
+}
+
+var File_core_block_kangaroo_evidence_proto protoreflect.FileDescriptor
+
+const file_core_block_kangaroo_evidence_proto_rawDesc = "" +
+	"\n" +
+	"\"core/block/kangaroo_evidence.proto\x12\x05block\"n\n" +
+	"\x10KangarooEvidence\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x04R\x06height\x12\x14\n" +
+	"\x05round\x18\x02 \x01(\x04R\x05round\x12\x14\n" +
+	"\x05first\x18\x03 \x01(\fR\x05first\x12\x16\n" +
+	"\x06second\x18\x04 \x01(\fR\x06secondB\x1fZ\x1dcore/block/pb;kangarooblockpbb\x06proto3"
+
+var (
+	file_core_block_kangaroo_evidence_proto_rawDescOnce sync.Once
+	file_core_block_kangaroo_evidence_proto_rawDescData []byte
+)
+
+func file_core_block_kangaroo_evidence_proto_rawDescGZIP() []byte {
+	file_core_block_kangaroo_evidence_proto_rawDescOnce.Do(func() {
+		file_core_block_kangaroo_evidence_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_core_block_kangaroo_evidence_proto_rawDesc), len(file_core_block_kangaroo_evidence_proto_rawDesc)))
+	})
+	return file_core_block_kangaroo_evidence_proto_rawDescData
+}
+
+var file_core_block_kangaroo_evidence_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_core_block_kangaroo_evidence_proto_goTypes = []any{
+	(*KangarooEvidence)(nil), // 0: block.KangarooEvidence
+}
+var file_core_block_kangaroo_evidence_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_core_block_kangaroo_evidence_proto_init() }
+func file_core_block_kangaroo_evidence_proto_init() {
+	if File_core_block_kangaroo_evidence_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_core_block_kangaroo_evidence_proto_rawDesc), len(file_core_block_kangaroo_evidence_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_core_block_kangaroo_evidence_proto_goTypes,
+		DependencyIndexes: file_core_block_kangaroo_evidence_proto_depIdxs,
+		MessageInfos:      file_core_block_kangaroo_evidence_proto_msgTypes,
+	}.Build()
+	File_core_block_kangaroo_evidence_proto = out.File
+	file_core_block_kangaroo_evidence_proto_goTypes = nil
+	file_core_block_kangaroo_evidence_proto_depIdxs = nil
+}
@@ -0,0 +1,186 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: core/block/kangaroo_header.proto
+
+package kangarooblockpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooHeader struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        uint64                 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	PrevBlockId   []byte                 `protobuf:"bytes,3,opt,name=prev_block_id,json=prevBlockId,proto3" json:"prev_block_id,omitempty"`
+	BodyHash      []byte                 `protobuf:"bytes,4,opt,name=body_hash,json=bodyHash,proto3" json:"body_hash,omitempty"`
+	StateRoot     []byte                 `protobuf:"bytes,5,opt,name=state_root,json=stateRoot,proto3" json:"state_root,omitempty"`
+	Proposer      []byte                 `protobuf:"bytes,6,opt,name=proposer,proto3" json:"proposer,omitempty"`
+	Nonce         uint64                 `protobuf:"varint,7,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Target        []byte                 `protobuf:"bytes,8,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooHeader) Reset() {
+	*x = KangarooHeader{}
+	mi := &file_core_block_kangaroo_header_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooHeader) ProtoMessage() {}
+
+func (x *KangarooHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_core_block_kangaroo_header_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooHeader.ProtoReflect.Descriptor instead.
+func (*KangarooHeader) Descriptor() ([]byte, []int) {
+	return file_core_block_kangaroo_header_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooHeader) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooHeader) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *KangarooHeader) GetPrevBlockId() []byte {
+	if x != nil {
+		return x.PrevBlockId
+	}
+	return nil
+}
+
+func (x *KangarooHeader) GetBodyHash() []byte {
+	if x != nil {
+		return x.BodyHash
+	}
+	return nil
+}
+
+func (x *KangarooHeader) GetStateRoot() []byte {
+	if x != nil {
+		return x.StateRoot
+	}
+	return nil
+}
+
+func (x *KangarooHeader) GetProposer() []byte {
+	if x != nil {
+		return x.Proposer
+	}
+	return nil
+}
+
+func (x *KangarooHeader) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *KangarooHeader) GetTarget() []byte {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+var File_core_block_kangaroo_header_proto protoreflect.FileDescriptor
+
+const file_core_block_kangaroo_header_proto_rawDesc = "" +
+	"\n" +
+	" core/block/kangaroo_header.proto\x12\x05block\"\xf0\x01\n" +
+	"\x0eKangarooHeader\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x04R\x06height\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\"\n" +
+	"\rprev_block_id\x18\x03 \x01(\fR\vprevBlockId\x12\x1b\n" +
+	"\tbody_hash\x18\x04 \x01(\fR\bbodyHash\x12\x1d\n" +
+	"\n" +
+	"state_root\x18\x05 \x01(\fR\tstateRoot\x12\x1a\n" +
+	"\bproposer\x18\x06 \x01(\fR\bproposer\x12\x14\n" +
+	"\x05nonce\x18\a \x01(\x04R\x05nonce\x12\x16\n" +
+	"\x06target\x18\b \x01(\fR\x06targetB\x1fZ\x1dcore/block/pb;kangarooblockpbb\x06proto3"
+
+var (
+	file_core_block_kangaroo_header_proto_rawDescOnce sync.Once
+	file_core_block_kangaroo_header_proto_rawDescData []byte
+)
+
+func file_core_block_kangaroo_header_proto_rawDescGZIP() []byte {
+	file_core_block_kangaroo_header_proto_rawDescOnce.Do(func() {
+		file_core_block_kangaroo_header_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_core_block_kangaroo_header_proto_rawDesc), len(file_core_block_kangaroo_header_proto_rawDesc)))
+	})
+	return file_core_block_kangaroo_header_proto_rawDescData
+}
+
+var file_core_block_kangaroo_header_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_core_block_kangaroo_header_proto_goTypes = []any{
+	(*KangarooHeader)(nil), // 0: block.KangarooHeader
+}
+var file_core_block_kangaroo_header_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_core_block_kangaroo_header_proto_init() }
+func file_core_block_kangaroo_header_proto_init() {
+	if File_core_block_kangaroo_header_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_core_block_kangaroo_header_proto_rawDesc), len(file_core_block_kangaroo_header_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_core_block_kangaroo_header_proto_goTypes,
+		DependencyIndexes: file_core_block_kangaroo_header_proto_depIdxs,
+		MessageInfos:      file_core_block_kangaroo_header_proto_msgTypes,
+	}.Build()
+	File_core_block_kangaroo_header_proto = out.File
+	file_core_block_kangaroo_header_proto_goTypes = nil
+	file_core_block_kangaroo_header_proto_depIdxs = nil
+}
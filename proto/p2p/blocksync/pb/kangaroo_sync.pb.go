@@ -0,0 +1,330 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: p2p/blocksync/kangaroo_sync.proto
+
+package kangaroosyncpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HeadHeight    uint64                 `protobuf:"varint,1,opt,name=head_height,json=headHeight,proto3" json:"head_height,omitempty"`
+	HeadHash      []byte                 `protobuf:"bytes,2,opt,name=head_hash,json=headHash,proto3" json:"head_hash,omitempty"`
+	GenesisHash   []byte                 `protobuf:"bytes,3,opt,name=genesis_hash,json=genesisHash,proto3" json:"genesis_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooStatus) Reset() {
+	*x = KangarooStatus{}
+	mi := &file_p2p_blocksync_kangaroo_sync_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooStatus) ProtoMessage() {}
+
+func (x *KangarooStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_blocksync_kangaroo_sync_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooStatus.ProtoReflect.Descriptor instead.
+func (*KangarooStatus) Descriptor() ([]byte, []int) {
+	return file_p2p_blocksync_kangaroo_sync_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooStatus) GetHeadHeight() uint64 {
+	if x != nil {
+		return x.HeadHeight
+	}
+	return 0
+}
+
+func (x *KangarooStatus) GetHeadHash() []byte {
+	if x != nil {
+		return x.HeadHash
+	}
+	return nil
+}
+
+func (x *KangarooStatus) GetGenesisHash() []byte {
+	if x != nil {
+		return x.GenesisHash
+	}
+	return nil
+}
+
+type KangarooRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Start         uint64                 `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	Count         uint64                 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooRangeRequest) Reset() {
+	*x = KangarooRangeRequest{}
+	mi := &file_p2p_blocksync_kangaroo_sync_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooRangeRequest) ProtoMessage() {}
+
+func (x *KangarooRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_blocksync_kangaroo_sync_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooRangeRequest.ProtoReflect.Descriptor instead.
+func (*KangarooRangeRequest) Descriptor() ([]byte, []int) {
+	return file_p2p_blocksync_kangaroo_sync_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KangarooRangeRequest) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *KangarooRangeRequest) GetStart() uint64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *KangarooRangeRequest) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type KangarooHeadersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Headers       [][]byte               `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooHeadersResponse) Reset() {
+	*x = KangarooHeadersResponse{}
+	mi := &file_p2p_blocksync_kangaroo_sync_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooHeadersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooHeadersResponse) ProtoMessage() {}
+
+func (x *KangarooHeadersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_blocksync_kangaroo_sync_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooHeadersResponse.ProtoReflect.Descriptor instead.
+func (*KangarooHeadersResponse) Descriptor() ([]byte, []int) {
+	return file_p2p_blocksync_kangaroo_sync_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KangarooHeadersResponse) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *KangarooHeadersResponse) GetHeaders() [][]byte {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+type KangarooBodiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Bodies        [][]byte               `protobuf:"bytes,2,rep,name=bodies,proto3" json:"bodies,omitempty"`
+	Tails         [][]byte               `protobuf:"bytes,3,rep,name=tails,proto3" json:"tails,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooBodiesResponse) Reset() {
+	*x = KangarooBodiesResponse{}
+	mi := &file_p2p_blocksync_kangaroo_sync_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooBodiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooBodiesResponse) ProtoMessage() {}
+
+func (x *KangarooBodiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_blocksync_kangaroo_sync_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooBodiesResponse.ProtoReflect.Descriptor instead.
+func (*KangarooBodiesResponse) Descriptor() ([]byte, []int) {
+	return file_p2p_blocksync_kangaroo_sync_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KangarooBodiesResponse) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *KangarooBodiesResponse) GetBodies() [][]byte {
+	if x != nil {
+		return x.Bodies
+	}
+	return nil
+}
+
+func (x *KangarooBodiesResponse) GetTails() [][]byte {
+	if x != nil {
+		return x.Tails
+	}
+	return nil
+}
+
+var File_p2p_blocksync_kangaroo_sync_proto protoreflect.FileDescriptor
+
+const file_p2p_blocksync_kangaroo_sync_proto_rawDesc = "" +
+	"\n" +
+	"!p2p/blocksync/kangaroo_sync.proto\x12\tblocksync\"q\n" +
+	"\x0eKangarooStatus\x12\x1f\n" +
+	"\vhead_height\x18\x01 \x01(\x04R\n" +
+	"headHeight\x12\x1b\n" +
+	"\thead_hash\x18\x02 \x01(\fR\bheadHash\x12!\n" +
+	"\fgenesis_hash\x18\x03 \x01(\fR\vgenesisHash\"a\n" +
+	"\x14KangarooRangeRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x04R\x05start\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x04R\x05count\"R\n" +
+	"\x17KangarooHeadersResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x18\n" +
+	"\aheaders\x18\x02 \x03(\fR\aheaders\"e\n" +
+	"\x16KangarooBodiesResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x16\n" +
+	"\x06bodies\x18\x02 \x03(\fR\x06bodies\x12\x14\n" +
+	"\x05tails\x18\x03 \x03(\fR\x05tailsB!Z\x1fp2p/blocksync/pb;kangaroosyncpbb\x06proto3"
+
+var (
+	file_p2p_blocksync_kangaroo_sync_proto_rawDescOnce sync.Once
+	file_p2p_blocksync_kangaroo_sync_proto_rawDescData []byte
+)
+
+func file_p2p_blocksync_kangaroo_sync_proto_rawDescGZIP() []byte {
+	file_p2p_blocksync_kangaroo_sync_proto_rawDescOnce.Do(func() {
+		file_p2p_blocksync_kangaroo_sync_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_p2p_blocksync_kangaroo_sync_proto_rawDesc), len(file_p2p_blocksync_kangaroo_sync_proto_rawDesc)))
+	})
+	return file_p2p_blocksync_kangaroo_sync_proto_rawDescData
+}
+
+var file_p2p_blocksync_kangaroo_sync_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_p2p_blocksync_kangaroo_sync_proto_goTypes = []any{
+	(*KangarooStatus)(nil),          // 0: blocksync.KangarooStatus
+	(*KangarooRangeRequest)(nil),    // 1: blocksync.KangarooRangeRequest
+	(*KangarooHeadersResponse)(nil), // 2: blocksync.KangarooHeadersResponse
+	(*KangarooBodiesResponse)(nil),  // 3: blocksync.KangarooBodiesResponse
+}
+var file_p2p_blocksync_kangaroo_sync_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_p2p_blocksync_kangaroo_sync_proto_init() }
+func file_p2p_blocksync_kangaroo_sync_proto_init() {
+	if File_p2p_blocksync_kangaroo_sync_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_p2p_blocksync_kangaroo_sync_proto_rawDesc), len(file_p2p_blocksync_kangaroo_sync_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_p2p_blocksync_kangaroo_sync_proto_goTypes,
+		DependencyIndexes: file_p2p_blocksync_kangaroo_sync_proto_depIdxs,
+		MessageInfos:      file_p2p_blocksync_kangaroo_sync_proto_msgTypes,
+	}.Build()
+	File_p2p_blocksync_kangaroo_sync_proto = out.File
+	file_p2p_blocksync_kangaroo_sync_proto_goTypes = nil
+	file_p2p_blocksync_kangaroo_sync_proto_depIdxs = nil
+}
@@ -0,0 +1,355 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: p2p/compact/kangaroo_compact.proto
+
+package kangaroocompactpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooCompactBlock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Header        []byte                 `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Tail          []byte                 `protobuf:"bytes,2,opt,name=tail,proto3" json:"tail,omitempty"`
+	ShortIds      []uint64               `protobuf:"fixed64,3,rep,packed,name=short_ids,json=shortIds,proto3" json:"short_ids,omitempty"`
+	Evidence      [][]byte               `protobuf:"bytes,4,rep,name=evidence,proto3" json:"evidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooCompactBlock) Reset() {
+	*x = KangarooCompactBlock{}
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooCompactBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooCompactBlock) ProtoMessage() {}
+
+func (x *KangarooCompactBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooCompactBlock.ProtoReflect.Descriptor instead.
+func (*KangarooCompactBlock) Descriptor() ([]byte, []int) {
+	return file_p2p_compact_kangaroo_compact_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooCompactBlock) GetHeader() []byte {
+	if x != nil {
+		return x.Header
+	}
+	return nil
+}
+
+func (x *KangarooCompactBlock) GetTail() []byte {
+	if x != nil {
+		return x.Tail
+	}
+	return nil
+}
+
+func (x *KangarooCompactBlock) GetShortIds() []uint64 {
+	if x != nil {
+		return x.ShortIds
+	}
+	return nil
+}
+
+func (x *KangarooCompactBlock) GetEvidence() [][]byte {
+	if x != nil {
+		return x.Evidence
+	}
+	return nil
+}
+
+type KangarooGetTransactions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlockId       []byte                 `protobuf:"bytes,1,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	Indexes       []uint32               `protobuf:"varint,2,rep,packed,name=indexes,proto3" json:"indexes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooGetTransactions) Reset() {
+	*x = KangarooGetTransactions{}
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooGetTransactions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooGetTransactions) ProtoMessage() {}
+
+func (x *KangarooGetTransactions) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooGetTransactions.ProtoReflect.Descriptor instead.
+func (*KangarooGetTransactions) Descriptor() ([]byte, []int) {
+	return file_p2p_compact_kangaroo_compact_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KangarooGetTransactions) GetBlockId() []byte {
+	if x != nil {
+		return x.BlockId
+	}
+	return nil
+}
+
+func (x *KangarooGetTransactions) GetIndexes() []uint32 {
+	if x != nil {
+		return x.Indexes
+	}
+	return nil
+}
+
+type KangarooTransactions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlockId       []byte                 `protobuf:"bytes,1,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	Transactions  [][]byte               `protobuf:"bytes,2,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooTransactions) Reset() {
+	*x = KangarooTransactions{}
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooTransactions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooTransactions) ProtoMessage() {}
+
+func (x *KangarooTransactions) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooTransactions.ProtoReflect.Descriptor instead.
+func (*KangarooTransactions) Descriptor() ([]byte, []int) {
+	return file_p2p_compact_kangaroo_compact_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KangarooTransactions) GetBlockId() []byte {
+	if x != nil {
+		return x.BlockId
+	}
+	return nil
+}
+
+func (x *KangarooTransactions) GetTransactions() [][]byte {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+type KangarooGetBlock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlockId       []byte                 `protobuf:"bytes,1,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooGetBlock) Reset() {
+	*x = KangarooGetBlock{}
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooGetBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooGetBlock) ProtoMessage() {}
+
+func (x *KangarooGetBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooGetBlock.ProtoReflect.Descriptor instead.
+func (*KangarooGetBlock) Descriptor() ([]byte, []int) {
+	return file_p2p_compact_kangaroo_compact_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KangarooGetBlock) GetBlockId() []byte {
+	if x != nil {
+		return x.BlockId
+	}
+	return nil
+}
+
+type KangarooFullBlock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Block         []byte                 `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooFullBlock) Reset() {
+	*x = KangarooFullBlock{}
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooFullBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooFullBlock) ProtoMessage() {}
+
+func (x *KangarooFullBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_compact_kangaroo_compact_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooFullBlock.ProtoReflect.Descriptor instead.
+func (*KangarooFullBlock) Descriptor() ([]byte, []int) {
+	return file_p2p_compact_kangaroo_compact_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *KangarooFullBlock) GetBlock() []byte {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+var File_p2p_compact_kangaroo_compact_proto protoreflect.FileDescriptor
+
+const file_p2p_compact_kangaroo_compact_proto_rawDesc = "" +
+	"\n" +
+	"\"p2p/compact/kangaroo_compact.proto\x12\acompact\"{\n" +
+	"\x14KangarooCompactBlock\x12\x16\n" +
+	"\x06header\x18\x01 \x01(\fR\x06header\x12\x12\n" +
+	"\x04tail\x18\x02 \x01(\fR\x04tail\x12\x1b\n" +
+	"\tshort_ids\x18\x03 \x03(\x06R\bshortIds\x12\x1a\n" +
+	"\bevidence\x18\x04 \x03(\fR\bevidence\"N\n" +
+	"\x17KangarooGetTransactions\x12\x19\n" +
+	"\bblock_id\x18\x01 \x01(\fR\ablockId\x12\x18\n" +
+	"\aindexes\x18\x02 \x03(\rR\aindexes\"U\n" +
+	"\x14KangarooTransactions\x12\x19\n" +
+	"\bblock_id\x18\x01 \x01(\fR\ablockId\x12\"\n" +
+	"\ftransactions\x18\x02 \x03(\fR\ftransactions\"-\n" +
+	"\x10KangarooGetBlock\x12\x19\n" +
+	"\bblock_id\x18\x01 \x01(\fR\ablockId\")\n" +
+	"\x11KangarooFullBlock\x12\x14\n" +
+	"\x05block\x18\x01 \x01(\fR\x05blockB\"Z p2p/compact/pb;kangaroocompactpbb\x06proto3"
+
+var (
+	file_p2p_compact_kangaroo_compact_proto_rawDescOnce sync.Once
+	file_p2p_compact_kangaroo_compact_proto_rawDescData []byte
+)
+
+func file_p2p_compact_kangaroo_compact_proto_rawDescGZIP() []byte {
+	file_p2p_compact_kangaroo_compact_proto_rawDescOnce.Do(func() {
+		file_p2p_compact_kangaroo_compact_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_p2p_compact_kangaroo_compact_proto_rawDesc), len(file_p2p_compact_kangaroo_compact_proto_rawDesc)))
+	})
+	return file_p2p_compact_kangaroo_compact_proto_rawDescData
+}
+
+var file_p2p_compact_kangaroo_compact_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_p2p_compact_kangaroo_compact_proto_goTypes = []any{
+	(*KangarooCompactBlock)(nil),    // 0: compact.KangarooCompactBlock
+	(*KangarooGetTransactions)(nil), // 1: compact.KangarooGetTransactions
+	(*KangarooTransactions)(nil),    // 2: compact.KangarooTransactions
+	(*KangarooGetBlock)(nil),        // 3: compact.KangarooGetBlock
+	(*KangarooFullBlock)(nil),       // 4: compact.KangarooFullBlock
+}
+var file_p2p_compact_kangaroo_compact_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_p2p_compact_kangaroo_compact_proto_init() }
+func file_p2p_compact_kangaroo_compact_proto_init() {
+	if File_p2p_compact_kangaroo_compact_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_p2p_compact_kangaroo_compact_proto_rawDesc), len(file_p2p_compact_kangaroo_compact_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_p2p_compact_kangaroo_compact_proto_goTypes,
+		DependencyIndexes: file_p2p_compact_kangaroo_compact_proto_depIdxs,
+		MessageInfos:      file_p2p_compact_kangaroo_compact_proto_msgTypes,
+	}.Build()
+	File_p2p_compact_kangaroo_compact_proto = out.File
+	file_p2p_compact_kangaroo_compact_proto_goTypes = nil
+	file_p2p_compact_kangaroo_compact_proto_depIdxs = nil
+}
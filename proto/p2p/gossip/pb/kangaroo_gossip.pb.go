@@ -0,0 +1,243 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: p2p/gossip/kangaroo_gossip.proto
+
+package kangaroogossippb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooGossip struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         uint32                 `protobuf:"varint,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooGossip) Reset() {
+	*x = KangarooGossip{}
+	mi := &file_p2p_gossip_kangaroo_gossip_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooGossip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooGossip) ProtoMessage() {}
+
+func (x *KangarooGossip) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_gossip_kangaroo_gossip_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooGossip.ProtoReflect.Descriptor instead.
+func (*KangarooGossip) Descriptor() ([]byte, []int) {
+	return file_p2p_gossip_kangaroo_gossip_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooGossip) GetTopic() uint32 {
+	if x != nil {
+		return x.Topic
+	}
+	return 0
+}
+
+func (x *KangarooGossip) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type KangarooAnnounce struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         uint32                 `protobuf:"varint,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Ids           [][]byte               `protobuf:"bytes,2,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooAnnounce) Reset() {
+	*x = KangarooAnnounce{}
+	mi := &file_p2p_gossip_kangaroo_gossip_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooAnnounce) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooAnnounce) ProtoMessage() {}
+
+func (x *KangarooAnnounce) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_gossip_kangaroo_gossip_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooAnnounce.ProtoReflect.Descriptor instead.
+func (*KangarooAnnounce) Descriptor() ([]byte, []int) {
+	return file_p2p_gossip_kangaroo_gossip_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KangarooAnnounce) GetTopic() uint32 {
+	if x != nil {
+		return x.Topic
+	}
+	return 0
+}
+
+func (x *KangarooAnnounce) GetIds() [][]byte {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type KangarooRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         uint32                 `protobuf:"varint,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Ids           [][]byte               `protobuf:"bytes,2,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooRequest) Reset() {
+	*x = KangarooRequest{}
+	mi := &file_p2p_gossip_kangaroo_gossip_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooRequest) ProtoMessage() {}
+
+func (x *KangarooRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_gossip_kangaroo_gossip_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooRequest.ProtoReflect.Descriptor instead.
+func (*KangarooRequest) Descriptor() ([]byte, []int) {
+	return file_p2p_gossip_kangaroo_gossip_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KangarooRequest) GetTopic() uint32 {
+	if x != nil {
+		return x.Topic
+	}
+	return 0
+}
+
+func (x *KangarooRequest) GetIds() [][]byte {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+var File_p2p_gossip_kangaroo_gossip_proto protoreflect.FileDescriptor
+
+const file_p2p_gossip_kangaroo_gossip_proto_rawDesc = "" +
+	"\n" +
+	" p2p/gossip/kangaroo_gossip.proto\x12\x06gossip\"@\n" +
+	"\x0eKangarooGossip\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\rR\x05topic\x12\x18\n" +
+	"\apayload\x18\x02 \x01(\fR\apayload\":\n" +
+	"\x10KangarooAnnounce\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\rR\x05topic\x12\x10\n" +
+	"\x03ids\x18\x02 \x03(\fR\x03ids\"9\n" +
+	"\x0fKangarooRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\rR\x05topic\x12\x10\n" +
+	"\x03ids\x18\x02 \x03(\fR\x03idsB Z\x1ep2p/gossip/pb;kangaroogossippbb\x06proto3"
+
+var (
+	file_p2p_gossip_kangaroo_gossip_proto_rawDescOnce sync.Once
+	file_p2p_gossip_kangaroo_gossip_proto_rawDescData []byte
+)
+
+func file_p2p_gossip_kangaroo_gossip_proto_rawDescGZIP() []byte {
+	file_p2p_gossip_kangaroo_gossip_proto_rawDescOnce.Do(func() {
+		file_p2p_gossip_kangaroo_gossip_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_p2p_gossip_kangaroo_gossip_proto_rawDesc), len(file_p2p_gossip_kangaroo_gossip_proto_rawDesc)))
+	})
+	return file_p2p_gossip_kangaroo_gossip_proto_rawDescData
+}
+
+var file_p2p_gossip_kangaroo_gossip_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_p2p_gossip_kangaroo_gossip_proto_goTypes = []any{
+	(*KangarooGossip)(nil),   // 0: gossip.KangarooGossip
+	(*KangarooAnnounce)(nil), // 1: gossip.KangarooAnnounce
+	(*KangarooRequest)(nil),  // 2: gossip.KangarooRequest
+}
+var file_p2p_gossip_kangaroo_gossip_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_p2p_gossip_kangaroo_gossip_proto_init() }
+func file_p2p_gossip_kangaroo_gossip_proto_init() {
+	if File_p2p_gossip_kangaroo_gossip_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_p2p_gossip_kangaroo_gossip_proto_rawDesc), len(file_p2p_gossip_kangaroo_gossip_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_p2p_gossip_kangaroo_gossip_proto_goTypes,
+		DependencyIndexes: file_p2p_gossip_kangaroo_gossip_proto_depIdxs,
+		MessageInfos:      file_p2p_gossip_kangaroo_gossip_proto_msgTypes,
+	}.Build()
+	File_p2p_gossip_kangaroo_gossip_proto = out.File
+	file_p2p_gossip_kangaroo_gossip_proto_goTypes = nil
+	file_p2p_gossip_kangaroo_gossip_proto_depIdxs = nil
+}
@@ -0,0 +1,310 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: p2p/kangaroo_p2p.proto
+
+package kangaroop2ppb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooHello struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PublicKey       []byte                 `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	ChainId         string                 `protobuf:"bytes,2,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	ProtocolVersion uint32                 `protobuf:"varint,3,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	Nonce           []byte                 `protobuf:"bytes,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *KangarooHello) Reset() {
+	*x = KangarooHello{}
+	mi := &file_p2p_kangaroo_p2p_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooHello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooHello) ProtoMessage() {}
+
+func (x *KangarooHello) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_kangaroo_p2p_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooHello.ProtoReflect.Descriptor instead.
+func (*KangarooHello) Descriptor() ([]byte, []int) {
+	return file_p2p_kangaroo_p2p_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooHello) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *KangarooHello) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *KangarooHello) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *KangarooHello) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+type KangarooHelloProof struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Signature     []byte                 `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooHelloProof) Reset() {
+	*x = KangarooHelloProof{}
+	mi := &file_p2p_kangaroo_p2p_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooHelloProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooHelloProof) ProtoMessage() {}
+
+func (x *KangarooHelloProof) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_kangaroo_p2p_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooHelloProof.ProtoReflect.Descriptor instead.
+func (*KangarooHelloProof) Descriptor() ([]byte, []int) {
+	return file_p2p_kangaroo_p2p_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KangarooHelloProof) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+type KangarooEnvelope struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          uint32                 `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooEnvelope) Reset() {
+	*x = KangarooEnvelope{}
+	mi := &file_p2p_kangaroo_p2p_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooEnvelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooEnvelope) ProtoMessage() {}
+
+func (x *KangarooEnvelope) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_kangaroo_p2p_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooEnvelope.ProtoReflect.Descriptor instead.
+func (*KangarooEnvelope) Descriptor() ([]byte, []int) {
+	return file_p2p_kangaroo_p2p_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KangarooEnvelope) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *KangarooEnvelope) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type KangarooNoisePayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PublicKey     []byte                 `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Signature     []byte                 `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooNoisePayload) Reset() {
+	*x = KangarooNoisePayload{}
+	mi := &file_p2p_kangaroo_p2p_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooNoisePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooNoisePayload) ProtoMessage() {}
+
+func (x *KangarooNoisePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_kangaroo_p2p_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooNoisePayload.ProtoReflect.Descriptor instead.
+func (*KangarooNoisePayload) Descriptor() ([]byte, []int) {
+	return file_p2p_kangaroo_p2p_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KangarooNoisePayload) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *KangarooNoisePayload) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+var File_p2p_kangaroo_p2p_proto protoreflect.FileDescriptor
+
+const file_p2p_kangaroo_p2p_proto_rawDesc = "" +
+	"\n" +
+	"\x16p2p/kangaroo_p2p.proto\x12\x03p2p\"\x8a\x01\n" +
+	"\rKangarooHello\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x01 \x01(\fR\tpublicKey\x12\x19\n" +
+	"\bchain_id\x18\x02 \x01(\tR\achainId\x12)\n" +
+	"\x10protocol_version\x18\x03 \x01(\rR\x0fprotocolVersion\x12\x14\n" +
+	"\x05nonce\x18\x04 \x01(\fR\x05nonce\"2\n" +
+	"\x12KangarooHelloProof\x12\x1c\n" +
+	"\tsignature\x18\x01 \x01(\fR\tsignature\"@\n" +
+	"\x10KangarooEnvelope\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\rR\x04type\x12\x18\n" +
+	"\apayload\x18\x02 \x01(\fR\apayload\"S\n" +
+	"\x14KangarooNoisePayload\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x01 \x01(\fR\tpublicKey\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignatureB\x16Z\x14p2p/pb;kangaroop2ppbb\x06proto3"
+
+var (
+	file_p2p_kangaroo_p2p_proto_rawDescOnce sync.Once
+	file_p2p_kangaroo_p2p_proto_rawDescData []byte
+)
+
+func file_p2p_kangaroo_p2p_proto_rawDescGZIP() []byte {
+	file_p2p_kangaroo_p2p_proto_rawDescOnce.Do(func() {
+		file_p2p_kangaroo_p2p_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_p2p_kangaroo_p2p_proto_rawDesc), len(file_p2p_kangaroo_p2p_proto_rawDesc)))
+	})
+	return file_p2p_kangaroo_p2p_proto_rawDescData
+}
+
+var file_p2p_kangaroo_p2p_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_p2p_kangaroo_p2p_proto_goTypes = []any{
+	(*KangarooHello)(nil),        // 0: p2p.KangarooHello
+	(*KangarooHelloProof)(nil),   // 1: p2p.KangarooHelloProof
+	(*KangarooEnvelope)(nil),     // 2: p2p.KangarooEnvelope
+	(*KangarooNoisePayload)(nil), // 3: p2p.KangarooNoisePayload
+}
+var file_p2p_kangaroo_p2p_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_p2p_kangaroo_p2p_proto_init() }
+func file_p2p_kangaroo_p2p_proto_init() {
+	if File_p2p_kangaroo_p2p_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_p2p_kangaroo_p2p_proto_rawDesc), len(file_p2p_kangaroo_p2p_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_p2p_kangaroo_p2p_proto_goTypes,
+		DependencyIndexes: file_p2p_kangaroo_p2p_proto_depIdxs,
+		MessageInfos:      file_p2p_kangaroo_p2p_proto_msgTypes,
+	}.Build()
+	File_p2p_kangaroo_p2p_proto = out.File
+	file_p2p_kangaroo_p2p_proto_goTypes = nil
+	file_p2p_kangaroo_p2p_proto_depIdxs = nil
+}
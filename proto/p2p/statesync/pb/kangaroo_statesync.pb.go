@@ -0,0 +1,369 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: p2p/statesync/kangaroo_statesync.proto
+
+package kangaroostatesyncpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooSnapshotsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooSnapshotsRequest) Reset() {
+	*x = KangarooSnapshotsRequest{}
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooSnapshotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooSnapshotsRequest) ProtoMessage() {}
+
+func (x *KangarooSnapshotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooSnapshotsRequest.ProtoReflect.Descriptor instead.
+func (*KangarooSnapshotsRequest) Descriptor() ([]byte, []int) {
+	return file_p2p_statesync_kangaroo_statesync_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooSnapshotsRequest) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+type KangarooSnapshotOffer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Manifest      []byte                 `protobuf:"bytes,1,opt,name=manifest,proto3" json:"manifest,omitempty"`
+	Block         []byte                 `protobuf:"bytes,2,opt,name=block,proto3" json:"block,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooSnapshotOffer) Reset() {
+	*x = KangarooSnapshotOffer{}
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooSnapshotOffer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooSnapshotOffer) ProtoMessage() {}
+
+func (x *KangarooSnapshotOffer) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooSnapshotOffer.ProtoReflect.Descriptor instead.
+func (*KangarooSnapshotOffer) Descriptor() ([]byte, []int) {
+	return file_p2p_statesync_kangaroo_statesync_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KangarooSnapshotOffer) GetManifest() []byte {
+	if x != nil {
+		return x.Manifest
+	}
+	return nil
+}
+
+func (x *KangarooSnapshotOffer) GetBlock() []byte {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+type KangarooSnapshotsResponse struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	RequestId     uint64                   `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Offers        []*KangarooSnapshotOffer `protobuf:"bytes,2,rep,name=offers,proto3" json:"offers,omitempty"`
+	GenesisHash   []byte                   `protobuf:"bytes,3,opt,name=genesis_hash,json=genesisHash,proto3" json:"genesis_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooSnapshotsResponse) Reset() {
+	*x = KangarooSnapshotsResponse{}
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooSnapshotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooSnapshotsResponse) ProtoMessage() {}
+
+func (x *KangarooSnapshotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooSnapshotsResponse.ProtoReflect.Descriptor instead.
+func (*KangarooSnapshotsResponse) Descriptor() ([]byte, []int) {
+	return file_p2p_statesync_kangaroo_statesync_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KangarooSnapshotsResponse) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *KangarooSnapshotsResponse) GetOffers() []*KangarooSnapshotOffer {
+	if x != nil {
+		return x.Offers
+	}
+	return nil
+}
+
+func (x *KangarooSnapshotsResponse) GetGenesisHash() []byte {
+	if x != nil {
+		return x.GenesisHash
+	}
+	return nil
+}
+
+type KangarooChunkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Height        uint64                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Index         uint32                 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooChunkRequest) Reset() {
+	*x = KangarooChunkRequest{}
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooChunkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooChunkRequest) ProtoMessage() {}
+
+func (x *KangarooChunkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooChunkRequest.ProtoReflect.Descriptor instead.
+func (*KangarooChunkRequest) Descriptor() ([]byte, []int) {
+	return file_p2p_statesync_kangaroo_statesync_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KangarooChunkRequest) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *KangarooChunkRequest) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooChunkRequest) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type KangarooChunkResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Chunk         []byte                 `protobuf:"bytes,2,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooChunkResponse) Reset() {
+	*x = KangarooChunkResponse{}
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooChunkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooChunkResponse) ProtoMessage() {}
+
+func (x *KangarooChunkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_p2p_statesync_kangaroo_statesync_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooChunkResponse.ProtoReflect.Descriptor instead.
+func (*KangarooChunkResponse) Descriptor() ([]byte, []int) {
+	return file_p2p_statesync_kangaroo_statesync_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *KangarooChunkResponse) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *KangarooChunkResponse) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+var File_p2p_statesync_kangaroo_statesync_proto protoreflect.FileDescriptor
+
+const file_p2p_statesync_kangaroo_statesync_proto_rawDesc = "" +
+	"\n" +
+	"&p2p/statesync/kangaroo_statesync.proto\x12\tstatesync\"9\n" +
+	"\x18KangarooSnapshotsRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\"I\n" +
+	"\x15KangarooSnapshotOffer\x12\x1a\n" +
+	"\bmanifest\x18\x01 \x01(\fR\bmanifest\x12\x14\n" +
+	"\x05block\x18\x02 \x01(\fR\x05block\"\x97\x01\n" +
+	"\x19KangarooSnapshotsResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x128\n" +
+	"\x06offers\x18\x02 \x03(\v2 .statesync.KangarooSnapshotOfferR\x06offers\x12!\n" +
+	"\fgenesis_hash\x18\x03 \x01(\fR\vgenesisHash\"c\n" +
+	"\x14KangarooChunkRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\x12\x14\n" +
+	"\x05index\x18\x03 \x01(\rR\x05index\"L\n" +
+	"\x15KangarooChunkResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x14\n" +
+	"\x05chunk\x18\x02 \x01(\fR\x05chunkB&Z$p2p/statesync/pb;kangaroostatesyncpbb\x06proto3"
+
+var (
+	file_p2p_statesync_kangaroo_statesync_proto_rawDescOnce sync.Once
+	file_p2p_statesync_kangaroo_statesync_proto_rawDescData []byte
+)
+
+func file_p2p_statesync_kangaroo_statesync_proto_rawDescGZIP() []byte {
+	file_p2p_statesync_kangaroo_statesync_proto_rawDescOnce.Do(func() {
+		file_p2p_statesync_kangaroo_statesync_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_p2p_statesync_kangaroo_statesync_proto_rawDesc), len(file_p2p_statesync_kangaroo_statesync_proto_rawDesc)))
+	})
+	return file_p2p_statesync_kangaroo_statesync_proto_rawDescData
+}
+
+var file_p2p_statesync_kangaroo_statesync_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_p2p_statesync_kangaroo_statesync_proto_goTypes = []any{
+	(*KangarooSnapshotsRequest)(nil),  // 0: statesync.KangarooSnapshotsRequest
+	(*KangarooSnapshotOffer)(nil),     // 1: statesync.KangarooSnapshotOffer
+	(*KangarooSnapshotsResponse)(nil), // 2: statesync.KangarooSnapshotsResponse
+	(*KangarooChunkRequest)(nil),      // 3: statesync.KangarooChunkRequest
+	(*KangarooChunkResponse)(nil),     // 4: statesync.KangarooChunkResponse
+}
+var file_p2p_statesync_kangaroo_statesync_proto_depIdxs = []int32{
+	1, // 0: statesync.KangarooSnapshotsResponse.offers:type_name -> statesync.KangarooSnapshotOffer
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_p2p_statesync_kangaroo_statesync_proto_init() }
+func file_p2p_statesync_kangaroo_statesync_proto_init() {
+	if File_p2p_statesync_kangaroo_statesync_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_p2p_statesync_kangaroo_statesync_proto_rawDesc), len(file_p2p_statesync_kangaroo_statesync_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_p2p_statesync_kangaroo_statesync_proto_goTypes,
+		DependencyIndexes: file_p2p_statesync_kangaroo_statesync_proto_depIdxs,
+		MessageInfos:      file_p2p_statesync_kangaroo_statesync_proto_msgTypes,
+	}.Build()
+	File_p2p_statesync_kangaroo_statesync_proto = out.File
+	file_p2p_statesync_kangaroo_statesync_proto_goTypes = nil
+	file_p2p_statesync_kangaroo_statesync_proto_depIdxs = nil
+}
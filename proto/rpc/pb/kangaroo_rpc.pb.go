@@ -0,0 +1,898 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: rpc/kangaroo_rpc.proto
+
+package kangaroorpcpb
+
+import (
+	pb "github.com/andantan/kangaroo/proto/core/block/pb"
+	pb1 "github.com/andantan/kangaroo/proto/core/transaction/pb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooChainInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooChainInfoRequest) Reset() {
+	*x = KangarooChainInfoRequest{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooChainInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooChainInfoRequest) ProtoMessage() {}
+
+func (x *KangarooChainInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooChainInfoRequest.ProtoReflect.Descriptor instead.
+func (*KangarooChainInfoRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{0}
+}
+
+type KangarooChainInfo struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ChainId         string                 `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Height          uint64                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	HeadId          []byte                 `protobuf:"bytes,3,opt,name=head_id,json=headId,proto3" json:"head_id,omitempty"`
+	GenesisId       []byte                 `protobuf:"bytes,4,opt,name=genesis_id,json=genesisId,proto3" json:"genesis_id,omitempty"`
+	BaseHeight      uint64                 `protobuf:"varint,5,opt,name=base_height,json=baseHeight,proto3" json:"base_height,omitempty"`
+	FinalizedHeight uint64                 `protobuf:"varint,6,opt,name=finalized_height,json=finalizedHeight,proto3" json:"finalized_height,omitempty"`
+	FinalizedId     []byte                 `protobuf:"bytes,7,opt,name=finalized_id,json=finalizedId,proto3" json:"finalized_id,omitempty"`
+	HashSuite       string                 `protobuf:"bytes,8,opt,name=hash_suite,json=hashSuite,proto3" json:"hash_suite,omitempty"`
+	AddressSuite    string                 `protobuf:"bytes,9,opt,name=address_suite,json=addressSuite,proto3" json:"address_suite,omitempty"`
+	Pending         uint32                 `protobuf:"varint,10,opt,name=pending,proto3" json:"pending,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *KangarooChainInfo) Reset() {
+	*x = KangarooChainInfo{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooChainInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooChainInfo) ProtoMessage() {}
+
+func (x *KangarooChainInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooChainInfo.ProtoReflect.Descriptor instead.
+func (*KangarooChainInfo) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KangarooChainInfo) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *KangarooChainInfo) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooChainInfo) GetHeadId() []byte {
+	if x != nil {
+		return x.HeadId
+	}
+	return nil
+}
+
+func (x *KangarooChainInfo) GetGenesisId() []byte {
+	if x != nil {
+		return x.GenesisId
+	}
+	return nil
+}
+
+func (x *KangarooChainInfo) GetBaseHeight() uint64 {
+	if x != nil {
+		return x.BaseHeight
+	}
+	return 0
+}
+
+func (x *KangarooChainInfo) GetFinalizedHeight() uint64 {
+	if x != nil {
+		return x.FinalizedHeight
+	}
+	return 0
+}
+
+func (x *KangarooChainInfo) GetFinalizedId() []byte {
+	if x != nil {
+		return x.FinalizedId
+	}
+	return nil
+}
+
+func (x *KangarooChainInfo) GetHashSuite() string {
+	if x != nil {
+		return x.HashSuite
+	}
+	return ""
+}
+
+func (x *KangarooChainInfo) GetAddressSuite() string {
+	if x != nil {
+		return x.AddressSuite
+	}
+	return ""
+}
+
+func (x *KangarooChainInfo) GetPending() uint32 {
+	if x != nil {
+		return x.Pending
+	}
+	return 0
+}
+
+type KangarooSupportedSuitesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooSupportedSuitesRequest) Reset() {
+	*x = KangarooSupportedSuitesRequest{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooSupportedSuitesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooSupportedSuitesRequest) ProtoMessage() {}
+
+func (x *KangarooSupportedSuitesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooSupportedSuitesRequest.ProtoReflect.Descriptor instead.
+func (*KangarooSupportedSuitesRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{2}
+}
+
+type KangarooSupportedSuites struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Hashes        []string               `protobuf:"bytes,2,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	Addresses     []string               `protobuf:"bytes,3,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooSupportedSuites) Reset() {
+	*x = KangarooSupportedSuites{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooSupportedSuites) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooSupportedSuites) ProtoMessage() {}
+
+func (x *KangarooSupportedSuites) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooSupportedSuites.ProtoReflect.Descriptor instead.
+func (*KangarooSupportedSuites) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KangarooSupportedSuites) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *KangarooSupportedSuites) GetHashes() []string {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+func (x *KangarooSupportedSuites) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+type KangarooSendTransactionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          []byte                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooSendTransactionResponse) Reset() {
+	*x = KangarooSendTransactionResponse{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooSendTransactionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooSendTransactionResponse) ProtoMessage() {}
+
+func (x *KangarooSendTransactionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooSendTransactionResponse.ProtoReflect.Descriptor instead.
+func (*KangarooSendTransactionResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *KangarooSendTransactionResponse) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+type KangarooHashRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          []byte                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooHashRequest) Reset() {
+	*x = KangarooHashRequest{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooHashRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooHashRequest) ProtoMessage() {}
+
+func (x *KangarooHashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooHashRequest.ProtoReflect.Descriptor instead.
+func (*KangarooHashRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *KangarooHashRequest) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+type KangarooHeightRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        uint64                 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooHeightRequest) Reset() {
+	*x = KangarooHeightRequest{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooHeightRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooHeightRequest) ProtoMessage() {}
+
+func (x *KangarooHeightRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooHeightRequest.ProtoReflect.Descriptor instead.
+func (*KangarooHeightRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *KangarooHeightRequest) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type KangarooStreamPendingRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// addresses filters on sender or recipient, empty streams everything.
+	Addresses     [][]byte `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooStreamPendingRequest) Reset() {
+	*x = KangarooStreamPendingRequest{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooStreamPendingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooStreamPendingRequest) ProtoMessage() {}
+
+func (x *KangarooStreamPendingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooStreamPendingRequest.ProtoReflect.Descriptor instead.
+func (*KangarooStreamPendingRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *KangarooStreamPendingRequest) GetAddresses() [][]byte {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+type KangarooAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       []byte                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooAccountRequest) Reset() {
+	*x = KangarooAccountRequest{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooAccountRequest) ProtoMessage() {}
+
+func (x *KangarooAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooAccountRequest.ProtoReflect.Descriptor instead.
+func (*KangarooAccountRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *KangarooAccountRequest) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+type KangarooAccountInfo struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Address []byte                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Balance []byte                 `protobuf:"bytes,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	Nonce   uint64                 `protobuf:"varint,3,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// pending_nonce also counts the consecutive nonces waiting in the pool.
+	PendingNonce  uint64 `protobuf:"varint,4,opt,name=pending_nonce,json=pendingNonce,proto3" json:"pending_nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooAccountInfo) Reset() {
+	*x = KangarooAccountInfo{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooAccountInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooAccountInfo) ProtoMessage() {}
+
+func (x *KangarooAccountInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooAccountInfo.ProtoReflect.Descriptor instead.
+func (*KangarooAccountInfo) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *KangarooAccountInfo) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *KangarooAccountInfo) GetBalance() []byte {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+func (x *KangarooAccountInfo) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *KangarooAccountInfo) GetPendingNonce() uint64 {
+	if x != nil {
+		return x.PendingNonce
+	}
+	return 0
+}
+
+type KangarooAttestations struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	Attestations  []*pb.KangarooAttestation `protobuf:"bytes,1,rep,name=attestations,proto3" json:"attestations,omitempty"`
+	Round         uint64                    `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooAttestations) Reset() {
+	*x = KangarooAttestations{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooAttestations) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooAttestations) ProtoMessage() {}
+
+func (x *KangarooAttestations) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooAttestations.ProtoReflect.Descriptor instead.
+func (*KangarooAttestations) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *KangarooAttestations) GetAttestations() []*pb.KangarooAttestation {
+	if x != nil {
+		return x.Attestations
+	}
+	return nil
+}
+
+func (x *KangarooAttestations) GetRound() uint64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+type KangarooIncludedTransaction struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Transaction   *pb1.KangarooTransaction `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	Height        uint64                   `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	BlockId       []byte                   `protobuf:"bytes,3,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	Index         uint32                   `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooIncludedTransaction) Reset() {
+	*x = KangarooIncludedTransaction{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooIncludedTransaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooIncludedTransaction) ProtoMessage() {}
+
+func (x *KangarooIncludedTransaction) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooIncludedTransaction.ProtoReflect.Descriptor instead.
+func (*KangarooIncludedTransaction) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *KangarooIncludedTransaction) GetTransaction() *pb1.KangarooTransaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+func (x *KangarooIncludedTransaction) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooIncludedTransaction) GetBlockId() []byte {
+	if x != nil {
+		return x.BlockId
+	}
+	return nil
+}
+
+func (x *KangarooIncludedTransaction) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type KangarooStreamBlocksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// from_height replays stored blocks from that height before following the
+	// head; zero only follows new blocks.
+	FromHeight uint64 `protobuf:"varint,1,opt,name=from_height,json=fromHeight,proto3" json:"from_height,omitempty"`
+	// finalized streams finalized blocks instead of new heads.
+	Finalized     bool `protobuf:"varint,2,opt,name=finalized,proto3" json:"finalized,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooStreamBlocksRequest) Reset() {
+	*x = KangarooStreamBlocksRequest{}
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooStreamBlocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooStreamBlocksRequest) ProtoMessage() {}
+
+func (x *KangarooStreamBlocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_kangaroo_rpc_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooStreamBlocksRequest.ProtoReflect.Descriptor instead.
+func (*KangarooStreamBlocksRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_kangaroo_rpc_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *KangarooStreamBlocksRequest) GetFromHeight() uint64 {
+	if x != nil {
+		return x.FromHeight
+	}
+	return 0
+}
+
+func (x *KangarooStreamBlocksRequest) GetFinalized() bool {
+	if x != nil {
+		return x.Finalized
+	}
+	return false
+}
+
+var File_rpc_kangaroo_rpc_proto protoreflect.FileDescriptor
+
+const file_rpc_kangaroo_rpc_proto_rawDesc = "" +
+	"\n" +
+	"\x16rpc/kangaroo_rpc.proto\x12\x03rpc\x1a+core/transaction/kangaroo_transaction.proto\x1a\x1fcore/block/kangaroo_block.proto\x1a\x1ecore/block/kangaroo_body.proto\x1a%core/block/kangaroo_attestation.proto\"\x1a\n" +
+	"\x18KangarooChainInfoRequest\"\xcb\x02\n" +
+	"\x11KangarooChainInfo\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\tR\achainId\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\x12\x17\n" +
+	"\ahead_id\x18\x03 \x01(\fR\x06headId\x12\x1d\n" +
+	"\n" +
+	"genesis_id\x18\x04 \x01(\fR\tgenesisId\x12\x1f\n" +
+	"\vbase_height\x18\x05 \x01(\x04R\n" +
+	"baseHeight\x12)\n" +
+	"\x10finalized_height\x18\x06 \x01(\x04R\x0ffinalizedHeight\x12!\n" +
+	"\ffinalized_id\x18\a \x01(\fR\vfinalizedId\x12\x1d\n" +
+	"\n" +
+	"hash_suite\x18\b \x01(\tR\thashSuite\x12#\n" +
+	"\raddress_suite\x18\t \x01(\tR\faddressSuite\x12\x18\n" +
+	"\apending\x18\n" +
+	" \x01(\rR\apending\" \n" +
+	"\x1eKangarooSupportedSuitesRequest\"c\n" +
+	"\x17KangarooSupportedSuites\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\x12\x16\n" +
+	"\x06hashes\x18\x02 \x03(\tR\x06hashes\x12\x1c\n" +
+	"\taddresses\x18\x03 \x03(\tR\taddresses\"5\n" +
+	"\x1fKangarooSendTransactionResponse\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\fR\x04hash\")\n" +
+	"\x13KangarooHashRequest\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\fR\x04hash\"/\n" +
+	"\x15KangarooHeightRequest\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x04R\x06height\"<\n" +
+	"\x1cKangarooStreamPendingRequest\x12\x1c\n" +
+	"\taddresses\x18\x01 \x03(\fR\taddresses\"2\n" +
+	"\x16KangarooAccountRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\fR\aaddress\"\x84\x01\n" +
+	"\x13KangarooAccountInfo\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\fR\aaddress\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\fR\abalance\x12\x14\n" +
+	"\x05nonce\x18\x03 \x01(\x04R\x05nonce\x12#\n" +
+	"\rpending_nonce\x18\x04 \x01(\x04R\fpendingNonce\"l\n" +
+	"\x14KangarooAttestations\x12>\n" +
+	"\fattestations\x18\x01 \x03(\v2\x1a.block.KangarooAttestationR\fattestations\x12\x14\n" +
+	"\x05round\x18\x02 \x01(\x04R\x05round\"\xaa\x01\n" +
+	"\x1bKangarooIncludedTransaction\x12B\n" +
+	"\vtransaction\x18\x01 \x01(\v2 .transaction.KangarooTransactionR\vtransaction\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\x12\x19\n" +
+	"\bblock_id\x18\x03 \x01(\fR\ablockId\x12\x14\n" +
+	"\x05index\x18\x04 \x01(\rR\x05index\"\\\n" +
+	"\x1bKangarooStreamBlocksRequest\x12\x1f\n" +
+	"\vfrom_height\x18\x01 \x01(\x04R\n" +
+	"fromHeight\x12\x1c\n" +
+	"\tfinalized\x18\x02 \x01(\bR\tfinalized2\xa7\x01\n" +
+	"\x04Node\x12E\n" +
+	"\fGetChainInfo\x12\x1d.rpc.KangarooChainInfoRequest\x1a\x16.rpc.KangarooChainInfo\x12X\n" +
+	"\x13ListSupportedSuites\x12#.rpc.KangarooSupportedSuitesRequest\x1a\x1c.rpc.KangarooSupportedSuites2\x9d\x02\n" +
+	"\aMempool\x12Y\n" +
+	"\x0fSendTransaction\x12 .transaction.KangarooTransaction\x1a$.rpc.KangarooSendTransactionResponse\x12S\n" +
+	"\x15GetPendingTransaction\x12\x18.rpc.KangarooHashRequest\x1a .transaction.KangarooTransaction\x12b\n" +
+	"\x19StreamPendingTransactions\x12!.rpc.KangarooStreamPendingRequest\x1a .transaction.KangarooTransaction0\x012L\n" +
+	"\x05State\x12C\n" +
+	"\n" +
+	"GetAccount\x12\x1b.rpc.KangarooAccountRequest\x1a\x18.rpc.KangarooAccountInfo2\xaa\x03\n" +
+	"\x06Blocks\x12D\n" +
+	"\x10GetBlockByHeight\x12\x1a.rpc.KangarooHeightRequest\x1a\x14.block.KangarooBlock\x12@\n" +
+	"\x0eGetBlockByHash\x12\x18.rpc.KangarooHashRequest\x1a\x14.block.KangarooBlock\x128\n" +
+	"\aGetBody\x12\x18.rpc.KangarooHashRequest\x1a\x13.block.KangarooBody\x12F\n" +
+	"\x0fGetAttestations\x12\x18.rpc.KangarooHashRequest\x1a\x19.rpc.KangarooAttestations\x12L\n" +
+	"\x0eGetTransaction\x12\x18.rpc.KangarooHashRequest\x1a .rpc.KangarooIncludedTransaction\x12H\n" +
+	"\fStreamBlocks\x12 .rpc.KangarooStreamBlocksRequest\x1a\x14.block.KangarooBlock0\x01B\x16Z\x14rpc/pb;kangaroorpcpbb\x06proto3"
+
+var (
+	file_rpc_kangaroo_rpc_proto_rawDescOnce sync.Once
+	file_rpc_kangaroo_rpc_proto_rawDescData []byte
+)
+
+func file_rpc_kangaroo_rpc_proto_rawDescGZIP() []byte {
+	file_rpc_kangaroo_rpc_proto_rawDescOnce.Do(func() {
+		file_rpc_kangaroo_rpc_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_rpc_kangaroo_rpc_proto_rawDesc), len(file_rpc_kangaroo_rpc_proto_rawDesc)))
+	})
+	return file_rpc_kangaroo_rpc_proto_rawDescData
+}
+
+var file_rpc_kangaroo_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_rpc_kangaroo_rpc_proto_goTypes = []any{
+	(*KangarooChainInfoRequest)(nil),        // 0: rpc.KangarooChainInfoRequest
+	(*KangarooChainInfo)(nil),               // 1: rpc.KangarooChainInfo
+	(*KangarooSupportedSuitesRequest)(nil),  // 2: rpc.KangarooSupportedSuitesRequest
+	(*KangarooSupportedSuites)(nil),         // 3: rpc.KangarooSupportedSuites
+	(*KangarooSendTransactionResponse)(nil), // 4: rpc.KangarooSendTransactionResponse
+	(*KangarooHashRequest)(nil),             // 5: rpc.KangarooHashRequest
+	(*KangarooHeightRequest)(nil),           // 6: rpc.KangarooHeightRequest
+	(*KangarooStreamPendingRequest)(nil),    // 7: rpc.KangarooStreamPendingRequest
+	(*KangarooAccountRequest)(nil),          // 8: rpc.KangarooAccountRequest
+	(*KangarooAccountInfo)(nil),             // 9: rpc.KangarooAccountInfo
+	(*KangarooAttestations)(nil),            // 10: rpc.KangarooAttestations
+	(*KangarooIncludedTransaction)(nil),     // 11: rpc.KangarooIncludedTransaction
+	(*KangarooStreamBlocksRequest)(nil),     // 12: rpc.KangarooStreamBlocksRequest
+	(*pb.KangarooAttestation)(nil),          // 13: block.KangarooAttestation
+	(*pb1.KangarooTransaction)(nil),         // 14: transaction.KangarooTransaction
+	(*pb.KangarooBlock)(nil),                // 15: block.KangarooBlock
+	(*pb.KangarooBody)(nil),                 // 16: block.KangarooBody
+}
+var file_rpc_kangaroo_rpc_proto_depIdxs = []int32{
+	13, // 0: rpc.KangarooAttestations.attestations:type_name -> block.KangarooAttestation
+	14, // 1: rpc.KangarooIncludedTransaction.transaction:type_name -> transaction.KangarooTransaction
+	0,  // 2: rpc.Node.GetChainInfo:input_type -> rpc.KangarooChainInfoRequest
+	2,  // 3: rpc.Node.ListSupportedSuites:input_type -> rpc.KangarooSupportedSuitesRequest
+	14, // 4: rpc.Mempool.SendTransaction:input_type -> transaction.KangarooTransaction
+	5,  // 5: rpc.Mempool.GetPendingTransaction:input_type -> rpc.KangarooHashRequest
+	7,  // 6: rpc.Mempool.StreamPendingTransactions:input_type -> rpc.KangarooStreamPendingRequest
+	8,  // 7: rpc.State.GetAccount:input_type -> rpc.KangarooAccountRequest
+	6,  // 8: rpc.Blocks.GetBlockByHeight:input_type -> rpc.KangarooHeightRequest
+	5,  // 9: rpc.Blocks.GetBlockByHash:input_type -> rpc.KangarooHashRequest
+	5,  // 10: rpc.Blocks.GetBody:input_type -> rpc.KangarooHashRequest
+	5,  // 11: rpc.Blocks.GetAttestations:input_type -> rpc.KangarooHashRequest
+	5,  // 12: rpc.Blocks.GetTransaction:input_type -> rpc.KangarooHashRequest
+	12, // 13: rpc.Blocks.StreamBlocks:input_type -> rpc.KangarooStreamBlocksRequest
+	1,  // 14: rpc.Node.GetChainInfo:output_type -> rpc.KangarooChainInfo
+	3,  // 15: rpc.Node.ListSupportedSuites:output_type -> rpc.KangarooSupportedSuites
+	4,  // 16: rpc.Mempool.SendTransaction:output_type -> rpc.KangarooSendTransactionResponse
+	14, // 17: rpc.Mempool.GetPendingTransaction:output_type -> transaction.KangarooTransaction
+	14, // 18: rpc.Mempool.StreamPendingTransactions:output_type -> transaction.KangarooTransaction
+	9,  // 19: rpc.State.GetAccount:output_type -> rpc.KangarooAccountInfo
+	15, // 20: rpc.Blocks.GetBlockByHeight:output_type -> block.KangarooBlock
+	15, // 21: rpc.Blocks.GetBlockByHash:output_type -> block.KangarooBlock
+	16, // 22: rpc.Blocks.GetBody:output_type -> block.KangarooBody
+	10, // 23: rpc.Blocks.GetAttestations:output_type -> rpc.KangarooAttestations
+	11, // 24: rpc.Blocks.GetTransaction:output_type -> rpc.KangarooIncludedTransaction
+	15, // 25: rpc.Blocks.StreamBlocks:output_type -> block.KangarooBlock
+	14, // [14:26] is the sub-list for method output_type
+	2,  // [2:14] is the sub-list for method input_type
+	2,  // [2:2] is the sub-list for extension type_name
+	2,  // [2:2] is the sub-list for extension extendee
+	0,  // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_rpc_kangaroo_rpc_proto_init() }
+func file_rpc_kangaroo_rpc_proto_init() {
+	if File_rpc_kangaroo_rpc_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rpc_kangaroo_rpc_proto_rawDesc), len(file_rpc_kangaroo_rpc_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   4,
+		},
+		GoTypes:           file_rpc_kangaroo_rpc_proto_goTypes,
+		DependencyIndexes: file_rpc_kangaroo_rpc_proto_depIdxs,
+		MessageInfos:      file_rpc_kangaroo_rpc_proto_msgTypes,
+	}.Build()
+	File_rpc_kangaroo_rpc_proto = out.File
+	file_rpc_kangaroo_rpc_proto_goTypes = nil
+	file_rpc_kangaroo_rpc_proto_depIdxs = nil
+}
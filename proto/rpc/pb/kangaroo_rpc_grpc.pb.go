@@ -0,0 +1,741 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.30.2
+// source: rpc/kangaroo_rpc.proto
+
+package kangaroorpcpb
+
+import (
+	context "context"
+	pb1 "github.com/andantan/kangaroo/proto/core/block/pb"
+	pb "github.com/andantan/kangaroo/proto/core/transaction/pb"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Node_GetChainInfo_FullMethodName        = "/rpc.Node/GetChainInfo"
+	Node_ListSupportedSuites_FullMethodName = "/rpc.Node/ListSupportedSuites"
+)
+
+// NodeClient is the client API for Node service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NodeClient interface {
+	GetChainInfo(ctx context.Context, in *KangarooChainInfoRequest, opts ...grpc.CallOption) (*KangarooChainInfo, error)
+	ListSupportedSuites(ctx context.Context, in *KangarooSupportedSuitesRequest, opts ...grpc.CallOption) (*KangarooSupportedSuites, error)
+}
+
+type nodeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNodeClient(cc grpc.ClientConnInterface) NodeClient {
+	return &nodeClient{cc}
+}
+
+func (c *nodeClient) GetChainInfo(ctx context.Context, in *KangarooChainInfoRequest, opts ...grpc.CallOption) (*KangarooChainInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KangarooChainInfo)
+	err := c.cc.Invoke(ctx, Node_GetChainInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) ListSupportedSuites(ctx context.Context, in *KangarooSupportedSuitesRequest, opts ...grpc.CallOption) (*KangarooSupportedSuites, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KangarooSupportedSuites)
+	err := c.cc.Invoke(ctx, Node_ListSupportedSuites_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NodeServer is the server API for Node service.
+// All implementations must embed UnimplementedNodeServer
+// for forward compatibility.
+type NodeServer interface {
+	GetChainInfo(context.Context, *KangarooChainInfoRequest) (*KangarooChainInfo, error)
+	ListSupportedSuites(context.Context, *KangarooSupportedSuitesRequest) (*KangarooSupportedSuites, error)
+	mustEmbedUnimplementedNodeServer()
+}
+
+// UnimplementedNodeServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNodeServer struct{}
+
+func (UnimplementedNodeServer) GetChainInfo(context.Context, *KangarooChainInfoRequest) (*KangarooChainInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChainInfo not implemented")
+}
+func (UnimplementedNodeServer) ListSupportedSuites(context.Context, *KangarooSupportedSuitesRequest) (*KangarooSupportedSuites, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSupportedSuites not implemented")
+}
+func (UnimplementedNodeServer) mustEmbedUnimplementedNodeServer() {}
+func (UnimplementedNodeServer) testEmbeddedByValue()              {}
+
+// UnsafeNodeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NodeServer will
+// result in compilation errors.
+type UnsafeNodeServer interface {
+	mustEmbedUnimplementedNodeServer()
+}
+
+func RegisterNodeServer(s grpc.ServiceRegistrar, srv NodeServer) {
+	// If the following call pancis, it indicates UnimplementedNodeServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Node_ServiceDesc, srv)
+}
+
+func _Node_GetChainInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KangarooChainInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetChainInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_GetChainInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetChainInfo(ctx, req.(*KangarooChainInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_ListSupportedSuites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KangarooSupportedSuitesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).ListSupportedSuites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_ListSupportedSuites_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).ListSupportedSuites(ctx, req.(*KangarooSupportedSuitesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Node_ServiceDesc is the grpc.ServiceDesc for Node service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Node_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rpc.Node",
+	HandlerType: (*NodeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetChainInfo",
+			Handler:    _Node_GetChainInfo_Handler,
+		},
+		{
+			MethodName: "ListSupportedSuites",
+			Handler:    _Node_ListSupportedSuites_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc/kangaroo_rpc.proto",
+}
+
+const (
+	Mempool_SendTransaction_FullMethodName           = "/rpc.Mempool/SendTransaction"
+	Mempool_GetPendingTransaction_FullMethodName     = "/rpc.Mempool/GetPendingTransaction"
+	Mempool_StreamPendingTransactions_FullMethodName = "/rpc.Mempool/StreamPendingTransactions"
+)
+
+// MempoolClient is the client API for Mempool service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MempoolClient interface {
+	SendTransaction(ctx context.Context, in *pb.KangarooTransaction, opts ...grpc.CallOption) (*KangarooSendTransactionResponse, error)
+	GetPendingTransaction(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*pb.KangarooTransaction, error)
+	StreamPendingTransactions(ctx context.Context, in *KangarooStreamPendingRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.KangarooTransaction], error)
+}
+
+type mempoolClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMempoolClient(cc grpc.ClientConnInterface) MempoolClient {
+	return &mempoolClient{cc}
+}
+
+func (c *mempoolClient) SendTransaction(ctx context.Context, in *pb.KangarooTransaction, opts ...grpc.CallOption) (*KangarooSendTransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KangarooSendTransactionResponse)
+	err := c.cc.Invoke(ctx, Mempool_SendTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mempoolClient) GetPendingTransaction(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*pb.KangarooTransaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.KangarooTransaction)
+	err := c.cc.Invoke(ctx, Mempool_GetPendingTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mempoolClient) StreamPendingTransactions(ctx context.Context, in *KangarooStreamPendingRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.KangarooTransaction], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Mempool_ServiceDesc.Streams[0], Mempool_StreamPendingTransactions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[KangarooStreamPendingRequest, pb.KangarooTransaction]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Mempool_StreamPendingTransactionsClient = grpc.ServerStreamingClient[pb.KangarooTransaction]
+
+// MempoolServer is the server API for Mempool service.
+// All implementations must embed UnimplementedMempoolServer
+// for forward compatibility.
+type MempoolServer interface {
+	SendTransaction(context.Context, *pb.KangarooTransaction) (*KangarooSendTransactionResponse, error)
+	GetPendingTransaction(context.Context, *KangarooHashRequest) (*pb.KangarooTransaction, error)
+	StreamPendingTransactions(*KangarooStreamPendingRequest, grpc.ServerStreamingServer[pb.KangarooTransaction]) error
+	mustEmbedUnimplementedMempoolServer()
+}
+
+// UnimplementedMempoolServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMempoolServer struct{}
+
+func (UnimplementedMempoolServer) SendTransaction(context.Context, *pb.KangarooTransaction) (*KangarooSendTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendTransaction not implemented")
+}
+func (UnimplementedMempoolServer) GetPendingTransaction(context.Context, *KangarooHashRequest) (*pb.KangarooTransaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPendingTransaction not implemented")
+}
+func (UnimplementedMempoolServer) StreamPendingTransactions(*KangarooStreamPendingRequest, grpc.ServerStreamingServer[pb.KangarooTransaction]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamPendingTransactions not implemented")
+}
+func (UnimplementedMempoolServer) mustEmbedUnimplementedMempoolServer() {}
+func (UnimplementedMempoolServer) testEmbeddedByValue()                 {}
+
+// UnsafeMempoolServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MempoolServer will
+// result in compilation errors.
+type UnsafeMempoolServer interface {
+	mustEmbedUnimplementedMempoolServer()
+}
+
+func RegisterMempoolServer(s grpc.ServiceRegistrar, srv MempoolServer) {
+	// If the following call pancis, it indicates UnimplementedMempoolServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Mempool_ServiceDesc, srv)
+}
+
+func _Mempool_SendTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.KangarooTransaction)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MempoolServer).SendTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Mempool_SendTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MempoolServer).SendTransaction(ctx, req.(*pb.KangarooTransaction))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Mempool_GetPendingTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KangarooHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MempoolServer).GetPendingTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Mempool_GetPendingTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MempoolServer).GetPendingTransaction(ctx, req.(*KangarooHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Mempool_StreamPendingTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(KangarooStreamPendingRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MempoolServer).StreamPendingTransactions(m, &grpc.GenericServerStream[KangarooStreamPendingRequest, pb.KangarooTransaction]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Mempool_StreamPendingTransactionsServer = grpc.ServerStreamingServer[pb.KangarooTransaction]
+
+// Mempool_ServiceDesc is the grpc.ServiceDesc for Mempool service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Mempool_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rpc.Mempool",
+	HandlerType: (*MempoolServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendTransaction",
+			Handler:    _Mempool_SendTransaction_Handler,
+		},
+		{
+			MethodName: "GetPendingTransaction",
+			Handler:    _Mempool_GetPendingTransaction_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamPendingTransactions",
+			Handler:       _Mempool_StreamPendingTransactions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rpc/kangaroo_rpc.proto",
+}
+
+const (
+	State_GetAccount_FullMethodName = "/rpc.State/GetAccount"
+)
+
+// StateClient is the client API for State service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StateClient interface {
+	GetAccount(ctx context.Context, in *KangarooAccountRequest, opts ...grpc.CallOption) (*KangarooAccountInfo, error)
+}
+
+type stateClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStateClient(cc grpc.ClientConnInterface) StateClient {
+	return &stateClient{cc}
+}
+
+func (c *stateClient) GetAccount(ctx context.Context, in *KangarooAccountRequest, opts ...grpc.CallOption) (*KangarooAccountInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KangarooAccountInfo)
+	err := c.cc.Invoke(ctx, State_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StateServer is the server API for State service.
+// All implementations must embed UnimplementedStateServer
+// for forward compatibility.
+type StateServer interface {
+	GetAccount(context.Context, *KangarooAccountRequest) (*KangarooAccountInfo, error)
+	mustEmbedUnimplementedStateServer()
+}
+
+// UnimplementedStateServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStateServer struct{}
+
+func (UnimplementedStateServer) GetAccount(context.Context, *KangarooAccountRequest) (*KangarooAccountInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedStateServer) mustEmbedUnimplementedStateServer() {}
+func (UnimplementedStateServer) testEmbeddedByValue()               {}
+
+// UnsafeStateServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StateServer will
+// result in compilation errors.
+type UnsafeStateServer interface {
+	mustEmbedUnimplementedStateServer()
+}
+
+func RegisterStateServer(s grpc.ServiceRegistrar, srv StateServer) {
+	// If the following call pancis, it indicates UnimplementedStateServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&State_ServiceDesc, srv)
+}
+
+func _State_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KangarooAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StateServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: State_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StateServer).GetAccount(ctx, req.(*KangarooAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// State_ServiceDesc is the grpc.ServiceDesc for State service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var State_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rpc.State",
+	HandlerType: (*StateServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAccount",
+			Handler:    _State_GetAccount_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc/kangaroo_rpc.proto",
+}
+
+const (
+	Blocks_GetBlockByHeight_FullMethodName = "/rpc.Blocks/GetBlockByHeight"
+	Blocks_GetBlockByHash_FullMethodName   = "/rpc.Blocks/GetBlockByHash"
+	Blocks_GetBody_FullMethodName          = "/rpc.Blocks/GetBody"
+	Blocks_GetAttestations_FullMethodName  = "/rpc.Blocks/GetAttestations"
+	Blocks_GetTransaction_FullMethodName   = "/rpc.Blocks/GetTransaction"
+	Blocks_StreamBlocks_FullMethodName     = "/rpc.Blocks/StreamBlocks"
+)
+
+// BlocksClient is the client API for Blocks service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BlocksClient interface {
+	GetBlockByHeight(ctx context.Context, in *KangarooHeightRequest, opts ...grpc.CallOption) (*pb1.KangarooBlock, error)
+	GetBlockByHash(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*pb1.KangarooBlock, error)
+	GetBody(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*pb1.KangarooBody, error)
+	GetAttestations(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*KangarooAttestations, error)
+	GetTransaction(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*KangarooIncludedTransaction, error)
+	StreamBlocks(ctx context.Context, in *KangarooStreamBlocksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb1.KangarooBlock], error)
+}
+
+type blocksClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBlocksClient(cc grpc.ClientConnInterface) BlocksClient {
+	return &blocksClient{cc}
+}
+
+func (c *blocksClient) GetBlockByHeight(ctx context.Context, in *KangarooHeightRequest, opts ...grpc.CallOption) (*pb1.KangarooBlock, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb1.KangarooBlock)
+	err := c.cc.Invoke(ctx, Blocks_GetBlockByHeight_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blocksClient) GetBlockByHash(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*pb1.KangarooBlock, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb1.KangarooBlock)
+	err := c.cc.Invoke(ctx, Blocks_GetBlockByHash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blocksClient) GetBody(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*pb1.KangarooBody, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb1.KangarooBody)
+	err := c.cc.Invoke(ctx, Blocks_GetBody_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blocksClient) GetAttestations(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*KangarooAttestations, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KangarooAttestations)
+	err := c.cc.Invoke(ctx, Blocks_GetAttestations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blocksClient) GetTransaction(ctx context.Context, in *KangarooHashRequest, opts ...grpc.CallOption) (*KangarooIncludedTransaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KangarooIncludedTransaction)
+	err := c.cc.Invoke(ctx, Blocks_GetTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blocksClient) StreamBlocks(ctx context.Context, in *KangarooStreamBlocksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb1.KangarooBlock], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Blocks_ServiceDesc.Streams[0], Blocks_StreamBlocks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[KangarooStreamBlocksRequest, pb1.KangarooBlock]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Blocks_StreamBlocksClient = grpc.ServerStreamingClient[pb1.KangarooBlock]
+
+// BlocksServer is the server API for Blocks service.
+// All implementations must embed UnimplementedBlocksServer
+// for forward compatibility.
+type BlocksServer interface {
+	GetBlockByHeight(context.Context, *KangarooHeightRequest) (*pb1.KangarooBlock, error)
+	GetBlockByHash(context.Context, *KangarooHashRequest) (*pb1.KangarooBlock, error)
+	GetBody(context.Context, *KangarooHashRequest) (*pb1.KangarooBody, error)
+	GetAttestations(context.Context, *KangarooHashRequest) (*KangarooAttestations, error)
+	GetTransaction(context.Context, *KangarooHashRequest) (*KangarooIncludedTransaction, error)
+	StreamBlocks(*KangarooStreamBlocksRequest, grpc.ServerStreamingServer[pb1.KangarooBlock]) error
+	mustEmbedUnimplementedBlocksServer()
+}
+
+// UnimplementedBlocksServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBlocksServer struct{}
+
+func (UnimplementedBlocksServer) GetBlockByHeight(context.Context, *KangarooHeightRequest) (*pb1.KangarooBlock, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockByHeight not implemented")
+}
+func (UnimplementedBlocksServer) GetBlockByHash(context.Context, *KangarooHashRequest) (*pb1.KangarooBlock, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockByHash not implemented")
+}
+func (UnimplementedBlocksServer) GetBody(context.Context, *KangarooHashRequest) (*pb1.KangarooBody, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBody not implemented")
+}
+func (UnimplementedBlocksServer) GetAttestations(context.Context, *KangarooHashRequest) (*KangarooAttestations, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAttestations not implemented")
+}
+func (UnimplementedBlocksServer) GetTransaction(context.Context, *KangarooHashRequest) (*KangarooIncludedTransaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedBlocksServer) StreamBlocks(*KangarooStreamBlocksRequest, grpc.ServerStreamingServer[pb1.KangarooBlock]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBlocks not implemented")
+}
+func (UnimplementedBlocksServer) mustEmbedUnimplementedBlocksServer() {}
+func (UnimplementedBlocksServer) testEmbeddedByValue()                {}
+
+// UnsafeBlocksServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BlocksServer will
+// result in compilation errors.
+type UnsafeBlocksServer interface {
+	mustEmbedUnimplementedBlocksServer()
+}
+
+func RegisterBlocksServer(s grpc.ServiceRegistrar, srv BlocksServer) {
+	// If the following call pancis, it indicates UnimplementedBlocksServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Blocks_ServiceDesc, srv)
+}
+
+func _Blocks_GetBlockByHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KangarooHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlocksServer).GetBlockByHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Blocks_GetBlockByHeight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlocksServer).GetBlockByHeight(ctx, req.(*KangarooHeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Blocks_GetBlockByHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KangarooHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlocksServer).GetBlockByHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Blocks_GetBlockByHash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlocksServer).GetBlockByHash(ctx, req.(*KangarooHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Blocks_GetBody_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KangarooHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlocksServer).GetBody(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Blocks_GetBody_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlocksServer).GetBody(ctx, req.(*KangarooHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Blocks_GetAttestations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KangarooHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlocksServer).GetAttestations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Blocks_GetAttestations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlocksServer).GetAttestations(ctx, req.(*KangarooHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Blocks_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KangarooHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlocksServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Blocks_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlocksServer).GetTransaction(ctx, req.(*KangarooHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Blocks_StreamBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(KangarooStreamBlocksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BlocksServer).StreamBlocks(m, &grpc.GenericServerStream[KangarooStreamBlocksRequest, pb1.KangarooBlock]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Blocks_StreamBlocksServer = grpc.ServerStreamingServer[pb1.KangarooBlock]
+
+// Blocks_ServiceDesc is the grpc.ServiceDesc for Blocks service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Blocks_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rpc.Blocks",
+	HandlerType: (*BlocksServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBlockByHeight",
+			Handler:    _Blocks_GetBlockByHeight_Handler,
+		},
+		{
+			MethodName: "GetBlockByHash",
+			Handler:    _Blocks_GetBlockByHash_Handler,
+		},
+		{
+			MethodName: "GetBody",
+			Handler:    _Blocks_GetBody_Handler,
+		},
+		{
+			MethodName: "GetAttestations",
+			Handler:    _Blocks_GetAttestations_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _Blocks_GetTransaction_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamBlocks",
+			Handler:       _Blocks_StreamBlocks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rpc/kangaroo_rpc.proto",
+}
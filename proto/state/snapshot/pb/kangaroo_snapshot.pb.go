@@ -0,0 +1,273 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: state/snapshot/kangaroo_snapshot.proto
+
+package kangaroosnapshotpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KangarooAccount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       []byte                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Balance       []byte                 `protobuf:"bytes,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	Nonce         uint64                 `protobuf:"varint,3,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooAccount) Reset() {
+	*x = KangarooAccount{}
+	mi := &file_state_snapshot_kangaroo_snapshot_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooAccount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooAccount) ProtoMessage() {}
+
+func (x *KangarooAccount) ProtoReflect() protoreflect.Message {
+	mi := &file_state_snapshot_kangaroo_snapshot_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooAccount.ProtoReflect.Descriptor instead.
+func (*KangarooAccount) Descriptor() ([]byte, []int) {
+	return file_state_snapshot_kangaroo_snapshot_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KangarooAccount) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *KangarooAccount) GetBalance() []byte {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+func (x *KangarooAccount) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+type KangarooSnapshotChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accounts      []*KangarooAccount     `protobuf:"bytes,1,rep,name=accounts,proto3" json:"accounts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooSnapshotChunk) Reset() {
+	*x = KangarooSnapshotChunk{}
+	mi := &file_state_snapshot_kangaroo_snapshot_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooSnapshotChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooSnapshotChunk) ProtoMessage() {}
+
+func (x *KangarooSnapshotChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_state_snapshot_kangaroo_snapshot_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooSnapshotChunk.ProtoReflect.Descriptor instead.
+func (*KangarooSnapshotChunk) Descriptor() ([]byte, []int) {
+	return file_state_snapshot_kangaroo_snapshot_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KangarooSnapshotChunk) GetAccounts() []*KangarooAccount {
+	if x != nil {
+		return x.Accounts
+	}
+	return nil
+}
+
+type KangarooSnapshotManifest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        uint64                 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	BlockId       []byte                 `protobuf:"bytes,2,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	StateRoot     []byte                 `protobuf:"bytes,3,opt,name=state_root,json=stateRoot,proto3" json:"state_root,omitempty"`
+	ChunkLevels   uint32                 `protobuf:"varint,4,opt,name=chunk_levels,json=chunkLevels,proto3" json:"chunk_levels,omitempty"`
+	ChunkRoots    [][]byte               `protobuf:"bytes,5,rep,name=chunk_roots,json=chunkRoots,proto3" json:"chunk_roots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KangarooSnapshotManifest) Reset() {
+	*x = KangarooSnapshotManifest{}
+	mi := &file_state_snapshot_kangaroo_snapshot_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KangarooSnapshotManifest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KangarooSnapshotManifest) ProtoMessage() {}
+
+func (x *KangarooSnapshotManifest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_snapshot_kangaroo_snapshot_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KangarooSnapshotManifest.ProtoReflect.Descriptor instead.
+func (*KangarooSnapshotManifest) Descriptor() ([]byte, []int) {
+	return file_state_snapshot_kangaroo_snapshot_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KangarooSnapshotManifest) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *KangarooSnapshotManifest) GetBlockId() []byte {
+	if x != nil {
+		return x.BlockId
+	}
+	return nil
+}
+
+func (x *KangarooSnapshotManifest) GetStateRoot() []byte {
+	if x != nil {
+		return x.StateRoot
+	}
+	return nil
+}
+
+func (x *KangarooSnapshotManifest) GetChunkLevels() uint32 {
+	if x != nil {
+		return x.ChunkLevels
+	}
+	return 0
+}
+
+func (x *KangarooSnapshotManifest) GetChunkRoots() [][]byte {
+	if x != nil {
+		return x.ChunkRoots
+	}
+	return nil
+}
+
+var File_state_snapshot_kangaroo_snapshot_proto protoreflect.FileDescriptor
+
+const file_state_snapshot_kangaroo_snapshot_proto_rawDesc = "" +
+	"\n" +
+	"&state/snapshot/kangaroo_snapshot.proto\x12\bsnapshot\"[\n" +
+	"\x0fKangarooAccount\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\fR\aaddress\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\fR\abalance\x12\x14\n" +
+	"\x05nonce\x18\x03 \x01(\x04R\x05nonce\"N\n" +
+	"\x15KangarooSnapshotChunk\x125\n" +
+	"\baccounts\x18\x01 \x03(\v2\x19.snapshot.KangarooAccountR\baccounts\"\xb0\x01\n" +
+	"\x18KangarooSnapshotManifest\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x04R\x06height\x12\x19\n" +
+	"\bblock_id\x18\x02 \x01(\fR\ablockId\x12\x1d\n" +
+	"\n" +
+	"state_root\x18\x03 \x01(\fR\tstateRoot\x12!\n" +
+	"\fchunk_levels\x18\x04 \x01(\rR\vchunkLevels\x12\x1f\n" +
+	"\vchunk_roots\x18\x05 \x03(\fR\n" +
+	"chunkRootsB&Z$state/snapshot/pb;kangaroosnapshotpbb\x06proto3"
+
+var (
+	file_state_snapshot_kangaroo_snapshot_proto_rawDescOnce sync.Once
+	file_state_snapshot_kangaroo_snapshot_proto_rawDescData []byte
+)
+
+func file_state_snapshot_kangaroo_snapshot_proto_rawDescGZIP() []byte {
+	file_state_snapshot_kangaroo_snapshot_proto_rawDescOnce.Do(func() {
+		file_state_snapshot_kangaroo_snapshot_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_state_snapshot_kangaroo_snapshot_proto_rawDesc), len(file_state_snapshot_kangaroo_snapshot_proto_rawDesc)))
+	})
+	return file_state_snapshot_kangaroo_snapshot_proto_rawDescData
+}
+
+var file_state_snapshot_kangaroo_snapshot_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_state_snapshot_kangaroo_snapshot_proto_goTypes = []any{
+	(*KangarooAccount)(nil),          // 0: snapshot.KangarooAccount
+	(*KangarooSnapshotChunk)(nil),    // 1: snapshot.KangarooSnapshotChunk
+	(*KangarooSnapshotManifest)(nil), // 2: snapshot.KangarooSnapshotManifest
+}
+var file_state_snapshot_kangaroo_snapshot_proto_depIdxs = []int32{
+	0, // 0: snapshot.KangarooSnapshotChunk.accounts:type_name -> snapshot.KangarooAccount
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_state_snapshot_kangaroo_snapshot_proto_init() }
+func file_state_snapshot_kangaroo_snapshot_proto_init() {
+	if File_state_snapshot_kangaroo_snapshot_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_state_snapshot_kangaroo_snapshot_proto_rawDesc), len(file_state_snapshot_kangaroo_snapshot_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_state_snapshot_kangaroo_snapshot_proto_goTypes,
+		DependencyIndexes: file_state_snapshot_kangaroo_snapshot_proto_depIdxs,
+		MessageInfos:      file_state_snapshot_kangaroo_snapshot_proto_msgTypes,
+	}.Build()
+	File_state_snapshot_kangaroo_snapshot_proto = out.File
+	file_state_snapshot_kangaroo_snapshot_proto_goTypes = nil
+	file_state_snapshot_kangaroo_snapshot_proto_depIdxs = nil
+}
@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"log"
+	"sync"
+)
+
+// ============================================================================================================
+//
+//	BLOCK SUITE REGISTRY
+//
+// ============================================================================================================
+var blockSuiteRegistry = make(map[string]block.BlockSuite)
+var blockSuiteLock = &sync.RWMutex{}
+
+func RegistryBlockSuite(s block.BlockSuite) {
+	blockSuiteLock.Lock()
+	defer blockSuiteLock.Unlock()
+	name := s.Type()
+	if _, exists := blockSuiteRegistry[name]; exists {
+		panic("block suite already registered: " + name)
+	}
+	blockSuiteRegistry[name] = s
+	log.Printf("[Registry] Registered Block Suite: name='%s', type=%T", name, s)
+}
+
+func GetBlockSuite(name string) (block.BlockSuite, error) {
+	blockSuiteLock.RLock()
+	defer blockSuiteLock.RUnlock()
+	suite, ok := blockSuiteRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("block suite not found: %s", name)
+	}
+	return suite, nil
+}
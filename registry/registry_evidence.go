@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"log"
+	"sync"
+)
+
+// ============================================================================================================
+//
+//	EVIDENCE SUITE REGISTRY
+//
+// ============================================================================================================
+var evidenceSuiteRegistry = make(map[string]block.EvidenceSuite)
+var evidenceSuiteLock = &sync.RWMutex{}
+
+func RegistryEvidenceSuite(s block.EvidenceSuite) {
+	evidenceSuiteLock.Lock()
+	defer evidenceSuiteLock.Unlock()
+	name := s.Type()
+	if _, exists := evidenceSuiteRegistry[name]; exists {
+		panic("evidence suite already registered: " + name)
+	}
+	evidenceSuiteRegistry[name] = s
+	log.Printf("[Registry] Registered Evidence Suite: name='%s', type=%T", name, s)
+}
+
+func GetEvidenceSuite(name string) (block.EvidenceSuite, error) {
+	evidenceSuiteLock.RLock()
+	defer evidenceSuiteLock.RUnlock()
+	suite, ok := evidenceSuiteRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("evidence suite not found: %s", name)
+	}
+	return suite, nil
+}
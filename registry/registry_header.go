@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"log"
+	"sync"
+)
+
+// ============================================================================================================
+//
+//	HEADER SUITE REGISTRY
+//
+// ============================================================================================================
+var headerSuiteRegistry = make(map[string]block.HeaderSuite)
+var headerSuiteLock = &sync.RWMutex{}
+
+func RegistryHeaderSuite(s block.HeaderSuite) {
+	headerSuiteLock.Lock()
+	defer headerSuiteLock.Unlock()
+	name := s.Type()
+	if _, exists := headerSuiteRegistry[name]; exists {
+		panic("header suite already registered: " + name)
+	}
+	headerSuiteRegistry[name] = s
+	log.Printf("[Registry] Registered Header Suite: name='%s', type=%T", name, s)
+}
+
+func GetHeaderSuite(name string) (block.HeaderSuite, error) {
+	headerSuiteLock.RLock()
+	defer headerSuiteLock.RUnlock()
+	suite, ok := headerSuiteRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("header suite not found: %s", name)
+	}
+	return suite, nil
+}
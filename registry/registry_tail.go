@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"log"
+	"sync"
+)
+
+// ============================================================================================================
+//
+//	TAIL SUITE REGISTRY
+//
+// ============================================================================================================
+var tailSuiteRegistry = make(map[string]block.TailSuite)
+var tailSuiteLock = &sync.RWMutex{}
+
+func RegistryTailSuite(s block.TailSuite) {
+	tailSuiteLock.Lock()
+	defer tailSuiteLock.Unlock()
+	name := s.Type()
+	if _, exists := tailSuiteRegistry[name]; exists {
+		panic("tail suite already registered: " + name)
+	}
+	tailSuiteRegistry[name] = s
+	log.Printf("[Registry] Registered Tail Suite: name='%s', type=%T", name, s)
+}
+
+func GetTailSuite(name string) (block.TailSuite, error) {
+	tailSuiteLock.RLock()
+	defer tailSuiteLock.RUnlock()
+	suite, ok := tailSuiteRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("tail suite not found: %s", name)
+	}
+	return suite, nil
+}
@@ -0,0 +1,113 @@
+package grpcapi
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/mempool"
+	kangaroorpcpb "github.com/andantan/kangaroo/proto/rpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const DefaultStreamBuffer = 256
+
+type Config struct {
+	// ChainID is reported by GetChainInfo so clients can tell networks apart.
+	ChainID string
+	// StreamBuffer is how many messages may queue for a stream. A stream
+	// that falls further behind is ended with ResourceExhausted so it never
+	// blocks the node.
+	StreamBuffer int
+	// OnTransaction is called for every transaction SendTransaction admits
+	// to the pool, e.g. to gossip it.
+	OnTransaction func(tx transaction.Transaction)
+}
+
+// Server implements the Node, Mempool, State and Blocks gRPC services on
+// top of a chain and its pool. The node feeds the streaming RPCs through
+// the Publish methods.
+type Server struct {
+	cfg   Config
+	chain *chain.Chain
+	pool  *mempool.Mempool
+
+	heads     *feed[block.Block]
+	finalized *feed[block.Block]
+	pending   *feed[transaction.Transaction]
+}
+
+func NewServer(cfg Config, c *chain.Chain, pool *mempool.Mempool) *Server {
+	if cfg.StreamBuffer <= 0 {
+		cfg.StreamBuffer = DefaultStreamBuffer
+	}
+
+	return &Server{
+		cfg:       cfg,
+		chain:     c,
+		pool:      pool,
+		heads:     newFeed[block.Block](cfg.StreamBuffer),
+		finalized: newFeed[block.Block](cfg.StreamBuffer),
+		pending:   newFeed[transaction.Transaction](cfg.StreamBuffer),
+	}
+}
+
+// Register adds all four services to r.
+func (s *Server) Register(r grpc.ServiceRegistrar) {
+	kangaroorpcpb.RegisterNodeServer(r, &nodeService{s: s})
+	kangaroorpcpb.RegisterMempoolServer(r, &mempoolService{s: s})
+	kangaroorpcpb.RegisterStateServer(r, &stateService{s: s})
+	kangaroorpcpb.RegisterBlocksServer(r, &blocksService{s: s})
+}
+
+// PublishHead feeds StreamBlocks. During a reorg it should be called for
+// every block of the new branch, in order.
+func (s *Server) PublishHead(blk block.Block) {
+	s.heads.publish(blk)
+}
+
+// PublishFinalized feeds finalized StreamBlocks. Call it once per newly
+// finalized block, in height order.
+func (s *Server) PublishFinalized(blk block.Block) {
+	s.finalized.publish(blk)
+}
+
+// PublishPendingTransaction feeds StreamPendingTransactions. SendTransaction
+// calls it itself; the node calls it for transactions arriving from peers.
+func (s *Server) PublishPendingTransaction(tx transaction.Transaction) {
+	s.pending.publish(tx)
+}
+
+// toProto returns the protobuf message of c, which must be of type T: the
+// services speak the kangaroo messages only.
+func toProto[T proto.Message](c codec.ProtoCodec) (T, error) {
+	var zero T
+
+	m, err := c.ToProto()
+	if err != nil {
+		return zero, status.Error(codes.Internal, err.Error())
+	}
+	pb, ok := m.(T)
+	if !ok {
+		return zero, status.Errorf(codes.Internal, "unsupported message type %T", m)
+	}
+	return pb, nil
+}
+
+// notFound maps the chain's unknown block and transaction errors to
+// NotFound, and anything else to Internal.
+func notFound(err error) error {
+	if errors.Is(err, chain.ErrUnknownBlock) || errors.Is(err, chain.ErrUnknownTransaction) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func invalidArgument(field string, err error) error {
+	return status.Error(codes.InvalidArgument, fmt.Sprintf("%s: %v", field, err))
+}
@@ -0,0 +1,183 @@
+package grpcapi
+
+import (
+	"context"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	kangarooblockpb "github.com/andantan/kangaroo/proto/core/block/pb"
+	kangarootxpb "github.com/andantan/kangaroo/proto/core/transaction/pb"
+	kangaroorpcpb "github.com/andantan/kangaroo/proto/rpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type blocksService struct {
+	kangaroorpcpb.UnimplementedBlocksServer
+	s *Server
+}
+
+func (b *blocksService) GetBlockByHeight(_ context.Context, req *kangaroorpcpb.KangarooHeightRequest) (*kangarooblockpb.KangarooBlock, error) {
+	blk, err := b.s.chain.GetBlockByHeight(req.GetHeight())
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return toProto[*kangarooblockpb.KangarooBlock](blk)
+}
+
+func (b *blocksService) GetBlockByHash(_ context.Context, req *kangaroorpcpb.KangarooHashRequest) (*kangarooblockpb.KangarooBlock, error) {
+	blk, err := b.byHash(req)
+	if err != nil {
+		return nil, err
+	}
+	return toProto[*kangarooblockpb.KangarooBlock](blk)
+}
+
+func (b *blocksService) GetBody(_ context.Context, req *kangaroorpcpb.KangarooHashRequest) (*kangarooblockpb.KangarooBody, error) {
+	blk, err := b.byHash(req)
+	if err != nil {
+		return nil, err
+	}
+	return toProto[*kangarooblockpb.KangarooBody](blk.GetBody())
+}
+
+func (b *blocksService) GetAttestations(_ context.Context, req *kangaroorpcpb.KangarooHashRequest) (*kangaroorpcpb.KangarooAttestations, error) {
+	blk, err := b.byHash(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tail := blk.GetTail()
+	resp := &kangaroorpcpb.KangarooAttestations{Round: tail.GetRound()}
+	for _, a := range tail.GetAttestations() {
+		pb, err := toProto[*kangarooblockpb.KangarooAttestation](a)
+		if err != nil {
+			return nil, err
+		}
+		resp.Attestations = append(resp.Attestations, pb)
+	}
+	return resp, nil
+}
+
+func (b *blocksService) GetTransaction(_ context.Context, req *kangaroorpcpb.KangarooHashRequest) (*kangaroorpcpb.KangarooIncludedTransaction, error) {
+	id, err := wrapper.UnwrapHash(req.GetHash())
+	if err != nil {
+		return nil, invalidArgument("hash", err)
+	}
+	tx, loc, err := b.s.chain.GetTransactionByHash(id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	pb, err := toProto[*kangarootxpb.KangarooTransaction](tx)
+	if err != nil {
+		return nil, err
+	}
+	blockID, err := wrapper.WrapHash(loc.BlockID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &kangaroorpcpb.KangarooIncludedTransaction{
+		Transaction: pb,
+		Height:      loc.Height,
+		BlockId:     blockID,
+		Index:       uint32(loc.Index),
+	}, nil
+}
+
+// StreamBlocks follows new heads, or finalized blocks. With a from height it
+// first replays the stored blocks from there; blocks published while the
+// replay runs are not sent twice.
+func (b *blocksService) StreamBlocks(req *kangaroorpcpb.KangarooStreamBlocksRequest, stream grpc.ServerStreamingServer[kangarooblockpb.KangarooBlock]) error {
+	f := b.s.heads
+	if req.GetFinalized() {
+		f = b.s.finalized
+	}
+	sub := f.subscribe()
+	defer f.unsubscribe(sub)
+
+	replayed, err := b.replay(req, stream)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-sub.dropped:
+			return status.Error(codes.ResourceExhausted, "stream fell behind")
+		case blk := <-sub.ch:
+			if replayed != nil {
+				id, err := blk.Hash(b.s.chain.Executor().HashDeriver())
+				if err != nil {
+					return status.Error(codes.Internal, err.Error())
+				}
+				if replayed[string(id.Bytes())] {
+					continue
+				}
+			}
+			if err := b.send(stream, blk); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// replay sends the stored blocks from the requested height up to the head,
+// or the finalized block, and returns the ids of the ones that may also be
+// waiting in the stream's queue.
+func (b *blocksService) replay(req *kangaroorpcpb.KangarooStreamBlocksRequest, stream grpc.ServerStreamingServer[kangarooblockpb.KangarooBlock]) (map[string]bool, error) {
+	if req.GetFromHeight() == 0 {
+		return nil, nil
+	}
+
+	top := b.s.chain.Height()
+	if req.GetFinalized() {
+		finalized, _, err := b.s.chain.Finalized()
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		top = finalized
+	}
+
+	replayed := make(map[string]bool)
+	for height := req.GetFromHeight(); height <= top; height++ {
+		blk, err := b.s.chain.GetBlockByHeight(height)
+		if err != nil {
+			return nil, notFound(err)
+		}
+		if err := b.send(stream, blk); err != nil {
+			return nil, err
+		}
+
+		if height+uint64(b.s.cfg.StreamBuffer) > top {
+			id, err := blk.Hash(b.s.chain.Executor().HashDeriver())
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			replayed[string(id.Bytes())] = true
+		}
+	}
+	return replayed, nil
+}
+
+func (b *blocksService) send(stream grpc.ServerStreamingServer[kangarooblockpb.KangarooBlock], blk block.Block) error {
+	pb, err := toProto[*kangarooblockpb.KangarooBlock](blk)
+	if err != nil {
+		return err
+	}
+	return stream.Send(pb)
+}
+
+func (b *blocksService) byHash(req *kangaroorpcpb.KangarooHashRequest) (block.Block, error) {
+	id, err := wrapper.UnwrapHash(req.GetHash())
+	if err != nil {
+		return nil, invalidArgument("hash", err)
+	}
+	blk, err := b.s.chain.GetBlockByID(id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return blk, nil
+}
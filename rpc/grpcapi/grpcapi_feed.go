@@ -0,0 +1,57 @@
+package grpcapi
+
+import (
+	"sync"
+)
+
+// feed fans published values out to streams. Publishing never blocks: a
+// subscriber whose queue is full is dropped and its stream ended.
+type feed[T any] struct {
+	lock   sync.Mutex
+	buffer int
+	subs   map[*feedSub[T]]struct{}
+}
+
+type feedSub[T any] struct {
+	ch      chan T
+	dropped chan struct{}
+}
+
+func newFeed[T any](buffer int) *feed[T] {
+	return &feed[T]{buffer: buffer, subs: make(map[*feedSub[T]]struct{})}
+}
+
+func (f *feed[T]) subscribe() *feedSub[T] {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	sub := &feedSub[T]{ch: make(chan T, f.buffer), dropped: make(chan struct{})}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+func (f *feed[T]) unsubscribe(sub *feedSub[T]) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.subs, sub)
+}
+
+func (f *feed[T]) publish(v T) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for sub := range f.subs {
+		select {
+		case sub.ch <- v:
+		default:
+			close(sub.dropped)
+			delete(f.subs, sub)
+		}
+	}
+}
+
+func (f *feed[T]) len() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.subs)
+}
@@ -0,0 +1,116 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/mempool"
+	kangarootxpb "github.com/andantan/kangaroo/proto/core/transaction/pb"
+	kangaroorpcpb "github.com/andantan/kangaroo/proto/rpc/pb"
+	"github.com/andantan/kangaroo/registry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type mempoolService struct {
+	kangaroorpcpb.UnimplementedMempoolServer
+	s *Server
+}
+
+func (m *mempoolService) SendTransaction(_ context.Context, req *kangarootxpb.KangarooTransaction) (*kangaroorpcpb.KangarooSendTransactionResponse, error) {
+	suite, err := registry.GetTransactionSuite(transaction.KangarooTransactionType)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	tx := suite.NewTransaction()
+	if err := tx.FromProto(req); err != nil {
+		return nil, invalidArgument("transaction", err)
+	}
+
+	id, err := m.s.pool.Add(tx)
+	switch {
+	case errors.Is(err, mempool.ErrAlreadyKnown):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, mempool.ErrMempoolFull):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if m.s.cfg.OnTransaction != nil {
+		m.s.cfg.OnTransaction(tx)
+	}
+	m.s.PublishPendingTransaction(tx)
+
+	wrapped, err := wrapper.WrapHash(id)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &kangaroorpcpb.KangarooSendTransactionResponse{Hash: wrapped}, nil
+}
+
+func (m *mempoolService) GetPendingTransaction(_ context.Context, req *kangaroorpcpb.KangarooHashRequest) (*kangarootxpb.KangarooTransaction, error) {
+	id, err := wrapper.UnwrapHash(req.GetHash())
+	if err != nil {
+		return nil, invalidArgument("hash", err)
+	}
+	tx, ok := m.s.pool.Get(id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "transaction %s is not pending", id.ShortString(8))
+	}
+	return toProto[*kangarootxpb.KangarooTransaction](tx)
+}
+
+// StreamPendingTransactions streams transactions as they enter the pool,
+// optionally only those sent from or to one of the requested addresses.
+func (m *mempoolService) StreamPendingTransactions(req *kangaroorpcpb.KangarooStreamPendingRequest, stream grpc.ServerStreamingServer[kangarootxpb.KangarooTransaction]) error {
+	var filter []hash.Address
+	for _, raw := range req.GetAddresses() {
+		addr, err := wrapper.UnwrapAddress(raw)
+		if err != nil {
+			return invalidArgument("address", err)
+		}
+		filter = append(filter, addr)
+	}
+
+	sub := m.s.pending.subscribe()
+	defer m.s.pending.unsubscribe(sub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-sub.dropped:
+			return status.Error(codes.ResourceExhausted, "stream fell behind")
+		case tx := <-sub.ch:
+			if !m.matches(filter, tx) {
+				continue
+			}
+			pb, err := toProto[*kangarootxpb.KangarooTransaction](tx)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(pb); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (m *mempoolService) matches(filter []hash.Address, tx transaction.Transaction) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	sender, err := m.s.chain.Executor().SenderOf(tx)
+	if err != nil {
+		return false
+	}
+	for _, addr := range filter {
+		if addr.Equal(sender) || (tx.GetToAddress() != nil && addr.Equal(tx.GetToAddress())) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package grpcapi
+
+import (
+	"context"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	kangaroorpcpb "github.com/andantan/kangaroo/proto/rpc/pb"
+	"github.com/andantan/kangaroo/registry"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type nodeService struct {
+	kangaroorpcpb.UnimplementedNodeServer
+	s *Server
+}
+
+func (n *nodeService) GetChainInfo(context.Context, *kangaroorpcpb.KangarooChainInfoRequest) (*kangaroorpcpb.KangarooChainInfo, error) {
+	c := n.s.chain
+	executor := c.Executor()
+
+	head := c.Head()
+	headID, err := head.Hash(executor.HashDeriver())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	genesisID, err := c.Genesis().Hash(executor.HashDeriver())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	finalized, finalizedID, err := c.Finalized()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	info := &kangaroorpcpb.KangarooChainInfo{
+		ChainId:         n.s.cfg.ChainID,
+		Height:          head.GetHeader().GetHeight(),
+		BaseHeight:      c.Base(),
+		FinalizedHeight: finalized,
+		HashSuite:       executor.HashDeriver().Type(),
+		AddressSuite:    executor.AddressDeriver().Type(),
+		Pending:         uint32(n.s.pool.Len()),
+	}
+	if info.HeadId, err = wrapper.WrapHash(headID); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if info.GenesisId, err = wrapper.WrapHash(genesisID); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if info.FinalizedId, err = wrapper.WrapHash(finalizedID); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return info, nil
+}
+
+func (n *nodeService) ListSupportedSuites(context.Context, *kangaroorpcpb.KangarooSupportedSuitesRequest) (*kangaroorpcpb.KangarooSupportedSuites, error) {
+	return &kangaroorpcpb.KangarooSupportedSuites{
+		Keys:      registry.ListKeySuiteTypes(),
+		Hashes:    registry.ListHashSuiteTypes(),
+		Addresses: registry.ListAddressSuiteTypes(),
+	}, nil
+}
@@ -0,0 +1,46 @@
+package grpcapi
+
+import (
+	"context"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	kangaroorpcpb "github.com/andantan/kangaroo/proto/rpc/pb"
+)
+
+type stateService struct {
+	kangaroorpcpb.UnimplementedStateServer
+	s *Server
+}
+
+// GetAccount returns the balance and nonce at the head. The balance is
+// big-endian, like transaction values.
+func (st *stateService) GetAccount(_ context.Context, req *kangaroorpcpb.KangarooAccountRequest) (*kangaroorpcpb.KangarooAccountInfo, error) {
+	addr, err := wrapper.UnwrapAddress(req.GetAddress())
+	if err != nil {
+		return nil, invalidArgument("address", err)
+	}
+
+	head := st.s.chain.State()
+	nonce := head.GetNonce(addr)
+
+	executor := st.s.chain.Executor()
+	pending := make(map[uint64]bool)
+	st.s.pool.Range(func(_ hash.Hash, tx transaction.Transaction) bool {
+		if sender, err := executor.SenderOf(tx); err == nil && sender.Equal(addr) {
+			pending[tx.GetNonce()] = true
+		}
+		return true
+	})
+	pendingNonce := nonce
+	for pending[pendingNonce] {
+		pendingNonce++
+	}
+
+	return &kangaroorpcpb.KangarooAccountInfo{
+		Address:      req.GetAddress(),
+		Balance:      head.GetBalance(addr).Bytes(),
+		Nonce:        nonce,
+		PendingNonce: pendingNonce,
+	}, nil
+}
@@ -0,0 +1,303 @@
+package grpcapi
+
+import (
+	"context"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	kangarooblockpb "github.com/andantan/kangaroo/proto/core/block/pb"
+	kangarootxpb "github.com/andantan/kangaroo/proto/core/transaction/pb"
+	kangaroorpcpb "github.com/andantan/kangaroo/proto/rpc/pb"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+type fixture struct {
+	t       *testing.T
+	chain   *chain.Chain
+	pool    *mempool.Mempool
+	user    key.PrivateKey
+	address hash.Address
+	server  *Server
+	conn    *grpc.ClientConn
+	sent    []transaction.Transaction
+}
+
+func newFixture(t *testing.T, cfg Config) *fixture {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	user, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	address := user.PublicKey().Address(addressSuite.Deriver())
+
+	c, err := chain.NewChain(state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver()), &chain.Genesis{
+		Timestamp: 1,
+		Alloc:     []chain.GenesisAccount{{Address: address, Balance: big.NewInt(1000)}},
+	}, nil)
+	require.NoError(t, err)
+
+	f := &fixture{t: t, chain: c, pool: mempool.NewMempool(hashSuite.Deriver(), 0), user: user, address: address}
+	cfg.ChainID = "kangaroo-test"
+	cfg.OnTransaction = func(tx transaction.Transaction) { f.sent = append(f.sent, tx) }
+	f.server = NewServer(cfg, c, f.pool)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	f.server.Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	f.conn, err = grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.conn.Close() })
+	return f
+}
+
+func (f *fixture) tx(to hash.Address, value int64, nonce uint64) transaction.Transaction {
+	tx := kangarootransaction.NewKangarooTransaction(to, big.NewInt(value), nil, nonce)
+	require.NoError(f.t, tx.Sign(f.user, f.chain.Executor().HashDeriver()))
+	return tx
+}
+
+func (f *fixture) txProto(tx transaction.Transaction) *kangarootxpb.KangarooTransaction {
+	pb, err := tx.ToProto()
+	require.NoError(f.t, err)
+	return pb.(*kangarootxpb.KangarooTransaction)
+}
+
+func (f *fixture) mine() block.Block {
+	blk, err := assembler.NewAssembler(f.chain, f.pool, 0).Assemble(nil, f.chain.Head().GetHeader().GetTimestamp()+1, nil)
+	require.NoError(f.t, err)
+	require.NoError(f.t, f.chain.AddBlock(blk))
+	f.pool.RemoveTransactions(blk.GetBody().GetTransactions())
+	return blk
+}
+
+func (f *fixture) hash(h hash.Hashable) hash.Hash {
+	id, err := h.Hash(f.chain.Executor().HashDeriver())
+	require.NoError(f.t, err)
+	return id
+}
+
+// id returns the wrapped hash of h, as carried in the messages.
+func (f *fixture) id(h hash.Hashable) []byte {
+	wrapped, err := wrapper.WrapHash(f.hash(h))
+	require.NoError(f.t, err)
+	return wrapped
+}
+
+func (f *fixture) decodeBlock(pb *kangarooblockpb.KangarooBlock) block.Block {
+	suite, err := registry.GetBlockSuite(block.KangarooBlockType)
+	require.NoError(f.t, err)
+	blk := suite.NewBlock()
+	require.NoError(f.t, blk.FromProto(pb))
+	return blk
+}
+
+func TestServer_Unary(t *testing.T) {
+	f := newFixture(t, Config{})
+	ctx := context.Background()
+	node := kangaroorpcpb.NewNodeClient(f.conn)
+	pool := kangaroorpcpb.NewMempoolClient(f.conn)
+	st := kangaroorpcpb.NewStateClient(f.conn)
+	blocks := kangaroorpcpb.NewBlocksClient(f.conn)
+
+	suites, err := node.ListSupportedSuites(ctx, &kangaroorpcpb.KangarooSupportedSuitesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, registry.ListKeySuiteTypes(), suites.Keys)
+	assert.Contains(t, suites.Hashes, "sha256")
+
+	tx := f.tx(nil, 10, 0)
+	sent, err := pool.SendTransaction(ctx, f.txProto(tx))
+	require.NoError(t, err)
+	assert.Equal(t, f.id(tx), sent.Hash)
+	assert.Len(t, f.sent, 1)
+
+	_, err = pool.SendTransaction(ctx, f.txProto(tx))
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	tampered := f.txProto(tx)
+	tampered.Value = big.NewInt(11).Bytes()
+	_, err = pool.SendTransaction(ctx, tampered)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	pending, err := pool.GetPendingTransaction(ctx, &kangaroorpcpb.KangarooHashRequest{Hash: sent.Hash})
+	require.NoError(t, err)
+	assert.Equal(t, f.txProto(tx).Signature, pending.Signature)
+
+	wrappedAddress, err := wrapper.WrapAddress(f.address)
+	require.NoError(t, err)
+	account, err := st.GetAccount(ctx, &kangaroorpcpb.KangarooAccountRequest{Address: wrappedAddress})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000).Bytes(), account.Balance)
+	assert.Equal(t, uint64(0), account.Nonce)
+	assert.Equal(t, uint64(1), account.PendingNonce)
+
+	blk := f.mine()
+
+	_, err = pool.GetPendingTransaction(ctx, &kangaroorpcpb.KangarooHashRequest{Hash: sent.Hash})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	included, err := blocks.GetTransaction(ctx, &kangaroorpcpb.KangarooHashRequest{Hash: sent.Hash})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), included.Height)
+	assert.Equal(t, f.id(blk), included.BlockId)
+	assert.Equal(t, uint32(0), included.Index)
+
+	byHeight, err := blocks.GetBlockByHeight(ctx, &kangaroorpcpb.KangarooHeightRequest{Height: 1})
+	require.NoError(t, err)
+	assert.Equal(t, f.id(blk), f.id(f.decodeBlock(byHeight)))
+	byHash, err := blocks.GetBlockByHash(ctx, &kangaroorpcpb.KangarooHashRequest{Hash: f.id(blk)})
+	require.NoError(t, err)
+	assert.Equal(t, byHeight.Header, byHash.Header)
+
+	body, err := blocks.GetBody(ctx, &kangaroorpcpb.KangarooHashRequest{Hash: f.id(blk)})
+	require.NoError(t, err)
+	require.Len(t, body.Transactions, 1)
+	wrappedTx, err := wrapper.WrapTransactionCanonical(tx)
+	require.NoError(t, err)
+	assert.Equal(t, wrappedTx, body.Transactions[0])
+
+	attestations, err := blocks.GetAttestations(ctx, &kangaroorpcpb.KangarooHashRequest{Hash: f.id(blk)})
+	require.NoError(t, err)
+	assert.Empty(t, attestations.Attestations)
+
+	_, err = blocks.GetBlockByHeight(ctx, &kangaroorpcpb.KangarooHeightRequest{Height: 9})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = blocks.GetBlockByHash(ctx, &kangaroorpcpb.KangarooHashRequest{Hash: []byte{0xff}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = blocks.GetTransaction(ctx, &kangaroorpcpb.KangarooHashRequest{Hash: f.id(blk)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	info, err := node.GetChainInfo(ctx, &kangaroorpcpb.KangarooChainInfoRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "kangaroo-test", info.ChainId)
+	assert.Equal(t, uint64(1), info.Height)
+	assert.Equal(t, f.id(blk), info.HeadId)
+	assert.Equal(t, f.id(f.chain.Genesis()), info.FinalizedId)
+	assert.Equal(t, "keccak256", info.AddressSuite)
+
+	account, err = st.GetAccount(ctx, &kangaroorpcpb.KangarooAccountRequest{Address: wrappedAddress})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(990).Bytes(), account.Balance)
+	assert.Equal(t, uint64(1), account.Nonce)
+}
+
+func TestServer_StreamBlocks(t *testing.T) {
+	f := newFixture(t, Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	blocks := kangaroorpcpb.NewBlocksClient(f.conn)
+
+	var mined []block.Block
+	for i := 0; i < 3; i++ {
+		mined = append(mined, f.mine())
+	}
+
+	replay, err := blocks.StreamBlocks(ctx, &kangaroorpcpb.KangarooStreamBlocksRequest{FromHeight: 2})
+	require.NoError(t, err)
+	finalized, err := blocks.StreamBlocks(ctx, &kangaroorpcpb.KangarooStreamBlocksRequest{Finalized: true})
+	require.NoError(t, err)
+
+	for _, blk := range mined[1:] {
+		pb, err := replay.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, f.id(blk), f.id(f.decodeBlock(pb)))
+	}
+	require.Eventually(t, func() bool { return f.server.heads.len() == 1 && f.server.finalized.len() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// A head published again, as on a late notification, is not repeated.
+	f.server.PublishHead(mined[2])
+	next := f.mine()
+	f.server.PublishHead(next)
+	pb, err := replay.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, f.id(next), f.id(f.decodeBlock(pb)))
+
+	require.NoError(t, f.chain.Finalize(2, f.hash(mined[1])))
+	f.server.PublishFinalized(mined[1])
+	pb, err = finalized.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, f.id(mined[1]), f.id(f.decodeBlock(pb)))
+
+	cancel()
+	require.Eventually(t, func() bool { return f.server.heads.len() == 0 && f.server.finalized.len() == 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServer_StreamPendingTransactions(t *testing.T) {
+	f := newFixture(t, Config{StreamBuffer: 2})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	pool := kangaroorpcpb.NewMempoolClient(f.conn)
+
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	other, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	recipient := other.PublicKey().Address(f.chain.Executor().AddressDeriver())
+	wrappedRecipient, err := wrapper.WrapAddress(recipient)
+	require.NoError(t, err)
+
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	watched, err := pool.StreamPendingTransactions(watchCtx, &kangaroorpcpb.KangarooStreamPendingRequest{Addresses: [][]byte{wrappedRecipient}})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return f.server.pending.len() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	toNobody, toRecipient := f.tx(nil, 1, 0), f.tx(recipient, 2, 1)
+	for _, tx := range []transaction.Transaction{toNobody, toRecipient} {
+		_, err := pool.SendTransaction(ctx, f.txProto(tx))
+		require.NoError(t, err)
+	}
+	pb, err := watched.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, f.txProto(toRecipient).Signature, pb.Signature)
+	watchCancel()
+	require.Eventually(t, func() bool { return f.server.pending.len() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// A stream that is not read falls behind and is ended instead of
+	// blocking publishers.
+	slowCtx, slowCancel := context.WithCancel(ctx)
+	defer slowCancel()
+	slow, err := pool.StreamPendingTransactions(slowCtx, &kangaroorpcpb.KangarooStreamPendingRequest{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return f.server.pending.len() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	large := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(1), make([]byte, 64<<10), 2)
+	require.NoError(t, large.Sign(f.user, f.chain.Executor().HashDeriver()))
+	for i := 0; i < 1000 && f.server.pending.len() == 1; i++ {
+		f.server.PublishPendingTransaction(large)
+	}
+	assert.Equal(t, 0, f.server.pending.len())
+
+	var last error
+	for last == nil {
+		_, last = slow.Recv()
+	}
+	assert.Equal(t, codes.ResourceExhausted, status.Code(last))
+}
@@ -0,0 +1,75 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const Version = "2.0"
+
+// Standard JSON-RPC 2.0 error codes, followed by the application range.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+
+	// CodeTransactionRejected is returned when the mempool refuses a
+	// transaction (bad signature, already known, pool full).
+	CodeTransactionRejected = -32000
+)
+
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// ID is absent for notifications, which get no response.
+	ID json.RawMessage `json:"id,omitempty"`
+}
+
+func (r *Request) IsNotification() bool {
+	return len(r.ID) == 0
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+func invalidParams(format string, args ...any) *Error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// positional decodes a params array into dst. The first required entries
+// must be present, the rest are optional and keep their zero value.
+func positional(params json.RawMessage, required int, dst ...any) error {
+	var raw []json.RawMessage
+	if len(params) != 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &raw); err != nil {
+			return invalidParams("params must be an array")
+		}
+	}
+	if len(raw) < required || len(raw) > len(dst) {
+		return invalidParams("expected %d to %d params, got %d", required, len(dst), len(raw))
+	}
+
+	for i, r := range raw {
+		if err := json.Unmarshal(r, dst[i]); err != nil {
+			return invalidParams("param %d: %v", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/registry"
+)
+
+const (
+	TagLatest  = "latest"
+	TagPending = "pending"
+)
+
+// sendRawTransaction takes a wrapped transaction string and returns its
+// wrapped hash once the pool accepted it.
+func (s *Server) sendRawTransaction(params json.RawMessage) (any, error) {
+	var raw string
+	if err := positional(params, 1, &raw); err != nil {
+		return nil, err
+	}
+
+	tx, err := wrapper.UnwrapTransactionFromString(raw)
+	if err != nil {
+		return nil, invalidParams("transaction: %v", err)
+	}
+	id, err := s.pool.Add(tx)
+	if err != nil {
+		return nil, &Error{Code: CodeTransactionRejected, Message: err.Error()}
+	}
+	if s.cfg.OnTransaction != nil {
+		s.cfg.OnTransaction(tx)
+	}
+	s.PublishPendingTransaction(tx)
+	return wrapper.WrapHashToString(id)
+}
+
+// getTransactionByHash looks in the pool first, then the chain. Unknown
+// transactions give a null result.
+func (s *Server) getTransactionByHash(params json.RawMessage) (any, error) {
+	var raw string
+	if err := positional(params, 1, &raw); err != nil {
+		return nil, err
+	}
+	id, err := wrapper.UnwrapHashFromString(raw)
+	if err != nil {
+		return nil, invalidParams("hash: %v", err)
+	}
+
+	if tx, ok := s.pool.Get(id); ok {
+		r, err := newTransaction(s.chain.Executor(), tx)
+		if err != nil {
+			return nil, err
+		}
+		r.Pending = true
+		return r, nil
+	}
+
+	tx, loc, err := s.chain.GetTransactionByHash(id)
+	if errors.Is(err, chain.ErrUnknownTransaction) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newIncludedTransaction(s.chain.Executor(), tx, loc)
+}
+
+// getBlockByHeight and getBlockByHash take an optional second param that
+// adds the whole wrapped block to the result. Unknown blocks give null.
+func (s *Server) getBlockByHeight(params json.RawMessage) (any, error) {
+	var (
+		height uint64
+		raw    bool
+	)
+	if err := positional(params, 1, &height, &raw); err != nil {
+		return nil, err
+	}
+
+	blk, err := s.chain.GetBlockByHeight(height)
+	if errors.Is(err, chain.ErrUnknownBlock) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.block(blk, raw)
+}
+
+func (s *Server) getBlockByHash(params json.RawMessage) (any, error) {
+	var (
+		h   string
+		raw bool
+	)
+	if err := positional(params, 1, &h, &raw); err != nil {
+		return nil, err
+	}
+	id, err := wrapper.UnwrapHashFromString(h)
+	if err != nil {
+		return nil, invalidParams("hash: %v", err)
+	}
+
+	blk, err := s.chain.GetBlockByID(id)
+	if errors.Is(err, chain.ErrUnknownBlock) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.block(blk, raw)
+}
+
+func (s *Server) block(blk block.Block, raw bool) (*Block, error) {
+	finalized, _, err := s.chain.Finalized()
+	if err != nil {
+		return nil, err
+	}
+	return newBlock(s.chain.Executor(), blk, blk.GetHeader().GetHeight() <= finalized, raw)
+}
+
+// getBalance returns the balance at the head as a decimal string.
+func (s *Server) getBalance(params json.RawMessage) (any, error) {
+	var raw string
+	if err := positional(params, 1, &raw); err != nil {
+		return nil, err
+	}
+	addr, err := wrapper.UnwrapAddressFromString(raw)
+	if err != nil {
+		return nil, invalidParams("address: %v", err)
+	}
+	return s.chain.State().GetBalance(addr).String(), nil
+}
+
+// getNonce returns the next nonce of an address. With the "pending" tag it
+// also counts the consecutive nonces the pool already holds for it.
+func (s *Server) getNonce(params json.RawMessage) (any, error) {
+	var raw string
+	tag := TagLatest
+	if err := positional(params, 1, &raw, &tag); err != nil {
+		return nil, err
+	}
+	addr, err := wrapper.UnwrapAddressFromString(raw)
+	if err != nil {
+		return nil, invalidParams("address: %v", err)
+	}
+
+	nonce := s.chain.State().GetNonce(addr)
+	switch tag {
+	case TagLatest:
+		return nonce, nil
+	case TagPending:
+	default:
+		return nil, invalidParams("unknown tag %q", tag)
+	}
+
+	executor := s.chain.Executor()
+	pending := make(map[uint64]bool)
+	s.pool.Range(func(_ hash.Hash, tx transaction.Transaction) bool {
+		if sender, err := executor.SenderOf(tx); err == nil && sender.Equal(addr) {
+			pending[tx.GetNonce()] = true
+		}
+		return true
+	})
+	for pending[nonce] {
+		nonce++
+	}
+	return nonce, nil
+}
+
+func (s *Server) chainInfo(json.RawMessage) (any, error) {
+	executor := s.chain.Executor()
+
+	head := s.chain.Head()
+	headID, err := head.Hash(executor.HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+	genesisID, err := s.chain.Genesis().Hash(executor.HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+	finalized, finalizedID, err := s.chain.Finalized()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ChainInfo{
+		ChainID:         s.cfg.ChainID,
+		Height:          head.GetHeader().GetHeight(),
+		BaseHeight:      s.chain.Base(),
+		FinalizedHeight: finalized,
+		HashSuite:       executor.HashDeriver().Type(),
+		AddressSuite:    executor.AddressDeriver().Type(),
+		Pending:         s.pool.Len(),
+	}
+	if info.HeadHash, err = wrapper.WrapHashToString(headID); err != nil {
+		return nil, err
+	}
+	if info.GenesisHash, err = wrapper.WrapHashToString(genesisID); err != nil {
+		return nil, err
+	}
+	if info.FinalizedHash, err = wrapper.WrapHashToString(finalizedID); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (s *Server) listSupportedSuites(json.RawMessage) (any, error) {
+	return &Suites{
+		Keys:      registry.ListKeySuiteTypes(),
+		Hashes:    registry.ListHashSuiteTypes(),
+		Addresses: registry.ListAddressSuiteTypes(),
+	}, nil
+}
@@ -0,0 +1,218 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/gorilla/websocket"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxBodySize      = 5 << 20
+	DefaultMaxBatchSize     = 100
+	DefaultSubscriberBuffer = 256
+	DefaultWriteTimeout     = 10 * time.Second
+)
+
+type Config struct {
+	// ChainID is reported by chainInfo so clients can tell networks apart.
+	ChainID      string
+	MaxBodySize  int64
+	MaxBatchSize int
+	// OnTransaction is called for every transaction sendRawTransaction
+	// admits to the pool, e.g. to gossip it.
+	OnTransaction func(tx transaction.Transaction)
+
+	// SubscriberBuffer is how many messages may queue for a websocket
+	// client. A client that falls further behind, or whose writes take
+	// longer than WriteTimeout, is dropped so it never blocks the node.
+	SubscriberBuffer int
+	WriteTimeout     time.Duration
+}
+
+type handler func(params json.RawMessage) (any, error)
+
+// Server answers JSON-RPC 2.0 requests, single or batched, POSTed over
+// HTTP. The same endpoint upgrades to a websocket, over which clients can
+// also subscribe to chain and mempool events.
+type Server struct {
+	cfg       Config
+	chain     *chain.Chain
+	pool      *mempool.Mempool
+	methods   map[string]handler
+	wsMethods map[string]wsHandler
+
+	subsLock sync.Mutex
+	subs     map[string]*subscription
+	nextSub  uint64
+}
+
+func NewServer(cfg Config, c *chain.Chain, pool *mempool.Mempool) *Server {
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = DefaultMaxBodySize
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = DefaultMaxBatchSize
+	}
+	if cfg.SubscriberBuffer <= 0 {
+		cfg.SubscriberBuffer = DefaultSubscriberBuffer
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = DefaultWriteTimeout
+	}
+
+	s := &Server{cfg: cfg, chain: c, pool: pool, subs: make(map[string]*subscription)}
+	s.methods = map[string]handler{
+		"sendRawTransaction":   s.sendRawTransaction,
+		"getTransactionByHash": s.getTransactionByHash,
+		"getBlockByHeight":     s.getBlockByHeight,
+		"getBlockByHash":       s.getBlockByHash,
+		"getBalance":           s.getBalance,
+		"getNonce":             s.getNonce,
+		"chainInfo":            s.chainInfo,
+		"listSupportedSuites":  s.listSupportedSuites,
+	}
+	s.wsMethods = map[string]wsHandler{
+		"subscribe":   s.subscribe,
+		"unsubscribe": s.unsubscribe,
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWebSocket(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.cfg.MaxBodySize))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	reply := s.Handle(body)
+	if reply == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(reply); err != nil {
+		log.Printf("[RPC] write response: %v", err)
+	}
+}
+
+// Handle serves one raw request body and returns the raw response, nil when
+// it held only notifications.
+func (s *Server) Handle(body []byte) []byte {
+	return s.handle(body, nil)
+}
+
+// handle serves a request body for an HTTP request, or for a websocket
+// client, which can also use the subscription methods.
+func (s *Server) handle(body []byte, client *wsClient) []byte {
+	body = bytes.TrimSpace(body)
+
+	if len(body) == 0 || body[0] != '[' {
+		var req Request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return marshal(errorResponse(nil, &Error{Code: CodeParseError, Message: err.Error()}))
+		}
+		resp := s.call(&req, client)
+		if resp == nil {
+			return nil
+		}
+		return marshal(resp)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return marshal(errorResponse(nil, &Error{Code: CodeParseError, Message: err.Error()}))
+	}
+	if len(batch) == 0 {
+		return marshal(errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"}))
+	}
+	if len(batch) > s.cfg.MaxBatchSize {
+		return marshal(errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "batch too large"}))
+	}
+
+	var resps []*Response
+	for _, raw := range batch {
+		var req Request
+		if err := json.Unmarshal(raw, &req); err != nil {
+			resps = append(resps, errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: err.Error()}))
+			continue
+		}
+		if resp := s.call(&req, client); resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+	if len(resps) == 0 {
+		return nil
+	}
+	return marshal(resps)
+}
+
+func (s *Server) call(req *Request, client *wsClient) *Response {
+	if req.JSONRPC != Version || req.Method == "" {
+		return errorResponse(req.ID, &Error{Code: CodeInvalidRequest, Message: "invalid request"})
+	}
+
+	var (
+		result any
+		err    error
+	)
+	if h, ok := s.methods[req.Method]; ok {
+		result, err = h(req.Params)
+	} else if h, ok := s.wsMethods[req.Method]; ok && client != nil {
+		result, err = h(client, req.Params)
+	} else {
+		err = &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+
+	if req.IsNotification() {
+		return nil
+	}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		return errorResponse(req.ID, rpcErr)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, &Error{Code: CodeInternalError, Message: err.Error()})
+	}
+	return &Response{JSONRPC: Version, Result: encoded, ID: req.ID}
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: Version, Error: err, ID: id}
+}
+
+func marshal(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		// Responses only hold already encoded results and plain errors.
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,238 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"log"
+)
+
+// Subscription topics.
+const (
+	// TopicNewHeads delivers every block that becomes the head, as a Block.
+	TopicNewHeads = "newHeads"
+	// TopicFinalizedBlocks delivers blocks as they are finalized.
+	TopicFinalizedBlocks = "finalizedBlocks"
+	// TopicPendingTransactions delivers transactions entering the pool.
+	TopicPendingTransactions = "pendingTransactions"
+	// TopicTransactions delivers transactions included in new heads.
+	TopicTransactions = "transactions"
+)
+
+const NotificationMethod = "subscription"
+
+// TransactionFilter narrows the transaction topics down to transactions
+// sent from or to one of the addresses. An empty filter matches everything.
+type TransactionFilter struct {
+	Addresses []string `json:"addresses"`
+}
+
+type Notification struct {
+	JSONRPC string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  SubscriptionResult `json:"params"`
+}
+
+type SubscriptionResult struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+type wsHandler func(client *wsClient, params json.RawMessage) (any, error)
+
+type subscription struct {
+	id     string
+	topic  string
+	client *wsClient
+	// addresses is the normalized filter, nil when it matches everything.
+	addresses map[string]bool
+}
+
+func (sub *subscription) matches(tx *Transaction) bool {
+	return sub.addresses == nil || sub.addresses[tx.From] || (tx.To != "" && sub.addresses[tx.To])
+}
+
+// subscribe takes a topic and, for the transaction topics, an optional
+// filter. It returns the subscription id carried by every notification.
+func (s *Server) subscribe(client *wsClient, params json.RawMessage) (any, error) {
+	var (
+		topic  string
+		filter TransactionFilter
+	)
+	if err := positional(params, 1, &topic, &filter); err != nil {
+		return nil, err
+	}
+
+	sub := &subscription{topic: topic, client: client}
+	switch topic {
+	case TopicNewHeads, TopicFinalizedBlocks:
+		if len(filter.Addresses) != 0 {
+			return nil, invalidParams("topic %s takes no filter", topic)
+		}
+	case TopicPendingTransactions, TopicTransactions:
+		for _, raw := range filter.Addresses {
+			// Round trip so differently cased hex still matches.
+			addr, err := wrapper.UnwrapAddressFromString(raw)
+			if err != nil {
+				return nil, invalidParams("address: %v", err)
+			}
+			wrapped, err := wrapper.WrapAddressToString(addr)
+			if err != nil {
+				return nil, invalidParams("address: %v", err)
+			}
+			if sub.addresses == nil {
+				sub.addresses = make(map[string]bool)
+			}
+			sub.addresses[wrapped] = true
+		}
+	default:
+		return nil, invalidParams("unknown topic %q", topic)
+	}
+
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+
+	if client.subs == nil {
+		return nil, fmt.Errorf("connection closed")
+	}
+	s.nextSub++
+	sub.id = fmt.Sprintf("0x%x", s.nextSub)
+	s.subs[sub.id] = sub
+	client.subs[sub.id] = sub
+	return sub.id, nil
+}
+
+// unsubscribe reports whether the subscription existed. Clients can only
+// cancel their own subscriptions.
+func (s *Server) unsubscribe(client *wsClient, params json.RawMessage) (any, error) {
+	var id string
+	if err := positional(params, 1, &id); err != nil {
+		return nil, err
+	}
+
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+
+	if _, ok := client.subs[id]; !ok {
+		return false, nil
+	}
+	delete(client.subs, id)
+	delete(s.subs, id)
+	return true, nil
+}
+
+// Subscriptions returns the number of live subscriptions.
+func (s *Server) Subscriptions() int {
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	return len(s.subs)
+}
+
+func (s *Server) subscribers(topic string) []*subscription {
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+
+	var subs []*subscription
+	for _, sub := range s.subs {
+		if sub.topic == topic {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+func (s *Server) notify(sub *subscription, result json.RawMessage) {
+	msg := marshal(&Notification{
+		JSONRPC: Version,
+		Method:  NotificationMethod,
+		Params:  SubscriptionResult{Subscription: sub.id, Result: result},
+	})
+	s.enqueue(sub.client, msg)
+}
+
+// PublishHead notifies newHeads subscribers of a block that became the head,
+// and transactions subscribers of the transactions in it. During a reorg it
+// should be called for every block of the new branch, in order.
+func (s *Server) PublishHead(blk block.Block) {
+	if heads := s.subscribers(TopicNewHeads); len(heads) != 0 {
+		r, err := newBlock(s.chain.Executor(), blk, false, false)
+		if err != nil {
+			log.Printf("[RPC] encode head: %v", err)
+			return
+		}
+		encoded := marshal(r)
+		for _, sub := range heads {
+			s.notify(sub, encoded)
+		}
+	}
+
+	subs := s.subscribers(TopicTransactions)
+	if len(subs) == 0 {
+		return
+	}
+	id, err := blk.Hash(s.chain.Executor().HashDeriver())
+	if err != nil {
+		log.Printf("[RPC] encode head: %v", err)
+		return
+	}
+	for i, tx := range blk.GetBody().GetTransactions() {
+		loc := chain.TxLocation{Height: blk.GetHeader().GetHeight(), BlockID: id, Index: i}
+		r, err := newIncludedTransaction(s.chain.Executor(), tx, loc)
+		if err != nil {
+			log.Printf("[RPC] encode transaction: %v", err)
+			continue
+		}
+		s.publishTransaction(subs, r)
+	}
+}
+
+// PublishFinalized notifies finalizedBlocks subscribers. Call it once per
+// newly finalized block, in height order.
+func (s *Server) PublishFinalized(blk block.Block) {
+	subs := s.subscribers(TopicFinalizedBlocks)
+	if len(subs) == 0 {
+		return
+	}
+	r, err := newBlock(s.chain.Executor(), blk, true, false)
+	if err != nil {
+		log.Printf("[RPC] encode finalized block: %v", err)
+		return
+	}
+	encoded := marshal(r)
+	for _, sub := range subs {
+		s.notify(sub, encoded)
+	}
+}
+
+// PublishPendingTransaction notifies pendingTransactions subscribers of a
+// transaction the pool accepted. sendRawTransaction calls it itself; the node
+// calls it for transactions arriving from peers.
+func (s *Server) PublishPendingTransaction(tx transaction.Transaction) {
+	subs := s.subscribers(TopicPendingTransactions)
+	if len(subs) == 0 {
+		return
+	}
+	r, err := newTransaction(s.chain.Executor(), tx)
+	if err != nil {
+		log.Printf("[RPC] encode transaction: %v", err)
+		return
+	}
+	r.Pending = true
+	s.publishTransaction(subs, r)
+}
+
+func (s *Server) publishTransaction(subs []*subscription, tx *Transaction) {
+	var encoded []byte
+	for _, sub := range subs {
+		if !sub.matches(tx) {
+			continue
+		}
+		if encoded == nil {
+			encoded = marshal(tx)
+		}
+		s.notify(sub, encoded)
+	}
+}
@@ -0,0 +1,478 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/consensus/assembler"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/mempool"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixture struct {
+	t       *testing.T
+	chain   *chain.Chain
+	pool    *mempool.Mempool
+	user    key.PrivateKey
+	address hash.Address
+	server  *Server
+	srv     *httptest.Server
+	sent    []transaction.Transaction
+}
+
+func newFixture(t *testing.T, cfg Config) *fixture {
+	keySuite, err := registry.GetKeySuite("eddsa-ed25519")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	user, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	address := user.PublicKey().Address(addressSuite.Deriver())
+
+	c, err := chain.NewChain(state.NewExecutor(hashSuite.Deriver(), addressSuite.Deriver()), &chain.Genesis{
+		Timestamp: 1,
+		Alloc:     []chain.GenesisAccount{{Address: address, Balance: big.NewInt(1000)}},
+	}, nil)
+	require.NoError(t, err)
+
+	f := &fixture{t: t, chain: c, pool: mempool.NewMempool(hashSuite.Deriver(), 0), user: user, address: address}
+	cfg.ChainID = "kangaroo-test"
+	cfg.OnTransaction = func(tx transaction.Transaction) { f.sent = append(f.sent, tx) }
+	f.server = NewServer(cfg, c, f.pool)
+	f.srv = httptest.NewServer(f.server)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fixture) tx(to hash.Address, value int64, nonce uint64) transaction.Transaction {
+	tx := kangarootransaction.NewKangarooTransaction(to, big.NewInt(value), []byte{0xca, 0xfe}, nonce)
+	require.NoError(f.t, tx.Sign(f.user, f.chain.Executor().HashDeriver()))
+	return tx
+}
+
+func (f *fixture) mine() block.Block {
+	blk, err := assembler.NewAssembler(f.chain, f.pool, 0).Assemble(nil, f.chain.Head().GetHeader().GetTimestamp()+1, nil)
+	require.NoError(f.t, err)
+	require.NoError(f.t, f.chain.AddBlock(blk))
+	f.pool.RemoveTransactions(blk.GetBody().GetTransactions())
+	return blk
+}
+
+func (f *fixture) post(body string) (int, []byte) {
+	resp, err := http.Post(f.srv.URL, "application/json", bytes.NewBufferString(body))
+	require.NoError(f.t, err)
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(resp.Body)
+	require.NoError(f.t, err)
+	return resp.StatusCode, buf.Bytes()
+}
+
+// call sends a single request and decodes its result into out, returning the
+// error object if there was one.
+func (f *fixture) call(out any, method string, params ...any) *Error {
+	if params == nil {
+		params = []any{}
+	}
+	body, err := json.Marshal(map[string]any{"jsonrpc": Version, "id": 1, "method": method, "params": params})
+	require.NoError(f.t, err)
+
+	status, reply := f.post(string(body))
+	require.Equal(f.t, http.StatusOK, status)
+
+	var resp Response
+	require.NoError(f.t, json.Unmarshal(reply, &resp))
+	assert.Equal(f.t, Version, resp.JSONRPC)
+	assert.JSONEq(f.t, "1", string(resp.ID))
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if out != nil {
+		require.NoError(f.t, json.Unmarshal(resp.Result, out))
+	}
+	return nil
+}
+
+func (f *fixture) wrapAddress(a hash.Address) string {
+	s, err := wrapper.WrapAddressToString(a)
+	require.NoError(f.t, err)
+	return s
+}
+
+func TestServer_Transactions(t *testing.T) {
+	f := newFixture(t, Config{})
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	other, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	recipient := other.PublicKey().Address(f.chain.Executor().AddressDeriver())
+
+	tx := f.tx(recipient, 10, 0)
+	raw, err := wrapper.WrapTransactionToString(tx)
+	require.NoError(t, err)
+
+	var sent string
+	require.Nil(t, f.call(&sent, "sendRawTransaction", raw))
+	id, err := tx.Hash(f.chain.Executor().HashDeriver())
+	require.NoError(t, err)
+	want, err := wrapper.WrapHashToString(id)
+	require.NoError(t, err)
+	assert.Equal(t, want, sent)
+	assert.Len(t, f.sent, 1)
+
+	rpcErr := f.call(nil, "sendRawTransaction", raw)
+	require.NotNil(t, rpcErr)
+	assert.Equal(t, CodeTransactionRejected, rpcErr.Code)
+	assert.Len(t, f.sent, 1)
+
+	rpcErr = f.call(nil, "sendRawTransaction", "0xzz")
+	require.NotNil(t, rpcErr)
+	assert.Equal(t, CodeInvalidParams, rpcErr.Code)
+
+	var pending Transaction
+	require.Nil(t, f.call(&pending, "getTransactionByHash", sent))
+	assert.True(t, pending.Pending)
+	assert.Equal(t, sent, pending.Hash)
+	assert.Equal(t, f.wrapAddress(f.address), pending.From)
+	assert.Equal(t, f.wrapAddress(recipient), pending.To)
+	assert.Equal(t, "10", pending.Value)
+	assert.Equal(t, "0xcafe", pending.Data)
+	assert.Equal(t, raw, pending.Raw)
+
+	decoded, err := wrapper.UnwrapTransactionFromString(pending.Raw)
+	require.NoError(t, err)
+	decodedID, err := decoded.Hash(f.chain.Executor().HashDeriver())
+	require.NoError(t, err)
+	assert.True(t, id.Equal(decodedID))
+
+	var nonce uint64
+	require.Nil(t, f.call(&nonce, "getNonce", f.wrapAddress(f.address)))
+	assert.Equal(t, uint64(0), nonce)
+	require.Nil(t, f.call(&nonce, "getNonce", f.wrapAddress(f.address), TagPending))
+	assert.Equal(t, uint64(1), nonce)
+
+	f.mine()
+
+	var included Transaction
+	require.Nil(t, f.call(&included, "getTransactionByHash", sent))
+	assert.False(t, included.Pending)
+	assert.Equal(t, uint64(1), included.BlockHeight)
+	assert.Equal(t, 0, included.Index)
+	head, err := f.chain.Head().Hash(f.chain.Executor().HashDeriver())
+	require.NoError(t, err)
+	headHash, err := wrapper.WrapHashToString(head)
+	require.NoError(t, err)
+	assert.Equal(t, headHash, included.BlockHash)
+
+	var balance string
+	require.Nil(t, f.call(&balance, "getBalance", f.wrapAddress(recipient)))
+	assert.Equal(t, "10", balance)
+	require.Nil(t, f.call(&balance, "getBalance", f.wrapAddress(f.address)))
+	assert.Equal(t, "990", balance)
+	require.Nil(t, f.call(&nonce, "getNonce", f.wrapAddress(f.address), TagLatest))
+	assert.Equal(t, uint64(1), nonce)
+
+	unknown, err := wrapper.WrapHashToString(f.chain.Executor().HashDeriver().Derive([]byte("unknown")))
+	require.NoError(t, err)
+	var missing *Transaction
+	require.Nil(t, f.call(&missing, "getTransactionByHash", unknown))
+	assert.Nil(t, missing)
+
+	rpcErr = f.call(nil, "getNonce", f.wrapAddress(f.address), "earliest")
+	require.NotNil(t, rpcErr)
+	assert.Equal(t, CodeInvalidParams, rpcErr.Code)
+}
+
+func TestServer_Blocks(t *testing.T) {
+	f := newFixture(t, Config{})
+	_, err := f.pool.Add(f.tx(nil, 1, 0))
+	require.NoError(t, err)
+	f.mine()
+
+	var byHeight Block
+	require.Nil(t, f.call(&byHeight, "getBlockByHeight", 1))
+	assert.Equal(t, uint64(1), byHeight.Height)
+	assert.Len(t, byHeight.Transactions, 1)
+	assert.Empty(t, byHeight.Raw)
+	assert.False(t, byHeight.Finalized)
+
+	genesis, err := f.chain.Genesis().Hash(f.chain.Executor().HashDeriver())
+	require.NoError(t, err)
+	genesisHash, err := wrapper.WrapHashToString(genesis)
+	require.NoError(t, err)
+	assert.Equal(t, genesisHash, byHeight.PrevBlockHash)
+
+	var byHash Block
+	require.Nil(t, f.call(&byHash, "getBlockByHash", byHeight.Hash, true))
+	assert.Equal(t, byHeight.Hash, byHash.Hash)
+	assert.Equal(t, byHeight.Transactions, byHash.Transactions)
+
+	blk, err := wrapper.UnwrapBlockFromString(byHash.Raw)
+	require.NoError(t, err)
+	id, err := blk.Hash(f.chain.Executor().HashDeriver())
+	require.NoError(t, err)
+	wrapped, err := wrapper.WrapHashToString(id)
+	require.NoError(t, err)
+	assert.Equal(t, byHash.Hash, wrapped)
+
+	var genesisBlock Block
+	require.Nil(t, f.call(&genesisBlock, "getBlockByHeight", 0))
+	assert.True(t, genesisBlock.Finalized)
+	assert.Empty(t, genesisBlock.Transactions)
+
+	var missing *Block
+	require.Nil(t, f.call(&missing, "getBlockByHeight", 5))
+	assert.Nil(t, missing)
+
+	var info ChainInfo
+	require.Nil(t, f.call(&info, "chainInfo"))
+	assert.Equal(t, "kangaroo-test", info.ChainID)
+	assert.Equal(t, uint64(1), info.Height)
+	assert.Equal(t, byHeight.Hash, info.HeadHash)
+	assert.Equal(t, genesisHash, info.GenesisHash)
+	assert.Equal(t, genesisHash, info.FinalizedHash)
+	assert.Equal(t, "sha256", info.HashSuite)
+	assert.Equal(t, "keccak256", info.AddressSuite)
+
+	var suites Suites
+	require.Nil(t, f.call(&suites, "listSupportedSuites"))
+	assert.Equal(t, registry.ListKeySuiteTypes(), suites.Keys)
+	assert.Contains(t, suites.Keys, "eddsa-ed25519")
+	assert.Contains(t, suites.Hashes, "sha256")
+	assert.Contains(t, suites.Addresses, "keccak256")
+}
+
+func TestServer_Protocol(t *testing.T) {
+	f := newFixture(t, Config{})
+
+	resp, err := http.Get(f.srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	decode := func(body []byte) Response {
+		var r Response
+		require.NoError(t, json.Unmarshal(body, &r))
+		return r
+	}
+
+	_, body := f.post(`{"jsonrpc":"2.0","id":1,"method":`)
+	r := decode(body)
+	require.NotNil(t, r.Error)
+	assert.Equal(t, CodeParseError, r.Error.Code)
+	assert.Equal(t, "null", string(r.ID))
+
+	_, body = f.post(`{"jsonrpc":"1.0","id":"a","method":"chainInfo"}`)
+	r = decode(body)
+	require.NotNil(t, r.Error)
+	assert.Equal(t, CodeInvalidRequest, r.Error.Code)
+	assert.Equal(t, `"a"`, string(r.ID))
+
+	_, body = f.post(`{"jsonrpc":"2.0","id":2,"method":"nope"}`)
+	r = decode(body)
+	require.NotNil(t, r.Error)
+	assert.Equal(t, CodeMethodNotFound, r.Error.Code)
+
+	_, body = f.post(`{"jsonrpc":"2.0","id":3,"method":"getBalance","params":{"address":"0x00"}}`)
+	r = decode(body)
+	require.NotNil(t, r.Error)
+	assert.Equal(t, CodeInvalidParams, r.Error.Code)
+
+	// Notifications get no response at all.
+	status, body := f.post(`{"jsonrpc":"2.0","method":"chainInfo"}`)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.Empty(t, body)
+
+	_, body = f.post(`[
+		{"jsonrpc":"2.0","id":1,"method":"listSupportedSuites"},
+		{"jsonrpc":"2.0","method":"chainInfo"},
+		{"jsonrpc":"2.0","id":2,"method":"nope"},
+		5
+	]`)
+	var batch []Response
+	require.NoError(t, json.Unmarshal(body, &batch))
+	require.Len(t, batch, 3)
+	assert.Nil(t, batch[0].Error)
+	assert.Equal(t, CodeMethodNotFound, batch[1].Error.Code)
+	assert.Equal(t, CodeInvalidRequest, batch[2].Error.Code)
+
+	_, body = f.post(`[]`)
+	r = decode(body)
+	require.NotNil(t, r.Error)
+	assert.Equal(t, CodeInvalidRequest, r.Error.Code)
+
+	status, body = f.post(`[{"jsonrpc":"2.0","method":"chainInfo"}]`)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.Empty(t, body)
+}
+
+func (f *fixture) dial() *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(f.srv.URL, "http"), nil)
+	require.NoError(f.t, err)
+	f.t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func (f *fixture) wsCall(conn *websocket.Conn, out any, method string, params ...any) *Error {
+	require.NoError(f.t, conn.WriteJSON(map[string]any{"jsonrpc": Version, "id": 7, "method": method, "params": params}))
+
+	var resp Response
+	require.NoError(f.t, conn.ReadJSON(&resp))
+	assert.JSONEq(f.t, "7", string(resp.ID))
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if out != nil {
+		require.NoError(f.t, json.Unmarshal(resp.Result, out))
+	}
+	return nil
+}
+
+func (f *fixture) subscribe(conn *websocket.Conn, params ...any) string {
+	var id string
+	require.Nil(f.t, f.wsCall(conn, &id, "subscribe", params...))
+	return id
+}
+
+// next reads the next notification and decodes its result into out.
+func (f *fixture) next(conn *websocket.Conn, out any) string {
+	require.NoError(f.t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var n Notification
+	require.NoError(f.t, conn.ReadJSON(&n))
+	assert.Equal(f.t, NotificationMethod, n.Method)
+	require.NoError(f.t, json.Unmarshal(n.Params.Result, out))
+	return n.Params.Subscription
+}
+
+func TestServer_Subscriptions(t *testing.T) {
+	f := newFixture(t, Config{})
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	other, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+	recipient := other.PublicKey().Address(f.chain.Executor().AddressDeriver())
+
+	heads, pending, watched, finalized := f.dial(), f.dial(), f.dial(), f.dial()
+	headsID := f.subscribe(heads, TopicNewHeads)
+	pendingID := f.subscribe(pending, TopicPendingTransactions)
+	// Upper case hex still matches the canonical form.
+	watchedID := f.subscribe(watched, TopicTransactions, TransactionFilter{Addresses: []string{"0x" + strings.ToUpper(f.wrapAddress(recipient)[2:])}})
+	finalizedID := f.subscribe(finalized, TopicFinalizedBlocks)
+	assert.Equal(t, 4, f.server.Subscriptions())
+	assert.NotEqual(t, headsID, pendingID)
+
+	rpcErr := f.wsCall(heads, nil, "subscribe", "logs")
+	require.NotNil(t, rpcErr)
+	assert.Equal(t, CodeInvalidParams, rpcErr.Code)
+	rpcErr = f.wsCall(heads, nil, "subscribe", TopicNewHeads, TransactionFilter{Addresses: []string{f.wrapAddress(recipient)}})
+	require.NotNil(t, rpcErr)
+	assert.Equal(t, CodeInvalidParams, rpcErr.Code)
+
+	// Plain methods work over the websocket too, subscriptions do not work
+	// over HTTP.
+	var info ChainInfo
+	require.Nil(t, f.wsCall(heads, &info, "chainInfo"))
+	assert.Equal(t, "kangaroo-test", info.ChainID)
+	rpcErr = f.call(nil, "subscribe", TopicNewHeads)
+	require.NotNil(t, rpcErr)
+	assert.Equal(t, CodeMethodNotFound, rpcErr.Code)
+
+	toRecipient, err := wrapper.WrapTransactionToString(f.tx(recipient, 5, 0))
+	require.NoError(t, err)
+	toNobody, err := wrapper.WrapTransactionToString(f.tx(nil, 1, 1))
+	require.NoError(t, err)
+	var sent [2]string
+	require.Nil(t, f.call(&sent[0], "sendRawTransaction", toRecipient))
+	require.Nil(t, f.call(&sent[1], "sendRawTransaction", toNobody))
+
+	for i := range sent {
+		var tx Transaction
+		assert.Equal(t, pendingID, f.next(pending, &tx))
+		assert.Equal(t, sent[i], tx.Hash)
+		assert.True(t, tx.Pending)
+	}
+
+	blk := f.mine()
+	f.server.PublishHead(blk)
+
+	var head Block
+	assert.Equal(t, headsID, f.next(heads, &head))
+	assert.Equal(t, uint64(1), head.Height)
+	assert.Len(t, head.Transactions, 2)
+
+	var included Transaction
+	assert.Equal(t, watchedID, f.next(watched, &included))
+	assert.Equal(t, sent[0], included.Hash)
+	assert.False(t, included.Pending)
+	assert.Equal(t, head.Hash, included.BlockHash)
+
+	id, err := blk.Hash(f.chain.Executor().HashDeriver())
+	require.NoError(t, err)
+	require.NoError(t, f.chain.Finalize(1, id))
+	f.server.PublishFinalized(blk)
+
+	var final Block
+	assert.Equal(t, finalizedID, f.next(finalized, &final))
+	assert.Equal(t, head.Hash, final.Hash)
+	assert.True(t, final.Finalized)
+
+	var ok bool
+	require.Nil(t, f.wsCall(heads, &ok, "unsubscribe", headsID))
+	assert.True(t, ok)
+	require.Nil(t, f.wsCall(heads, &ok, "unsubscribe", headsID))
+	assert.False(t, ok)
+	// Another connection's subscription cannot be cancelled.
+	require.Nil(t, f.wsCall(heads, &ok, "unsubscribe", watchedID))
+	assert.False(t, ok)
+	assert.Equal(t, 3, f.server.Subscriptions())
+
+	require.NoError(t, finalized.Close())
+	assert.Eventually(t, func() bool { return f.server.Subscriptions() == 2 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServer_SlowSubscriber(t *testing.T) {
+	f := newFixture(t, Config{SubscriberBuffer: 4, WriteTimeout: 100 * time.Millisecond})
+
+	slow, fast := f.dial(), f.dial()
+	f.subscribe(slow, TopicPendingTransactions)
+	fastID := f.subscribe(fast, TopicNewHeads)
+
+	// Large transactions fill the socket buffers quickly; the slow client
+	// never reads.
+	tx := kangarootransaction.NewKangarooTransaction(nil, big.NewInt(1), make([]byte, 256<<10), 0)
+	require.NoError(t, tx.Sign(f.user, f.chain.Executor().HashDeriver()))
+
+	start := time.Now()
+	for i := 0; i < 1000 && f.server.Subscriptions() == 2; i++ {
+		f.server.PublishPendingTransaction(tx)
+	}
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Eventually(t, func() bool { return f.server.Subscriptions() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	f.server.PublishHead(f.mine())
+	var head Block
+	assert.Equal(t, fastID, f.next(fast, &head))
+	assert.Equal(t, uint64(1), head.Height)
+}
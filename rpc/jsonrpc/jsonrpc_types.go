@@ -0,0 +1,174 @@
+package jsonrpc
+
+import (
+	"encoding/hex"
+	"github.com/andantan/kangaroo/chain"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/state"
+)
+
+// Every hash, address, key and raw object in the results below is in the
+// wrapped "0x" form produced by the codec/wrapper package, so it can be fed
+// back into the matching Unwrap*FromString. Amounts are decimal strings.
+
+type Transaction struct {
+	Hash   string `json:"hash"`
+	From   string `json:"from"`
+	Signer string `json:"signer"`
+	// To is empty for transactions without a recipient.
+	To    string `json:"to,omitempty"`
+	Value string `json:"value"`
+	Nonce uint64 `json:"nonce"`
+	Data  string `json:"data,omitempty"`
+	Raw   string `json:"raw"`
+
+	Pending     bool   `json:"pending"`
+	BlockHash   string `json:"blockHash,omitempty"`
+	BlockHeight uint64 `json:"blockHeight,omitempty"`
+	Index       int    `json:"index,omitempty"`
+}
+
+type Block struct {
+	Hash      string `json:"hash"`
+	Height    uint64 `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	// PrevBlockHash is empty for the genesis block.
+	PrevBlockHash string `json:"prevBlockHash,omitempty"`
+	BodyHash      string `json:"bodyHash"`
+	StateRoot     string `json:"stateRoot"`
+	Proposer      string `json:"proposer,omitempty"`
+	Nonce         uint64 `json:"nonce,omitempty"`
+	Target        string `json:"target,omitempty"`
+	// Transactions are the hashes of the body transactions, in order.
+	Transactions []string `json:"transactions"`
+	Finalized    bool     `json:"finalized"`
+	// Raw is the whole wrapped block, only set when asked for.
+	Raw string `json:"raw,omitempty"`
+}
+
+type ChainInfo struct {
+	ChainID         string `json:"chainId"`
+	Height          uint64 `json:"height"`
+	HeadHash        string `json:"headHash"`
+	GenesisHash     string `json:"genesisHash"`
+	BaseHeight      uint64 `json:"baseHeight"`
+	FinalizedHeight uint64 `json:"finalizedHeight"`
+	FinalizedHash   string `json:"finalizedHash"`
+	HashSuite       string `json:"hashSuite"`
+	AddressSuite    string `json:"addressSuite"`
+	Pending         int    `json:"pending"`
+}
+
+type Suites struct {
+	Keys      []string `json:"keys"`
+	Hashes    []string `json:"hashes"`
+	Addresses []string `json:"addresses"`
+}
+
+func newTransaction(executor *state.Executor, tx transaction.Transaction) (*Transaction, error) {
+	id, err := tx.Hash(executor.HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+	sender, err := executor.SenderOf(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Transaction{Value: tx.GetValue().String(), Nonce: tx.GetNonce()}
+	if r.Hash, err = wrapper.WrapHashToString(id); err != nil {
+		return nil, err
+	}
+	if r.From, err = wrapper.WrapAddressToString(sender); err != nil {
+		return nil, err
+	}
+	if r.Signer, err = wrapper.WrapPublicKeyToString(tx.GetSigner()); err != nil {
+		return nil, err
+	}
+	if to := tx.GetToAddress(); to != nil {
+		if r.To, err = wrapper.WrapAddressToString(to); err != nil {
+			return nil, err
+		}
+	}
+	if data := tx.GetData(); len(data) != 0 {
+		r.Data = "0x" + hex.EncodeToString(data)
+	}
+	if r.Raw, err = wrapper.WrapTransactionToString(tx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func newIncludedTransaction(executor *state.Executor, tx transaction.Transaction, loc chain.TxLocation) (*Transaction, error) {
+	r, err := newTransaction(executor, tx)
+	if err != nil {
+		return nil, err
+	}
+	if r.BlockHash, err = wrapper.WrapHashToString(loc.BlockID); err != nil {
+		return nil, err
+	}
+	r.BlockHeight = loc.Height
+	r.Index = loc.Index
+	return r, nil
+}
+
+func newBlock(executor *state.Executor, blk block.Block, finalized, raw bool) (*Block, error) {
+	id, err := blk.Hash(executor.HashDeriver())
+	if err != nil {
+		return nil, err
+	}
+	header := blk.GetHeader()
+
+	r := &Block{
+		Height:       header.GetHeight(),
+		Timestamp:    header.GetTimestamp(),
+		Nonce:        header.GetNonce(),
+		Transactions: []string{},
+		Finalized:    finalized,
+	}
+	if r.Hash, err = wrapper.WrapHashToString(id); err != nil {
+		return nil, err
+	}
+	if prev := header.GetPrevBlockID(); prev != nil {
+		if r.PrevBlockHash, err = wrapper.WrapHashToString(prev); err != nil {
+			return nil, err
+		}
+	}
+	if r.BodyHash, err = wrapper.WrapHashToString(header.GetBodyHash()); err != nil {
+		return nil, err
+	}
+	if r.StateRoot, err = wrapper.WrapHashToString(header.GetStateRoot()); err != nil {
+		return nil, err
+	}
+	if proposer := header.GetProposer(); proposer != nil {
+		if r.Proposer, err = wrapper.WrapPublicKeyToString(proposer); err != nil {
+			return nil, err
+		}
+	}
+	if target := header.GetTarget(); target != nil && !target.IsZero() {
+		if r.Target, err = wrapper.WrapHashToString(target); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tx := range blk.GetBody().GetTransactions() {
+		txID, err := tx.Hash(executor.HashDeriver())
+		if err != nil {
+			return nil, err
+		}
+		s, err := wrapper.WrapHashToString(txID)
+		if err != nil {
+			return nil, err
+		}
+		r.Transactions = append(r.Transactions, s)
+	}
+
+	if raw {
+		if r.Raw, err = wrapper.WrapBlockToString(blk); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,107 @@
+package jsonrpc
+
+import (
+	"github.com/gorilla/websocket"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var upgrader = websocket.Upgrader{}
+
+// wsClient is one websocket connection. Everything sent to it, responses
+// and notifications alike, goes through the send queue drained by a single
+// writer, so a slow client only ever fills its own queue.
+type wsClient struct {
+	conn      *websocket.Conn
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+	// subs is guarded by Server.subsLock.
+	subs map[string]*subscription
+}
+
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already replied with an HTTP error.
+		return
+	}
+	conn.SetReadLimit(s.cfg.MaxBodySize)
+
+	client := &wsClient{
+		conn: conn,
+		send: make(chan []byte, s.cfg.SubscriberBuffer),
+		done: make(chan struct{}),
+		subs: make(map[string]*subscription),
+	}
+	go s.writeLoop(client)
+	s.readLoop(client)
+}
+
+func (s *Server) readLoop(client *wsClient) {
+	defer s.drop(client)
+
+	for {
+		_, body, err := client.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if reply := s.handle(body, client); reply != nil && !s.enqueue(client, reply) {
+			return
+		}
+	}
+}
+
+func (s *Server) writeLoop(client *wsClient) {
+	defer s.drop(client)
+
+	for {
+		select {
+		case <-client.done:
+			return
+		case msg := <-client.send:
+			if err := client.conn.SetWriteDeadline(time.Now().Add(s.cfg.WriteTimeout)); err != nil {
+				return
+			}
+			if err := client.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// enqueue queues msg for the client without blocking, dropping the client
+// when its queue is full.
+func (s *Server) enqueue(client *wsClient, msg []byte) bool {
+	select {
+	case <-client.done:
+		return false
+	default:
+	}
+
+	select {
+	case client.send <- msg:
+		return true
+	default:
+		log.Printf("[RPC] dropping slow subscriber %s", client.conn.RemoteAddr())
+		s.drop(client)
+		return false
+	}
+}
+
+// drop closes the connection and cancels all of its subscriptions.
+func (s *Server) drop(client *wsClient) {
+	client.closeOnce.Do(func() {
+		close(client.done)
+		_ = client.conn.Close()
+
+		s.subsLock.Lock()
+		defer s.subsLock.Unlock()
+		for id := range client.subs {
+			delete(s.subs, id)
+		}
+		client.subs = nil
+	})
+}
@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/state"
+)
+
+const (
+	// DefaultChunkLevels makes chunks of 256 accounts.
+	DefaultChunkLevels = 8
+	MaxChunkLevels     = 16
+)
+
+var (
+	ErrInvalidManifest = errors.New("invalid snapshot manifest")
+	ErrInvalidChunk    = errors.New("invalid snapshot chunk")
+)
+
+// Snapshot is the state after one block, split into chunks of consecutive
+// accounts in state root order.
+type Snapshot struct {
+	Manifest *Manifest
+	Chunks   [][]*state.Account
+}
+
+// New takes a snapshot of st, the state after block blockID at height.
+func New(deriver hash.HashDeriver, st *state.StateDB, height uint64, blockID hash.Hash, chunkLevels uint32) (*Snapshot, error) {
+	if chunkLevels > MaxChunkLevels {
+		return nil, fmt.Errorf("chunk levels %d above %d", chunkLevels, MaxChunkLevels)
+	}
+
+	stateRoot, err := st.Root(deriver)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := st.Accounts()
+	size := 1 << chunkLevels
+
+	var chunks [][]*state.Account
+	for start := 0; start < len(accounts); start += size {
+		end := start + size
+		if end > len(accounts) {
+			end = len(accounts)
+		}
+		chunks = append(chunks, accounts[start:end])
+	}
+
+	roots := make([]hash.Hash, len(chunks))
+	for i, chunk := range chunks {
+		if roots[i], err = chunkRoot(deriver, chunk, chunkLevels, len(chunks) == 1); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Snapshot{
+		Manifest: &Manifest{
+			Height:      height,
+			BlockID:     blockID,
+			StateRoot:   stateRoot,
+			ChunkLevels: chunkLevels,
+			ChunkRoots:  roots,
+		},
+		Chunks: chunks,
+	}, nil
+}
+
+// Restore rebuilds the state from verified chunks and checks it against the
+// manifest's state root once more.
+func Restore(deriver hash.HashDeriver, m *Manifest, chunks [][]*state.Account) (*state.StateDB, error) {
+	if len(chunks) != len(m.ChunkRoots) {
+		return nil, fmt.Errorf("%w: %d of %d chunks", ErrInvalidChunk, len(chunks), len(m.ChunkRoots))
+	}
+
+	st := state.NewStateDB()
+	for _, chunk := range chunks {
+		for _, acc := range chunk {
+			if err := st.SetBalance(acc.Address, acc.Balance); err != nil {
+				return nil, err
+			}
+			if err := st.SetNonce(acc.Address, acc.Nonce); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	root, err := st.Root(deriver)
+	if err != nil {
+		return nil, err
+	}
+	if !root.Equal(m.StateRoot) {
+		return nil, fmt.Errorf("%w: restored state root mismatch", ErrInvalidChunk)
+	}
+	return st, nil
+}
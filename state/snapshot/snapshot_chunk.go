@@ -0,0 +1,94 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/hash"
+	kangaroosnapshotpb "github.com/andantan/kangaroo/proto/state/snapshot/pb"
+	"github.com/andantan/kangaroo/state"
+	"google.golang.org/protobuf/proto"
+	"math/big"
+)
+
+func EncodeChunk(accounts []*state.Account) ([]byte, error) {
+	pb := &kangaroosnapshotpb.KangarooSnapshotChunk{
+		Accounts: make([]*kangaroosnapshotpb.KangarooAccount, len(accounts)),
+	}
+
+	for i, acc := range accounts {
+		addr, err := wrapper.WrapAddressCanonical(acc.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap account address: %w", err)
+		}
+		pb.Accounts[i] = &kangaroosnapshotpb.KangarooAccount{
+			Address: addr,
+			Balance: acc.Balance.Bytes(),
+			Nonce:   acc.Nonce,
+		}
+	}
+
+	return proto.Marshal(pb)
+}
+
+func DecodeChunk(data []byte) ([]*state.Account, error) {
+	pb := &kangaroosnapshotpb.KangarooSnapshotChunk{}
+	if err := proto.Unmarshal(data, pb); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChunk, err)
+	}
+
+	accounts := make([]*state.Account, len(pb.Accounts))
+	for i, a := range pb.Accounts {
+		if a == nil {
+			return nil, fmt.Errorf("%w: missing account", ErrInvalidChunk)
+		}
+		addr, err := wrapper.UnwrapAddressCanonical(a.Address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidChunk, err)
+		}
+		accounts[i] = &state.Account{
+			Address: addr,
+			Balance: new(big.Int).SetBytes(a.Balance),
+			Nonce:   a.Nonce,
+		}
+	}
+	return accounts, nil
+}
+
+// chunkRoot is the node of the state merkle tree covering one chunk: the
+// leaves folded exactly levels times, duplicating the last node of odd
+// levels like state.MerkleRoot does. A snapshot of a single chunk is the
+// whole tree.
+func chunkRoot(deriver hash.HashDeriver, accounts []*state.Account, levels uint32, single bool) (hash.Hash, error) {
+	if len(accounts) == 0 {
+		return nil, errors.New("empty chunk")
+	}
+
+	leaves := make([]hash.Hash, len(accounts))
+	for i, acc := range accounts {
+		leaf, err := state.AccountLeaf(deriver, acc)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf
+	}
+
+	if single {
+		return state.MerkleRoot(deriver, leaves), nil
+	}
+
+	level := leaves
+	for l := uint32(0); l < levels; l++ {
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+
+		next := make([]hash.Hash, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			combined := append(level[i].Bytes(), level[i+1].Bytes()...)
+			next = append(next, deriver.Derive(combined))
+		}
+		level = next
+	}
+	return level[0], nil
+}
@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/crypto/hash"
+	kangaroosnapshotpb "github.com/andantan/kangaroo/proto/state/snapshot/pb"
+	"github.com/andantan/kangaroo/state"
+	"google.golang.org/protobuf/proto"
+)
+
+// Manifest describes a snapshot: the block it was taken after and the merkle
+// node of every chunk. The chunk roots fold up to the state root, so a
+// manifest can be checked against a header before any chunk is fetched.
+type Manifest struct {
+	Height      uint64
+	BlockID     hash.Hash
+	StateRoot   hash.Hash
+	ChunkLevels uint32
+	ChunkRoots  []hash.Hash
+}
+
+var _ codec.ProtoCodec = (*Manifest)(nil)
+
+// ChunkSize is the number of accounts in every chunk but the last.
+func (m *Manifest) ChunkSize() int {
+	return 1 << m.ChunkLevels
+}
+
+// Verify checks the manifest against the header of the block it claims to
+// be taken after.
+func (m *Manifest) Verify(deriver hash.HashDeriver, header block.Header) error {
+	if m.ChunkLevels > MaxChunkLevels {
+		return fmt.Errorf("%w: %d chunk levels", ErrInvalidManifest, m.ChunkLevels)
+	}
+
+	if header.GetHeight() != m.Height {
+		return fmt.Errorf("%w: height %d, header at %d", ErrInvalidManifest, m.Height, header.GetHeight())
+	}
+
+	id, err := header.Hash(deriver)
+	if err != nil {
+		return err
+	}
+	if m.BlockID == nil || !id.Equal(m.BlockID) {
+		return fmt.Errorf("%w: block id mismatch", ErrInvalidManifest)
+	}
+
+	if m.StateRoot == nil || !m.StateRoot.Equal(header.GetStateRoot()) {
+		return fmt.Errorf("%w: state root mismatch", ErrInvalidManifest)
+	}
+
+	if !state.MerkleRoot(deriver, m.ChunkRoots).Equal(m.StateRoot) {
+		return fmt.Errorf("%w: chunk roots do not commit to the state root", ErrInvalidManifest)
+	}
+
+	return nil
+}
+
+// VerifyChunk checks chunk index of a verified manifest.
+func (m *Manifest) VerifyChunk(deriver hash.HashDeriver, index int, accounts []*state.Account) error {
+	if index < 0 || index >= len(m.ChunkRoots) {
+		return fmt.Errorf("%w: index %d of %d", ErrInvalidChunk, index, len(m.ChunkRoots))
+	}
+
+	last := index == len(m.ChunkRoots)-1
+	if len(accounts) > m.ChunkSize() || (!last && len(accounts) != m.ChunkSize()) {
+		return fmt.Errorf("%w: %d accounts in chunk %d", ErrInvalidChunk, len(accounts), index)
+	}
+
+	root, err := chunkRoot(deriver, accounts, m.ChunkLevels, len(m.ChunkRoots) == 1)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChunk, err)
+	}
+
+	if !root.Equal(m.ChunkRoots[index]) {
+		return fmt.Errorf("%w: chunk %d does not match its root", ErrInvalidChunk, index)
+	}
+	return nil
+}
+
+func (m *Manifest) ToProto() (proto.Message, error) {
+	blockID, err := wrapper.WrapHashCanonical(m.BlockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap manifest block id: %w", err)
+	}
+
+	stateRoot, err := wrapper.WrapHashCanonical(m.StateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap manifest state root: %w", err)
+	}
+
+	roots := make([][]byte, len(m.ChunkRoots))
+	for i, root := range m.ChunkRoots {
+		if roots[i], err = wrapper.WrapHashCanonical(root); err != nil {
+			return nil, fmt.Errorf("failed to wrap chunk root %d: %w", i, err)
+		}
+	}
+
+	return &kangaroosnapshotpb.KangarooSnapshotManifest{
+		Height:      m.Height,
+		BlockId:     blockID,
+		StateRoot:   stateRoot,
+		ChunkLevels: m.ChunkLevels,
+		ChunkRoots:  roots,
+	}, nil
+}
+
+func (m *Manifest) FromProto(msg proto.Message) error {
+	pb, ok := msg.(*kangaroosnapshotpb.KangarooSnapshotManifest)
+	if !ok {
+		return errors.New("invalid proto message type for Manifest")
+	}
+
+	blockID, err := wrapper.UnwrapHashCanonical(pb.BlockId)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap manifest block id: %w", err)
+	}
+
+	stateRoot, err := wrapper.UnwrapHashCanonical(pb.StateRoot)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap manifest state root: %w", err)
+	}
+
+	roots := make([]hash.Hash, len(pb.ChunkRoots))
+	for i, b := range pb.ChunkRoots {
+		if roots[i], err = wrapper.UnwrapHashCanonical(b); err != nil {
+			return fmt.Errorf("failed to unwrap chunk root %d: %w", i, err)
+		}
+	}
+
+	m.Height = pb.Height
+	m.BlockID = blockID
+	m.StateRoot = stateRoot
+	m.ChunkLevels = pb.ChunkLevels
+	m.ChunkRoots = roots
+	return nil
+}
+
+func (m *Manifest) NewProto() proto.Message {
+	return &kangaroosnapshotpb.KangarooSnapshotManifest{}
+}
@@ -0,0 +1,127 @@
+package snapshot
+
+import (
+	"fmt"
+	"github.com/andantan/kangaroo/codec"
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block/kangarooheader"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/andantan/kangaroo/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func testState(t *testing.T, accounts int) (*state.StateDB, hash.HashDeriver) {
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("keccak256")
+	require.NoError(t, err)
+
+	st := state.NewStateDB()
+	for i := 0; i < accounts; i++ {
+		addr := addressSuite.Deriver().Derive([]byte(fmt.Sprintf("account-%d", i)))
+		require.NoError(t, st.SetBalance(addr, big.NewInt(int64(i*7+1))))
+		require.NoError(t, st.SetNonce(addr, uint64(i%3)))
+	}
+	return st, hashSuite.Deriver()
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		accounts int
+		levels   uint32
+	}{
+		{0, 2}, {1, 2}, {3, 2}, {4, 2}, {5, 2}, {17, 2}, {64, 3}, {300, DefaultChunkLevels}, {1000, 4},
+	} {
+		t.Run(fmt.Sprintf("%d accounts in chunks of %d", tc.accounts, 1<<tc.levels), func(t *testing.T) {
+			st, deriver := testState(t, tc.accounts)
+			root, err := st.Root(deriver)
+			require.NoError(t, err)
+
+			header := kangarooheader.NewKangarooHeader(42, 1, deriver.Derive(nil), deriver.Derive(nil), root, nil)
+			blockID, err := header.Hash(deriver)
+			require.NoError(t, err)
+
+			snap, err := New(deriver, st, 42, blockID, tc.levels)
+			require.NoError(t, err)
+			require.NoError(t, snap.Manifest.Verify(deriver, header))
+			assert.Len(t, snap.Chunks, (tc.accounts+snap.Manifest.ChunkSize()-1)/snap.Manifest.ChunkSize())
+
+			decoded := make([][]*state.Account, len(snap.Chunks))
+			for i, chunk := range snap.Chunks {
+				data, err := EncodeChunk(chunk)
+				require.NoError(t, err)
+				decoded[i], err = DecodeChunk(data)
+				require.NoError(t, err)
+				require.NoError(t, snap.Manifest.VerifyChunk(deriver, i, decoded[i]))
+			}
+
+			restored, err := Restore(deriver, snap.Manifest, decoded)
+			require.NoError(t, err)
+			restoredRoot, err := restored.Root(deriver)
+			require.NoError(t, err)
+			assert.True(t, root.Equal(restoredRoot))
+		})
+	}
+}
+
+func TestSnapshot_Verification(t *testing.T) {
+	st, deriver := testState(t, 20)
+	root, err := st.Root(deriver)
+	require.NoError(t, err)
+
+	header := kangarooheader.NewKangarooHeader(7, 1, deriver.Derive(nil), deriver.Derive(nil), root, nil)
+	blockID, err := header.Hash(deriver)
+	require.NoError(t, err)
+
+	snap, err := New(deriver, st, 7, blockID, 2)
+	require.NoError(t, err)
+
+	t.Run("manifest codec", func(t *testing.T) {
+		b, err := codec.EncodeProto(snap.Manifest)
+		require.NoError(t, err)
+
+		decoded := &Manifest{}
+		require.NoError(t, codec.DecodeProto(b, decoded))
+		assert.NoError(t, decoded.Verify(deriver, header))
+	})
+
+	t.Run("manifest for another header", func(t *testing.T) {
+		other := kangarooheader.NewKangarooHeader(7, 2, deriver.Derive(nil), deriver.Derive(nil), root, nil)
+		assert.ErrorIs(t, snap.Manifest.Verify(deriver, other), ErrInvalidManifest)
+	})
+
+	t.Run("manifest with forged chunk roots", func(t *testing.T) {
+		forged := *snap.Manifest
+		forged.ChunkRoots = append([]hash.Hash(nil), snap.Manifest.ChunkRoots...)
+		forged.ChunkRoots[1] = deriver.Derive([]byte("forged"))
+		assert.ErrorIs(t, forged.Verify(deriver, header), ErrInvalidManifest)
+	})
+
+	t.Run("tampered chunk", func(t *testing.T) {
+		chunk := make([]*state.Account, len(snap.Chunks[2]))
+		for i, acc := range snap.Chunks[2] {
+			chunk[i] = &state.Account{Address: acc.Address, Balance: new(big.Int).Set(acc.Balance), Nonce: acc.Nonce}
+		}
+		chunk[0].Balance.Add(chunk[0].Balance, big.NewInt(1))
+		assert.ErrorIs(t, snap.Manifest.VerifyChunk(deriver, 2, chunk), ErrInvalidChunk)
+	})
+
+	t.Run("chunk at the wrong index", func(t *testing.T) {
+		assert.ErrorIs(t, snap.Manifest.VerifyChunk(deriver, 1, snap.Chunks[2]), ErrInvalidChunk)
+		assert.ErrorIs(t, snap.Manifest.VerifyChunk(deriver, 9, snap.Chunks[2]), ErrInvalidChunk)
+	})
+
+	t.Run("short chunk", func(t *testing.T) {
+		assert.ErrorIs(t, snap.Manifest.VerifyChunk(deriver, 0, snap.Chunks[0][:2]), ErrInvalidChunk)
+	})
+
+	t.Run("missing chunks", func(t *testing.T) {
+		_, err := Restore(deriver, snap.Manifest, snap.Chunks[:3])
+		assert.ErrorIs(t, err, ErrInvalidChunk)
+	})
+}
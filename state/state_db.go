@@ -0,0 +1,270 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/codec/wrapper"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+type Account struct {
+	Address hash.Address
+	Balance *big.Int
+	Nonce   uint64
+}
+
+func (a *Account) copy() *Account {
+	return &Account{
+		Address: a.Address,
+		Balance: new(big.Int).Set(a.Balance),
+		Nonce:   a.Nonce,
+	}
+}
+
+// StateDB is the in-memory account state. Accounts are keyed by their wrapped
+// address, so the same 20 bytes derived by two different address suites are
+// two different accounts.
+type StateDB struct {
+	lock     sync.RWMutex
+	accounts map[string]*Account
+}
+
+func NewStateDB() *StateDB {
+	return &StateDB{
+		accounts: make(map[string]*Account),
+	}
+}
+
+func accountKey(addr hash.Address) (string, error) {
+	if addr == nil {
+		return "", errors.New("address cannot be nil")
+	}
+
+	wrapped, err := wrapper.WrapAddressCanonical(addr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(wrapped), nil
+}
+
+func (s *StateDB) GetBalance(addr hash.Address) *big.Int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	k, err := accountKey(addr)
+	if err != nil {
+		return big.NewInt(0)
+	}
+
+	acc, ok := s.accounts[k]
+	if !ok {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(acc.Balance)
+}
+
+func (s *StateDB) GetNonce(addr hash.Address) uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	k, err := accountKey(addr)
+	if err != nil {
+		return 0
+	}
+
+	acc, ok := s.accounts[k]
+	if !ok {
+		return 0
+	}
+	return acc.Nonce
+}
+
+func (s *StateDB) SetBalance(addr hash.Address, balance *big.Int) error {
+	if balance == nil || balance.Sign() < 0 {
+		return fmt.Errorf("invalid balance: %v", balance)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	acc, err := s.getOrCreate(addr)
+	if err != nil {
+		return err
+	}
+	acc.Balance = new(big.Int).Set(balance)
+	return nil
+}
+
+func (s *StateDB) SetNonce(addr hash.Address, nonce uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	acc, err := s.getOrCreate(addr)
+	if err != nil {
+		return err
+	}
+	acc.Nonce = nonce
+	return nil
+}
+
+func (s *StateDB) AddBalance(addr hash.Address, amount *big.Int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	acc, err := s.getOrCreate(addr)
+	if err != nil {
+		return err
+	}
+	acc.Balance = new(big.Int).Add(acc.Balance, amount)
+	return nil
+}
+
+// SubBalance debits amount from addr. A failed debit leaves the state
+// untouched, so it never creates the account either.
+func (s *StateDB) SubBalance(addr hash.Address, amount *big.Int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	k, err := accountKey(addr)
+	if err != nil {
+		return err
+	}
+
+	balance := big.NewInt(0)
+	if acc, ok := s.accounts[k]; ok {
+		balance = acc.Balance
+	}
+
+	if balance.Cmp(amount) < 0 {
+		return fmt.Errorf("%w: have %s, want %s", ErrInsufficientBalance, balance, amount)
+	}
+
+	acc, err := s.getOrCreate(addr)
+	if err != nil {
+		return err
+	}
+	acc.Balance = new(big.Int).Sub(acc.Balance, amount)
+	return nil
+}
+
+func (s *StateDB) getOrCreate(addr hash.Address) (*Account, error) {
+	k, err := accountKey(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	acc, ok := s.accounts[k]
+	if !ok {
+		acc = &Account{Address: addr, Balance: big.NewInt(0)}
+		s.accounts[k] = acc
+	}
+	return acc, nil
+}
+
+func (s *StateDB) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.accounts)
+}
+
+// Accounts returns a copy of every account ordered by wrapped address, which is
+// the same order the state root commits to.
+func (s *StateDB) Accounts() []*Account {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	keys := s.sortedKeys()
+	accounts := make([]*Account, len(keys))
+	for i, k := range keys {
+		accounts[i] = s.accounts[k].copy()
+	}
+	return accounts
+}
+
+func (s *StateDB) Copy() *StateDB {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	cp := NewStateDB()
+	for k, acc := range s.accounts {
+		cp.accounts[k] = acc.copy()
+	}
+	return cp
+}
+
+// Root commits to every account as an ordered binary merkle tree over the
+// account leaves. An empty state has the hash of no data as its root.
+func (s *StateDB) Root(deriver hash.HashDeriver) (hash.Hash, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	keys := s.sortedKeys()
+	if len(keys) == 0 {
+		return deriver.Derive(nil), nil
+	}
+
+	leaves := make([]hash.Hash, len(keys))
+	for i, k := range keys {
+		leaf, err := AccountLeaf(deriver, s.accounts[k])
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf
+	}
+
+	return MerkleRoot(deriver, leaves), nil
+}
+
+func (s *StateDB) sortedKeys() []string {
+	keys := make([]string, 0, len(s.accounts))
+	for k := range s.accounts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AccountLeaf hashes wrapped address || nonce (8 bytes, big endian) || balance.
+func AccountLeaf(deriver hash.HashDeriver, acc *Account) (hash.Hash, error) {
+	wrappedAddr, err := wrapper.WrapAddressCanonical(acc.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(wrappedAddr)
+	_ = binary.Write(&buf, binary.BigEndian, acc.Nonce)
+	buf.Write(acc.Balance.Bytes())
+
+	return deriver.Derive(buf.Bytes()), nil
+}
+
+// MerkleRoot folds the leaves pairwise in the given order, duplicating the last
+// node of odd levels.
+func MerkleRoot(deriver hash.HashDeriver, leaves []hash.Hash) hash.Hash {
+	if len(leaves) == 0 {
+		return deriver.Derive(nil)
+	}
+
+	level := append([]hash.Hash(nil), leaves...)
+	for len(level) > 1 {
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+
+		next := make([]hash.Hash, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			combined := append(level[i].Bytes(), level[i+1].Bytes()...)
+			next = append(next, deriver.Derive(combined))
+		}
+		level = next
+	}
+
+	return level[0]
+}
@@ -0,0 +1,95 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andantan/kangaroo/core/block"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/crypto/hash"
+)
+
+var (
+	ErrNonceTooLow         = errors.New("nonce too low")
+	ErrNonceTooHigh        = errors.New("nonce too high")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
+// Executor applies transactions to a StateDB. A transaction moves Value from the
+// signer's address to ToAddress (value is burned when ToAddress is nil) and bumps
+// the signer's nonce.
+type Executor struct {
+	hashDeriver    hash.HashDeriver
+	addressDeriver hash.AddressDeriver
+}
+
+func NewExecutor(hashDeriver hash.HashDeriver, addressDeriver hash.AddressDeriver) *Executor {
+	return &Executor{
+		hashDeriver:    hashDeriver,
+		addressDeriver: addressDeriver,
+	}
+}
+
+func (e *Executor) HashDeriver() hash.HashDeriver {
+	return e.hashDeriver
+}
+
+func (e *Executor) AddressDeriver() hash.AddressDeriver {
+	return e.addressDeriver
+}
+
+func (e *Executor) SenderOf(tx transaction.Transaction) (hash.Address, error) {
+	if tx.GetSigner() == nil {
+		return nil, errors.New("transaction is not signed")
+	}
+	return tx.GetSigner().Address(e.addressDeriver), nil
+}
+
+func (e *Executor) ApplyTransaction(st *StateDB, tx transaction.Transaction) error {
+	if err := tx.Verify(e.hashDeriver); err != nil {
+		return err
+	}
+
+	sender, err := e.SenderOf(tx)
+	if err != nil {
+		return err
+	}
+
+	nonce := st.GetNonce(sender)
+	switch {
+	case tx.GetNonce() < nonce:
+		return fmt.Errorf("%w: account=%d, tx=%d", ErrNonceTooLow, nonce, tx.GetNonce())
+	case tx.GetNonce() > nonce:
+		return fmt.Errorf("%w: account=%d, tx=%d", ErrNonceTooHigh, nonce, tx.GetNonce())
+	}
+
+	value := tx.GetValue()
+	if err = st.SubBalance(sender, value); err != nil {
+		return err
+	}
+
+	if to := tx.GetToAddress(); to != nil {
+		if err = st.AddBalance(to, value); err != nil {
+			return err
+		}
+	}
+
+	return st.SetNonce(sender, nonce+1)
+}
+
+// ApplyBody applies every transaction of the body in order and rejects the body
+// if any transaction or piece of evidence is invalid.
+func (e *Executor) ApplyBody(st *StateDB, body block.Body) error {
+	for i, tx := range body.GetTransactions() {
+		if err := e.ApplyTransaction(st, tx); err != nil {
+			return fmt.Errorf("failed to apply tx %d: %w", i, err)
+		}
+	}
+
+	for i, ev := range body.GetEvidence() {
+		if !ev.Verify() {
+			return fmt.Errorf("invalid evidence %d: %s", i, ev)
+		}
+	}
+
+	return nil
+}
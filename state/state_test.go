@@ -0,0 +1,131 @@
+package state
+
+import (
+	_ "github.com/andantan/kangaroo/core/all"
+	"github.com/andantan/kangaroo/core/block/kangaroobody"
+	"github.com/andantan/kangaroo/core/transaction"
+	"github.com/andantan/kangaroo/core/transaction/kangarootransaction"
+	_ "github.com/andantan/kangaroo/crypto/all"
+	"github.com/andantan/kangaroo/crypto/hash"
+	"github.com/andantan/kangaroo/crypto/key"
+	"github.com/andantan/kangaroo/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func setup(t *testing.T) (*Executor, key.PrivateKey, hash.Address) {
+	keySuite, err := registry.GetKeySuite("ecdsa-secp256k1")
+	require.NoError(t, err)
+	hashSuite, err := registry.GetHashSuite("sha256")
+	require.NoError(t, err)
+	addressSuite, err := registry.GetAddressSuite("blake2b256")
+	require.NoError(t, err)
+
+	privKey, err := keySuite.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	executor := NewExecutor(hashSuite.Deriver(), addressSuite.Deriver())
+	return executor, privKey, privKey.PublicKey().Address(addressSuite.Deriver())
+}
+
+func transfer(t *testing.T, e *Executor, from key.PrivateKey, to hash.Address, value int64, nonce uint64) transaction.Transaction {
+	tx := kangarootransaction.NewKangarooTransaction(to, big.NewInt(value), nil, nonce)
+	require.NoError(t, tx.Sign(from, e.HashDeriver()))
+	return tx
+}
+
+func TestStateDB_Root(t *testing.T) {
+	executor, _, sender := setup(t)
+	deriver := executor.HashDeriver()
+	recipient := executor.AddressDeriver().Derive([]byte("recipient"))
+
+	empty := NewStateDB()
+	emptyRoot, err := empty.Root(deriver)
+	require.NoError(t, err)
+	assert.True(t, emptyRoot.IsZero())
+
+	st := NewStateDB()
+	require.NoError(t, st.SetBalance(sender, big.NewInt(100)))
+	require.NoError(t, st.SetBalance(recipient, big.NewInt(5)))
+	root, err := st.Root(deriver)
+	require.NoError(t, err)
+
+	// insertion order must not matter
+	other := NewStateDB()
+	require.NoError(t, other.SetBalance(recipient, big.NewInt(5)))
+	require.NoError(t, other.SetBalance(sender, big.NewInt(100)))
+	otherRoot, err := other.Root(deriver)
+	require.NoError(t, err)
+	assert.True(t, root.Equal(otherRoot))
+
+	// copies are independent
+	cp := st.Copy()
+	require.NoError(t, cp.SetNonce(sender, 1))
+	cpRoot, err := cp.Root(deriver)
+	require.NoError(t, err)
+	assert.False(t, root.Equal(cpRoot))
+	assert.Equal(t, uint64(0), st.GetNonce(sender))
+}
+
+func TestExecutor_ApplyTransaction(t *testing.T) {
+	executor, privKey, sender := setup(t)
+	recipient := executor.AddressDeriver().Derive([]byte("recipient"))
+
+	st := NewStateDB()
+	require.NoError(t, st.SetBalance(sender, big.NewInt(100)))
+
+	t.Run("should transfer value and bump nonce", func(t *testing.T) {
+		require.NoError(t, executor.ApplyTransaction(st, transfer(t, executor, privKey, recipient, 40, 0)))
+		assert.Equal(t, big.NewInt(60), st.GetBalance(sender))
+		assert.Equal(t, big.NewInt(40), st.GetBalance(recipient))
+		assert.Equal(t, uint64(1), st.GetNonce(sender))
+	})
+
+	t.Run("should reject nonce gaps and replays", func(t *testing.T) {
+		err := executor.ApplyTransaction(st, transfer(t, executor, privKey, recipient, 1, 0))
+		assert.ErrorIs(t, err, ErrNonceTooLow)
+		err = executor.ApplyTransaction(st, transfer(t, executor, privKey, recipient, 1, 5))
+		assert.ErrorIs(t, err, ErrNonceTooHigh)
+	})
+
+	t.Run("should reject overspending", func(t *testing.T) {
+		err := executor.ApplyTransaction(st, transfer(t, executor, privKey, recipient, 61, 1))
+		assert.ErrorIs(t, err, ErrInsufficientBalance)
+		assert.Equal(t, uint64(1), st.GetNonce(sender))
+	})
+
+	t.Run("should not create accounts on a failed debit", func(t *testing.T) {
+		unfunded := executor.AddressDeriver().Derive([]byte("unfunded"))
+		before, err := st.Root(executor.HashDeriver())
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, st.SubBalance(unfunded, big.NewInt(1)), ErrInsufficientBalance)
+
+		after, err := st.Root(executor.HashDeriver())
+		require.NoError(t, err)
+		assert.True(t, before.Equal(after))
+		assert.Equal(t, 2, st.Len())
+	})
+
+	t.Run("should burn value without recipient", func(t *testing.T) {
+		require.NoError(t, executor.ApplyTransaction(st, transfer(t, executor, privKey, nil, 10, 1)))
+		assert.Equal(t, big.NewInt(50), st.GetBalance(sender))
+	})
+
+	t.Run("should apply bodies in order", func(t *testing.T) {
+		body := kangaroobody.NewKangarooBody([]transaction.Transaction{
+			transfer(t, executor, privKey, recipient, 1, 2),
+			transfer(t, executor, privKey, recipient, 1, 3),
+		})
+		require.NoError(t, executor.ApplyBody(st, body))
+		assert.Equal(t, uint64(4), st.GetNonce(sender))
+
+		reordered := kangaroobody.NewKangarooBody([]transaction.Transaction{
+			transfer(t, executor, privKey, recipient, 1, 5),
+			transfer(t, executor, privKey, recipient, 1, 4),
+		})
+		assert.Error(t, executor.ApplyBody(st.Copy(), reordered))
+	})
+}
@@ -0,0 +1,48 @@
+package atomicfile
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	require.NoError(t, WriteFile(path, []byte("first")))
+	require.NoError(t, WriteFile(path, []byte("second")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Mode().Perm()&0o077, "only the owner may read the file")
+
+	t.Run("failed write keeps the old content", func(t *testing.T) {
+		failure := errors.New("disk full")
+		err := Write(path, func(w io.Writer) error {
+			_, _ = w.Write([]byte("partial"))
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(data))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary files are cleaned up")
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		assert.Error(t, WriteFile(filepath.Join(dir, "missing", "state.json"), nil))
+	})
+}